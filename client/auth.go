@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Token is a bearer token issued by login or refresh.
+type Token struct {
+	Token string `json:"token"`
+	// ExpiresAt is the expiry as a Unix timestamp in seconds.
+	ExpiresAt int64 `json:"expires_at"`
+	// FailedAttempts summarizes the failed logins since the previous
+	// successful one. Only login sets it, and only when the server records
+	// the login history.
+	FailedAttempts *FailedLogins `json:"failed_attempts_since_last_login,omitempty"`
+}
+
+// FailedLogins is the failed-login summary returned by Auth.Login.
+type FailedLogins struct {
+	Count int64 `json:"count"`
+	// LastIP and LastAt belong to the latest failure; empty when Count is 0.
+	LastIP string     `json:"last_ip,omitempty"`
+	LastAt *time.Time `json:"last_at,omitempty"`
+}
+
+// Expiry returns ExpiresAt as a time.Time.
+func (t *Token) Expiry() time.Time {
+	return time.Unix(t.ExpiresAt, 0)
+}
+
+// RegisterRequest is the input for Auth.Register.
+type RegisterRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	// InviteCode is required when the server's auth.registration_mode is
+	// "invite".
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// RegisteredUser is the user data returned by a successful registration.
+type RegisteredUser struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuthService exposes the /api/v1/auth endpoints.
+type AuthService struct {
+	client *Client
+}
+
+// Login calls POST /api/v1/auth/login. The returned token is not applied to
+// the client automatically; call SetToken to use it.
+func (s *AuthService) Login(ctx context.Context, email, password string) (*Token, error) {
+	body := struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{email, password}
+
+	var tok Token
+	if err := s.client.do(ctx, http.MethodPost, "/auth/login", nil, body, &tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
+
+// Register calls POST /api/v1/auth/register. When the server runs with the
+// opaque registration conflict mode it returns no user data; Register then
+// returns (nil, nil).
+func (s *AuthService) Register(ctx context.Context, req RegisterRequest) (*RegisteredUser, error) {
+	// out stays nil when the envelope's data is null.
+	var out *RegisteredUser
+	if err := s.client.do(ctx, http.MethodPost, "/auth/register", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Refresh calls POST /api/v1/auth/refresh with the client's current token and
+// returns a new one. The old token is revoked by the server; the new token is
+// not applied automatically, call SetToken to use it.
+func (s *AuthService) Refresh(ctx context.Context) (*Token, error) {
+	var tok Token
+	if err := s.client.do(ctx, http.MethodPost, "/auth/refresh", nil, nil, &tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
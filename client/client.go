@@ -0,0 +1,178 @@
+// Package client is a typed Go client for the GoBase REST API.
+//
+// It mirrors the routes under /api/v1: users CRUD and auth login, register,
+// and refresh. Every response is decoded from the standard envelope
+// {code, message, data}; non-2xx responses are returned as *APIError.
+//
+// Usage:
+//
+//	c, err := client.New("http://localhost:8080")
+//	tok, err := c.Auth.Login(ctx, "alice@example.com", "secret1234")
+//	c.SetToken(tok.Token)
+//	page, err := c.Users.List(ctx, client.ListParams{Page: 1, PageSize: 20})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// apiPrefix is the path prefix shared by all versioned API routes.
+const apiPrefix = "/api/v1"
+
+// Client talks to a GoBase server. It is safe for concurrent use; the bearer
+// token may be changed with SetToken while requests are in flight.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+
+	mu    sync.RWMutex
+	token string
+
+	// Users exposes the /api/v1/users endpoints.
+	Users *UsersService
+	// Auth exposes the /api/v1/auth endpoints.
+	Auth *AuthService
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying http.Client (timeouts, transport,
+// proxies). A nil value keeps http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// WithToken sets the initial bearer token sent on every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New creates a Client for the server at baseURL (e.g. "http://localhost:8080").
+// baseURL must be an absolute http or https URL; any path is kept as a prefix.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base url %q must use http or https", baseURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("base url %q has no host", baseURL)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.Users = &UsersService{client: c}
+	c.Auth = &AuthService{client: c}
+	return c, nil
+}
+
+// SetToken replaces the bearer token sent on subsequent requests.
+// An empty token stops sending the Authorization header.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// Token returns the bearer token currently in use.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// envelope is the wire format of every API response.
+type envelope struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Data    json.RawMessage   `json:"data"`
+	Errors  map[string]string `json:"errors"`
+}
+
+// do sends a request to path (relative to the API prefix) and decodes the
+// envelope's data into out. body, when non-nil, is sent as JSON. out may be
+// nil when the caller does not need the data.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	if ctx == nil {
+		return errors.New("client: nil context")
+	}
+
+	u := *c.baseURL
+	u.Path = c.baseURL.Path + apiPrefix + path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("client: encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("client: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("client: %s %s: %w", method, u.Path, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("client: read response body: %w", err)
+	}
+
+	var env envelope
+	decodeErr := json.Unmarshal(raw, &env)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp.StatusCode, env, decodeErr)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("client: decode response envelope: %w", decodeErr)
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("client: decode response data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNew_RejectsInvalidBaseURL(t *testing.T) {
+	for _, raw := range []string{"", "localhost:8080", "ftp://example.com", "http://", "://bad"} {
+		if _, err := New(raw); err == nil {
+			t.Errorf("New(%q) expected error, got nil", raw)
+		}
+	}
+}
+
+func TestNew_KeepsBasePathPrefix(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = io.WriteString(w, `{"code":200,"message":"success","data":null}`)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL + "/gateway/")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := c.Users.Delete(context.Background(), 7); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if gotPath != "/gateway/api/v1/users/7" {
+		t.Errorf("path = %q, want %q", gotPath, "/gateway/api/v1/users/7")
+	}
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	var gotAuth []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, `{"code":200,"message":"success","data":null}`)
+	}, WithToken("tok-1"))
+
+	ctx := context.Background()
+	_ = c.Users.Delete(ctx, 1)
+	c.SetToken("tok-2")
+	_ = c.Users.Delete(ctx, 1)
+	c.SetToken("")
+	_ = c.Users.Delete(ctx, 1)
+
+	want := []string{"Bearer tok-1", "Bearer tok-2", ""}
+	if strings.Join(gotAuth, "|") != strings.Join(want, "|") {
+		t.Errorf("Authorization headers = %q, want %q", gotAuth, want)
+	}
+}
+
+func TestClient_CustomHTTPClient(t *testing.T) {
+	called := false
+	hc := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		called = true
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"code":200,"message":"success","data":{"id":3,"name":"Carol"}}`)),
+			Header:     make(http.Header),
+		}, nil
+	})}
+
+	c, err := New("http://api.invalid", WithHTTPClient(hc))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	u, err := c.Users.Get(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !called {
+		t.Error("custom http.Client was not used")
+	}
+	if u.ID != 3 || u.Name != "Carol" {
+		t.Errorf("unexpected user: %+v", u)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestUsers_List_EncodesParams(t *testing.T) {
+	var gotQuery string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		_, _ = io.WriteString(w, `{"code":200,"message":"success","data":{"items":[{"id":1,"name":"Alice"}],"total_items":1,"current_page":2}}`)
+	})
+
+	page, err := c.Users.List(context.Background(), ListParams{
+		Page:     2,
+		PageSize: 5,
+		Sort:     "name:asc",
+		Filter:   map[string]string{"name__like": "ali"},
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if gotQuery != "name__like=ali&page=2&page_size=5&sort=name%3Aasc" {
+		t.Errorf("query = %q", gotQuery)
+	}
+	if len(page.Items) != 1 || page.Items[0].Name != "Alice" || page.TotalItems != 1 || page.CurrentPage != 2 {
+		t.Errorf("unexpected page: %+v", page)
+	}
+}
+
+func TestClient_APIErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantCode    int
+		wantMessage string
+		wantErrors  map[string]string
+		is          func(error) bool
+	}{
+		{
+			name:        "not found",
+			status:      http.StatusNotFound,
+			body:        `{"code":404,"message":"user not found","data":null}`,
+			wantCode:    404,
+			wantMessage: "user not found",
+			is:          IsNotFound,
+		},
+		{
+			name:        "validation",
+			status:      http.StatusBadRequest,
+			body:        `{"code":400,"message":"validation error","errors":{"email":"Must be a valid email address"}}`,
+			wantCode:    400,
+			wantMessage: "validation error",
+			wantErrors:  map[string]string{"email": "Must be a valid email address"},
+			is:          IsValidation,
+		},
+		{
+			name:        "conflict",
+			status:      http.StatusConflict,
+			body:        `{"code":409,"message":"email already registered","data":null}`,
+			wantCode:    409,
+			wantMessage: "email already registered",
+			is:          IsConflict,
+		},
+		{
+			name:        "non-json body",
+			status:      http.StatusBadGateway,
+			body:        `<html>bad gateway</html>`,
+			wantCode:    502,
+			wantMessage: "Bad Gateway",
+			is:          func(err error) bool { return statusIs(err, http.StatusBadGateway) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			})
+
+			_, err := c.Users.Get(context.Background(), 1)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %T (%v)", err, err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage {
+				t.Errorf("unexpected error: %+v", apiErr)
+			}
+			for field, msg := range tt.wantErrors {
+				if apiErr.Errors[field] != msg {
+					t.Errorf("Errors[%q] = %q, want %q", field, apiErr.Errors[field], msg)
+				}
+			}
+			if !tt.is(err) {
+				t.Errorf("predicate returned false for %v", err)
+			}
+		})
+	}
+}
+
+func TestAPIError_ErrorIncludesFieldsSorted(t *testing.T) {
+	err := &APIError{StatusCode: 400, Code: 400, Message: "validation error", Errors: map[string]string{
+		"name":  "This field is required",
+		"email": "Must be a valid email address",
+	}}
+	want := "api error 400: validation error (email: Must be a valid email address; name: This field is required)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestAuth_Register_OpaqueModeReturnsNil(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"code":200,"message":"registration received, please check your email to continue","data":null}`)
+	})
+
+	user, err := c.Auth.Register(context.Background(), RegisterRequest{Name: "A", Email: "a@example.com", Password: "secret1234"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if user != nil {
+		t.Errorf("expected nil user in opaque mode, got %+v", user)
+	}
+}
+
+func TestClient_RespectsContext(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Users.Get(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
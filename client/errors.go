@@ -0,0 +1,76 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError is returned for every non-2xx response. It carries the HTTP status
+// and the envelope's code and message; validation failures (400) also carry
+// per-field messages keyed by JSON field name.
+type APIError struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Errors     map[string]string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+	if len(e.Errors) == 0 {
+		return msg
+	}
+
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, field+": "+e.Errors[field])
+	}
+	return msg + " (" + strings.Join(parts, "; ") + ")"
+}
+
+// newAPIError builds an APIError from a non-2xx response. If the body was not
+// a valid envelope the HTTP status text is used as the message.
+func newAPIError(status int, env envelope, decodeErr error) *APIError {
+	apiErr := &APIError{StatusCode: status, Code: status, Message: http.StatusText(status)}
+	if decodeErr != nil {
+		return apiErr
+	}
+	if env.Code != 0 {
+		apiErr.Code = env.Code
+	}
+	if env.Message != "" {
+		apiErr.Message = env.Message
+	}
+	apiErr.Errors = env.Errors
+	return apiErr
+}
+
+// statusIs reports whether err is an *APIError with the given HTTP status.
+func statusIs(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsNotFound reports whether err is a 404 APIError.
+func IsNotFound(err error) bool { return statusIs(err, http.StatusNotFound) }
+
+// IsConflict reports whether err is a 409 APIError (e.g. email already taken).
+func IsConflict(err error) bool { return statusIs(err, http.StatusConflict) }
+
+// IsValidation reports whether err is a 400 APIError.
+func IsValidation(err error) bool { return statusIs(err, http.StatusBadRequest) }
+
+// IsUnauthorized reports whether err is a 401 APIError.
+func IsUnauthorized(err error) bool { return statusIs(err, http.StatusUnauthorized) }
+
+// IsForbidden reports whether err is a 403 APIError.
+func IsForbidden(err error) bool { return statusIs(err, http.StatusForbidden) }
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// User is the public representation of a user returned by the API.
+type User struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Bio       string    `json:"bio"`
+}
+
+// CreateUserRequest is the input for Users.Create.
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Bio   string `json:"bio,omitempty"`
+}
+
+// UpdateUserRequest is the input for Users.Update. The API replaces all
+// fields, so every field should be set.
+type UpdateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Bio   string `json:"bio"`
+}
+
+// ListParams controls pagination, sorting, and filtering for list endpoints.
+// Zero values are omitted so the server defaults apply.
+type ListParams struct {
+	Page     int
+	PageSize int
+	// Sort is "field:asc" or "field:desc", e.g. "created_at:desc".
+	Sort string
+	// Filter maps field names to exact values; a "__like" suffix on the key
+	// requests a substring match (e.g. "name__like": "ali").
+	Filter map[string]string
+}
+
+// values encodes the params as query parameters.
+func (p ListParams) values() url.Values {
+	q := url.Values{}
+	for key, value := range p.Filter {
+		q.Set(key, value)
+	}
+	if p.Page > 0 {
+		q.Set("page", strconv.Itoa(p.Page))
+	}
+	if p.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(p.PageSize))
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	return q
+}
+
+// Page is one page of a paginated list response.
+type Page[T any] struct {
+	Items            []T   `json:"items"`
+	Pages            []int `json:"pages"`
+	TotalPages       int   `json:"total_pages"`
+	CurrentPage      int   `json:"current_page"`
+	FirstPage        int   `json:"first_page"`
+	LastPage         int   `json:"last_page"`
+	PreviousPage     *int  `json:"previous_page"`
+	NextPage         *int  `json:"next_page"`
+	ItemsPerPage     int   `json:"items_per_page"`
+	TotalItems       int64 `json:"total_items"`
+	FirstPageInRange int   `json:"first_page_in_range"`
+	LastPageInRange  int   `json:"last_page_in_range"`
+}
+
+// UsersService exposes the /api/v1/users endpoints.
+type UsersService struct {
+	client *Client
+}
+
+// List calls GET /api/v1/users.
+func (s *UsersService) List(ctx context.Context, params ListParams) (*Page[User], error) {
+	var page Page[User]
+	if err := s.client.do(ctx, http.MethodGet, "/users", params.values(), nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Get calls GET /api/v1/users/:id.
+func (s *UsersService) Get(ctx context.Context, id uint) (*User, error) {
+	var user User
+	if err := s.client.do(ctx, http.MethodGet, userPath(id), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Create calls POST /api/v1/users.
+func (s *UsersService) Create(ctx context.Context, req CreateUserRequest) (*User, error) {
+	var user User
+	if err := s.client.do(ctx, http.MethodPost, "/users", nil, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update calls PUT /api/v1/users/:id.
+func (s *UsersService) Update(ctx context.Context, id uint, req UpdateUserRequest) (*User, error) {
+	var user User
+	if err := s.client.do(ctx, http.MethodPut, userPath(id), nil, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Delete calls DELETE /api/v1/users/:id.
+func (s *UsersService) Delete(ctx context.Context, id uint) error {
+	return s.client.do(ctx, http.MethodDelete, userPath(id), nil, nil, nil)
+}
+
+func userPath(id uint) string {
+	return "/users/" + strconv.FormatUint(uint64(id), 10)
+}
@@ -1,8 +1,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/simp-lee/gobase/internal/app"
 	"github.com/simp-lee/gobase/internal/config"
@@ -10,19 +19,188 @@ import (
 
 func main() {
 	configPath := flag.String("config", "configs/config.yaml", "path to configuration file")
+	printRoutes := flag.Bool("print-routes", false, "print the route table and exit without starting the server")
+	routesFormat := flag.String("routes-format", "table", "output format for -print-routes: table or json")
+	printSchema := flag.Bool("print-config-schema", false, "print the configuration schema as JSON and exit")
+	genTypes := flag.Bool("gen-types", false, "print TypeScript definitions of the API's JSON bodies and exit")
+	backup := flag.Bool("backup", false, "write a SQLite snapshot to database.backup_dir, apply the retention and exit")
+	ctl := flag.String("ctl", "", `run a command against the running server's server.control_socket.path and exit, e.g. "cache purge /api/v1/users"`)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [%s|%s|%s] [flags]\n", filepath.Base(os.Args[0]), cmdServe, cmdMigrate, cmdMigrateAndServe)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	command, err := parseCommand(flag.CommandLine)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *printSchema {
+		if err := writeJSON(os.Stdout, config.Schema()); err != nil {
+			log.Fatal("failed to print config schema: ", err)
+		}
+		return
+	}
+
+	if *genTypes {
+		defs, err := app.TypeScriptDefinitions()
+		if err != nil {
+			log.Fatal("failed to generate TypeScript definitions: ", err)
+		}
+		if _, err := os.Stdout.Write(defs); err != nil {
+			log.Fatal("failed to print TypeScript definitions: ", err)
+		}
+		return
+	}
 
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatal("failed to load config: ", err)
 	}
 
+	if *ctl != "" {
+		if cfg.Server.ControlSocket.Path == "" {
+			log.Fatal("-ctl requires server.control_socket.path")
+		}
+		result, err := app.Control(cfg.Server.ControlSocket.Path, *ctl)
+		if err != nil {
+			log.Fatal("control command failed: ", err)
+		}
+		if err := writeJSON(os.Stdout, result); err != nil {
+			log.Fatal("failed to print result: ", err)
+		}
+		return
+	}
+
+	if *backup {
+		b, err := app.RunBackup(cfg)
+		if err != nil {
+			log.Fatal("failed to back up database: ", err)
+		}
+		fmt.Printf("%s\t%d bytes\n", filepath.Join(cfg.Database.BackupDir, b.Name), b.SizeBytes)
+		return
+	}
+
+	if *printRoutes {
+		routes, err := app.ListRoutes(cfg)
+		if err != nil {
+			log.Fatal("failed to list routes: ", err)
+		}
+		if err := writeRoutes(os.Stdout, routes, *routesFormat); err != nil {
+			log.Fatal("failed to print routes: ", err)
+		}
+		return
+	}
+
+	// SIGINT / SIGTERM abort the migrate phase; once serving, App.Run
+	// handles them with a graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, command, cfg, commandSteps{migrate: app.Migrate, serve: serve}); err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+// Commands, given as the first argument after the flags; serve is the
+// default. They let one image run as a migration job or as the server.
+const (
+	cmdServe           = "serve"
+	cmdMigrate         = "migrate"
+	cmdMigrateAndServe = "migrate-and-serve"
+)
+
+// parseCommand returns the command among the arguments fs left after its
+// flags. Flags may also follow the command (server migrate -config
+// prod.yaml); fs parses those too.
+func parseCommand(fs *flag.FlagSet) (string, error) {
+	args := fs.Args()
+	if len(args) == 0 {
+		return cmdServe, nil
+	}
+	command := args[0]
+	switch command {
+	case cmdServe, cmdMigrate, cmdMigrateAndServe:
+	default:
+		return "", fmt.Errorf("unknown command %q: must be %s, %s or %s", command, cmdServe, cmdMigrate, cmdMigrateAndServe)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return "", err
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("unexpected arguments after %s: %q", command, fs.Args())
+	}
+	return command, nil
+}
+
+// commandSteps are what the commands are made of: app.Migrate and serve,
+// replaced in tests.
+type commandSteps struct {
+	migrate func(ctx context.Context, cfg *config.Config) error
+	serve   func(ctx context.Context, cfg *config.Config) error
+}
+
+// run executes command. migrate-and-serve only starts serving (and
+// listening) after the migration succeeded, and not at all once ctx is
+// canceled.
+func run(ctx context.Context, command string, cfg *config.Config, steps commandSteps) error {
+	switch command {
+	case cmdServe:
+		return steps.serve(ctx, cfg)
+	case cmdMigrate, cmdMigrateAndServe:
+		if err := steps.migrate(ctx, cfg); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		log.Print("migration completed")
+		if command == cmdMigrate {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("not serving: %w", err)
+		}
+		return steps.serve(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// serve runs the server until SIGINT or SIGTERM. A taken port is reported
+// before the app is set up.
+func serve(_ context.Context, cfg *config.Config) error {
+	if err := app.CheckListenAddr(cfg); err != nil {
+		return err
+	}
 	a, err := app.New(cfg)
 	if err != nil {
-		log.Fatal("failed to create app: ", err)
+		return fmt.Errorf("failed to create app: %w", err)
 	}
-
 	if err := a.Run(); err != nil {
-		log.Fatal("server error: ", err)
+		return fmt.Errorf("server error: %w", err)
 	}
+	return nil
+}
+
+// writeRoutes prints routes as an aligned METHOD/PATH/HANDLER table or as a
+// JSON array.
+func writeRoutes(w io.Writer, routes []app.RouteInfo, format string) error {
+	switch format {
+	case "json":
+		return writeJSON(w, routes)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER")
+		for _, r := range routes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Method, r.Path, r.Handler)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown -routes-format %q: must be table or json", format)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
 }
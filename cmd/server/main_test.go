@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/app"
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args       []string
+		want       string
+		wantConfig string
+		wantErr    bool
+	}{
+		{args: nil, want: cmdServe, wantConfig: "configs/config.yaml"},
+		{args: []string{"-config", "a.yaml"}, want: cmdServe, wantConfig: "a.yaml"},
+		{args: []string{"-config", "a.yaml", "migrate"}, want: cmdMigrate, wantConfig: "a.yaml"},
+		{args: []string{"migrate-and-serve", "-config", "b.yaml"}, want: cmdMigrateAndServe, wantConfig: "b.yaml"},
+		{args: []string{"serve"}, want: cmdServe, wantConfig: "configs/config.yaml"},
+		{args: []string{"deploy"}, wantErr: true},
+		{args: []string{"migrate", "now"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("server", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		configPath := fs.String("config", "configs/config.yaml", "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%q): %v", tt.args, err)
+		}
+		got, err := parseCommand(fs)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCommand(%q) = %q, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || *configPath != tt.wantConfig {
+			t.Errorf("parseCommand(%q) = %q, %v with -config %q; want %q with %q", tt.args, got, err, *configPath, tt.want, tt.wantConfig)
+		}
+	}
+}
+
+// recordingSteps records the order the steps run in; migrate returns
+// migrateErr.
+func recordingSteps(calls *[]string, migrateErr error) commandSteps {
+	return commandSteps{
+		migrate: func(context.Context, *config.Config) error {
+			*calls = append(*calls, cmdMigrate)
+			return migrateErr
+		},
+		serve: func(context.Context, *config.Config) error {
+			*calls = append(*calls, cmdServe)
+			return nil
+		},
+	}
+}
+
+func TestRun_Commands(t *testing.T) {
+	failed := errors.New("boom")
+	tests := []struct {
+		command    string
+		migrateErr error
+		wantCalls  []string
+		wantErr    bool
+	}{
+		{command: cmdServe, wantCalls: []string{cmdServe}},
+		{command: cmdMigrate, wantCalls: []string{cmdMigrate}},
+		{command: cmdMigrate, migrateErr: failed, wantCalls: []string{cmdMigrate}, wantErr: true},
+		{command: cmdMigrateAndServe, wantCalls: []string{cmdMigrate, cmdServe}},
+		{command: cmdMigrateAndServe, migrateErr: failed, wantCalls: []string{cmdMigrate}, wantErr: true},
+	}
+	for _, tt := range tests {
+		var calls []string
+		err := run(context.Background(), tt.command, testutil.NewTestConfig(), recordingSteps(&calls, tt.migrateErr))
+		if (err != nil) != tt.wantErr || (tt.wantErr && !errors.Is(err, failed)) {
+			t.Errorf("run(%s, migrate error %v) error = %v, want error %v", tt.command, tt.migrateErr, err, tt.wantErr)
+		}
+		if !slices.Equal(calls, tt.wantCalls) {
+			t.Errorf("run(%s, migrate error %v) calls = %v, want %v", tt.command, tt.migrateErr, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestRun_SignalDuringMigrateDoesNotServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls []string
+	steps := recordingSteps(&calls, nil)
+	steps.migrate = func(context.Context, *config.Config) error {
+		calls = append(calls, cmdMigrate)
+		cancel() // the signal arrives while migrating
+		return nil
+	}
+	err := run(ctx, cmdMigrateAndServe, testutil.NewTestConfig(), steps)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("run() error = %v, want context.Canceled", err)
+	}
+	if !slices.Equal(calls, []string{cmdMigrate}) {
+		t.Errorf("calls = %v, want only migrate", calls)
+	}
+
+	calls = nil
+	if err := run(ctx, cmdMigrateAndServe, testutil.NewTestConfig(), commandSteps{migrate: app.Migrate, serve: recordingSteps(&calls, nil).serve}); !errors.Is(err, context.Canceled) {
+		t.Errorf("run(canceled) with app.Migrate error = %v, want context.Canceled", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("calls = %v, want none", calls)
+	}
+}
+
+func TestRun_MigrateAndServe_SchemaReadyBeforeServing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(path))
+
+	served := false
+	steps := commandSteps{
+		migrate: app.Migrate,
+		serve: func(_ context.Context, cfg *config.Config) error {
+			served = true
+			db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+			if err != nil {
+				return err
+			}
+			if sqlDB, err := db.DB(); err == nil {
+				defer sqlDB.Close()
+			}
+			if !db.Migrator().HasTable("users") {
+				t.Error("serve started before the users table was migrated")
+			}
+			return nil
+		},
+	}
+	if err := run(context.Background(), cmdMigrateAndServe, cfg, steps); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !served {
+		t.Error("serve did not run after a successful migration")
+	}
+}
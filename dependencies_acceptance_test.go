@@ -44,6 +44,30 @@ func NewPageResult[T any]() {}`
 	})
 }
 
+func TestTestutil_NotImportedByProductionCode(t *testing.T) {
+	t.Run("happy_repo_production_code_does_not_import_testutil", func(t *testing.T) {
+		matches, err := findProductionTestutilImports(".")
+		if err != nil {
+			t.Fatalf("scan repository: %v", err)
+		}
+		if len(matches) != 0 {
+			t.Fatalf("expected no production imports of internal/testutil, found in: %v", matches)
+		}
+	})
+
+	t.Run("error_fixture_with_testutil_import_is_detected", func(t *testing.T) {
+		fixture := `package app
+
+import "github.com/simp-lee/gobase/internal/testutil/apptest"`
+		if !importsTestutil(fixture) {
+			t.Fatal("expected testutil import to be detected in fixture")
+		}
+		if !importsTestutil(`import "github.com/simp-lee/gobase/internal/contract"`) {
+			t.Fatal("expected contract import to be detected in fixture")
+		}
+	})
+}
+
 func testModulePresence(t *testing.T, module string) {
 	t.Helper()
 
@@ -111,3 +135,45 @@ func hasLegacyNewPageResult(content string) bool {
 	re := regexp.MustCompile(`\bNewPageResult\s*(\[[^\]]+\])?\s*\(`)
 	return re.MatchString(content)
 }
+
+// findProductionTestutilImports returns non-test Go files outside
+// internal/testutil and internal/contract that import either (directly or
+// their subpackages). Both import "testing", which must not reach
+// production binaries.
+func findProductionTestutilImports(root string) ([]string, error) {
+	matches := make([]string, 0)
+	testutilDir := filepath.Join("internal", "testutil")
+	contractDir := filepath.Join("internal", "contract")
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if name == ".git" || name == ".agents-work" || name == "vendor" || filepath.Clean(path) == testutilDir || filepath.Clean(path) == contractDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		b, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		if importsTestutil(string(b)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func importsTestutil(content string) bool {
+	re := regexp.MustCompile(`"github\.com/simp-lee/gobase/internal/(testutil|contract)(/[^"]*)?"`)
+	return re.MatchString(content)
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/auth"
+	"github.com/simp-lee/gobase/internal/module/user"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/tsgen"
+)
+
+// typeScriptHeader starts the generated definitions.
+const typeScriptHeader = `// Code generated by "go run ./cmd/server -gen-types"; DO NOT EDIT.
+//
+// JSON request and response bodies of the API, with the default
+// snake_case field names (server.api.json_naming). Responses are
+// APIResponse<T>; list endpoints return APIResponse<PageResult<T>>.
+
+`
+
+// TypeScriptDefinitions returns the TypeScript declarations of the API's
+// JSON bodies: the response envelopes, the page of a list endpoint and the
+// types each module lists in its APITypes. The output is deterministic;
+// make types writes it to web/types/api.d.ts.
+func TypeScriptDefinitions() ([]byte, error) {
+	types := []tsgen.Type{
+		{Value: pkg.Response{}, Name: "APIResponse", Generic: true},
+		{Value: pkg.ValidationErrorResponse{}},
+		{Value: domain.PageResult[any]{}, Name: "PageResult", Generic: true},
+	}
+	types = append(types, user.APITypes()...)
+	types = append(types, auth.APITypes()...)
+	decls, err := tsgen.Generate(types)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(typeScriptHeader), decls...), nil
+}
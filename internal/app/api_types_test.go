@@ -0,0 +1,51 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTypeScriptDefinitions(t *testing.T) {
+	out, err := TypeScriptDefinitions()
+	if err != nil {
+		t.Fatalf("TypeScriptDefinitions() error = %v", err)
+	}
+	got := string(out)
+	for _, want := range []string{
+		"export interface APIResponse<T = unknown> {\n  code: number;\n  message: string;\n  data: T;\n}\n",
+		"export interface ValidationErrorResponse {\n  code: number;\n  message: string;\n  errors: Record<string, string>;\n}\n",
+		"export interface PageResult<T = unknown> {\n  items: T[];\n",
+		"  previous_page: number | null;\n",
+		"export interface User {\n  id: number;\n  created_at: string;\n  updated_at: string;\n  name: string;\n  email: string;\n  bio: string;\n}\n",
+		"export interface PatchUserRequest {\n  name?: string | null;\n",
+		"export interface BulkCreateUsersRequest {\n  users: CreateUserRequest[];\n}\n",
+		"  modified: boolean;\n",
+		"export interface LoginRequest {\n  email: string;\n  password: string;\n}\n",
+		"  failed_attempts_since_last_login?: FailedLoginSummary | null;\n",
+		"export interface FailedLoginSummary {\n  count: number;\n  last_ip?: string;\n  last_at?: string | null;\n}\n",
+		"export interface InviteResponse {\n  id: number;\n  code: string;\n  email?: string;\n  expires_at: string;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("definitions lack %q", want)
+		}
+	}
+	if strings.Contains(got, "password_hash") || strings.Contains(got, "tenant") {
+		t.Error("definitions contain a field that is not serialized")
+	}
+
+	again, err := TypeScriptDefinitions()
+	if err != nil || !bytes.Equal(again, out) {
+		t.Errorf("second TypeScriptDefinitions() differs (err %v)", err)
+	}
+
+	committed, err := os.ReadFile(filepath.Join("..", "..", "web", "types", "api.d.ts"))
+	if err != nil {
+		t.Fatalf("read web/types/api.d.ts: %v", err)
+	}
+	if !bytes.Equal(committed, out) {
+		t.Error("web/types/api.d.ts is out of date; run make types")
+	}
+}
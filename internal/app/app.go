@@ -9,12 +9,17 @@ import (
 	"io/fs"
 	"log/slog"
 	"math"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -28,36 +33,76 @@ import (
 
 	"github.com/simp-lee/gobase/internal/config"
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
 	"github.com/simp-lee/gobase/internal/module/auth"
+	"github.com/simp-lee/gobase/internal/module/changefeed"
+	"github.com/simp-lee/gobase/internal/module/note"
+	"github.com/simp-lee/gobase/internal/module/notification"
+	"github.com/simp-lee/gobase/internal/module/privacy"
+	"github.com/simp-lee/gobase/internal/module/ratelimit"
+	"github.com/simp-lee/gobase/internal/module/rbacsync"
 	"github.com/simp-lee/gobase/internal/module/user"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 	"github.com/simp-lee/gobase/web"
 )
 
 // App holds the core application dependencies and the HTTP server.
 type App struct {
-	engine      *gin.Engine
+	engine *gin.Engine
+	// handler is engine behind middleware.Head (server.head_requests).
+	handler     http.Handler
 	db          *gorm.DB
 	logger      *logger.Logger
 	cfg         *config.Config
 	cache       cache.CacheInterface
+	idempotency cache.CacheInterface
+	warmer      *cacheWarmer
+	staleCache  *middleware.StaleCache // nil without server.cache.stale_ttl
+	maintenance *maintenance
+	watcher     *fileWatcher             // nil outside debug mode
+	overrides   *ratelimit.OverrideCache // nil without server.rate_limit.overrides
 	jwtService  jwt.Service
 	rbacService rbac.Service
+	userPages   *user.UserPageHandler
+	events      *eventSocket // nil without server.websocket
+	control     *controlAPI  // nil without server.control_socket.path
+	supervisor  *dbSupervisor
+
+	// mu guards server and closed; server is set by Start.
+	mu        sync.Mutex
+	server    httpServer
+	serveErr  chan error
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
 }
 
+// defaultSingleFlightWait bounds how long duplicate GET requests wait for the
+// in-flight one when server.cache.singleflight_wait is unset.
+const defaultSingleFlightWait = 5 * time.Second
+
 type httpServer interface {
-	ListenAndServe() error
+	Serve(ln net.Listener) error
 	Shutdown(ctx context.Context) error
 }
 
-var newHTTPServer = func(addr string, handler http.Handler) httpServer {
+// shutdownTimeout bounds the graceful shutdown of the HTTP server and the
+// control socket in Close.
+const shutdownTimeout = 5 * time.Second
+
+// newHTTPServer builds the server App.Start serves, with the server.http
+// timeouts. Routes under server.http.long_transfer_paths extend the read
+// and write deadlines per connection to server.http.long_transfer_timeout
+// (middleware.LongTransfer).
+var newHTTPServer = func(addr string, handler http.Handler, timeouts config.HTTPConfig) httpServer {
 	return &http.Server{
 		Addr:              addr,
 		Handler:           handler,
-		ReadHeaderTimeout: 10 * time.Second,
-		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      60 * time.Second,
-		IdleTimeout:       120 * time.Second,
+		ReadHeaderTimeout: timeouts.EffectiveReadHeaderTimeout(),
+		ReadTimeout:       timeouts.EffectiveReadTimeout(),
+		WriteTimeout:      timeouts.EffectiveWriteTimeout(),
+		IdleTimeout:       timeouts.EffectiveIdleTimeout(),
 	}
 }
 
@@ -70,14 +115,30 @@ var notifyContext = func(parent context.Context, signals ...os.Signal) (context.
 // It sets up logging, database, domain repositories, services, handlers,
 // middleware, template rendering, and routes.
 func New(cfg *config.Config) (*App, error) {
+	return newApp(context.Background(), cfg, false)
+}
+
+// newApp implements New. With migrate set, as for Migrate, the models of
+// every module are auto-migrated in any server.mode, under ctx, and the
+// startup summary is left out.
+func newApp(ctx context.Context, cfg *config.Config, migrate bool) (*App, error) {
 	if cfg == nil {
 		return nil, errors.New("config is nil")
 	}
 
 	success := false
 
-	// 1. Setup logger.
-	log, err := config.SetupLogger(&cfg.Log)
+	// Token expiry, undo windows and the maintenance job all read this
+	// clock; their tests pass a pkg.FakeClock to the constructors instead.
+	clock := pkg.RealClock
+
+	// 1. Setup logger. Every logger below shares logFailsafe, which writes
+	// to stderr and counts (reported on /health) what the outputs fail on.
+	// The runtime config API can change logLevel, which every logger reads.
+	logFailsafe := config.NewLogFailsafe(os.Stderr)
+	logLevel := &slog.LevelVar{}
+	logLevel.Set(config.ParseLogLevel(cfg.Log.Level))
+	log, err := config.SetupLogger(&cfg.Log, logger.WithMiddleware(logFailsafe.Middleware()), logger.WithLevelVar(logLevel))
 	if err != nil {
 		return nil, fmt.Errorf("setup logger: %w", err)
 	}
@@ -103,6 +164,9 @@ func New(cfg *config.Config) (*App, error) {
 		if success {
 			return
 		}
+		if err := config.CloseReplicas(db); err != nil {
+			slog.Error("database replica close error", slog.Any("error", err))
+		}
 		sqlDB, err := db.DB()
 		if err != nil {
 			return
@@ -112,103 +176,283 @@ func New(cfg *config.Config) (*App, error) {
 		}
 	}()
 
-	// 3. AutoMigrate in debug mode only.
-	if cfg.Server.Mode == "debug" {
-		if err := db.AutoMigrate(&domain.User{}); err != nil {
-			return nil, fmt.Errorf("auto migrate: %w", err)
+	// In debug mode every request counts its queries and warns about
+	// repeated statements (N+1); the counter is not installed otherwise.
+	var queries *queryCounter
+	if cfg.Server.Mode == gin.DebugMode {
+		queries = newQueryCounter(cfg.Database.EffectiveRepeatedQueryThreshold(), log.Logger)
+		if err := db.Use(queries); err != nil {
+			return nil, fmt.Errorf("register query counter: %w", err)
 		}
-		log.Info("auto migration completed")
 	}
 
-	// 4. Manual dependency injection: repository → service → handler.
-	repo := user.NewUserRepository(db)
-	svc := user.NewUserService(repo)
-	handler := user.NewUserHandler(svc)
-	pageHandler := user.NewUserPageHandler(svc)
+	// The supervisor pings the primary and, while it is unreachable, fails
+	// statements fast with a 503 instead of waiting on broken connections.
+	supervisor := newDBSupervisor(pingPrimary(db), cfg.Database.Supervisor.EffectiveInterval(),
+		cfg.Database.Supervisor.EffectiveFailureThreshold(), log.Logger, clock)
+	if err := db.Use(supervisor); err != nil {
+		return nil, fmt.Errorf("register database supervisor: %w", err)
+	}
+
+	var backups *backupStore
+	if cfg.Database.BackupDir != "" {
+		if err := checkBackupDir(cfg.Database.BackupDir); err != nil {
+			return nil, err
+		}
+		backups = newBackupStore(db, cfg.Database, clock)
+	}
+
+	// 3. Manual dependency injection: repository → service → handler.
+	// Notifications are published by other modules; their API is only
+	// registered when auth is enabled, since every notification has an owner.
+	notificationSvc := notification.NewNotificationService(notification.NewNotificationRepository(db))
+	retry := config.EffectiveRetry(cfg.Database.Retry)
+	// The modules' tables referencing users are only known once the
+	// modules are assembled below; deletes read them from here.
+	var userDependents []pkg.UserDependent
+	repo := user.NewUserRepository(db,
+		user.WithRetry(retry.Attempts, retry.MaxBackoff.Std()),
+		user.WithChangePayloadMaxSize(cfg.Database.EffectiveChangePayloadMaxSize()),
+		user.WithDependents(func() []pkg.UserDependent { return userDependents }))
+	// User changes are broadcast on GET /ws when server.websocket is on.
+	var userOpts []user.ServiceOption
+	var broker *pkg.EventBroker
+	if cfg.Server.WebSocket.Enabled {
+		broker = pkg.NewEventBroker(clock)
+		userOpts = append(userOpts, user.WithEvents(broker))
+	}
+	svc := user.NewUserService(repo, userOpts...)
+	handler := user.NewUserHandler(svc, user.WithNotifier(notificationSvc))
+
+	// The response cache store (server.cache) is created ahead of the
+	// middleware so the user list page can keep its users in it too
+	// (server.cache.page_ttl).
+	var cacheInstance cache.CacheInterface
+	if cfg.Server.Cache.Enabled {
+		// already validated by config.Validate()
+		ttl := cfg.Server.Cache.TTL.Std()
+		cacheInstance = newClosableCache(cache.NewCache(cache.Options{
+			DefaultExpiration: ttl,
+			CleanupInterval:   ttl * 2,
+			MaxSize:           cfg.Server.Cache.MaxSize,
+		}))
+	}
+	pageOpts := []user.PageHandlerOption{user.WithUndoWindow(user.DefaultUndoWindow), user.WithClock(clock)}
+	if cacheInstance != nil && cfg.Server.Cache.PageTTL.IsSet() {
+		pageOpts = append(pageOpts, user.WithListCache(cacheInstance, cfg.Server.Cache.PageTTL.Std()))
+	}
+	pageHandler := user.NewUserPageHandler(svc, pageOpts...)
+	defer func() {
+		if !success {
+			pageHandler.Close()
+		}
+	}()
 	userModule := user.NewModule(handler, pageHandler)
-	modules := []Module{userModule}
+	noteModule := note.NewModule(note.NewNoteHandler(note.NewNoteService(note.NewNoteRepository(db))))
+	changeRepo := changefeed.NewChangeRepository(db)
+	changeModule := changefeed.NewModule(changefeed.NewChangeHandler(changefeed.NewChangeService(changeRepo)))
+	modules := []Module{userModule, noteModule, changeModule}
+	notificationModule := notification.NewModule(notification.NewNotificationHandler(notificationSvc))
 
 	var jwtSvc jwt.Service
 	var rbacSvc rbac.Service
+	var policies []middleware.Policy               // installed RBAC policies, checked against the routes
+	var public *publicPaths                        // nil without auth
+	var loginHistory domain.LoginAttemptRepository // nil without auth
 
-	// 5. Create Gin engine with custom middleware (not gin.Default()).
+	// 4. Create Gin engine with custom middleware (not gin.Default()).
 	if err := validateGinMode(cfg.Server.Mode); err != nil {
 		return nil, err
 	}
 	gin.SetMode(cfg.Server.Mode)
 	engine := gin.New()
+	if len(cfg.Server.TrustedProxies) > 0 {
+		if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			return nil, fmt.Errorf("set trusted proxies: %w", err)
+		}
+	}
+	// Trailing slashes are handled by middleware.CanonicalPath inside the
+	// chain instead, so redirects carry CORS headers and use 308 for /api.
+	engine.RedirectTrailingSlash = false
 
 	// Build shared logger options for ginx middlewares.
-	loggerOpts := config.BuildLoggerOpts(&cfg.Log)
+	loggerOpts := append(config.BuildLoggerOpts(&cfg.Log), logger.WithMiddleware(logFailsafe.Middleware()), logger.WithLevelVar(logLevel))
+	// Requests are logged as structured records, or in development as one
+	// pretty line each on the console the logger writes to.
+	accessLog := ginx.Logger(loggerOpts...)
+	if cfg.Log.AccessFormat == config.AccessFormatPretty {
+		accessLog = middleware.PrettyAccessLog(os.Stderr, cfg.Log.Color == nil || *cfg.Log.Color)
+	}
 
 	// Build CORS options from application settings.
 	corsOpts := resolveCORSOptions(cfg.Server.Mode, &cfg.Server.CORS)
 
-	// Parse timeout duration.
-	timeoutDuration := 30 * time.Second
-	serverTimeout := strings.TrimSpace(cfg.Server.Timeout)
-	if serverTimeout != "" {
-		parsed, err := time.ParseDuration(serverTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("parse server.timeout %q: %w", cfg.Server.Timeout, err)
+	// Inbound request IDs are only propagated from server.trusted_proxies.
+	var requestIDSources []netip.Prefix
+	if cfg.Server.RequestID.AcceptIncoming {
+		// already validated by config.Validate()
+		requestIDSources, _ = config.ParseTrustedProxies(cfg.Server.TrustedProxies)
+		if len(requestIDSources) == 0 {
+			log.Warn("server.request_id.accept_incoming has no effect without server.trusted_proxies")
 		}
-		timeoutDuration = parsed
 	}
 
-	// Build ginx middleware chain.
-	chain := ginx.NewChain().
-		WithErrorFormat(func(status int, message string) any {
-			return pkg.Response{Code: status, Message: message}
-		}).
-		Use(ginx.RecoveryWith(htmlRecoveryHandler, loggerOpts...)).
-		Use(ginx.RequestID(
-			ginx.WithIgnoreIncoming(),
-			ginx.WithContextInjector(func(ctx context.Context, requestID string) context.Context {
+	// Hosts outside server.allowed_hosts are rejected before anything is
+	// built from the Host header. Local health probes, which often address
+	// the server by IP, and cache warming requests are exempt.
+	healthPath := ginx.PathIs(cfg.Server.Health.EffectivePath())
+	if len(cfg.Server.AllowedHosts) == 0 {
+		log.Warn("server.allowed_hosts is empty: accepting any Host header")
+	}
+	trustedProxies, _ := config.ParseTrustedProxies(cfg.Server.TrustedProxies) // already validated by config.Validate()
+	allowedHosts := middleware.AllowedHosts(cfg.Server.AllowedHosts,
+		middleware.WithHostExempt(ginx.Or(ginx.And(healthPath, fromLoopback), isCacheWarm)),
+		middleware.WithHostErrorHandler(renderError),
+		middleware.WithForwardedProto(trustedProxies),
+	)
+
+	// Request timeout (server.timeout, default 30s).
+	timeoutDuration := 30 * time.Second
+	if cfg.Server.Timeout.IsSet() {
+		timeoutDuration = cfg.Server.Timeout.Std()
+	}
+
+	// Build ginx middleware chain. The error formatter is bound per request
+	// (instead of Chain.WithErrorFormat, whose closure cannot see the request)
+	// so middleware errors honor problem+json negotiation. Timeout runs the
+	// rest of the chain on a cloned context, so it is bound again after it.
+	chain := ginx.NewChain()
+	if cfg.Server.HeadRequests != config.HeadRequestsReject {
+		chain.Use(middleware.RestoreHead())
+	}
+	chain.Use(errorFormat(cfg.Server.API))
+	// Error rates are counted outside recovery, which turns panics into
+	// 500s.
+	var rates *errorRates
+	if cfg.Server.ErrorRates.Enabled {
+		rates = newErrorRates(cfg.Server.ErrorRates, log.Logger, clock)
+		chain.Use(rates.middleware())
+	}
+	chain.Use(ginx.RecoveryWith(htmlRecoveryHandler, loggerOpts...)).
+		Use(middleware.RequestID(cfg.Server.RequestID.TrustedHeader, requestIDSources,
+			func(ctx context.Context, requestID string) context.Context {
 				return logger.WithContextAttrs(ctx, slog.String("request_id", requestID))
-			}),
+			},
 		)).
-		Use(ginx.Logger(loggerOpts...)).
-		Use(ginx.CORS(corsOpts...)).
-		Use(ginx.Timeout(ginx.WithTimeout(timeoutDuration)))
-
-	// Conditionally add rate limiting for /api routes.
-	// /health lives at root level, so PathHasPrefix("/api") already excludes it.
-	if cfg.Server.RateLimit.Enabled {
-		rps := effectiveRateLimitRPS(cfg.Server.RateLimit.RPS)
-		chain.When(
-			ginx.PathHasPrefix("/api"),
-			ginx.RateLimit(rps, cfg.Server.RateLimit.Burst),
-		)
+		Use(accessLog).
+		Use(allowedHosts)
+	if cfg.Server.CORS.Preset != config.CORSPresetDisabled {
+		chain.Use(ginx.CORS(corsOpts...))
+	}
+	// With tenancy every request but the health check, static assets and
+	// site icons names a tenant, after CORS so preflights need not.
+	if cfg.Tenancy.Enabled {
+		chain.When(ginx.Not(ginx.Or(healthPath, staticPaths(cfg.Server.Static.Mounts), brandingPaths)),
+			middleware.Tenant(tenantResolver(cfg), renderError))
+	}
+	// Long transfers extend the connection deadlines and skip Timeout, which
+	// would buffer the whole response; so does the event stream.
+	untimed := ginx.PathIs(eventSocketPath)
+	if paths := cfg.Server.HTTP.LongTransferPaths; len(paths) > 0 {
+		longTransfer := middleware.LongTransferPaths(paths)
+		chain.When(longTransfer, middleware.LongTransfer(cfg.Server.HTTP.EffectiveLongTransferTimeout()))
+		untimed = ginx.Or(untimed, longTransfer)
+	}
+	chain.When(ginx.Not(untimed), ginx.Timeout(ginx.WithTimeout(timeoutDuration))).
+		Use(errorFormat(cfg.Server.API)).
+		// X-Feature-Override is a QA aid and only honored in debug mode.
+		Use(middleware.FeatureFlags(cfg.Features, cfg.Server.Mode == gin.DebugMode))
+	if queries != nil {
+		chain.Use(queries.middleware())
 	}
 
+	// Periodically purge expired entries from the in-memory stores, which
+	// otherwise only shrink when an expired key is looked up again. Started
+	// once New succeeds; the last pass is reported on /health. Purgers are
+	// added below as their stores are created.
+	upkeep := newMaintenance(cfg.Server.MaintenanceInterval.Std(), log.Logger, clock)
+
+	// The rate limit, response cache switch, log level and maintenance pause
+	// read their settings from liveCfg, which server.admin_runtime_config
+	// exposes for change without a restart.
+	liveCfg := newRuntimeConfig(cfg, logLevel, upkeep, log.Logger, clock)
+
+	// Conditionally add rate limiting for /api routes, except the health
+	// check should server.health.path place it under /api, and the runtime
+	// config API, so a limit can be raised by the client it throttles.
+	// With server.rate_limit.overrides it is added after authentication
+	// instead, so it can tell subjects apart.
+	exempt := healthPath
+	if cfg.Server.AdminRuntimeConfig.Enabled {
+		exempt = ginx.Or(healthPath, ginx.PathIs(runtimeConfigPath))
+	}
+	rateLimited := ginx.And(ginx.PathHasPrefix("/api"), ginx.Not(exempt))
+	if cfg.Server.RateLimit.Enabled && !cfg.Server.RateLimit.Overrides.Enabled {
+		chain.When(rateLimited, liveCfg.rateLimit(nil))
+	}
+	var limitOverrides *ratelimit.OverrideCache // nil without server.rate_limit.overrides
+
+	// Redirect unmatched non-canonical paths (trailing slash, and mixed-case
+	// /api paths when server.api.case_insensitive_paths is set).
+	caseInsensitiveAPI := cfg.Server.API.CaseInsensitivePaths
+	chain.Use(middleware.CanonicalPath(caseInsensitiveAPI))
+
 	// Conditionally add response caching for GET /api/* requests.
 	// Cache is disabled by default (controlled by server.cache config).
 	// ginx.Cache auto-skips requests with Authorization/Cookie headers.
-	var cacheInstance cache.CacheInterface
+	// Identical concurrent misses are collapsed into one handler execution.
+	// With server.cache.stale_ttl, middleware.StaleCache replaces
+	// ginx.Cache and serves expired entries while refreshing them.
+	// Both key requests by cacheKeyBuilder: path, sorted query and
+	// server.cache.vary_headers.
+	// Successful writes purge their resource prefix and, when
+	// server.cache.warm lists targets under it, re-warm them asynchronously.
+	// Writes through the API and through the HTML pages purge the entries
+	// of both (see cachePurges).
+	var warmer *cacheWarmer
+	var staleCache *middleware.StaleCache
+	cacheCounters := &middleware.CacheCounters{}
 	if cfg.Server.Cache.Enabled {
-		// already validated by config.Validate()
-		ttl, _ := time.ParseDuration(cfg.Server.Cache.TTL)
-		cacheInstance = cache.NewCache(cache.Options{
-			DefaultExpiration: ttl,
-			CleanupInterval:   ttl * 2,
-			MaxSize:           cfg.Server.Cache.MaxSize,
-		})
+		ttl := cfg.Server.Cache.TTL.Std()
+		if len(cfg.Server.Cache.Warm) > 0 {
+			warmer = newCacheWarmer(engine, &cfg.Server.Cache, log.Logger)
+		}
+		singleFlightWait := defaultSingleFlightWait
+		if cfg.Server.Cache.SingleFlightWait.IsSet() {
+			singleFlightWait = cfg.Server.Cache.SingleFlightWait.Std()
+		}
+		cacheKey := middleware.WithCacheKey(newCacheKeyBuilder(cfg.Server.Cache.VaryHeaders).key)
+		responseCache := middleware.CountingCache(cacheInstance, cacheCounters, cacheKey)
+		if cfg.Server.Cache.StaleTTL.IsSet() {
+			staleCache = middleware.NewStaleCache(engine, cacheInstance, cacheCounters, ttl, cfg.Server.Cache.StaleTTL.Std(), cacheKey)
+			responseCache = staleCache.Middleware
+		}
+		cacheable := ginx.And(ginx.PathHasPrefix("/api"), ginx.MethodIs("GET"), liveCfg.cacheEnabled)
+		// X-Debug-Whoami responses explain one caller; they are neither
+		// cached nor shared. Requests whoami ignores are cached as usual.
+		if whoami := whoamiUncacheable(cfg.Server.Mode, cfg.Auth.Enabled && cfg.Auth.RBAC.Enabled); whoami != nil {
+			cacheable = ginx.And(cacheable, ginx.Not(whoami))
+		}
+		chain.When(cacheable, responseCache)
+		chain.When(cacheable, middleware.SingleFlight(singleFlightWait, cacheKey))
+		purges := cachePurges{cache: cacheInstance, warmer: warmer}
+		chain.When(
+			ginx.PathHasPrefix("/api"),
+			middleware.CacheInvalidation(cacheInstance, purges.afterAPIWrite),
+		)
 		chain.When(
-			ginx.And(ginx.PathHasPrefix("/api"), ginx.MethodIs("GET")),
-			ginx.Cache(cacheInstance),
+			ginx.Not(ginx.PathHasPrefix("/api")),
+			middleware.CacheInvalidation(cacheInstance, purges.afterPageWrite, middleware.WithInvalidationPrefix(middleware.PageInvalidationPrefix)),
 		)
 	}
 
 	// Conditionally assemble Auth + RBAC when auth is enabled.
 	if cfg.Auth.Enabled {
-		// Parse token expiry duration.
-		tokenExpiry, err := time.ParseDuration(cfg.Auth.TokenExpiry)
-		if err != nil {
-			return nil, fmt.Errorf("parse auth.token_expiry %q: %w", cfg.Auth.TokenExpiry, err)
-		}
+		tokenExpiry := cfg.Auth.TokenExpiry.Std()
 
-		// Create jwt.Service.
-		jwtSvc, err = jwt.New(cfg.Auth.JWTSecret)
+		// Create jwt.Service over the signing key ring (auth.jwt_secret is a
+		// ring of one).
+		jwtSvc, err = pkg.NewJWTKeyRing(jwtKeys(cfg.Auth.SigningKeys()), jwt.WithClock(clock))
 		if err != nil {
 			return nil, fmt.Errorf("create jwt service: %w", err)
 		}
@@ -225,18 +469,14 @@ func New(cfg *config.Config) (*App, error) {
 				return nil, fmt.Errorf("get sql.DB for rbac: %w", err)
 			}
 
-			roleTTL, _ := time.ParseDuration(cfg.Auth.RBAC.Cache.RoleTTL)
-			userRoleTTL, _ := time.ParseDuration(cfg.Auth.RBAC.Cache.UserRoleTTL)
-			permissionTTL, _ := time.ParseDuration(cfg.Auth.RBAC.Cache.PermissionTTL)
-
 			rbacSvc, err = rbac.New(rbac.WithCachedStorage(sqlDB, &rbac.CacheConfig{
-				RoleTTL:      roleTTL,
-				UserRoleTTL:  userRoleTTL,
-				PermTTL:      permissionTTL,
+				RoleTTL:      cfg.Auth.RBAC.Cache.RoleTTL.Std(),
+				UserRoleTTL:  cfg.Auth.RBAC.Cache.UserRoleTTL.Std(),
+				PermTTL:      cfg.Auth.RBAC.Cache.PermissionTTL.Std(),
 				MaxRoles:     cfg.Auth.RBAC.Cache.MaxRoleEntries,
 				MaxUserRoles: cfg.Auth.RBAC.Cache.MaxUserEntries,
 				MaxUserPerms: cfg.Auth.RBAC.Cache.MaxPermissionEntries,
-			}))
+			}, config.RBACTablePrefix(&cfg.Database)))
 			if err != nil {
 				return nil, fmt.Errorf("create rbac service: %w", err)
 			}
@@ -251,45 +491,116 @@ func New(cfg *config.Config) (*App, error) {
 		}
 
 		// Create auth module.
-		authSvc := auth.NewService(jwtSvc, repo, tokenExpiry)
-		authHandler := auth.NewHandler(authSvc)
-		authModule := auth.NewModule(authHandler)
-		modules = append(modules, authModule)
-
-		// Add Auth middleware (exclude public paths).
-		// RBAC permission checks are already wired for users routes below.
-		// Extend the same pattern to additional resource route groups as needed.
-		// See: ginx.RequirePermission, ginx.RequireRolePermission
-		chain.When(
-			ginx.And(
-				ginx.PathHasPrefix("/api"),
-				ginx.Not(ginx.PathIs(cfg.Auth.PublicPaths...)),
-			),
-			ginx.Auth(jwtSvc),
+		conflictMode := auth.ConflictMode(cfg.Auth.RegistrationConflictMode)
+		registrationMode := auth.RegistrationMode(cfg.Auth.RegistrationMode)
+		loginHistory = auth.NewLoginAttemptRepository(db)
+		authOpts := []auth.ServiceOption{
+			auth.WithConflictMode(conflictMode),
+			auth.WithBcryptCost(cfg.Auth.BcryptCost),
+			auth.WithPasswordAlgorithm(auth.PasswordAlgorithm(cfg.Auth.PasswordAlgorithm)),
+			auth.WithLoginHistory(loginHistory),
+		}
+		if registrationMode == auth.RegistrationInvite {
+			authOpts = append(authOpts, auth.WithInvites(auth.NewInviteRepository(db)))
+		}
+		authSvc := auth.NewService(jwtSvc, repo, tokenExpiry, authOpts...)
+		authHandler := auth.NewHandler(authSvc, conflictMode)
+		authModule := auth.NewModule(authHandler, auth.WithRegistrationMode(registrationMode))
+		modules = append(modules, authModule, notificationModule)
+		if cfg.Auth.RBAC.Enabled {
+			modules = append(modules, rbacsync.NewModule(rbacsync.NewSyncHandler(rbacsync.NewService(rbacSvc, repo))))
+		}
+		if cfg.Server.RateLimit.Overrides.Enabled {
+			overrideRepo := ratelimit.NewOverrideRepository(db)
+			limitOverrides = ratelimit.NewOverrideCache(overrideRepo, cfg.Server.RateLimit.Overrides.EffectiveRefreshInterval(), clock, log.Logger)
+			modules = append(modules, ratelimit.NewModule(ratelimit.NewOverrideHandler(ratelimit.NewOverrideService(overrideRepo, limitOverrides))))
+		}
+		// Erasing a user cannot be undone, so the privacy API is only
+		// registered with auth. It covers the stores of the modules above.
+		erasureRepo := privacy.NewErasureRepository(db, collectErasables(modules))
+		modules = append(modules, privacy.NewModule(privacy.NewErasureHandler(privacy.NewErasureService(erasureRepo, primarySigningSecret(cfg.Auth.SigningKeys())))))
+
+		// Add Auth middleware (exclude public paths, and the health check,
+		// which server.health.token guards instead).
+		// Requests with a valid X-API-Key skip the JWT check.
+		public = newPublicPaths(append(slices.Clone(cfg.Auth.PublicPaths), cfg.Server.Health.EffectivePath()), caseInsensitiveAPI)
+		protectedAPI := ginx.And(
+			ginx.PathHasPrefix("/api"),
+			ginx.Not(public.is),
 		)
+		keys := apiKeys(cfg.Auth.APIKeys)
+
+		// RBAC permission checks come from the policy table: appPolicies
+		// plus each Module's Policies(). See policy.go. API keys are
+		// checked against their scopes.
+		var scopedRBAC rbac.Service
+		if cfg.Auth.RBAC.Enabled {
+			scopedRBAC = middleware.WithAPIKeyScopes(rbacSvc, keys)
+			policies, err = collectPolicies(modules)
+			if err != nil {
+				return nil, fmt.Errorf("collect rbac policies: %w", err)
+			}
+		}
+
+		// X-Debug-Whoami explains the requests Auth and the policies
+		// below reject too, so it comes first.
+		if whoami := whoamiMiddleware(cfg.Server.Mode, scopedRBAC, policies); whoami != nil {
+			chain.Use(whoami)
+		}
+
+		if len(keys) > 0 {
+			chain.When(protectedAPI, middleware.APIKeyAuth(keys))
+			log.Info("api key authentication enabled", slog.Int("keys", len(keys)))
+		}
+		chain.When(ginx.And(protectedAPI, ginx.Not(middleware.IsAPIKeyRequest)), ginx.Auth(jwtSvc))
+		// Tokens are bound to the tenant they were issued in (login
+		// records it in the token), so one cannot switch tenants with
+		// the header.
+		if cfg.Tenancy.Enabled {
+			chain.When(protectedAPI, middleware.TenantToken(renderError))
+		}
+
+		// Requests rejected by Auth are not counted against the rate limit
+		// here; anonymous requests to public paths still are, per IP.
+		if limitOverrides != nil {
+			chain.When(rateLimited, liveCfg.rateLimit(limitOverrides.Lookup))
+		}
 
 		if cfg.Auth.RBAC.Enabled {
-			usersPath := ginx.PathHasPrefix("/api/v1/users")
-
-			chain.When(
-				ginx.And(usersPath, ginx.MethodIs(http.MethodGet)),
-				ginx.RequirePermission(rbacSvc, "users", "read"),
-			)
-			chain.When(
-				ginx.And(usersPath, ginx.MethodIs(http.MethodPost)),
-				ginx.RequirePermission(rbacSvc, "users", "create"),
-			)
-			chain.When(
-				ginx.And(usersPath, ginx.MethodIs(http.MethodPut)),
-				ginx.RequirePermission(rbacSvc, "users", "update"),
-			)
-			chain.When(
-				ginx.And(usersPath, ginx.MethodIs(http.MethodDelete)),
-				ginx.RequirePermission(rbacSvc, "users", "delete"),
-			)
+			installPolicies(chain, scopedRBAC, policies)
+			log.Info("rbac policies installed", slog.Any("policies", policyMatrix(policies)))
 		}
+	} else if whoami := whoamiMiddleware(cfg.Server.Mode, nil, nil); whoami != nil {
+		chain.Use(whoami)
+	}
+
+	// Conditionally add Idempotency-Key support for POST/PUT /api/* requests.
+	// Registered after Auth so only authenticated callers can reserve keys.
+	var idempotencyStore cache.CacheInterface
+	if cfg.Server.API.Idempotency.Enabled {
+		ttl := cfg.Server.API.Idempotency.TTL.Std()
+		idempotencyStore = newClosableCache(cache.NewCache(cache.Options{
+			DefaultExpiration: ttl,
+			CleanupInterval:   ttl * 2,
+		}))
+		chain.When(
+			ginx.And(ginx.PathHasPrefix("/api"), ginx.MethodIs(http.MethodPost, http.MethodPut)),
+			middleware.Idempotency(idempotencyStore, ttl),
+		)
 	}
 
+	// Register the stores created above with the maintenance job.
+	if cacheInstance != nil {
+		upkeep.add("response_cache", cachePurger{cache: cacheInstance})
+	}
+	if idempotencyStore != nil {
+		upkeep.add("idempotency", cachePurger{cache: idempotencyStore})
+	}
+	if loginHistory != nil {
+		upkeep.add("login_history", loginHistoryPurger{repo: loginHistory, retention: cfg.Auth.EffectiveLoginHistoryRetention(), clock: clock})
+	}
+	upkeep.add("change_feed", changeFeedPurger{repo: changeRepo, retention: cfg.Database.EffectiveChangeFeedRetention(), clock: clock})
+
 	// OnError fires only when a handler or middleware calls c.Error().
 	// Timeout, RateLimit, and Recovery have self-contained responses and
 	// never call c.Error(), so this handler is not involved in those paths.
@@ -299,6 +610,33 @@ func New(cfg *config.Config) (*App, error) {
 
 	engine.Use(chain.Build())
 
+	// 5. AutoMigrate the models of every module in debug mode (or for
+	// Migrate) only, then compare them with the live schema in every mode.
+	// The notification store backs the publisher other modules use, so its
+	// model counts even when its API (auth disabled) is not registered.
+	models := collectModels(append(slices.Clone(modules), notificationModule))
+	userDependents = collectUserDependents(append(slices.Clone(modules), notificationModule))
+	if migrate || cfg.Server.Mode == "debug" {
+		if err := db.WithContext(ctx).AutoMigrate(models...); err != nil {
+			return nil, fmt.Errorf("auto migrate: %w", err)
+		}
+		log.Info("auto migration completed")
+	}
+	// Tenant-scoped models carry tenant_id whether or not tenancy is on, so
+	// the column is added to existing tables in every mode.
+	if err := migrateTenantColumns(db, models, log.Logger); err != nil {
+		return nil, fmt.Errorf("tenancy migration: %w", err)
+	}
+	if cfg.Database.SchemaCheck != config.SchemaCheckOff {
+		drifts, err := checkSchema(db, models)
+		if err != nil {
+			return nil, fmt.Errorf("schema check: %w", err)
+		}
+		if err := reportSchemaDrift(log.Logger, drifts, cfg.Database.SchemaCheck == config.SchemaCheckStrict); err != nil {
+			return nil, err
+		}
+	}
+
 	// 6. Determine filesystem mode and set up template renderer.
 	var fsys fs.FS
 	if cfg.Server.Mode == "debug" {
@@ -310,15 +648,43 @@ func New(cfg *config.Config) (*App, error) {
 		fsys = web.EmbeddedFS
 	}
 
-	renderer, err := NewTemplateRenderer(fsys, cfg.Server.Mode == "debug")
+	templateOpts := []TemplateOption{
+		WithSlowRenderThreshold(cfg.Server.Templates.SlowRenderThreshold.Std()),
+		WithTemplateLogger(log.Logger),
+		WithBranding(cfg.Server.Branding),
+	}
+	if dir := cfg.Server.Templates.LocaleDir; dir != "" {
+		templateOpts = append(templateOpts, WithLocaleBundles(os.DirFS(dir), cfg.Server.EffectiveLocales()[0]))
+	}
+	var overrides fs.FS
+	if dir := cfg.Server.Templates.OverrideDir; dir != "" {
+		if overrides, err = openOverrideDir(dir); err != nil {
+			return nil, err
+		}
+		templateOpts = append(templateOpts, WithTemplateOverrides(overrides))
+	}
+	if cfg.Server.Templates.Strict && cfg.Server.Mode != gin.ReleaseMode {
+		templateOpts = append(templateOpts, WithStrictData())
+	}
+	renderer, err := NewTemplateRenderer(fsys, cfg.Server.Mode == "debug", templateOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("setup template renderer: %w", err)
 	}
 	engine.HTMLRender = renderer
 
-	// 7. Resolve CSRF secret.
+	// In debug mode, poll the web directory and locale bundles and reload
+	// what changed; nothing is watched in other modes.
+	var watcher *fileWatcher
+	if cfg.Server.Mode == gin.DebugMode {
+		watcher = newFileWatcher(cfg.Server.Templates.EffectiveWatchInterval(), log.Logger, clock)
+		renderer.watchReloads(watcher)
+	}
+
+	// 7. Resolve CSRF secret. config.Load has already taken a missing one
+	// from the dev secrets file outside release mode; configs built in code
+	// get a random one.
 	csrfSecret := cfg.Server.CSRFSecret
-	if isPlaceholderCSRFSecret(csrfSecret) {
+	if config.IsPlaceholderCSRFSecret(csrfSecret) {
 		if cfg.Server.Mode == gin.ReleaseMode {
 			return nil, errors.New("csrf_secret must be a non-placeholder value in release mode")
 		}
@@ -338,38 +704,221 @@ func New(cfg *config.Config) (*App, error) {
 	}
 
 	// 8. Register all routes.
+	var runtimeRoutes *runtimeConfig
+	if cfg.Server.AdminRuntimeConfig.Enabled {
+		runtimeRoutes = liveCfg
+	}
+	stats := &runtimeStats{startedAt: time.Now(), db: db, cacheCounters: cacheCounters}
+	if cacheInstance != nil {
+		stats.cacheEntries = cacheInstance.Count
+	}
+	var siteMap *sitemap
+	if cfg.Server.Sitemap.Enabled {
+		var gated func(string) bool
+		if cfg.Auth.Enabled {
+			gated = authGate(public)
+		}
+		siteMap = newSitemap(cfg.Server.BaseURL, collectSitemapSources(modules), rbacSvc != nil, gated,
+			cfg.Server.Sitemap.EffectiveCacheTTL(), clock)
+	}
+	healthCheckers := []pkg.HealthChecker{supervisor.healthCheck()}
+	if cacheInstance != nil {
+		healthCheckers = append(healthCheckers, cacheHealthCheck(cacheInstance))
+	}
+	if rbacSvc != nil {
+		healthCheckers = append(healthCheckers, rbacHealthCheck(rbacSvc))
+	}
+	var events *eventSocket
+	if broker != nil {
+		events = newEventSocket(broker, jwtSvc, rbacSvc, cfg.Tenancy.Enabled, &cfg.Server, log.Logger)
+	}
+	var errorRateReport func() ErrorRateReport
+	if rates != nil {
+		errorRateReport = rates.report
+	}
 	if err := RegisterRoutes(engine, &RouteDeps{
-		Modules:    modules,
-		DB:         db,
-		Mode:       cfg.Server.Mode,
-		CSRFSecret: csrfSecret,
+		Modules:         modules,
+		DB:              db,
+		Mode:            cfg.Server.Mode,
+		CSRFSecret:      csrfSecret,
+		Locales:         cfg.Server.EffectiveLocales(),
+		RBAC:            rbacSvc,
+		PageIdentity:    pageIdentity(jwtSvc),
+		UnreadCount:     unreadCounter(jwtSvc, notificationSvc),
+		StaticMounts:    cfg.Server.Static.Mounts,
+		WebOverrides:    overrides,
+		LastMaintenance: upkeep.lastRun,
+		LogFailures:     logFailsafe.Failures,
+		Stats:           stats,
+		TemplateStats:   renderer.TemplateStats,
+		ErrorRates:      errorRateReport,
+		Health:          cfg.Server.Health,
+		HealthCheckers:  healthCheckers,
+		Meta:            cfg.Server.Meta,
+		Branding:        cfg.Server.Branding,
+		RuntimeConfig:   runtimeRoutes,
+		Backups:         backups,
+		Sitemap:         siteMap,
+		Events:          events,
 	}); err != nil {
 		return nil, fmt.Errorf("register routes: %w", err)
 	}
 
-	success = true
-	return &App{
+	// With RBAC on, every state-changing API route must have a policy, even
+	// an authentication-only one, so a new endpoint cannot ship unguarded.
+	if cfg.Auth.RBAC.Enabled {
+		if unguarded := unguardedRoutes(engine.Routes(), policies, public); len(unguarded) > 0 {
+			if cfg.Server.Mode == gin.ReleaseMode {
+				return nil, fmt.Errorf("%w: %s", errUnguardedRoutes, strings.Join(unguarded, ", "))
+			}
+			log.Warn("api routes without an authorization policy", slog.Any("routes", unguarded))
+		}
+	}
+
+	var httpHandler http.Handler = engine
+	if cfg.Server.HeadRequests != config.HeadRequestsReject {
+		httpHandler = middleware.Head(engine)
+	}
+
+	a := &App{
 		engine:      engine,
+		handler:     httpHandler,
 		db:          db,
 		logger:      log,
 		cfg:         cfg,
 		cache:       cacheInstance,
+		idempotency: idempotencyStore,
+		warmer:      warmer,
+		staleCache:  staleCache,
+		maintenance: upkeep,
+		watcher:     watcher,
+		overrides:   limitOverrides,
 		jwtService:  jwtSvc,
 		rbacService: rbacSvc,
-	}, nil
+		userPages:   pageHandler,
+		events:      events,
+		supervisor:  supervisor,
+	}
+	if path := cfg.Server.ControlSocket.Path; path != "" {
+		a.control = &controlAPI{
+			users:   svc,
+			cache:   cacheInstance,
+			onPurge: warmer.rewarm,
+			live:    liveCfg,
+			log:     log.Logger,
+			path:    path,
+		}
+	}
+
+	// 9. Warm the response cache now that every route is registered, and
+	// start purging it.
+	warmer.warmAtStartup()
+	upkeep.start()
+	watcher.start()
+	supervisor.start()
+	if limitOverrides != nil {
+		if err := limitOverrides.Refresh(context.Background()); err != nil {
+			log.Warn("load rate limit overrides, retrying every refresh interval", slog.Any("error", err))
+		}
+		limitOverrides.Start()
+	}
+
+	// 10. Emit the startup summary (and banner in debug mode).
+	if !migrate {
+		summarize(cfg, a)
+	}
+
+	success = true
+	return a, nil
 }
 
-func isPlaceholderCSRFSecret(secret string) bool {
-	trimmed := strings.TrimSpace(secret)
-	if trimmed == "" {
-		return true
+// fromLoopback is the ginx.Condition for requests whose direct peer, not a
+// forwarded client IP, is a loopback address.
+func fromLoopback(c *gin.Context) bool {
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	return err == nil && addr.Unmap().IsLoopback()
+}
+
+// routeIs matches requests routed to the given gin route pattern, such as
+// "/api/v1/users/:id", so guards can tell a collection from its items.
+func routeIs(pattern string) ginx.Condition {
+	return func(c *gin.Context) bool {
+		return c.FullPath() == pattern
+	}
+}
+
+// jwtKeys converts the configured signing keys for pkg.NewJWTKeyRing.
+func jwtKeys(keys []config.JWTKeyConfig) []pkg.JWTKey {
+	out := make([]pkg.JWTKey, len(keys))
+	for i, key := range keys {
+		out[i] = pkg.JWTKey{ID: key.KID, Secret: key.Secret, Primary: key.Primary}
 	}
+	return out
+}
 
-	switch strings.ToLower(trimmed) {
-	case "change-me-to-a-random-secret", "change-me-in-env":
-		return true
-	default:
-		return false
+// primarySigningSecret returns the secret of the primary key of keys, which
+// Config.Validate requires exactly one of.
+func primarySigningSecret(keys []config.JWTKeyConfig) string {
+	for _, key := range keys {
+		if key.Primary {
+			return key.Secret
+		}
+	}
+	return ""
+}
+
+// apiKeys converts auth.api_keys for middleware.APIKeyAuth. Config.Validate
+// rejects malformed hashes; a key skipped here never authenticates.
+func apiKeys(keys []config.APIKeyConfig) []middleware.APIKey {
+	out := make([]middleware.APIKey, 0, len(keys))
+	for _, key := range keys {
+		k := middleware.APIKey{Name: key.Name, Scopes: key.Scopes}
+		if len(key.KeyHash) != hex.EncodedLen(len(k.Hash)) {
+			continue
+		}
+		if _, err := hex.Decode(k.Hash[:], []byte(key.KeyHash)); err != nil {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+// unreadCounter adapts svc for the nav badge. It returns nil when auth is
+// disabled, which hides the badge.
+func unreadCounter(jwtSvc jwt.Service, svc domain.NotificationService) middleware.UnreadCounter {
+	if jwtSvc == nil {
+		return nil
+	}
+	return func(ctx context.Context, userID string) (int64, error) {
+		id, err := strconv.ParseUint(userID, 10, 0)
+		if err != nil {
+			return 0, nil
+		}
+		return svc.UnreadCount(ctx, uint(id))
+	}
+}
+
+// pageIdentity resolves page users from a bearer token in the Authorization
+// header, the same credential the API accepts; pages have no session cookie
+// of their own. It returns nil when auth is disabled.
+func pageIdentity(jwtSvc jwt.Service) middleware.IdentityFunc {
+	if jwtSvc == nil {
+		return nil
+	}
+	return func(c *gin.Context) (string, bool) {
+		if userID, ok := requestctx.UserID(c); ok {
+			return userID, true
+		}
+		scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", false
+		}
+		parsed, err := jwtSvc.ValidateAndParse(token)
+		if err != nil {
+			return "", false
+		}
+		return parsed.UserID, true
 	}
 }
 
@@ -397,14 +946,15 @@ func validateReleaseCSRFSecret(secret string) error {
 func resolveCORSOptions(mode string, corsCfg *config.CORSConfig) []ginx.Option[ginx.CORSConfig] {
 	var opts []ginx.Option[ginx.CORSConfig]
 
-	// Handle AllowOrigins.
+	// Handle AllowOrigins. A preset has already been expanded by
+	// config.Validate and is used as is.
 	if len(corsCfg.AllowOrigins) > 0 {
 		opts = append(opts, ginx.WithAllowOrigins(corsCfg.AllowOrigins...))
-	} else if mode != gin.ReleaseMode {
+	} else if mode != gin.ReleaseMode && corsCfg.Preset == "" {
 		// In non-release mode with no configured origins, default to permissive.
 		opts = append(opts, ginx.WithAllowOrigins("*"))
 	}
-	// In release mode with no configured origins, don't add WithAllowOrigins — ginx defaults to deny all.
+	// Otherwise don't add WithAllowOrigins — ginx defaults to deny all.
 
 	// Apply optional CORS settings from config.
 	if len(corsCfg.AllowMethods) > 0 {
@@ -416,18 +966,40 @@ func resolveCORSOptions(mode string, corsCfg *config.CORSConfig) []ginx.Option[g
 	if corsCfg.AllowCredentials {
 		opts = append(opts, ginx.WithAllowCredentials(true))
 	}
-	if corsCfg.MaxAge != "" {
-		d, err := time.ParseDuration(corsCfg.MaxAge)
-		if err != nil {
-			slog.Warn("ignoring invalid cors.max_age", slog.String("value", corsCfg.MaxAge), slog.Any("error", err))
-		} else if d > 0 {
-			opts = append(opts, ginx.WithMaxAge(d))
-		}
+	if corsCfg.MaxAge > 0 {
+		opts = append(opts, ginx.WithMaxAge(corsCfg.MaxAge.Std()))
 	}
 
 	return opts
 }
 
+// errorFormat returns a middleware that installs the ginx error formatter for
+// each request, producing pkg.ErrorBody: the standard envelope, or
+// problem+json when the client asks for it. server.api.problem_json selects
+// problem+json for every /api request, and server.api.json_naming "camel"
+// camelCase response keys (see pkg.ForceCamelJSON).
+func errorFormat(api config.APIConfig) ginx.Middleware {
+	forceCamelJSON := api.JSONNaming == "camel"
+	jsonLimits := pkg.JSONLimits{MaxDepth: api.MaxJSONDepth, MaxTokens: api.MaxJSONTokens, MaxBytes: api.MaxJSONBytes}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			pkg.SetJSONLimits(c, jsonLimits)
+			if strings.HasPrefix(c.Request.URL.Path, "/api") {
+				if api.ProblemJSON {
+					pkg.ForceProblemJSON(c)
+				}
+				if forceCamelJSON {
+					pkg.ForceCamelJSON(c)
+				}
+			}
+			ginx.SetErrorFormatter(c, func(status int, message string) any {
+				return pkg.ErrorBody(c, status, message)
+			})
+			next(c)
+		}
+	}
+}
+
 // htmlRecoveryHandler is the custom panic handler for ginx.RecoveryWith.
 // It renders an HTML error page for browser requests and a JSON response for API clients.
 func htmlRecoveryHandler(c *gin.Context, err any) {
@@ -462,39 +1034,19 @@ func resolveDebugWebFS() (fs.FS, error) {
 	return nil, errors.New("debug web directory not found")
 }
 
-// Run starts the HTTP server and blocks until a shutdown signal is received.
-// It performs graceful shutdown with a 5-second timeout and closes the database
-// connection (M2).
+// Run starts the HTTP server and blocks until a shutdown signal is received
+// or the server fails, then closes the App: the server shuts down
+// gracefully with a 5-second timeout before the database connection and
+// the other resources are released (M2).
 func (a *App) Run() error {
-	if a == nil {
-		return errors.New("app is nil")
-	}
-	if a.cfg == nil {
-		return errors.New("app config is nil")
-	}
-	if a.engine == nil {
-		return errors.New("app engine is nil")
-	}
-
-	addr := fmt.Sprintf("%s:%d", a.cfg.Server.Host, a.cfg.Server.Port)
-	srv := newHTTPServer(addr, a.engine)
-
 	// Listen for SIGINT / SIGTERM.
 	ctx, stop := notifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Start HTTP server in a goroutine.
-	errCh := make(chan error, 1)
-	go func() {
-		if a.logger != nil {
-			a.logger.Info("server started", slog.String("addr", addr))
-		} else {
-			slog.Info("server started", slog.String("addr", addr))
-		}
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			errCh <- err
-		}
-	}()
+	if _, err := a.Start(ctx); err != nil {
+		_ = a.Close()
+		return err
+	}
 
 	var runErr error
 
@@ -506,31 +1058,192 @@ func (a *App) Run() error {
 		} else {
 			slog.Info("shutdown signal received")
 		}
-	case err := <-errCh:
+	case err := <-a.serveErr:
 		runErr = fmt.Errorf("server error: %w", err)
 	}
 
-	if runErr == nil {
-		// Graceful shutdown with 5-second deadline.
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	// Errors are logged by Close; Run reports only server errors.
+	_ = a.Close()
 
-		if err := srv.Shutdown(shutdownCtx); err != nil {
-			if a.logger != nil {
-				a.logger.Error("server shutdown error", slog.Any("error", err))
-			} else {
-				slog.Error("server shutdown error", slog.Any("error", err))
-			}
+	return runErr
+}
+
+// Start listens on server.host:server.port and serves the application in
+// the background, returning the address it is bound to; port 0 picks a
+// free one. ctx bounds only the listen call. The control socket, when
+// configured, is started first. Close shuts the server down, so programs
+// and tests embedding the App drive its lifecycle with New, Start and
+// Close, without the signal handling of Run. An App starts at most once.
+func (a *App) Start(ctx context.Context) (string, error) {
+	if a == nil {
+		return "", errors.New("app is nil")
+	}
+	if a.cfg == nil {
+		return "", errors.New("app config is nil")
+	}
+	if a.engine == nil {
+		return "", errors.New("app engine is nil")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.closed:
+		return "", errors.New("app is closed")
+	case a.server != nil:
+		return "", errors.New("app is already started")
+	}
+
+	// The control socket is local-only: it is served on the unix socket
+	// alone, never next to the HTTP server on TCP.
+	if a.control != nil {
+		if err := a.control.listen(); err != nil {
+			return "", fmt.Errorf("control socket: %w", err)
+		}
+	}
+
+	addr := net.JoinHostPort(a.cfg.Server.Host, strconv.Itoa(a.cfg.Server.Port))
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		controlCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		a.control.shutdown(controlCtx)
+		cancel()
+		return "", listenError(&a.cfg.Server, addr, err)
+	}
+	bound := ln.Addr().String()
+
+	srv := newHTTPServer(bound, a.Handler(), a.cfg.Server.HTTP)
+	a.server = srv
+	a.serveErr = make(chan error, 1)
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.serveErr <- err
+		}
+	}()
+
+	if a.logger != nil {
+		a.logger.Info("server started", slog.String("addr", bound))
+	} else {
+		slog.Info("server started", slog.String("addr", bound))
+	}
+	return bound, nil
+}
+
+// Handler returns the HTTP handler serving all application routes.
+func (a *App) Handler() http.Handler {
+	if a.handler != nil {
+		return a.handler
+	}
+	return a.engine
+}
+
+// DB returns the application's database handle.
+func (a *App) DB() *gorm.DB {
+	return a.db
+}
+
+// JWTService returns the JWT service, or nil when auth is disabled.
+func (a *App) JWTService() jwt.Service {
+	return a.jwtService
+}
+
+// Close shuts down the HTTP server and the control socket when Start has
+// run, then releases the resources owned by the App: WebSocket connections,
+// deferred user deletes (carried out now), rate limiter stores, caches, the JWT and RBAC services,
+// the database connection, and the logger. Run calls it on the way out;
+// programs and tests that never call Run call it directly, whether or not
+// they called Start. Only the first call does any work; later calls return
+// its result. Errors are logged and returned joined.
+func (a *App) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.closeOnce.Do(func() { a.closeErr = a.close() })
+	return a.closeErr
+}
+
+// stopServer shuts down the server Start started, if any, and the control
+// socket, and keeps Start from starting one afterwards. It returns once
+// the in-flight requests have finished, or at the shutdown deadline, and
+// logs how long the drain took.
+func (a *App) stopServer() {
+	a.mu.Lock()
+	srv := a.server
+	a.closed = true
+	a.mu.Unlock()
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	drainStart := time.Now()
+	err := srv.Shutdown(ctx)
+	drainAttrs := []any{
+		slog.Duration("drain", time.Since(drainStart)),
+		slog.Bool("deadline_exceeded", errors.Is(err, context.DeadlineExceeded)),
+	}
+	if err != nil {
+		if a.logger != nil {
+			a.logger.Error("server shutdown error", slog.Any("error", err))
+		} else {
+			slog.Error("server shutdown error", slog.Any("error", err))
 		}
 	}
 
+	// Stop the control socket before close releases what its commands use.
+	controlCtx, cancelControl := context.WithTimeout(context.Background(), shutdownTimeout)
+	a.control.shutdown(controlCtx)
+	cancelControl()
+
+	if a.logger != nil {
+		a.logger.Info("server stopped", drainAttrs...)
+	} else {
+		slog.Info("server stopped", drainAttrs...)
+	}
+}
+
+func (a *App) close() error {
+	// Release nothing the handlers use until the server has drained: the
+	// requests still running past the deadline find the caches closed
+	// (closableCache), not gone.
+	a.stopServer()
+
+	var errs []error
+
+	// End the WebSocket connections, which http.Server.Shutdown does not
+	// track once they are hijacked.
+	a.events.close()
+
+	// Wait for cache warming and stale entry refreshes before the cache and
+	// database go away.
+	a.warmer.close()
+	a.staleCache.Close()
+
+	// Stop purging before the stores it sweeps are closed.
+	a.maintenance.close()
+	a.supervisor.close()
+	a.watcher.close()
+	if a.overrides != nil {
+		a.overrides.Close()
+	}
+
+	// Carry out deferred user deletes while the database is still open.
+	if a.userPages != nil {
+		a.userPages.Close()
+	}
+
 	// Clean up rate limiter stores.
 	ginx.CleanupRateLimiters()
 
-	// Clean up cache instance.
+	// Clean up cache instances.
 	if a.cache != nil {
 		a.cache.Close()
 	}
+	if a.idempotency != nil {
+		a.idempotency.Close()
+	}
 
 	// Close JWT service (stops background cleanup goroutine).
 	if a.jwtService != nil {
@@ -540,6 +1253,7 @@ func (a *App) Run() error {
 	// Close RBAC service.
 	if a.rbacService != nil {
 		if err := a.rbacService.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close rbac service: %w", err))
 			if a.logger != nil {
 				a.logger.Error("rbac service close error", slog.Any("error", err))
 			} else {
@@ -548,10 +1262,19 @@ func (a *App) Run() error {
 		}
 	}
 
-	// ★ M2: Close database connection.
+	// ★ M2: Close database connections, read replicas first.
 	if a.db != nil {
+		if err := config.CloseReplicas(a.db); err != nil {
+			errs = append(errs, err)
+			if a.logger != nil {
+				a.logger.Error("database replica close error", slog.Any("error", err))
+			} else {
+				slog.Error("database replica close error", slog.Any("error", err))
+			}
+		}
 		if sqlDB, err := a.db.DB(); err == nil {
 			if err := sqlDB.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close database: %w", err))
 				if a.logger != nil {
 					a.logger.Error("database close error", slog.Any("error", err))
 				} else {
@@ -568,17 +1291,11 @@ func (a *App) Run() error {
 	}
 
 	if a.logger != nil {
-		a.logger.Info("server stopped")
 		if err := a.logger.Close(); err != nil {
 			slog.Error("logger close error", slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("close logger: %w", err))
 		}
-	} else {
-		slog.Info("server stopped")
-	}
-
-	if runErr != nil {
-		return runErr
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
@@ -2,12 +2,16 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -20,47 +24,13 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/module/user"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
 )
 
-type fakeHTTPServer struct {
-	listenErr      error
-	listenStarted  chan struct{}
-	shutdownCalled bool
-	stopCh         chan struct{}
-	mu             sync.Mutex
-}
-
-func (f *fakeHTTPServer) ListenAndServe() error {
-	if f.listenStarted != nil {
-		close(f.listenStarted)
-	}
-	if f.listenErr != nil {
-		return f.listenErr
-	}
-	if f.stopCh != nil {
-		<-f.stopCh
-		return http.ErrServerClosed
-	}
-	return http.ErrServerClosed
-}
-
-func (f *fakeHTTPServer) Shutdown(context.Context) error {
-	f.mu.Lock()
-	f.shutdownCalled = true
-	f.mu.Unlock()
-	if f.stopCh != nil {
-		close(f.stopCh)
-	}
-	return nil
-}
-
-func (f *fakeHTTPServer) wasShutdownCalled() bool {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f.shutdownCalled
-}
-
 func TestResolveCORSOptions(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -84,6 +54,12 @@ func TestResolveCORSOptions(t *testing.T) {
 			corsCfg:     &config.CORSConfig{},
 			wantOrigins: nil,
 		},
+		{
+			name:        "expanded preset without origins denies cross-origin in debug mode",
+			mode:        gin.DebugMode,
+			corsCfg:     &config.CORSConfig{Preset: config.CORSPresetSameOrigin},
+			wantOrigins: nil,
+		},
 		{
 			name: "release mode uses explicit allowlist",
 			mode: gin.ReleaseMode,
@@ -118,7 +94,7 @@ func TestResolveCORSOptions(t *testing.T) {
 			mode: gin.ReleaseMode,
 			corsCfg: &config.CORSConfig{
 				AllowOrigins: []string{"https://example.com"},
-				MaxAge:       "12h",
+				MaxAge:       config.Duration(12 * time.Hour),
 			},
 			wantOrigins: []string{"https://example.com"},
 			wantMaxAge:  12 * time.Hour,
@@ -176,6 +152,44 @@ func TestResolveCORSOptions(t *testing.T) {
 	}
 }
 
+func TestNew_CORSSameOriginPreset(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithMode(gin.DebugMode), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.BaseURL = "https://example.com"
+		c.Server.CORS.Preset = config.CORSPresetSameOrigin
+		c.Server.CORS.AllowOrigins = []string{"*"} // replaced by the preset
+	})
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	for _, tt := range []struct {
+		origin string
+		want   string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"https://evil.example", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Origin", tt.origin)
+		w := testutil.Serve(a.engine, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("Origin %s: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.want)
+		}
+	}
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/api/v1/users", nil)
+	preflight.Header.Set("Origin", "https://evil.example")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	if w := testutil.Serve(a.engine, preflight); w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("foreign preflight: status %d, Access-Control-Allow-Origin %q; want 403 without it", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
 func TestValidateGinMode(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -284,22 +298,9 @@ func TestNew_CSRFSecretValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &config.Config{
-				Server: config.ServerConfig{
-					Host:       "127.0.0.1",
-					Port:       8080,
-					Mode:       tt.mode,
-					CSRFSecret: tt.csrfSecret,
-				},
-				Database: config.DatabaseConfig{
-					Driver: "sqlite",
-					SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-				},
-				Log: config.LogConfig{
-					Level:  "info",
-					Format: "text",
-				},
-			}
+			cfg := testutil.NewTestConfig(testutil.WithMode(tt.mode), func(c *config.Config) {
+				c.Server.CSRFSecret = tt.csrfSecret
+			})
 
 			app, err := New(cfg)
 			if (err != nil) != tt.wantErr {
@@ -323,15 +324,7 @@ func TestNew_CSRFSecretValidation(t *testing.T) {
 				t.Fatal("New() app = nil, want non-nil")
 			}
 
-			if app.db != nil {
-				sqlDB, dbErr := app.db.DB()
-				if dbErr == nil {
-					_ = sqlDB.Close()
-				}
-			}
-			if app.logger != nil {
-				_ = app.logger.Close()
-			}
+			cleanupTestApp(t, app)
 		})
 	}
 }
@@ -358,24 +351,13 @@ func TestEffectiveRateLimitRPS(t *testing.T) {
 	}
 }
 
-func TestNew_ServerTimeoutWhitespace_TreatedAsUnset(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host:       "127.0.0.1",
-			Port:       8080,
-			Mode:       gin.TestMode,
-			CSRFSecret: "Abcd1234!Abcd1234!Abcd1234!Abcd1234!",
-			Timeout:    "   ",
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-	}
+// Whitespace-only input decodes to an unset Duration at load time (see
+// config.TestLoad_OptionalDurationWhitespace_NormalizedAsUnset); New must
+// fall back to the default timeout.
+func TestNew_ServerTimeoutUnset_UsesDefault(t *testing.T) {
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+	})
 
 	app, err := New(cfg)
 	if err != nil {
@@ -385,35 +367,14 @@ func TestNew_ServerTimeoutWhitespace_TreatedAsUnset(t *testing.T) {
 		t.Fatal("New() app = nil, want non-nil")
 	}
 
-	if app.db != nil {
-		sqlDB, dbErr := app.db.DB()
-		if dbErr == nil {
-			_ = sqlDB.Close()
-		}
-	}
-	if app.logger != nil {
-		_ = app.logger.Close()
-	}
+	cleanupTestApp(t, app)
 }
 
 func TestMiddlewareErrorFormat_Timeout_ReturnsPkgResponse(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host:       "127.0.0.1",
-			Port:       8080,
-			Mode:       gin.TestMode,
-			CSRFSecret: "Abcd1234!Abcd1234!Abcd1234!Abcd1234!",
-			Timeout:    "5ms",
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-	}
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+		c.Server.Timeout = config.Duration(5 * time.Millisecond)
+	})
 
 	app, err := New(cfg)
 	if err != nil {
@@ -493,27 +454,10 @@ func TestMiddlewareErrorFormat_Timeout_ReturnsPkgResponse(t *testing.T) {
 }
 
 func TestMiddlewareErrorFormat_RateLimit_ReturnsPkgResponse(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host:       "127.0.0.1",
-			Port:       8080,
-			Mode:       gin.TestMode,
-			CSRFSecret: "Abcd1234!Abcd1234!Abcd1234!Abcd1234!",
-			RateLimit: config.RateLimitConfig{
-				Enabled: true,
-				RPS:     1,
-				Burst:   1,
-			},
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-	}
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+		c.Server.RateLimit = config.RateLimitConfig{Enabled: true, RPS: 1, Burst: 1}
+	})
 
 	app, err := New(cfg)
 	if err != nil {
@@ -577,6 +521,303 @@ func TestMiddlewareErrorFormat_RateLimit_ReturnsPkgResponse(t *testing.T) {
 	}
 }
 
+// assertProblem checks that w is an RFC 7807 problem+json response with the
+// given status and instance, and returns the decoded body.
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, instance string) pkg.Problem {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body = %s", w.Code, status, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != pkg.ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, pkg.ProblemContentType)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("json decode error: %v", err)
+	}
+	for _, member := range []string{"type", "title", "status", "instance"} {
+		if _, ok := raw[member]; !ok {
+			t.Errorf("problem missing member %q: %s", member, w.Body.String())
+		}
+	}
+	var p pkg.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("json decode error: %v", err)
+	}
+	if p.Type != "about:blank" || p.Status != status || p.Title != http.StatusText(status) || p.Instance != instance {
+		t.Errorf("problem = %+v, want status %d at %s", p, status, instance)
+	}
+	return p
+}
+
+func TestProblemJSON_NotFound(t *testing.T) {
+	a, err := New(testutil.NewTestConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/no-such-route", nil)
+	req.Header.Set("Accept", pkg.ProblemContentType)
+	p := assertProblem(t, testutil.Serve(a.engine, req), http.StatusNotFound, "/api/v1/no-such-route")
+	if p.Detail != "not found" {
+		t.Errorf("detail = %q, want %q", p.Detail, "not found")
+	}
+}
+
+func TestProblemJSON_ValidationFailure(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	req := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", `{"name":"","email":"not-an-email"}`)
+	req.Header.Set("Accept", pkg.ProblemContentType)
+	p := assertProblem(t, testutil.Serve(a.engine, req), http.StatusBadRequest, "/api/v1/users")
+
+	names := make(map[string]bool, len(p.InvalidParams))
+	for _, param := range p.InvalidParams {
+		if param.Reason == "" {
+			t.Errorf("invalid-param %q has empty reason", param.Name)
+		}
+		names[param.Name] = true
+	}
+	if !names["name"] || !names["email"] {
+		t.Errorf("invalid-params = %+v, want name and email", p.InvalidParams)
+	}
+}
+
+func TestProblemJSON_RateLimit(t *testing.T) {
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.RateLimit = config.RateLimitConfig{Enabled: true, RPS: 1, Burst: 1}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	a.engine.GET("/api/v1/test-rate-limit", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/test-rate-limit", nil)
+		req.Header.Set("Accept", pkg.ProblemContentType)
+		return testutil.Serve(a.engine, req)
+	}
+	if first := serve(); first.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", first.Code, http.StatusOK)
+	}
+	p := assertProblem(t, serve(), http.StatusTooManyRequests, "/api/v1/test-rate-limit")
+	if p.Detail != "rate limit exceeded" {
+		t.Errorf("detail = %q, want %q", p.Detail, "rate limit exceeded")
+	}
+}
+
+func TestProblemJSON_ForcedByConfig(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.API.ProblemJSON = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/no-such-route", nil)
+	req.Header.Set("Accept", "application/json")
+	assertProblem(t, testutil.Serve(a.engine, req), http.StatusNotFound, "/api/v1/no-such-route")
+}
+
+// decodeKeys decodes a JSON response body into nested maps for key checks.
+func decodeKeys(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+func TestJSONNaming_CamelRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		force  bool
+		header string
+	}{
+		{name: "header", header: "camel"},
+		{name: "forced by config", force: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+				if tc.force {
+					c.Server.API.JSONNaming = "camel"
+				}
+			}))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer cleanupTestApp(t, a)
+			testutil.Migrate(t, a.db)
+
+			serve := func(req *http.Request) *httptest.ResponseRecorder {
+				if tc.header != "" {
+					req.Header.Set(pkg.JSONNamingHeader, tc.header)
+				}
+				return testutil.Serve(a.engine, req)
+			}
+
+			w := serve(testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", `{"name":"Ada","email":"ada@example.com"}`))
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create status = %d, body %s", w.Code, w.Body.String())
+			}
+			user, _ := decodeKeys(t, w)["data"].(map[string]any)
+			if _, ok := user["createdAt"]; !ok || user["created_at"] != nil {
+				t.Errorf("create data = %v, want createdAt and no created_at", user)
+			}
+
+			w = serve(httptest.NewRequest(http.MethodGet, "/api/v1/users?page=1&page_size=1", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("list status = %d, body %s", w.Code, w.Body.String())
+			}
+			page, _ := decodeKeys(t, w)["data"].(map[string]any)
+			if page["totalItems"] != float64(1) || page["itemsPerPage"] != float64(1) || page["total_items"] != nil {
+				t.Errorf("list data = %v, want camelCase pagination keys", page)
+			}
+			items, _ := page["items"].([]any)
+			if len(items) != 1 {
+				t.Fatalf("items = %v, want 1", page["items"])
+			}
+			if item, _ := items[0].(map[string]any); item["updatedAt"] == nil {
+				t.Errorf("item = %v, want updatedAt", item)
+			}
+
+			w = serve(testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", `{"email":"not-an-email"}`))
+			errs, _ := decodeKeys(t, w)["errors"].(map[string]any)
+			if w.Code != http.StatusBadRequest || errs["name"] == nil || errs["email"] == nil {
+				t.Errorf("validation = %d %s, want name and email errors", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestJSONNaming_DefaultSnakeCase(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", `{"name":"Ada","email":"ada@example.com"}`))
+	user, _ := decodeKeys(t, w)["data"].(map[string]any)
+	if _, ok := user["created_at"]; !ok || user["createdAt"] != nil {
+		t.Errorf("create data = %v, want created_at only", user)
+	}
+	w = testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	if page, _ := decodeKeys(t, w)["data"].(map[string]any); page["total_items"] == nil {
+		t.Errorf("list data = %v, want total_items", page)
+	}
+}
+
+// userListWithOverride renders /users in mode with user_search configured
+// off and reports whether the response contains the search box, with and
+// without an X-Feature-Override turning it on.
+func userListWithOverride(t *testing.T, mode string) (withHeader, without bool) {
+	t.Helper()
+	dsn := testutil.MemoryDSN(t)
+	testutil.OpenTestDB(t, dsn)
+	cfg := testutil.NewTestConfig(testutil.WithMode(mode), testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+		c.Features = map[string]bool{"user_search": false}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	hasSearchBox := func(override string) bool {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if override != "" {
+			req.Header.Set(middleware.FeatureOverrideHeader, override)
+		}
+		w := testutil.Serve(a.engine, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /users status = %d, want 200", w.Code)
+		}
+		return strings.Contains(w.Body.String(), `name="name__like"`)
+	}
+	return hasSearchBox("user_search=true"), hasSearchBox("")
+}
+
+func TestFeatureOverride_HonoredInDebugMode(t *testing.T) {
+	withHeader, without := userListWithOverride(t, gin.DebugMode)
+	if !withHeader {
+		t.Error("debug mode: X-Feature-Override did not enable user_search")
+	}
+	if without {
+		t.Error("debug mode: search box shown without override, want configured value (off)")
+	}
+}
+
+func TestFeatureOverride_IgnoredInReleaseMode(t *testing.T) {
+	withHeader, without := userListWithOverride(t, gin.ReleaseMode)
+	if withHeader || without {
+		t.Errorf("release mode: search box shown (with header %v, without %v), want X-Feature-Override ignored", withHeader, without)
+	}
+}
+
+func TestNew_LocaleReachesTemplates(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	testutil.OpenTestDB(t, dsn)
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	tests := []struct{ target, acceptLanguage, want string }{
+		{"/", "", "zh-CN"},
+		{"/", "zh;q=0.5, en-US;q=0.9", "en"},
+		{"/users?lang=zh", "en", "zh-CN"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		w := testutil.Serve(a.engine, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", tt.target, w.Code)
+		}
+		if got := w.Header().Get("Content-Language"); got != tt.want {
+			t.Errorf("GET %s (%q) Content-Language = %q, want %q", tt.target, tt.acceptLanguage, got, tt.want)
+		}
+		if !strings.Contains(w.Body.String(), `<html lang="`+tt.want+`">`) {
+			t.Errorf("GET %s (%q) page lacks <html lang=%q>", tt.target, tt.acceptLanguage, tt.want)
+		}
+	}
+
+	// Only pages are localized; API, health and static responses do not
+	// vary on Accept-Language.
+	for _, target := range []string{"/api/v1/users", "/health", "/static/css/app.css"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept-Language", "en")
+		w := testutil.Serve(a.engine, req)
+		if got := w.Header().Get("Content-Language"); got != "" {
+			t.Errorf("GET %s Content-Language = %q, want none", target, got)
+		}
+		if got := w.Header().Values("Vary"); slices.Contains(got, "Accept-Language") {
+			t.Errorf("GET %s Vary = %q, want no Accept-Language", target, got)
+		}
+	}
+}
+
 func TestRun_ReturnsError_WhenListenFails(t *testing.T) {
 	originalNewHTTPServer := newHTTPServer
 	originalNotifyContext := notifyContext
@@ -586,8 +827,8 @@ func TestRun_ReturnsError_WhenListenFails(t *testing.T) {
 	}()
 
 	listenErr := errors.New("listen failed")
-	server := &fakeHTTPServer{listenErr: listenErr}
-	newHTTPServer = func(string, http.Handler) httpServer {
+	server := &testutil.FakeHTTPServer{ServeErr: listenErr}
+	newHTTPServer = func(string, http.Handler, config.HTTPConfig) httpServer {
 		return server
 	}
 	notifyContext = func(context.Context, ...os.Signal) (context.Context, context.CancelFunc) {
@@ -597,7 +838,7 @@ func TestRun_ReturnsError_WhenListenFails(t *testing.T) {
 	a := &App{
 		engine: gin.New(),
 		logger: logger.Default(),
-		cfg:    &config.Config{Server: config.ServerConfig{Host: "127.0.0.1", Port: 8080}},
+		cfg:    &config.Config{Server: config.ServerConfig{Host: "127.0.0.1", Port: 0}},
 	}
 
 	err := a.Run()
@@ -629,8 +870,8 @@ func TestRun_ShutdownSignal_ClosesDatabase(t *testing.T) {
 		t.Fatalf("db.DB() error = %v", err)
 	}
 
-	server := &fakeHTTPServer{listenStarted: make(chan struct{}), stopCh: make(chan struct{})}
-	newHTTPServer = func(string, http.Handler) httpServer {
+	server := testutil.NewFakeHTTPServer()
+	newHTTPServer = func(string, http.Handler, config.HTTPConfig) httpServer {
 		return server
 	}
 
@@ -643,7 +884,7 @@ func TestRun_ShutdownSignal_ClosesDatabase(t *testing.T) {
 		engine: gin.New(),
 		db:     db,
 		logger: logger.Default(),
-		cfg:    &config.Config{Server: config.ServerConfig{Host: "127.0.0.1", Port: 8080}},
+		cfg:    &config.Config{Server: config.ServerConfig{Host: "127.0.0.1", Port: 0}},
 	}
 
 	errCh := make(chan error, 1)
@@ -652,7 +893,7 @@ func TestRun_ShutdownSignal_ClosesDatabase(t *testing.T) {
 	}()
 
 	select {
-	case <-server.listenStarted:
+	case <-server.Started():
 	case <-time.After(2 * time.Second):
 		t.Fatal("server did not start listening in time")
 	}
@@ -668,7 +909,7 @@ func TestRun_ShutdownSignal_ClosesDatabase(t *testing.T) {
 		t.Fatal("Run() did not return in time after shutdown signal")
 	}
 
-	if !server.wasShutdownCalled() {
+	if !server.ShutdownCalled() {
 		t.Fatal("expected server Shutdown() to be called")
 	}
 
@@ -681,42 +922,13 @@ func TestRun_ShutdownSignal_ClosesDatabase(t *testing.T) {
 
 func cleanupTestApp(t *testing.T, a *App) {
 	t.Helper()
-	if a == nil {
-		return
-	}
-	if a.jwtService != nil {
-		a.jwtService.Close()
-	}
-	if a.rbacService != nil {
-		_ = a.rbacService.Close()
-	}
-	if a.db != nil {
-		sqlDB, dbErr := a.db.DB()
-		if dbErr == nil {
-			_ = sqlDB.Close()
-		}
-	}
-	if a.logger != nil {
-		_ = a.logger.Close()
+	if err := a.Close(); err != nil {
+		t.Errorf("App.Close() error = %v", err)
 	}
 }
 
 func TestNew_AuthDisabled_NoAuthServices(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.TestMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-	}
+	cfg := testutil.NewTestConfig()
 
 	app, err := New(cfg)
 	if err != nil {
@@ -733,27 +945,7 @@ func TestNew_AuthDisabled_NoAuthServices(t *testing.T) {
 }
 
 func TestNew_AuthEnabled_RoutesAndMiddleware(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.TestMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-		Auth: config.AuthConfig{
-			Enabled:     true,
-			JWTSecret:   "test-secret-key-must-be-at-least-32-chars-long!",
-			TokenExpiry: "24h",
-			PublicPaths: []string{"/api/v1/auth/login", "/api/v1/auth/register"},
-		},
-	}
+	cfg := testutil.NewTestConfig(testutil.WithAuth())
 
 	app, err := New(cfg)
 	if err != nil {
@@ -795,39 +987,73 @@ func TestNew_AuthEnabled_RoutesAndMiddleware(t *testing.T) {
 	}
 }
 
-func TestNew_AuthEnabled_WithRBAC(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.TestMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-		Auth: config.AuthConfig{
-			Enabled:     true,
-			JWTSecret:   "test-secret-key-must-be-at-least-32-chars-long!",
-			TokenExpiry: "24h",
-			PublicPaths: []string{"/api/v1/auth/login", "/api/v1/auth/register"},
-			RBAC: config.RBACConfig{
-				Enabled: true,
-				Cache: config.RBACCacheConfig{
-					RoleTTL:              "5m",
-					UserRoleTTL:          "5m",
-					PermissionTTL:        "5m",
-					MaxRoleEntries:       100,
-					MaxUserEntries:       100,
-					MaxPermissionEntries: 100,
-				},
-			},
-		},
+func TestNew_HealthUnderAPI_UsesTokenNotJWT(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithAuth(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.Health = config.HealthConfig{Path: "/api/health", Token: "probe-secret"}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	serve := func(auth string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set("Authorization", auth)
+		return testutil.Serve(a.engine, req).Code
+	}
+	if got := serve("Bearer probe-secret"); got != http.StatusOK {
+		t.Errorf("health token: status = %d, want 200 without a JWT", got)
+	}
+	if got := serve("Bearer wrong"); got != http.StatusNotFound {
+		t.Errorf("wrong token: status = %d, want 404", got)
+	}
+}
+
+func TestNew_AllowedHosts(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.AllowedHosts = []string{"app.example.com", "*.example.net"}
+		c.Server.Meta = config.MetaConfig{SecurityContact: "mailto:security@example.com", SecurityExpires: "2030-01-01T00:00:00Z"}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	serve := func(path, host, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host, req.RemoteAddr = host, remote
+		req.Header.Set("Accept", "application/json")
+		return testutil.Serve(a.engine, req)
+	}
+	tests := []struct {
+		name, path, host, remote string
+		want                     int
+	}{
+		{"allowed", "/health", "app.example.com", "203.0.113.9:4000", http.StatusOK},
+		{"wildcard", "/health", "eu.example.net:8443", "203.0.113.9:4000", http.StatusOK},
+		{"mismatch", "/users", "evil.example.com", "203.0.113.9:4000", http.StatusMisdirectedRequest},
+		{"malformed", "/users", "bad host", "203.0.113.9:4000", http.StatusBadRequest},
+		{"local health probe", "/health", "10.0.0.5:8080", "127.0.0.1:5000", http.StatusOK},
+		{"remote health probe", "/health", "10.0.0.5:8080", "203.0.113.9:4000", http.StatusMisdirectedRequest},
+		{"local non-health", "/users", "10.0.0.5:8080", "127.0.0.1:5000", http.StatusMisdirectedRequest},
+	}
+	for _, tt := range tests {
+		if w := serve(tt.path, tt.host, tt.remote); w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+
+	// security.txt names itself on the validated host.
+	w := serve("/.well-known/security.txt", "APP.example.com:443", "203.0.113.9:4000")
+	if !strings.Contains(w.Body.String(), "Canonical: http://app.example.com:443/.well-known/security.txt\n") {
+		t.Errorf("security.txt = %q, want a Canonical URL on the normalized host", w.Body.String())
 	}
+}
+
+func TestNew_AuthEnabled_WithRBAC(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithRBAC())
 
 	app, err := New(cfg)
 	if err != nil {
@@ -843,22 +1069,150 @@ func TestNew_AuthEnabled_WithRBAC(t *testing.T) {
 	}
 }
 
-func TestAutoMigrate_AddsPasswordHashColumnInDebug(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.DebugMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "debug-migrate.db")},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
+func TestNew_RBAC_UsersCanReadAndUpdateThemselves(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)))
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db,
+		domain.User{Name: "Alice", Email: "alice@example.com"},
+		domain.User{Name: "Bob", Email: "bob@example.com"},
+		domain.User{Name: "Admin", Email: "admin@example.com"},
+	)
+	if err := a.rbacService.AddUserPermissions("3", "users", []string{"read", "update", "delete"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+
+	serve := func(method, path, userID string) int {
+		req := testutil.NewJSONRequest(t, method, path, `{"name":"Renamed","email":"renamed@example.com"}`)
+		if userID != "" {
+			token, err := a.jwtService.GenerateToken(userID, nil, time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return testutil.Serve(a.engine, req).Code
+	}
+
+	tests := []struct {
+		method, path, user string
+		want               int
+	}{
+		{http.MethodGet, "/api/v1/users/1", "1", http.StatusOK},
+		{http.MethodPut, "/api/v1/users/1", "1", http.StatusOK},
+		{http.MethodGet, "/api/v1/users/2", "1", http.StatusForbidden},
+		{http.MethodPut, "/api/v1/users/2", "1", http.StatusForbidden},
+		{http.MethodPatch, "/api/v1/users/1", "1", http.StatusOK},
+		{http.MethodPatch, "/api/v1/users/2", "1", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/users", "1", http.StatusForbidden},
+		{http.MethodDelete, "/api/v1/users/1", "1", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/users", "1", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/users/2", "3", http.StatusOK},
+		{http.MethodGet, "/api/v1/users", "3", http.StatusOK},
+		{http.MethodGet, "/api/v1/users/1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := serve(tt.method, tt.path, tt.user); got != tt.want {
+			t.Errorf("%s %s as %q: status = %d, want %d", tt.method, tt.path, tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestNew_RBAC_PolicySync(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)))
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db,
+		domain.User{Name: "Admin", Email: "admin@example.com"},
+		domain.User{Name: "Bob", Email: "bob@example.com"},
+	)
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read", "update"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	if err := a.rbacService.AddUserPermissions("2", "admin", []string{"read"}); err != nil {
+		t.Fatalf("grant admin:read: %v", err)
+	}
+
+	sync := func(userID string) int {
+		req := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/admin/rbac/sync",
+			`{"roles":[{"id":"auditor","permissions":{"users":["read"]}}],"assignments":[{"email":"bob@example.com","roles":["auditor"]}]}`)
+		token, err := a.jwtService.GenerateToken(userID, nil, time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateToken() error = %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return testutil.Serve(a.engine, req).Code
+	}
+
+	if code := sync("2"); code != http.StatusForbidden {
+		t.Fatalf("sync as bob: status = %d, want 403", code)
+	}
+	if code := sync("1"); code != http.StatusOK {
+		t.Fatalf("sync as admin: status = %d, want 200", code)
+	}
+	if ok, err := a.rbacService.HasPermission("2", "users", "read"); err != nil || !ok {
+		t.Errorf("bob users:read = %v, %v; want granted through the auditor role", ok, err)
+	}
+}
+
+func TestNew_APIKeys(t *testing.T) {
+	sum := sha256.Sum256([]byte("batch-job-key"))
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Auth.APIKeys = []config.APIKeyConfig{{Name: "batch", KeyHash: hex.EncodeToString(sum[:]), Scopes: []string{"users:read"}}}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db, domain.User{Name: "Admin", Email: "admin@example.com"})
+	if err := a.rbacService.AddUserPermissions("1", "users", []string{"read"}); err != nil {
+		t.Fatalf("grant users:read: %v", err)
 	}
+	token, err := a.jwtService.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name, method, path, apiKey, bearer string
+		want                               int
+	}{
+		{"key in scope", http.MethodGet, "/api/v1/users", "batch-job-key", "", http.StatusOK},
+		{"key in scope by id", http.MethodGet, "/api/v1/users/1", "batch-job-key", "", http.StatusOK},
+		{"key out of scope", http.MethodDelete, "/api/v1/users/1", "batch-job-key", "", http.StatusForbidden},
+		{"invalid key", http.MethodGet, "/api/v1/users", "wrong-key", "", http.StatusUnauthorized},
+		{"invalid key with valid jwt", http.MethodGet, "/api/v1/users", "wrong-key", token, http.StatusUnauthorized},
+		{"jwt on the same route", http.MethodGet, "/api/v1/users", "", token, http.StatusOK},
+		{"neither", http.MethodGet, "/api/v1/users", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.NewJSONRequest(t, tt.method, tt.path, "")
+			if tt.apiKey != "" {
+				req.Header.Set(middleware.APIKeyHeader, tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if w := testutil.Serve(a.engine, req); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAutoMigrate_AddsPasswordHashColumnInDebug(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithMode(gin.DebugMode), testutil.WithSQLitePath(filepath.Join(t.TempDir(), "debug-migrate.db")))
 
 	app, err := New(cfg)
 	if err != nil {
@@ -887,21 +1241,7 @@ func TestAutoMigrate_AddsPasswordHashColumnInDebug(t *testing.T) {
 }
 
 func TestAutoMigrate_DoesNotRunOutsideDebug(t *testing.T) {
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.TestMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "no-migrate.db")},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-	}
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(filepath.Join(t.TempDir(), "no-migrate.db")))
 
 	app, err := New(cfg)
 	if err != nil {
@@ -918,6 +1258,60 @@ func TestAutoMigrate_DoesNotRunOutsideDebug(t *testing.T) {
 	}
 }
 
+func TestAutoMigrate_TablePrefix(t *testing.T) {
+	cfg := testutil.NewTestConfig(
+		testutil.WithMode(gin.DebugMode),
+		testutil.WithRBAC(),
+		testutil.WithSQLitePath(filepath.Join(t.TempDir(), "prefixed.db")),
+		testutil.WithTablePrefix("gobase_"),
+	)
+
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	defer cleanupTestApp(t, app)
+
+	var tables []string
+	if err := app.db.Raw("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Scan(&tables).Error; err != nil {
+		t.Fatalf("query sqlite_master: %v", err)
+	}
+	for _, table := range tables {
+		if !strings.HasPrefix(table, "gobase_") {
+			t.Errorf("table %q lacks the gobase_ prefix", table)
+		}
+	}
+	for _, want := range []string{"gobase_users", "gobase_notes", "gobase_notifications", "gobase_rbac_roles", "gobase_rbac_user_roles"} {
+		if !slices.Contains(tables, want) {
+			t.Errorf("tables = %v, missing %q", tables, want)
+		}
+	}
+
+	ctx := context.Background()
+	repo := user.NewUserRepository(app.db)
+	u := &domain.User{Name: "Alice", Email: "alice@example.com"}
+	if err := repo.Create(ctx, u); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	u.Name = "Alice Smith"
+	if _, err := repo.Update(ctx, u); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.GetByID(ctx, u.ID)
+	if err != nil || got.Name != "Alice Smith" {
+		t.Fatalf("GetByID = %+v, %v; want the updated user", got, err)
+	}
+	if page, err := repo.List(ctx, domain.PageRequest{Page: 1, PageSize: 10}); err != nil || page.TotalItems != 1 {
+		t.Fatalf("List = %+v, %v; want 1 user", page, err)
+	}
+	if err := repo.Delete(ctx, u.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, u.ID); !domain.IsNotFound(err) {
+		t.Errorf("GetByID after Delete: err = %v, want not found", err)
+	}
+}
+
 func TestRun_Shutdown_ClosesAuthServices(t *testing.T) {
 	originalNewHTTPServer := newHTTPServer
 	originalNotifyContext := notifyContext
@@ -926,38 +1320,7 @@ func TestRun_Shutdown_ClosesAuthServices(t *testing.T) {
 		notifyContext = originalNotifyContext
 	}()
 
-	cfg := &config.Config{
-		Server: config.ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
-			Mode: gin.TestMode,
-		},
-		Database: config.DatabaseConfig{
-			Driver: "sqlite",
-			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
-		},
-		Log: config.LogConfig{
-			Level:  "info",
-			Format: "text",
-		},
-		Auth: config.AuthConfig{
-			Enabled:     true,
-			JWTSecret:   "test-secret-key-must-be-at-least-32-chars-long!",
-			TokenExpiry: "24h",
-			PublicPaths: []string{"/api/v1/auth/login", "/api/v1/auth/register"},
-			RBAC: config.RBACConfig{
-				Enabled: true,
-				Cache: config.RBACCacheConfig{
-					RoleTTL:              "5m",
-					UserRoleTTL:          "5m",
-					PermissionTTL:        "5m",
-					MaxRoleEntries:       100,
-					MaxUserEntries:       100,
-					MaxPermissionEntries: 100,
-				},
-			},
-		},
-	}
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), func(c *config.Config) { c.Server.Port = 0 })
 
 	app, err := New(cfg)
 	if err != nil {
@@ -972,8 +1335,8 @@ func TestRun_Shutdown_ClosesAuthServices(t *testing.T) {
 		t.Fatal("expected rbacService to be non-nil")
 	}
 
-	server := &fakeHTTPServer{listenStarted: make(chan struct{}), stopCh: make(chan struct{})}
-	newHTTPServer = func(string, http.Handler) httpServer {
+	server := testutil.NewFakeHTTPServer()
+	newHTTPServer = func(string, http.Handler, config.HTTPConfig) httpServer {
 		return server
 	}
 
@@ -988,7 +1351,7 @@ func TestRun_Shutdown_ClosesAuthServices(t *testing.T) {
 	}()
 
 	select {
-	case <-server.listenStarted:
+	case <-server.Started():
 	case <-time.After(2 * time.Second):
 		t.Fatal("server did not start listening in time")
 	}
@@ -1004,7 +1367,442 @@ func TestRun_Shutdown_ClosesAuthServices(t *testing.T) {
 		t.Fatal("Run() did not return in time after shutdown signal")
 	}
 
-	if !server.wasShutdownCalled() {
+	if !server.ShutdownCalled() {
 		t.Error("expected server Shutdown() to be called")
 	}
 }
+
+func TestNew_CacheWarm_PopulatesAtStartupAndAfterInvalidation(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	testutil.SeedUsers(t, testutil.OpenTestDB(t, dsn), domain.User{Name: "Warm One", Email: "warm-one@example.com"})
+
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{
+			Enabled: true,
+			TTL:     config.Duration(time.Hour),
+			MaxSize: 100,
+			Warm:    []config.CacheWarmTarget{{Path: "/api/v1/users"}, {Path: "/api/v1/users", Query: "page=1&page_size=5"}},
+		}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	listKeys := []string{
+		middleware.ResponseCacheKey(http.MethodGet, "/api/v1/users", ""),
+		middleware.ResponseCacheKey(http.MethodGet, "/api/v1/users", "page=1&page_size=5"),
+	}
+	for _, key := range listKeys {
+		if !a.cache.Has(key) {
+			t.Fatalf("cache missing %q after New; keys = %v", key, a.cache.Keys())
+		}
+	}
+
+	created := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users",
+		`{"name":"Warm Two","email":"warm-two@example.com"}`))
+	if created.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, body = %s", created.Code, created.Body.String())
+	}
+
+	// The POST purges /api/v1/users synchronously; re-warming is async.
+	deadline := time.Now().Add(2 * time.Second)
+	for !a.cache.Has(listKeys[0]) || !a.cache.Has(listKeys[1]) {
+		if time.Now().After(deadline) {
+			t.Fatalf("cache not re-warmed after invalidating POST; keys = %v", a.cache.Keys())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The re-warmed entry is served from cache and already includes the write.
+	got := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	if !strings.Contains(got.Body.String(), "warm-two@example.com") {
+		t.Errorf("re-warmed list is stale: %s", got.Body.String())
+	}
+}
+
+func TestNew_UnchangedUpdateKeepsResponseCache(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	seeded := testutil.SeedUsers(t, testutil.OpenTestDB(t, dsn), domain.User{Name: "Alice", Email: "alice@example.com"})
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	listKey := middleware.ResponseCacheKey(http.MethodGet, "/api/v1/users", "")
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(seeded[0].ID), 10)
+	update := func(body string) (modified bool) {
+		t.Helper()
+		testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		if !a.cache.Has(listKey) {
+			t.Fatalf("list not cached; keys = %v", a.cache.Keys())
+		}
+		w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPut, path, body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				Modified bool `json:"modified"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return resp.Data.Modified
+	}
+
+	if update(`{"name":"Alice","email":"alice@example.com"}`) {
+		t.Error("PUT with the stored values reported modified")
+	}
+	if !a.cache.Has(listKey) {
+		t.Error("PUT with the stored values purged the cached list")
+	}
+	if !update(`{"name":"Alicia","email":"alice@example.com"}`) {
+		t.Error("PUT with a new name did not report modified")
+	}
+	if a.cache.Has(listKey) {
+		t.Error("cached list kept after a real update")
+	}
+}
+
+func TestNew_CacheWarm_FailuresDoNotBlockStartup(t *testing.T) {
+	// Test mode skips AutoMigrate, so the warm request fails with 500.
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{
+			Enabled:    true,
+			TTL:        config.Duration(time.Hour),
+			MaxSize:    100,
+			Warm:       []config.CacheWarmTarget{{Path: "/api/v1/users"}, {Path: "/api/v1/missing"}},
+			WarmBudget: config.Duration(time.Second),
+		}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v, want warming failures to be logged only", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	if n := a.cache.Count(); n != 0 {
+		t.Errorf("failed warm requests were cached: %v", a.cache.Keys())
+	}
+}
+
+func TestNew_CacheEnabled_ConcurrentListRequestsShareResponse(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	db := testutil.OpenTestDB(t, dsn)
+	testutil.SeedUsers(t, db, domain.User{}, domain.User{})
+
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	const n = 20
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recs[i] = testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, w := range recs {
+		if w.Code != http.StatusOK || w.Body.String() != recs[0].Body.String() {
+			t.Errorf("response %d = %d %s, want 200 with the shared body", i, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Request-ID") == recs[(i+1)%n].Header().Get("X-Request-ID") {
+			t.Errorf("responses %d and %d share an X-Request-ID", i, (i+1)%n)
+		}
+	}
+}
+
+func TestNew_CacheDisabled_NoWarmer(t *testing.T) {
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.Cache.Warm = []config.CacheWarmTarget{{Path: "/api/v1/users"}}
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	if a.warmer != nil || a.cache != nil {
+		t.Error("cache warming must be skipped when the cache is disabled")
+	}
+}
+
+func TestNew_Idempotency_UserCreateReplays(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.API.Idempotency = config.IdempotencyConfig{Enabled: true, TTL: config.Duration(time.Hour)}
+	})
+
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", body)
+		req.Header.Set(middleware.IdempotencyKeyHeader, "create-idem-1")
+		return testutil.Serve(a.engine, req)
+	}
+
+	body := `{"name":"Idem User","email":"idem-user@example.com"}`
+	first := post(body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first POST status = %d, want %d; body = %s", first.Code, http.StatusCreated, first.Body.String())
+	}
+	second := post(body)
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Errorf("replay missing %s header", middleware.IdempotentReplayedHeader)
+	}
+
+	var count int64
+	if err := a.db.Model(&domain.User{}).Where("email = ?", "idem-user@example.com").Count(&count).Error; err != nil {
+		t.Fatalf("count users: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("users with email = %d, want 1", count)
+	}
+
+	conflict := post(`{"name":"Other","email":"idem-other@example.com"}`)
+	if conflict.Code != http.StatusConflict {
+		t.Errorf("different body status = %d, want %d", conflict.Code, http.StatusConflict)
+	}
+}
+
+func newPathPolicyTestApp(t *testing.T, caseInsensitive, authEnabled bool) *App {
+	t.Helper()
+	opts := []testutil.ConfigOption{
+		testutil.WithSQLitePath(testutil.MemoryDSN(t)),
+		func(c *config.Config) { c.Server.API.CaseInsensitivePaths = caseInsensitive },
+	}
+	if authEnabled {
+		opts = append(opts, testutil.WithAuth())
+	}
+
+	a, err := New(testutil.NewTestConfig(opts...))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	return a
+}
+
+func TestNew_APITrailingSlash_Redirects308(t *testing.T) {
+	a := newPathPolicyTestApp(t, false, false)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := httptest.NewRecorder()
+		a.engine.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/users/?page=1", nil))
+		if w.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s /api/v1/users/: status = %d, want %d", method, w.Code, http.StatusPermanentRedirect)
+		}
+		if got := w.Header().Get("Location"); got != "/api/v1/users?page=1" {
+			t.Errorf("%s /api/v1/users/: Location = %q, want %q", method, got, "/api/v1/users?page=1")
+		}
+	}
+}
+
+func TestNew_APITrailingSlash_FollowedRedirectKeepsBody(t *testing.T) {
+	a := newPathPolicyTestApp(t, false, false)
+	srv := httptest.NewServer(a.engine)
+	defer srv.Close()
+
+	resp, err := srv.Client().Post(srv.URL+"/api/v1/users/", "application/json",
+		strings.NewReader(`{"name":"Slash User","email":"slash-user@example.com"}`))
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/v1/users/: status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	var body struct {
+		Data struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Data.Name != "Slash User" || body.Data.Email != "slash-user@example.com" {
+		t.Errorf("created user = %+v, want the posted body", body.Data)
+	}
+
+	getResp, err := srv.Client().Get(srv.URL + "/api/v1/users/")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer getResp.Body.Close()
+	if getResp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/v1/users/: status = %d, want %d", getResp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestNew_APIMixedCasePaths(t *testing.T) {
+	t.Run("enabled redirects to lowercase", func(t *testing.T) {
+		a := newPathPolicyTestApp(t, true, false)
+
+		w := httptest.NewRecorder()
+		a.engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/API/v1/Users?page=1", nil))
+		if w.Code != http.StatusPermanentRedirect {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusPermanentRedirect)
+		}
+		if got := w.Header().Get("Location"); got != "/api/v1/users?page=1" {
+			t.Errorf("Location = %q, want %q", got, "/api/v1/users?page=1")
+		}
+	})
+
+	t.Run("disabled returns JSON 404", func(t *testing.T) {
+		a := newPathPolicyTestApp(t, false, false)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/API/v1/users", nil)
+		req.Header.Set("Accept", "text/html")
+		a.engine.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/json") {
+			t.Errorf("Content-Type = %q, want JSON", ct)
+		}
+	})
+}
+
+func TestNew_PublicPathTrailingSlash_NotRejectedByAuth(t *testing.T) {
+	a := newPathPolicyTestApp(t, false, true)
+
+	w := httptest.NewRecorder()
+	a.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login/", strings.NewReader(`{}`)))
+	if w.Code != http.StatusPermanentRedirect {
+		t.Fatalf("POST /api/v1/auth/login/: status = %d, want %d", w.Code, http.StatusPermanentRedirect)
+	}
+	if got := w.Header().Get("Location"); got != "/api/v1/auth/login" {
+		t.Errorf("Location = %q, want %q", got, "/api/v1/auth/login")
+	}
+}
+
+func TestPublicPathIs_NormalizesRequestPath(t *testing.T) {
+	tests := []struct {
+		path            string
+		caseInsensitive bool
+		want            bool
+	}{
+		{path: "/api/v1/auth/login", want: true},
+		{path: "/api/v1/auth/login/", want: true},
+		{path: "/API/v1/auth/login", want: false},
+		{path: "/API/v1/auth/login", caseInsensitive: true, want: true},
+		{path: "/api/v1/users", want: false},
+	}
+	for _, tt := range tests {
+		public := newPublicPaths([]string{"/api/v1/auth/login"}, tt.caseInsensitive)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, tt.path, nil)
+		if got := public.is(c); got != tt.want {
+			t.Errorf("public.is(%q, %v) = %v, want %v", tt.path, tt.caseInsensitive, got, tt.want)
+		}
+	}
+}
+
+func TestRequestID_TrustedProxiesAndMiddlewareResponses(t *testing.T) {
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.TrustedProxies = []string{"192.0.2.0/24"}
+		c.Server.RequestID = config.RequestIDConfig{AcceptIncoming: true}
+		c.Server.RateLimit = config.RateLimitConfig{Enabled: true, RPS: 1, Burst: 1}
+	})
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	defer cleanupTestApp(t, app)
+
+	send := func(path, remote, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Request-ID", id)
+		return testutil.Serve(app.engine, req)
+	}
+
+	// From the trusted gateway the inbound ID is kept, also on the 429.
+	if w := send("/api/v1/users", "192.0.2.10:5000", "gw-1"); w.Header().Get("X-Request-ID") != "gw-1" {
+		t.Errorf("trusted: X-Request-ID = %q, want gw-1", w.Header().Get("X-Request-ID"))
+	}
+	w := send("/api/v1/users", "192.0.2.10:5000", "gw-2")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("X-Request-ID") != "gw-2" {
+		t.Errorf("rate limited: status = %d, X-Request-ID = %q; want 429 with gw-2", w.Code, w.Header().Get("X-Request-ID"))
+	}
+
+	// Elsewhere, or with unsafe characters, a fresh ID replaces it.
+	for _, tc := range []struct{ remote, id string }{
+		{"198.51.100.7:5000", "spoofed"},
+		{"192.0.2.11:5000", "bad id\t"},
+	} {
+		w := send("/no-such-page", tc.remote, tc.id)
+		if got := w.Header().Get("X-Request-ID"); w.Code != http.StatusNotFound || got == "" || got == tc.id {
+			t.Errorf("%s %q: status = %d, X-Request-ID = %q; want 404 with a generated ID", tc.remote, tc.id, w.Code, got)
+		}
+	}
+}
+
+func TestErrorPages_ThroughEngine(t *testing.T) {
+	app, err := New(testutil.NewTestConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v, want nil", err)
+	}
+	defer cleanupTestApp(t, app)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		accept     string
+		wantStatus int
+		want       string
+	}{
+		{"csrf html", http.MethodPost, "/users", "text/html", http.StatusForbidden, "禁止访问"},
+		{"csrf json", http.MethodPost, "/users", "application/json", http.StatusForbidden, `"message":"CSRF token missing"`},
+		{"not found", http.MethodGet, "/no-such-page", "text/html", http.StatusNotFound, "页面未找到"},
+		{"method not allowed html", http.MethodPatch, "/users", "text/html", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{"method not allowed api", http.MethodPatch, "/api/v1/users", "*/*", http.StatusMethodNotAllowed, `"message":"method not allowed"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := testutil.Serve(app.engine, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body missing %q:\n%s", tt.want, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusMethodNotAllowed && !strings.Contains(w.Header().Get("Allow"), http.MethodGet) {
+				t.Errorf("Allow = %q, want it to list GET", w.Header().Get("Allow"))
+			}
+		})
+	}
+}
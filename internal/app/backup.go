@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// backupPath creates a SQLite snapshot in database.backup_dir; GET
+// /api/v1/admin/backups lists them.
+const backupPath = "/api/v1/admin/backup"
+
+// Snapshot files are named backup-<UTC timestamp>.db, so sorting by name
+// sorts by age and the creation time survives copying the directory.
+const (
+	backupPrefix     = "backup-"
+	backupExt        = ".db"
+	backupTimeLayout = "20060102T150405.000Z"
+)
+
+// errBackupUnsupported is returned for a database that is not SQLite.
+var errBackupUnsupported = domain.NewAppError(domain.CodeValidation, "backups are only supported for the sqlite driver", nil)
+
+// Backup describes one snapshot in database.backup_dir.
+type Backup struct {
+	Name      string      `json:"name"`
+	SizeBytes int64       `json:"size_bytes"`
+	CreatedAt domain.Time `json:"created_at"`
+}
+
+// backupStore writes and lists SQLite snapshots in dir, keeping the newest
+// retention of them (all when retention is 0).
+type backupStore struct {
+	db        *gorm.DB
+	dir       string
+	retention int
+	clock     pkg.Clock
+	// mu serializes create, so two snapshots cannot race for the same
+	// name and pruning sees every finished file.
+	mu sync.Mutex
+}
+
+func newBackupStore(db *gorm.DB, cfg config.DatabaseConfig, clock pkg.Clock) *backupStore {
+	return &backupStore{db: db, dir: cfg.BackupDir, retention: cfg.BackupRetention, clock: clock}
+}
+
+// create snapshots the database with VACUUM INTO, which reads a consistent
+// copy inside a read transaction, so writers are only held up by the WAL
+// checkpoint it may wait for, not for the length of the copy. The file is
+// written under a temporary name and renamed, so list never reports a
+// partial snapshot. Older snapshots beyond the retention are then removed.
+func (s *backupStore) create(ctx context.Context) (Backup, error) {
+	if s.db.Dialector.Name() != "sqlite" {
+		return Backup{}, errBackupUnsupported
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := s.clock.Now().UTC()
+	name := backupPrefix + created.Format(backupTimeLayout) + backupExt
+	final := filepath.Join(s.dir, name)
+	tmp := final + ".tmp"
+	if err := s.db.WithContext(ctx).Exec("VACUUM INTO ?", tmp).Error; err != nil {
+		_ = os.Remove(tmp)
+		return Backup{}, fmt.Errorf("vacuum into %q: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		_ = os.Remove(tmp)
+		return Backup{}, fmt.Errorf("rename backup: %w", err)
+	}
+	info, err := os.Stat(final)
+	if err != nil {
+		return Backup{}, fmt.Errorf("stat backup: %w", err)
+	}
+	if err := s.prune(); err != nil {
+		slog.WarnContext(ctx, "prune database backups failed", slog.String("dir", s.dir), slog.Any("error", err))
+	}
+	return Backup{Name: name, SizeBytes: info.Size(), CreatedAt: domain.NewTime(created)}, nil
+}
+
+// list returns the snapshots in dir, newest first. Files that do not follow
+// the snapshot naming are ignored.
+func (s *backupStore) list() ([]Backup, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	backups := make([]Backup, 0, len(entries))
+	for _, e := range entries {
+		created, ok := parseBackupName(e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: e.Name(), SizeBytes: info.Size(), CreatedAt: domain.NewTime(created)})
+	}
+	slices.SortFunc(backups, func(a, b Backup) int { return b.CreatedAt.Compare(a.CreatedAt.Time) })
+	return backups, nil
+}
+
+// prune removes the snapshots older than the newest retention.
+func (s *backupStore) prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+	backups, err := s.list()
+	if err != nil || len(backups) <= s.retention {
+		return err
+	}
+	var errs []error
+	for _, b := range backups[s.retention:] {
+		if err := os.Remove(filepath.Join(s.dir, b.Name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseBackupName returns the creation time encoded in a snapshot name.
+func parseBackupName(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(name, backupPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, backupExt)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(backupTimeLayout, stamp)
+	return t, err == nil
+}
+
+// checkBackupDir creates dir if needed and verifies a file can be written
+// in it, so a misconfigured database.backup_dir fails startup rather than
+// the first backup.
+func checkBackupDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create database.backup_dir %q: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("database.backup_dir %q is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// registerBackupRoutes adds POST /api/v1/admin/backup and GET
+// /api/v1/admin/backups. They only exist with RBAC, which guards them with
+// admin:update and admin:read (see appPolicies).
+func registerBackupRoutes(api *gin.RouterGroup, store *backupStore, rbacEnabled bool) {
+	if store == nil || !rbacEnabled {
+		return
+	}
+	api.POST("/admin/backup", func(c *gin.Context) {
+		b, err := store.create(c.Request.Context())
+		if err != nil {
+			if !domain.IsValidation(err) {
+				slog.ErrorContext(c.Request.Context(), "database backup failed", slog.Any("error", err))
+			}
+			pkg.Error(c, err)
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "database backup created", slog.String("name", b.Name), slog.Int64("size_bytes", b.SizeBytes))
+		pkg.Success(c, b)
+	})
+	api.GET("/admin/backups", func(c *gin.Context) {
+		if store.db.Dialector.Name() != "sqlite" {
+			pkg.Error(c, errBackupUnsupported)
+			return
+		}
+		backups, err := store.list()
+		if err != nil {
+			pkg.Error(c, err)
+			return
+		}
+		pkg.Success(c, backups)
+	})
+}
+
+// RunBackup opens the database of cfg, writes one snapshot to
+// database.backup_dir and applies database.backup_retention. It backs the
+// -backup flag of cmd/server, for cron jobs next to a running server.
+func RunBackup(cfg *config.Config) (Backup, error) {
+	if cfg == nil {
+		return Backup{}, errors.New("config is nil")
+	}
+	if cfg.Database.BackupDir == "" {
+		return Backup{}, errors.New("database.backup_dir is not configured")
+	}
+	if cfg.Database.Driver != "sqlite" {
+		return Backup{}, errBackupUnsupported
+	}
+	if err := checkBackupDir(cfg.Database.BackupDir); err != nil {
+		return Backup{}, err
+	}
+	db, err := config.SetupDatabase(&cfg.Database, slog.Default())
+	if err != nil {
+		return Backup{}, err
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+	return newBackupStore(db, cfg.Database, pkg.RealClock).create(context.Background())
+}
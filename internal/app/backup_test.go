@@ -0,0 +1,198 @@
+package app
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// countSnapshotUsers opens the snapshot at path on its own connection and
+// returns the number of users in it.
+func countSnapshotUsers(t *testing.T, path string) int64 {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+	var n int64
+	if err := db.Model(&domain.User{}).Count(&n).Error; err != nil {
+		t.Fatalf("count snapshot users: %v", err)
+	}
+	return n
+}
+
+func TestBackupStore_CreateListAndRetention(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, domain.User{}, domain.User{})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a snapshot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	clock := pkg.NewFakeClock(time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC))
+	store := newBackupStore(db, config.DatabaseConfig{BackupDir: dir, BackupRetention: 2}, clock)
+
+	first, err := store.create(t.Context())
+	if err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	if first.Name != "backup-20261014T030000.000Z.db" || first.SizeBytes == 0 || !first.CreatedAt.Equal(clock.Now()) {
+		t.Errorf("first backup = %+v", first)
+	}
+	if n := countSnapshotUsers(t, filepath.Join(dir, first.Name)); n != 2 {
+		t.Errorf("snapshot users = %d, want 2", n)
+	}
+
+	// Later writes are not in the earlier snapshot.
+	testutil.SeedUsers(t, db, domain.User{Email: "late@example.com"})
+	var names []string
+	for range 2 {
+		clock.Advance(24 * time.Hour)
+		b, err := store.create(t.Context())
+		if err != nil {
+			t.Fatalf("create() error = %v", err)
+		}
+		names = append(names, b.Name)
+	}
+	if n := countSnapshotUsers(t, filepath.Join(dir, names[1])); n != 3 {
+		t.Errorf("latest snapshot users = %d, want 3", n)
+	}
+
+	backups, err := store.list()
+	if err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != names[1] || backups[1].Name != names[0] {
+		t.Fatalf("list() = %+v, want the two newest, newest first", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.Name)); !os.IsNotExist(err) {
+		t.Errorf("oldest snapshot still present (err = %v), want pruned", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("unrelated file removed: %v", err)
+	}
+}
+
+func TestBackupStore_RejectsPostgres(t *testing.T) {
+	registerBlockingPingDriver()
+	sqlDB, err := sql.Open(blockingPingDriverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	store := newBackupStore(db, config.DatabaseConfig{BackupDir: t.TempDir()}, pkg.RealClock)
+	if _, err := store.create(t.Context()); !domain.IsValidation(err) {
+		t.Errorf("create() on postgres error = %v, want validation error", err)
+	}
+}
+
+func TestNew_Backups(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Database.BackupDir = dir
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db, domain.User{}, domain.User{})
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read", "update"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	if err := a.rbacService.AddUserPermissions("2", "admin", []string{"read"}); err != nil {
+		t.Fatalf("grant admin:read: %v", err)
+	}
+
+	call := func(method, path string, userID uint) (int, string) {
+		req := testutil.NewJSONRequest(t, method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+testutil.MintToken(t, a.jwtService, userID))
+		w := testutil.Serve(a.engine, req)
+		return w.Code, w.Body.String()
+	}
+
+	if code, _ := call(http.MethodPost, "/api/v1/admin/backup", 2); code != http.StatusForbidden {
+		t.Fatalf("backup with admin:read only: status = %d, want 403", code)
+	}
+	code, body := call(http.MethodPost, "/api/v1/admin/backup", 1)
+	if code != http.StatusOK || !strings.Contains(body, `"name":"backup-`) {
+		t.Fatalf("backup: %d %s", code, body)
+	}
+	code, body = call(http.MethodGet, "/api/v1/admin/backups", 2)
+	if code != http.StatusOK || strings.Count(body, `"name":"backup-`) != 1 || !strings.Contains(body, `"size_bytes":`) {
+		t.Fatalf("list backups: %d %s", code, body)
+	}
+}
+
+func TestNew_BackupDirNotWritable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Database.BackupDir = filepath.Join(file, "backups")
+	})
+	if a, err := New(cfg); err == nil || !strings.Contains(err.Error(), "database.backup_dir") {
+		if a != nil {
+			cleanupTestApp(t, a)
+		}
+		t.Fatalf("New() error = %v, want database.backup_dir error", err)
+	}
+}
+
+func TestRunBackup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	testutil.SeedUsers(t, testutil.OpenTestDB(t, dbPath), domain.User{})
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dbPath), func(c *config.Config) {
+		c.Database.BackupDir = filepath.Join(t.TempDir(), "backups")
+		c.Database.BackupRetention = 1
+	})
+
+	var last Backup
+	for range 2 {
+		b, err := RunBackup(cfg)
+		if err != nil {
+			t.Fatalf("RunBackup() error = %v", err)
+		}
+		last = b
+		time.Sleep(2 * time.Millisecond) // distinct millisecond timestamps
+	}
+	entries, err := os.ReadDir(cfg.Database.BackupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != last.Name {
+		t.Errorf("backup dir = %v, want only %s", entries, last.Name)
+	}
+	if n := countSnapshotUsers(t, filepath.Join(cfg.Database.BackupDir, last.Name)); n != 1 {
+		t.Errorf("snapshot users = %d, want 1", n)
+	}
+
+	cfg.Database.Driver = "postgres"
+	if _, err := RunBackup(cfg); !domain.IsValidation(err) {
+		t.Errorf("RunBackup() on postgres error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/web"
+)
+
+// iconCacheControl is sent with the icons, which change only with a new
+// build. Their URLs are fixed, so they are not marked immutable.
+const iconCacheControl = "public, max-age=2592000"
+
+// webManifestPath is where the layout links the web app manifest.
+const webManifestPath = "/site.webmanifest"
+
+// manifestContentType is the media type of web app manifests.
+const manifestContentType = "application/manifest+json"
+
+// siteIcon is an icon served at the site root, where browsers look for it
+// without a link, from web/static/icons.
+type siteIcon struct {
+	path        string
+	file        string
+	contentType string
+	size        int // square PNG size listed in the manifest; 0 omits it
+}
+
+var siteIcons = []siteIcon{
+	{path: "/favicon.ico", file: "favicon.ico", contentType: "image/x-icon"},
+	{path: "/apple-touch-icon.png", file: "apple-touch-icon.png", contentType: "image/png"},
+	{path: "/icon-192.png", file: "icon-192.png", contentType: "image/png", size: 192},
+	{path: "/icon-512.png", file: "icon-512.png", contentType: "image/png", size: 512},
+}
+
+// registerBrandingRoutes serves the site icons from the embedded assets and
+// the web app manifest, built once from branding.
+func registerBrandingRoutes(r *gin.Engine, branding config.BrandingConfig) error {
+	for _, icon := range siteIcons {
+		body, err := fs.ReadFile(web.EmbeddedFS, "static/icons/"+icon.file)
+		if err != nil {
+			return fmt.Errorf("read icon %s: %w", icon.file, err)
+		}
+		r.GET(icon.path, brandingFile(icon.contentType, iconCacheControl, body))
+	}
+
+	manifest, err := webManifest(branding)
+	if err != nil {
+		return fmt.Errorf("build web manifest: %w", err)
+	}
+	r.GET(webManifestPath, brandingFile(manifestContentType, metaCacheControl, manifest))
+	return nil
+}
+
+// brandingPaths matches the icon and manifest requests, which are the same
+// for every tenant.
+func brandingPaths(c *gin.Context) bool {
+	if c.Request.URL.Path == webManifestPath {
+		return true
+	}
+	for _, icon := range siteIcons {
+		if c.Request.URL.Path == icon.path {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestIcon is an entry of the manifest's icons member.
+type manifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// webManifest returns the web app manifest for branding, which lets mobile
+// browsers add the site to the home screen.
+func webManifest(branding config.BrandingConfig) ([]byte, error) {
+	var icons []manifestIcon
+	for _, icon := range siteIcons {
+		if icon.size > 0 {
+			icons = append(icons, manifestIcon{Src: icon.path, Sizes: fmt.Sprintf("%dx%d", icon.size, icon.size), Type: icon.contentType})
+		}
+	}
+	return json.Marshal(struct {
+		Name            string         `json:"name"`
+		ShortName       string         `json:"short_name"`
+		StartURL        string         `json:"start_url"`
+		Display         string         `json:"display"`
+		ThemeColor      string         `json:"theme_color"`
+		BackgroundColor string         `json:"background_color"`
+		Icons           []manifestIcon `json:"icons"`
+	}{
+		Name:            branding.EffectiveName(),
+		ShortName:       branding.EffectiveShortName(),
+		StartURL:        "/",
+		Display:         "standalone",
+		ThemeColor:      branding.EffectiveThemeColor(),
+		BackgroundColor: branding.EffectiveBackgroundColor(),
+		Icons:           icons,
+	})
+}
+
+func brandingFile(contentType, cacheControl string, body []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", cacheControl)
+		c.Data(http.StatusOK, contentType, body)
+	}
+}
+
+// WithBranding sets what the branding template helper returns, for the
+// site name and theme color in the layout (default: the zero
+// config.BrandingConfig, whose Effective methods give the defaults).
+func WithBranding(branding config.BrandingConfig) TemplateOption {
+	return func(r *TemplateRenderer) {
+		r.funcMap["branding"] = func() config.BrandingConfig { return branding }
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestBrandingRoutes_ThroughEngine(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.Branding = config.BrandingConfig{Name: "Acme Portal", ShortName: "Acme", ThemeColor: "#0f766e", BackgroundColor: "#fff"}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	tests := []struct {
+		path, contentType, cacheControl string
+	}{
+		{"/favicon.ico", "image/x-icon", iconCacheControl},
+		{"/apple-touch-icon.png", "image/png", iconCacheControl},
+		{"/icon-192.png", "image/png", iconCacheControl},
+		{"/icon-512.png", "image/png", iconCacheControl},
+		{"/site.webmanifest", "application/manifest+json", metaCacheControl},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != http.StatusOK || w.Body.Len() == 0 {
+				t.Fatalf("GET %s = %d with %d bytes, want 200 with a body", tt.path, w.Code, w.Body.Len())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+		})
+	}
+
+	w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/site.webmanifest", nil))
+	var manifest struct {
+		Name            string `json:"name"`
+		ShortName       string `json:"short_name"`
+		StartURL        string `json:"start_url"`
+		ThemeColor      string `json:"theme_color"`
+		BackgroundColor string `json:"background_color"`
+		Icons           []struct {
+			Src   string `json:"src"`
+			Sizes string `json:"sizes"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("decode manifest %s: %v", w.Body.String(), err)
+	}
+	if manifest.Name != "Acme Portal" || manifest.ShortName != "Acme" || manifest.StartURL != "/" ||
+		manifest.ThemeColor != "#0f766e" || manifest.BackgroundColor != "#fff" {
+		t.Errorf("manifest = %+v, want the configured branding", manifest)
+	}
+	for _, icon := range manifest.Icons {
+		if w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, icon.Src, nil)); w.Code != http.StatusOK {
+			t.Errorf("manifest icon %s (%s) = %d, want 200", icon.Src, icon.Sizes, w.Code)
+		}
+	}
+	if len(manifest.Icons) != 2 {
+		t.Errorf("manifest icons = %+v, want 192x192 and 512x512", manifest.Icons)
+	}
+
+	// The layout links the icons and uses the configured name and color.
+	w = testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/", nil))
+	for _, want := range []string{
+		`<title>Acme Portal</title>`,
+		`<link rel="icon" href="/favicon.ico" sizes="any">`,
+		`<link rel="manifest" href="/site.webmanifest">`,
+		`<meta name="theme-color" content="#0f766e">`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("home page missing %s", want)
+		}
+	}
+}
+
+func TestWebManifest_Defaults(t *testing.T) {
+	body, err := webManifest(config.BrandingConfig{})
+	if err != nil {
+		t.Fatalf("webManifest() error = %v", err)
+	}
+	for _, want := range []string{
+		`"name":"` + config.DefaultBrandingName + `"`,
+		`"short_name":"` + config.DefaultBrandingName + `"`,
+		`"theme_color":"` + config.DefaultThemeColor + `"`,
+		`"background_color":"` + config.DefaultBackgroundColor + `"`,
+		`"display":"standalone"`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("manifest %s missing %s", body, want)
+		}
+	}
+}
+
+func TestBrandingRoutes_SkipTenancy(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithTenancy()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	for _, path := range []string{"/favicon.ico", "/site.webmanifest"} {
+		if w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, path, nil)); w.Code != http.StatusOK {
+			t.Errorf("GET %s without a tenant = %d, want 200", path, w.Code)
+		}
+	}
+}
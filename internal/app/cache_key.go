@@ -0,0 +1,56 @@
+package app
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// cacheKeyBuilder builds the response cache key of GET /api requests, shared
+// by the cache and the single-flight collapsing so both tell the same
+// requests apart. The key is middleware.CacheKey (method, path, query sorted
+// by name, JSON naming), so CacheInvalidation's resource prefixes still
+// purge it, followed by the value of each server.cache.vary_headers header,
+// the request's tenant when tenancy resolved one (as the user page cache
+// does) and, when subject is set, the authenticated subject.
+//
+// ginx.Cache bypasses requests carrying credentials, so subject is nil in
+// every mode today and only anonymous responses are cached. A per-user
+// caching mode sets it to keep private responses apart per user.
+type cacheKeyBuilder struct {
+	varyHeaders []string // canonical header names
+	subject     func(c *gin.Context) (string, bool)
+}
+
+func newCacheKeyBuilder(varyHeaders []string) *cacheKeyBuilder {
+	return &cacheKeyBuilder{varyHeaders: varyHeaders}
+}
+
+// key implements ginx.CacheKeyFunc. Header values, the tenant and the
+// subject are query-escaped, so a value cannot pose as another part of the
+// key.
+func (b *cacheKeyBuilder) key(c *gin.Context) string {
+	var sb strings.Builder
+	sb.WriteString(middleware.CacheKey(c))
+	for _, h := range b.varyHeaders {
+		sb.WriteString(" ")
+		sb.WriteString(strings.ToLower(h))
+		sb.WriteString("=")
+		sb.WriteString(url.QueryEscape(strings.Join(c.Request.Header.Values(h), ",")))
+	}
+	if tenant, ok := requestctx.Tenant(c); ok {
+		sb.WriteString(" tenant=")
+		sb.WriteString(url.QueryEscape(tenant))
+	}
+	if b.subject != nil {
+		if subject, ok := b.subject(c); ok {
+			sb.WriteString(" subject=")
+			sb.WriteString(url.QueryEscape(subject))
+		}
+	}
+	return sb.String()
+}
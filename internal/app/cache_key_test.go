@@ -0,0 +1,126 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestCacheKeyBuilder(t *testing.T) {
+	keyOf := func(b *cacheKeyBuilder, target string, headers ...string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			c.Request.Header.Add(headers[i], headers[i+1])
+		}
+		return b.key(c)
+	}
+
+	plain := newCacheKeyBuilder(nil)
+	if got := keyOf(plain, "/api/v1/users?page_size=5&page=2"); got != "GET /api/v1/users?page=2&page_size=5" {
+		t.Errorf("key = %q, want the query sorted", got)
+	}
+	if keyOf(plain, "/api/v1/users?page=1") == keyOf(plain, "/api/v1/users?page=2") {
+		t.Error("pages 1 and 2 share a key")
+	}
+	if keyOf(plain, "/api/v1/users", "Accept-Language", "en") != keyOf(plain, "/api/v1/users", "Accept-Language", "zh-CN") {
+		t.Error("Accept-Language changes the key without vary_headers")
+	}
+
+	vary := newCacheKeyBuilder([]string{"Accept-Language"})
+	en := keyOf(vary, "/api/v1/users", "Accept-Language", "en")
+	if en != "GET /api/v1/users accept-language=en" {
+		t.Errorf("key = %q, want the header value appended", en)
+	}
+	if en == keyOf(vary, "/api/v1/users", "Accept-Language", "zh-CN") || en == keyOf(vary, "/api/v1/users") {
+		t.Error("different Accept-Language values share a key")
+	}
+	if got := keyOf(vary, "/api/v1/users", "Accept-Language", "en subject=1"); got == en+" subject=1" {
+		t.Errorf("key = %q, header value not escaped", got)
+	}
+
+	vary.subject = func(c *gin.Context) (string, bool) {
+		return c.GetHeader("X-Test-User"), c.GetHeader("X-Test-User") != ""
+	}
+	if got := keyOf(vary, "/api/v1/users", "Accept-Language", "en", "X-Test-User", "7"); got != en+" subject=7" {
+		t.Errorf("key = %q, want the subject appended", got)
+	}
+	if got := keyOf(vary, "/api/v1/users", "Accept-Language", "en"); got != en {
+		t.Errorf("anonymous key = %q, want %q", got, en)
+	}
+
+	keyOfTenant := func(tenant string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		requestctx.SetTenant(c, tenant)
+		return plain.key(c)
+	}
+	if got := keyOfTenant("alpha"); got != "GET /api/v1/users tenant=alpha" {
+		t.Errorf("key = %q, want the tenant appended", got)
+	}
+	if keyOfTenant("alpha") == keyOfTenant("beta") {
+		t.Error("tenants alpha and beta share a key")
+	}
+}
+
+func TestNew_CacheKeysSeparateVariants(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	testutil.SeedUsers(t, testutil.OpenTestDB(t, dsn), domain.User{}, domain.User{})
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{
+			Enabled:     true,
+			TTL:         config.Duration(time.Hour),
+			MaxSize:     100,
+			VaryHeaders: []string{"Accept-Language"},
+		}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	get := func(target, lang string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		w := testutil.Serve(a.engine, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", target, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	page1 := get("/api/v1/users?page=1&page_size=1", "")
+	page2 := get("/api/v1/users?page_size=1&page=2", "")
+	if page1 == page2 {
+		t.Fatalf("page 2 answered with page 1's body %s", page1)
+	}
+	if got := get("/api/v1/users?page=2&page_size=1", ""); got != page2 {
+		t.Errorf("reordered page 2 query = %s, want the cached page 2 %s", got, page2)
+	}
+	for _, key := range []string{
+		"GET /api/v1/users?page=1&page_size=1 accept-language=",
+		"GET /api/v1/users?page=2&page_size=1 accept-language=",
+	} {
+		if !a.cache.Has(key) {
+			t.Errorf("cache missing %q; keys = %v", key, a.cache.Keys())
+		}
+	}
+
+	get("/api/v1/users", "en")
+	get("/api/v1/users", "zh-CN")
+	for _, key := range []string{"GET /api/v1/users accept-language=en", "GET /api/v1/users accept-language=zh-CN"} {
+		if !a.cache.Has(key) {
+			t.Errorf("cache missing %q; keys = %v", key, a.cache.Keys())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	cache "github.com/simp-lee/cache"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// apiBasePath is the path of the /api route group. An HTML page's resource
+// is the API's without it: /users for /api/v1/users.
+const apiBasePath = "/api/v1"
+
+// cachePurges are the onPurge hooks of the response cache invalidation. A
+// write to a resource through either the API or its HTML pages changes what
+// both list, so each purges the other's entries too: the API responses and
+// the page data cached with server.cache.page_ttl.
+type cachePurges struct {
+	cache  cache.CacheInterface
+	warmer *cacheWarmer // nil without server.cache.warm
+}
+
+// afterAPIWrite runs after a write under /api purged prefix.
+func (p cachePurges) afterAPIWrite(prefix string) {
+	if page, ok := strings.CutPrefix(prefix, apiBasePath); ok && strings.HasPrefix(page, "/") {
+		p.purge(page)
+	}
+	p.warmer.rewarm(prefix)
+}
+
+// afterPageWrite runs after a write to an HTML page purged prefix.
+func (p cachePurges) afterPageWrite(prefix string) {
+	if prefix == "/" {
+		return
+	}
+	api := apiBasePath + prefix
+	p.purge(api)
+	p.warmer.rewarm(api)
+}
+
+func (p cachePurges) purge(prefix string) {
+	p.cache.DeletePrefix(middleware.ResponseCacheKey(http.MethodGet, prefix, ""))
+	p.cache.DeletePrefix(middleware.ResponseCacheKey(http.MethodHead, prefix, ""))
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// assertUserRows checks that a user list page shows exactly the users from
+// down to to.
+func assertUserRows(t *testing.T, body string, from, to int) {
+	t.Helper()
+	for id := from; id >= to; id-- {
+		if !strings.Contains(body, fmt.Sprintf(`id="user-row-%d"`, id)) {
+			t.Errorf("list lacks user %d", id)
+		}
+	}
+	for _, id := range []int{from + 1, to - 1} {
+		if strings.Contains(body, fmt.Sprintf(`id="user-row-%d"`, id)) {
+			t.Errorf("list shows user %d, want users %d to %d", id, from, to)
+		}
+	}
+}
+
+func TestUserListPage_CachedAndPurgedByWrites(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	db := testutil.OpenTestDB(t, dsn)
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{
+			Enabled: true,
+			TTL:     config.Duration(time.Minute),
+			MaxSize: 100,
+			PageTTL: config.Duration(time.Minute),
+		}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.SeedUsers(t, db, make([]domain.User, 45)...)
+
+	var csrfCookie *http.Cookie
+	page2 := func() string {
+		t.Helper()
+		w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/users?page=2&page_size=20", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /users?page=2 status = %d, body = %.200s", w.Code, w.Body.String())
+		}
+		for _, c := range w.Result().Cookies() {
+			if c.Name == "_csrf_token" {
+				csrfCookie = c
+			}
+		}
+		return w.Body.String()
+	}
+
+	// Newest first: page 2 holds users 25 to 6, between pages 1 and 3.
+	body := page2()
+	assertUserRows(t, body, 25, 6)
+	for _, want := range []string{
+		`href="/users?page=1&amp;page_size=20"`,
+		`href="/users?page=3&amp;page_size=20"`,
+		"上一页", "下一页",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("pager lacks %s", want)
+		}
+	}
+	if got := strings.Count(body, `aria-current="page"`); got != 1 {
+		t.Errorf("pager marks %d current pages, want 1", got)
+	}
+
+	// A row written behind the app's back is not seen until the entry
+	// expires or a write purges it.
+	testutil.SeedUsers(t, db, domain.User{Name: "Direct", Email: "direct@example.com"})
+	assertUserRows(t, page2(), 25, 6)
+
+	// Creating a user through the API purges the page at once.
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if w := testutil.Serve(a.engine, req); w.Code != http.StatusCreated {
+		t.Fatalf("POST /api/v1/users status = %d, body = %s", w.Code, w.Body.String())
+	}
+	assertUserRows(t, page2(), 27, 8)
+
+	// So does a write through the HTML pages.
+	form := url.Values{"name": {"Renamed"}, "email": {"user20@example.com"}}
+	req = httptest.NewRequest(http.MethodPut, "/users/20", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-CSRF-Token", csrfCookie.Value)
+	req.AddCookie(csrfCookie)
+	if w := testutil.Serve(a.engine, req); w.Code != http.StatusOK {
+		t.Fatalf("PUT /users/20 status = %d, body = %.200s", w.Code, w.Body.String())
+	}
+	if body := page2(); !strings.Contains(body, "Renamed") {
+		t.Error("page 2 still shows the old name after PUT /users/20")
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// defaultCacheWarmBudget bounds startup warming when server.cache.warm_budget
+// is unset.
+const defaultCacheWarmBudget = 5 * time.Second
+
+// cacheWarmUserAgent identifies warming requests in access logs.
+const cacheWarmUserAgent = "gobase-cache-warmer"
+
+// cacheWarmKey marks the context of warming requests, which carry no Host
+// and are exempt from server.allowed_hosts (see isCacheWarm).
+type cacheWarmKey struct{}
+
+// isCacheWarm is the ginx.Condition for requests made by a cacheWarmer.
+// Unlike the User-Agent, the marker cannot come from a client.
+func isCacheWarm(c *gin.Context) bool {
+	return c.Request.Context().Value(cacheWarmKey{}) != nil
+}
+
+// cacheWarmer populates the response cache by replaying the configured
+// server.cache.warm targets against the app's own handler, so the requests
+// pass through the same middleware chain (including ginx.Cache) as real
+// traffic. Warming is best-effort: failures are logged and never returned.
+type cacheWarmer struct {
+	handler http.Handler
+	targets []config.CacheWarmTarget
+	budget  time.Duration
+	log     *slog.Logger
+
+	mu      sync.Mutex
+	closed  bool
+	running map[string]bool // prefix -> re-run requested while running
+	wg      sync.WaitGroup
+}
+
+func newCacheWarmer(handler http.Handler, cfg *config.CacheConfig, log *slog.Logger) *cacheWarmer {
+	budget := defaultCacheWarmBudget
+	if cfg.WarmBudget.IsSet() {
+		budget = cfg.WarmBudget.Std()
+	}
+	return &cacheWarmer{
+		handler: handler,
+		targets: cfg.Warm,
+		budget:  budget,
+		log:     log,
+		running: make(map[string]bool),
+	}
+}
+
+// warmAtStartup warms every target, waiting at most the configured budget.
+// Targets not yet replayed when the budget runs out are skipped rather than
+// delaying startup further.
+func (w *cacheWarmer) warmAtStartup() {
+	if w == nil || len(w.targets) == 0 {
+		return
+	}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), w.budget)
+	defer cancel()
+	done := make(chan int, 1)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		done <- w.warm(ctx, "")
+	}()
+
+	select {
+	case warmed := <-done:
+		w.log.Info("response cache warmed",
+			slog.Int("warmed", warmed),
+			slog.Int("targets", len(w.targets)),
+			slog.Duration("duration", time.Since(start)),
+		)
+	case <-ctx.Done():
+		w.log.Warn("response cache warming exceeded budget, skipping remaining targets",
+			slog.Duration("budget", w.budget),
+		)
+	}
+}
+
+// rewarm asynchronously re-warms the targets under prefix after it was
+// purged. A rewarm requested while one for the same prefix is running is
+// coalesced into a single re-run afterwards, so a write that lands mid-warm
+// never leaves a stale entry behind.
+func (w *cacheWarmer) rewarm(prefix string) {
+	if w == nil || !w.hasTargets(prefix) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if _, running := w.running[prefix]; running {
+		w.running[prefix] = true
+		return
+	}
+	w.running[prefix] = false
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), w.budget)
+			w.warm(ctx, prefix)
+			cancel()
+
+			w.mu.Lock()
+			again := w.running[prefix] && !w.closed
+			if !again {
+				delete(w.running, prefix)
+			} else {
+				w.running[prefix] = false
+			}
+			w.mu.Unlock()
+			if !again {
+				return
+			}
+		}
+	}()
+}
+
+// close stops new rewarms and waits for in-flight warming, so it must run
+// before the cache and database are released.
+func (w *cacheWarmer) close() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.wg.Wait()
+}
+
+func (w *cacheWarmer) hasTargets(prefix string) bool {
+	for _, target := range w.targets {
+		if strings.HasPrefix(target.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// warm replays the targets whose path starts with prefix ("" for all) and
+// returns how many produced a 2xx response. It stops when ctx is done.
+func (w *cacheWarmer) warm(ctx context.Context, prefix string) int {
+	warmed := 0
+	for _, target := range w.targets {
+		if !strings.HasPrefix(target.Path, prefix) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return warmed
+		}
+
+		uri := target.Path
+		if target.Query != "" {
+			uri += "?" + target.Query
+		}
+		req, err := http.NewRequestWithContext(context.WithValue(ctx, cacheWarmKey{}, true), http.MethodGet, uri, nil)
+		if err != nil {
+			w.log.Warn("cache warm request invalid", slog.String("path", uri), slog.Any("error", err))
+			continue
+		}
+		req.RemoteAddr = "127.0.0.1:0"
+		req.Header.Set("User-Agent", cacheWarmUserAgent)
+
+		rec := httptest.NewRecorder()
+		w.handler.ServeHTTP(rec, req)
+		if rec.Code < 200 || rec.Code >= 300 {
+			w.log.Warn("cache warm request failed", slog.String("path", uri), slog.Int("status", rec.Code))
+			continue
+		}
+		warmed++
+	}
+	return warmed
+}
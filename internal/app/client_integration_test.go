@@ -0,0 +1,167 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/simp-lee/gobase/client"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/internal/testutil/apptest"
+)
+
+// TestClient_Integration drives the real engine built by New through the
+// public client package: register, login, full users CRUD, and token refresh.
+func TestClient_Integration(t *testing.T) {
+	a := apptest.NewTestApp(t, testutil.WithAuth())
+
+	srv := httptest.NewServer(a.Handler())
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, client.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Protected routes reject anonymous callers.
+	if _, err := c.Users.List(ctx, client.ListParams{}); !client.IsUnauthorized(err) {
+		t.Fatalf("List without token: expected unauthorized, got %v", err)
+	}
+
+	// Register and log in.
+	registered, err := c.Auth.Register(ctx, client.RegisterRequest{
+		Name:     "Client Owner",
+		Email:    "client-owner@example.com",
+		Password: "secret1234",
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if registered == nil || registered.ID == 0 {
+		t.Fatalf("Register() returned %+v, want a user with an ID", registered)
+	}
+
+	if _, err := c.Auth.Login(ctx, "client-owner@example.com", "wrong-password"); !client.IsUnauthorized(err) {
+		t.Fatalf("Login with wrong password: expected unauthorized, got %v", err)
+	}
+	tok, err := c.Auth.Login(ctx, "client-owner@example.com", "secret1234")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if tok.FailedAttempts == nil || tok.FailedAttempts.Count > 1 {
+		t.Errorf("Login() failed attempts = %+v, want a summary of at most the one failure", tok.FailedAttempts)
+	}
+	c.SetToken(tok.Token)
+
+	// Create.
+	created, err := c.Users.Create(ctx, client.CreateUserRequest{
+		Name:  "Client Alice",
+		Email: "client-alice@example.com",
+		Bio:   "hello",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.ID == 0 || created.Name != "Client Alice" || created.Bio != "hello" {
+		t.Fatalf("Create() returned %+v", created)
+	}
+
+	// Duplicate and invalid input surface typed errors.
+	_, err = c.Users.Create(ctx, client.CreateUserRequest{Name: "Client Alice", Email: "client-alice@example.com"})
+	if !client.IsConflict(err) {
+		t.Errorf("duplicate Create: expected conflict, got %v", err)
+	}
+	_, err = c.Users.Create(ctx, client.CreateUserRequest{Name: "X", Email: "not-an-email"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || !client.IsValidation(err) {
+		t.Fatalf("invalid Create: expected validation APIError, got %v", err)
+	}
+	if apiErr.Errors["email"] == "" || apiErr.Errors["name"] == "" {
+		t.Errorf("invalid Create: expected field errors for name and email, got %v", apiErr.Errors)
+	}
+
+	// Get.
+	got, err := c.Users.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Email != "client-alice@example.com" {
+		t.Errorf("Get() email = %q", got.Email)
+	}
+
+	// List.
+	page, err := c.Users.List(ctx, client.ListParams{
+		PageSize: 5,
+		Filter:   map[string]string{"email": "client-alice@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if page.TotalItems != 1 || len(page.Items) != 1 || page.Items[0].ID != created.ID {
+		t.Errorf("List() = %+v, want exactly the created user", page)
+	}
+	if page.ItemsPerPage != 5 {
+		t.Errorf("List() items_per_page = %d, want 5", page.ItemsPerPage)
+	}
+
+	// Update.
+	updated, err := c.Users.Update(ctx, created.ID, client.UpdateUserRequest{
+		Name:  "Client Alice Updated",
+		Email: "client-alice@example.com",
+		Bio:   "updated",
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Name != "Client Alice Updated" || updated.Bio != "updated" {
+		t.Errorf("Update() returned %+v", updated)
+	}
+
+	// Delete, then the user is gone.
+	if err := c.Users.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := c.Users.Get(ctx, created.ID); !client.IsNotFound(err) {
+		t.Errorf("Get after Delete: expected not found, got %v", err)
+	}
+
+	// Refresh issues a new token and revokes the old one.
+	oldToken := c.Token()
+	refreshed, err := c.Auth.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if refreshed.Token == "" || refreshed.Token == oldToken {
+		t.Fatalf("Refresh() returned token %q, want a new token", refreshed.Token)
+	}
+	if _, err := c.Users.List(ctx, client.ListParams{}); !client.IsUnauthorized(err) {
+		t.Errorf("List with revoked token: expected unauthorized, got %v", err)
+	}
+	c.SetToken(refreshed.Token)
+	if _, err := c.Users.List(ctx, client.ListParams{}); err != nil {
+		t.Errorf("List with refreshed token: %v", err)
+	}
+}
+
+// TestAuthenticatedRequest_ReachesProtectedRoute shows the apptest harness
+// end to end: a seeded user fetched through the auth middleware with a token
+// minted by the app's own JWT service.
+func TestAuthenticatedRequest_ReachesProtectedRoute(t *testing.T) {
+	a := apptest.NewTestApp(t, testutil.WithAuth())
+	users := testutil.SeedUsers(t, a.DB(), domain.User{Name: "Seeded"})
+
+	w := testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodGet, fmt.Sprintf("/api/v1/users/%d", users[0].ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("authenticated GET: status = %d, want %d; body = %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	w = testutil.Serve(a.Handler(), testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous GET: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
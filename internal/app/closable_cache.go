@@ -0,0 +1,149 @@
+package app
+
+import (
+	"sync/atomic"
+	"time"
+
+	cache "github.com/simp-lee/cache"
+)
+
+// closableCache wraps the App's cache stores so the requests still running
+// when Close releases them, past the shutdown deadline, cannot fail on
+// them: after Close every lookup misses, writes are dropped and Close
+// itself does nothing, where the store would panic on a second Close. An
+// operation racing Close may still reach the store, which tolerates it.
+// A nil *closableCache behaves as a closed one, but for Group.
+type closableCache struct {
+	store  cache.CacheInterface
+	closed atomic.Bool
+}
+
+var _ cache.CacheInterface = (*closableCache)(nil)
+
+// newClosableCache wraps store; it returns nil for a nil store, so the
+// result can stand in for an optional store's interface value.
+func newClosableCache(store cache.CacheInterface) cache.CacheInterface {
+	if store == nil {
+		return nil
+	}
+	return &closableCache{store: store}
+}
+
+func (c *closableCache) open() bool { return c != nil && !c.closed.Load() }
+
+func (c *closableCache) Set(key string, value any) {
+	if c.open() {
+		c.store.Set(key, value)
+	}
+}
+
+func (c *closableCache) SetWithExpiration(key string, value any, expiration time.Duration) {
+	if c.open() {
+		c.store.SetWithExpiration(key, value, expiration)
+	}
+}
+
+func (c *closableCache) Get(key string) (any, bool) {
+	if !c.open() {
+		return nil, false
+	}
+	return c.store.Get(key)
+}
+
+func (c *closableCache) GetWithExpiration(key string) (any, time.Time, bool) {
+	if !c.open() {
+		return nil, time.Time{}, false
+	}
+	return c.store.GetWithExpiration(key)
+}
+
+func (c *closableCache) Delete(key string) bool {
+	return c.open() && c.store.Delete(key)
+}
+
+func (c *closableCache) DeleteKeys(keys []string) int {
+	if !c.open() {
+		return 0
+	}
+	return c.store.DeleteKeys(keys)
+}
+
+func (c *closableCache) DeletePrefix(prefix string) int {
+	if !c.open() {
+		return 0
+	}
+	return c.store.DeletePrefix(prefix)
+}
+
+// GetOrSet returns value without storing it once closed.
+func (c *closableCache) GetOrSet(key string, value any) any {
+	if !c.open() {
+		return value
+	}
+	return c.store.GetOrSet(key, value)
+}
+
+// GetOrSetFunc returns f's value without storing it once closed.
+func (c *closableCache) GetOrSetFunc(key string, f func() any) any {
+	if !c.open() {
+		return f()
+	}
+	return c.store.GetOrSetFunc(key, f)
+}
+
+func (c *closableCache) GetOrSetFuncWithExpiration(key string, f func() any, expiration time.Duration) any {
+	if !c.open() {
+		return f()
+	}
+	return c.store.GetOrSetFuncWithExpiration(key, f, expiration)
+}
+
+func (c *closableCache) Stats() map[string]any {
+	if !c.open() {
+		return map[string]any{}
+	}
+	return c.store.Stats()
+}
+
+func (c *closableCache) OnEvicted(f func(key string, value any)) {
+	if c.open() {
+		c.store.OnEvicted(f)
+	}
+}
+
+func (c *closableCache) Keys() []string {
+	if !c.open() {
+		return nil
+	}
+	return c.store.Keys()
+}
+
+func (c *closableCache) Count() int {
+	if !c.open() {
+		return 0
+	}
+	return c.store.Count()
+}
+
+func (c *closableCache) Has(key string) bool {
+	return c.open() && c.store.Has(key)
+}
+
+func (c *closableCache) Clear() {
+	if c.open() {
+		c.store.Clear()
+	}
+}
+
+// Close closes the store the first time it is called.
+func (c *closableCache) Close() {
+	if c != nil && c.closed.CompareAndSwap(false, true) {
+		c.store.Close()
+	}
+}
+
+// Group returns a group of the store. Groups are not guarded; the App
+// uses none.
+func (c *closableCache) Group(name string) cache.Group {
+	return c.store.Group(name)
+}
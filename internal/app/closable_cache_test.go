@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/simp-lee/cache"
+)
+
+func TestClosableCache(t *testing.T) {
+	c := newClosableCache(cache.NewCache(cache.Options{CleanupInterval: time.Hour}))
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %v, %v; want 1, true", v, ok)
+	}
+
+	c.Close()
+	c.Close() // the store would panic
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Get(a) after Close hit, want a miss")
+	}
+	if c.Has("b") || c.Count() != 0 || c.Delete("a") {
+		t.Error("closed cache reports entries")
+	}
+	if got := c.GetOrSetFunc("c", func() any { return 3 }); got != 3 {
+		t.Errorf("GetOrSetFunc() after Close = %v, want the computed 3", got)
+	}
+
+	var nilCache *closableCache
+	nilCache.Set("a", 1)
+	if _, ok := nilCache.Get("a"); ok {
+		t.Error("nil cache Get hit")
+	}
+	nilCache.Close()
+	if newClosableCache(nil) != nil {
+		t.Error("newClosableCache(nil) != nil")
+	}
+}
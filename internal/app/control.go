@@ -0,0 +1,292 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	cache "github.com/simp-lee/cache"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// controlActor is the user_id the runtime config audit log records for
+// changes made through the control socket.
+const controlActor = "control-socket"
+
+// controlUsersPageSize is how many users one "users list" page shows.
+const controlUsersPageSize = 20
+
+// controlTimeout bounds one control command, client and server side.
+const controlTimeout = 10 * time.Second
+
+// controlAPI is the command API App.Run serves on server.control_socket.path:
+// plain JSON over HTTP on a 0600 unix socket, for `server -ctl` (see
+// Control). File permissions are its only access control, so it is never
+// served on TCP.
+//
+//   - GET  /users?page=N          a page of users
+//   - POST /cache/purge?prefix=P  drop cached responses under P
+//   - GET  /maintenance           whether the maintenance job is paused
+//   - PUT  /maintenance?paused=B  pause or resume it
+type controlAPI struct {
+	users   domain.UserService
+	cache   cache.CacheInterface // nil without server.cache
+	onPurge func(prefix string)  // re-warms a purged prefix; may be nil
+	live    *runtimeConfig
+	log     *slog.Logger
+
+	path string
+	srv  *http.Server
+}
+
+// controlUser is one user as listed by the control API.
+type controlUser struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// controlUserPage is the response of GET /users.
+type controlUserPage struct {
+	Users      []controlUser `json:"users"`
+	Page       int           `json:"page"`
+	TotalPages int           `json:"total_pages"`
+	TotalUsers int64         `json:"total_users"`
+}
+
+// controlPurge is the response of POST /cache/purge.
+type controlPurge struct {
+	Prefix  string `json:"prefix"`
+	Deleted int    `json:"deleted"`
+}
+
+// controlMaintenance is the response of GET and PUT /maintenance.
+type controlMaintenance struct {
+	Paused bool `json:"paused"`
+}
+
+func (c *controlAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", c.listUsers)
+	mux.HandleFunc("POST /cache/purge", c.purgeCache)
+	mux.HandleFunc("GET /maintenance", func(w http.ResponseWriter, _ *http.Request) {
+		writeControl(w, http.StatusOK, controlMaintenance{Paused: c.live.settings().Maintenance.Paused})
+	})
+	mux.HandleFunc("PUT /maintenance", c.setMaintenance)
+	return mux
+}
+
+func (c *controlAPI) listUsers(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeControlError(w, http.StatusBadRequest, "page must be a positive integer")
+			return
+		}
+		page = n
+	}
+	result, err := c.users.ListUsers(r.Context(), domain.PageRequest{Page: page, PageSize: controlUsersPageSize})
+	if err != nil {
+		c.log.Error("control: list users failed", slog.Any("error", err))
+		writeControlError(w, http.StatusInternalServerError, "list users failed")
+		return
+	}
+	resp := controlUserPage{
+		Users:      make([]controlUser, 0, len(result.Items)),
+		Page:       result.CurrentPage,
+		TotalPages: result.TotalPages,
+		TotalUsers: result.TotalItems,
+	}
+	for _, u := range result.Items {
+		resp.Users = append(resp.Users, controlUser{ID: u.ID, Name: u.Name, Email: u.Email})
+	}
+	writeControl(w, http.StatusOK, resp)
+}
+
+// purgeCache drops the cached GET and HEAD responses under prefix, like a
+// write through the API does (see middleware.CacheInvalidation).
+func (c *controlAPI) purgeCache(w http.ResponseWriter, r *http.Request) {
+	if c.cache == nil {
+		writeControlError(w, http.StatusConflict, "response cache is disabled (server.cache.enabled)")
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	if !strings.HasPrefix(prefix, "/") {
+		writeControlError(w, http.StatusBadRequest, "prefix must be a path starting with /")
+		return
+	}
+	deleted := c.cache.DeletePrefix(middleware.ResponseCacheKey(http.MethodGet, prefix, "")) +
+		c.cache.DeletePrefix(middleware.ResponseCacheKey(http.MethodHead, prefix, ""))
+	if c.onPurge != nil {
+		c.onPurge(prefix)
+	}
+	c.log.Info("control: response cache purged", slog.String("prefix", prefix), slog.Int("deleted", deleted))
+	writeControl(w, http.StatusOK, controlPurge{Prefix: prefix, Deleted: deleted})
+}
+
+// setMaintenance pauses or resumes the maintenance job through the runtime
+// config, so the change is audit-logged and shows on the runtime config API.
+func (c *controlAPI) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	paused, err := strconv.ParseBool(r.URL.Query().Get("paused"))
+	if err != nil {
+		writeControlError(w, http.StatusBadRequest, "paused must be true or false")
+		return
+	}
+	var patch runtimeConfigPatch
+	patch.Maintenance.Paused = pkg.Some(paused)
+	resp, err := c.live.update(patch, controlActor)
+	if err != nil {
+		writeControlError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeControl(w, http.StatusOK, controlMaintenance{Paused: resp.Effective.Maintenance.Paused})
+}
+
+func writeControl(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, status int, message string) {
+	writeControl(w, status, map[string]string{"error": message})
+}
+
+// listen serves the control API on the unix socket at c.path, readable and
+// writable by the owner only. A stale socket from a crashed process is
+// removed first; a live one, or any other file at the path, is an error.
+func (c *controlAPI) listen() error {
+	if info, err := os.Lstat(c.path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return fmt.Errorf("%s exists and is not a socket", c.path)
+		}
+		if conn, err := net.DialTimeout("unix", c.path, time.Second); err == nil {
+			_ = conn.Close()
+			return fmt.Errorf("%s is in use by another process", c.path)
+		}
+		if err := os.Remove(c.path); err != nil {
+			return fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	ln, err := net.Listen("unix", c.path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(c.path, 0o600); err != nil {
+		_ = ln.Close()
+		return fmt.Errorf("chmod %s: %w", c.path, err)
+	}
+	c.srv = &http.Server{
+		Handler:           http.TimeoutHandler(c.handler(), controlTimeout, `{"error":"timeout"}`),
+		ReadHeaderTimeout: controlTimeout,
+	}
+	go func() {
+		if err := c.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			c.log.Error("control socket stopped", slog.Any("error", err))
+		}
+	}()
+	c.log.Info("control socket listening", slog.String("path", c.path))
+	return nil
+}
+
+// shutdown stops the control server and removes the socket file.
+func (c *controlAPI) shutdown(ctx context.Context) {
+	if c == nil || c.srv == nil {
+		return
+	}
+	if err := c.srv.Shutdown(ctx); err != nil {
+		c.log.Error("control socket shutdown error", slog.Any("error", err))
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.log.Error("remove control socket", slog.Any("error", err))
+	}
+}
+
+// Control runs one operator command against the control socket at
+// socketPath (server.control_socket.path of a running server) and returns
+// the JSON result. Commands are space-separated words:
+//
+//	users list [page]
+//	cache purge <path prefix>     e.g. cache purge /api/v1/users
+//	maintenance status|pause|resume
+func Control(socketPath, command string) (json.RawMessage, error) {
+	method, target, err := parseControlCommand(strings.Fields(command))
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: controlTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := http.NewRequest(method, "http://control"+target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connect to control socket: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read control response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(body))
+		}
+		return nil, fmt.Errorf("%s: %s", command, e.Error)
+	}
+	return body, nil
+}
+
+// controlUsage lists the commands Control accepts.
+const controlUsage = `commands: "users list [page]", "cache purge <prefix>", "maintenance status|pause|resume"`
+
+// parseControlCommand maps command words to the control API request.
+func parseControlCommand(words []string) (method, target string, err error) {
+	if len(words) < 2 {
+		return "", "", fmt.Errorf("incomplete command; %s", controlUsage)
+	}
+	switch cmd, args := words[0]+" "+words[1], words[2:]; {
+	case cmd == "users list" && len(args) <= 1:
+		if len(args) == 1 {
+			return http.MethodGet, "/users?page=" + url.QueryEscape(args[0]), nil
+		}
+		return http.MethodGet, "/users", nil
+	case cmd == "cache purge" && len(args) == 1:
+		return http.MethodPost, "/cache/purge?prefix=" + url.QueryEscape(args[0]), nil
+	case cmd == "maintenance status" && len(args) == 0:
+		return http.MethodGet, "/maintenance", nil
+	case cmd == "maintenance pause" && len(args) == 0:
+		return http.MethodPut, "/maintenance?paused=true", nil
+	case cmd == "maintenance resume" && len(args) == 0:
+		return http.MethodPut, "/maintenance?paused=false", nil
+	}
+	return "", "", fmt.Errorf("unknown command %q; %s", strings.Join(words, " "), controlUsage)
+}
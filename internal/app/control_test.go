@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// runControlApp runs an App with the control socket at a temporary path
+// and the response cache enabled until the test ends. It returns the app
+// and the socket path.
+func runControlApp(t *testing.T) (*App, string) {
+	t.Helper()
+	originalNewHTTPServer := newHTTPServer
+	originalNotifyContext := notifyContext
+	t.Cleanup(func() {
+		newHTTPServer = originalNewHTTPServer
+		notifyContext = originalNotifyContext
+	})
+	server := testutil.NewFakeHTTPServer()
+	newHTTPServer = func(string, http.Handler, config.HTTPConfig) httpServer { return server }
+	ctx, cancel := context.WithCancel(context.Background())
+	notifyContext = func(context.Context, ...os.Signal) (context.Context, context.CancelFunc) {
+		return ctx, cancel
+	}
+
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.Port = 0
+		c.Server.ControlSocket.Path = path
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	testutil.Migrate(t, a.DB())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- a.Run() }()
+	select {
+	case <-server.Started():
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not start in time")
+	}
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Run() did not return after shutdown")
+		}
+		if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("socket file after shutdown: Lstat error = %v, want not exist", err)
+		}
+	})
+	return a, path
+}
+
+func TestControlSocket_Commands(t *testing.T) {
+	a, path := runControlApp(t)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat(socket) error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %v, want 0600", perm)
+	}
+
+	t.Run("users list", func(t *testing.T) {
+		testutil.SeedUsers(t, a.DB(), domain.User{Name: "Ctl", Email: "ctl@example.com"})
+		out, err := Control(path, "users list")
+		if err != nil {
+			t.Fatalf("Control() error = %v", err)
+		}
+		var page controlUserPage
+		if err := json.Unmarshal(out, &page); err != nil {
+			t.Fatalf("unmarshal %s: %v", out, err)
+		}
+		if page.TotalUsers != 1 || len(page.Users) != 1 || page.Users[0].Email != "ctl@example.com" {
+			t.Errorf("users list = %s, want the seeded user", out)
+		}
+	})
+
+	t.Run("cache purge", func(t *testing.T) {
+		if w := testutil.Serve(a.Handler(), testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users", nil)); w.Code != http.StatusOK {
+			t.Fatalf("GET /api/v1/users = %d", w.Code)
+		}
+		key := middleware.ResponseCacheKey(http.MethodGet, "/api/v1/users", "")
+		if !hasKeyWithPrefix(a, key) {
+			t.Fatalf("no cache entry under %q after GET", key)
+		}
+		out, err := Control(path, "cache purge /api/v1/users")
+		if err != nil {
+			t.Fatalf("Control() error = %v", err)
+		}
+		if hasKeyWithPrefix(a, key) {
+			t.Errorf("cache entry under %q survived the purge (%s)", key, out)
+		}
+		if !strings.Contains(string(out), `"deleted":1`) {
+			t.Errorf("cache purge = %s, want deleted 1", out)
+		}
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		if _, err := Control(path, "maintenance pause"); err != nil {
+			t.Fatalf("pause: %v", err)
+		}
+		if !a.maintenance.paused.Load() {
+			t.Error("maintenance not paused after pause")
+		}
+		out, err := Control(path, "maintenance status")
+		if err != nil || !strings.Contains(string(out), `"paused":true`) {
+			t.Errorf("status = %s, %v; want paused", out, err)
+		}
+		if _, err := Control(path, "maintenance resume"); err != nil {
+			t.Fatalf("resume: %v", err)
+		}
+		if a.maintenance.paused.Load() {
+			t.Error("maintenance still paused after resume")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, cmd := range []string{"", "users", "users delete 1", "cache purge", "maintenance stop"} {
+			if _, err := Control(path, cmd); err == nil || !strings.Contains(err.Error(), "commands:") {
+				t.Errorf("Control(%q) error = %v, want usage", cmd, err)
+			}
+		}
+		if _, err := Control(path, "cache purge users"); err == nil || !strings.Contains(err.Error(), "prefix must be a path") {
+			t.Errorf("Control(cache purge users) error = %v, want the server's message", err)
+		}
+	})
+}
+
+func TestControlSocket_RefusesNonSocketFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	if err := os.WriteFile(path, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := &controlAPI{path: path}
+	if err := c.listen(); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Fatalf("listen() error = %v, want not a socket", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "keep" {
+		t.Error("listen() touched the existing file")
+	}
+}
+
+func TestControl_UnreachableSocket(t *testing.T) {
+	_, err := Control(filepath.Join(t.TempDir(), "missing.sock"), "maintenance status")
+	if err == nil || !strings.Contains(err.Error(), "connect to control socket") {
+		t.Fatalf("Control() error = %v, want connect error", err)
+	}
+}
+
+func hasKeyWithPrefix(a *App, prefix string) bool {
+	for _, k := range a.cache.Keys() {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
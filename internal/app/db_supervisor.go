@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+const dbSupervisorPluginName = "gobase:db_supervisor"
+
+// errDatabaseUnavailable is what statements fail with while the supervisor
+// has the database marked unavailable.
+var errDatabaseUnavailable = domain.NewAppError(domain.CodeUnavailable, "database unavailable", nil)
+
+// dbSupervisor pings the primary database every interval (database.supervisor).
+// After threshold pings in a row fail it marks the database unavailable:
+// every statement then fails at once with errDatabaseUnavailable, which
+// repositories pass on as a 503, instead of each request waiting on a
+// broken connection, and the "database_connection" health component
+// reports an error. The first successful ping clears the mark. Only the
+// changes are logged, not each failed ping. It is owned by App and also
+// installed on the database as a gorm.Plugin.
+type dbSupervisor struct {
+	ping      func(context.Context) error
+	interval  time.Duration
+	threshold int
+	log       *slog.Logger
+	clock     pkg.Clock
+
+	down atomic.Bool
+
+	// Only the loop goroutine touches these.
+	failures  int
+	downSince time.Time
+
+	ctx       context.Context // cancelled by close to abort a ping in flight
+	cancel    context.CancelFunc
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newDBSupervisor(ping func(context.Context) error, interval time.Duration, threshold int, log *slog.Logger, clock pkg.Clock) *dbSupervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dbSupervisor{
+		ping:      ping,
+		interval:  interval,
+		threshold: threshold,
+		log:       log,
+		clock:     clock,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// Name implements gorm.Plugin.
+func (s *dbSupervisor) Name() string { return dbSupervisorPluginName }
+
+// Initialize implements gorm.Plugin.
+func (s *dbSupervisor) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("gobase:db_supervisor_create", s.failFast); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("gobase:db_supervisor_query", s.failFast); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("gobase:db_supervisor_update", s.failFast); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("gobase:db_supervisor_delete", s.failFast); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register("gobase:db_supervisor_row", s.failFast); err != nil {
+		return err
+	}
+	return cb.Raw().Before("*").Register("gobase:db_supervisor_raw", s.failFast)
+}
+
+// failFast stops a statement before it reaches the database while the
+// database is marked unavailable. gorm skips the remaining callbacks of a
+// statement that has an error.
+func (s *dbSupervisor) failFast(db *gorm.DB) {
+	if s.down.Load() {
+		_ = db.AddError(errDatabaseUnavailable)
+	}
+}
+
+// start launches the ping loop.
+func (s *dbSupervisor) start() {
+	if s == nil {
+		return
+	}
+	s.started = true
+	go s.loop()
+}
+
+func (s *dbSupervisor) loop() {
+	defer close(s.done)
+	timer := s.clock.NewTimer(s.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			s.check()
+			timer.Reset(s.interval)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// check pings once and logs a change of state.
+func (s *dbSupervisor) check() {
+	ctx, cancel := context.WithTimeout(s.ctx, s.interval)
+	err := s.ping(ctx)
+	cancel()
+	if s.ctx.Err() != nil {
+		return
+	}
+	if err == nil {
+		s.failures = 0
+		if s.down.CompareAndSwap(true, false) {
+			s.log.Info("database reachable again",
+				slog.Duration("outage", s.clock.Now().Sub(s.downSince)))
+		}
+		return
+	}
+	s.failures++
+	if s.failures >= s.threshold && !s.down.Load() {
+		s.downSince = s.clock.Now()
+		s.down.Store(true)
+		s.log.Error("database unreachable, failing statements fast until it recovers",
+			slog.Int("failed_pings", s.failures), slog.Any("error", err))
+	}
+}
+
+// healthCheck reports the supervisor's state as the critical
+// "database_connection" component.
+func (s *dbSupervisor) healthCheck() pkg.HealthChecker {
+	return pkg.NewHealthCheck("database_connection", true, func(context.Context) error {
+		if s.down.Load() {
+			return errors.New("database marked unavailable")
+		}
+		return nil
+	})
+}
+
+// close stops the loop, aborting a ping in flight, and waits for it to
+// return.
+func (s *dbSupervisor) close() {
+	if s == nil {
+		return
+	}
+	s.closeOnce.Do(func() {
+		s.cancel()
+		if s.started {
+			<-s.done
+		}
+	})
+}
+
+// pingPrimary pings db's primary connection pool.
+func pingPrimary(db *gorm.DB) func(context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("database handle: %w", err)
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
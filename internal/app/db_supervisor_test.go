@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/user"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// syncBuffer is a bytes.Buffer the supervisor goroutine can log to while
+// the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDBSupervisor_FailsFastWhileDownAndRecovers(t *testing.T) {
+	const interval = 5 * time.Second
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, domain.User{})
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ping fails while offline is set, as it would with the server gone.
+	var offline atomic.Bool
+	ping := func(ctx context.Context) error {
+		if offline.Load() {
+			return errors.New("connection refused")
+		}
+		return sqlDB.PingContext(ctx)
+	}
+
+	logs := &syncBuffer{}
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newDBSupervisor(ping, interval, 2, slog.New(slog.NewTextHandler(logs, nil)), clock)
+	if err := db.Use(s); err != nil {
+		t.Fatalf("db.Use() error = %v", err)
+	}
+	s.start()
+	t.Cleanup(s.close)
+	tick := func() {
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		clock.BlockUntil(1) // the loop re-arms its timer once the check is done
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users", user.NewUserHandler(user.NewUserService(user.NewUserRepository(db))).List)
+	health := healthHandler([]pkg.HealthChecker{s.healthCheck()}, time.Second, nil, nil, true)
+	r.GET("/health", health)
+
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users", nil)); w.Code != http.StatusOK {
+		t.Fatalf("GET /users while up = %d, want 200", w.Code)
+	}
+
+	offline.Store(true)
+	tick()
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users", nil)); w.Code != http.StatusOK {
+		t.Errorf("GET /users after one failed ping = %d, want 200 below the threshold", w.Code)
+	}
+	tick()
+
+	start := time.Now()
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /users while down = %d %s, want 503", w.Code, w.Body)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("GET /users while down took %v, want an immediate failure", elapsed)
+	}
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil)); w.Code != http.StatusServiceUnavailable ||
+		!strings.Contains(w.Body.String(), `"database_connection":"error"`) {
+		t.Errorf("GET /health while down = %d %s, want 503 with database_connection error", w.Code, w.Body)
+	}
+	tick() // a third failure is not logged again
+	if got := strings.Count(logs.String(), "database unreachable"); got != 1 {
+		t.Errorf("logged the outage %d times, want once:\n%s", got, logs)
+	}
+
+	offline.Store(false)
+	tick()
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users", nil)); w.Code != http.StatusOK {
+		t.Errorf("GET /users after recovery = %d, want 200", w.Code)
+	}
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil)); w.Code != http.StatusOK {
+		t.Errorf("GET /health after recovery = %d, want 200", w.Code)
+	}
+	if out := logs.String(); !strings.Contains(out, "database reachable again") || !strings.Contains(out, "outage=10s") {
+		t.Errorf("logs = %q, want the recovery with a 10s outage", out)
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import "github.com/simp-lee/gobase/internal/pkg"
+
+// ErasureProvider is implemented by modules that store records about users.
+// Erasing a user through the privacy module deletes the records of every
+// module's stores, and the user's data export includes them.
+type ErasureProvider interface {
+	Erasables() []pkg.Erasable
+}
+
+// collectErasables returns the stores of each module that declares some.
+func collectErasables(modules []Module) []pkg.Erasable {
+	var stores []pkg.Erasable
+	for _, m := range modules {
+		if p, ok := m.(ErasureProvider); ok {
+			stores = append(stores, p.Erasables()...)
+		}
+	}
+	return stores
+}
+
+// DependentProvider is implemented by modules whose tables reference users
+// through a foreign key. Deleting a user is refused with a conflict while
+// rows of a table whose key restricts deletes reference them.
+type DependentProvider interface {
+	UserDependents() []pkg.UserDependent
+}
+
+// collectUserDependents returns the dependent tables of each module that
+// declares some, each once.
+func collectUserDependents(modules []Module) []pkg.UserDependent {
+	var deps []pkg.UserDependent
+	seen := make(map[string]bool)
+	for _, m := range modules {
+		p, ok := m.(DependentProvider)
+		if !ok {
+			continue
+		}
+		for _, d := range p.UserDependents() {
+			if seen[d.Name] {
+				continue
+			}
+			seen[d.Name] = true
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
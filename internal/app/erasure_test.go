@@ -0,0 +1,111 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/privacy"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/internal/testutil/apptest"
+)
+
+// TestErasure_ExportThenErase creates a user through the API, which writes
+// a change feed entry, adds a notification and login attempts, and checks
+// that the export lists every store's records and that erasure leaves only
+// the receipt behind.
+func TestErasure_ExportThenErase(t *testing.T) {
+	a := apptest.NewTestApp(t, testutil.WithAuth())
+	testutil.SeedUsers(t, a.DB(), domain.User{Name: "Admin", Email: "admin@example.com"})
+
+	w := testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodPost, "/api/v1/users",
+		map[string]string{"name": "Erin", "email": "erin@example.com"}))
+	var created struct {
+		Data domain.User `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", w.Code, w.Body.String())
+	}
+	erin := created.Data
+	for _, r := range []any{
+		&domain.Notification{UserID: erin.ID, Type: "test", Title: "welcome"},
+		&domain.LoginAttempt{UserID: &erin.ID, Email: erin.Email, Success: true},
+		&domain.LoginAttempt{Email: erin.Email},
+	} {
+		if err := a.DB().Create(r).Error; err != nil {
+			t.Fatalf("seed %T: %v", r, err)
+		}
+	}
+
+	w = testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/export", erin.ID), nil))
+	var export struct {
+		Data struct {
+			User    domain.User                 `json:"user"`
+			Records map[string][]map[string]any `json:"records"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if export.Data.User.Email != erin.Email {
+		t.Errorf("export user = %+v, want erin", export.Data.User)
+	}
+	for name, n := range map[string]int{"notifications": 1, "login_attempts": 2, "changes": 1} {
+		if got := len(export.Data.Records[name]); got != n {
+			t.Errorf("export %s = %d records, want %d", name, got, n)
+		}
+	}
+	if changes := export.Data.Records["changes"]; len(changes) == 1 && changes[0]["payload"] == nil {
+		t.Errorf("exported change %v, want its decoded payload", changes[0])
+	}
+
+	w = testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/erase", erin.ID), nil))
+	var receipt struct {
+		Data domain.ErasureReceipt `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &receipt); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("erase status = %d, body = %s", w.Code, w.Body.String())
+	}
+	wantHash := privacy.SubjectHash(privacy.SubjectKey(testutil.TestJWTSecret), erin.ID)
+	if receipt.Data.ErasedBy != strconv.FormatUint(uint64(apptest.DefaultUserID), 10) || receipt.Data.SubjectHash != wantHash {
+		t.Errorf("receipt = %+v, want one by the caller with subject hash %s", receipt.Data, wantHash)
+	}
+
+	erinID := strconv.FormatUint(uint64(erin.ID), 10)
+	for _, c := range []struct {
+		model any
+		query string
+		args  []any
+	}{
+		{&domain.User{}, "id = ? OR email = ?", []any{erin.ID, erin.Email}},
+		{&domain.Notification{}, "user_id = ?", []any{erin.ID}},
+		{&domain.LoginAttempt{}, "user_id = ? OR email = ?", []any{erin.ID, erin.Email}},
+		{&domain.Change{}, "entity_type = ? AND entity_id = ?", []any{"users", erinID}},
+	} {
+		var n int64
+		if err := a.DB().Model(c.model).Where(c.query, c.args...).Count(&n).Error; err != nil {
+			t.Fatalf("count %T: %v", c.model, err)
+		}
+		if n != 0 {
+			t.Errorf("%T rows of the erased user = %d, want 0", c.model, n)
+		}
+	}
+
+	w = testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/export", erin.ID), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("export after erase = %d, want 404", w.Code)
+	}
+}
+
+func TestErasure_RoutesRequireAuth(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	user := testutil.SeedUsers(t, a.DB(), domain.User{})[0]
+
+	w := testutil.Serve(a.Handler(), testutil.NewJSONRequest(t, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/erase", user.ID), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DELETE erase with auth disabled: status = %d, want 404", w.Code)
+	}
+}
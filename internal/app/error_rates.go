@@ -0,0 +1,207 @@
+package app
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// errorRateBuckets is how many buckets a server.error_rates window is
+// counted in; 5 minutes gives 10-second buckets.
+const errorRateBuckets = 30
+
+// ErrorRateReport is the body of GET /debug/error-rates.
+type ErrorRateReport struct {
+	WindowSeconds int64            `json:"window_seconds"`
+	Routes        []RouteErrorRate `json:"routes"`
+}
+
+// RouteErrorRate is the outcome of the requests one route served in the
+// window. Route is the route template, e.g. /api/v1/users/:id.
+type RouteErrorRate struct {
+	Method    string  `json:"method"`
+	Route     string  `json:"route"`
+	Requests  uint64  `json:"requests"`
+	Errors    uint64  `json:"errors"`     // 5xx responses
+	ErrorRate float64 `json:"error_rate"` // errors / requests
+}
+
+// errorRates counts the requests and 5xx responses of each route over a
+// rolling window (server.error_rates). Requests that matched no route are
+// not counted, so it holds at most one window per registered route. When a
+// route with at least minRequests requests in the window answers more than
+// warnRatio of them with a 5xx, a warning is logged once; it is logged
+// again after the ratio has dropped back below.
+type errorRates struct {
+	clock       pkg.Clock
+	window      time.Duration
+	bucket      time.Duration
+	warnRatio   float64
+	minRequests int
+	logger      *slog.Logger
+
+	mu     sync.Mutex
+	routes map[routeKey]*routeWindow
+}
+
+type routeKey struct {
+	method, route string
+}
+
+// routeWindow is a ring of buckets; each counts the requests of one
+// bucket-long interval, numbered from the Unix epoch.
+type routeWindow struct {
+	buckets [errorRateBuckets]rateBucket
+	warned  bool
+}
+
+type rateBucket struct {
+	interval int64
+	requests uint64
+	errors   uint64
+}
+
+func newErrorRates(cfg config.ErrorRatesConfig, logger *slog.Logger, clock pkg.Clock) *errorRates {
+	window := cfg.EffectiveWindow()
+	return &errorRates{
+		clock:       clock,
+		window:      window,
+		bucket:      window / errorRateBuckets,
+		warnRatio:   cfg.EffectiveWarnRatio(),
+		minRequests: cfg.EffectiveMinRequests(),
+		logger:      logger,
+		routes:      make(map[routeKey]*routeWindow),
+	}
+}
+
+// interval returns the number of the bucket-long interval t falls in.
+func (e *errorRates) interval(t time.Time) int64 {
+	return t.UnixNano() / int64(e.bucket)
+}
+
+// add counts one request in the bucket of interval, which it takes over
+// from an interval that has left the window.
+func (w *routeWindow) add(interval int64, failed bool) {
+	b := &w.buckets[interval%errorRateBuckets]
+	if b.interval != interval {
+		*b = rateBucket{interval: interval}
+	}
+	b.requests++
+	if failed {
+		b.errors++
+	}
+}
+
+// sum totals the buckets of the window ending with interval.
+func (w *routeWindow) sum(interval int64) (requests, errors uint64) {
+	for _, b := range w.buckets {
+		if b.interval > interval-errorRateBuckets && b.interval <= interval {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+	return requests, errors
+}
+
+// record counts one response of route.
+func (e *errorRates) record(method, route string, status int) {
+	now := e.interval(e.clock.Now())
+	key := routeKey{method: method, route: route}
+
+	e.mu.Lock()
+	w := e.routes[key]
+	if w == nil {
+		w = &routeWindow{}
+		e.routes[key] = w
+	}
+	w.add(now, status >= http.StatusInternalServerError)
+	requests, errors := w.sum(now)
+	over := requests >= uint64(e.minRequests) && float64(errors) > e.warnRatio*float64(requests)
+	warn := over && !w.warned
+	w.warned = over
+	e.mu.Unlock()
+
+	if warn {
+		e.logger.Warn("route error rate above threshold",
+			slog.String("method", method),
+			slog.String("route", route),
+			slog.Uint64("requests", requests),
+			slog.Uint64("errors", errors),
+			slog.Float64("error_rate", float64(errors)/float64(requests)),
+			slog.Float64("threshold", e.warnRatio),
+			slog.Duration("window", e.window),
+		)
+	}
+}
+
+// report returns the rates of the routes that served requests in the
+// window, the highest error rate first.
+func (e *errorRates) report() ErrorRateReport {
+	now := e.interval(e.clock.Now())
+	routes := []RouteErrorRate{}
+
+	e.mu.Lock()
+	for key, w := range e.routes {
+		requests, errors := w.sum(now)
+		if requests == 0 {
+			continue
+		}
+		routes = append(routes, RouteErrorRate{
+			Method:    key.method,
+			Route:     key.route,
+			Requests:  requests,
+			Errors:    errors,
+			ErrorRate: float64(errors) / float64(requests),
+		})
+	}
+	e.mu.Unlock()
+
+	slices.SortFunc(routes, func(a, b RouteErrorRate) int {
+		return cmp.Or(
+			cmp.Compare(b.ErrorRate, a.ErrorRate),
+			cmp.Compare(b.Errors, a.Errors),
+			cmp.Compare(a.Route, b.Route),
+			cmp.Compare(a.Method, b.Method),
+		)
+	})
+	return ErrorRateReport{WindowSeconds: int64(e.window / time.Second), Routes: routes}
+}
+
+// middleware counts the response of every request that matched a route.
+// It runs outside recovery, so a panic counts as the 500 it turns into.
+func (e *errorRates) middleware() ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			next(c)
+			if route := c.FullPath(); route != "" {
+				e.record(c.Request.Method, route, c.Writer.Status())
+			}
+		}
+	}
+}
+
+// registerErrorRateRoutes adds GET /debug/error-rates. Like the stats
+// dashboard it requires statsPermission with RBAC enabled and exists only
+// in debug mode without RBAC.
+func registerErrorRateRoutes(pages *gin.RouterGroup, deps *RouteDeps) {
+	if deps.ErrorRates == nil || (deps.RBAC == nil && deps.Mode != gin.DebugMode) {
+		return
+	}
+	pages.GET("/debug/error-rates", func(c *gin.Context) {
+		if deps.RBAC != nil && !middleware.GetPermissions(c).Can(statsPermission) {
+			pkg.JSONError(c, http.StatusForbidden, "forbidden")
+			return
+		}
+		pkg.Success(c, deps.ErrorRates())
+	})
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newErrorRateRouter serves /ok, /items/:id (500 for id "bad") and /panic
+// behind the error rate middleware and recovery.
+func newErrorRateRouter(rates *errorRates) *gin.Engine {
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(rates.middleware()).Use(ginx.Recovery()).Build())
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/items/:id", func(c *gin.Context) {
+		if c.Param("id") == "bad" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/panic", func(*gin.Context) { panic("boom") })
+	return r
+}
+
+// hit requests path n times.
+func hit(r http.Handler, path string, n int) {
+	for range n {
+		testutil.Serve(r, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+}
+
+// routeRates indexes a report by route.
+func routeRates(report ErrorRateReport) map[string]RouteErrorRate {
+	out := make(map[string]RouteErrorRate, len(report.Routes))
+	for _, r := range report.Routes {
+		out[r.Route] = r
+	}
+	return out
+}
+
+func TestErrorRates_RatesAndBucketExpiry(t *testing.T) {
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rates := newErrorRates(config.ErrorRatesConfig{}, slog.New(slog.DiscardHandler), clock)
+	r := newErrorRateRouter(rates)
+
+	hit(r, "/ok", 6)
+	hit(r, "/items/bad", 3)
+	hit(r, "/items/1", 1)
+	hit(r, "/panic", 1)
+	hit(r, "/missing", 2)
+
+	report := rates.report()
+	if report.WindowSeconds != 300 {
+		t.Errorf("WindowSeconds = %d, want 300", report.WindowSeconds)
+	}
+	var order []string
+	for _, route := range report.Routes {
+		order = append(order, route.Route)
+	}
+	if got := strings.Join(order, ","); got != "/panic,/items/:id,/ok" {
+		t.Fatalf("routes = %s, want worst first: /panic,/items/:id,/ok (unmatched paths not counted)", got)
+	}
+	if got := report.Routes[1]; got.Method != http.MethodGet || got.Requests != 4 || got.Errors != 3 || got.ErrorRate != 0.75 {
+		t.Errorf("/items/:id = %+v, want 3 of 4 requests failed", got)
+	}
+	if got := report.Routes[0]; got.Requests != 1 || got.Errors != 1 {
+		t.Errorf("/panic = %+v, want the recovered panic counted as a 5xx", got)
+	}
+
+	// The next bucket adds to the window.
+	clock.Advance(10 * time.Second)
+	hit(r, "/ok", 2)
+	if got := routeRates(rates.report())["/ok"]; got.Requests != 8 || got.Errors != 0 {
+		t.Errorf("/ok after a second bucket = %+v, want 8 requests", got)
+	}
+
+	// Five minutes after the first bucket it has left the window; its slot
+	// in the ring is reused.
+	clock.Advance(4*time.Minute + 50*time.Second)
+	hit(r, "/ok", 1)
+	got := routeRates(rates.report())
+	if len(got) != 1 || got["/ok"].Requests != 3 {
+		t.Errorf("report after the first bucket expired = %+v, want only /ok with 3 requests", got)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if report := rates.report(); report.Routes == nil || len(report.Routes) != 0 {
+		t.Errorf("report after the window passed = %+v, want an empty list", report.Routes)
+	}
+}
+
+func TestErrorRates_ThresholdWarning(t *testing.T) {
+	var logs bytes.Buffer
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rates := newErrorRates(config.ErrorRatesConfig{WarnRatio: 0.5, MinRequests: 4},
+		slog.New(slog.NewJSONHandler(&logs, nil)), clock)
+	r := newErrorRateRouter(rates)
+	warnings := func() int { return strings.Count(logs.String(), "route error rate above threshold") }
+
+	hit(r, "/items/bad", 3)
+	if n := warnings(); n != 0 {
+		t.Fatalf("warnings below min_requests = %d, want 0", n)
+	}
+	hit(r, "/items/1", 1) // 3 of 4
+	if n := warnings(); n != 1 {
+		t.Fatalf("warnings at 3 of 4 failed = %d, want 1", n)
+	}
+	var entry struct {
+		Level     string  `json:"level"`
+		Method    string  `json:"method"`
+		Route     string  `json:"route"`
+		Requests  int     `json:"requests"`
+		Errors    int     `json:"errors"`
+		Threshold float64 `json:"threshold"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(logs.Bytes()), &entry); err != nil {
+		t.Fatalf("decode warning: %v", err)
+	}
+	if entry.Level != "WARN" || entry.Method != http.MethodGet || entry.Route != "/items/:id" || entry.Requests != 4 || entry.Errors != 3 || entry.Threshold != 0.5 {
+		t.Errorf("warning = %+v", entry)
+	}
+
+	// Staying above the threshold does not repeat it.
+	hit(r, "/items/bad", 2)
+	if n := warnings(); n != 1 {
+		t.Errorf("warnings while still above = %d, want 1", n)
+	}
+
+	// Dropping below (5 of 12) and rising again (8 of 15) warns again.
+	hit(r, "/items/1", 6)
+	hit(r, "/items/bad", 2)
+	if n := warnings(); n != 1 {
+		t.Errorf("warnings at 7 of 14 = %d, want 1", n)
+	}
+	hit(r, "/items/bad", 1)
+	if n := warnings(); n != 2 {
+		t.Errorf("warnings after rising above again = %d, want 2", n)
+	}
+}
+
+// setupErrorRateRouter registers the routes with a fixed error rate
+// report; page users are identified by X-Test-User.
+func setupErrorRateRouter(t *testing.T, mode string, svc rbac.Service) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	err := RegisterRoutes(r, &RouteDeps{
+		Modules:    []Module{&mockModule{}},
+		DB:         openTestSQLiteDB(t),
+		Mode:       mode,
+		CSRFSecret: "test-secret-32-chars-long-enough",
+		RBAC:       svc,
+		PageIdentity: func(c *gin.Context) (string, bool) {
+			id := c.GetHeader("X-Test-User")
+			return id, id != ""
+		},
+		ErrorRates: func() ErrorRateReport {
+			return ErrorRateReport{WindowSeconds: 300, Routes: []RouteErrorRate{{Method: "GET", Route: "/x", Requests: 2, Errors: 1, ErrorRate: 0.5}}}
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+	return r
+}
+
+func TestErrorRateRoutes_Access(t *testing.T) {
+	get := func(r *gin.Engine, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/debug/error-rates", nil)
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		return testutil.Serve(r, req)
+	}
+
+	w := get(setupErrorRateRouter(t, gin.DebugMode, nil), "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"route":"/x"`) {
+		t.Errorf("debug: GET /debug/error-rates = %d %s, want 200 with the report", w.Code, w.Body.String())
+	}
+	if w := get(setupErrorRateRouter(t, gin.ReleaseMode, nil), ""); w.Code != http.StatusNotFound {
+		t.Errorf("release without RBAC: status = %d, want 404", w.Code)
+	}
+
+	r := setupErrorRateRouter(t, gin.ReleaseMode, &fakeRBAC{grants: map[string][]string{"ops": {statsPermission}}})
+	if w := get(r, "ops"); w.Code != http.StatusOK {
+		t.Errorf("RBAC admin: status = %d, want 200", w.Code)
+	}
+	for _, user := range []string{"", "reader"} {
+		if w := get(r, user); w.Code != http.StatusForbidden {
+			t.Errorf("RBAC user %q: status = %d, want 403", user, w.Code)
+		}
+	}
+}
+
+func TestNew_ErrorRates(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithMode(gin.DebugMode), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.ErrorRates.Enabled = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.DB())
+
+	hit(a.engine, "/api/v1/users/1", 1)
+	w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/debug/error-rates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /debug/error-rates status = %d, want 200", w.Code)
+	}
+	var resp struct {
+		Data ErrorRateReport `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := routeRates(resp.Data)["/api/v1/users/:id"]; got.Requests != 1 || got.Errors != 0 {
+		t.Errorf("/api/v1/users/:id = %+v, want one request without errors; report %+v", got, resp.Data)
+	}
+}
@@ -6,54 +6,83 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/simp-lee/gobase/internal/middleware"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 )
 
-// errorTemplates maps HTTP status codes to their error template paths.
-var errorTemplates = map[int]string{
-	400: "errors/400.html",
-	404: "errors/404.html",
-	500: "errors/500.html",
-}
+// genericErrorTemplate renders any status without its own errors/<code>.html.
+const genericErrorTemplate = "errors/error.html"
 
 // renderError sends an error response appropriate for the client.
-// For requests that accept HTML, it renders the corresponding error template
-// (falling back to errors/500.html for unmapped codes, then plain text if
-// template rendering panics). For other requests it returns a JSON envelope.
+// For requests that accept HTML, it renders an error page (see
+// renderHTMLErrorPage). For other requests it returns a JSON envelope, or
+// problem+json when pkg.WantsProblemJSON.
 func renderError(c *gin.Context, code int, message string) {
 	accept := strings.ToLower(c.GetHeader("Accept"))
 	// Explicit JSON request — check before acceptsHTML because acceptsHTML also matches */*.
-	if strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html") {
-		c.JSON(code, pkg.Response{Code: code, Message: message})
+	if pkg.WantsProblemJSON(c) || (strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")) {
+		pkg.JSONError(c, code, message)
 		return
 	}
 	if acceptsHTML(c) {
-		renderHTMLErrorPage(c, code)
+		renderHTMLErrorPage(c, code, message)
 		return
 	}
-	c.JSON(code, pkg.Response{
-		Code:    code,
-		Message: message,
-		Data:    nil,
-	})
+	pkg.JSONError(c, code, message)
 }
 
-// renderHTMLErrorPage renders the error template for the given status code.
-// If no template exists for the code, it falls back to errors/500.html.
-// If rendering panics, it falls back to a plain text response.
-func renderHTMLErrorPage(c *gin.Context, code int) {
+// renderHTMLErrorPage renders errors/<code>.html, or errors/error.html when
+// the status has no template of its own; both get Status, StatusText and
+// Message in the data, plus the keys the base layout reads. If neither can be rendered (no renderer, missing or
+// broken templates) it writes a minimal inline HTML page; it never panics.
+func renderHTMLErrorPage(c *gin.Context, code int, message string) {
+	data := gin.H{
+		"Status":     code,
+		"StatusText": defaultStatusText(code),
+		"Message":    message,
+		"Locale":     requestctx.Locale(c),
+		"Nav":        middleware.GetNav(c),
+		"Unread":     middleware.GetUnread(c),
+	}
+	if tryHTML(c, code, errorTemplateName(code), data) {
+		return
+	}
+	if tryHTML(c, code, genericErrorTemplate, data) {
+		return
+	}
+	c.Data(code, "text/html; charset=utf-8", []byte(inlineErrorPage(code)))
+}
+
+// errorTemplateName is the status-specific error template for code.
+func errorTemplateName(code int) string {
+	return fmt.Sprintf("errors/%d.html", code)
+}
+
+// tryHTML renders the template name and reports whether anything was
+// written. A missing template leaves the response untouched (gin records
+// the render error in c.Errors, which is dropped here), as does a panic
+// while no HTMLRender is configured.
+func tryHTML(c *gin.Context, code int, name string, data gin.H) (ok bool) {
+	errCount := len(c.Errors)
 	defer func() {
 		if r := recover(); r != nil {
-			c.Data(code, "text/plain; charset=utf-8",
-				[]byte(fmt.Sprintf("%d %s", code, defaultStatusText(code))))
+			ok = false
+		}
+		if !ok {
+			c.Errors = c.Errors[:errCount]
+			c.Writer.Header().Del("Content-Type")
 		}
 	}()
+	c.HTML(code, name, data)
+	return len(c.Errors) == errCount || c.Writer.Written()
+}
 
-	tmpl, ok := errorTemplates[code]
-	if !ok {
-		tmpl = errorTemplates[500]
-	}
-	c.HTML(code, tmpl, gin.H{})
+// inlineErrorPage is the last-resort error page, independent of templates.
+func inlineErrorPage(code int) string {
+	title := fmt.Sprintf("%d %s", code, defaultStatusText(code))
+	return "<!DOCTYPE html><html lang=\"zh-CN\"><head><meta charset=\"UTF-8\"><title>" + title +
+		"</title></head><body><h1>" + title + "</h1><p><a href=\"/\">返回首页</a></p></body></html>"
 }
 
 // acceptsHTML returns true if the client accepts an HTML response.
@@ -70,14 +99,20 @@ func defaultStatusText(code int) string {
 	switch code {
 	case 400:
 		return "Bad Request"
+	case 403:
+		return "Forbidden"
 	case 404:
 		return "Not Found"
+	case 405:
+		return "Method Not Allowed"
 	case 408:
 		return "Request Timeout"
 	case 429:
 		return "Too Many Requests"
 	case 500:
 		return "Internal Server Error"
+	case 503:
+		return "Service Unavailable"
 	default:
 		return "Error"
 	}
@@ -2,13 +2,18 @@ package app
 
 import (
 	"encoding/json"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/web"
 )
 
 func init() {
@@ -92,13 +97,13 @@ func TestRenderError_JSON(t *testing.T) {
 	}
 }
 
-func TestRenderError_HTML_FallsBackToPlainText(t *testing.T) {
-	// Without an HTML renderer configured on the engine, c.HTML will panic.
-	// renderError should recover and fall back to plain text.
+func TestRenderError_HTML_FallsBackToInlinePage(t *testing.T) {
+	// Without an HTML renderer configured on the engine, c.HTML panics.
+	// renderError should recover and fall back to a minimal inline page.
 	tests := []struct {
-		name     string
-		code     int
-		wantBody string
+		name      string
+		code      int
+		wantTitle string
 	}{
 		{"500 fallback", 500, "500 Internal Server Error"},
 		{"400 fallback", 400, "400 Bad Request"},
@@ -120,18 +125,87 @@ func TestRenderError_HTML_FallsBackToPlainText(t *testing.T) {
 			}
 
 			body := w.Body.String()
-			if body != tt.wantBody {
-				t.Fatalf("body = %q, want %q", body, tt.wantBody)
+			if !strings.HasPrefix(body, "<!DOCTYPE html>") || !strings.Contains(body, "<h1>"+tt.wantTitle+"</h1>") {
+				t.Fatalf("body = %q, want an inline page titled %q", body, tt.wantTitle)
 			}
 
 			ct := w.Header().Get("Content-Type")
-			if ct != "text/plain; charset=utf-8" {
-				t.Fatalf("Content-Type = %q, want %q", ct, "text/plain; charset=utf-8")
+			if ct != "text/html; charset=utf-8" {
+				t.Fatalf("Content-Type = %q, want %q", ct, "text/html; charset=utf-8")
 			}
 		})
 	}
 }
 
+// errorPageRouter serves GET /fail/:code, which calls renderError with the
+// status from the path, rendering through fsys.
+func errorPageRouter(t *testing.T, fsys fs.FS) *gin.Engine {
+	t.Helper()
+	renderer, err := NewTemplateRenderer(fsys, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	r := gin.New()
+	r.HTMLRender = renderer
+	r.GET("/fail/:code", func(c *gin.Context) {
+		code, _ := strconv.Atoi(c.Param("code"))
+		renderError(c, code, "detail for "+c.Param("code"))
+	})
+	return r
+}
+
+func TestRenderError_HTML_TemplateLookup(t *testing.T) {
+	r := errorPageRouter(t, web.EmbeddedFS)
+
+	tests := []struct {
+		code int
+		want string
+	}{
+		{400, "400"},
+		{403, "禁止访问"},
+		{404, "页面未找到"},
+		{500, "服务器错误"},
+		// No errors/<code>.html: the generic page shows status and message.
+		{405, "Method Not Allowed"},
+		{429, "detail for 429"},
+		{503, "Service Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/fail/"+strconv.Itoa(tt.code), nil)
+			req.Header.Set("Accept", "text/html")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d", w.Code, tt.code)
+			}
+			if body := w.Body.String(); !strings.Contains(body, tt.want) || !strings.Contains(body, "</html>") {
+				t.Errorf("body missing %q in a full page:\n%s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestRenderError_HTML_MissingGenericTemplate(t *testing.T) {
+	r := errorPageRouter(t, fstest.MapFS{
+		"templates/layouts/base.html": &fstest.MapFile{Data: []byte(`{{ define "base" }}<html>{{ block "content" . }}{{ end }}</html>{{ end }}`)},
+		"templates/errors/404.html":   &fstest.MapFile{Data: []byte(`{{ template "base" . }}{{ define "content" }}custom 404{{ end }}`)},
+	})
+
+	for code, want := range map[int]string{
+		404: "<html>custom 404</html>",
+		503: "<h1>503 Service Unavailable</h1>",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/fail/"+strconv.Itoa(code), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != code || !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%d: status = %d, body = %q; want %q", code, w.Code, w.Body.String(), want)
+		}
+	}
+}
+
 func TestDefaultStatusText(t *testing.T) {
 	tests := []struct {
 		code int
@@ -142,7 +216,8 @@ func TestDefaultStatusText(t *testing.T) {
 		{408, "Request Timeout"},
 		{429, "Too Many Requests"},
 		{500, "Internal Server Error"},
-		{503, "Error"},
+		{503, "Service Unavailable"},
+		{418, "Error"},
 	}
 
 	for _, tt := range tests {
@@ -155,21 +230,14 @@ func TestDefaultStatusText(t *testing.T) {
 	}
 }
 
-func TestErrorTemplates(t *testing.T) {
-	// Verify the template map contains expected entries.
-	expected := map[int]string{
+func TestErrorTemplateName(t *testing.T) {
+	for code, want := range map[int]string{
 		400: "errors/400.html",
 		404: "errors/404.html",
 		500: "errors/500.html",
-	}
-
-	for code, tmpl := range expected {
-		got, ok := errorTemplates[code]
-		if !ok {
-			t.Fatalf("errorTemplates[%d] missing", code)
-		}
-		if got != tmpl {
-			t.Fatalf("errorTemplates[%d] = %q, want %q", code, got, tmpl)
+	} {
+		if got := errorTemplateName(code); got != want {
+			t.Fatalf("errorTemplateName(%d) = %q, want %q", code, got, want)
 		}
 	}
 }
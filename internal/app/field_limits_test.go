@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// fieldLimitCase is one submission of a user at, or one character over, a
+// length limit. Names use multibyte characters, since the limits count
+// characters rather than bytes.
+type fieldLimitCase struct {
+	name            string
+	userName, email string
+	bio             string
+	wantOK          bool
+}
+
+func fieldLimitCases() []fieldLimitCase {
+	return []fieldLimitCase{
+		{name: "name at limit", userName: strings.Repeat("张", domain.NameMaxLen), email: "name-max@example.com", wantOK: true},
+		{name: "name over limit", userName: strings.Repeat("张", domain.NameMaxLen+1), email: "name-over@example.com"},
+		{name: "name under minimum", userName: strings.Repeat("é", domain.NameMinLen-1), email: "name-min@example.com"},
+		{name: "email at limit", userName: "Max Email", email: testutil.EmailOfLength(domain.EmailMaxLen), wantOK: true},
+		{name: "email over limit", userName: "Long Email", email: testutil.EmailOfLength(domain.EmailMaxLen + 1)},
+		{name: "bio at limit", userName: "Max Bio", email: "bio-max@example.com", bio: strings.Repeat("ß", domain.BioMaxLen), wantOK: true},
+		{name: "bio over limit", userName: "Long Bio", email: "bio-over@example.com", bio: strings.Repeat("ß", domain.BioMaxLen+1)},
+	}
+}
+
+func newFieldLimitApp(t *testing.T, opts ...testutil.ConfigOption) *App {
+	t.Helper()
+	a, err := New(testutil.NewTestConfig(append([]testutil.ConfigOption{testutil.WithSQLitePath(testutil.MemoryDSN(t))}, opts...)...))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	return a
+}
+
+// assertStored checks that a user with email exists exactly when want is
+// true, holding the values that were sent.
+func assertStored(t *testing.T, a *App, tc fieldLimitCase, want bool) {
+	t.Helper()
+	var users []domain.User
+	if err := a.db.Where("email = ?", tc.email).Find(&users).Error; err != nil {
+		t.Fatalf("find user: %v", err)
+	}
+	if !want {
+		if len(users) != 0 {
+			t.Errorf("user %q was stored, want it rejected", tc.email)
+		}
+		return
+	}
+	if len(users) != 1 {
+		t.Fatalf("users with email %q = %d, want 1", tc.email, len(users))
+	}
+	if u := users[0]; u.Name != tc.userName || u.Bio != tc.bio {
+		t.Errorf("stored user = %q/%d bio runes, want the submitted values", u.Name, len([]rune(u.Bio)))
+	}
+}
+
+func TestFieldLimits_API(t *testing.T) {
+	a := newFieldLimitApp(t)
+
+	for _, tc := range fieldLimitCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users",
+				map[string]string{"name": tc.userName, "email": tc.email, "bio": tc.bio}))
+
+			want := http.StatusBadRequest
+			if tc.wantOK {
+				want = http.StatusCreated
+			}
+			if w.Code != want {
+				t.Fatalf("POST status = %d, want %d; body = %s", w.Code, want, w.Body.String())
+			}
+			assertStored(t, a, tc, tc.wantOK)
+		})
+	}
+
+	// Updates run the same checks.
+	seeded := testutil.SeedUsers(t, a.db, domain.User{Name: "Update Target", Email: "update-target@example.com"})
+	path := fmt.Sprintf("/api/v1/users/%d", seeded[0].ID)
+	for _, body := range []map[string]string{
+		{"name": strings.Repeat("张", domain.NameMaxLen+1), "email": "update-target@example.com"},
+		{"name": "Update Target", "email": testutil.EmailOfLength(domain.EmailMaxLen + 1)},
+	} {
+		if w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPut, path, body)); w.Code != http.StatusBadRequest {
+			t.Errorf("PUT over-length status = %d, want 400; body = %s", w.Code, w.Body.String())
+		}
+		if w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPatch, path, body)); w.Code != http.StatusBadRequest {
+			t.Errorf("PATCH over-length status = %d, want 400; body = %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestFieldLimits_Register(t *testing.T) {
+	a := newFieldLimitApp(t, testutil.WithAuth())
+
+	for _, tc := range fieldLimitCases() {
+		if tc.bio != "" {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register",
+				map[string]string{"name": tc.userName, "email": tc.email, "password": "secret1234"}))
+
+			want := http.StatusBadRequest
+			if tc.wantOK {
+				want = http.StatusCreated
+			}
+			if w.Code != want {
+				t.Fatalf("register status = %d, want %d; body = %s", w.Code, want, w.Body.String())
+			}
+			assertStored(t, a, tc, tc.wantOK)
+		})
+	}
+}
+
+var (
+	formTokenInput = regexp.MustCompile(`name="` + pkg.FormTokenField + `" value="([^"]*)"`)
+	maxLengthAttr  = regexp.MustCompile(`id="(name|email|bio)"[^>]*?maxlength="(\d+)"`)
+)
+
+func TestFieldLimits_HTMXForm(t *testing.T) {
+	a := newFieldLimitApp(t)
+
+	newForm := func(t *testing.T) (body string, cookies []*http.Cookie) {
+		t.Helper()
+		w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/users/new", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /users/new status = %d; body = %s", w.Code, w.Body.String())
+		}
+		return w.Body.String(), w.Result().Cookies()
+	}
+
+	body, _ := newForm(t)
+	wantMax := map[string]string{
+		"name":  fmt.Sprint(domain.NameMaxLen),
+		"email": fmt.Sprint(domain.EmailMaxLen),
+		"bio":   fmt.Sprint(domain.BioMaxLen),
+	}
+	for _, m := range maxLengthAttr.FindAllStringSubmatch(body, -1) {
+		if m[2] != wantMax[m[1]] {
+			t.Errorf("%s maxlength = %s, want %s", m[1], m[2], wantMax[m[1]])
+		}
+		delete(wantMax, m[1])
+	}
+	if len(wantMax) != 0 {
+		t.Errorf("form has no maxlength for %v", wantMax)
+	}
+
+	for _, tc := range fieldLimitCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			body, cookies := newForm(t)
+			csrf := hiddenCSRFInput.FindStringSubmatch(body)
+			formToken := formTokenInput.FindStringSubmatch(body)
+			if csrf == nil || formToken == nil {
+				t.Fatalf("form has no CSRF or form token:\n%s", body)
+			}
+
+			form := url.Values{
+				"name":                   {tc.userName},
+				"email":                  {tc.email},
+				"bio":                    {tc.bio},
+				pkg.FormTokenField:       {html.UnescapeString(formToken[1])},
+				middleware.CSRFFormField: {html.UnescapeString(csrf[1])},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("HX-Request", "true")
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			w := testutil.Serve(a.engine, req)
+
+			// Rejected submissions get the form back with the error, as a
+			// 200 so htmx swaps it in; nothing may surface as a 500.
+			if w.Code != http.StatusOK {
+				t.Fatalf("POST /users status = %d; body = %s", w.Code, w.Body.String())
+			}
+			if redirected := w.Header().Get("HX-Redirect") == "/users"; redirected != tc.wantOK {
+				t.Fatalf("HX-Redirect = %q, want redirect %v; body = %s", w.Header().Get("HX-Redirect"), tc.wantOK, w.Body.String())
+			}
+			if !tc.wantOK && !strings.Contains(w.Body.String(), "请检查输入格式") {
+				t.Errorf("rejected form does not show the validation error:\n%s", w.Body.String())
+			}
+			assertStored(t, a, tc, tc.wantOK)
+		})
+	}
+}
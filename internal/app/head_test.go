@@ -0,0 +1,105 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// countQueries counts the SELECTs (queries and Count) run on db.
+func countQueries(t *testing.T, db *gorm.DB) *atomic.Int64 {
+	t.Helper()
+	var n atomic.Int64
+	count := func(*gorm.DB) { n.Add(1) }
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_query", count); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:count_row", count); err != nil {
+		t.Fatal(err)
+	}
+	return &n
+}
+
+func TestNew_HeadRequests(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db, domain.User{}, domain.User{})
+	queries := countQueries(t, a.db)
+
+	serve := func(method, path string) (*httptest.ResponseRecorder, int64) {
+		before := queries.Load()
+		w := httptest.NewRecorder()
+		a.Handler().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w, queries.Load() - before
+	}
+
+	// The list endpoint runs as GET: same queries and headers, no body, and
+	// the Content-Length of the GET body.
+	get, getQueries := serve(http.MethodGet, "/api/v1/users?page_size=1")
+	head, headQueries := serve(http.MethodHead, "/api/v1/users?page_size=1")
+	if get.Code != http.StatusOK || head.Code != http.StatusOK {
+		t.Fatalf("GET = %d, HEAD = %d; want 200", get.Code, head.Code)
+	}
+	if getQueries == 0 || headQueries != getQueries {
+		t.Errorf("queries: GET = %d, HEAD = %d; want equal and non-zero", getQueries, headQueries)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body = %q, want empty", head.Body.String())
+	}
+	if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+		t.Errorf("HEAD Content-Length = %q, want %q", got, want)
+	}
+	for name := range get.Header() {
+		if name == "X-Request-Id" {
+			continue
+		}
+		if g, h := get.Header().Values(name), head.Header().Values(name); len(g) != len(h) || (len(g) > 0 && g[0] != h[0]) {
+			t.Errorf("header %s: GET %q, HEAD %q", name, g, h)
+		}
+	}
+
+	// The home page answers HEAD without its page middleware or render.
+	home, homeQueries := serve(http.MethodHead, "/")
+	if home.Code != http.StatusOK || home.Body.Len() != 0 || homeQueries != 0 {
+		t.Errorf("HEAD / = %d, body %d bytes, %d queries; want 200, empty, none", home.Code, home.Body.Len(), homeQueries)
+	}
+	if ct := home.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("HEAD / Content-Type = %q", ct)
+	}
+
+	// Static files report their size.
+	css, _ := serve(http.MethodGet, "/static/css/app.css")
+	staticHead, _ := serve(http.MethodHead, "/static/css/app.css")
+	if staticHead.Code != http.StatusOK || staticHead.Body.Len() != 0 || staticHead.Header().Get("Content-Length") != strconv.Itoa(css.Body.Len()) {
+		t.Errorf("HEAD static = %d, body %d bytes, Content-Length %q; want 200, empty, %d",
+			staticHead.Code, staticHead.Body.Len(), staticHead.Header().Get("Content-Length"), css.Body.Len())
+	}
+}
+
+func TestNew_HeadRequestsReject(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.HeadRequests = config.HeadRequestsReject
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD / status = %d, want 405", w.Code)
+	}
+}
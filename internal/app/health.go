@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/rbac"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// HealthProvider is implemented by modules with components of their own to
+// report on the health check, such as a queue or an outbound client.
+type HealthProvider interface {
+	HealthCheckers() []pkg.HealthChecker
+}
+
+// collectHealthCheckers returns the checkers of each module that declares
+// some, in module order.
+func collectHealthCheckers(modules []Module) []pkg.HealthChecker {
+	var checkers []pkg.HealthChecker
+	for _, m := range modules {
+		if p, ok := m.(HealthProvider); ok {
+			checkers = append(checkers, p.HealthCheckers()...)
+		}
+	}
+	return checkers
+}
+
+// databaseHealthCheckers pings the primary as "database" and each read
+// replica as "database_replica_<index>"; all are critical. A nil db is
+// reported as a failed database.
+func databaseHealthCheckers(db *gorm.DB) []pkg.HealthChecker {
+	if db == nil {
+		return []pkg.HealthChecker{pkg.NewHealthCheck("database", true, func(context.Context) error {
+			return errors.New("no database")
+		})}
+	}
+	checkers := []pkg.HealthChecker{pkg.NewHealthCheck("database", true, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})}
+	for i, replica := range config.Replicas(db) {
+		checkers = append(checkers, pkg.NewHealthCheck(fmt.Sprintf("database_replica_%d", i), true, replica.PingContext))
+	}
+	return checkers
+}
+
+// cacheHealthProbeKey is written and read back by cacheHealthCheck. No
+// response cache key looks like it.
+const cacheHealthProbeKey = "health:probe"
+
+// cacheHealthCheck reports the response cache as "cache" by storing and
+// reading back a probe entry. It is not critical: without the cache
+// requests are slower but still served.
+func cacheHealthCheck(c cache.CacheInterface) pkg.HealthChecker {
+	return pkg.NewHealthCheck("cache", false, func(context.Context) error {
+		c.Set(cacheHealthProbeKey, true)
+		defer c.Delete(cacheHealthProbeKey)
+		if _, ok := c.Get(cacheHealthProbeKey); !ok {
+			return errors.New("probe entry not readable")
+		}
+		return nil
+	})
+}
+
+// rbacHealthProbeRole is the role rbacHealthCheck looks up; it need not
+// exist.
+const rbacHealthProbeRole = "health-probe"
+
+// rbacHealthCheck reports the RBAC storage as "rbac" by looking up a role.
+// It is critical: every permission check depends on it.
+func rbacHealthCheck(svc rbac.Service) pkg.HealthChecker {
+	return pkg.NewHealthCheck("rbac", true, func(context.Context) error {
+		_, err := svc.RoleExists(rbacHealthProbeRole)
+		return err
+	})
+}
+
+// runHealthChecks runs every checker concurrently, each bounded by timeout
+// (and the request context), and returns their errors by index. A checker
+// still running at its deadline is reported as timed out and left to
+// finish in the background.
+func runHealthChecks(ctx context.Context, checkers []pkg.HealthChecker, timeout time.Duration) []error {
+	errs := make([]error, len(checkers))
+	var wg sync.WaitGroup
+	for i, hc := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- hc.Check(ctx) }()
+			select {
+			case errs[i] = <-done:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// healthHandler returns a handler that runs checkers and reports each as
+// "ok" or "error" under "components". Only a failed critical checker (see
+// pkg.IsCriticalHealthChecker) degrades the status and answers 503. When
+// lastMaintenance has a run, it is included as "maintenance", and
+// logFailures as "log_write_failures", neither affecting the status.
+// Without details the body is only the status.
+func healthHandler(checkers []pkg.HealthChecker, timeout time.Duration, lastMaintenance func() (MaintenanceRun, bool), logFailures func() uint64, details bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := "ok"
+		code := http.StatusOK
+		components := gin.H{}
+		for i, err := range runHealthChecks(c.Request.Context(), checkers, timeout) {
+			if err == nil {
+				components[checkers[i].Name()] = "ok"
+				continue
+			}
+			components[checkers[i].Name()] = "error"
+			if pkg.IsCriticalHealthChecker(checkers[i]) {
+				status = "degraded"
+				code = http.StatusServiceUnavailable
+			}
+		}
+
+		if !details {
+			c.JSON(code, gin.H{"status": status})
+			return
+		}
+		body := gin.H{"status": status, "version": buildVersion(), "components": components}
+		if lastMaintenance != nil {
+			if run, ok := lastMaintenance(); ok {
+				body["maintenance"] = run
+			}
+		}
+		if logFailures != nil {
+			body["log_write_failures"] = logFailures()
+		}
+		c.JSON(code, body)
+	}
+}
+
+// buildVersion identifies the running build on the health check, which
+// also tells a second instance what holds its port (see AddrInUseError):
+// the main module version, or the VCS revision for a development build.
+var buildVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value[:min(len(s.Value), 12)]
+		}
+	}
+	return "(devel)"
+})
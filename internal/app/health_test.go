@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// healthModule is a module reporting its own health components.
+type healthModule struct {
+	mockModule
+	checkers []pkg.HealthChecker
+}
+
+func (m *healthModule) HealthCheckers() []pkg.HealthChecker { return m.checkers }
+
+// healthBody is the detailed health check response.
+type healthBody struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components"`
+}
+
+func serveHealth(t *testing.T, h gin.HandlerFunc) (int, healthBody) {
+	t.Helper()
+	r := gin.New()
+	r.GET("/health", h)
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body healthBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return w.Code, body
+}
+
+func TestHealthHandler_CriticalOnlyDegrades(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("down") }
+
+	tests := []struct {
+		name       string
+		checkers   []pkg.HealthChecker
+		wantCode   int
+		wantStatus string
+		want       map[string]string
+	}{
+		{
+			name: "non-critical failure",
+			checkers: []pkg.HealthChecker{
+				pkg.NewHealthCheck("database", true, ok),
+				pkg.NewHealthCheck("cache", false, fail),
+				pkg.NewHealthCheck("mail", false, ok),
+			},
+			wantCode: http.StatusOK, wantStatus: "ok",
+			want: map[string]string{"database": "ok", "cache": "error", "mail": "ok"},
+		},
+		{
+			name: "critical failure",
+			checkers: []pkg.HealthChecker{
+				pkg.NewHealthCheck("database", true, fail),
+				pkg.NewHealthCheck("cache", false, ok),
+			},
+			wantCode: http.StatusServiceUnavailable, wantStatus: "degraded",
+			want: map[string]string{"database": "error", "cache": "ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := serveHealth(t, healthHandler(tt.checkers, time.Second, nil, nil, true))
+			if code != tt.wantCode || body.Status != tt.wantStatus || !reflect.DeepEqual(body.Components, tt.want) {
+				t.Errorf("health = %d %+v, want %d %s %v", code, body, tt.wantCode, tt.wantStatus, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthHandler_TimesOutHangingChecker(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hanging := pkg.NewHealthCheck("queue", true, func(context.Context) error {
+		<-release // ignores ctx
+		return nil
+	})
+	quick := pkg.NewHealthCheck("cache", false, func(context.Context) error { return nil })
+
+	start := time.Now()
+	code, body := serveHealth(t, healthHandler([]pkg.HealthChecker{hanging, quick}, 20*time.Millisecond, nil, nil, true))
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("health check took %v, want it bounded by the checker timeout", elapsed)
+	}
+	want := map[string]string{"queue": "error", "cache": "ok"}
+	if code != http.StatusServiceUnavailable || !reflect.DeepEqual(body.Components, want) {
+		t.Errorf("health = %d %+v, want 503 with %v", code, body, want)
+	}
+}
+
+func TestRegisterRoutes_ModuleHealthCheckers(t *testing.T) {
+	r := setupTestRouter()
+	mod := &healthModule{checkers: []pkg.HealthChecker{
+		pkg.NewHealthCheck("webhooks", false, func(context.Context) error { return errors.New("backlog") }),
+	}}
+	err := RegisterRoutes(r, &RouteDeps{
+		Modules:        []Module{mod},
+		DB:             openTestSQLiteDB(t),
+		Mode:           "debug",
+		CSRFSecret:     "test-secret-32-chars-long-enough",
+		HealthCheckers: []pkg.HealthChecker{pkg.NewHealthCheck("cache", false, func(context.Context) error { return nil })},
+		Health:         config.HealthConfig{Timeout: config.Duration(time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body healthBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]string{"database": "ok", "cache": "ok", "webhooks": "error"}
+	if w.Code != http.StatusOK || body.Status != "ok" || !reflect.DeepEqual(body.Components, want) {
+		t.Errorf("health = %d %+v, want 200 ok with %v", w.Code, body, want)
+	}
+}
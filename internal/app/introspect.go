@@ -0,0 +1,58 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// RouteInfo is one entry of the route table reported by ListRoutes.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// introspectDSN is the throwaway database ListRoutes builds the app against.
+const introspectDSN = "file:gobase_introspect?mode=memory&cache=shared"
+
+// ListRoutes returns the routes an App built from cfg would serve, sorted by
+// path then method, without binding a port or opening the configured
+// database. The app is assembled in test mode against an in-memory SQLite
+// database, with logging limited to errors and cache warming disabled, then
+// closed. Routes do not depend on server.mode, so the result matches what
+// the configured mode would register; a placeholder CSRF secret is replaced
+// by a random one as in any non-release mode.
+func ListRoutes(cfg *config.Config) ([]RouteInfo, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	c := *cfg
+	c.Server.Mode = gin.TestMode
+	c.Server.Cache.Warm = nil
+	c.Database = config.DatabaseConfig{
+		Driver: "sqlite",
+		SQLite: config.SQLiteConfig{Path: introspectDSN},
+	}
+	c.Log = config.LogConfig{Level: "error", Format: "text"}
+
+	a, err := New(&c)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = a.Close() }()
+
+	routes := a.engine.Routes()
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, r := range routes {
+		infos = append(infos, RouteInfo{Method: r.Method, Path: r.Path, Handler: r.Handler})
+	}
+	slices.SortFunc(infos, func(x, y RouteInfo) int {
+		return cmp.Or(cmp.Compare(x.Path, y.Path), cmp.Compare(x.Method, y.Method))
+	})
+	return infos, nil
+}
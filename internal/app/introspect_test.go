@@ -0,0 +1,57 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestListRoutes_ReportsKnownRoutes(t *testing.T) {
+	// A postgres database that does not exist proves the configured
+	// database is never opened.
+	cfg := testutil.NewTestConfig(testutil.WithAuth(), func(c *config.Config) {
+		c.Database = config.DatabaseConfig{
+			Driver:   "postgres",
+			Postgres: config.PostgresConfig{Host: "db.invalid", Port: 5432, User: "nobody", DBName: "none", SSLMode: "disable"},
+		}
+	})
+
+	routes, err := ListRoutes(cfg)
+	if err != nil {
+		t.Fatalf("ListRoutes() error = %v", err)
+	}
+
+	want := map[string]string{
+		http.MethodGet + " /health":              "healthHandler",
+		http.MethodGet + " /api/v1/users":        "(*UserHandler).List",
+		http.MethodPost + " /api/v1/users":       "(*UserHandler).Create",
+		http.MethodPost + " /api/v1/auth/login":  "Login",
+		http.MethodDelete + " /api/v1/users/:id": "(*UserHandler).Delete",
+		http.MethodGet + " /users/:id/edit":      "EditPage",
+	}
+	seen := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		key := r.Method + " " + r.Path
+		seen[key] = true
+		if fragment, ok := want[key]; ok && !strings.Contains(r.Handler, fragment) {
+			t.Errorf("%s handler = %q, want it to contain %q", key, r.Handler, fragment)
+		}
+	}
+	for key := range want {
+		if !seen[key] {
+			t.Errorf("route %s not listed", key)
+		}
+	}
+
+	for i := 1; i < len(routes); i++ {
+		if routes[i-1].Path > routes[i].Path {
+			t.Fatalf("routes not sorted by path: %q before %q", routes[i-1].Path, routes[i].Path)
+		}
+	}
+	if cfg.Database.Driver != "postgres" || cfg.Server.Mode != "test" {
+		t.Errorf("ListRoutes modified the caller's config: %+v", cfg.Server)
+	}
+}
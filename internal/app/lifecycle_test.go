@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newLifecycleApp builds an App on a free loopback port.
+func newLifecycleApp(t *testing.T, opts ...testutil.ConfigOption) *App {
+	t.Helper()
+	opts = append([]testutil.ConfigOption{testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.Port = 0
+	}}, opts...)
+	a, err := New(testutil.NewTestConfig(opts...))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a
+}
+
+func TestApp_StartClose(t *testing.T) {
+	a := newLifecycleApp(t)
+
+	addr, err := a.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	url := "http://" + addr + a.cfg.Server.Health.EffectivePath()
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s status = %d, want 200", url, resp.StatusCode)
+	}
+
+	if _, err := a.Start(context.Background()); err == nil {
+		t.Error("second Start() error = nil, want an error")
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if resp, err := client.Get(url); err == nil {
+		resp.Body.Close()
+		t.Errorf("GET %s after Close succeeded, want the server stopped", url)
+	}
+	sqlDB, err := a.DB().DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Error("database Ping() after Close succeeded, want it closed")
+	}
+}
+
+func TestApp_CloseTwice(t *testing.T) {
+	a := newLifecycleApp(t)
+	if _, err := a.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	for i := range 2 {
+		if err := a.Close(); err != nil {
+			t.Errorf("Close() #%d error = %v", i+1, err)
+		}
+	}
+}
+
+func TestApp_CloseBeforeStart(t *testing.T) {
+	a := newLifecycleApp(t)
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if addr, err := a.Start(context.Background()); err == nil {
+		t.Errorf("Start() after Close = %q, want an error", addr)
+	}
+}
+
+func TestApp_CloseDrainsInFlightRequest(t *testing.T) {
+	a := newLifecycleApp(t, func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+	})
+	// The handler blocks until released, then uses the cache and the
+	// database, which must still be open.
+	entered, release := make(chan struct{}), make(chan struct{})
+	usedResources := make(chan error, 1)
+	a.engine.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		a.cache.Set("drain", "ok")
+		if v, ok := a.cache.Get("drain"); !ok || v != "ok" {
+			usedResources <- errors.New("cache lookup missed")
+		} else {
+			usedResources <- sqlPing(c, a)
+		}
+		c.String(http.StatusOK, "done")
+	})
+	addr, err := a.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	type response struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Get("http://" + addr + "/slow")
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	select {
+	case <-entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("request did not reach the handler")
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- a.Close() }()
+	// Shutdown closes the listener first; the request is then held
+	// across it.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still accepts connections after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("Close() = %v returned with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-usedResources; err != nil {
+		t.Errorf("in-flight handler: %v", err)
+	}
+	if r := <-responses; r.err != nil || r.status != http.StatusOK || r.body != "done" {
+		t.Errorf("in-flight response = %d %q (err %v), want 200 done", r.status, r.body, r.err)
+	}
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("Close() did not return after the request finished")
+	}
+
+	// Past Close the cache is inert instead of failing.
+	a.cache.Set("late", 1)
+	if _, ok := a.cache.Get("late"); ok {
+		t.Error("cache stored a value after Close")
+	}
+	a.cache.Close()
+}
+
+// sqlPing pings the App's database.
+func sqlPing(ctx context.Context, a *App) error {
+	sqlDB, err := a.DB().DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
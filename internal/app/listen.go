@@ -0,0 +1,153 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// instanceProbeTimeout bounds the health check request that looks for a
+// running instance on a taken address.
+const instanceProbeTimeout = 500 * time.Millisecond
+
+// AddrInUseError reports that the server address is already taken. When
+// the process holding it answers the health check like this server does,
+// Instance is set, with the Version its health check reports (when it
+// exposes details).
+type AddrInUseError struct {
+	Addr     string
+	Instance bool
+	Version  string
+	Err      error
+}
+
+func (e *AddrInUseError) Error() string {
+	var holder string
+	switch {
+	case e.Instance && e.Version != "":
+		holder = fmt.Sprintf("another instance of this server (version %s) is running there", e.Version)
+	case e.Instance:
+		holder = "another instance of this server is running there"
+	default:
+		holder = "another process is listening there"
+	}
+	return fmt.Sprintf("listen on %s: address already in use: %s; stop it or set server.port (APP__SERVER__PORT) to a free port", e.Addr, holder)
+}
+
+func (e *AddrInUseError) Unwrap() error { return e.Err }
+
+// CheckListenAddr fails with an *AddrInUseError when the configured
+// server.host and server.port are taken, by binding and releasing them.
+// cmd/server calls it before New, so a port clash is reported in
+// milliseconds rather than after the database and templates are set up;
+// Run reports one that happens in between the same way.
+func CheckListenAddr(cfg *config.Config) error {
+	if cfg == nil {
+		return errors.New("config is nil")
+	}
+	addr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return listenError(&cfg.Server, addr, err)
+	}
+	return ln.Close()
+}
+
+// listenError turns a failure to listen on addr into an *AddrInUseError
+// when the address is taken, probing the holder's health check, and
+// returns other errors unchanged.
+func listenError(server *config.ServerConfig, addr string, err error) error {
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		return err
+	}
+	inUse := &AddrInUseError{Addr: addr, Err: err}
+	inUse.Instance, inUse.Version = probeInstance(server, addr)
+	return inUse
+}
+
+// probeInstance requests the health check at addr and reports whether it
+// answered like this server's, with the version it reports. The probe
+// sends no credentials, as the holder may be any process: the health
+// token stays with this server, and the holder is recognised by the shape
+// of the body healthHandler writes. An instance whose health check needs
+// the token answers 404 and is reported as another process. A wildcard
+// host is probed on loopback.
+func probeInstance(server *config.ServerConfig, addr string) (instance bool, version string) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false, ""
+	}
+	switch ip := net.ParseIP(host); {
+	case host == "":
+		host = "127.0.0.1"
+	case ip != nil && ip.IsUnspecified() && ip.To4() != nil:
+		host = "127.0.0.1"
+	case ip != nil && ip.IsUnspecified():
+		host = "::1"
+	}
+
+	client := &http.Client{Timeout: instanceProbeTimeout}
+	resp, err := client.Get("http://" + net.JoinHostPort(host, port) + server.Health.EffectivePath())
+	if err != nil {
+		return false, ""
+	}
+	defer resp.Body.Close()
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, 64<<10)).Decode(&body); err != nil {
+		return false, ""
+	}
+	if !isHealthBody(resp.StatusCode, body) {
+		return false, ""
+	}
+	_ = json.Unmarshal(body["version"], &version)
+	return true, version
+}
+
+// isHealthBody reports whether a response with status code and JSON object
+// body is one healthHandler writes: "status" is "ok" with 200 or
+// "degraded" with 503, "components" maps names to "ok" or "error", and
+// there are no other members but "version", "maintenance" and
+// "log_write_failures".
+func isHealthBody(code int, body map[string]json.RawMessage) bool {
+	var status string
+	if err := json.Unmarshal(body["status"], &status); err != nil {
+		return false
+	}
+	switch {
+	case status == "ok" && code == http.StatusOK:
+	case status == "degraded" && code == http.StatusServiceUnavailable:
+	default:
+		return false
+	}
+	for name, raw := range body {
+		switch name {
+		case "status", "maintenance", "log_write_failures":
+		case "version":
+			var version string
+			if err := json.Unmarshal(raw, &version); err != nil {
+				return false
+			}
+		case "components":
+			var components map[string]string
+			if err := json.Unmarshal(raw, &components); err != nil {
+				return false
+			}
+			for _, state := range components {
+				if state != "ok" && state != "error" {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// occupyPort listens on a free loopback port until the test ends and
+// returns the listener and a config pointing at it.
+func occupyPort(t *testing.T) (net.Listener, *config.Config) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	cfg := &config.Config{Server: config.ServerConfig{Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port}}
+	return ln, cfg
+}
+
+func TestCheckListenAddr_PortTakenByOtherProcess(t *testing.T) {
+	_, cfg := occupyPort(t)
+
+	start := time.Now()
+	err := CheckListenAddr(cfg)
+	elapsed := time.Since(start)
+
+	var inUse *AddrInUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("CheckListenAddr() error = %v, want *AddrInUseError", err)
+	}
+	if inUse.Instance {
+		t.Error("Instance = true for a listener that does not speak HTTP")
+	}
+	if elapsed > instanceProbeTimeout+time.Second {
+		t.Errorf("CheckListenAddr() took %v, want a fast failure", elapsed)
+	}
+	msg := err.Error()
+	for _, want := range []string{inUse.Addr, "address already in use", "another process", "server.port"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q lacks %q", msg, want)
+		}
+	}
+}
+
+func TestCheckListenAddr_PortTakenByInstance(t *testing.T) {
+	ln, cfg := occupyPort(t)
+	cfg.Server.Health.Token = "probe-token"
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.URL.RawQuery != "" {
+			t.Errorf("probe sent credentials: Authorization %q, query %q", r.Header.Get("Authorization"), r.URL.RawQuery)
+		}
+		if r.URL.Path != config.DefaultHealthPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","version":"v1.4.2","components":{"database":"ok"}}`))
+	})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+
+	err := CheckListenAddr(cfg)
+	var inUse *AddrInUseError
+	if !errors.As(err, &inUse) {
+		t.Fatalf("CheckListenAddr() error = %v, want *AddrInUseError", err)
+	}
+	if !inUse.Instance || inUse.Version != "v1.4.2" {
+		t.Errorf("AddrInUseError = %+v, want an instance at v1.4.2", inUse)
+	}
+	if !strings.Contains(err.Error(), "another instance of this server (version v1.4.2)") {
+		t.Errorf("error = %q, want it to name the running instance", err)
+	}
+}
+
+func TestCheckListenAddr_PortTakenByOtherHealthCheck(t *testing.T) {
+	for name, body := range map[string]string{
+		"other members":     `{"status":"ok","uptime":42}`,
+		"other status":      `{"status":"healthy"}`,
+		"other components":  `{"status":"ok","components":{"database":"up"}}`,
+		"not an object":     `"ok"`,
+		"status code":       `{"status":"degraded"}`,
+		"version not a tag": `{"status":"ok","version":3}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ln, cfg := occupyPort(t)
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+			})}
+			go srv.Serve(ln)
+			t.Cleanup(func() { srv.Close() })
+
+			var inUse *AddrInUseError
+			if err := CheckListenAddr(cfg); !errors.As(err, &inUse) {
+				t.Fatalf("CheckListenAddr() error = %v, want *AddrInUseError", err)
+			}
+			if inUse.Instance {
+				t.Errorf("Instance = true for a health check answering %s", body)
+			}
+		})
+	}
+}
+
+// TestIsHealthBody keeps the probe in step with what healthHandler writes.
+func TestIsHealthBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	failing := pkg.NewHealthCheck("database", true, func(context.Context) error { return errors.New("down") })
+	maintenance := func() (MaintenanceRun, bool) { return MaintenanceRun{Removed: map[string]int{"sessions": 1}}, true }
+	logFailures := func() uint64 { return 0 }
+	for name, h := range map[string]gin.HandlerFunc{
+		"details":    healthHandler(nil, time.Second, maintenance, logFailures, true),
+		"degraded":   healthHandler([]pkg.HealthChecker{failing}, time.Second, nil, nil, true),
+		"no details": healthHandler([]pkg.HealthChecker{failing}, time.Second, nil, nil, false),
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, config.DefaultHealthPath, nil)
+		h(c)
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %s: %v", name, w.Body, err)
+		}
+		if !isHealthBody(w.Code, body) {
+			t.Errorf("%s: isHealthBody(%d, %s) = false, want true", name, w.Code, w.Body)
+		}
+	}
+}
+
+func TestCheckListenAddr_FreePort(t *testing.T) {
+	ln, cfg := occupyPort(t)
+	ln.Close()
+	if err := CheckListenAddr(cfg); err != nil {
+		t.Fatalf("CheckListenAddr() error = %v, want nil", err)
+	}
+	// The check releases the port again.
+	if err := CheckListenAddr(cfg); err != nil {
+		t.Fatalf("second CheckListenAddr() error = %v, want nil", err)
+	}
+}
+
+func TestRun_ReportsTakenPort(t *testing.T) {
+	_, taken := occupyPort(t)
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.Port = taken.Server.Port
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = a.Run()
+	var inUse *AddrInUseError
+	if !errors.As(err, &inUse) || !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("Run() error = %v, want an *AddrInUseError wrapping EADDRINUSE", err)
+	}
+}
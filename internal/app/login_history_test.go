@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// waitForLoginAttempts waits until the background writes have stored n login
+// attempts.
+func waitForLoginAttempts(t *testing.T, a *App, n int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var count int64
+		if err := a.db.Model(&domain.LoginAttempt{}).Count(&count).Error; err != nil {
+			t.Fatalf("count login attempts: %v", err)
+		}
+		if count >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("login attempts = %d, want %d", count, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNew_LoginHistory(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithAuth(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.TrustedProxies = []string{"192.0.2.0/24"}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	type loginResponse struct {
+		Data struct {
+			Token  string                     `json:"token"`
+			Failed *domain.FailedLoginSummary `json:"failed_attempts_since_last_login"`
+		} `json:"data"`
+	}
+	// login returns nil when the login is rejected.
+	login := func(password string) *loginResponse {
+		t.Helper()
+		req := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/login", `{"email":"alice@example.com","password":"`+password+`"}`)
+		req.RemoteAddr = "192.0.2.10:4000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		req.Header.Set("User-Agent", "history-test")
+		w := testutil.Serve(a.engine, req)
+		if w.Code != http.StatusOK {
+			return nil
+		}
+		var resp loginResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode login response: %v", err)
+		}
+		return &resp
+	}
+
+	register := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`)
+	if w := testutil.Serve(a.engine, register); w.Code != http.StatusCreated {
+		t.Fatalf("register status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	if login("wrong-password") != nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	waitForLoginAttempts(t, a, 1)
+
+	ok := login("secret1234")
+	if ok == nil {
+		t.Fatal("login failed")
+	}
+	if f := ok.Data.Failed; f == nil || f.Count != 1 || f.LastIP != "198.51.100.7" || f.LastAt == nil {
+		t.Fatalf("failed_attempts_since_last_login = %+v, want 1 failure from 198.51.100.7", f)
+	}
+	waitForLoginAttempts(t, a, 2)
+
+	req := testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/auth/login-history", "")
+	req.Header.Set("Authorization", "Bearer "+ok.Data.Token)
+	w := testutil.Serve(a.engine, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login-history status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var history struct {
+		Data struct {
+			TotalItems int                   `json:"total_items"`
+			Items      []domain.LoginAttempt `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	items := history.Data.Items
+	if history.Data.TotalItems != 2 || len(items) != 2 || !items[0].Success || items[1].Success {
+		t.Fatalf("history = %+v, want the success then the failure", history.Data)
+	}
+	if items[1].IP != "198.51.100.7" || items[1].UserAgent != "history-test" {
+		t.Errorf("failed attempt = %+v, want the client IP and user agent", items[1])
+	}
+
+	// The next login reports nothing new.
+	if again := login("secret1234"); again == nil || again.Data.Failed == nil || again.Data.Failed.Count != 0 {
+		t.Errorf("second login summary = %+v, want 0 failures", again)
+	}
+
+	// Anonymous callers get no history.
+	anon := testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/auth/login-history", "")
+	if w := testutil.Serve(a.engine, anon); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous login-history status = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// TestLongTransferPaths_OutlastWriteTimeout serves a 3-second stream through
+// a real listener with a 1-second write timeout: the long-transfer path
+// delivers all of it, any other path is cut off.
+func TestLongTransferPaths_OutlastWriteTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("streams for several seconds")
+	}
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.HTTP = config.HTTPConfig{
+			WriteTimeout:        config.Duration(time.Second),
+			LongTransferPaths:   []string{"/stream/long"},
+			LongTransferTimeout: config.Duration(10 * time.Second),
+		}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cleanupTestApp(t, a)
+
+	const chunks = 6
+	stream := func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain")
+		for i := range chunks {
+			if _, err := fmt.Fprintf(c.Writer, "chunk %d\n", i); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+	a.engine.GET("/stream/long", stream)
+	a.engine.GET("/stream/short", stream)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(ln.Addr().String(), a.Handler(), a.cfg.Server.HTTP).(*http.Server)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+
+	get := func(path string) (string, error) {
+		resp, err := http.Get("http://" + ln.Addr().String() + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	body, err := get("/stream/long")
+	if err != nil {
+		t.Fatalf("GET /stream/long error = %v, want the full stream", err)
+	}
+	if got := strings.Count(body, "chunk"); got != chunks {
+		t.Errorf("GET /stream/long got %d chunks, want %d: %q", got, chunks, body)
+	}
+
+	body, err = get("/stream/short")
+	if err == nil && strings.Count(body, "chunk") == chunks {
+		t.Errorf("GET /stream/short delivered the full stream past the write timeout: %q", body)
+	}
+}
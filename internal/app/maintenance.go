@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cache "github.com/simp-lee/cache"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// defaultMaintenanceInterval is how often expired entries are purged when
+// server.maintenance_interval is unset.
+const defaultMaintenanceInterval = 5 * time.Minute
+
+// Purger is a stateful component that can drop its expired entries, such as
+// an in-memory store that would otherwise grow until restart.
+type Purger interface {
+	PurgeExpired(ctx context.Context) (removed int, err error)
+}
+
+// cachePurger adapts a cache.CacheInterface to Purger. The cache exposes no
+// sweep of its own, so every key is looked up, which deletes the expired
+// ones; the lookups count toward the cache's hit/miss statistics.
+type cachePurger struct {
+	cache cache.CacheInterface
+}
+
+// PurgeExpired implements Purger.
+func (p cachePurger) PurgeExpired(ctx context.Context) (int, error) {
+	removed := 0
+	for _, key := range p.cache.Keys() {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if _, _, ok := p.cache.GetWithExpiration(key); !ok {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// loginHistoryPurger adapts the login history to Purger, deleting the
+// attempts older than retention.
+type loginHistoryPurger struct {
+	repo      domain.LoginAttemptRepository
+	retention time.Duration
+	clock     pkg.Clock
+}
+
+// PurgeExpired implements Purger.
+func (p loginHistoryPurger) PurgeExpired(ctx context.Context) (int, error) {
+	removed, err := p.repo.Prune(ctx, p.clock.Now().Add(-p.retention))
+	return int(removed), err
+}
+
+// changeFeedPurger adapts the change feed to Purger, deleting the entries
+// older than retention.
+type changeFeedPurger struct {
+	repo      domain.ChangeRepository
+	retention time.Duration
+	clock     pkg.Clock
+}
+
+// PurgeExpired implements Purger.
+func (p changeFeedPurger) PurgeExpired(ctx context.Context) (int, error) {
+	removed, err := p.repo.Prune(ctx, p.clock.Now().Add(-p.retention))
+	return int(removed), err
+}
+
+// MaintenanceRun reports one pass of the maintenance job, as shown on
+// /health.
+type MaintenanceRun struct {
+	At         domain.Time       `json:"at"`
+	DurationMS int64             `json:"duration_ms"`
+	Removed    map[string]int    `json:"removed"`          // component -> entries purged
+	Errors     map[string]string `json:"errors,omitempty"` // component -> purge error
+}
+
+type namedPurger struct {
+	name   string
+	purger Purger
+}
+
+// maintenance periodically asks each registered Purger to drop expired
+// entries. It is owned by App, which closes it before the components it
+// purges.
+type maintenance struct {
+	interval time.Duration
+	log      *slog.Logger
+	clock    pkg.Clock
+	purgers  []namedPurger
+
+	mu   sync.Mutex
+	last *MaintenanceRun
+
+	// paused skips the scheduled passes (maintenance.paused in the runtime
+	// config API) until cleared.
+	paused atomic.Bool
+
+	ctx       context.Context // cancelled by close to abort a purge in flight
+	cancel    context.CancelFunc
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMaintenance(interval time.Duration, log *slog.Logger, clock pkg.Clock) *maintenance {
+	if interval <= 0 {
+		interval = defaultMaintenanceInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &maintenance{
+		interval: interval,
+		log:      log,
+		clock:    clock,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// add registers p under name; call it before start.
+func (m *maintenance) add(name string, p Purger) {
+	m.purgers = append(m.purgers, namedPurger{name: name, purger: p})
+}
+
+// start launches the job. Without purgers it does nothing.
+func (m *maintenance) start() {
+	if m == nil || len(m.purgers) == 0 {
+		return
+	}
+	m.started = true
+	go m.loop()
+}
+
+func (m *maintenance) loop() {
+	defer close(m.done)
+	timer := m.clock.NewTimer(m.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			if !m.paused.Load() {
+				m.run()
+			}
+			timer.Reset(m.interval)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// run purges every component once, logs the counts, and records the pass
+// for lastRun.
+func (m *maintenance) run() MaintenanceRun {
+	start := m.clock.Now()
+	run := MaintenanceRun{At: domain.NewTime(start.UTC()), Removed: make(map[string]int, len(m.purgers))}
+	for _, p := range m.purgers {
+		removed, err := p.purger.PurgeExpired(m.ctx)
+		run.Removed[p.name] = removed
+		if err != nil {
+			if run.Errors == nil {
+				run.Errors = make(map[string]string)
+			}
+			run.Errors[p.name] = err.Error()
+			m.log.Warn("maintenance purge failed", slog.String("component", p.name), slog.Int("removed", removed), slog.Any("error", err))
+			continue
+		}
+		m.log.Info("maintenance purge", slog.String("component", p.name), slog.Int("removed", removed))
+	}
+	run.DurationMS = m.clock.Now().Sub(start).Milliseconds()
+
+	m.mu.Lock()
+	m.last = &run
+	m.mu.Unlock()
+	return run
+}
+
+// lastRun returns the most recent pass; ok is false before the first one.
+func (m *maintenance) lastRun() (run MaintenanceRun, ok bool) {
+	if m == nil {
+		return MaintenanceRun{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return MaintenanceRun{}, false
+	}
+	return *m.last, true
+}
+
+// close stops the job, aborting a purge in flight, and waits for it to
+// return.
+func (m *maintenance) close() {
+	if m == nil {
+		return
+	}
+	m.closeOnce.Do(func() {
+		m.cancel()
+		if m.started {
+			<-m.done
+		}
+	})
+}
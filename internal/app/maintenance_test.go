@@ -0,0 +1,207 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/simp-lee/cache"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// fakePurger reports a fixed result and counts its invocations. When block
+// is set, PurgeExpired waits for ctx to end and then calls onReturn.
+type fakePurger struct {
+	removed  int
+	err      error
+	block    bool
+	entered  chan struct{}
+	onReturn func()
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakePurger) PurgeExpired(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if f.block {
+		close(f.entered)
+		<-ctx.Done()
+		f.onReturn()
+		return 0, ctx.Err()
+	}
+	return f.removed, f.err
+}
+
+func (f *fakePurger) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// waitForRun polls m until it has completed a pass.
+func waitForRun(t *testing.T, m *maintenance) MaintenanceRun {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if run, ok := m.lastRun(); ok {
+			return run
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("maintenance did not run")
+	return MaintenanceRun{}
+}
+
+func TestMaintenance_RunsPurgersAndAggregatesCounts(t *testing.T) {
+	cachePurge := &fakePurger{removed: 3}
+	idemPurge := &fakePurger{removed: 1, err: errors.New("store unavailable")}
+
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := newMaintenance(time.Minute, discardLogger(), clock)
+	m.add("response_cache", cachePurge)
+	m.add("idempotency", idemPurge)
+	m.start()
+	defer m.close()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+	if _, ok := m.lastRun(); ok {
+		t.Fatal("lastRun() ok before the first pass")
+	}
+	clock.Advance(time.Second)
+	clock.BlockUntil(1) // the loop re-arms its timer once the pass is recorded
+
+	run, ok := m.lastRun()
+	if !ok {
+		t.Fatal("lastRun() ok = false after the interval elapsed")
+	}
+	if run.Removed["response_cache"] != 3 || run.Removed["idempotency"] != 1 {
+		t.Errorf("Removed = %v, want response_cache=3 idempotency=1", run.Removed)
+	}
+	if run.Errors["idempotency"] != "store unavailable" || len(run.Errors) != 1 {
+		t.Errorf("Errors = %v, want only idempotency", run.Errors)
+	}
+	if want := time.Date(2026, 1, 1, 0, 1, 0, 0, time.UTC); !run.At.Equal(want) {
+		t.Errorf("At = %v, want %v", run.At, want)
+	}
+	if cachePurge.callCount() == 0 || idemPurge.callCount() == 0 {
+		t.Errorf("calls = %d, %d; want both purgers invoked", cachePurge.callCount(), idemPurge.callCount())
+	}
+}
+
+func TestMaintenance_CloseWaitsForPurgeBeforeStoresClose(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+
+	p := &fakePurger{block: true, entered: make(chan struct{}), onReturn: func() { record("purge returned") }}
+	clock := pkg.NewFakeClock(time.Now())
+	m := newMaintenance(time.Minute, discardLogger(), clock)
+	m.add("response_cache", p)
+	m.start()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	<-p.entered
+
+	m.close()
+	record("store closed")
+	m.close() // idempotent
+
+	clock.Advance(time.Hour)
+	if got := p.callCount(); got != 1 {
+		t.Errorf("calls = %d, want 1 (no pass after close)", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "purge returned" || events[1] != "store closed" {
+		t.Errorf("events = %v, want the purge to return before the store closes", events)
+	}
+}
+
+func TestMaintenance_WithoutPurgers(t *testing.T) {
+	m := newMaintenance(0, discardLogger(), pkg.RealClock)
+	if m.interval != defaultMaintenanceInterval {
+		t.Errorf("interval = %v, want default %v", m.interval, defaultMaintenanceInterval)
+	}
+	m.start()
+	m.close() // must not block
+
+	var nilM *maintenance
+	nilM.start()
+	nilM.close()
+	if _, ok := nilM.lastRun(); ok {
+		t.Error("nil lastRun() ok = true")
+	}
+}
+
+func TestCachePurger_RemovesOnlyExpired(t *testing.T) {
+	c := cache.NewCache(cache.Options{CleanupInterval: 0})
+	defer c.Close()
+	c.SetWithExpiration("a", 1, time.Millisecond)
+	c.SetWithExpiration("b", 2, time.Millisecond)
+	c.SetWithExpiration("c", 3, time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	removed, err := cachePurger{cache: c}.PurgeExpired(context.Background())
+	if err != nil || removed != 2 {
+		t.Fatalf("PurgeExpired() = %d, %v; want 2, nil", removed, err)
+	}
+	if c.Count() != 1 || !c.Has("c") {
+		t.Errorf("Count() = %d, keys %v; want only c", c.Count(), c.Keys())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (cachePurger{cache: c}).PurgeExpired(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("PurgeExpired(cancelled) error = %v, want context.Canceled", err)
+	}
+}
+
+func TestNew_MaintenanceReportedOnHealth(t *testing.T) {
+	cfg := testutil.NewTestConfig(func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+		c.Server.MaintenanceInterval = config.Duration(10 * time.Millisecond)
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	waitForRun(t, a.maintenance)
+	w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body struct {
+		Status      string          `json:"status"`
+		Maintenance *MaintenanceRun `json:"maintenance"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode /health: %v", err)
+	}
+	if body.Maintenance == nil {
+		t.Fatalf("/health = %s, want a maintenance report", w.Body.String())
+	}
+	if _, ok := body.Maintenance.Removed["response_cache"]; !ok {
+		t.Errorf("maintenance.removed = %v, want response_cache", body.Maintenance.Removed)
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// markdownRenderer converts CommonMark to HTML. Raw HTML in the source is
+// omitted by goldmark's default (unsafe rendering is not enabled), and the
+// output is additionally passed through markdownPolicy before being trusted.
+var markdownRenderer = goldmark.New()
+
+// markdownPolicy is the allowlist sanitizer applied to rendered markdown.
+// It keeps common formatting tags (headings, emphasis, lists, links, code,
+// blockquotes, tables, images) and strips scripts, iframes, event handler
+// attributes, and non-http(s)/mailto URL schemes such as javascript:.
+var markdownPolicy = newMarkdownPolicy()
+
+func newMarkdownPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// renderMarkdown renders src as CommonMark and returns sanitized HTML that is
+// safe to embed in templates. Rendering errors yield an empty string.
+func renderMarkdown(src string) template.HTML {
+	if src == "" {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(src), &buf); err != nil {
+		slog.Warn("markdown render failed", slog.Any("error", err))
+		return ""
+	}
+
+	// Sanitization is the last step: only its output is marked as trusted HTML.
+	return template.HTML(markdownPolicy.SanitizeBytes(buf.Bytes()))
+}
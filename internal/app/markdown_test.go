@@ -0,0 +1,88 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown_Formatting(t *testing.T) {
+	got := string(renderMarkdown("# Title\n\nSome **bold** and _italic_ text with a [link](https://example.com).\n\n- one\n- two\n\n`code`"))
+
+	for _, want := range []string{
+		"<h1>Title</h1>",
+		"<strong>bold</strong>",
+		"<em>italic</em>",
+		`href="https://example.com"`,
+		"<li>one</li>",
+		"<code>code</code>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderMarkdown() = %q; want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestRenderMarkdown_Empty(t *testing.T) {
+	if got := renderMarkdown(""); got != "" {
+		t.Errorf("renderMarkdown(\"\") = %q; want empty", got)
+	}
+}
+
+func TestRenderMarkdown_NeutralizesXSS(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		forbidden []string
+		keep      string
+	}{
+		{
+			name:      "inline script tag",
+			input:     "hello <script>alert(1)</script> **world**",
+			forbidden: []string{"<script", "alert(1)</script>"},
+			keep:      "<strong>world</strong>",
+		},
+		{
+			name:      "javascript link",
+			input:     "[click](javascript:alert(1))",
+			forbidden: []string{"javascript:"},
+			keep:      "click",
+		},
+		{
+			name:      "onerror attribute on inline img",
+			input:     `<img src="x" onerror="alert(1)"> text`,
+			forbidden: []string{"onerror", "alert(1)"},
+			keep:      "text",
+		},
+		{
+			name:      "iframe block",
+			input:     "<iframe src=\"https://evil.example\"></iframe>\n\nparagraph",
+			forbidden: []string{"<iframe"},
+			keep:      "<p>paragraph</p>",
+		},
+		{
+			name:      "markdown image with javascript src",
+			input:     "![x](javascript:alert(1))",
+			forbidden: []string{"javascript:"},
+		},
+		{
+			name:      "event handler in html block",
+			input:     "<div onclick=\"steal()\">hi</div>",
+			forbidden: []string{"onclick", "steal()"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(renderMarkdown(tt.input))
+			lower := strings.ToLower(got)
+			for _, f := range tt.forbidden {
+				if strings.Contains(lower, strings.ToLower(f)) {
+					t.Errorf("renderMarkdown(%q) = %q; must not contain %q", tt.input, got, f)
+				}
+			}
+			if tt.keep != "" && !strings.Contains(got, tt.keep) {
+				t.Errorf("renderMarkdown(%q) = %q; want it to contain %q", tt.input, got, tt.keep)
+			}
+		})
+	}
+}
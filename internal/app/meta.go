@@ -0,0 +1,74 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// metaCacheControl is sent with robots.txt and security.txt, which only
+// change with the configuration.
+const metaCacheControl = "public, max-age=86400"
+
+// securityTxtPath is where RFC 9116 places security.txt.
+const securityTxtPath = "/.well-known/security.txt"
+
+// defaultSecurityTTL is how long security.txt stays valid when
+// server.meta.security_expires is unset.
+const defaultSecurityTTL = 365 * 24 * time.Hour
+
+// registerMetaRoutes serves GET /robots.txt and, when a security contact is
+// configured, GET /.well-known/security.txt (RFC 9116). Both bodies are
+// built once from meta.
+func registerMetaRoutes(r *gin.Engine, meta config.MetaConfig) {
+	r.GET("/robots.txt", metaFile(robotsTxt(meta.Disallow())))
+	if meta.SecurityContact != "" {
+		expires := meta.SecurityExpires
+		if expires == "" {
+			expires = time.Now().Add(defaultSecurityTTL).UTC().Format(time.RFC3339)
+		}
+		r.GET(securityTxtPath, securityFile(securityTxt(meta.SecurityContact, expires)))
+	}
+}
+
+// robotsTxt disallows disallow for every crawler.
+func robotsTxt(disallow []string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, p := range disallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	return b.String()
+}
+
+func securityTxt(contact, expires string) string {
+	return "Contact: " + contact + "\nExpires: " + expires + "\n"
+}
+
+func metaFile(body string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", metaCacheControl)
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(body))
+	}
+}
+
+// securityFile serves body followed by a Canonical field with the file's
+// own URL on the request's validated origin (see pkg.AbsoluteURL), or
+// without one when the request has none.
+func securityFile(body string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		text := body
+		if canonical, ok := pkg.AbsoluteURL(c, securityTxtPath); ok {
+			text += "Canonical: " + canonical + "\n"
+		}
+		metaFile(text)(c)
+	}
+}
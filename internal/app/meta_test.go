@@ -0,0 +1,80 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func serveMeta(t *testing.T, meta config.MetaConfig, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	registerMetaRoutes(r, meta)
+	return testutil.Serve(r, httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+func TestRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name string
+		meta config.MetaConfig
+		want string
+	}{
+		{"defaults", config.MetaConfig{}, "User-agent: *\nDisallow: /api\nDisallow: /admin\n"},
+		{"configured", config.MetaConfig{RobotsDisallow: []string{"/private"}}, "User-agent: *\nDisallow: /private\n"},
+		{"allow all", config.MetaConfig{RobotsDisallow: []string{}}, "User-agent: *\nDisallow:\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveMeta(t, tt.meta, "/robots.txt")
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("GET /robots.txt = %d %q, want 200 %q", w.Code, w.Body.String(), tt.want)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != metaCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", cc, metaCacheControl)
+			}
+		})
+	}
+}
+
+func TestSecurityTxt(t *testing.T) {
+	if w := serveMeta(t, config.MetaConfig{}, "/.well-known/security.txt"); w.Code != http.StatusNotFound {
+		t.Errorf("without a contact: status = %d, want 404", w.Code)
+	}
+
+	w := serveMeta(t, config.MetaConfig{
+		SecurityContact: "mailto:security@example.com",
+		SecurityExpires: "2030-01-01T00:00:00Z",
+	}, "/.well-known/security.txt")
+	want := "Contact: mailto:security@example.com\nExpires: 2030-01-01T00:00:00Z\n"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Errorf("GET security.txt = %d %q, want 200 %q", w.Code, w.Body.String(), want)
+	}
+	if ct, cc := w.Header().Get("Content-Type"), w.Header().Get("Cache-Control"); ct != "text/plain; charset=utf-8" || cc != metaCacheControl {
+		t.Errorf("Content-Type = %q, Cache-Control = %q", ct, cc)
+	}
+
+	if strings.Contains(w.Body.String(), "Canonical:") {
+		t.Errorf("security.txt = %q, want no Canonical field without a validated origin", w.Body.String())
+	}
+
+	// Without an expiry the file stays valid for a year from startup.
+	w = serveMeta(t, config.MetaConfig{SecurityContact: "https://example.com/security"}, "/.well-known/security.txt")
+	_, value, _ := strings.Cut(w.Body.String(), "Expires: ")
+	expires, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		t.Fatalf("Expires %q: %v", value, err)
+	}
+	if d := time.Until(expires); d < defaultSecurityTTL-time.Minute || d > defaultSecurityTTL {
+		t.Errorf("Expires in %v, want about %v", d, defaultSecurityTTL)
+	}
+}
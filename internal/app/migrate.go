@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// Migrate is the migrate command of cmd/server: it waits for the database
+// as configured by database.wait, auto-migrates the models of every module
+// as New does in debug mode, runs the schema check, and closes everything
+// again without serving. Canceling ctx aborts the wait and the migration.
+func Migrate(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil {
+		return errors.New("config is nil")
+	}
+	if err := waitForDatabase(ctx, &cfg.Database, slog.Default()); err != nil {
+		return err
+	}
+	c := *cfg
+	c.Server.Cache.Warm = nil // nothing will be served
+	a, err := newApp(ctx, &c, true)
+	if err != nil {
+		return err
+	}
+	return a.Close()
+}
+
+// waitForDatabase connects to the database until it succeeds, every
+// wait.interval for up to wait.timeout, or only once when no timeout is
+// configured.
+func waitForDatabase(ctx context.Context, cfg *config.DatabaseConfig, log *slog.Logger) error {
+	deadline := time.Now().Add(cfg.Wait.Timeout.Std())
+	interval := cfg.Wait.EffectiveInterval()
+	for attempt := 1; ; attempt++ {
+		err := pingDatabase(ctx, cfg)
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if time.Until(deadline) < interval {
+			return fmt.Errorf("database not reachable after %d attempt(s): %w", attempt, err)
+		}
+		log.Warn("database not reachable, retrying",
+			slog.Int("attempt", attempt), slog.Duration("interval", interval), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// pingDatabase opens the database, pings it and closes it again.
+func pingDatabase(ctx context.Context, cfg *config.DatabaseConfig) error {
+	db, err := config.SetupDatabase(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = config.CloseReplicas(db)
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
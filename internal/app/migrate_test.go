@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestMigrate_CreatesTablesOutsideDebugMode(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(filepath.Join(t.TempDir(), "app.db")))
+	cfg.Database.SchemaCheck = config.SchemaCheckStrict
+
+	// Outside debug mode New does not migrate, so the strict schema check
+	// refuses the empty database.
+	if a, err := New(cfg); err == nil {
+		_ = a.Close()
+		t.Fatal("New() on an empty database succeeded; want the strict schema check to fail")
+	}
+
+	if err := Migrate(context.Background(), cfg); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() after Migrate error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	for _, table := range []string{"users", "notes", "changes", "notifications"} {
+		if !a.db.Migrator().HasTable(table) {
+			t.Errorf("table %s missing after Migrate", table)
+		}
+	}
+}
+
+func TestMigrate_CanceledContext(t *testing.T) {
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(filepath.Join(t.TempDir(), "app.db")))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Migrate(ctx, cfg); !errors.Is(err, context.Canceled) {
+		t.Errorf("Migrate(canceled) error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitForDatabase(t *testing.T) {
+	// A database inside a regular file can never be opened.
+	notDir := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(notDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unreachable := config.DatabaseConfig{Driver: "sqlite", SQLite: config.SQLiteConfig{Path: filepath.Join(notDir, "app.db")}}
+	log := slog.New(slog.DiscardHandler)
+
+	t.Run("reachable", func(t *testing.T) {
+		cfg := config.DatabaseConfig{Driver: "sqlite", SQLite: config.SQLiteConfig{Path: testutil.MemoryDSN(t)}}
+		if err := waitForDatabase(context.Background(), &cfg, log); err != nil {
+			t.Errorf("waitForDatabase() error = %v", err)
+		}
+	})
+
+	t.Run("no timeout tries once", func(t *testing.T) {
+		cfg := unreachable
+		err := waitForDatabase(context.Background(), &cfg, log)
+		if err == nil || !strings.Contains(err.Error(), "after 1 attempt(s)") {
+			t.Errorf("waitForDatabase() error = %v, want a failure after 1 attempt", err)
+		}
+	})
+
+	t.Run("retries until the timeout", func(t *testing.T) {
+		cfg := unreachable
+		cfg.Wait = config.WaitConfig{Timeout: config.Duration(100 * time.Millisecond), Interval: config.Duration(20 * time.Millisecond)}
+		start := time.Now()
+		err := waitForDatabase(context.Background(), &cfg, log)
+		if err == nil || strings.Contains(err.Error(), "after 1 attempt(s)") {
+			t.Errorf("waitForDatabase() error = %v, want a failure after several attempts", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("gave up after %v, want about the 100ms timeout", elapsed)
+		}
+	})
+
+	t.Run("cancel aborts the wait", func(t *testing.T) {
+		cfg := unreachable
+		cfg.Wait = config.WaitConfig{Timeout: config.Duration(time.Minute)}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := waitForDatabase(ctx, &cfg, log); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("waitForDatabase() error = %v, want the context error", err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/internal/testutil/apptest"
+)
+
+// TestNotifications_UserDeletePublishesToActor deletes a user through the API
+// and checks that the caller gets an unread notification, both from the
+// unread-count endpoint and in the nav badge.
+func TestNotifications_UserDeletePublishesToActor(t *testing.T) {
+	a := apptest.NewTestApp(t, testutil.WithAuth())
+	// The actor is seeded first so it has apptest.DefaultUserID; its
+	// notification references it through a foreign key.
+	users := testutil.SeedUsers(t, a.DB(), domain.User{}, domain.User{})
+	victim := users[1]
+
+	w := testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", victim.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = testutil.Serve(a.Handler(), apptest.AuthenticatedRequest(t, a, http.MethodGet, "/api/v1/notifications/unread-count", nil))
+	var resp struct {
+		Data struct {
+			Count int64 `json:"count"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v (body %s)", err, w.Body.String())
+	}
+	if w.Code != http.StatusOK || resp.Data.Count != 1 {
+		t.Fatalf("unread-count = %d %s, want 200 with count 1", w.Code, w.Body.String())
+	}
+
+	page := apptest.AuthenticatedRequest(t, a, http.MethodGet, "/", nil)
+	page.Header.Set("Accept", "text/html")
+	w = testutil.Serve(a.Handler(), page)
+	if !strings.Contains(w.Body.String(), `data-unread-count="1"`) {
+		t.Errorf("home page has no unread badge for the actor:\n%s", w.Body.String())
+	}
+
+	w = testutil.Serve(a.Handler(), testutil.NewJSONRequest(t, http.MethodGet, "/", nil))
+	if strings.Contains(w.Body.String(), "data-unread-count") {
+		t.Error("anonymous home page shows an unread badge")
+	}
+}
+
+func TestNotifications_RoutesRequireAuth(t *testing.T) {
+	a := apptest.NewTestApp(t)
+
+	w := testutil.Serve(a.Handler(), testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/notifications", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/notifications with auth disabled: status = %d, want 404", w.Code)
+	}
+}
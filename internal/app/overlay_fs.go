@@ -0,0 +1,157 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// overlayFS layers top over base: a path present in top is read from top,
+// anything else from base, and directories list the entries of both. It
+// lets a deployment replace individual templates and assets
+// (server.templates.override_dir) without copying the rest.
+//
+// A file top replaces also hides the precompressed siblings base has for it
+// (app.css.br, app.css.gz), which would otherwise be served in its place
+// with the old content.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+// newOverlayFS returns fsys layered over base, or base itself when fsys is
+// nil.
+func newOverlayFS(fsys, base fs.FS) fs.FS {
+	if fsys == nil {
+		return base
+	}
+	return overlayFS{top: fsys, base: base}
+}
+
+// WithTemplateOverrides layers fsys over the renderer's filesystem: any
+// template (layout, partial or page) or static/manifest.json present in
+// fsys replaces the one in the base filesystem.
+func WithTemplateOverrides(fsys fs.FS) TemplateOption {
+	return func(r *TemplateRenderer) {
+		r.fs = newOverlayFS(fsys, r.fs)
+	}
+}
+
+// openOverrideDir opens the server.templates.override_dir directory. It
+// must exist when configured; an empty directory overrides nothing.
+func openOverrideDir(dir string) (fs.FS, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stat template override directory %q: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template override directory %q is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// Open implements fs.FS.
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if o.shadowed(name) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return o.base.Open(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.IsDir() {
+		return f, nil
+	}
+	entries, err := o.ReadDir(name)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &overlayDir{File: f, entries: entries}, nil
+}
+
+// ReadDir implements fs.ReadDirFS, merging the entries of both layers
+// sorted by name; top's entry wins where both have one.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	top, topErr := fs.ReadDir(o.top, name)
+	if topErr != nil && !errors.Is(topErr, fs.ErrNotExist) {
+		return nil, topErr
+	}
+	base, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && (topErr != nil || !errors.Is(baseErr, fs.ErrNotExist)) {
+		return nil, baseErr
+	}
+
+	seen := make(map[string]bool, len(top))
+	entries := make([]fs.DirEntry, 0, len(top)+len(base))
+	for _, e := range top {
+		seen[e.Name()] = true
+		entries = append(entries, e)
+	}
+	for _, e := range base {
+		if seen[e.Name()] || o.shadowed(joinPath(name, e.Name())) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// shadowed reports whether name is a precompressed sibling of a file top
+// replaces, and so must not be read from base.
+func (o overlayFS) shadowed(name string) bool {
+	for _, enc := range precompressedEncodings {
+		if stem, ok := strings.CutSuffix(name, enc.ext); ok {
+			info, err := fs.Stat(o.top, stem)
+			return err == nil && info.Mode().IsRegular()
+		}
+	}
+	return false
+}
+
+// joinPath joins a directory and entry name as fs paths, where the root
+// is ".".
+func joinPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// overlayDir is a directory of top whose listing also includes base's
+// entries.
+type overlayDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+// ReadDir implements fs.ReadDirFile over the merged entries.
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	rest = rest[:min(n, len(rest))]
+	d.offset += len(rest)
+	return rest, nil
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// writeOverride writes content to name under dir, creating parents.
+func writeOverride(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestOverlayFS(t *testing.T) {
+	base := fstest.MapFS{
+		"static/css/app.css":    {Data: []byte("base css")},
+		"static/css/app.css.gz": {Data: []byte("base css gzipped")},
+		"static/js/app.js":      {Data: []byte("base js")},
+		"static/js/app.js.gz":   {Data: []byte("base js gzipped")},
+	}
+	top := fstest.MapFS{
+		"static/css/app.css":  {Data: []byte("custom css")},
+		"static/img/logo.svg": {Data: []byte("<svg/>")},
+	}
+	fsys := newOverlayFS(top, base)
+
+	if err := fstest.TestFS(fsys, "static/css/app.css", "static/js/app.js", "static/js/app.js.gz", "static/img/logo.svg"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"static/css/app.css":  "custom css",
+		"static/js/app.js":    "base js",
+		"static/img/logo.svg": "<svg/>",
+	} {
+		if got, err := fs.ReadFile(fsys, name); err != nil || string(got) != want {
+			t.Errorf("ReadFile(%s) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	// The base's app.css.gz is a stale copy of the replaced file.
+	if _, err := fs.Stat(fsys, "static/css/app.css.gz"); !os.IsNotExist(err) {
+		t.Errorf("Stat(app.css.gz) error = %v, want not exist", err)
+	}
+
+	entries, err := fs.ReadDir(fsys, "static")
+	if err != nil {
+		t.Fatalf("ReadDir(static) error = %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, ","); got != "css,img,js" {
+		t.Errorf("ReadDir(static) = %s, want css,img,js", got)
+	}
+
+	if _, ok := newOverlayFS(nil, base).(fstest.MapFS); !ok {
+		t.Error("newOverlayFS(nil, base) should return base")
+	}
+}
+
+func TestTemplateRenderer_Overrides(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		name := "release"
+		if debug {
+			name = "debug"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeOverride(t, dir, "templates/partials/nav.html", `{{ define "nav" }}<nav>Acme</nav>{{ end }}`)
+
+			r, err := NewTemplateRenderer(stampedFS(), debug, WithTemplateOverrides(os.DirFS(dir)))
+			if err != nil {
+				t.Fatalf("NewTemplateRenderer() error: %v", err)
+			}
+			if body := renderBody(t, r, "user/list.html"); !strings.Contains(body, "<nav>Acme</nav>") || strings.Contains(body, "Navigation") {
+				t.Errorf("user/list.html = %q, want the override nav", body)
+			}
+			if body := renderBody(t, r, "errors/404.html"); !strings.Contains(body, "<h1>404 Not Found</h1>") {
+				t.Errorf("errors/404.html = %q, want the base page", body)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderer_Debug_PicksUpAddedOverride(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewTemplateRenderer(stampedFS(), true, WithTemplateOverrides(os.DirFS(dir)))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	if body := renderBody(t, r, "errors/404.html"); !strings.Contains(body, "404 Not Found") {
+		t.Fatalf("errors/404.html = %q, want the base page", body)
+	}
+
+	writeOverride(t, dir, "templates/errors/404.html", `{{ template "base" . }}{{ define "content" }}<h1>Lost?</h1>{{ end }}`)
+	if body := renderBody(t, r, "errors/404.html"); !strings.Contains(body, "<h1>Lost?</h1>") {
+		t.Errorf("errors/404.html after adding an override = %q, want the override", body)
+	}
+}
+
+func TestRegisterStaticRoutes_Overrides(t *testing.T) {
+	overrides := fstest.MapFS{"static/css/app.css": {Data: []byte("body{color:red}"), ModTime: time.Unix(1_700_000_000, 0)}}
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "release", nil, overrides); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		return testutil.Serve(r, req)
+	}
+	if w := serve("/static/css/app.css"); w.Code != http.StatusOK || w.Body.String() != "body{color:red}" {
+		t.Errorf("GET /static/css/app.css = %d %q, want the override", w.Code, w.Body.String())
+	}
+	if w := serve("/static/js/app.js"); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("GET /static/js/app.js = %d with %d bytes, want the embedded asset", w.Code, w.Body.Len())
+	}
+}
+
+func TestNew_TemplateOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	writeOverride(t, dir, "templates/partials/nav.html", `{{ define "nav" }}<nav>Acme Corp</nav>{{ end }}`)
+
+	dsn := testutil.MemoryDSN(t)
+	testutil.OpenTestDB(t, dsn)
+	cfg := testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Templates.OverrideDir = dir
+	})
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<nav>Acme Corp</nav>") {
+		t.Errorf("GET /users status = %d, want 200 with the override nav; body = %.200s", w.Code, w.Body.String())
+	}
+
+	cfg = testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Templates.OverrideDir = filepath.Join(dir, "missing")
+	})
+	if a, err := New(cfg); err == nil {
+		cleanupTestApp(t, a)
+		t.Error("New() with a missing override_dir succeeded, want an error")
+	}
+}
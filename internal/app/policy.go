@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// PolicyProvider is implemented by modules whose API routes need RBAC
+// permissions. With auth.rbac enabled, New installs the policies of every
+// module, in order, after the Auth middleware.
+type PolicyProvider interface {
+	Policies() []middleware.Policy
+}
+
+// appPolicies guard the API routes App registers itself.
+var appPolicies = []middleware.Policy{
+	{PathPrefix: "/api/v1/admin", Resource: "admin", Action: "read"},
+	{PathPrefix: runtimeConfigPath, Method: http.MethodPut, Resource: "admin", Action: "update"},
+	{PathPrefix: backupPath, Method: http.MethodPost, Resource: "admin", Action: "update"},
+}
+
+// collectPolicies returns appPolicies followed by the policies of each
+// module that declares some, and rejects incomplete ones.
+func collectPolicies(modules []Module) ([]middleware.Policy, error) {
+	policies := slices.Clone(appPolicies)
+	for _, m := range modules {
+		if p, ok := m.(PolicyProvider); ok {
+			policies = append(policies, p.Policies()...)
+		}
+	}
+	for _, p := range policies {
+		switch {
+		case (p.PathPrefix == "") == (p.Route == ""):
+			return nil, fmt.Errorf("policy %q: exactly one of path prefix and route is required", p)
+		case p.Route != "" && p.AllowSelf:
+			return nil, fmt.Errorf("policy %q: allow self needs a path prefix", p)
+		case p.Resource != "" && p.Action == "":
+			return nil, fmt.Errorf("policy %q: action is required with a resource", p)
+		case p.Resource == "" && p.AllowSelf:
+			return nil, fmt.Errorf("policy %q: allow self needs a resource", p)
+		}
+	}
+	return policies, nil
+}
+
+// installPolicies adds the permission check of each policy to chain. A
+// self-service policy checks PathPrefix+"/:id" with RequirePermissionOrSelf
+// and the rest of its routes with ginx.RequirePermission.
+func installPolicies(chain *ginx.Chain, svc rbac.Service, policies []middleware.Policy) {
+	for _, p := range policies {
+		if p.Resource == "" {
+			continue
+		}
+		cond := ginx.Condition(p.Matches)
+		if !p.AllowSelf {
+			chain.When(cond, ginx.RequirePermission(svc, p.Resource, p.Action))
+			continue
+		}
+		byID := routeIs(p.PathPrefix + "/:id")
+		chain.When(ginx.And(cond, ginx.Not(byID)), ginx.RequirePermission(svc, p.Resource, p.Action))
+		chain.When(ginx.And(cond, byID), middleware.RequirePermissionOrSelf(svc, p.Resource, p.Action, "id"))
+	}
+}
+
+// policyMatrix renders policies for the startup log.
+func policyMatrix(policies []middleware.Policy) []string {
+	out := make([]string, len(policies))
+	for i, p := range policies {
+		out[i] = p.String()
+	}
+	return out
+}
+
+// unguardedRoutes returns the state-changing API routes ("POST /api/...")
+// that no policy covers, leaving out public paths, which skip
+// authentication altogether. public may be nil.
+func unguardedRoutes(routes gin.RoutesInfo, policies []middleware.Policy, public *publicPaths) []string {
+	var out []string
+	for _, r := range routes {
+		if !isMutatingMethod(r.Method) || !isAPIPath(r.Path) || (public != nil && public.match(r.Method, r.Path)) {
+			continue
+		}
+		if !slices.ContainsFunc(policies, func(p middleware.Policy) bool { return p.Covers(r.Method, r.Path) }) {
+			out = append(out, r.Method+" "+r.Path)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isAPIPath(path string) bool {
+	return path == "/api" || strings.HasPrefix(path, "/api/")
+}
+
+// errUnguardedRoutes is returned by New in release mode when RBAC is enabled
+// and a state-changing API route has no policy.
+var errUnguardedRoutes = errors.New("api routes without an authorization policy")
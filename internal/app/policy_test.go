@@ -0,0 +1,149 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// policyModule is a Module that only declares policies.
+type policyModule struct {
+	policies []middleware.Policy
+}
+
+func (policyModule) RegisterRoutes(*gin.RouterGroup, *gin.RouterGroup) {}
+
+func (m policyModule) Policies() []middleware.Policy { return m.policies }
+
+func TestCollectPolicies(t *testing.T) {
+	widgets := middleware.Policy{PathPrefix: "/api/v1/widgets", Method: http.MethodPost, Resource: "widgets", Action: "create"}
+	got, err := collectPolicies([]Module{policyModule{policies: []middleware.Policy{widgets}}, &mockModule{}})
+	if err != nil {
+		t.Fatalf("collectPolicies() error = %v", err)
+	}
+	if want := append(slices.Clone(appPolicies), widgets); !slices.Equal(got, want) {
+		t.Errorf("collectPolicies() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []middleware.Policy{
+		{Resource: "widgets", Action: "read"},
+		{PathPrefix: "/api/v1/widgets", Resource: "widgets"},
+		{PathPrefix: "/api/v1/widgets", AllowSelf: true},
+		{PathPrefix: "/api/v1/widgets", Route: "/api/v1/widgets/:id/archive", Resource: "widgets", Action: "archive"},
+		{Route: "/api/v1/widgets/:id/archive", Resource: "widgets", Action: "archive", AllowSelf: true},
+	} {
+		if _, err := collectPolicies([]Module{policyModule{policies: []middleware.Policy{bad}}}); err == nil {
+			t.Errorf("collectPolicies(%+v) error = nil, want an incomplete policy error", bad)
+		}
+	}
+}
+
+func TestInstallPolicies_Enforces(t *testing.T) {
+	svc := &fakeRBAC{grants: map[string][]string{"7": {"widgets:create"}}}
+	chain := ginx.NewChain()
+	chain.Use(func(next gin.HandlerFunc) gin.HandlerFunc { // stands in for ginx.Auth
+		return func(c *gin.Context) {
+			ginx.SetUserID(c, c.GetHeader("X-Test-User"))
+			next(c)
+		}
+	})
+	installPolicies(chain, svc, []middleware.Policy{
+		{PathPrefix: "/api/v1/widgets", Method: http.MethodPost, Resource: "widgets", Action: "create"},
+		{PathPrefix: "/api/v1/people", Method: http.MethodPut, Resource: "people", Action: "update", AllowSelf: true},
+		{PathPrefix: "/api/v1/open"},
+		{Route: "/api/v1/widgets/:id/archive", Method: http.MethodPost, Resource: "widgets", Action: "archive"},
+	})
+	r := gin.New()
+	r.Use(chain.Build())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/api/v1/widgets", ok)
+	r.GET("/api/v1/widgets", ok)
+	r.PUT("/api/v1/people/:id", ok)
+	r.POST("/api/v1/open", ok)
+	r.POST("/api/v1/widgets/:id/archive", ok)
+	r.POST("/api/v1/widgets/:id/restore", ok)
+
+	tests := []struct {
+		method, path, user string
+		want               int
+	}{
+		{http.MethodPost, "/api/v1/widgets", "7", http.StatusOK},
+		{http.MethodPost, "/api/v1/widgets", "8", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/widgets", "8", http.StatusOK},
+		{http.MethodPut, "/api/v1/people/8", "8", http.StatusOK},
+		{http.MethodPut, "/api/v1/people/9", "8", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/open", "8", http.StatusOK},
+		{http.MethodPost, "/api/v1/widgets/1/archive", "7", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/widgets/1/restore", "7", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := testutil.NewJSONRequest(t, tt.method, tt.path, "{}")
+		req.Header.Set("X-Test-User", tt.user)
+		if got := testutil.Serve(r, req).Code; got != tt.want {
+			t.Errorf("%s %s as %s: status = %d, want %d", tt.method, tt.path, tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestUnguardedRoutes(t *testing.T) {
+	r := gin.New()
+	ok := func(c *gin.Context) {}
+	r.POST("/api/v1/users", ok)
+	r.DELETE("/api/v1/users/:id", ok)
+	r.POST("/api/v1/widgets", ok)
+	r.GET("/api/v1/widgets", ok)
+	r.PATCH("/api/v1/widgets/:id", ok)
+	r.POST("/api/v1/auth/login", ok)
+	r.POST("/users", ok) // page route, guarded by CSRF and page permissions
+
+	policies := []middleware.Policy{
+		{PathPrefix: "/api/v1/users", Method: http.MethodPost, Resource: "users", Action: "create"},
+		{PathPrefix: "/api/v1/users", Method: http.MethodDelete, Resource: "users", Action: "delete"},
+		{Route: "/api/v1/widgets/:id", Method: http.MethodPatch, Resource: "widgets", Action: "update"},
+	}
+	got := unguardedRoutes(r.Routes(), policies, newPublicPaths([]string{"/api/v1/auth/login"}, false))
+	want := []string{"POST /api/v1/widgets"}
+	if !slices.Equal(got, want) {
+		t.Errorf("unguardedRoutes() = %v, want %v", got, want)
+	}
+}
+
+func TestNew_RBAC_BuiltInRoutesAllHavePolicies(t *testing.T) {
+	// Release mode turns an unguarded route into a startup error.
+	cfg := testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithMode(gin.ReleaseMode), func(c *config.Config) {
+		c.Server.CSRFSecret = "Release-CSRF-secret-0123456789-abcdef"
+	})
+	a, err := New(cfg)
+	if errors.Is(err, errUnguardedRoutes) {
+		t.Fatalf("New() error = %v, want every built-in mutating route covered", err)
+	}
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cleanupTestApp(t, a)
+}
+
+func TestPolicy_String(t *testing.T) {
+	tests := []struct {
+		p    middleware.Policy
+		want string
+	}{
+		{middleware.Policy{PathPrefix: "/api/v1/users", Method: http.MethodPut, Resource: "users", Action: "update", AllowSelf: true}, "PUT /api/v1/users -> users:update (or self)"},
+		{middleware.Policy{PathPrefix: "/api/v1/admin", Resource: "admin", Action: "read"}, "* /api/v1/admin -> admin:read"},
+		{middleware.Policy{PathPrefix: "/api/v1/notes"}, "* /api/v1/notes -> authenticated"},
+		{middleware.Policy{Route: "/api/v1/users/:id/erase", Method: http.MethodDelete, Resource: "users", Action: "erase"}, "DELETE /api/v1/users/:id/erase -> users:erase"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
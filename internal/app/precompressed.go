@@ -0,0 +1,130 @@
+package app
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// precompressedEncodings lists the sibling files servePrecompressed looks
+// for, in order of preference: app.css.br before app.css.gz for app.css.
+var precompressedEncodings = []struct {
+	coding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed answers a static file request with a precompressed
+// sibling of name, e.g. app.css.br, when the client accepts its encoding,
+// and reports whether it did. The response keeps the Content-Type of name
+// and always declines Range requests with a full 200, since byte ranges of
+// the encoded file are not ranges of name. Any response for a file that has
+// a sibling carries Vary: Accept-Encoding, including the plain fallback the
+// caller serves when servePrecompressed returns false. The variant is
+// written to w, with its own ETag from etags.
+func servePrecompressed(c *gin.Context, w http.ResponseWriter, fsys http.FileSystem, name string, etags *assetETags) bool {
+	if strings.HasSuffix(name, "/") || !isRegularFile(fsys, name) {
+		return false
+	}
+	accepted := acceptedEncodings(c.GetHeader("Accept-Encoding"))
+	served, hasVariant := false, false
+	for _, enc := range precompressedEncodings {
+		f, err := fsys.Open(name + enc.ext)
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			f.Close()
+			continue
+		}
+		if !hasVariant {
+			hasVariant = true
+			c.Writer.Header().Add("Vary", "Accept-Encoding")
+		}
+		if served || !accepted[enc.coding] {
+			f.Close()
+			continue
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := c.Writer.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Content-Encoding", enc.coding)
+		h.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+		if tag := etags.get(name + enc.ext); tag != "" {
+			h.Set("ETag", tag)
+		}
+		c.Request.Header.Del("Range")
+		c.Request.Header.Del("If-Range")
+		http.ServeContent(noRangesWriter{w}, c.Request, name, info.ModTime(), f)
+		f.Close()
+		served = true
+	}
+	return served
+}
+
+// isRegularFile reports whether name exists in fsys and is not a directory.
+func isRegularFile(fsys http.FileSystem, name string) bool {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular()
+}
+
+// acceptedEncodings returns the content codings of an Accept-Encoding header
+// with a non-zero quality; "*" accepts every coding not listed explicitly.
+func acceptedEncodings(header string) map[string]bool {
+	accepted := map[string]bool{}
+	wildcard := false
+	explicit := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		ok := true
+		if q, found := strings.CutPrefix(strings.ReplaceAll(strings.TrimSpace(params), " ", ""), "q="); found {
+			v, err := strconv.ParseFloat(q, 64)
+			ok = err == nil && v > 0
+		}
+		if coding == "*" {
+			wildcard = ok
+			continue
+		}
+		explicit[coding] = true
+		accepted[coding] = ok
+	}
+	if wildcard {
+		for _, enc := range precompressedEncodings {
+			if !explicit[enc.coding] {
+				accepted[enc.coding] = true
+			}
+		}
+	}
+	return accepted
+}
+
+// noRangesWriter replaces the Accept-Ranges: bytes http.ServeContent sets
+// with none, as the encoded variants are always served whole. ServeContent
+// always calls WriteHeader before writing the body.
+type noRangesWriter struct {
+	http.ResponseWriter
+}
+
+func (w noRangesWriter) WriteHeader(code int) {
+	w.Header().Set("Accept-Ranges", "none")
+	w.ResponseWriter.WriteHeader(code)
+}
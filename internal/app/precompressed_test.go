@@ -0,0 +1,105 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func precompressedRouter() *gin.Engine {
+	memFS := fstest.MapFS{
+		"app.css":    &fstest.MapFile{Data: []byte("plain-css")},
+		"app.css.gz": &fstest.MapFile{Data: []byte("gzip-css")},
+		"app.css.br": &fstest.MapFile{Data: []byte("br-css")},
+		"app.js":     &fstest.MapFile{Data: []byte("plain-js")},
+		"app.js.gz":  &fstest.MapFile{Data: []byte("gzip-js")},
+		"lib.js":     &fstest.MapFile{Data: []byte("plain-lib")},
+		"orphan.gz":  &fstest.MapFile{Data: []byte("orphan")},
+	}
+	r := gin.New()
+	r.GET("/static/*filepath", cacheStaticHandler("/static", http.FS(memFS), time.Hour, nil))
+	return r
+}
+
+func TestCacheStaticHandler_Precompressed(t *testing.T) {
+	r := precompressedRouter()
+
+	tests := []struct {
+		name, path, acceptEncoding string
+		wantBody, wantEncoding     string
+		wantVary                   bool
+	}{
+		{"brotli preferred", "/static/app.css", "gzip, deflate, br", "br-css", "br", true},
+		{"gzip only", "/static/app.css", "gzip", "gzip-css", "gzip", true},
+		{"brotli refused", "/static/app.css", "br;q=0, gzip;q=0.8", "gzip-css", "gzip", true},
+		{"wildcard", "/static/app.css", "*", "br-css", "br", true},
+		{"wildcard without gzip", "/static/app.css", "*, br;q=0", "gzip-css", "gzip", true},
+		{"no accept-encoding", "/static/app.css", "", "plain-css", "", true},
+		{"identity", "/static/app.css", "identity", "plain-css", "", true},
+		{"only gzip sibling", "/static/app.js", "br, gzip", "gzip-js", "gzip", true},
+		{"no sibling", "/static/lib.js", "br, gzip", "plain-lib", "", false},
+		{"variant requested directly", "/static/app.css.gz", "gzip", "gzip-css", "", false},
+		{"sibling without original", "/static/orphan", "gzip", "404 page not found\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get("Vary") == "Accept-Encoding"; got != tt.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding: %v", w.Header().Get("Vary"), tt.wantVary)
+			}
+			if tt.wantEncoding != "" {
+				if ct := w.Header().Get("Content-Type"); ct != "text/css; charset=utf-8" && ct != "text/javascript; charset=utf-8" {
+					t.Errorf("Content-Type = %q, want the original file's type", ct)
+				}
+				if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+					t.Errorf("Cache-Control = %q, want the mount's max-age", cc)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheStaticHandler_PrecompressedIgnoresRange(t *testing.T) {
+	r := precompressedRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.css", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("Range", "bytes=0-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "br-css" {
+		t.Errorf("response = %d %q, want the whole variant with 200", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Accept-Ranges"); got != "none" {
+		t.Errorf("Accept-Ranges = %q, want none", got)
+	}
+	if got := w.Header().Get("Content-Length"); got != "6" {
+		t.Errorf("Content-Length = %q, want 6", got)
+	}
+
+	// The plain file still honors ranges.
+	req = httptest.NewRequest(http.MethodGet, "/static/app.css", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "plain" {
+		t.Errorf("plain range = %d %q, want 206 plain", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// publicPaths matches requests against auth.public_paths, which skip
+// authentication. Plain paths are looked up in a map; only the entries
+// with a pattern are tried one by one. Entries and request paths are
+// compared in canonical form (see middleware.CanonicalRequestPath).
+type publicPaths struct {
+	caseInsensitiveAPI bool
+	exact              map[string][]string // canonical path → methods; "" for every method
+	patterns           []config.PublicPath
+}
+
+// newPublicPaths builds the matcher for entries, as validated by
+// config.Validate; an entry that does not parse is skipped.
+func newPublicPaths(entries []string, caseInsensitiveAPI bool) *publicPaths {
+	p := &publicPaths{caseInsensitiveAPI: caseInsensitiveAPI, exact: make(map[string][]string)}
+	for _, entry := range entries {
+		parsed, err := config.ParsePublicPath(entry)
+		if err != nil {
+			continue
+		}
+		parsed.Path = middleware.CanonicalRequestPath(parsed.Path, caseInsensitiveAPI)
+		if parsed.IsPattern() {
+			p.patterns = append(p.patterns, parsed)
+		} else {
+			p.exact[parsed.Path] = append(p.exact[parsed.Path], parsed.Method)
+		}
+	}
+	return p
+}
+
+// match reports whether a request for method and path is public.
+func (p *publicPaths) match(method, path string) bool {
+	path = middleware.CanonicalRequestPath(path, p.caseInsensitiveAPI)
+	for _, m := range p.exact[path] {
+		if (config.PublicPath{Method: m, Path: path}).Matches(method, path) {
+			return true
+		}
+	}
+	for _, pattern := range p.patterns {
+		if pattern.Matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// is is the ginx.Condition for public requests.
+func (p *publicPaths) is(c *gin.Context) bool {
+	return p.match(c.Request.Method, c.Request.URL.Path)
+}
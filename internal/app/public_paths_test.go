@@ -0,0 +1,64 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestPublicPaths_Match(t *testing.T) {
+	public := newPublicPaths([]string{"/api/v1/auth/login", "GET /api/v1/users", "/api/v1/public/**", "DELETE /api/v1/items/*"}, true)
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{http.MethodPost, "/api/v1/auth/login", true},
+		{http.MethodGet, "/api/v1/users", true},
+		{http.MethodHead, "/API/v1/Users/", true},
+		{http.MethodPost, "/api/v1/users", false},
+		{http.MethodGet, "/api/v1/users/1", false},
+		{http.MethodGet, "/api/v1/public", true},
+		{http.MethodPost, "/api/v1/public/docs/a.json", true},
+		{http.MethodGet, "/api/v1/publicity", false},
+		{http.MethodDelete, "/api/v1/items/7", true},
+		{http.MethodDelete, "/api/v1/items/7/tags", false},
+		{http.MethodGet, "/api/v1/items/7", false},
+	}
+	for _, tt := range tests {
+		if got := public.match(tt.method, tt.path); got != tt.want {
+			t.Errorf("match(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNew_MethodQualifiedAndPatternPublicPaths(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithAuth(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Auth.PublicPaths = append(c.Auth.PublicPaths, "GET /api/v1/users", "/api/v1/public/**")
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.DB())
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/users", http.StatusOK},
+		{http.MethodPost, "/api/v1/users", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/users/1", http.StatusUnauthorized},
+		// Past authentication, a public path without a route is a 404.
+		{http.MethodGet, "/api/v1/public/docs/a.json", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/publicity", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		w := testutil.Serve(a.engine, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/logger"
+	"gorm.io/gorm"
+)
+
+// QueryCountHeader carries the number of database queries a request ran,
+// in debug mode only.
+const QueryCountHeader = "X-DB-Query-Count"
+
+// queryCountPluginName is the key of the query counter in
+// gorm.Config.Plugins.
+const queryCountPluginName = "gobase:query_count"
+
+// maxCountedStatements bounds the distinct statements tracked per request;
+// later ones are still counted in the total.
+const maxCountedStatements = 256
+
+// queryCountKey is the request context key of the *requestQueries.
+type queryCountKey struct{}
+
+// queryCounter counts the queries each request runs (server.mode debug
+// only) to expose N+1 patterns. Its middleware puts a *requestQueries in
+// the request context; the gorm callbacks find it through the context the
+// repositories pass with WithContext and count every statement, keyed by
+// its SQL with placeholders so the same query with other parameters counts
+// as a repeat. Queries without a request context are not counted.
+//
+// The total goes out as X-DB-Query-Count and as the db_queries attribute
+// of logs made with the request context. When one statement runs more than
+// threshold times, a warning with its SQL and digest is logged once the
+// request is done. Outside debug mode neither the plugin nor the
+// middleware is installed.
+type queryCounter struct {
+	threshold int
+	logger    *slog.Logger
+}
+
+// requestQueries is the count of one request.
+type requestQueries struct {
+	mu          sync.Mutex
+	total       int
+	byStatement map[string]int
+}
+
+// LogValue implements slog.LogValuer, so the db_queries attribute reports
+// the count as of each log call.
+func (q *requestQueries) LogValue() slog.Value {
+	return slog.IntValue(q.count())
+}
+
+func (q *requestQueries) count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.total
+}
+
+func (q *requestQueries) add(sql string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.total++
+	if _, ok := q.byStatement[sql]; ok || len(q.byStatement) < maxCountedStatements {
+		q.byStatement[sql]++
+	}
+}
+
+func newQueryCounter(threshold int, logger *slog.Logger) *queryCounter {
+	return &queryCounter{threshold: threshold, logger: logger}
+}
+
+// Name implements gorm.Plugin.
+func (q *queryCounter) Name() string { return queryCountPluginName }
+
+// Initialize implements gorm.Plugin.
+func (q *queryCounter) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("gobase:query_count_create", q.count); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("gobase:query_count_query", q.count); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("gobase:query_count_update", q.count); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("gobase:query_count_delete", q.count); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("gobase:query_count_row", q.count); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("gobase:query_count_raw", q.count)
+}
+
+// count records the statement that just ran against its request, if any.
+func (q *queryCounter) count(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Context == nil || stmt.SQL.Len() == 0 {
+		return
+	}
+	if queries, ok := stmt.Context.Value(queryCountKey{}).(*requestQueries); ok {
+		queries.add(stmt.SQL.String())
+	}
+}
+
+// middleware counts the queries of each request. The header is set when
+// the response head is written, so it covers the queries run before that.
+func (q *queryCounter) middleware() ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			queries := &requestQueries{byStatement: make(map[string]int)}
+			ctx := context.WithValue(c.Request.Context(), queryCountKey{}, queries)
+			ctx = logger.WithContextAttrs(ctx, slog.Any("db_queries", queries))
+			c.Request = c.Request.WithContext(ctx)
+			c.Writer = &queryCountWriter{ResponseWriter: c.Writer, queries: queries}
+
+			next(c)
+
+			if !c.Writer.Written() {
+				c.Header(QueryCountHeader, strconv.Itoa(queries.count()))
+			}
+			q.report(ctx, c.Request.Method+" "+c.FullPath(), queries)
+		}
+	}
+}
+
+// report logs a warning for each statement run more than threshold times.
+// The warnings are logged without holding queries.mu, since their
+// db_queries attribute reads it.
+func (q *queryCounter) report(ctx context.Context, route string, queries *requestQueries) {
+	repeated := make(map[string]int)
+	queries.mu.Lock()
+	for sql, n := range queries.byStatement {
+		if n > q.threshold {
+			repeated[sql] = n
+		}
+	}
+	queries.mu.Unlock()
+
+	for sql, n := range repeated {
+		h := fnv.New64a()
+		h.Write([]byte(sql))
+		q.logger.WarnContext(ctx, "repeated sql: possible N+1 query",
+			slog.String("route", route),
+			slog.String("sql", sql),
+			slog.String("digest", fmt.Sprintf("%016x", h.Sum64())),
+			slog.Int("count", n),
+			slog.Int("threshold", q.threshold),
+		)
+	}
+}
+
+// queryCountWriter sets X-DB-Query-Count just before the response head is
+// written.
+type queryCountWriter struct {
+	gin.ResponseWriter
+	queries *requestQueries
+}
+
+func (w *queryCountWriter) setHeader() {
+	if !w.Written() {
+		w.Header().Set(QueryCountHeader, strconv.Itoa(w.queries.count()))
+	}
+}
+
+func (w *queryCountWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *queryCountWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *queryCountWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the connection, as GET /ws
+// does to clear the server deadlines.
+func (w *queryCountWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/logger"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/user"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// loopGetByID returns a handler that loads user id n times, one query each:
+// the N+1 shape the counter should flag.
+func loopGetByID(db *gorm.DB, id uint, n int) gin.HandlerFunc {
+	repo := user.NewUserRepository(db)
+	return func(c *gin.Context) {
+		for range n {
+			if _, err := repo.GetByID(c.Request.Context(), id); err != nil {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"loaded": n})
+	}
+}
+
+func TestQueryCounter_WarnsOnRepeatedQueries(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	seeded := testutil.SeedUsers(t, db, domain.User{})
+	var logs bytes.Buffer
+	log, err := logger.New(logger.WithConsoleWriter(&logs), logger.WithConsoleFormat(logger.FormatJSON), logger.WithMiddleware(logger.ContextMiddleware()))
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = log.Close() })
+	counter := newQueryCounter(5, log.Logger)
+	if err := db.Use(counter); err != nil {
+		t.Fatalf("db.Use() error = %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(counter.middleware()).Build())
+	r.GET("/loop", loopGetByID(db, seeded[0].ID, 10))
+	r.GET("/few", loopGetByID(db, seeded[0].ID, 2))
+	r.GET("/none", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/loop", nil))
+	if got := w.Header().Get(QueryCountHeader); got != "10" {
+		t.Errorf("%s = %q, want 10", QueryCountHeader, got)
+	}
+	var warning struct {
+		Msg       string `json:"msg"`
+		Route     string `json:"route"`
+		SQL       string `json:"sql"`
+		Digest    string `json:"digest"`
+		Count     int    `json:"count"`
+		DBQueries int    `json:"db_queries"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &warning); err != nil {
+		t.Fatalf("want one JSON warning, got %q: %v", logs.String(), err)
+	}
+	if warning.Msg != "repeated sql: possible N+1 query" || warning.Route != "GET /loop" || warning.Count != 10 {
+		t.Errorf("warning = %+v, want the repeated GET /loop query counted 10 times", warning)
+	}
+	if !strings.Contains(warning.SQL, "?") || len(warning.Digest) != 16 {
+		t.Errorf("warning sql %q digest %q, want placeholder SQL and a 16-digit digest", warning.SQL, warning.Digest)
+	}
+	if warning.DBQueries != 10 {
+		t.Errorf("db_queries = %d, want 10", warning.DBQueries)
+	}
+
+	logs.Reset()
+	w = testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/few", nil))
+	if got := w.Header().Get(QueryCountHeader); got != "2" {
+		t.Errorf("%s = %q, want 2", QueryCountHeader, got)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("logs = %q, want no warning under the threshold", logs.String())
+	}
+
+	w = testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/none", nil))
+	if got := w.Header().Get(QueryCountHeader); got != "0" {
+		t.Errorf("%s on a bodiless response = %q, want 0", QueryCountHeader, got)
+	}
+}
+
+func TestNew_QueryCountOnlyInDebugMode(t *testing.T) {
+	for _, tt := range []struct {
+		mode       string
+		wantHeader string
+	}{
+		{gin.DebugMode, "10"},
+		{gin.ReleaseMode, ""},
+		{gin.TestMode, ""},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			a, err := New(testutil.NewTestConfig(testutil.WithMode(tt.mode), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+				c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+			}))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			t.Cleanup(func() { cleanupTestApp(t, a) })
+			testutil.Migrate(t, a.db)
+			seeded := testutil.SeedUsers(t, a.db, domain.User{})
+			a.engine.GET("/loop", loopGetByID(a.db, seeded[0].ID, 10))
+
+			w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/loop", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /loop status = %d, want 200", w.Code)
+			}
+			if got := w.Header().Get(QueryCountHeader); got != tt.wantHeader {
+				t.Errorf("%s = %q, want %q", QueryCountHeader, got, tt.wantHeader)
+			}
+			if _, installed := a.db.Config.Plugins[queryCountPluginName]; installed != (tt.mode == gin.DebugMode) {
+				t.Errorf("query counter installed = %v in %s mode", installed, tt.mode)
+			}
+		})
+	}
+}
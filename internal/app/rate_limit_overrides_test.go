@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestNew_RateLimitOverrides(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.RateLimit = config.RateLimitConfig{
+			Enabled:   true,
+			RPS:       100,
+			Burst:     100,
+			Overrides: config.RateLimitOverridesConfig{Enabled: true},
+		}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read", "update"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	a.engine.GET("/api/v1/test-rate-limit", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	serveAs := func(userID, method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		token, err := a.jwtService.GenerateToken(userID, nil, time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateToken() error = %v", err)
+		}
+		req := testutil.NewJSONRequest(t, method, path, body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return testutil.Serve(a.engine, req)
+	}
+
+	w := serveAs("1", http.MethodPost, "/api/v1/admin/rate-limits", `{"subject_type":"user","subject_id":"2","rps":1,"burst":1}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST override status = %d, want %d; body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var created struct {
+		Data domain.RateLimitOverride `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if w := serveAs("2", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusOK {
+		t.Fatalf("user 2 first request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serveAs("2", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user 2 second request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	for i := range 3 {
+		if w := serveAs("3", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusOK {
+			t.Fatalf("user 3 request %d status = %d, want the default limit's %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	path := fmt.Sprintf("/api/v1/admin/rate-limits/%d", created.Data.ID)
+	if w := serveAs("1", http.MethodPut, path, `{"rps":1,"burst":5}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT override status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if err := a.overrides.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	for i := range 5 {
+		if w := serveAs("2", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusOK {
+			t.Fatalf("user 2 request %d after raising the burst: status = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+	if w := serveAs("2", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user 2 request past the raised burst: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	// An override removed behind the instance's back (say, by another
+	// instance) lapses on the next refresh.
+	if err := a.db.Delete(&domain.RateLimitOverride{}, created.Data.ID).Error; err != nil {
+		t.Fatalf("delete override: %v", err)
+	}
+	if err := a.overrides.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if w := serveAs("2", http.MethodGet, "/api/v1/test-rate-limit", ""); w.Code != http.StatusOK {
+		t.Errorf("user 2 request after the override is gone: status = %d, want the default limit's %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestNew_RateLimitOverrides_Disabled(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t))))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	if a.overrides != nil {
+		t.Error("overrides cache created with server.rate_limit.overrides disabled")
+	}
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	token, err := a.jwtService.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	req := testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/admin/rate-limits", "")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if w := testutil.Serve(a.engine, req); w.Code != http.StatusNotFound {
+		t.Errorf("GET /api/v1/admin/rate-limits status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
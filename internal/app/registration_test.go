@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newRegistrationApp returns an App with RBAC and auth.registration_mode
+// mode, its schema migrated.
+func newRegistrationApp(t *testing.T, mode string) *App {
+	t.Helper()
+	a, err := New(testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Auth.RegistrationMode = mode
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	return a
+}
+
+func postRegistration(t *testing.T, a *App, body string) int {
+	t.Helper()
+	return testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", body)).Code
+}
+
+func TestNew_RegistrationOpen(t *testing.T) {
+	a := newRegistrationApp(t, "open")
+
+	if code := postRegistration(t, a, `{"name":"Alice","email":"alice@example.com","password":"password123"}`); code != http.StatusCreated {
+		t.Errorf("register status = %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestNew_RegistrationDisabled(t *testing.T) {
+	a := newRegistrationApp(t, "disabled")
+
+	code := postRegistration(t, a, `{"name":"Alice","email":"alice@example.com","password":"password123"}`)
+	if code != http.StatusNotFound && code != http.StatusMethodNotAllowed {
+		t.Errorf("register status = %d, want the route to be absent", code)
+	}
+	login := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/login", `{"email":"alice@example.com","password":"password123"}`))
+	if login.Code != http.StatusUnauthorized {
+		t.Errorf("login status = %d, want %d for an unknown user", login.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestNew_RegistrationInvite(t *testing.T) {
+	a := newRegistrationApp(t, "invite")
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read", "update"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	mint := func(userID, body string) (int, string) {
+		t.Helper()
+		token, err := a.jwtService.GenerateToken(userID, nil, time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateToken() error = %v", err)
+		}
+		req := testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/admin/invites", body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := testutil.Serve(a.engine, req)
+		var resp struct {
+			Data struct {
+				Code string `json:"code"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data.Code
+	}
+
+	if code := postRegistration(t, a, `{"name":"Alice","email":"alice@example.com","password":"password123"}`); code != http.StatusBadRequest {
+		t.Errorf("register without a code: status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code, _ := mint("2", `{}`); code != http.StatusForbidden {
+		t.Errorf("mint without admin:update: status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	status, invite := mint("1", `{"expires_in_hours":24}`)
+	if status != http.StatusCreated || invite == "" {
+		t.Fatalf("mint status = %d, code %q; want %d and a code", status, invite, http.StatusCreated)
+	}
+	if code := postRegistration(t, a, `{"name":"Alice","email":"alice@example.com","password":"password123","invite_code":"`+invite+`"}`); code != http.StatusCreated {
+		t.Fatalf("register with the invite: status = %d, want %d", code, http.StatusCreated)
+	}
+	if code := postRegistration(t, a, `{"name":"Bob","email":"bob@example.com","password":"password123","invite_code":"`+invite+`"}`); code != http.StatusForbidden {
+		t.Errorf("register with a used invite: status = %d, want %d", code, http.StatusForbidden)
+	}
+
+	_, expiring := mint("1", `{"email":"carol@example.com"}`)
+	if err := a.db.Model(&domain.Invite{}).Where("email = ?", "carol@example.com").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire invite: %v", err)
+	}
+	if code := postRegistration(t, a, `{"name":"Carol","email":"carol@example.com","password":"password123","invite_code":"`+expiring+`"}`); code != http.StatusForbidden {
+		t.Errorf("register with an expired invite: status = %d, want %d", code, http.StatusForbidden)
+	}
+}
@@ -1,22 +1,27 @@
 package app
 
 import (
-	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/rbac"
 	"gorm.io/gorm"
 
+	"github.com/simp-lee/gobase/internal/config"
 	"github.com/simp-lee/gobase/internal/middleware"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 	"github.com/simp-lee/gobase/web"
 )
 
@@ -26,6 +31,63 @@ type RouteDeps struct {
 	DB         *gorm.DB
 	Mode       string // "debug" or "release"
 	CSRFSecret string
+	// Locales are the locales pages are rendered in, the default first
+	// (server.locales); empty renders every page in the default locale.
+	Locales []string
+	// RBAC and PageIdentity drive role-aware page rendering; both are nil
+	// when auth (or RBAC) is disabled, which shows every link and control.
+	RBAC         rbac.Service
+	PageIdentity middleware.IdentityFunc
+	// UnreadCount feeds the nav's unread notification badge; nil (auth
+	// disabled) hides it.
+	UnreadCount middleware.UnreadCounter
+	// StaticMounts replaces the default /static mount (server.static.mounts).
+	StaticMounts []config.StaticMount
+	// WebOverrides, when non-nil, is the server.templates.override_dir
+	// filesystem; its static/ files replace those of the embedded mount.
+	WebOverrides fs.FS
+	// LastMaintenance reports the latest purge of expired entries for
+	// /health; nil or ok == false omits it.
+	LastMaintenance func() (run MaintenanceRun, ok bool)
+	// Meta configures /robots.txt and /.well-known/security.txt.
+	Meta config.MetaConfig
+	// Branding fills /site.webmanifest, served with the site icons.
+	Branding config.BrandingConfig
+	// RuntimeConfig, when non-nil, serves GET/PUT
+	// /api/v1/admin/runtime-config (server.admin_runtime_config).
+	RuntimeConfig *runtimeConfig
+	// Backups, when non-nil, serves the SQLite snapshot API under
+	// /api/v1/admin (database.backup_dir); it also needs RBAC.
+	Backups *backupStore
+	// Sitemap, when non-nil, serves /sitemap.xml (server.sitemap).
+	Sitemap *sitemap
+	// Events, when non-nil, serves the live event WebSocket at /ws
+	// (server.websocket).
+	Events *eventSocket
+	// LogFailures reports how many log records the configured outputs
+	// failed to write, shown on the health check (optional).
+	LogFailures func() uint64
+	// Stats feeds the admin stats dashboard; nil leaves it unregistered.
+	Stats StatsCollector
+	// TemplateStats feeds GET /debug/templates in debug mode; nil leaves it
+	// unregistered.
+	TemplateStats func() []TemplateStat
+	// ErrorRates feeds GET /debug/error-rates (server.error_rates); nil
+	// leaves it unregistered.
+	ErrorRates func() ErrorRateReport
+	// Health relocates and guards the health check (server.health); the
+	// zero value serves full details at config.DefaultHealthPath.
+	Health config.HealthConfig
+	// HealthCheckers are app-level components reported on the health check
+	// after the database; modules add theirs through HealthProvider.
+	HealthCheckers []pkg.HealthChecker
+}
+
+// pageNav is the site navigation after the always-visible home link. Each
+// item is shown only to users holding its permission (see
+// middleware.PagePermissions).
+var pageNav = []middleware.NavItem{
+	{Label: "用户管理", URL: "/users", Permission: "users:read"},
 }
 
 // RegisterRoutes registers all application routes on the given gin.Engine.
@@ -43,27 +105,45 @@ func RegisterRoutes(r *gin.Engine, deps *RouteDeps) error {
 		return errors.New("csrf secret is required")
 	}
 
-	// Static assets
-	if err := registerStaticRoutesWithError(r, deps.Mode); err != nil {
-		return fmt.Errorf("register static routes: %w", err)
+	// Health check (M3): the database first, then the app-level and module
+	// components.
+	checkers := append(databaseHealthCheckers(deps.DB), deps.HealthCheckers...)
+	checkers = append(checkers, collectHealthCheckers(deps.Modules)...)
+	health := []gin.HandlerFunc{healthHandler(checkers, deps.Health.EffectiveTimeout(), deps.LastMaintenance, deps.LogFailures, deps.Health.Details())}
+	if deps.Health.Token != "" {
+		health = append([]gin.HandlerFunc{healthTokenGuard(deps.Health.Token)}, health...)
 	}
+	r.GET(deps.Health.EffectivePath(), health...)
 
-	// Health check (M3)
-	r.GET("/health", healthHandler(deps.DB))
+	pageMiddleware := []gin.HandlerFunc{
+		middleware.Locale(deps.Locales),
+		middleware.CSRF(deps.CSRFSecret, middleware.WithCSRFErrorHandler(renderError)),
+		middleware.PagePermissions(deps.RBAC, deps.PageIdentity, pageNav),
+	}
+	if deps.UnreadCount != nil {
+		pageMiddleware = append(pageMiddleware, middleware.UnreadNotifications(deps.UnreadCount, deps.PageIdentity))
+	}
 
-	// Home page (with CSRF so templates have a token)
-	r.GET("/", middleware.CSRF(deps.CSRFSecret), func(c *gin.Context) {
+	// Home page (with CSRF so templates have a token). Uptime checks HEAD
+	// it, so HEAD skips the page middleware and the render.
+	home := append([]gin.HandlerFunc{middleware.CheapHead("text/html; charset=utf-8")}, pageMiddleware...)
+	r.GET("/", append(home, func(c *gin.Context) {
 		c.HTML(http.StatusOK, "home.html", gin.H{
 			"CSRFToken": middleware.GetCSRFToken(c),
+			"Perms":     middleware.GetPermissions(c),
+			"Nav":       middleware.GetNav(c),
+			"Features":  pkg.GetFeatures(c),
+			"Locale":    requestctx.Locale(c),
+			"Unread":    middleware.GetUnread(c),
 		})
-	})
+	})...)
 
 	// API routes — no CSRF
-	api := r.Group("/api/v1")
+	api := r.Group(apiBasePath)
 
-	// Page routes — with CSRF
+	// Page routes — with the locale, CSRF and the current user's permission snapshot
 	pages := r.Group("/")
-	pages.Use(middleware.CSRF(deps.CSRFSecret))
+	pages.Use(pageMiddleware...)
 
 	// Register module routes
 	for i, m := range deps.Modules {
@@ -72,65 +152,60 @@ func RegisterRoutes(r *gin.Engine, deps *RouteDeps) error {
 		}
 		m.RegisterRoutes(api, pages)
 	}
+	registerStatsRoutes(api, pages, deps)
+	registerRuntimeConfigRoutes(api, deps.RuntimeConfig)
+	registerBackupRoutes(api, deps.Backups, deps.RBAC != nil)
+	registerDebugRoutes(r, deps)
+	registerErrorRateRoutes(pages, deps)
+	registerMetaRoutes(r, deps.Meta)
+	if err := registerBrandingRoutes(r, deps.Branding); err != nil {
+		return fmt.Errorf("register branding routes: %w", err)
+	}
+	registerSitemapRoutes(r, deps.Sitemap)
+	registerEventSocketRoutes(r, deps.Events)
+
+	// Static assets, after the pages so mounts can be checked against them.
+	if err := registerStaticRoutesWithError(r, deps.Mode, deps.StaticMounts, deps.WebOverrides); err != nil {
+		return fmt.Errorf("register static routes: %w", err)
+	}
 
 	// NoRoute handler (M5)
 	r.NoRoute(noRouteHandler())
 
+	// Known paths requested with an unsupported method answer 405 with an
+	// Allow header instead of 404.
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(noMethodHandler())
+
 	return nil
 }
 
-// healthHandler returns a handler that pings the database and reports status.
-func healthHandler(db *gorm.DB) gin.HandlerFunc {
+// healthTokenGuard admits health checks carrying token as a bearer token or
+// the token query parameter, and answers everything else as an unknown
+// route (404) so probing does not reveal the endpoint.
+func healthTokenGuard(token string) gin.HandlerFunc {
+	want := []byte(token)
 	return func(c *gin.Context) {
-		dbStatus := "ok"
-		status := "ok"
-		code := http.StatusOK
-
-		if db == nil {
-			dbStatus = "error"
-			status = "degraded"
-			code = http.StatusServiceUnavailable
-			c.JSON(code, gin.H{
-				"status": status,
-				"components": gin.H{
-					"database": dbStatus,
-				},
-			})
-			return
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			got = c.Query("token")
 		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			dbStatus = "error"
-			status = "degraded"
-			code = http.StatusServiceUnavailable
-		} else {
-			ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
-			defer cancel()
-			err = sqlDB.PingContext(ctx)
-			if err != nil {
-				dbStatus = "error"
-				status = "degraded"
-				code = http.StatusServiceUnavailable
-			}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), want) != 1 {
+			c.Abort()
+			noRouteHandler()(c)
+			return
 		}
-
-		c.JSON(code, gin.H{
-			"status": status,
-			"components": gin.H{
-				"database": dbStatus,
-			},
-		})
+		c.Next()
 	}
 }
 
 // noRouteHandler returns a handler that renders a 404 HTML page for browser
-// requests or a JSON response for API clients.
+// requests or a JSON response for API clients. The /api check ignores case so
+// /API/... still gets JSON when case-insensitive paths are disabled.
 func noRouteHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		path := c.Request.URL.Path
-		if strings.HasPrefix(path, "/api/") {
-			c.JSON(http.StatusNotFound, pkg.Response{Code: http.StatusNotFound, Message: "not found"})
+		if middleware.IsAPIPath(c.Request.URL.Path) {
+			pkg.JSONError(c, http.StatusNotFound, "not found")
 			return
 		}
 
@@ -138,26 +213,113 @@ func noRouteHandler() gin.HandlerFunc {
 	}
 }
 
-func registerStaticRoutesWithError(r *gin.Engine, mode string) error {
+// noMethodHandler is noRouteHandler for 405 Method Not Allowed; gin has
+// already set the Allow header.
+func noMethodHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if middleware.IsAPIPath(c.Request.URL.Path) {
+			pkg.JSONError(c, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		renderError(c, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// defaultStaticMaxAge is the Cache-Control max-age of the default /static
+// mount outside debug mode (M8).
+const defaultStaticMaxAge = 24 * time.Hour
+
+// registerStaticRoutesWithError registers a GET route per static mount, or
+// the default /static mount when mounts is empty. The static/ directory of
+// overrides, when non-nil, is layered over the config.StaticEmbedded
+// mounts. It fails when a mount collides with a route already on r.
+func registerStaticRoutesWithError(r *gin.Engine, mode string, mounts []config.StaticMount, overrides fs.FS) error {
+	if len(mounts) == 0 {
+		maxAge := config.Duration(defaultStaticMaxAge)
+		if mode == "debug" {
+			maxAge = 0
+		}
+		mounts = []config.StaticMount{{URLPrefix: "/static", Dir: config.StaticEmbedded, CacheMaxAge: maxAge}}
+	}
+
+	for _, m := range mounts {
+		for _, route := range r.Routes() {
+			if route.Path == m.URLPrefix || strings.HasPrefix(route.Path, m.URLPrefix+"/") {
+				return fmt.Errorf("static mount %q collides with route %s %s", m.URLPrefix, route.Method, route.Path)
+			}
+		}
+		fsys, err := staticFS(mode, m.Dir)
+		if err != nil {
+			return fmt.Errorf("static mount %q: %w", m.URLPrefix, err)
+		}
+		// The embedded assets are revalidated by content hash; files on
+		// disk by their modification time only.
+		var etags *assetETags
+		if m.Dir == config.StaticEmbedded && mode != "debug" {
+			tags, err := embeddedETags()
+			if err != nil {
+				return fmt.Errorf("static mount %q: hash embedded assets: %w", m.URLPrefix, err)
+			}
+			etags = &assetETags{tags: tags}
+		}
+		if m.Dir == config.StaticEmbedded && overrides != nil {
+			staticOverrides, err := fs.Sub(overrides, "static")
+			if err != nil {
+				return fmt.Errorf("static mount %q: %w", m.URLPrefix, err)
+			}
+			fsys = newOverlayFS(staticOverrides, fsys)
+			if etags != nil {
+				etags.shadow = staticOverrides
+			}
+		}
+		if err := addStaticRoute(r, m.URLPrefix, cacheStaticHandler(m.URLPrefix, http.FS(fsys), m.CacheMaxAge.Std(), etags)); err != nil {
+			return fmt.Errorf("static mount %q: %w", m.URLPrefix, err)
+		}
+	}
+	return nil
+}
+
+// addStaticRoute registers handler for everything under prefix, turning
+// gin's panic on a conflicting wildcard into an error.
+func addStaticRoute(r *gin.Engine, prefix string, handler gin.HandlerFunc) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("register route: %v", v)
+		}
+	}()
+	r.GET(prefix+"/*filepath", handler)
+	return nil
+}
+
+// staticFS opens the directory of a static mount: the built-in assets for
+// config.StaticEmbedded, from the source tree in debug mode so edits show
+// up without a rebuild.
+func staticFS(mode, dir string) (fs.FS, error) {
+	if dir != config.StaticEmbedded {
+		if info, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("stat static directory %q: %w", dir, err)
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("static directory %q is not a directory", dir)
+		}
+		return os.DirFS(dir), nil
+	}
+
 	if mode == "debug" {
 		debugStaticFS, err := resolveDebugStaticFS()
 		if err != nil {
-			return fmt.Errorf("resolve debug static filesystem: %w", err)
+			return nil, fmt.Errorf("resolve debug static filesystem: %w", err)
 		}
-		fileServer := http.StripPrefix("/static", http.FileServer(http.FS(debugStaticFS)))
-		r.GET("/static/*filepath", func(c *gin.Context) {
-			fileServer.ServeHTTP(c.Writer, c.Request)
-		})
-		return nil
+		return debugStaticFS, nil
 	}
 
-	// Release mode: serve from embed.FS with cache headers.
-	staticFS, err := fs.Sub(web.EmbeddedFS, "static")
+	// Release mode: serve from embed.FS, whose files have no modification
+	// time of their own.
+	embedded, err := fs.Sub(web.EmbeddedFS, "static")
 	if err != nil {
-		return fmt.Errorf("create sub filesystem for static assets: %w", err)
+		return nil, fmt.Errorf("create sub filesystem for static assets: %w", err)
 	}
-	r.GET("/static/*filepath", cacheStaticHandler(http.FS(staticFS)))
-	return nil
+	return modTimeFS{fsys: embedded, modTime: staticModTime()}, nil
 }
 
 func resolveDebugStaticFS() (fs.FS, error) {
@@ -175,12 +337,38 @@ func resolveDebugStaticFS() (fs.FS, error) {
 	return os.DirFS(staticDir), nil
 }
 
-// cacheStaticHandler wraps an http.FileSystem handler and sets a Cache-Control header
-// for release mode static assets (M8).
-func cacheStaticHandler(fsys http.FileSystem) gin.HandlerFunc {
-	fileServer := http.StripPrefix("/static", http.FileServer(fsys))
+// cacheStaticHandler serves fsys under prefix and, when maxAge is positive,
+// sets a Cache-Control header (M8). Paths with a ".." segment are answered
+// 404 rather than http.FileServer's 400. A file with a .br or .gz sibling
+// is served precompressed to clients that accept it (see
+// servePrecompressed); the embedded assets include such siblings when they
+// are present in web/static at build time. Files with an ETag in etags,
+// which may be nil, carry it and are answered 304 on a matching
+// If-None-Match; If-Modified-Since is checked against the files'
+// modification times.
+func cacheStaticHandler(prefix string, fsys http.FileSystem, maxAge time.Duration, etags *assetETags) gin.HandlerFunc {
+	fileServer := http.StripPrefix(prefix, http.FileServer(fsys))
+	cacheControl := fmt.Sprintf("public, max-age=%d", int64(maxAge/time.Second))
+	var lastModified string
+	if t := staticModTime(); !t.IsZero() {
+		lastModified = t.Format(http.TimeFormat)
+	}
 	return func(c *gin.Context) {
-		c.Header("Cache-Control", "public, max-age=86400")
-		fileServer.ServeHTTP(c.Writer, c.Request)
+		if slices.Contains(strings.Split(c.Param("filepath"), "/"), "..") {
+			http.NotFound(c.Writer, c.Request)
+			return
+		}
+		if maxAge > 0 {
+			c.Header("Cache-Control", cacheControl)
+		}
+		name := path.Clean("/" + c.Param("filepath"))
+		w := validatorsWriter{ResponseWriter: c.Writer, lastModified: lastModified}
+		if servePrecompressed(c, w, fsys, name, etags) {
+			return
+		}
+		if tag := etags.get(name); tag != "" {
+			c.Header("ETag", tag)
+		}
+		fileServer.ServeHTTP(w, c.Request)
 	}
 }
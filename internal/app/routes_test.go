@@ -5,9 +5,17 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/fs"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -16,8 +24,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/logger"
+	"github.com/simp-lee/rbac"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/user"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/web"
 )
 
 func init() {
@@ -66,7 +83,7 @@ func TestHealthHandler_OK(t *testing.T) {
 	// Use a real SQLite in-memory DB for a passing ping.
 	db := openTestSQLiteDB(t)
 
-	r.GET("/health", healthHandler(db))
+	r.GET("/health", healthHandler(databaseHealthCheckers(db), time.Second, nil, nil, true))
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -83,6 +100,9 @@ func TestHealthHandler_OK(t *testing.T) {
 	if body["status"] != "ok" {
 		t.Errorf("expected status ok, got %v", body["status"])
 	}
+	if v, _ := body["version"].(string); v == "" {
+		t.Errorf("expected a build version, got %v", body["version"])
+	}
 	comps, ok := body["components"].(map[string]any)
 	if !ok {
 		t.Fatal("missing components")
@@ -100,7 +120,7 @@ func TestHealthHandler_DBDown(t *testing.T) {
 	sqlDB, _ := db.DB()
 	sqlDB.Close()
 
-	r.GET("/health", healthHandler(db))
+	r.GET("/health", healthHandler(databaseHealthCheckers(db), time.Second, nil, nil, true))
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -123,6 +143,36 @@ func TestHealthHandler_DBDown(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_ReportsReplicasSeparately(t *testing.T) {
+	db := openTestSQLiteDB(t)
+	healthy, _ := openTestSQLiteDB(t).DB()
+	down, _ := openTestSQLiteDB(t).DB()
+	down.Close()
+	if err := config.UseReplicas(db, []*sql.DB{healthy, down}); err != nil {
+		t.Fatalf("UseReplicas: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/health", healthHandler(databaseHealthCheckers(db), time.Second, nil, nil, true))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 with a replica down, got %d", w.Code)
+	}
+	var body struct {
+		Status     string            `json:"status"`
+		Components map[string]string `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := map[string]string{"database": "ok", "database_replica_0": "ok", "database_replica_1": "error"}
+	if body.Status != "degraded" || !reflect.DeepEqual(body.Components, want) {
+		t.Errorf("body = %+v, want degraded with components %v", body, want)
+	}
+}
+
 func TestHealthHandler_UsesRequestContextTimeout(t *testing.T) {
 	registerBlockingPingDriver()
 
@@ -140,7 +190,7 @@ func TestHealthHandler_UsesRequestContextTimeout(t *testing.T) {
 	}
 
 	r := gin.New()
-	r.GET("/health", healthHandler(db))
+	r.GET("/health", healthHandler(databaseHealthCheckers(db), time.Second, nil, nil, true))
 
 	reqCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
 	t.Cleanup(cancel)
@@ -160,6 +210,136 @@ func TestHealthHandler_UsesRequestContextTimeout(t *testing.T) {
 	}
 }
 
+// setupHealthRouter registers the routes with the given health config.
+func setupHealthRouter(t *testing.T, health config.HealthConfig) *gin.Engine {
+	t.Helper()
+	r := setupTestRouter()
+	err := RegisterRoutes(r, &RouteDeps{
+		Modules:    []Module{&mockModule{}},
+		DB:         openTestSQLiteDB(t),
+		Mode:       "debug",
+		CSRFSecret: "test-secret-32-chars-long-enough",
+		Health:     health,
+	})
+	if err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+	return r
+}
+
+func TestHealthRoute_Relocated(t *testing.T) {
+	r := setupHealthRouter(t, config.HealthConfig{Path: "/internal/healthz"})
+
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/internal/healthz", nil)); w.Code != http.StatusOK {
+		t.Errorf("GET /internal/healthz status = %d, want 200", w.Code)
+	}
+	if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("GET /health status = %d, want 404 once relocated", w.Code)
+	}
+}
+
+func TestHealthRoute_Token(t *testing.T) {
+	r := setupHealthRouter(t, config.HealthConfig{Token: "probe-secret"})
+
+	tests := []struct {
+		name   string
+		target string
+		auth   string
+		want   int
+	}{
+		{"missing token", "/health", "", http.StatusNotFound},
+		{"wrong bearer", "/health", "Bearer nope", http.StatusNotFound},
+		{"wrong query", "/health?token=nope", "", http.StatusNotFound},
+		{"non-bearer scheme", "/health", "Basic probe-secret", http.StatusNotFound},
+		{"correct bearer", "/health", "Bearer probe-secret", http.StatusOK},
+		{"correct query", "/health?token=probe-secret", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := testutil.Serve(r, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusNotFound && strings.Contains(w.Body.String(), "components") {
+				t.Errorf("404 body leaks health details: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHealthRoute_HidesDetails(t *testing.T) {
+	hide := false
+	r := setupHealthRouter(t, config.HealthConfig{ExposeDetails: &hide})
+
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != `{"status":"ok"}` {
+		t.Errorf("body = %s, want only the status", got)
+	}
+
+	r = gin.New()
+	db := openTestSQLiteDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	r.GET("/health", healthHandler(databaseHealthCheckers(db), time.Second, nil, nil, false))
+	w = testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable || strings.TrimSpace(w.Body.String()) != `{"status":"degraded"}` {
+		t.Errorf("DB down: status = %d, body = %s; want 503 with only the status", w.Code, w.Body.String())
+	}
+}
+
+// failingOutput is a log handler whose writes fail, like a file on a full
+// disk.
+type failingOutput struct{ slog.Handler }
+
+func (failingOutput) Handle(context.Context, slog.Record) error {
+	return errors.New("no space left on device")
+}
+
+func TestRequestLogger_FailingOutputKeepsServing(t *testing.T) {
+	var stderr strings.Builder
+	failsafe := config.NewLogFailsafe(&stderr)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(ginx.Logger(
+		logger.WithConsoleWriter(io.Discard),
+		logger.WithMiddleware(func(next slog.Handler) slog.Handler { return failingOutput{next} }),
+		logger.WithMiddleware(failsafe.Middleware()),
+	)).Build())
+	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+	r.GET("/health", healthHandler(databaseHealthCheckers(openTestSQLiteDB(t)), time.Second, nil, failsafe.Failures, true))
+
+	for range 3 {
+		if w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/ping", nil)); w.Code != http.StatusOK || w.Body.String() != "pong" {
+			t.Fatalf("GET /ping = %d %q, want 200 pong", w.Code, w.Body.String())
+		}
+	}
+	if got := failsafe.Failures(); got != 3 {
+		t.Errorf("Failures() = %d, want 3", got)
+	}
+	if got := strings.Count(stderr.String(), `"msg":"HTTP Request"`); got != 3 {
+		t.Errorf("stderr has %d request records, want 3: %s", got, stderr.String())
+	}
+
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body struct {
+		Status           string `json:"status"`
+		LogWriteFailures uint64 `json:"log_write_failures"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode /health: %v", err)
+	}
+	// The health request itself is logged (and fails) after its body is written.
+	if body.Status != "ok" || body.LogWriteFailures != 3 {
+		t.Errorf("/health = %s, want status ok and log_write_failures 3", w.Body.String())
+	}
+}
+
 // --- NoRoute handler tests (M5) ---
 
 func TestNoRouteHandler_JSON(t *testing.T) {
@@ -250,7 +430,7 @@ func TestNoRouteHandler_APIPath_PrefersJSON(t *testing.T) {
 // registerStaticRoutes is a test helper that wraps registerStaticRoutesWithError,
 // discarding the error for convenience in test setup.
 func registerStaticRoutes(r *gin.Engine, mode string) {
-	_ = registerStaticRoutesWithError(r, mode)
+	_ = registerStaticRoutesWithError(r, mode, nil, nil)
 }
 
 func TestRegisterStaticRoutes_Debug(t *testing.T) {
@@ -296,7 +476,7 @@ func TestCacheStaticHandler_SetsCacheControl(t *testing.T) {
 	httpFS := http.FS(memFS)
 
 	r := gin.New()
-	r.GET("/static/*filepath", cacheStaticHandler(httpFS))
+	r.GET("/static/*filepath", cacheStaticHandler("/static", httpFS, 24*time.Hour, nil))
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/static/test.css", nil)
@@ -311,6 +491,101 @@ func TestCacheStaticHandler_SetsCacheControl(t *testing.T) {
 	}
 }
 
+func TestRegisterStaticRoutes_Mounts(t *testing.T) {
+	distDir, vendorDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(distDir, "app.js"), []byte("dist"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vendorDir, "lib.js"), []byte("vendor"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	err := registerStaticRoutesWithError(r, "release", []config.StaticMount{
+		{URLPrefix: "/app", Dir: distDir, CacheMaxAge: config.Duration(time.Hour)},
+		{URLPrefix: "/vendor", Dir: vendorDir},
+		{URLPrefix: "/static", Dir: config.StaticEmbedded, CacheMaxAge: config.Duration(24 * time.Hour)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+
+	tests := []struct {
+		path         string
+		wantCode     int
+		wantBody     string
+		cacheControl string
+	}{
+		{"/app/app.js", http.StatusOK, "dist", "public, max-age=3600"},
+		{"/vendor/lib.js", http.StatusOK, "vendor", ""},
+		{"/static/js/app.js", http.StatusOK, "", "public, max-age=86400"},
+		{"/app/missing.js", http.StatusNotFound, "", ""}, // http.FileServer drops Cache-Control on errors
+	}
+	for _, tt := range tests {
+		w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("GET %s = %d %q, want %d containing %q", tt.path, w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("GET %s Cache-Control = %q, want %q", tt.path, got, tt.cacheControl)
+		}
+	}
+}
+
+func TestRegisterStaticRoutes_RejectsTraversal(t *testing.T) {
+	// The mount sits one level below a file that traversal would reach.
+	dir := filepath.Join(t.TempDir(), "dist")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "release", []config.StaticMount{{URLPrefix: "/app", Dir: dir}}, nil); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+
+	for _, target := range []string{
+		"/app/../secret.txt",
+		"/app/%2e%2e/secret.txt",
+		"/app/..%2fsecret.txt",
+		"/app/sub/../../secret.txt",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL, _ = url.Parse(target) // bypass NewRequest's cleaning of the target
+		w := testutil.Serve(r, req)
+		if w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "secret") {
+			t.Errorf("GET %s = %d %q, want 404", target, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterStaticRoutes_Collisions(t *testing.T) {
+	r := gin.New()
+	r.GET("/users/:id", func(c *gin.Context) {})
+	err := registerStaticRoutesWithError(r, "release", []config.StaticMount{{URLPrefix: "/users", Dir: config.StaticEmbedded}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "collides with route GET /users/:id") {
+		t.Errorf("mount over a page route: error = %v, want collision", err)
+	}
+
+	err = registerStaticRoutesWithError(gin.New(), "release", []config.StaticMount{{URLPrefix: "/app", Dir: filepath.Join(t.TempDir(), "missing")}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "stat static directory") {
+		t.Errorf("missing dir: error = %v, want stat error", err)
+	}
+}
+
+func TestRegisterStaticRoutes_DefaultMountUncachedInDebug(t *testing.T) {
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "debug", nil, nil); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/static/js/app.js", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("GET /static/js/app.js = %d, Cache-Control %q; want 200 without Cache-Control", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
 // --- RegisterRoutes validation tests ---
 
 // mockModule implements Module for testing.
@@ -549,3 +824,104 @@ type blockingPingTx struct{}
 
 func (blockingPingTx) Commit() error   { return nil }
 func (blockingPingTx) Rollback() error { return nil }
+
+// --- Role-aware page rendering ---
+
+// fakeRBAC grants each user the "resource:action" permissions listed for it.
+// Only HasPermission is implemented; other rbac.Service methods panic.
+type fakeRBAC struct {
+	rbac.Service
+	grants map[string][]string
+}
+
+func (f *fakeRBAC) HasPermission(userID, resource, action string) (bool, error) {
+	return slices.Contains(f.grants[userID], resource+":"+action), nil
+}
+
+// setupRolePageRouter registers the real user module and embedded templates
+// with the given RBAC service; page users are identified by X-Test-User.
+func setupRolePageRouter(t *testing.T, svc rbac.Service) *gin.Engine {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, domain.User{Name: "Alice", Email: "alice@example.com"})
+
+	userSvc := user.NewUserService(user.NewUserRepository(db))
+	r := gin.New()
+	renderer, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	r.HTMLRender = renderer
+	err = RegisterRoutes(r, &RouteDeps{
+		Modules:    []Module{user.NewModule(user.NewUserHandler(userSvc), user.NewUserPageHandler(userSvc))},
+		DB:         db,
+		Mode:       gin.ReleaseMode,
+		CSRFSecret: "test-secret-32-chars-long-enough",
+		RBAC:       svc,
+		PageIdentity: func(c *gin.Context) (string, bool) {
+			id := c.GetHeader("X-Test-User")
+			return id, id != ""
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+	return r
+}
+
+func getUserListPage(t *testing.T, r *gin.Engine, userID string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "text/html")
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := testutil.Serve(r, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /users as %q status = %d, want 200", userID, w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestUserListPage_HidesControlsByPermission(t *testing.T) {
+	r := setupRolePageRouter(t, &fakeRBAC{grants: map[string][]string{
+		"reader": {"users:read"},
+		"admin":  {"users:read", "users:create", "users:update", "users:delete"},
+	}})
+
+	const deleteButton = `hx-get="/users/1/confirm-delete"`
+	const navLink = `<a href="/users" class="text-gray-300`
+
+	reader := getUserListPage(t, r, "reader")
+	if strings.Contains(reader, deleteButton) {
+		t.Error("read-only user sees the delete button")
+	}
+	if strings.Contains(reader, `href="/users/new"`) || strings.Contains(reader, `href="/users/1/edit"`) {
+		t.Error("read-only user sees create or edit links")
+	}
+	if !strings.Contains(reader, navLink) {
+		t.Error("read-only user should see the 用户管理 nav link")
+	}
+
+	admin := getUserListPage(t, r, "admin")
+	for _, want := range []string{deleteButton, `href="/users/new"`, `href="/users/1/edit"`, navLink} {
+		if !strings.Contains(admin, want) {
+			t.Errorf("admin page missing %q", want)
+		}
+	}
+
+	if anonymous := getUserListPage(t, r, ""); strings.Contains(anonymous, navLink) || strings.Contains(anonymous, deleteButton) {
+		t.Error("anonymous user should see neither the nav link nor the delete button")
+	}
+}
+
+func TestUserListPage_WithoutRBACShowsEverything(t *testing.T) {
+	r := setupRolePageRouter(t, nil)
+
+	body := getUserListPage(t, r, "")
+	for _, want := range []string{`hx-get="/users/1/confirm-delete"`, `href="/users/new"`, `<a href="/users" class="text-gray-300`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q with RBAC disabled", want)
+		}
+	}
+}
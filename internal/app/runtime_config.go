@@ -0,0 +1,363 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// runtimeConfigPath serves the runtime config admin API
+// (server.admin_runtime_config). It is exempt from rate limiting so a limit
+// can be raised by a client it throttles.
+const runtimeConfigPath = "/api/v1/admin/runtime-config"
+
+// maxRuntimeConfigBody caps the PUT runtime-config document.
+const maxRuntimeConfigBody = 64 << 10
+
+// RuntimeSettings are the effective values of the settings the runtime
+// config API can change without a restart.
+type RuntimeSettings struct {
+	RateLimit   RuntimeRateLimit   `json:"rate_limit"`
+	Cache       RuntimeCache       `json:"cache"`
+	Log         RuntimeLog         `json:"log"`
+	Maintenance RuntimeMaintenance `json:"maintenance"`
+}
+
+// RuntimeRateLimit is the /api rate limit. Enabled is fixed at startup.
+type RuntimeRateLimit struct {
+	Enabled bool    `json:"enabled"`
+	RPS     float64 `json:"rps"`
+	Burst   int     `json:"burst"`
+}
+
+// RuntimeCache switches the response cache. It can only be turned on when
+// server.cache was enabled at startup.
+type RuntimeCache struct {
+	Enabled bool `json:"enabled"`
+}
+
+// RuntimeLog is the level of every App logger.
+type RuntimeLog struct {
+	Level string `json:"level"`
+}
+
+// RuntimeMaintenance pauses the scheduled purge of expired entries.
+type RuntimeMaintenance struct {
+	Paused bool `json:"paused"`
+}
+
+// RuntimeConfigResponse is the body of GET and PUT runtime-config: the
+// effective settings and the last change made through the API.
+type RuntimeConfigResponse struct {
+	Effective RuntimeSettings `json:"effective"`
+	UpdatedBy string          `json:"updated_by,omitempty"`
+	UpdatedAt *domain.Time    `json:"updated_at,omitempty"`
+}
+
+// runtimeConfigPatch is a PUT runtime-config document. Only the fields sent
+// change.
+type runtimeConfigPatch struct {
+	RateLimit struct {
+		RPS   pkg.Optional[float64] `json:"rps"`
+		Burst pkg.Optional[int]     `json:"burst"`
+	} `json:"rate_limit"`
+	Cache struct {
+		Enabled pkg.Optional[bool] `json:"enabled"`
+	} `json:"cache"`
+	Log struct {
+		Level pkg.Optional[string] `json:"level"`
+	} `json:"log"`
+	Maintenance struct {
+		Paused pkg.Optional[bool] `json:"paused"`
+	} `json:"maintenance"`
+}
+
+// fields maps every key a runtime-config document may set, as
+// "section.key", to where it is decoded. Everything else needs a config
+// change and a restart.
+func (p *runtimeConfigPatch) fields() map[string]any {
+	return map[string]any{
+		"rate_limit.rps":     &p.RateLimit.RPS,
+		"rate_limit.burst":   &p.RateLimit.Burst,
+		"cache.enabled":      &p.Cache.Enabled,
+		"log.level":          &p.Log.Level,
+		"maintenance.paused": &p.Maintenance.Paused,
+	}
+}
+
+// runtimeConfig holds the live RuntimeSettings. The rate limiter, response
+// cache and loggers read them on every request, so a change applies from
+// the next request on.
+type runtimeConfig struct {
+	cacheConfigured bool
+	logLevel        *slog.LevelVar
+	maintenance     *maintenance
+	log             *slog.Logger
+	clock           pkg.Clock
+
+	current atomic.Pointer[RuntimeSettings]
+	// limitGen is part of every rate limiter key and bumped with the rate
+	// limit, so clients start over with a full bucket of the new budget.
+	limitGen atomic.Uint64
+
+	mu        sync.Mutex // serializes updates and guards the fields below
+	updatedBy string
+	updatedAt time.Time
+}
+
+func newRuntimeConfig(cfg *config.Config, logLevel *slog.LevelVar, upkeep *maintenance, log *slog.Logger, clock pkg.Clock) *runtimeConfig {
+	r := &runtimeConfig{
+		cacheConfigured: cfg.Server.Cache.Enabled,
+		logLevel:        logLevel,
+		maintenance:     upkeep,
+		log:             log,
+		clock:           clock,
+	}
+	r.current.Store(&RuntimeSettings{
+		RateLimit: RuntimeRateLimit{
+			Enabled: cfg.Server.RateLimit.Enabled,
+			RPS:     cfg.Server.RateLimit.RPS,
+			Burst:   cfg.Server.RateLimit.Burst,
+		},
+		Cache:       RuntimeCache{Enabled: cfg.Server.Cache.Enabled},
+		Log:         RuntimeLog{Level: cfg.Log.Level},
+		Maintenance: RuntimeMaintenance{Paused: false},
+	})
+	return r
+}
+
+// settings returns the effective settings.
+func (r *runtimeConfig) settings() RuntimeSettings {
+	return *r.current.Load()
+}
+
+// rateLimitOverrides returns the override of the authenticated subject with
+// the ginx user ID userID; ok is false when it has none.
+type rateLimitOverrides func(userID string) (o domain.RateLimitOverride, ok bool)
+
+// overrideKeyPrefix marks the rate limiter keys of subjects with an
+// override, "subject:<version>:<user ID>", which are keyed by user ID
+// rather than client IP. The version is the override's updated_at, so a
+// changed override starts from a full bucket.
+const overrideKeyPrefix = "subject:"
+
+// rateLimit returns the /api rate limiting middleware, which reads its
+// budget from the current settings, per client IP. With overrides, an
+// authenticated subject that has one is limited by it instead, in a bucket
+// of its own; it must then run after authentication.
+func (r *runtimeConfig) rateLimit(overrides rateLimitOverrides) ginx.Middleware {
+	return ginx.RateLimit(0, 0,
+		ginx.WithKeyFunc(func(c *gin.Context) string {
+			gen := strconv.FormatUint(r.limitGen.Load(), 10) + "|"
+			if overrides != nil {
+				if userID, ok := requestctx.UserID(c); ok {
+					if o, ok := overrides(userID); ok {
+						return gen + overrideKeyPrefix + strconv.FormatInt(o.UpdatedAt.UnixNano(), 10) + ":" + userID
+					}
+				}
+			}
+			return gen + c.ClientIP()
+		}),
+		ginx.WithDynamicLimits(func(key string) (int, int) {
+			_, subject, _ := strings.Cut(key, "|")
+			if versioned, ok := strings.CutPrefix(subject, overrideKeyPrefix); ok && overrides != nil {
+				_, userID, _ := strings.Cut(versioned, ":")
+				// An override deleted since the key was chosen falls
+				// through to the defaults.
+				if o, ok := overrides(userID); ok {
+					return effectiveRateLimitRPS(o.RPS), o.Burst
+				}
+			}
+			rl := r.current.Load().RateLimit
+			return effectiveRateLimitRPS(rl.RPS), rl.Burst
+		}),
+	)
+}
+
+// cacheEnabled is the ginx.Condition under which the response cache serves
+// requests. Invalidation runs regardless, so re-enabling serves no entry
+// older than a write made meanwhile.
+func (r *runtimeConfig) cacheEnabled(*gin.Context) bool {
+	return r.current.Load().Cache.Enabled
+}
+
+// update validates patch with the rules of config.Validate, then applies
+// every change at once and audit-logs it. Invalid documents leave the
+// settings untouched and return pkg.ParamErrors.
+func (r *runtimeConfig) update(patch runtimeConfigPatch, by string) (RuntimeConfigResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := *r.current.Load()
+	errs := pkg.ParamErrors{}
+	rpsSet := setOptional(&next.RateLimit.RPS, patch.RateLimit.RPS, "rate_limit.rps", errs)
+	burstSet := setOptional(&next.RateLimit.Burst, patch.RateLimit.Burst, "rate_limit.burst", errs)
+	if (rpsSet || burstSet) && !next.RateLimit.Enabled {
+		errs["rate_limit"] = "Requires server.rate_limit.enabled at startup"
+	}
+	if err := config.ValidateRateLimit(config.RateLimitConfig{
+		Enabled: next.RateLimit.Enabled, RPS: next.RateLimit.RPS, Burst: next.RateLimit.Burst,
+	}); err != nil {
+		addFieldError(errs, err)
+	}
+	if setOptional(&next.Cache.Enabled, patch.Cache.Enabled, "cache.enabled", errs) && next.Cache.Enabled && !r.cacheConfigured {
+		errs["cache.enabled"] = "Requires server.cache.enabled at startup"
+	}
+	if setOptional(&next.Log.Level, patch.Log.Level, "log.level", errs) {
+		level, err := config.NormalizeLogLevel(next.Log.Level)
+		if err != nil {
+			addFieldError(errs, err)
+		}
+		next.Log.Level = level
+	}
+	setOptional(&next.Maintenance.Paused, patch.Maintenance.Paused, "maintenance.paused", errs)
+	if len(errs) > 0 {
+		return RuntimeConfigResponse{}, errs
+	}
+
+	prev := r.current.Load()
+	r.logLevel.Set(config.ParseLogLevel(next.Log.Level))
+	r.maintenance.paused.Store(next.Maintenance.Paused)
+	if next.RateLimit != prev.RateLimit {
+		r.limitGen.Add(1)
+	}
+	r.current.Store(&next)
+	r.updatedBy, r.updatedAt = by, r.clock.Now().UTC()
+
+	r.log.Info("runtime config changed",
+		slog.String("user_id", by),
+		slog.Any("before", *prev),
+		slog.Any("after", next),
+	)
+	return r.responseLocked(), nil
+}
+
+// response returns the effective settings with the last change.
+func (r *runtimeConfig) response() RuntimeConfigResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.responseLocked()
+}
+
+func (r *runtimeConfig) responseLocked() RuntimeConfigResponse {
+	resp := RuntimeConfigResponse{Effective: r.settings(), UpdatedBy: r.updatedBy}
+	if !r.updatedAt.IsZero() {
+		resp.UpdatedAt = domain.TimePtr(r.updatedAt)
+	}
+	return resp
+}
+
+// setOptional copies o into dst when a value was sent and reports whether
+// it did; an explicit null is recorded in errs under key.
+func setOptional[T any](dst *T, o pkg.Optional[T], key string, errs pkg.ParamErrors) bool {
+	if o.IsNull() {
+		errs[key] = "Must not be null"
+		return false
+	}
+	v, ok := o.Value()
+	if ok {
+		*dst = v
+	}
+	return ok
+}
+
+// addFieldError records a config validation error under its key relative
+// to the server section, e.g. "rate_limit.rps".
+func addFieldError(errs pkg.ParamErrors, err error) {
+	var fe *config.FieldError
+	if !errors.As(err, &fe) {
+		errs["body"] = err.Error()
+		return
+	}
+	errs[strings.TrimPrefix(fe.Key, "server.")] = fe.Error()
+}
+
+// parseRuntimePatch decodes a runtime-config document. Keys outside
+// runtimeConfigPatch.fields are rejected by name rather than ignored, so a
+// caller learns that the change did not happen.
+func parseRuntimePatch(body []byte) (runtimeConfigPatch, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		return runtimeConfigPatch{}, pkg.ParamErrors{"body": "Must be a JSON object"}
+	}
+	var patch runtimeConfigPatch
+	fields := patch.fields()
+	sections := map[string]bool{}
+	for name := range fields {
+		section, _, _ := strings.Cut(name, ".")
+		sections[section] = true
+	}
+	errs := pkg.ParamErrors{}
+	for section, raw := range doc {
+		if !sections[section] {
+			errs[section] = "Cannot be changed at runtime"
+			continue
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil || values == nil {
+			errs[section] = "Must be an object"
+			continue
+		}
+		for key, value := range values {
+			name := section + "." + key
+			dst, ok := fields[name]
+			if !ok {
+				errs[name] = "Cannot be changed at runtime"
+				continue
+			}
+			if err := json.Unmarshal(value, dst); err != nil {
+				errs[name] = "Has the wrong type"
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return runtimeConfigPatch{}, errs
+	}
+	return patch, nil
+}
+
+// registerRuntimeConfigRoutes adds GET and PUT runtimeConfigPath when
+// server.admin_runtime_config is enabled. appPolicies require admin:read
+// for both and admin:update for PUT.
+func registerRuntimeConfigRoutes(api *gin.RouterGroup, rc *runtimeConfig) {
+	if rc == nil {
+		return
+	}
+	api.GET("/admin/runtime-config", func(c *gin.Context) {
+		pkg.Success(c, rc.response())
+	})
+	api.PUT("/admin/runtime-config", func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRuntimeConfigBody))
+		if err != nil {
+			pkg.ValidationError(c, pkg.ParamErrors{"body": "Must be a JSON object of at most 64 KiB"})
+			return
+		}
+		patch, err := parseRuntimePatch(body)
+		if err != nil {
+			pkg.ValidationError(c, err)
+			return
+		}
+		by := requestctx.Actor(c)
+		resp, err := rc.update(patch, by)
+		if err != nil {
+			pkg.ValidationError(c, err)
+			return
+		}
+		pkg.Success(c, resp)
+	})
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newRuntimeConfigApp returns an App with RBAC, the runtime config API and
+// a generous rate limit, plus a function serving a request as user 1 who
+// holds admin:read and admin:update.
+func newRuntimeConfigApp(t *testing.T) (*App, func(method, path, body string) *httptest.ResponseRecorder) {
+	t.Helper()
+	a, err := New(testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.RateLimit = config.RateLimitConfig{Enabled: true, RPS: 100, Burst: 100}
+		c.Server.AdminRuntimeConfig.Enabled = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	if err := a.rbacService.AddUserPermissions("1", "admin", []string{"read", "update"}); err != nil {
+		t.Fatalf("grant admin permissions: %v", err)
+	}
+	a.engine.GET("/api/v1/test-rate-limit", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	token, err := a.jwtService.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := testutil.NewJSONRequest(t, method, path, body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return testutil.Serve(a.engine, req)
+	}
+	return a, serve
+}
+
+func decodeRuntimeConfig(t *testing.T, w *httptest.ResponseRecorder) RuntimeConfigResponse {
+	t.Helper()
+	var resp struct {
+		Data RuntimeConfigResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Data
+}
+
+func TestRuntimeConfig_RateLimitAppliesToNextRequests(t *testing.T) {
+	_, serve := newRuntimeConfigApp(t)
+
+	w := serve(http.MethodPut, runtimeConfigPath, `{"rate_limit":{"rps":1,"burst":2}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	got := decodeRuntimeConfig(t, w)
+	if got.Effective.RateLimit != (RuntimeRateLimit{Enabled: true, RPS: 1, Burst: 2}) || got.UpdatedBy != "1" || got.UpdatedAt == nil {
+		t.Errorf("response = %+v, want the new limit changed by user 1", got)
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := serve(http.MethodGet, "/api/v1/test-rate-limit", "").Code; code != want {
+			t.Fatalf("request %d after lowering: status = %d, want %d", i+1, code, want)
+		}
+	}
+
+	// The throttled client can still raise its own limit.
+	if w := serve(http.MethodPut, runtimeConfigPath, `{"rate_limit":{"burst":50}}`); w.Code != http.StatusOK {
+		t.Fatalf("raise status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if code := serve(http.MethodGet, "/api/v1/test-rate-limit", "").Code; code != http.StatusOK {
+		t.Errorf("request after raising: status = %d, want 200", code)
+	}
+}
+
+func TestRuntimeConfig_RejectsImmutableAndInvalidFields(t *testing.T) {
+	a, serve := newRuntimeConfigApp(t)
+
+	tests := []struct {
+		name, body string
+		wantKeys   []string
+	}{
+		{"immutable fields", `{"database":{"dsn":"x"},"rate_limit":{"enabled":false}}`, []string{"database", "rate_limit.enabled"}},
+		{"invalid value", `{"rate_limit":{"burst":0},"log":{"level":"verbose"}}`, []string{"rate_limit.burst", "log.level"}},
+		{"cache not configured", `{"cache":{"enabled":true}}`, []string{"cache.enabled"}},
+		{"null", `{"maintenance":{"paused":null}}`, []string{"maintenance.paused"}},
+		{"wrong type", `{"rate_limit":{"rps":"fast"}}`, []string{"rate_limit.rps"}},
+		{"not an object", `[]`, []string{"body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(http.MethodPut, runtimeConfigPath, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			var resp pkg.ValidationErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			for _, key := range tt.wantKeys {
+				if resp.Errors[key] == "" {
+					t.Errorf("errors = %v, want one for %q", resp.Errors, key)
+				}
+			}
+		})
+	}
+
+	// A rejected document changes nothing, not even its valid fields.
+	serve(http.MethodPut, runtimeConfigPath, `{"maintenance":{"paused":true},"log":{"level":"verbose"}}`)
+	if a.maintenance.paused.Load() {
+		t.Error("maintenance paused by a rejected document")
+	}
+	got := decodeRuntimeConfig(t, serve(http.MethodGet, runtimeConfigPath, ""))
+	if got.Effective.RateLimit.Burst != 100 || got.UpdatedBy != "" {
+		t.Errorf("effective = %+v, want the startup values", got)
+	}
+}
+
+func TestRuntimeConfig_GetAndApply(t *testing.T) {
+	a, serve := newRuntimeConfigApp(t)
+
+	w := serve(http.MethodGet, runtimeConfigPath, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", w.Code)
+	}
+	if got := decodeRuntimeConfig(t, w); got.Effective.RateLimit.RPS != 100 || got.UpdatedAt != nil {
+		t.Errorf("GET = %+v, want the startup values and no change", got)
+	}
+
+	w = serve(http.MethodPut, runtimeConfigPath, `{"log":{"level":"DEBUG"},"maintenance":{"paused":true}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := decodeRuntimeConfig(t, w); got.Effective.Log.Level != "debug" || !got.Effective.Maintenance.Paused {
+		t.Errorf("PUT = %+v, want debug logging and paused maintenance", got)
+	}
+	if !a.maintenance.paused.Load() {
+		t.Error("maintenance not paused")
+	}
+	if !a.logger.Enabled(t.Context(), slog.LevelDebug) {
+		t.Error("logger does not log debug records")
+	}
+}
+
+func TestRuntimeConfig_RequiresAdminUpdate(t *testing.T) {
+	a, _ := newRuntimeConfigApp(t)
+	if err := a.rbacService.AddUserPermissions("2", "admin", []string{"read"}); err != nil {
+		t.Fatalf("grant admin:read: %v", err)
+	}
+	token, err := a.jwtService.GenerateToken("2", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	req := testutil.NewJSONRequest(t, http.MethodPut, runtimeConfigPath, `{"maintenance":{"paused":true}}`)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if w := testutil.Serve(a.engine, req); w.Code != http.StatusForbidden {
+		t.Errorf("PUT with admin:read only: status = %d, want 403", w.Code)
+	}
+}
+
+func TestRuntimeConfig_DisabledByDefault(t *testing.T) {
+	a, err := New(testutil.NewTestConfig())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	w := testutil.Serve(a.engine, testutil.NewJSONRequest(t, http.MethodGet, runtimeConfigPath, ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ModelProvider is implemented by modules that own database tables. New
+// auto-migrates their models in debug mode and checks them against the live
+// schema at startup in every mode (database.schema_check).
+type ModelProvider interface {
+	Models() []any
+}
+
+// errSchemaDrift is returned by New when database.schema_check is "strict"
+// and the live schema lacks tables, columns or indexes of a model.
+var errSchemaDrift = errors.New("database schema drifts from the models")
+
+// SchemaDrift lists what one model expects that the live schema lacks.
+type SchemaDrift struct {
+	Model          string
+	Table          string
+	MissingTable   bool
+	MissingColumns []string
+	MissingIndexes []string
+}
+
+// String renders d for logs and the strict mode error, e.g.
+// "users (domain.User): missing columns [bio]".
+func (d SchemaDrift) String() string {
+	var parts []string
+	if d.MissingTable {
+		parts = append(parts, "missing table")
+	}
+	if len(d.MissingColumns) > 0 {
+		parts = append(parts, fmt.Sprintf("missing columns %v", d.MissingColumns))
+	}
+	if len(d.MissingIndexes) > 0 {
+		parts = append(parts, fmt.Sprintf("missing indexes %v", d.MissingIndexes))
+	}
+	return fmt.Sprintf("%s (%s): %s", d.Table, d.Model, strings.Join(parts, ", "))
+}
+
+// collectModels returns the models of every module implementing
+// ModelProvider, in module order, each model type once.
+func collectModels(modules []Module) []any {
+	var models []any
+	seen := make(map[reflect.Type]bool)
+	for _, m := range modules {
+		p, ok := m.(ModelProvider)
+		if !ok {
+			continue
+		}
+		for _, model := range p.Models() {
+			typ := reflect.TypeOf(model)
+			if seen[typ] {
+				continue
+			}
+			seen[typ] = true
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// checkSchema compares the tables, columns and indexes GORM derives from
+// models (as AutoMigrate would create them) with the live schema, using
+// only the Migrator's read methods: it never executes DDL. Models without
+// drift are left out of the result. Type and constraint differences are not
+// reported.
+func checkSchema(db *gorm.DB, models []any) ([]SchemaDrift, error) {
+	migrator := db.Migrator()
+	var drifts []SchemaDrift
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("parse model %T: %w", model, err)
+		}
+		drift := SchemaDrift{Model: stmt.Schema.ModelType.String(), Table: stmt.Schema.Table}
+		if !migrator.HasTable(model) {
+			drift.MissingTable = true
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		columns, err := migrator.ColumnTypes(model)
+		if err != nil {
+			return nil, fmt.Errorf("read columns of %s: %w", drift.Table, err)
+		}
+		live := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			live[strings.ToLower(c.Name())] = true
+		}
+		for _, name := range stmt.Schema.DBNames {
+			if field := stmt.Schema.FieldsByDBName[name]; field != nil && field.IgnoreMigration {
+				continue
+			}
+			if !live[strings.ToLower(name)] {
+				drift.MissingColumns = append(drift.MissingColumns, name)
+			}
+		}
+		for _, idx := range stmt.Schema.ParseIndexes() {
+			if !migrator.HasIndex(model, idx.Name) {
+				drift.MissingIndexes = append(drift.MissingIndexes, idx.Name)
+			}
+		}
+
+		if len(drift.MissingColumns) > 0 || len(drift.MissingIndexes) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+// reportSchemaDrift logs one warning per drifting model. In strict mode it
+// returns errSchemaDrift with the full report instead, so New refuses to
+// start.
+func reportSchemaDrift(log *slog.Logger, drifts []SchemaDrift, strict bool) error {
+	if len(drifts) == 0 {
+		return nil
+	}
+	if strict {
+		report := make([]string, len(drifts))
+		for i, d := range drifts {
+			report[i] = d.String()
+		}
+		return fmt.Errorf("%w (database.schema_check is strict):\n  %s", errSchemaDrift, strings.Join(report, "\n  "))
+	}
+	for _, d := range drifts {
+		log.Warn("database schema drifts from model",
+			slog.String("table", d.Table),
+			slog.String("model", d.Model),
+			slog.Bool("missing_table", d.MissingTable),
+			slog.Any("missing_columns", d.MissingColumns),
+			slog.Any("missing_indexes", d.MissingIndexes),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/module/note"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// dropBio removes users.bio and the users email index from the migrated
+// database at dsn, the drift a deployment gets when a release adds a field
+// and nobody migrates. It returns the open handle: opening the DSN again
+// through testutil would migrate the drift away.
+func dropBio(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db := testutil.OpenTestDB(t, dsn)
+	if err := db.Exec("ALTER TABLE users DROP COLUMN bio").Error; err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+	if err := db.Exec("DROP INDEX idx_users_tenant_email").Error; err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	return db
+}
+
+func TestCollectModels(t *testing.T) {
+	noteModule := note.NewModule(note.NewNoteHandler(nil))
+	models := collectModels([]Module{noteModule, &mockModule{}, noteModule})
+	if len(models) != 1 {
+		t.Fatalf("collectModels() = %v, want the note model once", models)
+	}
+	if _, ok := models[0].(*domain.Note); !ok {
+		t.Errorf("collectModels()[0] = %T, want *domain.Note", models[0])
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	db := dropBio(t, testutil.MemoryDSN(t))
+	if err := db.Exec("DROP TABLE notes").Error; err != nil {
+		t.Fatalf("drop table: %v", err)
+	}
+
+	drifts, err := checkSchema(db, []any{&domain.User{}, &domain.Note{}, &domain.Notification{}})
+	if err != nil {
+		t.Fatalf("checkSchema() error = %v", err)
+	}
+	if len(drifts) != 2 {
+		t.Fatalf("checkSchema() = %+v, want users and notes", drifts)
+	}
+	users, notes := drifts[0], drifts[1]
+	if users.Table != "users" || users.Model != "domain.User" || users.MissingTable ||
+		strings.Join(users.MissingColumns, ",") != "bio" || strings.Join(users.MissingIndexes, ",") != "idx_users_tenant_email" {
+		t.Errorf("users drift = %+v, want missing column bio and index idx_users_tenant_email", users)
+	}
+	if notes.Table != "notes" || !notes.MissingTable {
+		t.Errorf("notes drift = %+v, want missing table", notes)
+	}
+
+	// The check is read-only: the column is still missing afterwards.
+	if db.Migrator().HasColumn(&domain.User{}, "bio") {
+		t.Error("checkSchema() added the missing column")
+	}
+}
+
+func TestReportSchemaDrift_WarnMode(t *testing.T) {
+	var logs bytes.Buffer
+	drifts := []SchemaDrift{{Model: "domain.User", Table: "users", MissingColumns: []string{"bio"}}}
+	if err := reportSchemaDrift(slog.New(slog.NewTextHandler(&logs, nil)), drifts, false); err != nil {
+		t.Fatalf("reportSchemaDrift() error = %v, want nil in warn mode", err)
+	}
+	out := logs.String()
+	for _, want := range []string{"level=WARN", "database schema drifts from model", "table=users", "model=domain.User", "missing_columns=[bio]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestNew_SchemaCheck(t *testing.T) {
+	newApp := func(mode string) (*App, error) {
+		dsn := testutil.MemoryDSN(t)
+		dropBio(t, dsn)
+		return New(testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+			c.Database.SchemaCheck = mode
+		}))
+	}
+
+	a, err := newApp(config.SchemaCheckWarn)
+	if err != nil {
+		t.Fatalf("New() in warn mode error = %v", err)
+	}
+	cleanupTestApp(t, a)
+
+	a, err = newApp(config.SchemaCheckStrict)
+	if err == nil {
+		cleanupTestApp(t, a)
+		t.Fatal("New() in strict mode error = nil, want schema drift")
+	}
+	if !errors.Is(err, errSchemaDrift) {
+		t.Errorf("New() error = %v, want errSchemaDrift", err)
+	}
+	// The tenant migration, which runs first, restores the dropped index.
+	if msg := err.Error(); !strings.Contains(msg, "users (domain.User): missing columns [bio]") || strings.Contains(msg, "missing indexes") {
+		t.Errorf("New() error = %q, want the users drift in the report", msg)
+	}
+}
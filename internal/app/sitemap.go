@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// sitemapPath is where the sitemap is served (server.sitemap.enabled).
+const sitemapPath = "/sitemap.xml"
+
+// sitemapNS is the XML namespace of the sitemaps protocol 0.9.
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// SitemapProvider is implemented by modules with public pages. With
+// server.sitemap enabled, /sitemap.xml lists the sources of every module
+// after the home page.
+type SitemapProvider interface {
+	Sitemap() []pkg.SitemapSource
+}
+
+// appSitemap lists the pages App registers itself.
+var appSitemap = []pkg.SitemapSource{{Paths: []string{"/"}}}
+
+// collectSitemapSources returns appSitemap followed by the sources of each
+// module that declares some.
+func collectSitemapSources(modules []Module) []pkg.SitemapSource {
+	sources := append([]pkg.SitemapSource(nil), appSitemap...)
+	for _, m := range modules {
+		if p, ok := m.(SitemapProvider); ok {
+			sources = append(sources, p.Sitemap()...)
+		}
+	}
+	return sources
+}
+
+// authGate reports which paths the Auth middleware guards for a GET: /api
+// paths that are not public. It mirrors the condition New installs
+// ginx.Auth under.
+func authGate(public *publicPaths) func(path string) bool {
+	return func(path string) bool {
+		return strings.HasPrefix(path, "/api") && !public.match(http.MethodGet, path)
+	}
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemap renders /sitemap.xml from its sources and reuses the document for
+// ttl. Entries are never listed when a visitor would have to sign in to see
+// them: sources with a Permission are skipped when rbacEnabled, and paths
+// gated reports (the protected API) are dropped.
+type sitemap struct {
+	baseURL     string
+	sources     []pkg.SitemapSource
+	rbacEnabled bool
+	gated       func(path string) bool // nil when auth is disabled
+	ttl         time.Duration
+	clock       pkg.Clock
+
+	// mu guards the cached document and serializes regeneration, so a
+	// burst of crawler requests after expiry collects the entries once.
+	mu      sync.Mutex
+	body    []byte
+	expires time.Time
+}
+
+func newSitemap(baseURL string, sources []pkg.SitemapSource, rbacEnabled bool, gated func(string) bool, ttl time.Duration, clock pkg.Clock) *sitemap {
+	return &sitemap{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		sources:     sources,
+		rbacEnabled: rbacEnabled,
+		gated:       gated,
+		ttl:         ttl,
+		clock:       clock,
+	}
+}
+
+// document returns the cached XML, generating it first when it has expired.
+// When generation fails a stale document is served rather than none.
+func (s *sitemap) document(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if s.body != nil && now.Before(s.expires) {
+		return s.body, nil
+	}
+	body, err := s.render(ctx)
+	if err != nil {
+		if s.body != nil {
+			slog.WarnContext(ctx, "sitemap refresh failed, serving stale document", slog.Any("error", err))
+			return s.body, nil
+		}
+		return nil, err
+	}
+	s.body, s.expires = body, now.Add(s.ttl)
+	return body, nil
+}
+
+// render collects the entries of every source, in order, and encodes
+// them. A path listed twice keeps its first entry; entries beyond
+// pkg.SitemapMaxURLs are dropped with a warning.
+func (s *sitemap) render(ctx context.Context) ([]byte, error) {
+	set := sitemapURLSet{XMLNS: sitemapNS}
+	seen := make(map[string]bool)
+	add := func(e pkg.SitemapEntry) {
+		if !strings.HasPrefix(e.Path, "/") || seen[e.Path] || (s.gated != nil && s.gated(e.Path)) {
+			return
+		}
+		seen[e.Path] = true
+		u := sitemapURL{Loc: s.baseURL + e.Path}
+		if !e.LastMod.IsZero() {
+			u.LastMod = e.LastMod.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, u)
+	}
+	for _, src := range s.sources {
+		if src.Permission != "" && s.rbacEnabled {
+			continue
+		}
+		for _, p := range src.Paths {
+			add(pkg.SitemapEntry{Path: p})
+		}
+		if src.Entries == nil {
+			continue
+		}
+		entries, err := src.Entries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("collect sitemap entries: %w", err)
+		}
+		for _, e := range entries {
+			add(e)
+		}
+	}
+	if n := len(set.URLs); n > pkg.SitemapMaxURLs {
+		slog.WarnContext(ctx, "sitemap truncated", slog.Int("urls", n), slog.Int("max", pkg.SitemapMaxURLs))
+		set.URLs = set.URLs[:pkg.SitemapMaxURLs]
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(set); err != nil {
+		return nil, fmt.Errorf("encode sitemap: %w", err)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// registerSitemapRoutes serves GET /sitemap.xml; a nil s leaves it
+// unregistered.
+func registerSitemapRoutes(r *gin.Engine, s *sitemap) {
+	if s == nil {
+		return
+	}
+	cacheControl := "public, max-age=" + strconv.Itoa(int(s.ttl.Seconds()))
+	r.GET(sitemapPath, func(c *gin.Context) {
+		body, err := s.document(c.Request.Context())
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "render sitemap failed", slog.Any("error", err))
+			renderError(c, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		c.Header("Cache-Control", cacheControl)
+		c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+	})
+}
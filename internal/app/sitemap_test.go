@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// getSitemap fetches /sitemap.xml from a and decodes it.
+func getSitemap(t *testing.T, a *App) sitemapURLSet {
+	t.Helper()
+	w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, sitemapPath, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /sitemap.xml status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var set sitemapURLSet
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("decode sitemap: %v\n%s", err, w.Body.String())
+	}
+	if set.XMLName.Space != sitemapNS || set.XMLName.Local != "urlset" {
+		t.Errorf("root element = %+v, want urlset in %s", set.XMLName, sitemapNS)
+	}
+	return set
+}
+
+func TestSitemap_Render(t *testing.T) {
+	lastMod := time.Date(2026, 10, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	sources := []pkg.SitemapSource{
+		{Paths: []string{"/", "/about"}},
+		{Paths: []string{"/api/v1/notes", "/api/v1/auth/login", "relative"}},
+		{Entries: func(context.Context) ([]pkg.SitemapEntry, error) {
+			return []pkg.SitemapEntry{{Path: "/posts/1", LastMod: lastMod}, {Path: "/about", LastMod: lastMod}}, nil
+		}},
+		{Paths: []string{"/members"}, Permission: "members:read"},
+	}
+	gated := authGate(newPublicPaths([]string{"/api/v1/auth/login"}, false))
+	s := newSitemap("https://example.com/", sources, true, gated, time.Hour, pkg.RealClock)
+
+	body, err := s.render(t.Context())
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	var set sitemapURLSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		t.Fatalf("decode sitemap: %v", err)
+	}
+	want := []sitemapURL{
+		{Loc: "https://example.com/"},
+		{Loc: "https://example.com/about"},
+		{Loc: "https://example.com/api/v1/auth/login"},
+		{Loc: "https://example.com/posts/1", LastMod: "2026-10-01T00:30:00Z"},
+	}
+	if len(set.URLs) != len(want) {
+		t.Fatalf("urls = %+v, want %+v", set.URLs, want)
+	}
+	for i := range want {
+		if set.URLs[i] != want[i] {
+			t.Errorf("url[%d] = %+v, want %+v", i, set.URLs[i], want[i])
+		}
+	}
+
+	// Without RBAC everyone may see the members page.
+	s = newSitemap("https://example.com", sources[3:], false, nil, time.Hour, pkg.RealClock)
+	if body, _ := s.render(t.Context()); !xmlHasLoc(t, body, "https://example.com/members") {
+		t.Errorf("sitemap without rbac = %s, want /members", body)
+	}
+}
+
+func xmlHasLoc(t *testing.T, body []byte, loc string) bool {
+	t.Helper()
+	var set sitemapURLSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		t.Fatalf("decode sitemap: %v", err)
+	}
+	for _, u := range set.URLs {
+		if u.Loc == loc {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSitemap_CachesForTTL(t *testing.T) {
+	calls := 0
+	fail := false
+	src := pkg.SitemapSource{Entries: func(context.Context) ([]pkg.SitemapEntry, error) {
+		calls++
+		if fail {
+			return nil, errors.New("database down")
+		}
+		return []pkg.SitemapEntry{{Path: "/"}}, nil
+	}}
+	clock := pkg.NewFakeClock(time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC))
+	s := newSitemap("https://example.com", []pkg.SitemapSource{src}, false, nil, time.Hour, clock)
+
+	first, err := s.document(t.Context())
+	if err != nil {
+		t.Fatalf("document() error = %v", err)
+	}
+	clock.Advance(59 * time.Minute)
+	if _, err := s.document(t.Context()); err != nil || calls != 1 {
+		t.Fatalf("document() within ttl: calls = %d, err = %v; want cached", calls, err)
+	}
+
+	clock.Advance(time.Minute)
+	fail = true
+	stale, err := s.document(t.Context())
+	if err != nil || calls != 2 || string(stale) != string(first) {
+		t.Fatalf("document() after failed refresh: calls = %d, err = %v; want the stale document", calls, err)
+	}
+
+	s = newSitemap("https://example.com", []pkg.SitemapSource{src}, false, nil, time.Hour, clock)
+	if _, err := s.document(t.Context()); err == nil {
+		t.Error("document() without a cached copy error = nil, want the source error")
+	}
+}
+
+func TestNew_Sitemap(t *testing.T) {
+	updated := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	newApp := func(opts ...testutil.ConfigOption) *App {
+		dsn := testutil.MemoryDSN(t)
+		testutil.SeedUsers(t, testutil.OpenTestDB(t, dsn),
+			domain.User{BaseModel: domain.BaseModel{UpdatedAt: domain.NewTime(updated.Add(-time.Hour))}},
+			domain.User{BaseModel: domain.BaseModel{UpdatedAt: domain.NewTime(updated)}},
+		)
+		opts = append(opts, testutil.WithSQLitePath(dsn), func(c *config.Config) {
+			c.Server.BaseURL = "https://example.com"
+			c.Server.Sitemap.Enabled = true
+		})
+		a, err := New(testutil.NewTestConfig(opts...))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		t.Cleanup(func() { cleanupTestApp(t, a) })
+		return a
+	}
+
+	set := getSitemap(t, newApp())
+	want := []sitemapURL{
+		{Loc: "https://example.com/"},
+		{Loc: "https://example.com/users", LastMod: "2026-10-10T12:00:00Z"},
+		{Loc: "https://example.com/users/1", LastMod: "2026-10-10T11:00:00Z"},
+		{Loc: "https://example.com/users/2", LastMod: "2026-10-10T12:00:00Z"},
+	}
+	if len(set.URLs) != len(want) {
+		t.Fatalf("urls = %+v, want %+v", set.URLs, want)
+	}
+	for i := range want {
+		if set.URLs[i] != want[i] {
+			t.Errorf("url[%d] = %+v, want %+v", i, set.URLs[i], want[i])
+		}
+	}
+
+	// With RBAC the user pages need users:read, which crawlers lack.
+	set = getSitemap(t, newApp(testutil.WithRBAC()))
+	if len(set.URLs) != 1 || set.URLs[0].Loc != "https://example.com/" {
+		t.Errorf("urls with rbac = %+v, want only the home page", set.URLs)
+	}
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestNew_StaleCacheRefreshesThroughEngine(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	db := testutil.OpenTestDB(t, dsn)
+	testutil.SeedUsers(t, db, domain.User{})
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{
+			Enabled:  true,
+			TTL:      config.Duration(50 * time.Millisecond),
+			StaleTTL: config.Duration(time.Hour),
+			MaxSize:  100,
+		}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+
+	get := func() *httptest.ResponseRecorder {
+		w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/v1/users status = %d, want 200", w.Code)
+		}
+		return w
+	}
+
+	before := get().Body.String()
+	// Written behind the app's back, so nothing purges the entry.
+	testutil.SeedUsers(t, db, domain.User{Name: "Late User", Email: "late@example.com"})
+	time.Sleep(80 * time.Millisecond)
+
+	w := get()
+	if w.Body.String() != before || w.Header().Get(middleware.CacheStatusHeader) != "STALE" {
+		t.Fatalf("GET past ttl = %s (X-Cache %q), want the cached list marked STALE", w.Body.String(), w.Header().Get(middleware.CacheStatusHeader))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		w := get()
+		if w.Header().Get(middleware.CacheStatusHeader) == "" {
+			if w.Body.String() == before {
+				t.Fatalf("refreshed list = %s, want the seeded user included", w.Body.String())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("entry was not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
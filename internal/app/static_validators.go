@@ -0,0 +1,171 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/simp-lee/gobase/web"
+)
+
+// buildTime is when the binary was built, in RFC 3339, set at link time:
+//
+//	go build -ldflags "-X github.com/simp-lee/gobase/internal/app.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// make build sets it. The embedded static assets report it as their
+// modification time, since embed.FS has none.
+var buildTime string
+
+// staticModTime is the Last-Modified of the embedded static assets:
+// buildTime, or the VCS commit time of a build without it. It is zero,
+// and no Last-Modified is sent, when neither is known. Tests replace it.
+var staticModTime func() time.Time = sync.OnceValue(func() time.Time {
+	if t, err := time.Parse(time.RFC3339, buildTime); err == nil {
+		return t.UTC()
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.time" {
+				if t, err := time.Parse(time.RFC3339, s.Value); err == nil {
+					return t.UTC()
+				}
+			}
+		}
+	}
+	return time.Time{}
+})
+
+// embeddedETags holds a content-hash ETag per embedded static file, keyed
+// by its path under static/. The files cannot change while the process
+// runs, so they are hashed once.
+var embeddedETags = sync.OnceValues(func() (map[string]string, error) {
+	sub, err := fs.Sub(web.EmbeddedFS, "static")
+	if err != nil {
+		return nil, err
+	}
+	return hashFiles(sub)
+})
+
+// hashFiles returns the strong ETag of every regular file in fsys: the
+// first 128 bits of its SHA-256, in hex.
+func hashFiles(fsys fs.FS) (map[string]string, error) {
+	tags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		tags[name] = `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+		return nil
+	})
+	return tags, err
+}
+
+// assetETags looks up the ETags of a static mount. Files that shadow holds
+// (the server.templates.override_dir static/ files) are served from disk
+// instead and get none. A nil *assetETags has no ETags.
+type assetETags struct {
+	tags   map[string]string
+	shadow fs.FS
+}
+
+// get returns the ETag of the file at name, a slash-rooted path, or "".
+func (e *assetETags) get(name string) string {
+	if e == nil {
+		return ""
+	}
+	name = strings.TrimPrefix(name, "/")
+	tag := e.tags[name]
+	if tag != "" && e.shadow != nil {
+		if _, err := fs.Stat(e.shadow, name); err == nil {
+			return ""
+		}
+	}
+	return tag
+}
+
+// modTimeFS reports modTime as the modification time of every file, for
+// http.FileServer's Last-Modified and If-Modified-Since handling.
+type modTimeFS struct {
+	fsys    fs.FS
+	modTime time.Time
+}
+
+// Open implements fs.FS.
+func (s modTimeFS) Open(name string) (fs.File, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return modTimeFile{File: f, modTime: s.modTime}, nil
+}
+
+// modTimeFile keeps the Seek and ReadDir of the file it wraps, which
+// http.FS needs to serve ranges and directories.
+type modTimeFile struct {
+	fs.File
+	modTime time.Time
+}
+
+func (f modTimeFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return modTimeInfo{FileInfo: info, modTime: f.modTime}, nil
+}
+
+func (f modTimeFile) Seek(offset int64, whence int) (int64, error) {
+	s, ok := f.File.(io.Seeker)
+	if !ok {
+		return 0, errors.New("seek not supported")
+	}
+	return s.Seek(offset, whence)
+}
+
+func (f modTimeFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	d, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, errors.New("not a directory")
+	}
+	return d.ReadDir(n)
+}
+
+type modTimeInfo struct {
+	fs.FileInfo
+	modTime time.Time
+}
+
+func (i modTimeInfo) ModTime() time.Time { return i.modTime }
+
+// validatorsWriter puts Last-Modified back on a 304 that carries an ETag,
+// which http.ServeContent removes, so both validators are on every
+// response for an embedded asset. lastModified is empty when
+// staticModTime is unknown.
+type validatorsWriter struct {
+	http.ResponseWriter
+	lastModified string
+}
+
+func (w validatorsWriter) WriteHeader(code int) {
+	h := w.Header()
+	if code == http.StatusNotModified && w.lastModified != "" && h.Get("ETag") != "" && h.Get("Last-Modified") == "" {
+		h.Set("Last-Modified", w.lastModified)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
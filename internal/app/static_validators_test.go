@@ -0,0 +1,161 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/web"
+)
+
+// withBuildTime makes the embedded assets report t as their modification
+// time for the rest of the test.
+func withBuildTime(t *testing.T, mod time.Time) {
+	t.Helper()
+	orig := staticModTime
+	staticModTime = func() time.Time { return mod }
+	t.Cleanup(func() { staticModTime = orig })
+}
+
+// getAsset requests path with the given request headers.
+func getAsset(r http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return testutil.Serve(r, req)
+}
+
+func TestStaticAssets_ConditionalRequests(t *testing.T) {
+	built := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	withBuildTime(t, built)
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "release", nil, nil); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+	css, err := web.EmbeddedFS.ReadFile("static/css/app.css")
+	if err != nil {
+		t.Fatalf("read embedded app.css: %v", err)
+	}
+	sum := sha256.Sum256(css)
+	wantETag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	wantLastModified := built.Format(http.TimeFormat)
+
+	checkValidators := func(name string, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if got := w.Header().Get("ETag"); got != wantETag {
+			t.Errorf("%s: ETag = %q, want %q", name, got, wantETag)
+		}
+		if got := w.Header().Get("Last-Modified"); got != wantLastModified {
+			t.Errorf("%s: Last-Modified = %q, want %q", name, got, wantLastModified)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "public, max-age=86400" {
+			t.Errorf("%s: Cache-Control = %q", name, got)
+		}
+	}
+
+	w := getAsset(r, "/static/css/app.css", nil)
+	if w.Code != http.StatusOK || w.Body.String() != string(css) {
+		t.Fatalf("GET app.css = %d with %d bytes, want 200 with the file", w.Code, w.Body.Len())
+	}
+	checkValidators("200", w)
+
+	for _, tt := range []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"If-None-Match", map[string]string{"If-None-Match": wantETag}, http.StatusNotModified},
+		{"If-None-Match list", map[string]string{"If-None-Match": `"other", ` + wantETag}, http.StatusNotModified},
+		{"If-Modified-Since", map[string]string{"If-Modified-Since": wantLastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": built.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		// If-None-Match takes precedence over If-Modified-Since.
+		{"stale ETag", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": wantLastModified}, http.StatusOK},
+	} {
+		w := getAsset(r, "/static/css/app.css", tt.headers)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+			continue
+		}
+		if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("%s: 304 body = %q, want empty", tt.name, w.Body.String())
+		}
+		checkValidators(tt.name, w)
+	}
+}
+
+func TestStaticAssets_DebugKeepsFileModTime(t *testing.T) {
+	withBuildTime(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "debug", nil, nil); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+	info, err := os.Stat(filepath.Join("..", "..", "web", "static", "css", "app.css"))
+	if err != nil {
+		t.Fatalf("stat app.css: %v", err)
+	}
+
+	w := getAsset(r, "/static/css/app.css", nil)
+	if got := w.Header().Get("ETag"); got != "" {
+		t.Errorf("debug ETag = %q, want none", got)
+	}
+	if got, want := w.Header().Get("Last-Modified"), info.ModTime().UTC().Format(http.TimeFormat); got != want {
+		t.Errorf("debug Last-Modified = %q, want the file's %q", got, want)
+	}
+}
+
+func TestStaticAssets_OverriddenFileHasNoETag(t *testing.T) {
+	withBuildTime(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	overridden := time.Date(2026, 10, 5, 8, 0, 0, 0, time.UTC)
+	overrides := fstest.MapFS{"static/css/app.css": &fstest.MapFile{Data: []byte("body{}"), ModTime: overridden}}
+	r := gin.New()
+	if err := registerStaticRoutesWithError(r, "release", nil, overrides); err != nil {
+		t.Fatalf("registerStaticRoutesWithError() error = %v", err)
+	}
+
+	w := getAsset(r, "/static/css/app.css", nil)
+	if w.Body.String() != "body{}" || w.Header().Get("ETag") != "" || w.Header().Get("Last-Modified") != overridden.Format(http.TimeFormat) {
+		t.Errorf("overridden app.css = %q, ETag %q, Last-Modified %q; want the override by its own mtime",
+			w.Body.String(), w.Header().Get("ETag"), w.Header().Get("Last-Modified"))
+	}
+	if w := getAsset(r, "/static/js/app.js", nil); w.Header().Get("ETag") == "" {
+		t.Error("embedded app.js next to an override has no ETag")
+	}
+}
+
+func TestStaticAssets_PrecompressedVariantETag(t *testing.T) {
+	memFS := fstest.MapFS{
+		"app.css":    &fstest.MapFile{Data: []byte("plain-css")},
+		"app.css.br": &fstest.MapFile{Data: []byte("br-css")},
+	}
+	tags, err := hashFiles(memFS)
+	if err != nil {
+		t.Fatalf("hashFiles() error = %v", err)
+	}
+	r := gin.New()
+	r.GET("/static/*filepath", cacheStaticHandler("/static", http.FS(memFS), time.Hour, &assetETags{tags: tags}))
+
+	plain := getAsset(r, "/static/app.css", nil)
+	br := getAsset(r, "/static/app.css", map[string]string{"Accept-Encoding": "br"})
+	if plain.Header().Get("ETag") != tags["app.css"] || br.Header().Get("ETag") != tags["app.css.br"] || tags["app.css"] == tags["app.css.br"] {
+		t.Fatalf("ETags: plain %q, br %q; want each representation's own hash", plain.Header().Get("ETag"), br.Header().Get("ETag"))
+	}
+
+	w := getAsset(r, "/static/app.css", map[string]string{"Accept-Encoding": "br", "If-None-Match": tags["app.css.br"]})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("br revalidation = %d %q, want an empty 304", w.Code, w.Body.String())
+	}
+	w = getAsset(r, "/static/app.css", map[string]string{"If-None-Match": tags["app.css.br"]})
+	if w.Code != http.StatusOK || w.Body.String() != "plain-css" {
+		t.Errorf("plain request with the br ETag = %d %q, want the plain file", w.Code, w.Body.String())
+	}
+}
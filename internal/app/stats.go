@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// statsPermission guards the stats dashboard and its JSON endpoint when RBAC
+// is enabled.
+const statsPermission = "admin:read"
+
+// Stats is a snapshot of runtime health for the admin stats dashboard. It
+// holds counters only: no configuration, secrets or connection strings.
+type Stats struct {
+	CollectedAt   domain.Time `json:"collected_at"`
+	StartedAt     domain.Time `json:"started_at"`
+	UptimeSeconds int64       `json:"uptime_seconds"`
+	GoVersion     string      `json:"go_version"`
+	Goroutines    int         `json:"goroutines"`
+	Memory        MemoryStats `json:"memory"`
+	Database      DBPoolStats `json:"database"`
+	Cache         *CacheStats `json:"cache"` // nil when the response cache is disabled
+}
+
+// MemoryStats summarizes runtime.MemStats.
+type MemoryStats struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	TotalAlloc     uint64 `json:"total_alloc_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	PauseTotalMS   int64  `json:"gc_pause_total_ms"`
+}
+
+// DBPoolStats summarizes sql.DBStats for the primary database.
+type DBPoolStats struct {
+	Driver             string `json:"driver"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDurationMS     int64  `json:"wait_duration_ms"`
+	Error              string `json:"error,omitempty"` // set when the pool could not be read
+}
+
+// CacheStats reports response cache lookups counted by
+// middleware.CountingCache.
+type CacheStats struct {
+	Entries int     `json:"entries"`
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits / (hits + misses), 0 before any lookup
+}
+
+// StatsCollector assembles a Stats snapshot; it is called once per request
+// to the dashboard, so the numbers are always current.
+type StatsCollector interface {
+	Collect(ctx context.Context) Stats
+}
+
+// runtimeStats is the StatsCollector of a running App.
+type runtimeStats struct {
+	startedAt     time.Time
+	db            *gorm.DB
+	cacheEntries  func() int // nil when the response cache is disabled
+	cacheCounters *middleware.CacheCounters
+}
+
+// Collect implements StatsCollector.
+func (s *runtimeStats) Collect(context.Context) Stats {
+	now := time.Now()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	st := Stats{
+		CollectedAt:   domain.NewTime(now.UTC()),
+		StartedAt:     domain.NewTime(s.startedAt.UTC()),
+		UptimeSeconds: int64(now.Sub(s.startedAt) / time.Second),
+		GoVersion:     runtime.Version(),
+		Goroutines:    runtime.NumGoroutine(),
+		Memory: MemoryStats{
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapObjects:    mem.HeapObjects,
+			SysBytes:       mem.Sys,
+			TotalAlloc:     mem.TotalAlloc,
+			NumGC:          mem.NumGC,
+			PauseTotalMS:   time.Duration(mem.PauseTotalNs).Milliseconds(),
+		},
+		Database: s.dbStats(),
+	}
+	if s.cacheEntries != nil {
+		hits, misses := s.cacheCounters.Hits(), s.cacheCounters.Misses()
+		st.Cache = &CacheStats{Entries: s.cacheEntries(), Hits: hits, Misses: misses}
+		if total := hits + misses; total > 0 {
+			st.Cache.HitRate = float64(hits) / float64(total)
+		}
+	}
+	return st
+}
+
+func (s *runtimeStats) dbStats() DBPoolStats {
+	if s.db == nil {
+		return DBPoolStats{Error: "no database"}
+	}
+	out := DBPoolStats{Driver: s.db.Dialector.Name()}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		out.Error = "connection pool unavailable"
+		return out
+	}
+	ds := sqlDB.Stats()
+	out.MaxOpenConnections = ds.MaxOpenConnections
+	out.OpenConnections = ds.OpenConnections
+	out.InUse = ds.InUse
+	out.Idle = ds.Idle
+	out.WaitCount = ds.WaitCount
+	out.WaitDurationMS = ds.WaitDuration.Milliseconds()
+	return out
+}
+
+// registerStatsRoutes adds the stats dashboard page (GET /admin/stats) and
+// its JSON form (GET /api/v1/admin/stats). With RBAC enabled both require
+// statsPermission (the API through app.New's RBAC wiring); without RBAC they
+// exist only in debug mode.
+func registerStatsRoutes(api, pages *gin.RouterGroup, deps *RouteDeps) {
+	if deps.Stats == nil || (deps.RBAC == nil && deps.Mode != gin.DebugMode) {
+		return
+	}
+	api.GET("/admin/stats", func(c *gin.Context) {
+		pkg.Success(c, deps.Stats.Collect(c.Request.Context()))
+	})
+	pages.GET("/admin/stats", func(c *gin.Context) {
+		if deps.RBAC != nil && !middleware.GetPermissions(c).Can(statsPermission) {
+			renderError(c, http.StatusForbidden, "forbidden")
+			return
+		}
+		c.HTML(http.StatusOK, "admin/stats.html", gin.H{
+			"Stats":     deps.Stats.Collect(c.Request.Context()),
+			"CSRFToken": middleware.GetCSRFToken(c),
+			"Perms":     middleware.GetPermissions(c),
+			"Nav":       middleware.GetNav(c),
+			"Features":  pkg.GetFeatures(c),
+			"Locale":    requestctx.Locale(c),
+			"Unread":    middleware.GetUnread(c),
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/web"
+)
+
+// fakeStats returns a fixed snapshot.
+type fakeStats struct{}
+
+func (fakeStats) Collect(context.Context) Stats {
+	at := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+	return Stats{
+		CollectedAt:   domain.NewTime(at),
+		StartedAt:     domain.NewTime(at.Add(-90 * time.Second)),
+		UptimeSeconds: 90,
+		GoVersion:     "go1.99",
+		Goroutines:    42,
+		Memory:        MemoryStats{HeapAllocBytes: 3 << 20, HeapObjects: 1234, SysBytes: 16 << 20, NumGC: 7},
+		Database:      DBPoolStats{Driver: "sqlite", OpenConnections: 2, InUse: 1, Idle: 1, MaxOpenConnections: 10},
+		Cache:         &CacheStats{Entries: 5, Hits: 3, Misses: 1, HitRate: 0.75},
+	}
+}
+
+// setupStatsRouter registers the routes with the embedded templates and
+// fakeStats; page users are identified by X-Test-User.
+func setupStatsRouter(t *testing.T, mode string, svc rbac.Service) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	renderer, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	r.HTMLRender = renderer
+	err = RegisterRoutes(r, &RouteDeps{
+		Modules:    []Module{&mockModule{}},
+		DB:         openTestSQLiteDB(t),
+		Mode:       mode,
+		CSRFSecret: "test-secret-32-chars-long-enough",
+		RBAC:       svc,
+		PageIdentity: func(c *gin.Context) (string, bool) {
+			id := c.GetHeader("X-Test-User")
+			return id, id != ""
+		},
+		Stats: fakeStats{},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRoutes: %v", err)
+	}
+	return r
+}
+
+func getStats(r *gin.Engine, path, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	return testutil.Serve(r, req)
+}
+
+func TestStatsPage_RendersSections(t *testing.T) {
+	w := getStats(setupStatsRouter(t, gin.DebugMode, nil), "/admin/stats", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /admin/stats status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`id="stats-runtime"`, `id="stats-memory"`, `id="stats-database"`, `id="stats-cache"`,
+		"go1.99", "90 秒", "3.0 MiB", "1 / 1 / 10", "3 / 1", "0.75",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stats page missing %q", want)
+		}
+	}
+}
+
+func TestStatsAPI_Fields(t *testing.T) {
+	w := getStats(setupStatsRouter(t, gin.DebugMode, nil), "/api/v1/admin/stats", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/admin/stats status = %d, want 200", w.Code)
+	}
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, key := range []string{"collected_at", "started_at", "uptime_seconds", "go_version", "goroutines", "memory", "database", "cache"} {
+		if _, ok := resp.Data[key]; !ok {
+			t.Errorf("stats data missing %q: %s", key, w.Body.String())
+		}
+	}
+	var db map[string]any
+	if err := json.Unmarshal(resp.Data["database"], &db); err != nil || db["in_use"] != float64(1) {
+		t.Errorf("database = %s, want in_use 1", resp.Data["database"])
+	}
+}
+
+func TestStatsRoutes_Access(t *testing.T) {
+	if w := getStats(setupStatsRouter(t, gin.ReleaseMode, nil), "/admin/stats", ""); w.Code != http.StatusNotFound {
+		t.Errorf("release without RBAC: status = %d, want 404", w.Code)
+	}
+
+	r := setupStatsRouter(t, gin.ReleaseMode, &fakeRBAC{grants: map[string][]string{"ops": {statsPermission}}})
+	if w := getStats(r, "/admin/stats", "ops"); w.Code != http.StatusOK {
+		t.Errorf("RBAC admin: status = %d, want 200", w.Code)
+	}
+	for _, user := range []string{"", "reader"} {
+		if w := getStats(r, "/admin/stats", user); w.Code != http.StatusForbidden {
+			t.Errorf("RBAC user %q: status = %d, want 403", user, w.Code)
+		}
+	}
+}
+
+func TestRuntimeStats_Collect(t *testing.T) {
+	store := cache.NewCache(cache.Options{CleanupInterval: 0})
+	defer store.Close()
+	store.Set("k", 1)
+	counters := &middleware.CacheCounters{}
+	s := &runtimeStats{startedAt: time.Now().Add(-time.Hour), db: openTestSQLiteDB(t), cacheEntries: store.Count, cacheCounters: counters}
+
+	st := s.Collect(context.Background())
+	if st.UptimeSeconds < 3600 || st.GoVersion == "" || st.Goroutines == 0 || st.Memory.SysBytes == 0 {
+		t.Errorf("runtime fields = %+v", st)
+	}
+	if st.Database.Driver != "sqlite" || st.Database.Error != "" {
+		t.Errorf("Database = %+v, want sqlite pool stats", st.Database)
+	}
+	if st.Cache == nil || st.Cache.Entries != 1 || st.Cache.HitRate != 0 {
+		t.Errorf("Cache = %+v, want 1 entry and no lookups", st.Cache)
+	}
+
+	s.cacheEntries = nil
+	if st := s.Collect(context.Background()); st.Cache != nil {
+		t.Errorf("Cache = %+v, want nil without a response cache", st.Cache)
+	}
+}
+
+func TestNew_StatsOmitSecrets(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	a, err := New(testutil.NewTestConfig(testutil.WithMode(gin.DebugMode), testutil.WithSQLitePath(dsn), func(c *config.Config) {
+		c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Minute), MaxSize: 100}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	for _, path := range []string{"/api/v1/admin/stats", "/admin/stats"} {
+		w := testutil.Serve(a.engine, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d, want 200", path, w.Code)
+		}
+		for _, secret := range []string{dsn, a.cfg.Server.CSRFSecret} {
+			if strings.Contains(w.Body.String(), secret) {
+				t.Errorf("GET %s leaks %q", path, secret)
+			}
+		}
+	}
+}
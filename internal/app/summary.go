@@ -0,0 +1,159 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// bannerOutput is where the debug-mode startup banner is written.
+// Tests replace it to capture or silence the banner.
+var bannerOutput io.Writer = os.Stdout
+
+// coreRouteGroup is the route-count bucket for routes registered by the app
+// itself (static assets, health check, home page) rather than by a module.
+const coreRouteGroup = "core"
+
+// summarize emits a single structured Info record describing the effective
+// runtime configuration, for audit purposes. Secrets are never logged: the
+// record is built from cfg.Redacted(). In debug mode a human-friendly banner
+// with the same information is also printed to bannerOutput.
+func summarize(cfg *config.Config, a *App) {
+	if cfg == nil || a == nil {
+		return
+	}
+
+	redacted := cfg.Redacted()
+	addr := net.JoinHostPort(redacted.Server.Host, strconv.Itoa(redacted.Server.Port))
+
+	var routeCounts map[string]int
+	if a.engine != nil {
+		routeCounts = routeCountsByModule(a.engine.Routes())
+	}
+	moduleNames := make([]string, 0, len(routeCounts))
+	for name := range routeCounts {
+		moduleNames = append(moduleNames, name)
+	}
+	sort.Strings(moduleNames)
+	routeAttrs := make([]any, 0, len(moduleNames))
+	for _, name := range moduleNames {
+		routeAttrs = append(routeAttrs, slog.Int(name, routeCounts[name]))
+	}
+
+	pool := config.EffectivePool(redacted.Database.Pool)
+
+	log := slog.Default()
+	if a.logger != nil {
+		log = a.logger.Logger
+	}
+	log.Info("startup summary",
+		slog.String("mode", redacted.Server.Mode),
+		slog.String("addr", addr),
+		slog.Group("database",
+			slog.String("driver", redacted.Database.Driver),
+			slog.String("host", databaseLocation(&redacted.Database)),
+			slog.Int("max_idle_conns", pool.MaxIdleConns),
+			slog.Int("max_open_conns", pool.MaxOpenConns),
+			slog.String("conn_max_lifetime", pool.ConnMaxLifetime.String()),
+		),
+		slog.Group("middleware",
+			slog.Bool("rate_limit", redacted.Server.RateLimit.Enabled),
+			slog.Bool("cache", redacted.Server.Cache.Enabled),
+			slog.Bool("auth", redacted.Auth.Enabled),
+			slog.Bool("rbac", redacted.Auth.RBAC.Enabled),
+			slog.String("csrf_secret_source", csrfSecretSource(cfg)),
+		),
+		slog.String("templates", templateMode(redacted.Server.Mode)),
+		slog.Group("routes", routeAttrs...),
+	)
+
+	if redacted.Server.Mode != gin.DebugMode || bannerOutput == nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("\n  GoBase\n  ------\n")
+	fmt.Fprintf(&b, "  mode        %s\n", redacted.Server.Mode)
+	fmt.Fprintf(&b, "  listen      http://%s\n", addr)
+	fmt.Fprintf(&b, "  database    %s (%s) pool idle=%d open=%d lifetime=%s\n",
+		redacted.Database.Driver, databaseLocation(&redacted.Database),
+		pool.MaxIdleConns, pool.MaxOpenConns, pool.ConnMaxLifetime)
+	fmt.Fprintf(&b, "  templates   %s\n", templateMode(redacted.Server.Mode))
+	fmt.Fprintf(&b, "  middleware  rate_limit=%t cache=%t auth=%t rbac=%t csrf=%s\n",
+		redacted.Server.RateLimit.Enabled,
+		redacted.Server.Cache.Enabled,
+		redacted.Auth.Enabled,
+		redacted.Auth.RBAC.Enabled,
+		csrfSecretSource(cfg),
+	)
+	for _, name := range moduleNames {
+		fmt.Fprintf(&b, "  routes      %-10s %d\n", name, routeCounts[name])
+	}
+	b.WriteString("\n")
+	_, _ = io.WriteString(bannerOutput, b.String())
+}
+
+// databaseLocation returns a password-free description of where the database
+// lives: host:port/dbname for postgres, the file path for sqlite.
+func databaseLocation(cfg *config.DatabaseConfig) string {
+	switch cfg.Driver {
+	case "postgres":
+		return net.JoinHostPort(cfg.Postgres.Host, strconv.Itoa(cfg.Postgres.Port)) + "/" + cfg.Postgres.DBName
+	case "sqlite":
+		return cfg.SQLite.Path
+	default:
+		return ""
+	}
+}
+
+// csrfSecretSource reports whether the CSRF secret came from config, from
+// the dev secrets file, or was generated at startup because only a
+// placeholder was configured.
+func csrfSecretSource(cfg *config.Config) string {
+	switch {
+	case cfg.FromDevSecrets(config.DevSecretCSRF):
+		return "dev_secrets_file"
+	case config.IsPlaceholderCSRFSecret(cfg.Server.CSRFSecret):
+		return "generated"
+	default:
+		return "config"
+	}
+}
+
+// templateMode mirrors the filesystem choice made in New: debug mode reads
+// templates from disk for hot reload, other modes use the embedded copy.
+func templateMode(mode string) string {
+	if mode == gin.DebugMode {
+		return "disk"
+	}
+	return "embedded"
+}
+
+// routeCountsByModule groups registered routes by the module package that
+// owns their handler (e.g. "user", "auth"). Routes whose handler is not in
+// internal/module are counted under coreRouteGroup.
+func routeCountsByModule(routes gin.RoutesInfo) map[string]int {
+	const marker = "/internal/module/"
+
+	counts := make(map[string]int)
+	for _, ri := range routes {
+		name := coreRouteGroup
+		if idx := strings.Index(ri.Handler, marker); idx >= 0 {
+			rest := ri.Handler[idx+len(marker):]
+			if end := strings.IndexAny(rest, "./"); end > 0 {
+				name = rest[:end]
+			}
+		}
+		counts[name]++
+	}
+	return counts
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/logger"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+func init() {
+	// Keep test output clean: New() prints the banner in debug mode.
+	bannerOutput = io.Discard
+}
+
+// captureHandler records every slog.Record it receives.
+type captureHandler struct {
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
+
+// flattenAttrs returns record attributes keyed by their dotted group path.
+func flattenAttrs(r slog.Record) map[string]string {
+	out := make(map[string]string)
+	var walk func(prefix string, a slog.Attr)
+	walk = func(prefix string, a slog.Attr) {
+		key := a.Key
+		if prefix != "" {
+			key = prefix + "." + a.Key
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			for _, ga := range a.Value.Group() {
+				walk(key, ga)
+			}
+			return
+		}
+		out[key] = a.Value.String()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		walk("", a)
+		return true
+	})
+	return out
+}
+
+func summaryTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			Host:       "127.0.0.1",
+			Port:       8080,
+			Mode:       gin.ReleaseMode,
+			CSRFSecret: "csrf-Secret-Value-That-Is-Long-Enough-123",
+			RateLimit:  config.RateLimitConfig{Enabled: true, RPS: 10, Burst: 20},
+		},
+		Database: config.DatabaseConfig{
+			Driver: "postgres",
+			Postgres: config.PostgresConfig{
+				Host:     "db.internal",
+				Port:     5432,
+				User:     "app",
+				Password: "super-secret-db-password",
+				DBName:   "gobase",
+				SSLMode:  "require",
+			},
+			Pool: config.PoolConfig{MaxOpenConns: 50},
+		},
+		Auth: config.AuthConfig{
+			Enabled:   true,
+			JWTSecret: "jwt-Secret-Value-That-Is-Long-Enough-456",
+		},
+	}
+}
+
+func TestSummarize_LogsKeyAttributesWithoutSecrets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/health", func(c *gin.Context) {})
+
+	h := &captureHandler{}
+	a := &App{engine: engine, logger: &logger.Logger{Logger: slog.New(h)}}
+	cfg := summaryTestConfig()
+
+	summarize(cfg, a)
+
+	if len(h.records) != 1 {
+		t.Fatalf("expected exactly 1 log record, got %d", len(h.records))
+	}
+	rec := h.records[0]
+	if rec.Level != slog.LevelInfo {
+		t.Errorf("level = %v; want INFO", rec.Level)
+	}
+
+	attrs := flattenAttrs(rec)
+	want := map[string]string{
+		"mode":                          gin.ReleaseMode,
+		"addr":                          "127.0.0.1:8080",
+		"database.driver":               "postgres",
+		"database.host":                 "db.internal:5432/gobase",
+		"database.max_idle_conns":       "10",
+		"database.max_open_conns":       "50",
+		"database.conn_max_lifetime":    "1h",
+		"middleware.rate_limit":         "true",
+		"middleware.cache":              "false",
+		"middleware.auth":               "true",
+		"middleware.rbac":               "false",
+		"middleware.csrf_secret_source": "config",
+		"templates":                     "embedded",
+		"routes.core":                   "1",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("attr %s = %q; want %q", k, attrs[k], v)
+		}
+	}
+
+	for _, v := range attrs {
+		for _, secret := range []string{cfg.Database.Postgres.Password, cfg.Auth.JWTSecret, cfg.Server.CSRFSecret} {
+			if strings.Contains(v, secret) {
+				t.Errorf("summary leaked secret %q", secret)
+			}
+		}
+	}
+}
+
+func TestSummarize_BannerOnlyInDebug(t *testing.T) {
+	orig := bannerOutput
+	t.Cleanup(func() { bannerOutput = orig })
+
+	for _, tt := range []struct {
+		mode       string
+		wantBanner bool
+	}{
+		{gin.DebugMode, true},
+		{gin.ReleaseMode, false},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			var buf bytes.Buffer
+			bannerOutput = &buf
+
+			cfg := summaryTestConfig()
+			cfg.Server.Mode = tt.mode
+			a := &App{logger: &logger.Logger{Logger: slog.New(&captureHandler{})}}
+			summarize(cfg, a)
+
+			got := buf.String()
+			if tt.wantBanner != strings.Contains(got, "GoBase") {
+				t.Fatalf("banner presence = %v; want %v (output %q)", !tt.wantBanner, tt.wantBanner, got)
+			}
+			if strings.Contains(got, cfg.Database.Postgres.Password) {
+				t.Error("banner leaked database password")
+			}
+		})
+	}
+}
+
+func TestRouteCountsByModule(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Handler: "github.com/simp-lee/gobase/internal/module/user.(*UserHandler).Create-fm"},
+		{Handler: "github.com/simp-lee/gobase/internal/module/user.(*UserPageHandler).ListPage-fm"},
+		{Handler: "github.com/simp-lee/gobase/internal/module/auth.(*AuthHandler).Login-fm"},
+		{Handler: "github.com/simp-lee/gobase/internal/app.RegisterRoutes.func1"},
+	}
+
+	got := routeCountsByModule(routes)
+	want := map[string]int{"user": 2, "auth": 1, coreRouteGroup: 1}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("count[%s] = %d; want %d", k, got[k], v)
+		}
+	}
+}
@@ -2,22 +2,33 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin/render"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
 )
 
 // TemplateRenderer is a custom Gin HTML renderer that supports layout + partial
 // template inheritance and dual-mode operation (debug / release).
 //
-// In debug mode, templates are re-parsed from the filesystem on every request,
-// enabling instant hot-reload during development. In release mode, templates are
-// parsed once at startup and served from memory for maximum performance.
+// In debug mode, every request stats the template files and re-parses only
+// what changed since the last request: the edited page templates, or the whole
+// set when a layout or partial changed or files were added or removed. If the
+// filesystem reports no modification times, every request re-parses
+// everything. In release mode, templates are parsed once at startup and served
+// from memory for maximum performance.
 //
 // Template loading strategy:
 //  1. Load all layout templates   (templates/layouts/*.html)
@@ -28,11 +39,47 @@ import (
 // Page templates use {{ template "base" . }} to invoke the layout, and define
 // blocks ({{ define "title" }}, {{ define "content" }}, etc.) to inject content
 // into the layout's block slots.
+//
+// Request-scoped helpers (csrfField, hxCSRF, htmxForm, formTokenField) are
+// bound per render: Instance executes a clone of the page template with
+// those functions closed over the render data. The parsed templates are never executed
+// themselves, which keeps them clonable (html/template refuses to clone a
+// template after it has run).
 type TemplateRenderer struct {
-	templates map[string]*template.Template // page name -> compiled template set (release mode only)
+	templates map[string]*template.Template // page name -> parsed, never-executed template set
 	fs        fs.FS                         // filesystem containing templates/ directory
 	funcMap   template.FuncMap
 	debug     bool
+	strict    bool // see WithStrictData
+
+	// Debug-mode cache state, guarded by mu. base is the parsed layouts and
+	// partials that pages are cloned from; stamps records every template
+	// file as of the last successful parse.
+	mu     sync.Mutex
+	base   *template.Template
+	stamps map[string]fileStamp
+
+	parses int // template files parsed so far, for tests
+
+	// The asset manifest and locale bundles, swapped whole when the
+	// watcher reloads them in debug mode.
+	manifest      atomic.Pointer[map[string]string]
+	bundleFS      fs.FS
+	defaultLocale string
+	bundles       atomic.Pointer[map[string]map[string]string]
+
+	// Render timings per page. In release mode the map is filled at startup
+	// and only read afterwards; in debug mode timingsMu guards it.
+	timingsMu  sync.Mutex
+	timings    map[string]*templateTimings
+	slowRender time.Duration
+	logger     *slog.Logger
+}
+
+// fileStamp identifies a version of a template file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
 }
 
 // Compile-time check: TemplateRenderer implements render.HTMLRender.
@@ -43,7 +90,8 @@ var _ render.HTMLRender = (*TemplateRenderer)(nil)
 // Parameters:
 //   - fsys: filesystem to read templates from. Use os.DirFS("web") for debug mode
 //     (hot reload from disk) or web.EmbeddedFS for release mode (embedded binary).
-//   - debug: when true, templates are re-parsed on every request for hot reload.
+//   - debug: when true, templates are parsed lazily and changed files are
+//     re-parsed on the next request for hot reload.
 //
 // The filesystem must contain a templates/ directory structured as:
 //
@@ -51,19 +99,49 @@ var _ render.HTMLRender = (*TemplateRenderer)(nil)
 //	  layouts/   – layout templates defining the page skeleton (e.g., base.html)
 //	  partials/  – reusable partial templates (e.g., nav, footer)
 //	  <module>/  – page templates organized by module (e.g., user/, errors/)
-func NewTemplateRenderer(fsys fs.FS, debug bool) (*TemplateRenderer, error) {
+//
+// Every render is timed (see TemplateStats); renders slower than the
+// WithSlowRenderThreshold threshold are logged as warnings.
+//
+// Templates can link assets through the static/manifest.json asset manifest
+// ({{ asset "css/app.css" }}) and translate with the WithLocaleBundles
+// bundles ({{ t "nav.users" }}); both are read here.
+func NewTemplateRenderer(fsys fs.FS, debug bool, opts ...TemplateOption) (*TemplateRenderer, error) {
+	funcMap := templateFuncMap()
+	for name, fn := range placeholderRequestFuncs() {
+		funcMap[name] = fn
+	}
 	r := &TemplateRenderer{
-		fs:      fsys,
-		funcMap: templateFuncMap(),
-		debug:   debug,
+		fs:         fsys,
+		funcMap:    funcMap,
+		debug:      debug,
+		timings:    make(map[string]*templateTimings),
+		slowRender: DefaultSlowRenderThreshold,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	funcMap["asset"] = r.assetURL
+	for name, fn := range r.localeFuncs(nil) {
+		funcMap[name] = fn
+	}
+	if err := r.loadManifest(); err != nil {
+		return nil, err
+	}
+	if err := r.loadBundles(); err != nil {
+		return nil, err
 	}
 
 	if !debug {
-		templates, err := r.parseAllTemplates()
+		_, templates, err := r.parseAllTemplates()
 		if err != nil {
 			return nil, fmt.Errorf("parse templates: %w", err)
 		}
 		r.templates = templates
+		for name := range templates {
+			r.timings[name] = &templateTimings{}
+		}
 	}
 
 	return r, nil
@@ -75,33 +153,178 @@ func NewTemplateRenderer(fsys fs.FS, debug bool) (*TemplateRenderer, error) {
 //
 // This implements the render.HTMLRender interface required by Gin.
 func (r *TemplateRenderer) Instance(name string, data any) render.Render {
+	var master *template.Template
 	if r.debug {
-		// Re-parse all templates on every request for hot reload.
-		templates, err := r.parseAllTemplates()
-		if err != nil {
+		var err error
+		if master, err = r.reloadTemplate(name); err != nil {
 			return &HTMLInstance{err: err}
 		}
-		return &HTMLInstance{
-			Template: templates[name],
-			Name:     name,
-			Data:     data,
+	} else {
+		master = r.templates[name]
+	}
+	var tmpl *template.Template
+	if master != nil {
+		clone, err := master.Clone()
+		if err != nil {
+			return &HTMLInstance{err: fmt.Errorf("clone template %q: %w", name, err)}
 		}
+		tmpl = clone
+	}
+	if tmpl != nil {
+		tmpl.Funcs(requestFuncs(data)).Funcs(r.localeFuncs(data))
 	}
 
-	return &HTMLInstance{
-		Template: r.templates[name],
+	inst := &HTMLInstance{
+		Template: tmpl,
 		Name:     name,
 		Data:     data,
+		strict:   r.strict,
+	}
+	if tmpl != nil {
+		inst.timings = r.timingsFor(name)
+		inst.slowRender = r.slowRender
+		inst.logger = r.logger
+	}
+	return inst
+}
+
+// reloadTemplate returns the debug-mode master template for page name,
+// first re-parsing whatever changed on disk since the previous call. It
+// returns nil when no such page exists.
+func (r *TemplateRenderer) reloadTemplate(name string) (*template.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Time the reload against name whenever it parsed anything.
+	start, parsed := time.Now(), r.parses
+	defer func() {
+		if r.parses != parsed {
+			r.timingsFor(name).parse.record(time.Since(start))
+		}
+	}()
+
+	stamps, ok := r.statTemplates()
+	if !ok || r.templates == nil || !sameFileSet(r.stamps, stamps) || baseChanged(r.stamps, stamps) {
+		base, templates, err := r.parseAllTemplates()
+		if err != nil {
+			return nil, err
+		}
+		r.base, r.templates, r.stamps = base, templates, stamps
+		return r.templates[name], nil
+	}
+
+	// Only page templates changed: re-parse each on top of the cached base.
+	for path, stamp := range stamps {
+		if r.stamps[path] == stamp {
+			continue
+		}
+		page, tmpl, err := r.parsePage(r.base, path)
+		if err != nil {
+			return nil, err
+		}
+		r.templates[page] = tmpl
+		r.stamps[path] = stamp
+	}
+	return r.templates[name], nil
+}
+
+// statTemplates records the modification time and size of every .html file
+// under templates/. It reports false when the filesystem cannot stat a file
+// or has no modification times (e.g. embed.FS), in which case changes cannot
+// be detected.
+func (r *TemplateRenderer) statTemplates() (map[string]fileStamp, bool) {
+	return statFiles(r.fs, "templates", isTemplateFile)
+}
+
+// isTemplateFile reports whether path is an .html template.
+func isTemplateFile(path string) bool {
+	return strings.HasSuffix(path, ".html")
+}
+
+// statFiles records the modification time and size of every file under
+// root for which match is true, reporting false like statTemplates.
+func statFiles(fsys fs.FS, root string, match func(path string) bool) (map[string]fileStamp, bool) {
+	stamps := make(map[string]fileStamp)
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !match(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().IsZero() {
+			return errNoModTime
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return stamps, err == nil
+}
+
+var errNoModTime = errors.New("filesystem reports no modification times")
+
+// sameFileSet reports whether old and cur cover the same files.
+func sameFileSet(old, cur map[string]fileStamp) bool {
+	if len(old) != len(cur) {
+		return false
+	}
+	for path := range cur {
+		if _, ok := old[path]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// baseChanged reports whether any file outside the page templates changed,
+// which invalidates every page cloned from the base set.
+func baseChanged(old, cur map[string]fileStamp) bool {
+	for path, stamp := range cur {
+		if !isPageTemplate(path) && old[path] != stamp {
+			return true
+		}
 	}
+	return false
 }
 
 // parseAllTemplates walks the templates directory, builds a base template set from
 // layouts and partials, then creates a separate compiled template for each page by
 // cloning the base and parsing the page on top.
 //
-// Returns a map from page name (e.g., "user/list.html") to its compiled template.
-func (r *TemplateRenderer) parseAllTemplates() (map[string]*template.Template, error) {
-	// Step 1: Collect layout and partial file paths.
+// Returns the base set and a map from page name (e.g., "user/list.html") to its
+// compiled template.
+func (r *TemplateRenderer) parseAllTemplates() (*template.Template, map[string]*template.Template, error) {
+	base, err := r.parseBase()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Discover page templates (everything not in layouts/ or partials/).
+	pageFiles, err := r.discoverPageTemplates()
+	if err != nil {
+		return nil, nil, fmt.Errorf("discover pages: %w", err)
+	}
+
+	// For each page, clone base and parse the page template on top.
+	templates := make(map[string]*template.Template, len(pageFiles))
+	for _, pf := range pageFiles {
+		name, tmpl, err := r.parsePage(base, pf)
+		if err != nil {
+			return nil, nil, err
+		}
+		templates[name] = tmpl
+	}
+
+	return base, templates, nil
+}
+
+// parseBase builds the base template set from layouts and partials.
+func (r *TemplateRenderer) parseBase() (*template.Template, error) {
+	// Collect layout and partial file paths.
 	layoutFiles, err := fs.Glob(r.fs, "templates/layouts/*.html")
 	if err != nil {
 		return nil, fmt.Errorf("glob layouts: %w", err)
@@ -111,8 +334,10 @@ func (r *TemplateRenderer) parseAllTemplates() (map[string]*template.Template, e
 		return nil, fmt.Errorf("glob partials: %w", err)
 	}
 
-	// Step 2: Build the base template set from layouts + partials.
 	base := template.New("").Funcs(r.funcMap)
+	if r.strict {
+		base.Option("missingkey=error")
+	}
 	baseFiles := append(layoutFiles, partialFiles...)
 	for _, f := range baseFiles {
 		content, err := fs.ReadFile(r.fs, f)
@@ -122,34 +347,28 @@ func (r *TemplateRenderer) parseAllTemplates() (map[string]*template.Template, e
 		if _, err := base.New(f).Parse(string(content)); err != nil {
 			return nil, fmt.Errorf("parse %s: %w", f, err)
 		}
+		r.parses++
 	}
+	return base, nil
+}
 
-	// Step 3: Discover page templates (everything not in layouts/ or partials/).
-	pageFiles, err := r.discoverPageTemplates()
+// parsePage clones base and parses the page template at path on top. The
+// returned name is relative to templates/, e.g., "user/list.html".
+func (r *TemplateRenderer) parsePage(base *template.Template, path string) (string, *template.Template, error) {
+	clone, err := base.Clone()
 	if err != nil {
-		return nil, fmt.Errorf("discover pages: %w", err)
+		return "", nil, fmt.Errorf("clone base for %s: %w", path, err)
 	}
-
-	// Step 4: For each page, clone base and parse the page template on top.
-	templates := make(map[string]*template.Template, len(pageFiles))
-	for _, pf := range pageFiles {
-		clone, err := base.Clone()
-		if err != nil {
-			return nil, fmt.Errorf("clone base for %s: %w", pf, err)
-		}
-		content, err := fs.ReadFile(r.fs, pf)
-		if err != nil {
-			return nil, fmt.Errorf("read %s: %w", pf, err)
-		}
-		// Page name is relative to templates/, e.g., "user/list.html".
-		name := strings.TrimPrefix(pf, "templates/")
-		if _, err := clone.New(name).Parse(string(content)); err != nil {
-			return nil, fmt.Errorf("parse %s: %w", pf, err)
-		}
-		templates[name] = clone
+	content, err := fs.ReadFile(r.fs, path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read %s: %w", path, err)
 	}
-
-	return templates, nil
+	name := strings.TrimPrefix(path, "templates/")
+	if _, err := clone.New(name).Parse(string(content)); err != nil {
+		return "", nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	r.parses++
+	return name, clone, nil
 }
 
 // discoverPageTemplates finds all .html files under templates/ that are not in
@@ -160,12 +379,8 @@ func (r *TemplateRenderer) discoverPageTemplates() ([]string, error) {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() || !strings.HasSuffix(path, ".html") {
-			return nil
-		}
 		// Skip layouts and partials; they form the base template set.
-		rel := strings.TrimPrefix(path, "templates/")
-		if strings.HasPrefix(rel, "layouts/") || strings.HasPrefix(rel, "partials/") {
+		if d.IsDir() || !strings.HasSuffix(path, ".html") || !isPageTemplate(path) {
 			return nil
 		}
 		pages = append(pages, path)
@@ -174,6 +389,13 @@ func (r *TemplateRenderer) discoverPageTemplates() ([]string, error) {
 	return pages, err
 }
 
+// isPageTemplate reports whether path (under templates/) is a page rather
+// than part of the layouts/ or partials/ base set.
+func isPageTemplate(path string) bool {
+	rel := strings.TrimPrefix(path, "templates/")
+	return !strings.HasPrefix(rel, "layouts/") && !strings.HasPrefix(rel, "partials/")
+}
+
 // templateFuncMap returns the default set of template helper functions.
 func templateFuncMap() template.FuncMap {
 	return template.FuncMap{
@@ -188,9 +410,22 @@ func templateFuncMap() template.FuncMap {
 			return template.JS(b)
 		},
 
-		// formatDate formats a time.Time value as "YYYY-MM-DD HH:MM:SS".
-		"formatDate": func(t time.Time) string {
-			return t.Format("2006-01-02 15:04:05")
+		// formatDate formats a time.Time or domain.Time (model timestamps)
+		// value as "YYYY-MM-DD HH:MM:SS".
+		"formatDate": formatDate,
+
+		// formatBytes formats a byte count with binary units, e.g. "1.5 MiB".
+		"formatBytes": func(n uint64) string {
+			const unit = 1024
+			if n < unit {
+				return fmt.Sprintf("%d B", n)
+			}
+			div, exp := uint64(unit), 0
+			for v := n / unit; v >= unit; v /= unit {
+				div *= unit
+				exp++
+			}
+			return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 		},
 
 		// dangerouslySetInnerHTML marks a string as safe HTML, bypassing
@@ -202,6 +437,23 @@ func templateFuncMap() template.FuncMap {
 			return template.HTML(s)
 		},
 
+		// markdown renders CommonMark source to HTML and sanitizes the result
+		// with a strict allowlist (no scripts, iframes, event handlers, or
+		// javascript: URLs). Unlike dangerouslySetInnerHTML it is safe to use
+		// with user-supplied content such as profile bios.
+		"markdown": renderMarkdown,
+
+		// can reports whether the page user holds a "resource:action"
+		// permission, e.g. {{ if can .Perms "users:delete" }}. It is true
+		// when .Perms is absent (auth or RBAC disabled).
+		"can": func(p *middleware.Permissions, permission string) bool {
+			return p.Can(permission)
+		},
+
+		// branding returns the server.branding settings set by WithBranding,
+		// e.g. {{ (branding).EffectiveName }}.
+		"branding": func() config.BrandingConfig { return config.BrandingConfig{} },
+
 		// add returns the sum of two integers (useful for pagination: page + 1).
 		"add": func(a, b int) int {
 			return a + b
@@ -227,6 +479,20 @@ func templateFuncMap() template.FuncMap {
 	}
 }
 
+// formatDate is the formatDate template function. Models carry their
+// timestamps as domain.Time, which templates cannot pass where a time.Time
+// is expected, so both are accepted.
+func formatDate(t any) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05"), nil
+	case domain.Time:
+		return v.Format("2006-01-02 15:04:05"), nil
+	default:
+		return "", fmt.Errorf("formatDate: want time.Time or domain.Time, got %T", t)
+	}
+}
+
 // HTMLInstance implements gin's render.Render interface for a single template
 // execution. It is returned by TemplateRenderer.Instance.
 type HTMLInstance struct {
@@ -234,12 +500,23 @@ type HTMLInstance struct {
 	Name     string
 	Data     any
 	err      error // set when template parsing failed (debug mode)
+	strict   bool  // buffer output and report missing data keys
+
+	timings    *templateTimings // nil when no template was found
+	slowRender time.Duration
+	logger     *slog.Logger
 }
 
 const htmlContentType = "text/html; charset=utf-8"
 
 // Render writes the template output to the HTTP response writer.
 func (h *HTMLInstance) Render(w http.ResponseWriter) error {
+	if h.strict && h.err == nil && h.Template != nil {
+		start := time.Now()
+		err := h.renderStrict(w, h.Template)
+		h.observeRender(time.Since(start))
+		return err
+	}
 	h.WriteContentType(w)
 	if h.err != nil {
 		return h.err
@@ -247,7 +524,13 @@ func (h *HTMLInstance) Render(w http.ResponseWriter) error {
 	if h.Template == nil {
 		return fmt.Errorf("template %q not found", h.Name)
 	}
-	return h.Template.ExecuteTemplate(w, h.Name, h.Data)
+	start := time.Now()
+	err := h.Template.ExecuteTemplate(w, h.Name, h.Data)
+	h.observeRender(time.Since(start))
+	if err != nil {
+		return wrapRenderError(h.Name, err)
+	}
+	return nil
 }
 
 // WriteContentType sets the Content-Type header to text/html; charset=utf-8
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// assetManifestPath is the optional manifest, in the web filesystem, that
+// maps asset names to their built (e.g. fingerprinted) file names:
+//
+//	{"css/app.css": "css/app.3f2c8a9e.css"}
+const assetManifestPath = "static/manifest.json"
+
+// staticURLPrefix is where the asset helper points: the default static mount.
+const staticURLPrefix = "/static/"
+
+// localeDataKey is the template data key page handlers use for the locale
+// from requestctx.Locale.
+const localeDataKey = "Locale"
+
+// WithLocaleBundles loads message bundles for the t helper from fsys: one
+// <locale>.json file of key → message per locale, e.g. en.json. Messages
+// missing from the render's locale fall back to defaultLocale, then to the
+// key itself. In debug mode the bundles are reloaded when they change (see
+// fileWatcher).
+func WithLocaleBundles(fsys fs.FS, defaultLocale string) TemplateOption {
+	return func(r *TemplateRenderer) {
+		r.bundleFS = fsys
+		r.defaultLocale = defaultLocale
+	}
+}
+
+// loadManifest reads assetManifestPath, replacing the asset map. Without a
+// manifest asset names are used as they are.
+func (r *TemplateRenderer) loadManifest() error {
+	content, err := fs.ReadFile(r.fs, assetManifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.manifest.Store(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", assetManifestPath, err)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return fmt.Errorf("parse %s: %w", assetManifestPath, err)
+	}
+	r.manifest.Store(&manifest)
+	return nil
+}
+
+// loadBundles reads every <locale>.json file of the bundle filesystem,
+// replacing the bundles in use only when all of them parse.
+func (r *TemplateRenderer) loadBundles() error {
+	if r.bundleFS == nil {
+		return nil
+	}
+	files, err := fs.Glob(r.bundleFS, "*.json")
+	if err != nil {
+		return fmt.Errorf("glob locale bundles: %w", err)
+	}
+	bundles := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		content, err := fs.ReadFile(r.bundleFS, f)
+		if err != nil {
+			return fmt.Errorf("read locale bundle %s: %w", f, err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(content, &messages); err != nil {
+			return fmt.Errorf("parse locale bundle %s: %w", f, err)
+		}
+		bundles[strings.TrimSuffix(path.Base(f), ".json")] = messages
+	}
+	r.bundles.Store(&bundles)
+	return nil
+}
+
+// assetURL returns the URL of the static asset name, as renamed by the asset
+// manifest when it lists name.
+func (r *TemplateRenderer) assetURL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if m := r.manifest.Load(); m != nil {
+		if built, ok := (*m)[name]; ok {
+			name = strings.TrimPrefix(built, "/")
+		}
+	}
+	return staticURLPrefix + name
+}
+
+// translate returns the message for key in locale, falling back to the
+// default locale and then to key.
+func (r *TemplateRenderer) translate(locale, key string) string {
+	b := r.bundles.Load()
+	if b == nil {
+		return key
+	}
+	for _, l := range []string{locale, r.defaultLocale} {
+		if msg, ok := (*b)[l][key]; ok {
+			return msg
+		}
+	}
+	return key
+}
+
+// localeFuncs returns the t helper bound to the locale of one render's data
+// (the "Locale" entry of a gin.H); without one it uses the default locale.
+//
+//   - t "nav.users": the message for the key, e.g. "Users" for en.
+func (r *TemplateRenderer) localeFuncs(data any) template.FuncMap {
+	locale, _ := dataMap(data)[localeDataKey].(string)
+	return template.FuncMap{
+		"t": func(key string) string { return r.translate(locale, key) },
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// csrfDataKey is the template data key page handlers use for the token from
+// middleware.GetCSRFToken.
+const csrfDataKey = "CSRFToken"
+
+// formTokenDataKey is the template data key page handlers use for the
+// one-time token from pkg.FormTokens.Issue.
+const formTokenDataKey = "FormToken"
+
+// errNoRequestFuncs is returned by the request-scoped helpers when a
+// template is executed without going through TemplateRenderer.Instance.
+var errNoRequestFuncs = errors.New("template helper requires a request render (TemplateRenderer.Instance)")
+
+// placeholderRequestFuncs registers the request-scoped helper names so
+// templates parse; Instance replaces them with requestFuncs for each render.
+func placeholderRequestFuncs() template.FuncMap {
+	return template.FuncMap{
+		"csrfField":      func() (template.HTML, error) { return "", errNoRequestFuncs },
+		"hxCSRF":         func() (template.HTMLAttr, error) { return "", errNoRequestFuncs },
+		"htmxForm":       func(string, string) (template.HTMLAttr, error) { return "", errNoRequestFuncs },
+		"formTokenField": func() (template.HTML, error) { return "", errNoRequestFuncs },
+	}
+}
+
+// requestFuncs returns the helpers bound to the CSRF token in one render's
+// data (the "CSRFToken" entry of a gin.H). Each fails the render when the
+// token is missing, so a page that forgot middleware.CSRF or the data entry
+// errors out instead of emitting a form the server will reject.
+//
+//   - csrfField: the hidden <input> carrying the token for form posts.
+//   - hxCSRF: an hx-headers attribute sending the token as X-CSRF-Token.
+//   - htmxForm "put" "/users/1": hx-put plus hx-headers, and the
+//     hx-target="body" hx-swap="outerHTML" pair the page forms use.
+//   - formTokenField: the hidden <input> carrying the one-time form token
+//     (the "FormToken" entry) that guards against double submission.
+func requestFuncs(data any) template.FuncMap {
+	token := csrfTokenFromData(data)
+	formToken, _ := dataMap(data)[formTokenDataKey].(string)
+	requireToken := func(name string) error {
+		if token == "" {
+			return fmt.Errorf("%s: no %s in template data", name, csrfDataKey)
+		}
+		return nil
+	}
+	hxHeaders := func() template.HTMLAttr {
+		headers, _ := json.Marshal(map[string]string{middleware.CSRFHeaderName: token})
+		return template.HTMLAttr(`hx-headers="` + template.HTMLEscapeString(string(headers)) + `"`)
+	}
+
+	return template.FuncMap{
+		"csrfField": func() (template.HTML, error) {
+			if err := requireToken("csrfField"); err != nil {
+				return "", err
+			}
+			return template.HTML(`<input type="hidden" name="` + middleware.CSRFFormField +
+				`" value="` + template.HTMLEscapeString(token) + `">`), nil
+		},
+		"hxCSRF": func() (template.HTMLAttr, error) {
+			if err := requireToken("hxCSRF"); err != nil {
+				return "", err
+			}
+			return hxHeaders(), nil
+		},
+		"htmxForm": func(method, path string) (template.HTMLAttr, error) {
+			if err := requireToken("htmxForm"); err != nil {
+				return "", err
+			}
+			method = strings.ToLower(method)
+			switch method {
+			case "get", "post", "put", "patch", "delete":
+			default:
+				return "", fmt.Errorf("htmxForm: unsupported method %q", method)
+			}
+			// Only same-origin absolute paths: the token must never be sent
+			// to another host.
+			if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, `/\`) {
+				return "", fmt.Errorf("htmxForm: path %q must be a same-origin absolute path", path)
+			}
+			return template.HTMLAttr(`hx-` + method + `="` + template.HTMLEscapeString(path) + `" ` +
+				string(hxHeaders()) + ` hx-target="body" hx-swap="outerHTML"`), nil
+		},
+		"formTokenField": func() (template.HTML, error) {
+			if formToken == "" {
+				return "", fmt.Errorf("formTokenField: no %s in template data", formTokenDataKey)
+			}
+			return template.HTML(`<input type="hidden" name="` + pkg.FormTokenField +
+				`" value="` + template.HTMLEscapeString(formToken) + `">`), nil
+		},
+	}
+}
+
+// csrfTokenFromData returns the CSRFToken entry of a gin.H (or plain map)
+// render payload, or "" when there is none.
+func csrfTokenFromData(data any) string {
+	token, _ := dataMap(data)[csrfDataKey].(string)
+	return token
+}
+
+// dataMap returns a gin.H (or plain map) render payload, or nil for any
+// other data.
+func dataMap(data any) map[string]any {
+	switch d := data.(type) {
+	case gin.H:
+		return d
+	case map[string]any:
+		return d
+	default:
+		return nil
+	}
+}
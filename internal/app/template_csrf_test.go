@@ -0,0 +1,154 @@
+package app
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/web"
+)
+
+// renderUserForm serves the real user form through middleware.CSRF and
+// returns the body together with the token the handler saw.
+func renderUserForm(t *testing.T, data gin.H) (body, token string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	renderer, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	r := gin.New()
+	r.HTMLRender = renderer
+	r.GET("/form", middleware.CSRF("test-csrf-secret"), func(c *gin.Context) {
+		token = middleware.GetCSRFToken(c)
+		data["CSRFToken"] = token
+		c.HTML(http.StatusOK, "user/form.html", data)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/form", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if token == "" {
+		t.Fatal("middleware.GetCSRFToken returned an empty token")
+	}
+	return w.Body.String(), token
+}
+
+var hiddenCSRFInput = regexp.MustCompile(`<input type="hidden" name="_csrf_token" value="([^"]*)">`)
+var hxHeadersAttr = regexp.MustCompile(`hx-headers="([^"]*)"`)
+
+func TestUserFormTemplate_CSRFHelpersUseRequestToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     gin.H
+		wantAttr string
+	}{
+		{name: "create", data: gin.H{"IsEdit": false, "FormToken": "form-token"}, wantAttr: `hx-post="/users"`},
+		{name: "edit", data: gin.H{"IsEdit": true, "User": &domain.User{BaseModel: domain.BaseModel{ID: 7}}}, wantAttr: `hx-put="/users/7"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, token := renderUserForm(t, tt.data)
+
+			m := hiddenCSRFInput.FindStringSubmatch(body)
+			if m == nil {
+				t.Fatalf("no hidden CSRF input in body:\n%s", body)
+			}
+			if got := html.UnescapeString(m[1]); got != token {
+				t.Errorf("csrfField value = %q, want %q", got, token)
+			}
+
+			h := hxHeadersAttr.FindStringSubmatch(body)
+			if h == nil {
+				t.Fatalf("no hx-headers attribute in body:\n%s", body)
+			}
+			if got, want := html.UnescapeString(h[1]), `{"X-CSRF-Token":"`+token+`"}`; got != want {
+				t.Errorf("hx-headers = %q, want %q", got, want)
+			}
+			for _, want := range []string{tt.wantAttr, `hx-target="body"`, `hx-swap="outerHTML"`} {
+				if !strings.Contains(body, want) {
+					t.Errorf("form missing %s", want)
+				}
+			}
+		})
+	}
+}
+
+// csrfHelperFS is a one-page template set exercising the request helpers.
+func csrfHelperFS(page string) fstest.MapFS {
+	return fstest.MapFS{
+		"templates/page.html": {Data: []byte(page)},
+	}
+}
+
+func renderPage(t *testing.T, page string, data any) (string, error) {
+	t.Helper()
+	r, err := NewTemplateRenderer(csrfHelperFS(page), false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	err = r.Instance("page.html", data).Render(w)
+	return w.Body.String(), err
+}
+
+func TestCSRFHelpers_EscapeToken(t *testing.T) {
+	body, err := renderPage(t, `{{ csrfField }}<b {{ hxCSRF }}></b>`, gin.H{"CSRFToken": `a"b<c>'`})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if !strings.Contains(body, `value="a&#34;b&lt;c&gt;&#39;"`) {
+		t.Errorf("csrfField did not escape the token: %s", body)
+	}
+	if !strings.Contains(body, `hx-headers="{&#34;X-CSRF-Token&#34;:&#34;a\&#34;b\u003cc\u003e&#39;&#34;}"`) {
+		t.Errorf("hxCSRF did not escape the token: %s", body)
+	}
+}
+
+func TestCSRFHelpers_RequestScoped(t *testing.T) {
+	r, err := NewTemplateRenderer(csrfHelperFS(`{{ csrfField }}`), false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	for _, token := range []string{"first", "second", "first"} {
+		w := httptest.NewRecorder()
+		if err := r.Instance("page.html", gin.H{"CSRFToken": token}).Render(w); err != nil {
+			t.Fatalf("Render(%s) error: %v", token, err)
+		}
+		if !strings.Contains(w.Body.String(), `value="`+token+`"`) {
+			t.Errorf("render with token %q produced %s", token, w.Body.String())
+		}
+	}
+}
+
+func TestCSRFHelpers_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		data    any
+		wantErr string
+	}{
+		{name: "missing token", page: `{{ csrfField }}`, data: gin.H{}, wantErr: "no CSRFToken"},
+		{name: "non-map data", page: `<p {{ hxCSRF }}></p>`, data: struct{}{}, wantErr: "no CSRFToken"},
+		{name: "cross-origin path", page: `<form {{ htmxForm "post" "//evil.example" }}>`, data: gin.H{"CSRFToken": "t"}, wantErr: "same-origin"},
+		{name: "unsupported method", page: `<form {{ htmxForm "trace" "/users" }}>`, data: gin.H{"CSRFToken": "t"}, wantErr: "unsupported method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderPage(t, tt.page, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Render() error = %v, want contains %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// DefaultSlowRenderThreshold is the render duration above which a template
+// execution is logged as slow when no threshold is configured.
+const DefaultSlowRenderThreshold = 200 * time.Millisecond
+
+// timingWindow is how many recent samples each template keeps for its
+// percentiles; count and max cover every sample.
+const timingWindow = 256
+
+// TemplateOption configures a TemplateRenderer.
+type TemplateOption func(*TemplateRenderer)
+
+// WithSlowRenderThreshold logs a warning for every render that takes longer
+// than d (default DefaultSlowRenderThreshold). A non-positive d keeps the
+// default.
+func WithSlowRenderThreshold(d time.Duration) TemplateOption {
+	return func(r *TemplateRenderer) {
+		if d > 0 {
+			r.slowRender = d
+		}
+	}
+}
+
+// WithTemplateLogger sets the logger slow renders are reported to (default
+// slog.Default()).
+func WithTemplateLogger(l *slog.Logger) TemplateOption {
+	return func(r *TemplateRenderer) {
+		if l != nil {
+			r.logger = l
+		}
+	}
+}
+
+// durationRing records durations with atomic operations only: a fixed ring
+// of recent samples plus a running count and maximum. Recording never
+// allocates or locks, so it stays on in release mode.
+type durationRing struct {
+	count   atomic.Uint64
+	max     atomic.Int64
+	samples [timingWindow]atomic.Int64
+}
+
+func (d *durationRing) record(v time.Duration) {
+	n := d.count.Add(1)
+	d.samples[(n-1)%timingWindow].Store(int64(v))
+	for {
+		cur := d.max.Load()
+		if int64(v) <= cur || d.max.CompareAndSwap(cur, int64(v)) {
+			return
+		}
+	}
+}
+
+// DurationStats aggregates the recorded durations of one template.
+// Percentiles cover the most recent samples (up to 256); Count and MaxMS
+// cover all of them.
+type DurationStats struct {
+	Count uint64  `json:"count"`
+	P50MS float64 `json:"p50_ms"`
+	P95MS float64 `json:"p95_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+func (d *durationRing) stats() DurationStats {
+	n := d.count.Load()
+	out := DurationStats{Count: n, MaxMS: millis(d.max.Load())}
+	if n == 0 {
+		return out
+	}
+	window := make([]int64, min(n, timingWindow))
+	for i := range window {
+		window[i] = d.samples[i].Load()
+	}
+	slices.Sort(window)
+	out.P50MS = millis(percentile(window, 50))
+	out.P95MS = millis(percentile(window, 95))
+	return out
+}
+
+// percentile returns the nearest-rank p-th percentile of the sorted samples.
+func percentile(sorted []int64, p int) int64 {
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func millis(ns int64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
+
+// templateTimings holds the render and, in debug mode, parse durations of
+// one page template.
+type templateTimings struct {
+	render durationRing
+	parse  durationRing
+}
+
+// TemplateStat is the timing summary of one page template. Parse is only
+// reported in debug mode, where templates are re-parsed on change.
+type TemplateStat struct {
+	Name   string         `json:"name"`
+	Render DurationStats  `json:"render"`
+	Parse  *DurationStats `json:"parse,omitempty"`
+}
+
+// timingsFor returns the timings of page name. In release mode the set is
+// fixed at startup and read without locking; nil means the page does not
+// exist.
+func (r *TemplateRenderer) timingsFor(name string) *templateTimings {
+	if !r.debug {
+		return r.timings[name]
+	}
+	r.timingsMu.Lock()
+	defer r.timingsMu.Unlock()
+	t, ok := r.timings[name]
+	if !ok {
+		t = &templateTimings{}
+		r.timings[name] = t
+	}
+	return t
+}
+
+// TemplateStats returns the timing summary of every template rendered so
+// far, slowest (by p95 render time) first.
+func (r *TemplateRenderer) TemplateStats() []TemplateStat {
+	r.timingsMu.Lock()
+	names := make(map[string]*templateTimings, len(r.timings))
+	for name, t := range r.timings {
+		names[name] = t
+	}
+	r.timingsMu.Unlock()
+
+	out := make([]TemplateStat, 0, len(names))
+	for name, t := range names {
+		render := t.render.stats()
+		if render.Count == 0 {
+			continue
+		}
+		st := TemplateStat{Name: name, Render: render}
+		if r.debug {
+			parse := t.parse.stats()
+			st.Parse = &parse
+		}
+		out = append(out, st)
+	}
+	slices.SortFunc(out, func(a, b TemplateStat) int {
+		if c := cmp.Compare(b.Render.P95MS, a.Render.P95MS); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// observeRender records one execution of page name and warns when it
+// exceeded the slow render threshold.
+func (h *HTMLInstance) observeRender(d time.Duration) {
+	if h.timings == nil {
+		return
+	}
+	h.timings.render.record(d)
+	if d > h.slowRender {
+		h.logger.Warn("slow template render",
+			slog.String("template", h.Name),
+			slog.Duration("duration", d),
+			slog.Duration("threshold", h.slowRender),
+		)
+	}
+}
+
+// registerDebugRoutes adds GET /debug/templates, the per-template render
+// timings, in debug mode.
+func registerDebugRoutes(r *gin.Engine, deps *RouteDeps) {
+	if deps.Mode != gin.DebugMode || deps.TemplateStats == nil {
+		return
+	}
+	r.GET("/debug/templates", func(c *gin.Context) {
+		pkg.Success(c, deps.TemplateStats())
+	})
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestDurationRing_Stats(t *testing.T) {
+	var d durationRing
+	if st := d.stats(); st != (DurationStats{}) {
+		t.Errorf("empty stats = %+v, want zero", st)
+	}
+	for i := 1; i <= 100; i++ {
+		d.record(time.Duration(i) * time.Millisecond)
+	}
+	st := d.stats()
+	if st.Count != 100 || st.P50MS != 50 || st.P95MS != 95 || st.MaxMS != 100 {
+		t.Errorf("stats = %+v, want count 100, p50 50, p95 95, max 100", st)
+	}
+
+	// Older samples leave the window but still count towards count and max.
+	for range timingWindow {
+		d.record(time.Millisecond)
+	}
+	st = d.stats()
+	if st.Count != 100+timingWindow || st.P95MS != 1 || st.MaxMS != 100 {
+		t.Errorf("stats after window = %+v, want p95 1 and max 100", st)
+	}
+}
+
+func TestTemplateStats_CountsRenders(t *testing.T) {
+	r, err := NewTemplateRenderer(testFS(), false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	for range 5 {
+		renderBody(t, r, "user/list.html")
+	}
+	renderBody(t, r, "errors/404.html")
+
+	stats := r.TemplateStats()
+	if len(stats) != 2 {
+		t.Fatalf("TemplateStats() = %+v, want the two rendered pages", stats)
+	}
+	counts := map[string]uint64{}
+	for _, st := range stats {
+		counts[st.Name] = st.Render.Count
+		if st.Parse != nil {
+			t.Errorf("%s: Parse = %+v, want nil in release mode", st.Name, st.Parse)
+		}
+	}
+	if counts["user/list.html"] != 5 || counts["errors/404.html"] != 1 {
+		t.Errorf("render counts = %v", counts)
+	}
+
+	// A missing page renders nothing and is not tracked.
+	_ = r.Instance("missing.html", nil).Render(httptest.NewRecorder())
+	if got := len(r.TemplateStats()); got != 2 {
+		t.Errorf("TemplateStats() after missing page has %d entries, want 2", got)
+	}
+}
+
+func TestDebugTemplatesRoute(t *testing.T) {
+	renderer, err := NewTemplateRenderer(stampedFS(), true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	for range 3 {
+		renderBody(t, renderer, "user/list.html")
+	}
+
+	r := gin.New()
+	registerDebugRoutes(r, &RouteDeps{Mode: gin.DebugMode, TemplateStats: renderer.TemplateStats})
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/debug/templates", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /debug/templates status = %d, want 200", w.Code)
+	}
+	var resp struct {
+		Data []TemplateStat `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].Name != "user/list.html" || resp.Data[0].Render.Count != 3 {
+		t.Fatalf("data = %+v, want user/list.html rendered 3 times", resp.Data)
+	}
+	if p := resp.Data[0].Parse; p == nil || p.Count != 1 {
+		t.Errorf("parse = %+v, want the one debug parse", p)
+	}
+
+	release := gin.New()
+	registerDebugRoutes(release, &RouteDeps{Mode: gin.ReleaseMode, TemplateStats: renderer.TemplateStats})
+	if w := testutil.Serve(release, httptest.NewRequest(http.MethodGet, "/debug/templates", nil)); w.Code != http.StatusNotFound {
+		t.Errorf("release GET /debug/templates status = %d, want 404", w.Code)
+	}
+}
+
+func TestTemplateRenderer_WarnsOnSlowRender(t *testing.T) {
+	fsys := stampedFS()
+	fsys["templates/slow/page.html"] = &fstest.MapFile{
+		Data:    []byte(`{{ template "base" . }}{{ define "content" }}{{ slow }}{{ end }}`),
+		ModTime: time.Unix(1_700_000_000, 0),
+	}
+	var logs bytes.Buffer
+	r, err := NewTemplateRenderer(fsys, true,
+		WithSlowRenderThreshold(time.Millisecond),
+		WithTemplateLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	// Debug mode parses on first use, so the function can still be added.
+	r.funcMap["slow"] = func() template.HTML {
+		time.Sleep(5 * time.Millisecond)
+		return ""
+	}
+
+	renderBody(t, r, "slow/page.html")
+	out := logs.String()
+	if !strings.Contains(out, "slow template render") || !strings.Contains(out, "template=slow/page.html") {
+		t.Errorf("logs = %q, want a slow render warning for slow/page.html", out)
+	}
+}
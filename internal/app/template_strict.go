@@ -0,0 +1,64 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+)
+
+// WithStrictData makes templates fail on map keys their data lacks
+// (missingkey=error) instead of rendering them as empty, so a handler that
+// forgets a key such as .CSRFToken is caught by the first render. Strict
+// renders buffer their output: a failed one writes no partial page but a
+// 500 text/plain response carrying the TemplateDataError. New only enables
+// it outside release mode (server.templates.strict).
+func WithStrictData() TemplateOption {
+	return func(r *TemplateRenderer) {
+		r.strict = true
+	}
+}
+
+// TemplateDataError reports a page template that read a data key its
+// render did not provide.
+type TemplateDataError struct {
+	Template string // page template, e.g. "user/form.html"
+	Key      string // key path as written in the template, e.g. ".User.Name"
+	Err      error  // the underlying execution error
+}
+
+func (e *TemplateDataError) Error() string {
+	return fmt.Sprintf("template %q: missing data key %s: %v", e.Template, e.Key, e.Err)
+}
+
+func (e *TemplateDataError) Unwrap() error { return e.Err }
+
+// missingKeyPattern extracts the key path from text/template's missingkey=error
+// message: `executing "content" at <.Error>: map has no entry for key "Error"`.
+var missingKeyPattern = regexp.MustCompile(`at <([^>]+)>: map has no entry for key`)
+
+// wrapRenderError names the page template in err, as a TemplateDataError when
+// err is a missing data key.
+func wrapRenderError(name string, err error) error {
+	if m := missingKeyPattern.FindStringSubmatch(err.Error()); m != nil {
+		return &TemplateDataError{Template: name, Key: m[1], Err: err}
+	}
+	return fmt.Errorf("render template %q: %w", name, err)
+}
+
+// renderStrict executes the template into a buffer and writes it only when
+// execution succeeded; otherwise it answers 500 with the error as text.
+func (h *HTMLInstance) renderStrict(w http.ResponseWriter, tmpl *template.Template) error {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, h.Name, h.Data); err != nil {
+		err = wrapRenderError(h.Name, err)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return err
+	}
+	h.WriteContentType(w)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,38 @@
+package app_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+	"github.com/simp-lee/gobase/internal/testutil/apptest"
+)
+
+// TestStrictTemplates_UserPages renders every user page with its handler's
+// data under strict templates, so a key a template reads but the handler
+// does not pass fails here.
+func TestStrictTemplates_UserPages(t *testing.T) {
+	a := apptest.NewTestApp(t)
+	u := testutil.SeedUsers(t, a.DB(), domain.User{})[0]
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/", http.StatusOK},
+		{"/users", http.StatusOK},
+		{"/users/new", http.StatusOK},
+		{fmt.Sprintf("/users/%d", u.ID), http.StatusOK},
+		{fmt.Sprintf("/users/%d/edit", u.ID), http.StatusOK},
+		{fmt.Sprintf("/users/%d/confirm-delete", u.ID), http.StatusOK},
+		{"/users/999999", http.StatusNotFound},
+		{"/users/abc/edit", http.StatusBadRequest},
+		{"/no-such-page", http.StatusNotFound},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			apptest.RenderPage(t, a, testutil.NewJSONRequest(t, http.MethodGet, tc.path, nil), tc.want)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// strictFS has a layout reading .Locale and a form page reading .CSRFToken.
+func strictFS() fstest.MapFS {
+	return fstest.MapFS{
+		"templates/layouts/base.html": &fstest.MapFile{
+			Data: []byte(`{{ define "base" }}<html lang="{{ .Locale }}">{{ block "content" . }}{{ end }}</html>{{ end }}`),
+		},
+		"templates/user/form.html": &fstest.MapFile{
+			Data: []byte(`{{ template "base" . }}{{ define "content" }}<input value="{{ .CSRFToken }}">{{ end }}`),
+		},
+	}
+}
+
+func TestStrictData_MissingKeyNamesTemplateAndKey(t *testing.T) {
+	r, err := NewTemplateRenderer(strictFS(), false, WithStrictData())
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	err = r.Instance("user/form.html", map[string]any{"Locale": "en"}).Render(w)
+
+	var dataErr *TemplateDataError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("Render() error = %v, want *TemplateDataError", err)
+	}
+	if dataErr.Template != "user/form.html" || dataErr.Key != ".CSRFToken" {
+		t.Errorf("TemplateDataError = {%q, %q}, want {user/form.html, .CSRFToken}", dataErr.Template, dataErr.Key)
+	}
+	for _, want := range []string{`"user/form.html"`, ".CSRFToken"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("response = %d %q, want 500 text/plain", w.Code, w.Header().Get("Content-Type"))
+	}
+	if body := w.Body.String(); strings.Contains(body, "<html") || !strings.Contains(body, ".CSRFToken") {
+		t.Errorf("body = %q, want the error without partial page output", body)
+	}
+}
+
+func TestStrictData_CompleteDataRenders(t *testing.T) {
+	r, err := NewTemplateRenderer(strictFS(), false, WithStrictData())
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := r.Instance("user/form.html", map[string]any{"Locale": "en", "CSRFToken": "tok"}).Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if got, want := w.Body.String(), `<html lang="en"><input value="tok"></html>`; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != htmlContentType {
+		t.Errorf("Content-Type = %q, want %q", got, htmlContentType)
+	}
+}
+
+func TestStrictData_OffRendersMissingKeys(t *testing.T) {
+	r, err := NewTemplateRenderer(strictFS(), false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	if err := r.Instance("user/form.html", map[string]any{"Locale": "en"}).Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if !strings.Contains(w.Body.String(), `<input value="">`) {
+		t.Errorf("body = %q, want the missing key rendered empty", w.Body.String())
+	}
+}
+
+func TestNew_StrictTemplatesIgnoredInRelease(t *testing.T) {
+	for mode, want := range map[string]bool{gin.TestMode: true, gin.ReleaseMode: false} {
+		app, err := New(testutil.NewTestConfig(testutil.WithMode(mode), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+			c.Server.CSRFSecret = "Abcd1234!Abcd1234!Abcd1234!Abcd1234!"
+		}))
+		if err != nil {
+			t.Fatalf("New(%s) error = %v", mode, err)
+		}
+		cleanupTestApp(t, app)
+		if got := app.engine.HTMLRender.(*TemplateRenderer).strict; got != want {
+			t.Errorf("%s mode: strict = %v, want %v", mode, got, want)
+		}
+	}
+}
@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"html/template"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"testing/fstest"
 	"time"
 
-	"github.com/simp-lee/pagination"
-
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
 	"github.com/simp-lee/gobase/web"
 )
 
@@ -101,12 +103,37 @@ func TestTemplateFuncMap(t *testing.T) {
 	})
 
 	t.Run("formatDate", func(t *testing.T) {
-		fn := fm["formatDate"].(func(time.Time) string)
+		fn := fm["formatDate"].(func(any) (string, error))
 		d := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
-		got := fn(d)
 		want := "2024-03-15 14:30:00"
-		if got != want {
-			t.Errorf("formatDate() = %q; want %q", got, want)
+		// Model timestamps are domain.Time; both render the same.
+		for _, v := range []any{d, domain.NewTime(d)} {
+			if got, err := fn(v); err != nil || got != want {
+				t.Errorf("formatDate(%T) = %q, %v; want %q", v, got, err, want)
+			}
+		}
+		if _, err := fn("2024-03-15"); err == nil {
+			t.Error("formatDate(string) error = nil, want an error")
+		}
+	})
+
+	t.Run("formatBytes", func(t *testing.T) {
+		fn := fm["formatBytes"].(func(uint64) string)
+		for n, want := range map[uint64]string{512: "512 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB", 3 << 30: "3.0 GiB"} {
+			if got := fn(n); got != want {
+				t.Errorf("formatBytes(%d) = %q; want %q", n, got, want)
+			}
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		fn := fm["markdown"].(func(string) template.HTML)
+		got := fn("**bold** <script>alert(1)</script>")
+		if !strings.Contains(string(got), "<strong>bold</strong>") {
+			t.Errorf("markdown() = %q; want rendered <strong>", got)
+		}
+		if strings.Contains(string(got), "<script") {
+			t.Errorf("markdown() = %q; want script stripped", got)
 		}
 	})
 
@@ -308,18 +335,7 @@ func TestPaginationTemplate_UsesPagesRange(t *testing.T) {
 	data := map[string]any{
 		"Users":   []any{},
 		"BaseURL": "/users",
-		"Pagination": &pagination.Pagination[any]{
-			CurrentPage:      5,
-			ItemsPerPage:     10,
-			TotalPages:       10,
-			PreviousPage:     intPtr(4),
-			NextPage:         intPtr(6),
-			FirstPage:        1,
-			LastPage:         10,
-			FirstPageInRange: 4,
-			LastPageInRange:  6,
-			Pages:            []int{4, 5, 6},
-		},
+		"Pager":   pkg.BuildPager("/users", url.Values{"page_size": {"10"}}, 5, 10),
 	}
 
 	inst := r.Instance("user/list.html", data)
@@ -330,10 +346,10 @@ func TestPaginationTemplate_UsesPagesRange(t *testing.T) {
 
 	body := w.Body.String()
 	for _, want := range []string{
-		"href=\"/users?page=4&page_size=10\"",
-		"href=\"/users?page=6&page_size=10\"",
-		"href=\"/users?page=1&page_size=10\"",
-		"href=\"/users?page=10&page_size=10\"",
+		"href=\"/users?page=4&amp;page_size=10\"",
+		"href=\"/users?page=6&amp;page_size=10\"",
+		"href=\"/users?page=1&amp;page_size=10\"",
+		"href=\"/users?page=10&amp;page_size=10\"",
 		"&hellip;",
 	} {
 		if !strings.Contains(body, want) {
@@ -341,11 +357,54 @@ func TestPaginationTemplate_UsesPagesRange(t *testing.T) {
 		}
 	}
 
-	if strings.Contains(body, "href=\"/users?page=5&page_size=10\"") {
+	if strings.Contains(body, "href=\"/users?page=5&amp;page_size=10\"") {
 		t.Error("current page should be rendered as active state, not link")
 	}
 }
 
+func TestPaginationTemplate_EllipsisAndPreservedQuery(t *testing.T) {
+	r, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	query := url.Values{"name__like": {"ali"}, "sort": {"name:asc"}, "page": {"6"}}
+	data := map[string]any{
+		"Users":   []any{},
+		"BaseURL": "/users",
+		"Pager":   pkg.BuildPager("/users", query, 6, 42),
+	}
+
+	inst := r.Instance("user/list.html", data)
+	w := httptest.NewRecorder()
+	if err := inst.Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	body := w.Body.String()
+	if got := strings.Count(body, "&hellip;"); got != 2 {
+		t.Errorf("ellipsis count = %d, want 2 (1 … 4 5 [6] 7 8 … 42)", got)
+	}
+	for _, p := range []int{1, 4, 5, 7, 8, 42} {
+		want := fmt.Sprintf(`href="/users?name__like=ali&amp;page=%d&amp;sort=name%%3Aasc"`, p)
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %s", want)
+		}
+	}
+	for _, p := range []int{2, 3, 9, 41} {
+		if strings.Contains(body, fmt.Sprintf("page=%d&amp;", p)) {
+			t.Errorf("page %d is inside a gap and should not be linked", p)
+		}
+	}
+	if !strings.Contains(body, `aria-current="page"`) {
+		t.Error("current page should carry aria-current")
+	}
+	// Prev/next links point at pages 5 and 7; page 6 itself is never linked.
+	if strings.Contains(body, `href="/users?name__like=ali&amp;page=6&amp;`) {
+		t.Error("current page should not be linked")
+	}
+}
+
 func TestPaginationTemplate_HidesNavigationWhenSinglePage(t *testing.T) {
 	r, err := NewTemplateRenderer(web.EmbeddedFS, false)
 	if err != nil {
@@ -355,14 +414,7 @@ func TestPaginationTemplate_HidesNavigationWhenSinglePage(t *testing.T) {
 	data := map[string]any{
 		"Users":   []any{},
 		"BaseURL": "/users",
-		"Pagination": &pagination.Pagination[any]{
-			CurrentPage:  1,
-			ItemsPerPage: 10,
-			TotalPages:   1,
-			FirstPage:    1,
-			LastPage:     1,
-			Pages:        []int{1},
-		},
+		"Pager":   pkg.BuildPager("/users", nil, 1, 1),
 	}
 
 	inst := r.Instance("user/list.html", data)
@@ -377,8 +429,98 @@ func TestPaginationTemplate_HidesNavigationWhenSinglePage(t *testing.T) {
 	}
 }
 
-func intPtr(v int) *int {
-	return &v
+func TestUserDetailTemplate_RendersFields(t *testing.T) {
+	r, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 2, 18, 45, 10, 0, time.UTC)
+	data := map[string]any{
+		"User": &domain.User{
+			BaseModel: domain.BaseModel{ID: 7, CreatedAt: domain.NewTime(created), UpdatedAt: domain.NewTime(updated)},
+			Name:      "Alice",
+			Email:     "alice@example.com",
+			Bio:       "**bold** <script>alert(1)</script>",
+		},
+		"CSRFToken": "tok",
+	}
+
+	inst := r.Instance("user/detail.html", data)
+	w := httptest.NewRecorder()
+	if err := inst.Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"Alice",
+		"alice@example.com",
+		"2024-03-01 09:30:00",
+		"2024-03-02 18:45:10",
+		`href="/users/7/edit"`,
+		"<strong>bold</strong>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert(1)</script>") {
+		t.Error("bio must be sanitized")
+	}
+}
+
+func TestUserListTemplate_RowsLinkToDetail(t *testing.T) {
+	r, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	data := map[string]any{
+		"Users":     []domain.User{{BaseModel: domain.BaseModel{ID: 3}, Name: "Bob", Email: "bob@example.com"}},
+		"BaseURL":   "/users",
+		"Pager":     pkg.BuildPager("/users", nil, 1, 1),
+		"CSRFToken": "tok",
+	}
+
+	inst := r.Instance("user/list.html", data)
+	w := httptest.NewRecorder()
+	if err := inst.Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if !strings.Contains(w.Body.String(), `href="/users/3"`) {
+		t.Errorf("list row should link to the detail page, body:\n%s", w.Body.String())
+	}
+}
+
+func TestUserListTemplate_ExportButton(t *testing.T) {
+	r, err := NewTemplateRenderer(web.EmbeddedFS, false)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	data := map[string]any{
+		"BaseURL":   "/users",
+		"ExportURL": "/users/export?name__like=a&sort=name%3Aasc",
+		"Pager":     pkg.BuildPager("/users", nil, 1, 1),
+	}
+	w := httptest.NewRecorder()
+	if err := r.Instance("user/list.html", data).Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`hx-get="/users/export?name__like=a&amp;sort=name%3Aasc"`,
+		`hx-swap="none"`,
+		`hx-disabled-elt="this"`,
+		`hx-indicator="#export-spinner"`,
+		`id="export-spinner" class="htmx-indicator`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("export button missing %s, body:\n%s", want, body)
+		}
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -459,3 +601,166 @@ func TestDiscoverPageTemplates(t *testing.T) {
 		}
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Debug-mode reload tests
+// ---------------------------------------------------------------------------
+
+// stampedFS returns testFS with modification times set, so the debug
+// renderer can detect changes.
+func stampedFS() fstest.MapFS {
+	fsys := testFS()
+	for _, f := range fsys {
+		f.ModTime = time.Unix(1_700_000_000, 0)
+	}
+	return fsys
+}
+
+// touch replaces the content of path and advances its modification time.
+func touch(fsys fstest.MapFS, path, content string) {
+	modTime := time.Unix(1_700_000_000, 0)
+	if f, ok := fsys[path]; ok {
+		modTime = f.ModTime
+	}
+	fsys[path] = &fstest.MapFile{Data: []byte(content), ModTime: modTime.Add(time.Second)}
+}
+
+func renderBody(t *testing.T, r *TemplateRenderer, name string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := r.Instance(name, nil).Render(w); err != nil {
+		t.Fatalf("Render(%s) error: %v", name, err)
+	}
+	return w.Body.String()
+}
+
+func TestTemplateRenderer_Debug_SkipsUnchanged(t *testing.T) {
+	r, err := NewTemplateRenderer(stampedFS(), true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	renderBody(t, r, "user/list.html")
+	// base.html, nav.html, and the two pages.
+	if r.parses != 4 {
+		t.Fatalf("parses after first request = %d, want 4", r.parses)
+	}
+	renderBody(t, r, "user/list.html")
+	renderBody(t, r, "errors/404.html")
+	if r.parses != 4 {
+		t.Errorf("parses after unchanged requests = %d, want 4", r.parses)
+	}
+}
+
+func TestTemplateRenderer_Debug_ReparsesChangedPage(t *testing.T) {
+	fsys := stampedFS()
+	r, err := NewTemplateRenderer(fsys, true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	renderBody(t, r, "user/list.html")
+	before := r.parses
+
+	touch(fsys, "templates/user/list.html", `{{ template "base" . }}{{ define "content" }}<h1>Edited</h1>{{ end }}`)
+	if body := renderBody(t, r, "user/list.html"); !strings.Contains(body, "<h1>Edited</h1>") {
+		t.Errorf("body = %s, want the edited page", body)
+	}
+	if got := r.parses - before; got != 1 {
+		t.Errorf("files parsed after page edit = %d, want 1", got)
+	}
+	if body := renderBody(t, r, "errors/404.html"); !strings.Contains(body, "<h1>404 Not Found</h1>") {
+		t.Errorf("unchanged page body = %s", body)
+	}
+}
+
+func TestTemplateRenderer_Debug_ReparsesAllOnBaseChange(t *testing.T) {
+	fsys := stampedFS()
+	r, err := NewTemplateRenderer(fsys, true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	renderBody(t, r, "errors/404.html")
+	before := r.parses
+
+	touch(fsys, "templates/partials/nav.html", `{{ define "nav" }}<nav>Edited</nav>{{ end }}`)
+	if body := renderBody(t, r, "user/list.html"); !strings.Contains(body, "<nav>Edited</nav>") {
+		t.Errorf("body = %s, want the edited partial", body)
+	}
+	if got := r.parses - before; got != 4 {
+		t.Errorf("files parsed after partial edit = %d, want 4", got)
+	}
+}
+
+func TestTemplateRenderer_Debug_PicksUpAddedPage(t *testing.T) {
+	fsys := stampedFS()
+	r, err := NewTemplateRenderer(fsys, true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	if err := r.Instance("user/new.html", nil).Render(httptest.NewRecorder()); err == nil {
+		t.Fatal("Render() of a missing page should fail")
+	}
+
+	touch(fsys, "templates/user/new.html", `{{ template "base" . }}{{ define "content" }}<h1>New</h1>{{ end }}`)
+	if body := renderBody(t, r, "user/new.html"); !strings.Contains(body, "<h1>New</h1>") {
+		t.Errorf("body = %s, want the added page", body)
+	}
+}
+
+func TestTemplateRenderer_Debug_RecoversFromParseError(t *testing.T) {
+	fsys := stampedFS()
+	r, err := NewTemplateRenderer(fsys, true)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	renderBody(t, r, "user/list.html")
+
+	touch(fsys, "templates/user/list.html", `{{ invalid_syntax `)
+	if err := r.Instance("user/list.html", nil).Render(httptest.NewRecorder()); err == nil {
+		t.Fatal("Render() should report the parse error")
+	}
+	touch(fsys, "templates/user/list.html", `{{ template "base" . }}{{ define "content" }}<h1>Fixed</h1>{{ end }}`)
+	if body := renderBody(t, r, "user/list.html"); !strings.Contains(body, "<h1>Fixed</h1>") {
+		t.Errorf("body = %s, want the fixed page", body)
+	}
+}
+
+func TestTemplateRenderer_Debug_NoModTimeReparsesEveryRequest(t *testing.T) {
+	r, err := NewTemplateRenderer(testFS(), true) // MapFiles without ModTime
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	renderBody(t, r, "user/list.html")
+	renderBody(t, r, "user/list.html")
+	if r.parses != 8 {
+		t.Errorf("parses = %d, want 8 (full parse per request)", r.parses)
+	}
+}
+
+// BenchmarkTemplateRenderer_Debug compares a debug-mode request when nothing
+// changed with a full re-parse of the real templates.
+func BenchmarkTemplateRenderer_Debug(b *testing.B) {
+	dir := b.TempDir()
+	if err := os.CopyFS(dir, web.EmbeddedFS); err != nil {
+		b.Fatalf("copy templates: %v", err)
+	}
+	r, err := NewTemplateRenderer(os.DirFS(dir), true)
+	if err != nil {
+		b.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+
+	b.Run("unchanged", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := r.reloadTemplate("user/list.html"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("full_parse", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := r.parseAllTemplates(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// tenantField is the field domain.TenantScoped adds to a model.
+const tenantField = "TenantID"
+
+// tenantResolver returns the middleware.TenantResolver tenancy.resolver
+// names.
+func tenantResolver(cfg *config.Config) middleware.TenantResolver {
+	if cfg.Tenancy.Resolver == config.TenantResolverSubdomain {
+		u, _ := url.Parse(cfg.Server.BaseURL) // already validated by config.Validate()
+		return middleware.TenantFromSubdomain(u.Hostname())
+	}
+	return middleware.TenantFromHeader(cfg.Tenancy.EffectiveHeader())
+}
+
+// staticPaths matches requests under the static mounts (the default /static
+// when there are none), which serve the same files to every tenant.
+func staticPaths(mounts []config.StaticMount) ginx.Condition {
+	prefixes := []string{"/static/"}
+	if len(mounts) > 0 {
+		prefixes = prefixes[:0]
+		for _, m := range mounts {
+			prefixes = append(prefixes, strings.TrimRight(m.URLPrefix, "/")+"/")
+		}
+	}
+	return func(c *gin.Context) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(c.Request.URL.Path, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// migrateTenantColumns adds the tenant_id column, and the indexes that
+// include it, to the existing tables of models embedding
+// domain.TenantScoped (or declaring its column, as domain.User does) that
+// lack them.
+// New runs it in every mode, with or without tenancy, since the models
+// read and write the column either way and a missing one would fail every
+// request; tables that do not exist yet are left to migration and the
+// schema check.
+func migrateTenantColumns(db *gorm.DB, models []any, log *slog.Logger) error {
+	m := db.Migrator()
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("parse %T: %w", model, err)
+		}
+		field := stmt.Schema.LookUpField(tenantField)
+		if field == nil || !m.HasTable(model) {
+			continue
+		}
+		if !m.HasColumn(model, tenantField) {
+			if err := m.AddColumn(model, tenantField); err != nil {
+				return fmt.Errorf("add %s.%s: %w", stmt.Schema.Table, field.DBName, err)
+			}
+			log.Info("tenancy: added tenant column", slog.String("table", stmt.Schema.Table))
+		}
+		for _, idx := range stmt.Schema.ParseIndexes() {
+			if !slices.ContainsFunc(idx.Fields, func(o schema.IndexOption) bool { return o.Field == field }) {
+				continue
+			}
+			if !m.HasIndex(model, idx.Name) {
+				if err := m.CreateIndex(model, idx.Name); err != nil {
+					return fmt.Errorf("create index %s: %w", idx.Name, err)
+				}
+			}
+			if err := dropGlobalUniqueIndex(db, model, stmt.Schema.Table, idx, field, log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dropGlobalUniqueIndex drops the unique index a model had on the column
+// that idx, a unique index, now pairs with the tenant column, such as the
+// users email index from before emails were unique per tenant. It is found
+// by GORM's default name for a single-column index.
+func dropGlobalUniqueIndex(db *gorm.DB, model any, table string, idx *schema.Index, tenant *schema.Field, log *slog.Logger) error {
+	if idx.Class != "UNIQUE" || len(idx.Fields) != 2 {
+		return nil
+	}
+	other := idx.Fields[0].Field
+	if other == tenant {
+		other = idx.Fields[1].Field
+	}
+	name := db.NamingStrategy.IndexName(table, other.DBName)
+	if name == idx.Name || !db.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	if err := db.Migrator().DropIndex(model, name); err != nil {
+		return fmt.Errorf("drop index %s: %w", name, err)
+	}
+	log.Info("tenancy: replaced global unique index", slog.String("table", table), slog.String("dropped", name), slog.String("index", idx.Name))
+	return nil
+}
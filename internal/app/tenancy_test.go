@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestTenancy_HeaderIsolatesAPI(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), testutil.WithTenancy()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	acme := &domain.User{Name: "Alice", Email: "alice@acme.test"}
+	globex := &domain.User{Name: "Bob", Email: "bob@globex.test"}
+	for ctx, u := range map[context.Context]*domain.User{
+		domain.WithTenant(context.Background(), "acme"):   acme,
+		domain.WithTenant(context.Background(), "globex"): globex,
+	} {
+		if err := a.db.WithContext(ctx).Create(u).Error; err != nil {
+			t.Fatalf("create %s: %v", u.Name, err)
+		}
+	}
+
+	get := func(path, tenant string) (int, []byte) {
+		req := testutil.NewJSONRequest(t, http.MethodGet, path, nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := testutil.Serve(a.engine, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := get("/api/v1/users", "acme")
+	var resp struct {
+		Data struct {
+			Items []domain.User `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("unmarshal: %v (body %s)", err, body)
+	}
+	if code != http.StatusOK || len(resp.Data.Items) != 1 || resp.Data.Items[0].ID != acme.ID {
+		t.Errorf("GET /api/v1/users as acme = %d %s, want only Alice", code, body)
+	}
+	if code, body := get(fmt.Sprintf("/api/v1/users/%d", globex.ID), "acme"); code != http.StatusNotFound {
+		t.Errorf("GET globex user as acme = %d %s, want 404", code, body)
+	}
+	if code, _ := get(fmt.Sprintf("/api/v1/users/%d", globex.ID), "globex"); code != http.StatusOK {
+		t.Errorf("GET globex user as globex = %d, want 200", code)
+	}
+	if code, body := get("/api/v1/users", ""); code != http.StatusBadRequest {
+		t.Errorf("GET /api/v1/users without tenant = %d %s, want 400", code, body)
+	}
+	if code, body := get(a.cfg.Server.Health.EffectivePath(), ""); code != http.StatusOK {
+		t.Errorf("GET health without tenant = %d %s, want 200", code, body)
+	}
+}
+
+// TestTenancy_CacheSeparatesTenants checks that the response cache and the
+// single-flight collapsing, which share its key, never answer one tenant
+// with another's list.
+func TestTenancy_CacheSeparatesTenants(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), testutil.WithTenancy(), func(c *config.Config) {
+		c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+	if err := a.db.WithContext(domain.WithTenant(context.Background(), "alpha")).Create(&domain.User{Name: "Alice", Email: "alice@alpha.test"}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	list := func(tenant string) string {
+		req := testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		w := testutil.Serve(a.engine, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/v1/users as %s = %d %s, want 200", tenant, w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+	if alpha := list("alpha"); !strings.Contains(alpha, "Alice") {
+		t.Fatalf("alpha list = %s, want Alice", alpha)
+	}
+	if beta := list("beta"); strings.Contains(beta, "Alice") {
+		t.Errorf("beta list = %s, served from alpha's cache entry", beta)
+	}
+}
+
+func TestTenancy_TokenBoundToTenant(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(testutil.MemoryDSN(t)), testutil.WithAuth(), testutil.WithTenancy()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+	testutil.Migrate(t, a.db)
+
+	token, err := a.jwtService.GenerateToken("1", domain.TokenRoles(domain.WithTenant(context.Background(), "alpha")), time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	get := func(tenant string) int {
+		req := testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return testutil.Serve(a.engine, req).Code
+	}
+	if code := get("alpha"); code != http.StatusOK {
+		t.Errorf("GET /api/v1/users as alpha with alpha's token = %d, want 200", code)
+	}
+	if code := get("beta"); code != http.StatusForbidden {
+		t.Errorf("GET /api/v1/users as beta with alpha's token = %d, want 403", code)
+	}
+}
+
+func TestMigrateTenantColumns_AddsColumnToExistingTable(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	// A users table from before tenancy, without the column.
+	if err := db.Exec("DROP TABLE users").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, created_at datetime, updated_at datetime, deleted_at datetime, name text, email text, password_hash text)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX idx_users_email ON users (email)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO users (name, email) VALUES ('Old', 'old@example.com')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	models := []any{&domain.User{}, &struct{ ID uint }{}}
+	if err := migrateTenantColumns(db, models, slog.New(slog.DiscardHandler)); err != nil {
+		t.Fatalf("migrateTenantColumns() error = %v", err)
+	}
+	m := db.Migrator()
+	if !m.HasColumn(&domain.User{}, "TenantID") || !m.HasIndex(&domain.User{}, "idx_users_tenant_id") {
+		t.Fatal("users has no tenant_id column and index after migration")
+	}
+	// Emails become unique per tenant.
+	if !m.HasIndex(&domain.User{}, "idx_users_tenant_email") || m.HasIndex(&domain.User{}, "idx_users_email") {
+		t.Error("users email index is still global after migration")
+	}
+	var tenant string
+	if err := db.Raw("SELECT tenant_id FROM users WHERE email = 'old@example.com'").Scan(&tenant).Error; err != nil || tenant != "" {
+		t.Errorf("existing row tenant_id = %q, %v; want empty", tenant, err)
+	}
+	// Running it again is a no-op.
+	if err := migrateTenantColumns(db, models, slog.New(slog.DiscardHandler)); err != nil {
+		t.Errorf("second migrateTenantColumns() error = %v", err)
+	}
+}
+
+// TestNew_AddsTenantColumnWithoutTenancy starts a release App, which does
+// not auto-migrate, with tenancy off on a users table from before tenancy:
+// the column the model writes must still be added, or creating users fails.
+func TestNew_AddsTenantColumnWithoutTenancy(t *testing.T) {
+	dsn := testutil.MemoryDSN(t)
+	db := testutil.OpenTestDB(t, dsn)
+	if err := db.Exec("DROP TABLE users").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id integer PRIMARY KEY, created_at datetime, updated_at datetime, name text, email text, password_hash text, bio text)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(testutil.NewTestConfig(testutil.WithSQLitePath(dsn), testutil.WithMode(gin.ReleaseMode), func(c *config.Config) {
+		c.Server.CSRFSecret = "Release-CSRF-secret-0123456789-abcdef"
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer cleanupTestApp(t, a)
+
+	if err := a.db.Create(&domain.User{Name: "Alice", Email: "alice@example.com"}).Error; err != nil {
+		t.Errorf("create user after startup: %v", err)
+	}
+}
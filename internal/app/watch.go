@@ -0,0 +1,170 @@
+package app
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// fileWatcher polls sets of files in debug mode and reloads whatever they
+// feed when one is added, removed or modified. Polling costs a walk of a
+// few small directories per interval and behaves the same on every
+// platform, which a notification API would not.
+type fileWatcher struct {
+	interval time.Duration
+	log      *slog.Logger
+	clock    pkg.Clock
+	targets  []*watchTarget
+
+	ctx       context.Context // cancelled by close
+	cancel    context.CancelFunc
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// watchTarget is one set of watched files and the reload it triggers.
+type watchTarget struct {
+	name   string
+	fsys   fs.FS
+	root   string
+	match  func(path string) bool
+	reload func() error
+	stamps map[string]fileStamp
+}
+
+func newFileWatcher(interval time.Duration, log *slog.Logger, clock pkg.Clock) *fileWatcher {
+	if interval <= 0 {
+		interval = config.DefaultTemplateWatchInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fileWatcher{
+		interval: interval,
+		log:      log,
+		clock:    clock,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// watch registers the files under root in fsys for which match is true,
+// calling reload after they change; call it before start. The files as
+// they are now are the baseline.
+func (w *fileWatcher) watch(name string, fsys fs.FS, root string, match func(path string) bool, reload func() error) {
+	stamps, _ := statFiles(fsys, root, match)
+	w.targets = append(w.targets, &watchTarget{name: name, fsys: fsys, root: root, match: match, reload: reload, stamps: stamps})
+}
+
+// start launches the polling loop. Without targets it does nothing.
+func (w *fileWatcher) start() {
+	if w == nil || len(w.targets) == 0 {
+		return
+	}
+	w.started = true
+	go w.loop()
+}
+
+func (w *fileWatcher) loop() {
+	defer close(w.done)
+	timer := w.clock.NewTimer(w.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			w.poll()
+			timer.Reset(w.interval)
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// poll reloads every target whose files changed since the previous poll
+// and logs the files behind each reload. A target that cannot be read
+// right now (a directory being replaced) is skipped until the next poll;
+// a failed reload is logged and the previous content stays in use.
+func (w *fileWatcher) poll() {
+	for _, t := range w.targets {
+		stamps, ok := statFiles(t.fsys, t.root, t.match)
+		if !ok {
+			continue
+		}
+		changed := changedFiles(t.stamps, stamps)
+		if len(changed) == 0 {
+			continue
+		}
+		t.stamps = stamps
+		if err := t.reload(); err != nil {
+			w.log.Error("hot reload failed", slog.String("component", t.name), slog.Any("files", changed), slog.Any("error", err))
+			continue
+		}
+		w.log.Info("hot reload", slog.String("component", t.name), slog.Any("files", changed))
+	}
+}
+
+// close stops the polling loop and waits for it to return.
+func (w *fileWatcher) close() {
+	if w == nil {
+		return
+	}
+	w.closeOnce.Do(func() {
+		w.cancel()
+		if w.started {
+			<-w.done
+		}
+	})
+}
+
+// changedFiles returns, sorted, the files added, removed or modified
+// between old and cur.
+func changedFiles(old, cur map[string]fileStamp) []string {
+	var changed []string
+	for p, stamp := range cur {
+		if prev, ok := old[p]; !ok || prev != stamp {
+			changed = append(changed, p)
+		}
+	}
+	for p := range old {
+		if _, ok := cur[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// watchReloads registers the renderer's caches with w in debug mode: the
+// parsed templates, the asset manifest and the locale bundles. In other
+// modes nothing is ever reloaded, so nothing is registered.
+func (r *TemplateRenderer) watchReloads(w *fileWatcher) {
+	if !r.debug {
+		return
+	}
+	w.watch("templates", r.fs, "templates", isTemplateFile, func() error {
+		r.invalidateTemplates()
+		return nil
+	})
+	w.watch("asset_manifest", r.fs, path.Dir(assetManifestPath), func(p string) bool { return p == assetManifestPath }, r.loadManifest)
+	if r.bundleFS != nil {
+		w.watch("locale_bundles", r.bundleFS, ".", func(p string) bool {
+			ok, _ := path.Match("*.json", p)
+			return ok
+		}, r.loadBundles)
+	}
+}
+
+// invalidateTemplates drops the debug-mode template cache so the next
+// render re-parses every template, whatever the modification times say.
+func (r *TemplateRenderer) invalidateTemplates() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates, r.stamps = nil, nil
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// writeWatched writes content to dir/name with modification time mtime, so
+// each rewrite in a test changes the file's stamp.
+func writeWatched(t *testing.T, dir, name, content string, mtime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newWatchedRenderer returns a debug renderer over a temp web directory
+// whose home.html prints a translated greeting and an asset URL, and the
+// web and locale directories.
+func newWatchedRenderer(t *testing.T, debug bool, mtime time.Time) (r *TemplateRenderer, webDir, localeDir string) {
+	t.Helper()
+	webDir, localeDir = t.TempDir(), t.TempDir()
+	writeWatched(t, webDir, "templates/home.html", `{{ define "home.html" }}{{ t "greeting" }} {{ asset "css/app.css" }}{{ end }}`, mtime)
+	writeWatched(t, webDir, "static/manifest.json", `{"css/app.css": "css/app.v1.css"}`, mtime)
+	writeWatched(t, localeDir, "en.json", `{"greeting": "Hello"}`, mtime)
+	writeWatched(t, localeDir, "zh-CN.json", `{"greeting": "你好"}`, mtime)
+
+	r, err := NewTemplateRenderer(os.DirFS(webDir), debug, WithLocaleBundles(os.DirFS(localeDir), "zh-CN"))
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	return r, webDir, localeDir
+}
+
+func renderHome(t *testing.T, r *TemplateRenderer, locale string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := r.Instance("home.html", gin.H{"Locale": locale}).Render(w); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	return w.Body.String()
+}
+
+func TestFileWatcher_ReloadsBundlesAndManifest(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, webDir, localeDir := newWatchedRenderer(t, true, start)
+	w := newFileWatcher(time.Second, discardLogger(), pkg.NewFakeClock(start))
+	r.watchReloads(w)
+
+	if got, want := renderHome(t, r, "en"), "Hello /static/css/app.v1.css"; got != want {
+		t.Fatalf("first render = %q, want %q", got, want)
+	}
+	if got, want := renderHome(t, r, "fr"), "你好 /static/css/app.v1.css"; got != want {
+		t.Errorf("render for an unbundled locale = %q, want the default locale's %q", got, want)
+	}
+
+	writeWatched(t, localeDir, "en.json", `{"greeting": "Hi"}`, start.Add(time.Hour))
+	writeWatched(t, webDir, "static/manifest.json", `{"css/app.css": "css/app.v2.css"}`, start.Add(time.Hour))
+	w.poll()
+	if got, want := renderHome(t, r, "en"), "Hi /static/css/app.v2.css"; got != want {
+		t.Errorf("render after the change = %q, want %q", got, want)
+	}
+
+	// A bundle that no longer parses leaves the previous messages in use.
+	writeWatched(t, localeDir, "en.json", `{"greeting": `, start.Add(2*time.Hour))
+	w.poll()
+	if got, want := renderHome(t, r, "en"), "Hi /static/css/app.v2.css"; got != want {
+		t.Errorf("render after a broken bundle = %q, want %q", got, want)
+	}
+}
+
+func TestFileWatcher_PollsUntilClosed(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, webDir, _ := newWatchedRenderer(t, true, start)
+	clock := pkg.NewFakeClock(start)
+	w := newFileWatcher(time.Second, discardLogger(), clock)
+	r.watchReloads(w)
+	w.start()
+
+	writeWatched(t, webDir, "templates/home.html", `{{ define "home.html" }}edited{{ end }}`, start.Add(time.Hour))
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	clock.BlockUntil(1) // the loop re-arms its timer once the poll is done
+	if got := renderHome(t, r, "en"); got != "edited" {
+		t.Errorf("render after the poll = %q, want the edited template", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("close() did not stop the polling loop")
+	}
+}
+
+func TestTemplateRenderer_WatchReloads_NotInRelease(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, _, _ := newWatchedRenderer(t, false, start)
+	w := newFileWatcher(time.Second, discardLogger(), pkg.NewFakeClock(start))
+	r.watchReloads(w)
+	w.start()
+	defer w.close()
+
+	if len(w.targets) != 0 || w.started {
+		t.Errorf("release renderer registered %d watch targets (started %v), want none", len(w.targets), w.started)
+	}
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/jwt"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// eventSocketPath serves the live event stream (server.websocket). It is
+// left out of the request timeout, which cannot hold a hijacked
+// connection.
+const eventSocketPath = "/ws"
+
+// eventSocketWriteTimeout bounds writing one event frame.
+const eventSocketWriteTimeout = 10 * time.Second
+
+// eventSocket serves GET /ws, a WebSocket that forwards the events of
+// broker as JSON text frames, one domain.Event per frame. Browsers cannot
+// set headers on a WebSocket, and pages have no session cookie, so the JWT
+// is taken from the Authorization header or the access_token query
+// parameter, which the request log redacts. With RBAC the user also needs
+// users:read, the permission of the user list the events describe. With
+// tenancy the token must have been issued for the tenant the request
+// resolves to (domain.TokenTenant), and only that tenant's events are
+// forwarded. Everything is checked before the upgrade, so a refused client gets a
+// plain HTTP error instead of a socket.
+//
+// Each connection has its own subscription of sendBuffer events; a client
+// that falls further behind is closed with StatusPolicyViolation, and one
+// that does not answer a ping within pingInterval is dropped. close ends
+// every connection with StatusGoingAway and waits for them.
+type eventSocket struct {
+	broker       *pkg.EventBroker
+	jwt          jwt.Service
+	rbac         rbac.Service // nil without auth.rbac
+	tenancy      bool
+	origins      []string
+	pingInterval time.Duration
+	sendBuffer   int
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	closed bool
+	conns  sync.WaitGroup
+}
+
+func newEventSocket(broker *pkg.EventBroker, jwtSvc jwt.Service, rbacSvc rbac.Service, tenancy bool, server *config.ServerConfig, logger *slog.Logger) *eventSocket {
+	return &eventSocket{
+		broker:       broker,
+		jwt:          jwtSvc,
+		rbac:         rbacSvc,
+		tenancy:      tenancy,
+		origins:      socketOrigins(server.CORS.AllowOrigins, server.AllowedHosts),
+		pingInterval: server.WebSocket.EffectivePingInterval(),
+		sendBuffer:   server.WebSocket.EffectiveSendBuffer(),
+		logger:       logger,
+	}
+}
+
+// socketOrigins returns the websocket.AcceptOptions.OriginPatterns for the
+// configured CORS origins and allowed hosts; the request's own host is
+// always accepted. A "*" CORS origin is not carried over: any page could
+// then open an authenticated stream with a token it obtained.
+func socketOrigins(corsOrigins, allowedHosts []string) []string {
+	var patterns []string
+	for _, origin := range corsOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" && origin != "*" {
+			patterns = append(patterns, origin)
+		}
+	}
+	for _, host := range allowedHosts {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]" // IPv6, as it appears in an Origin
+		}
+		patterns = append(patterns, host, host+":*")
+	}
+	return patterns
+}
+
+// acquire registers a connection about to be upgraded, or reports false
+// once close has begun.
+func (s *eventSocket) acquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns.Add(1)
+	return true
+}
+
+func (s *eventSocket) serve(c *gin.Context) {
+	userID, tenant, ok := s.authenticate(c)
+	if !ok {
+		renderError(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if s.tenancy && tenant == "" {
+		renderError(c, http.StatusForbidden, "token was not issued for this tenant")
+		return
+	}
+	if s.rbac != nil {
+		allowed, err := s.rbac.HasPermission(userID, "users", "read")
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "websocket permission check failed", slog.Any("error", err))
+			renderError(c, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		if !allowed {
+			renderError(c, http.StatusForbidden, "forbidden")
+			return
+		}
+	}
+	if !s.acquire() {
+		renderError(c, http.StatusServiceUnavailable, "server shutting down")
+		return
+	}
+	defer s.conns.Done()
+
+	// The server's read and write timeouts would otherwise cut the
+	// connection after a minute; liveness is left to the pings.
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	// Subscribed first, so the client gets every event published after
+	// its handshake completes.
+	var sub *pkg.EventSubscription
+	if s.tenancy {
+		sub = s.broker.SubscribeTenant(tenant, s.sendBuffer)
+	} else {
+		sub = s.broker.Subscribe(s.sendBuffer)
+	}
+	defer sub.Close()
+	conn, err := websocket.Accept(upgradeWriter{c.Writer}, c.Request, &websocket.AcceptOptions{OriginPatterns: s.origins})
+	if err != nil {
+		return // Accept has answered, e.g. 403 for a foreign Origin
+	}
+	defer conn.CloseNow()
+
+	// Only control frames are expected from the client; ctx ends when it
+	// closes the connection or a ping goes unanswered.
+	ctx := conn.CloseRead(context.Background())
+	ping := time.NewTicker(s.pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case e := <-sub.Events():
+			wctx, cancel := context.WithTimeout(ctx, eventSocketWriteTimeout)
+			err := wsjson.Write(wctx, conn, e)
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			go s.keepalive(ctx, conn)
+		case <-sub.Done():
+			if errors.Is(sub.Err(), pkg.ErrSlowSubscriber) {
+				s.logger.Warn("websocket client too slow, disconnecting", slog.String("user_id", userID))
+				_ = conn.Close(websocket.StatusPolicyViolation, "too slow")
+				return
+			}
+			_ = conn.Close(websocket.StatusGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// upgradeWriter lets websocket.Accept hijack the connection under a gin
+// writer. Accept sends the 101 through WriteHeaderNow, so gin records it for
+// the request log, but gin's Hijack then refuses a written response; the
+// innermost http.ResponseWriter is hijacked instead.
+type upgradeWriter struct{ gin.ResponseWriter }
+
+func (w upgradeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	var rw http.ResponseWriter = w.ResponseWriter
+	for {
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = u.Unwrap()
+	}
+	return http.NewResponseController(rw).Hijack()
+}
+
+// keepalive pings conn and drops it when no pong arrives within the ping
+// interval.
+func (s *eventSocket) keepalive(ctx context.Context, conn *websocket.Conn) {
+	ctx, cancel := context.WithTimeout(ctx, s.pingInterval)
+	defer cancel()
+	if err := conn.Ping(ctx); err != nil {
+		_ = conn.CloseNow()
+	}
+}
+
+// authenticate returns the user of the bearer token in the Authorization
+// header or the access_token query parameter and, with tenancy, the tenant
+// middleware.Tenant resolved when the token was issued for it, as
+// middleware.TenantToken checks; the tenant is empty otherwise.
+func (s *eventSocket) authenticate(c *gin.Context) (userID, tenant string, ok bool) {
+	token := c.Query("access_token")
+	if scheme, value, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = value
+	}
+	if token == "" {
+		return "", "", false
+	}
+	parsed, err := s.jwt.ValidateAndParse(token)
+	if err != nil {
+		return "", "", false
+	}
+	if s.tenancy {
+		resolved, _ := requestctx.Tenant(c)
+		if claimed, ok := domain.TokenTenant(parsed.Roles); ok && claimed == resolved {
+			tenant = resolved
+		}
+	}
+	return parsed.UserID, tenant, true
+}
+
+// close ends every connection and waits for their handlers to return. It is
+// safe on a nil eventSocket.
+func (s *eventSocket) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.broker.Close()
+	s.conns.Wait()
+}
+
+// registerEventSocketRoutes adds GET eventSocketPath when s is non-nil.
+func registerEventSocketRoutes(r *gin.Engine, s *eventSocket) {
+	if s == nil {
+		return
+	}
+	r.GET(eventSocketPath, s.serve)
+}
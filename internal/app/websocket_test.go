@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newSocketServer starts an App with server.websocket enabled behind a real
+// HTTP server and returns the server and a token for user 1.
+func newSocketServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	a, err := New(testutil.NewTestConfig(testutil.WithAuth(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.WebSocket.Enabled = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db, domain.User{})
+	token, err := a.jwtService.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(srv.Close)
+	return srv, token
+}
+
+func socketURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + eventSocketPath
+}
+
+func TestEventSocket_DeliversUserEvents(t *testing.T) {
+	srv, token := newSocketServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, socketURL(srv)+"?access_token="+token, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.CloseNow() })
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/users", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/v1/users error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/v1/users status = %d, want 201", resp.StatusCode)
+	}
+
+	var event struct {
+		Type string      `json:"type"`
+		Data domain.User `json:"data"`
+		Time time.Time   `json:"time"`
+	}
+	if err := wsjson.Read(ctx, conn, &event); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	if event.Type != domain.EventUserCreated || event.Data.Email != "ada@example.com" || event.Time.IsZero() {
+		t.Errorf("event = %+v, want %s for ada@example.com with a time", event, domain.EventUserCreated)
+	}
+}
+
+func TestEventSocket_SeparatesTenants(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithAuth(), testutil.WithTenancy(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.WebSocket.Enabled = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	srv := httptest.NewServer(a.Handler())
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokens := map[string]string{}
+	conns := map[string]*websocket.Conn{}
+	for _, tenant := range []string{"alpha", "beta"} {
+		token, err := a.jwtService.GenerateToken("1", domain.TokenRoles(domain.WithTenant(ctx, tenant)), time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateToken() error = %v", err)
+		}
+		tokens[tenant] = token
+		conn, _, err := websocket.Dial(ctx, socketURL(srv), &websocket.DialOptions{HTTPHeader: http.Header{
+			"Authorization": {"Bearer " + token},
+			"X-Tenant-ID":   {tenant},
+		}})
+		if err != nil {
+			t.Fatalf("Dial() as %s error = %v", tenant, err)
+		}
+		t.Cleanup(func() { _ = conn.CloseNow() })
+		conns[tenant] = conn
+	}
+
+	// A token is refused for another tenant's stream.
+	conn, resp, err := websocket.Dial(ctx, socketURL(srv), &websocket.DialOptions{HTTPHeader: http.Header{
+		"Authorization": {"Bearer " + tokens["alpha"]},
+		"X-Tenant-ID":   {"beta"},
+	}})
+	if err == nil {
+		_ = conn.CloseNow()
+		t.Fatal("Dial() with alpha's token as beta succeeded, want 403")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Dial() with alpha's token as beta = %v (%v), want status 403", resp, err)
+	}
+
+	for _, tenant := range []string{"alpha", "beta"} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/users", strings.NewReader(`{"name":"Ada","email":"`+tenant+`@example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tokens[tenant])
+		req.Header.Set("X-Tenant-ID", tenant)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/v1/users as %s error = %v", tenant, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST /api/v1/users as %s status = %d, want 201", tenant, resp.StatusCode)
+		}
+	}
+
+	// Each stream's first event is its own tenant's; had alpha's stream
+	// received beta's event, it would come second and is checked below.
+	for _, tenant := range []string{"alpha", "beta"} {
+		var event struct {
+			Data domain.User `json:"data"`
+		}
+		if err := wsjson.Read(ctx, conns[tenant], &event); err != nil {
+			t.Fatalf("read %s event: %v", tenant, err)
+		}
+		if want := tenant + "@example.com"; event.Data.Email != want {
+			t.Errorf("%s stream got the event for %q, want %q", tenant, event.Data.Email, want)
+		}
+	}
+	readCtx, readCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer readCancel()
+	var extra map[string]any
+	if err := wsjson.Read(readCtx, conns["alpha"], &extra); err == nil {
+		t.Errorf("alpha stream got another event %v, want none", extra)
+	}
+}
+
+func TestEventSocket_RefusesAtHandshake(t *testing.T) {
+	srv, token := newSocketServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, tt := range []struct {
+		name   string
+		url    string
+		header http.Header
+		want   int
+	}{
+		{"no token", socketURL(srv), nil, http.StatusUnauthorized},
+		{"bad token", socketURL(srv) + "?access_token=invalid", nil, http.StatusUnauthorized},
+		{"foreign origin", socketURL(srv), http.Header{"Authorization": {"Bearer " + token}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := websocket.Dial(ctx, tt.url, &websocket.DialOptions{HTTPHeader: tt.header})
+			if err == nil {
+				_ = conn.CloseNow()
+				t.Fatal("Dial() succeeded, want the handshake refused")
+			}
+			if resp == nil || resp.StatusCode != tt.want {
+				t.Errorf("Dial() response = %v (%v), want status %d", resp, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventSocket_ClosedOnShutdown(t *testing.T) {
+	a, err := New(testutil.NewTestConfig(testutil.WithAuth(), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.WebSocket.Enabled = true
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	closed := false
+	t.Cleanup(func() {
+		if !closed {
+			cleanupTestApp(t, a)
+		}
+	})
+	token, _ := a.jwtService.GenerateToken("1", nil, time.Hour)
+	srv := httptest.NewServer(a.Handler())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, socketURL(srv), &websocket.DialOptions{HTTPHeader: http.Header{"Authorization": {"Bearer " + token}}})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.CloseNow()
+
+	// The read loop answers the server's close handshake.
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := conn.Read(ctx)
+		readErr <- err
+	}()
+	closed = true
+	cleanupTestApp(t, a)
+
+	var ce websocket.CloseError
+	if err := <-readErr; !errors.As(err, &ce) || ce.Code != websocket.StatusGoingAway {
+		t.Errorf("Read() after Close = %v, want close status %v", err, websocket.StatusGoingAway)
+	}
+}
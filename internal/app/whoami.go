@@ -0,0 +1,52 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// whoamiMiddleware returns the X-Debug-Whoami middleware, or nil when
+// there is none. It answers every caller in debug and test mode; in
+// release mode only callers with the admin:read permission, so only with
+// RBAC. svc is the RBAC service the policies are checked with, nil
+// without RBAC.
+func whoamiMiddleware(mode string, svc rbac.Service, policies []middleware.Policy) ginx.Middleware {
+	var opts []middleware.WhoamiOption
+	if svc != nil {
+		opts = append(opts, middleware.WithWhoamiRBAC(svc, policies))
+	}
+	if mode == gin.ReleaseMode {
+		if svc == nil {
+			return nil
+		}
+		opts = append(opts, middleware.WithWhoamiGate(func(_ *gin.Context, userID string) bool {
+			if userID == "" {
+				return false
+			}
+			allowed, err := svc.HasPermission(userID, "admin", "read")
+			return err == nil && allowed
+		}))
+	}
+	return middleware.Whoami(opts...)
+}
+
+// whoamiUncacheable returns the condition matching the X-Debug-Whoami
+// requests whoamiMiddleware(mode, ...) may explain, which the response
+// cache and single-flight must skip, as their responses describe one
+// caller; nil when it explains none. In release mode only an authenticated
+// admin's are explained, and those with Authorization or Cookie already
+// bypass the cache, so only those presenting an API key are left.
+func whoamiUncacheable(mode string, rbacEnabled bool) ginx.Condition {
+	if mode != gin.ReleaseMode {
+		return middleware.IsWhoamiRequest
+	}
+	if !rbacEnabled {
+		return nil
+	}
+	return ginx.And(middleware.IsWhoamiRequest, func(c *gin.Context) bool {
+		return c.GetHeader(middleware.APIKeyHeader) != ""
+	})
+}
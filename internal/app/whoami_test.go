@@ -0,0 +1,192 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+var whoamiHeaders = []string{
+	middleware.DebugSubjectHeader,
+	middleware.DebugRolesHeader,
+	middleware.DebugPermissionCheckHeader,
+	middleware.DebugMatchedRouteHeader,
+}
+
+// newWhoamiApp returns an App with RBAC in mode, where user 1 has the
+// viewer role (users:read) and admin:read and user 2 has nothing, plus a
+// function serving a request as userID ("" for anonymous), with the
+// X-Debug-Whoami header when whoami is set.
+func newWhoamiApp(t *testing.T, mode string) func(method, path, userID string, whoami bool) *httptest.ResponseRecorder {
+	t.Helper()
+	a, err := New(testutil.NewTestConfig(testutil.WithRBAC(), testutil.WithMode(mode), testutil.WithSQLitePath(testutil.MemoryDSN(t)), func(c *config.Config) {
+		c.Server.CSRFSecret = "Release-CSRF-secret-0123456789-abcdef"
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { cleanupTestApp(t, a) })
+	testutil.Migrate(t, a.db)
+	testutil.SeedUsers(t, a.db,
+		domain.User{Name: "Alice", Email: "alice@example.com"},
+		domain.User{Name: "Bob", Email: "bob@example.com"},
+	)
+	svc := a.rbacService
+	if err := svc.CreateRole("viewer", "Viewer", ""); err != nil {
+		t.Fatalf("CreateRole() error = %v", err)
+	}
+	if err := svc.AddRolePermission("viewer", "users", "read"); err != nil {
+		t.Fatalf("AddRolePermission() error = %v", err)
+	}
+	if err := svc.AssignRole("1", "viewer"); err != nil {
+		t.Fatalf("AssignRole() error = %v", err)
+	}
+	if err := svc.AddUserPermissions("1", "admin", []string{"read"}); err != nil {
+		t.Fatalf("AddUserPermissions() error = %v", err)
+	}
+
+	return func(method, path, userID string, whoami bool) *httptest.ResponseRecorder {
+		req := testutil.NewJSONRequest(t, method, path, nil)
+		if userID != "" {
+			token, err := a.jwtService.GenerateToken(userID, nil, time.Hour)
+			if err != nil {
+				t.Fatalf("GenerateToken() error = %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if whoami {
+			req.Header.Set(middleware.WhoamiHeader, "1")
+		}
+		return testutil.Serve(a.Handler(), req)
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	serve := newWhoamiApp(t, gin.TestMode)
+
+	tests := []struct {
+		name                  string
+		method, path, userID  string
+		subject, roles, check string
+		route                 string
+		status                int
+	}{
+		{"authenticated", http.MethodGet, "/api/v1/users", "1", "1", "viewer", "users:read=granted", "/api/v1/users", http.StatusOK},
+		{"anonymous", http.MethodGet, "/api/v1/users", "", "anonymous", "none", "users:read=denied", "/api/v1/users", http.StatusUnauthorized},
+		{"public path", http.MethodGet, "/health", "", "anonymous", "none", "none", "/health", http.StatusOK},
+		{"denied", http.MethodDelete, "/api/v1/users/1", "2", "2", "none", "users:delete=denied", "/api/v1/users/:id", http.StatusForbidden},
+		{"self", http.MethodGet, "/api/v1/users/2", "2", "2", "none", "users:read=granted (self)", "/api/v1/users/:id", http.StatusOK},
+		{"unmatched", http.MethodGet, "/api/v1/nothing-here", "1", "1", "viewer", "none", "none", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.method, tt.path, tt.userID, true)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			h := w.Header()
+			for name, want := range map[string]string{
+				middleware.DebugSubjectHeader:         tt.subject,
+				middleware.DebugRolesHeader:           tt.roles,
+				middleware.DebugPermissionCheckHeader: tt.check,
+				middleware.DebugMatchedRouteHeader:    tt.route,
+			} {
+				if got := h.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+
+	h := serve(http.MethodGet, "/api/v1/users", "1", false).Header()
+	for _, name := range whoamiHeaders {
+		if got := h.Get(name); got != "" {
+			t.Errorf("without %s: %s = %q, want none", middleware.WhoamiHeader, name, got)
+		}
+	}
+}
+
+func TestWhoami_ReleaseRequiresAdmin(t *testing.T) {
+	serve := newWhoamiApp(t, gin.ReleaseMode)
+
+	for _, userID := range []string{"", "2"} {
+		h := serve(http.MethodGet, "/api/v1/users", userID, true).Header()
+		for _, name := range whoamiHeaders {
+			if got := h.Get(name); got != "" {
+				t.Errorf("release, user %q: %s = %q, want none", userID, name, got)
+			}
+		}
+	}
+	if h := serve(http.MethodGet, "/api/v1/users", "1", true).Header(); h.Get(middleware.DebugSubjectHeader) != "1" {
+		t.Errorf("release, admin: %s = %q, want 1", middleware.DebugSubjectHeader, h.Get(middleware.DebugSubjectHeader))
+	}
+}
+
+// TestWhoami_Cache checks that X-Debug-Whoami requests skip the response
+// cache only where whoami explains them: in release mode without RBAC
+// there is no whoami, and they are cached like any other.
+func TestWhoami_Cache(t *testing.T) {
+	for mode, wantCached := range map[string]bool{gin.TestMode: false, gin.ReleaseMode: true} {
+		t.Run(mode, func(t *testing.T) {
+			dsn := testutil.MemoryDSN(t)
+			db := testutil.OpenTestDB(t, dsn)
+			testutil.SeedUsers(t, db, domain.User{})
+			a, err := New(testutil.NewTestConfig(testutil.WithMode(mode), testutil.WithSQLitePath(dsn), func(c *config.Config) {
+				c.Server.CSRFSecret = "Release-CSRF-secret-0123456789-abcdef"
+				c.Server.Cache = config.CacheConfig{Enabled: true, TTL: config.Duration(time.Hour), MaxSize: 100}
+			}))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			t.Cleanup(func() { cleanupTestApp(t, a) })
+
+			get := func() string {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+				req.Header.Set(middleware.WhoamiHeader, "1")
+				w := testutil.Serve(a.engine, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("GET /api/v1/users status = %d, want 200", w.Code)
+				}
+				return w.Body.String()
+			}
+			before := get()
+			// Written behind the app's back, so nothing purges the entry.
+			testutil.SeedUsers(t, db, domain.User{Name: "Late User", Email: "late@example.com"})
+			if cached := get() == before; cached != wantCached {
+				t.Errorf("second X-Debug-Whoami GET cached = %v, want %v", cached, wantCached)
+			}
+		})
+	}
+}
+
+func TestWhoamiUncacheable(t *testing.T) {
+	if whoamiUncacheable(gin.ReleaseMode, false) != nil {
+		t.Error("release mode without RBAC: condition set, want nil (no whoami)")
+	}
+	cond := whoamiUncacheable(gin.ReleaseMode, true)
+	for name, tt := range map[string]struct {
+		header string
+		want   bool
+	}{
+		"anonymous": {"", false},
+		"api key":   {middleware.APIKeyHeader, true},
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		c.Request.Header.Set(middleware.WhoamiHeader, "1")
+		if tt.header != "" {
+			c.Request.Header.Set(tt.header, "key")
+		}
+		if got := cond(c); got != tt.want {
+			t.Errorf("release mode with RBAC, %s: uncacheable = %v, want %v", name, got, tt.want)
+		}
+	}
+}
@@ -1,12 +1,23 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
@@ -19,6 +30,115 @@ type Config struct {
 	Database DatabaseConfig `koanf:"database"`
 	Log      LogConfig      `koanf:"log"`
 	Auth     AuthConfig     `koanf:"auth"`
+	Tenancy  TenancyConfig  `koanf:"tenancy"`
+	// Features maps feature flag names (lowercase snake_case) to whether
+	// they are on; flags missing from the map are off.
+	Features map[string]bool `koanf:"features"`
+	// StrictKeys makes Load fail on configuration keys that match no Config
+	// field (typically typos) instead of only logging a warning.
+	StrictKeys bool `koanf:"strict_keys"`
+	// DevSecretsFile is where Load keeps the secrets it generates for
+	// unset ones outside release mode (default DefaultDevSecretsFile).
+	DevSecretsFile string `koanf:"dev_secrets_file"`
+
+	devSecrets []string // keys Load took from DevSecretsFile
+}
+
+// TenancyConfig controls per-tenant data scoping. When enabled, every
+// request outside the health check and static assets must name a tenant,
+// and models embedding domain.TenantScoped only see and create rows of it.
+type TenancyConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Resolver is how a request's tenant is found: TenantResolverHeader
+	// (default) reads Header, TenantResolverSubdomain takes the first label
+	// of a host one level below the host of server.base_url.
+	Resolver string `koanf:"resolver"`
+	// Header carries the tenant ID for TenantResolverHeader (default
+	// DefaultTenantHeader).
+	Header string `koanf:"header"`
+}
+
+// tenancy.resolver values.
+const (
+	TenantResolverHeader    = "header"
+	TenantResolverSubdomain = "subdomain"
+)
+
+// DefaultTenantHeader is tenancy.header when unset.
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// EffectiveHeader returns Header, or DefaultTenantHeader when it is unset.
+func (t TenancyConfig) EffectiveHeader() string {
+	if t.Header == "" {
+		return DefaultTenantHeader
+	}
+	return t.Header
+}
+
+// featureNamePattern is the required form of feature flag names.
+var featureNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// localePattern is the required form of server.locales entries: a BCP 47
+// language subtag followed by optional region, script or variant subtags.
+var localePattern = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// tablePrefixPattern is the required form of database.table_prefix.
+var tablePrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// maxTablePrefixLength keeps prefixed names well inside identifier limits
+// (63 bytes in PostgreSQL).
+const maxTablePrefixLength = 20
+
+// headerNamePattern matches HTTP header names (RFC 9110 tokens).
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// hostnamePattern matches lower-case DNS host names.
+var hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// NormalizeAllowedHost returns a server.allowed_hosts entry lower-cased,
+// without surrounding space, a trailing dot or IPv6 brackets. An entry is a
+// host name, an IP address, or "*." followed by a host name, which matches
+// its subdomains but not the name itself.
+func NormalizeAllowedHost(entry string) (string, error) {
+	host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry)), ".")
+	if name, ok := strings.CutPrefix(host, "*."); ok {
+		if !hostnamePattern.MatchString(name) {
+			return "", errors.New("a wildcard must be followed by a host name")
+		}
+		return host, nil
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")); err == nil {
+		return addr.Unmap().String(), nil
+	}
+	if !hostnamePattern.MatchString(host) {
+		return "", errors.New("must be a host name or IP address without scheme, port or path")
+	}
+	return host, nil
+}
+
+// ParseTrustedProxies parses server.trusted_proxies entries, each an IP
+// address or a CIDR range, into prefixes; a lone address becomes a /32 or
+// /128.
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
 }
 
 // ServerConfig holds HTTP server settings.
@@ -27,19 +147,535 @@ type ServerConfig struct {
 	Port       int             `koanf:"port"`
 	Mode       string          `koanf:"mode"`
 	CSRFSecret string          `koanf:"csrf_secret"`
-	Timeout    string          `koanf:"timeout"`
+	Timeout    Duration        `koanf:"timeout"`
 	CORS       CORSConfig      `koanf:"cors"`
 	RateLimit  RateLimitConfig `koanf:"rate_limit"`
 	Cache      CacheConfig     `koanf:"cache"`
+	API        APIConfig       `koanf:"api"`
+	// TrustedProxies lists the proxy IPs or CIDR ranges whose
+	// X-Forwarded-For gin believes for ClientIP, and from which
+	// request_id.accept_incoming takes inbound IDs. Unset keeps gin's
+	// default for ClientIP but trusts no one with request IDs.
+	TrustedProxies []string `koanf:"trusted_proxies"`
+	// AllowedHosts lists the Host header values requests may carry (see
+	// NormalizeAllowedHost); others are rejected before routing, so URLs
+	// built from the Host cannot be poisoned. Unset accepts any host.
+	AllowedHosts []string `koanf:"allowed_hosts"`
+	// Locales lists the language tags the app serves, the default first
+	// (default DefaultLocales). middleware.Locale picks one per request
+	// from ?lang and Accept-Language.
+	Locales   []string        `koanf:"locales"`
+	RequestID RequestIDConfig `koanf:"request_id"`
+	Static    StaticConfig    `koanf:"static"`
+	// MaintenanceInterval is how often expired entries are purged from the
+	// response cache and idempotency store (default 5m).
+	MaintenanceInterval Duration        `koanf:"maintenance_interval"`
+	Templates           TemplatesConfig `koanf:"templates"`
+	Health              HealthConfig    `koanf:"health"`
+	Meta                MetaConfig      `koanf:"meta"`
+	Branding            BrandingConfig  `koanf:"branding"`
+	// BaseURL is the public origin of the site, e.g.
+	// "https://example.com", for absolute URLs that cannot be taken from a
+	// request (the sitemap). Required when Sitemap is enabled.
+	BaseURL string        `koanf:"base_url"`
+	Sitemap SitemapConfig `koanf:"sitemap"`
+	// HeadRequests is how HEAD requests are answered: HeadRequestsGet
+	// (default) serves them through the GET route without a body,
+	// HeadRequestsReject leaves gin's 405.
+	HeadRequests string `koanf:"head_requests"`
+	// AdminRuntimeConfig enables the admin API that changes the rate limit,
+	// response cache, log level and maintenance job of a running App.
+	AdminRuntimeConfig AdminRuntimeConfig  `koanf:"admin_runtime_config"`
+	WebSocket          WebSocketConfig     `koanf:"websocket"`
+	ControlSocket      ControlSocketConfig `koanf:"control_socket"`
+	HTTP               HTTPConfig          `koanf:"http"`
+	ErrorRates         ErrorRatesConfig    `koanf:"error_rates"`
+}
+
+// HTTPConfig holds the timeouts of the http.Server App.Run starts. Unset
+// ones keep the Default*Timeout values below.
+type HTTPConfig struct {
+	ReadTimeout       Duration `koanf:"read_timeout"`
+	WriteTimeout      Duration `koanf:"write_timeout"`
+	IdleTimeout       Duration `koanf:"idle_timeout"`
+	ReadHeaderTimeout Duration `koanf:"read_header_timeout"`
+	// LongTransferPaths lists the request paths whose connection read and
+	// write deadlines are extended to LongTransferTimeout, for uploads and
+	// downloads that outlast ReadTimeout and WriteTimeout. An entry ending
+	// in "/" matches every path below it; others match exactly. These paths
+	// also skip server.timeout, which buffers the whole response.
+	LongTransferPaths []string `koanf:"long_transfer_paths"`
+	// LongTransferTimeout bounds a request to LongTransferPaths, from when
+	// it is routed (default DefaultLongTransferTimeout).
+	LongTransferTimeout Duration `koanf:"long_transfer_timeout"`
+}
+
+// Defaults for server.http timeouts left unset.
+const (
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 60 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultReadHeaderTimeout = 10 * time.Second
+	// DefaultLongTransferTimeout is the server.http.long_transfer_timeout
+	// default.
+	DefaultLongTransferTimeout = 30 * time.Minute
+)
+
+// EffectiveReadTimeout returns ReadTimeout, or DefaultReadTimeout when it
+// is unset.
+func (h HTTPConfig) EffectiveReadTimeout() time.Duration {
+	if h.ReadTimeout == 0 {
+		return DefaultReadTimeout
+	}
+	return h.ReadTimeout.Std()
+}
+
+// EffectiveWriteTimeout returns WriteTimeout, or DefaultWriteTimeout when
+// it is unset.
+func (h HTTPConfig) EffectiveWriteTimeout() time.Duration {
+	if h.WriteTimeout == 0 {
+		return DefaultWriteTimeout
+	}
+	return h.WriteTimeout.Std()
+}
+
+// EffectiveIdleTimeout returns IdleTimeout, or DefaultIdleTimeout when it
+// is unset.
+func (h HTTPConfig) EffectiveIdleTimeout() time.Duration {
+	if h.IdleTimeout == 0 {
+		return DefaultIdleTimeout
+	}
+	return h.IdleTimeout.Std()
+}
+
+// EffectiveReadHeaderTimeout returns ReadHeaderTimeout, or
+// DefaultReadHeaderTimeout when it is unset.
+func (h HTTPConfig) EffectiveReadHeaderTimeout() time.Duration {
+	if h.ReadHeaderTimeout == 0 {
+		return DefaultReadHeaderTimeout
+	}
+	return h.ReadHeaderTimeout.Std()
+}
+
+// EffectiveLongTransferTimeout returns LongTransferTimeout, or
+// DefaultLongTransferTimeout when it is unset.
+func (h HTTPConfig) EffectiveLongTransferTimeout() time.Duration {
+	if h.LongTransferTimeout == 0 {
+		return DefaultLongTransferTimeout
+	}
+	return h.LongTransferTimeout.Std()
+}
+
+// ErrorRatesConfig controls the per-route error rates reported at GET
+// /debug/error-rates: every request's outcome is counted against its route
+// template over a rolling window, and a warning is logged when a route's
+// share of 5xx responses rises above WarnRatio.
+type ErrorRatesConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Window is how far back the rates look (default
+	// DefaultErrorRateWindow, at least 30s); it is counted in 30 buckets.
+	Window Duration `koanf:"window"`
+	// WarnRatio is the share of 5xx responses, between 0 and 1, above
+	// which a route is logged (default DefaultErrorRateWarnRatio).
+	WarnRatio float64 `koanf:"warn_ratio"`
+	// MinRequests is how many requests a route needs in the window before
+	// it is logged (default DefaultErrorRateMinRequests).
+	MinRequests int `koanf:"min_requests"`
+}
+
+// Defaults for server.error_rates settings left unset.
+const (
+	DefaultErrorRateWindow      = 5 * time.Minute
+	DefaultErrorRateWarnRatio   = 0.1
+	DefaultErrorRateMinRequests = 20
+)
+
+// EffectiveWindow returns Window, or DefaultErrorRateWindow when it is
+// unset.
+func (e ErrorRatesConfig) EffectiveWindow() time.Duration {
+	if e.Window == 0 {
+		return DefaultErrorRateWindow
+	}
+	return e.Window.Std()
+}
+
+// EffectiveWarnRatio returns WarnRatio, or DefaultErrorRateWarnRatio when
+// it is unset.
+func (e ErrorRatesConfig) EffectiveWarnRatio() float64 {
+	if e.WarnRatio == 0 {
+		return DefaultErrorRateWarnRatio
+	}
+	return e.WarnRatio
+}
+
+// EffectiveMinRequests returns MinRequests, or
+// DefaultErrorRateMinRequests when it is unset.
+func (e ErrorRatesConfig) EffectiveMinRequests() int {
+	if e.MinRequests == 0 {
+		return DefaultErrorRateMinRequests
+	}
+	return e.MinRequests
+}
+
+// ControlSocketConfig controls the local admin command API that App.Run
+// serves on a unix socket for `server -ctl`. It is never served on TCP and
+// has no auth beyond the socket's 0600 file mode.
+type ControlSocketConfig struct {
+	// Path of the unix socket; empty disables the control socket. A stale
+	// socket left at Path by a crashed process is replaced.
+	Path string `koanf:"path"`
+}
+
+// maxControlSocketPath is the longest unix socket path every supported
+// platform accepts (sun_path is 104 bytes on macOS, 108 on Linux).
+const maxControlSocketPath = 103
+
+// WebSocketConfig controls GET /ws, the WebSocket stream of live user
+// events. It requires auth.enabled, since every connection authenticates
+// with a JWT.
+type WebSocketConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// PingInterval is how often each connection is pinged; one that does
+	// not answer before the next ping is closed (default
+	// DefaultWebSocketPingInterval).
+	PingInterval Duration `koanf:"ping_interval"`
+	// SendBuffer is how many events may wait for a connection; a client
+	// that falls further behind is disconnected (default
+	// DefaultWebSocketSendBuffer).
+	SendBuffer int `koanf:"send_buffer"`
+}
+
+// Defaults for server.websocket settings left unset.
+const (
+	DefaultWebSocketPingInterval = 30 * time.Second
+	DefaultWebSocketSendBuffer   = 16
+)
+
+// EffectivePingInterval returns PingInterval, or
+// DefaultWebSocketPingInterval when it is unset.
+func (w WebSocketConfig) EffectivePingInterval() time.Duration {
+	if w.PingInterval == 0 {
+		return DefaultWebSocketPingInterval
+	}
+	return w.PingInterval.Std()
+}
+
+// EffectiveSendBuffer returns SendBuffer, or DefaultWebSocketSendBuffer
+// when it is unset.
+func (w WebSocketConfig) EffectiveSendBuffer() int {
+	if w.SendBuffer == 0 {
+		return DefaultWebSocketSendBuffer
+	}
+	return w.SendBuffer
+}
+
+// AdminRuntimeConfig controls GET/PUT /api/v1/admin/runtime-config. It
+// requires auth.rbac.enabled, so only admins reach it.
+type AdminRuntimeConfig struct {
+	Enabled bool `koanf:"enabled"`
+}
+
+// MetaConfig controls the site metadata files served at /robots.txt and
+// /.well-known/security.txt.
+type MetaConfig struct {
+	// RobotsDisallow lists the path prefixes robots.txt disallows for every
+	// crawler (default DefaultRobotsDisallow); an empty list allows all.
+	RobotsDisallow []string `koanf:"robots_disallow"`
+	// SecurityContact is the security.txt Contact, a mailto:, https: or
+	// tel: URI. Without it /.well-known/security.txt answers 404.
+	SecurityContact string `koanf:"security_contact"`
+	// SecurityExpires is the security.txt Expires date in RFC 3339 and must
+	// lie in the future (default one year after startup).
+	SecurityExpires string `koanf:"security_expires"`
+}
+
+// server.head_requests values.
+const (
+	HeadRequestsGet    = "get"
+	HeadRequestsReject = "reject"
+)
+
+// SitemapConfig controls /sitemap.xml, which lists the public pages the
+// modules declare.
+type SitemapConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// CacheTTL is how long a generated document is served before the
+	// entries are collected again (default DefaultSitemapCacheTTL).
+	CacheTTL Duration `koanf:"cache_ttl"`
+}
+
+// DefaultSitemapCacheTTL is the sitemap cache duration when
+// server.sitemap.cache_ttl is unset.
+const DefaultSitemapCacheTTL = time.Hour
+
+// EffectiveCacheTTL returns CacheTTL, or DefaultSitemapCacheTTL when it is
+// unset.
+func (s SitemapConfig) EffectiveCacheTTL() time.Duration {
+	if s.CacheTTL == 0 {
+		return DefaultSitemapCacheTTL
+	}
+	return s.CacheTTL.Std()
+}
+
+// DefaultRobotsDisallow is what robots.txt disallows when
+// server.meta.robots_disallow is unset.
+var DefaultRobotsDisallow = []string{"/api", "/admin"}
+
+// Disallow returns RobotsDisallow, or DefaultRobotsDisallow when it is
+// unset.
+func (m MetaConfig) Disallow() []string {
+	if m.RobotsDisallow == nil {
+		return DefaultRobotsDisallow
+	}
+	return m.RobotsDisallow
+}
+
+// BrandingConfig names the site and sets the colors of the web app
+// manifest served at /site.webmanifest and of the page theme-color.
+type BrandingConfig struct {
+	// Name is the site name in the manifest and page titles (default
+	// DefaultBrandingName).
+	Name string `koanf:"name"`
+	// ShortName is the home screen label (default Name).
+	ShortName string `koanf:"short_name"`
+	// ThemeColor and BackgroundColor are hex colors, "#rgb" or "#rrggbb"
+	// (default DefaultThemeColor and DefaultBackgroundColor).
+	ThemeColor      string `koanf:"theme_color"`
+	BackgroundColor string `koanf:"background_color"`
+}
+
+// Branding defaults, matching the indigo and gray of the built-in pages.
+const (
+	DefaultBrandingName    = "GoBase"
+	DefaultThemeColor      = "#4f46e5"
+	DefaultBackgroundColor = "#f9fafb"
+)
+
+// EffectiveName returns Name, or DefaultBrandingName when it is unset.
+func (b BrandingConfig) EffectiveName() string {
+	if b.Name == "" {
+		return DefaultBrandingName
+	}
+	return b.Name
+}
+
+// EffectiveShortName returns ShortName, or the effective Name when it is
+// unset.
+func (b BrandingConfig) EffectiveShortName() string {
+	if b.ShortName == "" {
+		return b.EffectiveName()
+	}
+	return b.ShortName
+}
+
+// EffectiveThemeColor returns ThemeColor, or DefaultThemeColor when it is
+// unset.
+func (b BrandingConfig) EffectiveThemeColor() string {
+	if b.ThemeColor == "" {
+		return DefaultThemeColor
+	}
+	return b.ThemeColor
+}
+
+// EffectiveBackgroundColor returns BackgroundColor, or
+// DefaultBackgroundColor when it is unset.
+func (b BrandingConfig) EffectiveBackgroundColor() string {
+	if b.BackgroundColor == "" {
+		return DefaultBackgroundColor
+	}
+	return b.BackgroundColor
+}
+
+// hexColorPattern matches the colors BrandingConfig accepts.
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// DefaultLocales is what server.locales falls back to when unset.
+var DefaultLocales = []string{"zh-CN", "en"}
+
+// EffectiveLocales returns Locales, or DefaultLocales when it is unset.
+func (s ServerConfig) EffectiveLocales() []string {
+	if len(s.Locales) == 0 {
+		return DefaultLocales
+	}
+	return s.Locales
+}
+
+// HealthConfig controls the health check endpoint.
+type HealthConfig struct {
+	// Path is where the endpoint is served (default "/health").
+	Path string `koanf:"path"`
+	// Token, when set, must be sent as "Authorization: Bearer <token>" or
+	// as the token query parameter; any other request is answered 404 so
+	// the endpoint's existence is not disclosed.
+	Token string `koanf:"token"`
+	// ExposeDetails reports the per-component breakdown and the last
+	// maintenance run (default true); false reduces the body to the status.
+	ExposeDetails *bool `koanf:"expose_details"`
+	// Timeout bounds each component check (default
+	// DefaultHealthCheckTimeout); the checks run concurrently.
+	Timeout Duration `koanf:"timeout"`
+}
+
+// DefaultHealthCheckTimeout is how long each health check component may
+// take when server.health.timeout is unset.
+const DefaultHealthCheckTimeout = time.Second
+
+// DefaultHealthPath is where the health check is served when
+// server.health.path is unset.
+const DefaultHealthPath = "/health"
+
+// EffectivePath returns Path, or DefaultHealthPath when it is unset.
+func (h HealthConfig) EffectivePath() string {
+	if h.Path == "" {
+		return DefaultHealthPath
+	}
+	return h.Path
+}
+
+// EffectiveTimeout returns Timeout, or DefaultHealthCheckTimeout when it is
+// unset.
+func (h HealthConfig) EffectiveTimeout() time.Duration {
+	if h.Timeout == 0 {
+		return DefaultHealthCheckTimeout
+	}
+	return h.Timeout.Std()
+}
+
+// Details reports whether the health check exposes its component
+// breakdown.
+func (h HealthConfig) Details() bool {
+	return h.ExposeDetails == nil || *h.ExposeDetails
+}
+
+// TemplatesConfig controls HTML template rendering.
+type TemplatesConfig struct {
+	// SlowRenderThreshold is the render duration above which a template
+	// execution is logged as a warning (default 200ms).
+	SlowRenderThreshold Duration `koanf:"slow_render_threshold"`
+	// LocaleDir is a directory of message bundles named after the locale
+	// (en.json, zh-CN.json) for the templates' t helper; empty disables
+	// translation.
+	LocaleDir string `koanf:"locale_dir"`
+	// OverrideDir is a directory laid out like web/ whose files replace
+	// the built-in ones of the same path: templates/ (layouts, partials
+	// and pages) and static/ (the embedded static mount). Empty uses the
+	// built-in files only.
+	OverrideDir string `koanf:"override_dir"`
+	// WatchInterval is how often debug mode polls the web directory and
+	// LocaleDir for changes to reload (default DefaultTemplateWatchInterval).
+	// Nothing is watched in other modes.
+	WatchInterval Duration `koanf:"watch_interval"`
+	// Strict fails a render that reads a map key its data lacks instead of
+	// rendering it empty, answering 500 with the template and key. It is
+	// ignored in release mode, which keeps the forgiving behavior.
+	Strict bool `koanf:"strict"`
+}
+
+// DefaultTemplateWatchInterval is how often debug mode polls for changed
+// templates, asset manifest and locale bundles when no interval is set.
+const DefaultTemplateWatchInterval = time.Second
+
+// EffectiveWatchInterval returns WatchInterval, or
+// DefaultTemplateWatchInterval when it is unset.
+func (t TemplatesConfig) EffectiveWatchInterval() time.Duration {
+	if t.WatchInterval == 0 {
+		return DefaultTemplateWatchInterval
+	}
+	return t.WatchInterval.Std()
+}
+
+// StaticEmbedded is the StaticMount.Dir value that serves the assets built
+// into the binary (web/static, read from disk in debug mode for hot reload).
+const StaticEmbedded = "embedded"
+
+// StaticConfig controls static asset serving.
+type StaticConfig struct {
+	// Mounts replaces the default mount, which serves StaticEmbedded at
+	// /static with a one-day max-age (none in debug mode).
+	Mounts []StaticMount `koanf:"mounts"`
+}
+
+// StaticMount serves one directory under a URL prefix.
+type StaticMount struct {
+	// URLPrefix is where the files appear, e.g. "/app"; it must not be or
+	// lie under /api, nor collide with page routes.
+	URLPrefix string `koanf:"url_prefix"`
+	// Dir is a directory on disk, or StaticEmbedded.
+	Dir string `koanf:"dir"`
+	// CacheMaxAge sets "Cache-Control: public, max-age=..." on responses;
+	// zero sends no Cache-Control.
+	CacheMaxAge Duration `koanf:"cache_max_age"`
+}
+
+// RequestIDConfig controls where request IDs come from.
+type RequestIDConfig struct {
+	// AcceptIncoming propagates the ID an upstream gateway sent in
+	// TrustedHeader, when the request comes from server.trusted_proxies,
+	// instead of generating a new one.
+	AcceptIncoming bool `koanf:"accept_incoming"`
+	// TrustedHeader is the inbound header read (default X-Request-ID). The
+	// response always carries the ID in X-Request-ID.
+	TrustedHeader string `koanf:"trusted_header"`
 }
 
 // CORSConfig holds CORS middleware settings.
 type CORSConfig struct {
+	// Preset names a canned policy that Validate expands into the fields
+	// below, replacing what they held (see ApplyPreset). CORSPresetCustom
+	// keeps the fields but requires AllowOrigins. Unset also keeps them,
+	// and outside release mode an empty AllowOrigins then allows any
+	// origin.
+	Preset           string   `koanf:"preset"`
 	AllowOrigins     []string `koanf:"allow_origins"`
 	AllowMethods     []string `koanf:"allow_methods"`
 	AllowHeaders     []string `koanf:"allow_headers"`
 	AllowCredentials bool     `koanf:"allow_credentials"`
-	MaxAge           string   `koanf:"max_age"`
+	MaxAge           Duration `koanf:"max_age"`
+}
+
+// server.cors.preset values.
+const (
+	// CORSPresetDisabled installs no CORS middleware: responses carry no
+	// CORS headers and preflights are routed like any other request.
+	CORSPresetDisabled = "disabled"
+	// CORSPresetSameOrigin allows no cross-origin requests, except from
+	// server.base_url when it is set.
+	CORSPresetSameOrigin = "same-origin"
+	// CORSPresetDevPermissive allows any origin without credentials, for
+	// local front-end development. Release mode rejects it.
+	CORSPresetDevPermissive = "dev-permissive"
+	// CORSPresetCustom uses the configured fields as they are.
+	CORSPresetCustom = "custom"
+)
+
+// DevPermissiveCORSMethods and DevPermissiveCORSHeaders are what
+// CORSPresetDevPermissive allows.
+var (
+	DevPermissiveCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	DevPermissiveCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key"}
+)
+
+// ApplyPreset replaces the fields of c with the settings of its Preset;
+// baseURL is the normalized server.base_url. Unset and CORSPresetCustom
+// presets leave c unchanged.
+func (c *CORSConfig) ApplyPreset(baseURL string) {
+	switch c.Preset {
+	case CORSPresetDisabled:
+		*c = CORSConfig{Preset: c.Preset}
+	case CORSPresetSameOrigin:
+		expanded := CORSConfig{Preset: c.Preset}
+		if baseURL != "" {
+			expanded.AllowOrigins = []string{baseURL}
+		}
+		*c = expanded
+	case CORSPresetDevPermissive:
+		*c = CORSConfig{
+			Preset:       c.Preset,
+			AllowOrigins: []string{"*"},
+			AllowMethods: slices.Clone(DevPermissiveCORSMethods),
+			AllowHeaders: slices.Clone(DevPermissiveCORSHeaders),
+		}
+	}
 }
 
 // RateLimitConfig holds rate limiting settings.
@@ -47,13 +683,96 @@ type RateLimitConfig struct {
 	Enabled bool    `koanf:"enabled"`
 	RPS     float64 `koanf:"rps"`
 	Burst   int     `koanf:"burst"`
+	// Overrides gives authenticated subjects stored limits of their own in
+	// place of RPS and Burst.
+	Overrides RateLimitOverridesConfig `koanf:"overrides"`
+}
+
+// RateLimitOverridesConfig controls the rate_limit_overrides table and its
+// admin API under /api/v1/admin/rate-limits. It requires
+// server.rate_limit.enabled and auth.rbac.enabled.
+type RateLimitOverridesConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// RefreshInterval is how often the overrides are reloaded from the
+	// database (default DefaultRateLimitOverridesRefresh), so changes made
+	// elsewhere apply within it.
+	RefreshInterval Duration `koanf:"refresh_interval"`
+}
+
+// DefaultRateLimitOverridesRefresh is how often rate limit overrides are
+// reloaded when no interval is set.
+const DefaultRateLimitOverridesRefresh = 30 * time.Second
+
+// EffectiveRefreshInterval returns RefreshInterval, or
+// DefaultRateLimitOverridesRefresh when it is unset.
+func (o RateLimitOverridesConfig) EffectiveRefreshInterval() time.Duration {
+	if o.RefreshInterval == 0 {
+		return DefaultRateLimitOverridesRefresh
+	}
+	return o.RefreshInterval.Std()
 }
 
 // CacheConfig holds HTTP response caching settings.
 type CacheConfig struct {
-	Enabled bool   `koanf:"enabled"`
-	TTL     string `koanf:"ttl"`
-	MaxSize int    `koanf:"max_size"`
+	Enabled bool     `koanf:"enabled"`
+	TTL     Duration `koanf:"ttl"`
+	// StaleTTL is how long past TTL an entry may still be served, marked
+	// X-Cache: STALE, while one background request refreshes it. Unset
+	// disables stale serving: entries expire at TTL.
+	StaleTTL Duration `koanf:"stale_ttl"`
+	MaxSize  int      `koanf:"max_size"`
+	// PageTTL caches the user list page's users for anonymous visitors for
+	// this long (keep it short; writes purge them sooner). Unset caches no
+	// HTML page data.
+	PageTTL Duration `koanf:"page_ttl"`
+	// Warm lists GET /api requests replayed against the app after startup
+	// and after a write purges their prefix, so the first real request is a
+	// cache hit.
+	Warm []CacheWarmTarget `koanf:"warm"`
+	// WarmBudget caps how long startup waits for warming (default 5s);
+	// targets not reached by then are skipped.
+	WarmBudget Duration `koanf:"warm_budget"`
+	// SingleFlightWait caps how long an identical concurrent request waits
+	// for the in-flight one before running on its own (default 5s).
+	SingleFlightWait Duration `koanf:"singleflight_wait"`
+	// VaryHeaders lists request headers whose values are part of the cache
+	// key, e.g. Accept-Language for localized responses, so requests that
+	// differ in them never share an entry. The query string always is.
+	VaryHeaders []string `koanf:"vary_headers"`
+}
+
+// CacheWarmTarget is one request replayed by cache warming.
+type CacheWarmTarget struct {
+	Path  string `koanf:"path"`
+	Query string `koanf:"query"`
+}
+
+// APIConfig holds settings that apply to the /api route group.
+type APIConfig struct {
+	Idempotency IdempotencyConfig `koanf:"idempotency"`
+	// CaseInsensitivePaths redirects mixed-case /api paths that match no
+	// route (e.g. /API/v1/users) to their lowercase form.
+	CaseInsensitivePaths bool `koanf:"case_insensitive_paths"`
+	// ProblemJSON sends every /api error as RFC 7807 application/problem+json,
+	// not only to clients whose Accept header asks for it.
+	ProblemJSON bool `koanf:"problem_json"`
+	// JSONNaming is the key convention of /api JSON responses: "snake"
+	// (default) or "camel". With "snake", clients still get camelCase by
+	// sending X-JSON-Naming: camel. Request bodies accept both either way.
+	JSONNaming string `koanf:"json_naming"`
+	// MaxJSONDepth, MaxJSONTokens and MaxJSONBytes bound JSON request
+	// bodies bound by pkg.BindAndValidate: deeper, longer or larger bodies
+	// get a 400 before they are decoded. 0 uses pkg.DefaultMaxJSONDepth /
+	// DefaultMaxJSONTokens / DefaultMaxJSONBytes.
+	MaxJSONDepth  int   `koanf:"max_json_depth"`
+	MaxJSONTokens int   `koanf:"max_json_tokens"`
+	MaxJSONBytes  int64 `koanf:"max_json_bytes"`
+}
+
+// IdempotencyConfig holds Idempotency-Key settings for POST/PUT API requests.
+type IdempotencyConfig struct {
+	Enabled bool     `koanf:"enabled"`
+	TTL     Duration `koanf:"ttl"`
 }
 
 // DatabaseConfig holds database connection settings.
@@ -62,13 +781,186 @@ type DatabaseConfig struct {
 	SQLite   SQLiteConfig   `koanf:"sqlite"`
 	Postgres PostgresConfig `koanf:"postgres"`
 	Pool     PoolConfig     `koanf:"pool"`
+	Retry    RetryConfig    `koanf:"retry"`
+	// TablePrefix is prepended to every table name, e.g. "gobase_" makes
+	// "users" "gobase_users", so several apps can share one database.
+	TablePrefix string `koanf:"table_prefix"`
+	// PrepareStmt caches a prepared statement per distinct SQL string and
+	// reuses it (and, on PostgreSQL, its query plan) for later executions.
+	PrepareStmt bool `koanf:"prepare_stmt"`
+	// SkipDefaultTransaction stops GORM from wrapping each single create,
+	// update, or delete in its own transaction. Explicit transactions
+	// (pkg.RetryTx, db.Transaction) are unaffected.
+	SkipDefaultTransaction bool `koanf:"skip_default_transaction"`
+	// LogSlowThreshold is the duration above which GORM logs a query as
+	// slow (default 200ms).
+	LogSlowThreshold Duration `koanf:"log_slow_threshold"`
+	// ExplainSlow additionally logs the query plan of slow queries. It is
+	// only allowed with server.mode debug.
+	ExplainSlow bool `koanf:"explain_slow"`
+	// RepeatedQueryThreshold is how many structurally identical queries
+	// (same SQL, any parameters) one request may run in debug mode before
+	// an N+1 warning is logged (default 5).
+	RepeatedQueryThreshold int `koanf:"repeated_query_threshold"`
+	// Replicas are read-only PostgreSQL servers. SELECTs outside a
+	// transaction are spread over them round-robin; everything else goes to
+	// Postgres. Ignored when Driver is sqlite.
+	Replicas []ReplicaConfig `koanf:"replicas"`
+	// BackupDir is where SQLite snapshots are written by the admin backup
+	// API and the -backup flag. Unset disables both.
+	BackupDir string `koanf:"backup_dir"`
+	// BackupRetention is how many of the newest snapshots are kept; older
+	// ones are removed after each backup. 0 keeps all.
+	BackupRetention int `koanf:"backup_retention"`
+	// SchemaCheck compares the models of every module with the live schema
+	// at startup: SchemaCheckWarn (default) logs what is missing,
+	// SchemaCheckStrict refuses to start, SchemaCheckOff skips the check.
+	SchemaCheck string `koanf:"schema_check"`
+	// ChangeFeedRetention is how long change feed entries (GET
+	// /api/v1/changes) are kept (default DefaultChangeFeedRetention); older
+	// ones are pruned by the maintenance job.
+	ChangeFeedRetention Duration `koanf:"change_feed_retention"`
+	// ChangePayloadMaxSize caps, in bytes, the JSON snapshot stored with
+	// each change feed entry (default DefaultChangePayloadMaxSize); larger
+	// snapshots are replaced by a {"truncated": true} marker.
+	ChangePayloadMaxSize int `koanf:"change_payload_max_size"`
+	// Supervisor pings the primary in the background and fails statements
+	// fast while it is unreachable.
+	Supervisor SupervisorConfig `koanf:"supervisor"`
+	// Wait retries connecting before the migrate and migrate-and-serve
+	// commands migrate, for databases that start together with the app.
+	Wait WaitConfig `koanf:"wait"`
+}
+
+// DefaultChangeFeedRetention is the change feed retention when
+// database.change_feed_retention is unset.
+const DefaultChangeFeedRetention = 30 * 24 * time.Hour
+
+// EffectiveChangeFeedRetention returns ChangeFeedRetention, or
+// DefaultChangeFeedRetention when it is unset.
+func (d *DatabaseConfig) EffectiveChangeFeedRetention() time.Duration {
+	if d.ChangeFeedRetention == 0 {
+		return DefaultChangeFeedRetention
+	}
+	return d.ChangeFeedRetention.Std()
+}
+
+// DefaultChangePayloadMaxSize is the change feed payload cap when
+// database.change_payload_max_size is unset.
+const DefaultChangePayloadMaxSize = 64 << 10
+
+// minChangePayloadMaxSize is the smallest payload cap accepted, which
+// leaves room for the snapshot of an ordinary update.
+const minChangePayloadMaxSize = 1 << 10
+
+// EffectiveChangePayloadMaxSize returns ChangePayloadMaxSize, or
+// DefaultChangePayloadMaxSize when it is unset.
+func (d *DatabaseConfig) EffectiveChangePayloadMaxSize() int {
+	if d.ChangePayloadMaxSize == 0 {
+		return DefaultChangePayloadMaxSize
+	}
+	return d.ChangePayloadMaxSize
+}
+
+// SupervisorConfig controls the database connection supervisor. After
+// FailureThreshold consecutive failed pings the database is marked
+// unavailable: statements fail at once with domain.CodeUnavailable (503)
+// and /health reports it, until a ping succeeds again.
+type SupervisorConfig struct {
+	// Interval between pings, also the timeout of each (default
+	// DefaultSupervisorInterval).
+	Interval Duration `koanf:"interval"`
+	// FailureThreshold is how many pings in a row must fail (default
+	// DefaultSupervisorFailureThreshold).
+	FailureThreshold int `koanf:"failure_threshold"`
+}
+
+// Defaults for database.supervisor settings left unset.
+const (
+	DefaultSupervisorInterval         = 5 * time.Second
+	DefaultSupervisorFailureThreshold = 3
+)
+
+// EffectiveInterval returns Interval, or DefaultSupervisorInterval when it
+// is unset.
+func (s SupervisorConfig) EffectiveInterval() time.Duration {
+	if s.Interval == 0 {
+		return DefaultSupervisorInterval
+	}
+	return s.Interval.Std()
+}
+
+// EffectiveFailureThreshold returns FailureThreshold, or
+// DefaultSupervisorFailureThreshold when it is unset.
+func (s SupervisorConfig) EffectiveFailureThreshold() int {
+	if s.FailureThreshold == 0 {
+		return DefaultSupervisorFailureThreshold
+	}
+	return s.FailureThreshold
+}
+
+// WaitConfig controls how long the migrate commands wait for the database
+// to accept connections.
+type WaitConfig struct {
+	// Timeout is how long to keep retrying; unset (default) tries once.
+	Timeout Duration `koanf:"timeout"`
+	// Interval between attempts (default DefaultWaitInterval).
+	Interval Duration `koanf:"interval"`
+}
+
+// DefaultWaitInterval is database.wait.interval when unset.
+const DefaultWaitInterval = time.Second
+
+// EffectiveInterval returns Interval, or DefaultWaitInterval when it is
+// unset.
+func (w WaitConfig) EffectiveInterval() time.Duration {
+	if w.Interval == 0 {
+		return DefaultWaitInterval
+	}
+	return w.Interval.Std()
 }
 
+// DefaultRepeatedQueryThreshold is database.repeated_query_threshold when
+// unset.
+const DefaultRepeatedQueryThreshold = 5
+
+// EffectiveRepeatedQueryThreshold returns RepeatedQueryThreshold, or
+// DefaultRepeatedQueryThreshold when it is unset.
+func (d DatabaseConfig) EffectiveRepeatedQueryThreshold() int {
+	if d.RepeatedQueryThreshold == 0 {
+		return DefaultRepeatedQueryThreshold
+	}
+	return d.RepeatedQueryThreshold
+}
+
+// database.schema_check values.
+const (
+	SchemaCheckOff    = "off"
+	SchemaCheckWarn   = "warn"
+	SchemaCheckStrict = "strict"
+)
+
 // SQLiteConfig holds SQLite-specific settings.
 type SQLiteConfig struct {
 	Path string `koanf:"path"`
+	// IntegrityCheck is run on an existing database file before it is
+	// opened: SQLiteCheckFull (default, PRAGMA integrity_check),
+	// SQLiteCheckQuick (PRAGMA quick_check, faster on large files) or
+	// SQLiteCheckOff. A file that fails it stops startup.
+	IntegrityCheck string `koanf:"integrity_check"`
+	// AutoRecover moves a file that fails the integrity check aside, with
+	// a timestamp suffix, and starts with a fresh database instead. It is
+	// only allowed with server.mode debug.
+	AutoRecover bool `koanf:"auto_recover"`
 }
 
+// database.sqlite.integrity_check values.
+const (
+	SQLiteCheckOff   = "off"
+	SQLiteCheckQuick = "quick"
+	SQLiteCheckFull  = "full"
+)
+
 // PostgresConfig holds PostgreSQL-specific settings.
 type PostgresConfig struct {
 	Host     string `koanf:"host"`
@@ -79,11 +971,39 @@ type PostgresConfig struct {
 	SSLMode  string `koanf:"sslmode"`
 }
 
+// ReplicaConfig is one entry of database.replicas: the connection fields of
+// the postgres block plus the replica's own pool settings.
+type ReplicaConfig struct {
+	Host     string     `koanf:"host"`
+	Port     int        `koanf:"port"`
+	User     string     `koanf:"user"`
+	Password string     `koanf:"password"`
+	DBName   string     `koanf:"dbname"`
+	SSLMode  string     `koanf:"sslmode"`
+	Pool     PoolConfig `koanf:"pool"`
+}
+
+func (r *ReplicaConfig) postgres() PostgresConfig {
+	return PostgresConfig{Host: r.Host, Port: r.Port, User: r.User, Password: r.Password, DBName: r.DBName, SSLMode: r.SSLMode}
+}
+
+func (r *ReplicaConfig) setPostgres(pg PostgresConfig) {
+	r.Host, r.Port, r.User, r.Password, r.DBName, r.SSLMode = pg.Host, pg.Port, pg.User, pg.Password, pg.DBName, pg.SSLMode
+}
+
 // PoolConfig holds database connection pool settings.
 type PoolConfig struct {
-	MaxIdleConns    int    `koanf:"max_idle_conns"`
-	MaxOpenConns    int    `koanf:"max_open_conns"`
-	ConnMaxLifetime string `koanf:"conn_max_lifetime"`
+	MaxIdleConns    int      `koanf:"max_idle_conns"`
+	MaxOpenConns    int      `koanf:"max_open_conns"`
+	ConnMaxLifetime Duration `koanf:"conn_max_lifetime"`
+}
+
+// RetryConfig holds the retry policy for transactions that fail with a
+// transient lock or serialization error. Attempts counts the first try, so 1
+// disables retries.
+type RetryConfig struct {
+	Attempts   int      `koanf:"attempts"`
+	MaxBackoff Duration `koanf:"max_backoff"`
 }
 
 // LogConfig holds logging settings.
@@ -96,15 +1016,99 @@ type LogConfig struct {
 	RetentionDays   int    `koanf:"retention_days"`
 	MaxBackups      int    `koanf:"max_backups"`
 	CompressRotated *bool  `koanf:"compress_rotated"`
+	// AccessFormat is how completed requests are logged: AccessFormatStructured
+	// (default) as log records in Format, AccessFormatPretty as one aligned,
+	// colored line each for local development. Pretty is rejected in release
+	// mode.
+	AccessFormat string `koanf:"access_format"`
 }
 
+// log.access_format values.
+const (
+	AccessFormatStructured = "structured"
+	AccessFormatPretty     = "pretty"
+)
+
 // AuthConfig holds authentication and authorization settings.
 type AuthConfig struct {
 	Enabled     bool       `koanf:"enabled"`
 	JWTSecret   string     `koanf:"jwt_secret"`
-	TokenExpiry string     `koanf:"token_expiry"`
+	TokenExpiry Duration   `koanf:"token_expiry"`
 	PublicPaths []string   `koanf:"public_paths"`
 	RBAC        RBACConfig `koanf:"rbac"`
+	// JWTSecrets is a signing key ring for secret rotation, used instead of
+	// JWTSecret. Tokens are signed with the primary key; the others only
+	// validate tokens issued before the rotation.
+	JWTSecrets []JWTKeyConfig `koanf:"jwt_secrets"`
+	// RegistrationConflictMode is "explicit" (409 on duplicate email, default)
+	// or "opaque" (generic 200 response that does not reveal existing emails).
+	RegistrationConflictMode string `koanf:"registration_conflict_mode"`
+	// RegistrationMode is "open" (anyone may register, default), "invite"
+	// (a single-use code from POST /api/v1/admin/invites is required; needs
+	// RBAC) or "disabled" (no register route).
+	RegistrationMode string `koanf:"registration_mode"`
+	// APIKeys are static keys for service callers that send X-API-Key
+	// instead of a JWT.
+	APIKeys []APIKeyConfig `koanf:"api_keys"`
+	// BcryptCost is the bcrypt cost of new password hashes (4-31, default
+	// 10). Raising it upgrades existing hashes as their users log in.
+	BcryptCost int `koanf:"bcrypt_cost"`
+	// PasswordAlgorithm is "bcrypt" (default) or "argon2id" for new password
+	// hashes. Hashes of the other algorithm keep verifying and are replaced
+	// on the next successful login.
+	PasswordAlgorithm string `koanf:"password_algorithm"`
+	// LoginHistoryRetention is how long login attempts are kept (default
+	// DefaultLoginHistoryRetention); older ones are pruned by the
+	// maintenance job.
+	LoginHistoryRetention Duration `koanf:"login_history_retention"`
+}
+
+// DefaultLoginHistoryRetention is the login history retention when
+// auth.login_history_retention is unset.
+const DefaultLoginHistoryRetention = 90 * 24 * time.Hour
+
+// EffectiveLoginHistoryRetention returns LoginHistoryRetention, or
+// DefaultLoginHistoryRetention when it is unset.
+func (a *AuthConfig) EffectiveLoginHistoryRetention() time.Duration {
+	if a.LoginHistoryRetention == 0 {
+		return DefaultLoginHistoryRetention
+	}
+	return a.LoginHistoryRetention.Std()
+}
+
+// APIKeyConfig is one entry of auth.api_keys. Only the SHA-256 hash of the
+// key is configured, as 64 hex digits (e.g. from `printf %s "$KEY" |
+// sha256sum`).
+type APIKeyConfig struct {
+	Name    string `koanf:"name"`
+	KeyHash string `koanf:"key_hash"`
+	// Scopes are the "resource:action" permissions the key holds under
+	// RBAC; "*" works as in rbac for either part.
+	Scopes []string `koanf:"scopes"`
+}
+
+// DefaultBcryptCost is the auth.bcrypt_cost used when none is configured. It
+// matches bcrypt.DefaultCost.
+const DefaultBcryptCost = 10
+
+// DefaultJWTKeyID is the key ID given to auth.jwt_secret when it is used as a
+// one-key ring.
+const DefaultJWTKeyID = "default"
+
+// JWTKeyConfig is one entry of auth.jwt_secrets.
+type JWTKeyConfig struct {
+	KID     string `koanf:"kid"`
+	Secret  string `koanf:"secret"`
+	Primary bool   `koanf:"primary"`
+}
+
+// SigningKeys returns the JWT key ring: auth.jwt_secrets when set, otherwise
+// auth.jwt_secret as a single primary key with ID DefaultJWTKeyID.
+func (a *AuthConfig) SigningKeys() []JWTKeyConfig {
+	if len(a.JWTSecrets) > 0 {
+		return a.JWTSecrets
+	}
+	return []JWTKeyConfig{{KID: DefaultJWTKeyID, Secret: a.JWTSecret, Primary: true}}
 }
 
 // RBACConfig holds role-based access control settings.
@@ -115,12 +1119,12 @@ type RBACConfig struct {
 
 // RBACCacheConfig holds RBAC cache tuning parameters.
 type RBACCacheConfig struct {
-	RoleTTL              string `koanf:"role_ttl"`
-	UserRoleTTL          string `koanf:"user_role_ttl"`
-	PermissionTTL        string `koanf:"permission_ttl"`
-	MaxRoleEntries       int    `koanf:"max_role_entries"`
-	MaxUserEntries       int    `koanf:"max_user_entries"`
-	MaxPermissionEntries int    `koanf:"max_permission_entries"`
+	RoleTTL              Duration `koanf:"role_ttl"`
+	UserRoleTTL          Duration `koanf:"user_role_ttl"`
+	PermissionTTL        Duration `koanf:"permission_ttl"`
+	MaxRoleEntries       int      `koanf:"max_role_entries"`
+	MaxUserEntries       int      `koanf:"max_user_entries"`
+	MaxPermissionEntries int      `koanf:"max_permission_entries"`
 }
 
 // Load reads configuration from a YAML file and overlays environment variables.
@@ -135,6 +1139,10 @@ func Load(configPath string) (*Config, error) {
 	if err := k.Load(file.Provider(configPath), yaml.Parser()); err != nil {
 		return nil, fmt.Errorf("failed to load config file %s: %w", configPath, err)
 	}
+	fileKeys := make(map[string]bool)
+	for _, key := range k.Keys() {
+		fileKeys[key] = true
+	}
 
 	// Overlay environment variables with prefix APP__.
 	// APP__SERVER__PORT -> server.port
@@ -149,9 +1157,28 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	var cfg Config
-	if err := k.Unmarshal("", &cfg); err != nil {
+	if err := k.UnmarshalWithConf("", &cfg, koanf.UnmarshalConf{
+		DecoderConfig: &mapstructure.DecoderConfig{
+			// Same hooks as koanf's default, with Duration parsing first.
+			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+				durationDecodeHook(),
+				mapstructure.StringToTimeDurationHookFunc(),
+				mapstructure.TextUnmarshallerHookFunc(),
+			),
+			WeaklyTypedInput: true,
+		},
+	}); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	if unknown := describeUnknownKeys(UnknownKeys(k.Keys()), fileKeys); len(unknown) > 0 {
+		if cfg.StrictKeys {
+			return nil, fmt.Errorf("unknown config keys (strict_keys is set): %s", strings.Join(unknown, ", "))
+		}
+		slog.Warn("ignoring unknown config keys", slog.Any("keys", unknown))
+	}
+	if err := cfg.applyDevSecrets(); err != nil {
+		return nil, err
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -182,6 +1209,36 @@ func (c *Config) Validate() error {
 	}
 	c.Server.Host = host
 
+	if _, err := ParseTrustedProxies(c.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
+	for i, entry := range c.Server.AllowedHosts {
+		host, err := NormalizeAllowedHost(entry)
+		if err != nil {
+			return fmt.Errorf("invalid server.allowed_hosts[%d] %q: %w", i, entry, err)
+		}
+		c.Server.AllowedHosts[i] = host
+	}
+	seenLocales := make(map[string]struct{}, len(c.Server.Locales))
+	for i, entry := range c.Server.Locales {
+		tag := strings.TrimSpace(entry)
+		if !localePattern.MatchString(tag) {
+			return fmt.Errorf("invalid server.locales[%d] %q: must be a language tag such as \"en\" or \"zh-CN\"", i, entry)
+		}
+		if _, dup := seenLocales[strings.ToLower(tag)]; dup {
+			return fmt.Errorf("duplicate server.locales entry %q", tag)
+		}
+		seenLocales[strings.ToLower(tag)] = struct{}{}
+		c.Server.Locales[i] = tag
+	}
+	c.Server.RequestID.TrustedHeader = strings.TrimSpace(c.Server.RequestID.TrustedHeader)
+	if h := c.Server.RequestID.TrustedHeader; h != "" && !headerNamePattern.MatchString(h) {
+		return fmt.Errorf("invalid server.request_id.trusted_header %q: must be an HTTP header name", h)
+	}
+	if err := validateStaticMounts(c.Server.Static.Mounts); err != nil {
+		return err
+	}
+
 	// Validate database.driver.
 	switch c.Database.Driver {
 	case "sqlite", "postgres":
@@ -198,170 +1255,414 @@ func (c *Config) Validate() error {
 		c.Database.SQLite.Path = sqlitePath
 	}
 
-	// When driver is postgres, required connection fields must be valid.
+	// When driver is postgres, required connection fields must be valid,
+	// for the primary and for every read replica. Replicas are ignored (with
+	// a warning from SetupDatabase) under sqlite.
 	if c.Database.Driver == "postgres" {
-		host := strings.TrimSpace(c.Database.Postgres.Host)
-		if host == "" {
-			return fmt.Errorf("database.postgres.host is required when driver is postgres")
-		}
-		if c.Database.Postgres.Port < 1 || c.Database.Postgres.Port > 65535 {
-			return fmt.Errorf("invalid database.postgres.port %d: must be between 1 and 65535", c.Database.Postgres.Port)
+		release := c.Server.Mode == gin.ReleaseMode
+		if err := validatePostgres("database.postgres", &c.Database.Postgres, release); err != nil {
+			return err
 		}
-		user := strings.TrimSpace(c.Database.Postgres.User)
-		if user == "" {
-			return fmt.Errorf("database.postgres.user is required when driver is postgres")
+		for i := range c.Database.Replicas {
+			replica := &c.Database.Replicas[i]
+			name := fmt.Sprintf("database.replicas[%d]", i)
+			pg := replica.postgres()
+			if err := validatePostgres(name, &pg, release); err != nil {
+				return err
+			}
+			replica.setPostgres(pg)
+			if replica.Pool.ConnMaxLifetime < 0 {
+				return fmt.Errorf("invalid %s.pool.conn_max_lifetime %q: must be greater than 0", name, replica.Pool.ConnMaxLifetime)
+			}
 		}
-		dbName := strings.TrimSpace(c.Database.Postgres.DBName)
-		if dbName == "" {
-			return fmt.Errorf("database.postgres.dbname is required when driver is postgres")
+	}
+
+	// Optional duration fields: zero means unset (empty or whitespace-only
+	// input), anything else must be positive. Parsing already happened in Load.
+	optionalDurations := []struct {
+		name  string
+		value Duration
+	}{
+		{"server.timeout", c.Server.Timeout},
+		{"server.cors.max_age", c.Server.CORS.MaxAge},
+		{"database.pool.conn_max_lifetime", c.Database.Pool.ConnMaxLifetime},
+		{"database.retry.max_backoff", c.Database.Retry.MaxBackoff},
+		{"database.log_slow_threshold", c.Database.LogSlowThreshold},
+		{"server.cache.warm_budget", c.Server.Cache.WarmBudget},
+		{"server.cache.singleflight_wait", c.Server.Cache.SingleFlightWait},
+		{"server.cache.stale_ttl", c.Server.Cache.StaleTTL},
+		{"server.cache.page_ttl", c.Server.Cache.PageTTL},
+		{"server.maintenance_interval", c.Server.MaintenanceInterval},
+		{"server.templates.slow_render_threshold", c.Server.Templates.SlowRenderThreshold},
+		{"server.sitemap.cache_ttl", c.Server.Sitemap.CacheTTL},
+		{"auth.login_history_retention", c.Auth.LoginHistoryRetention},
+		{"database.change_feed_retention", c.Database.ChangeFeedRetention},
+		{"server.health.timeout", c.Server.Health.Timeout},
+		{"server.templates.watch_interval", c.Server.Templates.WatchInterval},
+		{"server.rate_limit.overrides.refresh_interval", c.Server.RateLimit.Overrides.RefreshInterval},
+		{"server.websocket.ping_interval", c.Server.WebSocket.PingInterval},
+		{"server.http.read_timeout", c.Server.HTTP.ReadTimeout},
+		{"database.supervisor.interval", c.Database.Supervisor.Interval},
+		{"database.wait.timeout", c.Database.Wait.Timeout},
+		{"database.wait.interval", c.Database.Wait.Interval},
+		{"server.http.write_timeout", c.Server.HTTP.WriteTimeout},
+		{"server.http.idle_timeout", c.Server.HTTP.IdleTimeout},
+		{"server.http.read_header_timeout", c.Server.HTTP.ReadHeaderTimeout},
+		{"server.http.long_transfer_timeout", c.Server.HTTP.LongTransferTimeout},
+		{"server.error_rates.window", c.Server.ErrorRates.Window},
+	}
+	for _, f := range optionalDurations {
+		if f.value < 0 {
+			return fmt.Errorf("invalid %s %q: must be greater than 0", f.name, f.value)
 		}
-		sslMode := strings.TrimSpace(c.Database.Postgres.SSLMode)
+	}
 
-		switch sslMode {
-		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
-			// ok
-		default:
-			return fmt.Errorf("invalid database.postgres.sslmode %q: must be one of %q, %q, %q, %q, %q, %q", c.Database.Postgres.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	if p := c.Database.TablePrefix; p != "" && (len(p) > maxTablePrefixLength || !tablePrefixPattern.MatchString(p)) {
+		return fmt.Errorf("invalid database.table_prefix %q: must match %s and be at most %d characters", p, tablePrefixPattern, maxTablePrefixLength)
+	}
+
+	schemaCheck := strings.ToLower(strings.TrimSpace(c.Database.SchemaCheck))
+	switch schemaCheck {
+	case "":
+		schemaCheck = SchemaCheckWarn
+	case SchemaCheckOff, SchemaCheckWarn, SchemaCheckStrict:
+		// ok
+	default:
+		return fmt.Errorf("invalid database.schema_check %q: must be one of %q, %q, %q", c.Database.SchemaCheck, SchemaCheckOff, SchemaCheckWarn, SchemaCheckStrict)
+	}
+	c.Database.SchemaCheck = schemaCheck
+
+	integrityCheck := strings.ToLower(strings.TrimSpace(c.Database.SQLite.IntegrityCheck))
+	switch integrityCheck {
+	case "":
+		integrityCheck = SQLiteCheckFull
+	case SQLiteCheckOff, SQLiteCheckQuick, SQLiteCheckFull:
+		// ok
+	default:
+		return fmt.Errorf("invalid database.sqlite.integrity_check %q: must be one of %q, %q, %q", c.Database.SQLite.IntegrityCheck, SQLiteCheckOff, SQLiteCheckQuick, SQLiteCheckFull)
+	}
+	c.Database.SQLite.IntegrityCheck = integrityCheck
+
+	// Recovering discards the data of the corrupt file, which is never done
+	// to a production database behind the operator's back.
+	if c.Database.SQLite.AutoRecover && c.Server.Mode != gin.DebugMode {
+		return fmt.Errorf("invalid database.sqlite.auto_recover for server.mode %q: only allowed in %q mode", c.Server.Mode, gin.DebugMode)
+	}
+
+	c.Database.BackupDir = strings.TrimSpace(c.Database.BackupDir)
+	if c.Database.BackupRetention < 0 {
+		return fmt.Errorf("invalid database.backup_retention %d: must be 0 (keep all) or greater", c.Database.BackupRetention)
+	}
+	if n := c.Database.ChangePayloadMaxSize; n != 0 && n < minChangePayloadMaxSize {
+		return fmt.Errorf("invalid database.change_payload_max_size %d: must be 0 (default) or at least %d", n, minChangePayloadMaxSize)
+	}
+
+	// EXPLAIN capture re-runs statements and logs bound parameters, so it
+	// never runs outside debug mode.
+	if c.Database.ExplainSlow && c.Server.Mode != gin.DebugMode {
+		return fmt.Errorf("invalid database.explain_slow for server.mode %q: only allowed in %q mode", c.Server.Mode, gin.DebugMode)
+	}
+
+	if c.Database.RepeatedQueryThreshold < 0 {
+		return fmt.Errorf("invalid database.repeated_query_threshold %d: must be 0 (default %d) or greater", c.Database.RepeatedQueryThreshold, DefaultRepeatedQueryThreshold)
+	}
+	if c.Database.Supervisor.FailureThreshold < 0 {
+		return fmt.Errorf("invalid database.supervisor.failure_threshold %d: must be 0 (default %d) or greater", c.Database.Supervisor.FailureThreshold, DefaultSupervisorFailureThreshold)
+	}
+
+	if c.Database.Retry.Attempts < 0 || c.Database.Retry.Attempts > maxRetryAttempts {
+		return fmt.Errorf("invalid database.retry.attempts %d: must be between 0 and %d", c.Database.Retry.Attempts, maxRetryAttempts)
+	}
+
+	// Validate server.rate_limit (when enabled, rps and burst must be positive).
+	if err := ValidateRateLimit(c.Server.RateLimit); err != nil {
+		return err
+	}
+
+	// Validate server.cache (when enabled, ttl must be a valid positive duration, max_size > 0).
+	if c.Server.Cache.Enabled {
+		if c.Server.Cache.TTL <= 0 {
+			return fmt.Errorf("invalid server.cache.ttl %q: must be greater than 0 when caching is enabled", c.Server.Cache.TTL)
 		}
-		if c.Server.Mode == gin.ReleaseMode {
-			switch sslMode {
-			case "require", "verify-ca", "verify-full":
-				// ok
-			default:
-				return fmt.Errorf("invalid database.postgres.sslmode %q for server.mode %q: must be one of %q, %q, %q", c.Database.Postgres.SSLMode, gin.ReleaseMode, "require", "verify-ca", "verify-full")
+		if c.Server.Cache.MaxSize <= 0 {
+			return fmt.Errorf("invalid server.cache.max_size %d: must be positive when caching is enabled", c.Server.Cache.MaxSize)
+		}
+		for i, h := range c.Server.Cache.VaryHeaders {
+			h = strings.TrimSpace(h)
+			if !headerNamePattern.MatchString(h) {
+				return fmt.Errorf("invalid server.cache.vary_headers[%d] %q: must be an HTTP header name", i, c.Server.Cache.VaryHeaders[i])
 			}
+			c.Server.Cache.VaryHeaders[i] = http.CanonicalHeaderKey(h)
 		}
+		for i, target := range c.Server.Cache.Warm {
+			// Only GET /api/* responses are cached, so other paths could never be warmed.
+			if !strings.HasPrefix(target.Path, "/api/") || strings.ContainsAny(target.Path, "?#") {
+				return fmt.Errorf("invalid server.cache.warm[%d].path %q: must be an /api/ path without query", i, target.Path)
+			}
+			if _, err := url.ParseQuery(target.Query); err != nil {
+				return fmt.Errorf("invalid server.cache.warm[%d].query %q: %w", i, target.Query, err)
+			}
+		}
+	}
 
-		c.Database.Postgres.Host = host
-		c.Database.Postgres.User = user
-		c.Database.Postgres.DBName = dbName
-		c.Database.Postgres.SSLMode = sslMode
+	// Validate server.api.idempotency (when enabled, ttl must be positive).
+	if c.Server.API.Idempotency.Enabled && c.Server.API.Idempotency.TTL <= 0 {
+		return fmt.Errorf("invalid server.api.idempotency.ttl %q: must be greater than 0 when idempotency is enabled", c.Server.API.Idempotency.TTL)
 	}
 
-	// Normalize optional duration fields: whitespace-only means unset.
-	c.Server.Timeout = strings.TrimSpace(c.Server.Timeout)
-	c.Server.CORS.MaxAge = strings.TrimSpace(c.Server.CORS.MaxAge)
-	c.Database.Pool.ConnMaxLifetime = strings.TrimSpace(c.Database.Pool.ConnMaxLifetime)
-	c.Server.Cache.TTL = strings.TrimSpace(c.Server.Cache.TTL)
+	// Validate server.health.path.
+	healthPath := strings.TrimSpace(c.Server.Health.Path)
+	switch {
+	case healthPath == "":
+		healthPath = DefaultHealthPath
+	case !strings.HasPrefix(healthPath, "/"):
+		return fmt.Errorf("invalid server.health.path %q: must start with '/'", c.Server.Health.Path)
+	case healthPath == "/":
+		return fmt.Errorf("invalid server.health.path %q: must not be the site root", c.Server.Health.Path)
+	}
+	c.Server.Health.Path = healthPath
 
-	// Validate server.timeout (optional; must be a valid Go duration if set).
-	if t := c.Server.Timeout; t != "" {
-		d, err := time.ParseDuration(t)
-		if err != nil {
-			return fmt.Errorf("invalid server.timeout %q: %w", c.Server.Timeout, err)
-		}
-		if d <= 0 {
-			return fmt.Errorf("invalid server.timeout %q: must be greater than 0", c.Server.Timeout)
+	// Validate server.meta.
+	for i, p := range c.Server.Meta.RobotsDisallow {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid server.meta.robots_disallow[%d] %q: must start with '/'", i, p)
 		}
 	}
-
-	// Validate server.cors.max_age (optional; must be a valid Go duration if set).
-	if ma := c.Server.CORS.MaxAge; ma != "" {
-		d, err := time.ParseDuration(ma)
-		if err != nil {
-			return fmt.Errorf("invalid server.cors.max_age %q: must be a valid duration (e.g. \"24h\", \"3600s\"): %w", c.Server.CORS.MaxAge, err)
+	if contact := c.Server.Meta.SecurityContact; contact != "" {
+		if !strings.HasPrefix(contact, "mailto:") && !strings.HasPrefix(contact, "https://") && !strings.HasPrefix(contact, "tel:") {
+			return fmt.Errorf("invalid server.meta.security_contact %q: must be a mailto:, https:// or tel: URI", contact)
 		}
-		if d <= 0 {
-			return fmt.Errorf("invalid server.cors.max_age %q: must be greater than 0", c.Server.CORS.MaxAge)
+		// security.txt names its own absolute URL, built from the Host.
+		if c.Server.Mode == gin.ReleaseMode && len(c.Server.AllowedHosts) == 0 {
+			return fmt.Errorf("server.allowed_hosts is required in release mode when server.meta.security_contact is set")
 		}
 	}
-
-	// Validate database.pool.conn_max_lifetime (optional; must be positive if set).
-	if lm := c.Database.Pool.ConnMaxLifetime; lm != "" {
-		d, err := time.ParseDuration(lm)
+	if expires := c.Server.Meta.SecurityExpires; expires != "" {
+		t, err := time.Parse(time.RFC3339, expires)
 		if err != nil {
-			return fmt.Errorf("invalid database.pool.conn_max_lifetime %q: %w", c.Database.Pool.ConnMaxLifetime, err)
+			return fmt.Errorf("invalid server.meta.security_expires %q: must be an RFC 3339 date", expires)
 		}
-		if d <= 0 {
-			return fmt.Errorf("invalid database.pool.conn_max_lifetime %q: must be greater than 0", c.Database.Pool.ConnMaxLifetime)
+		if !t.After(time.Now()) {
+			return fmt.Errorf("invalid server.meta.security_expires %q: must be in the future", expires)
 		}
 	}
 
-	// Validate server.rate_limit (when enabled, rps and burst must be positive).
-	if c.Server.RateLimit.Enabled {
-		if c.Server.RateLimit.RPS <= 0 {
-			return fmt.Errorf("invalid server.rate_limit.rps %v: must be positive when rate limiting is enabled", c.Server.RateLimit.RPS)
-		}
-		if c.Server.RateLimit.Burst <= 0 {
-			return fmt.Errorf("invalid server.rate_limit.burst %d: must be positive when rate limiting is enabled", c.Server.RateLimit.Burst)
+	// Validate server.branding.
+	for _, f := range []struct{ key, color string }{
+		{"theme_color", c.Server.Branding.ThemeColor},
+		{"background_color", c.Server.Branding.BackgroundColor},
+	} {
+		if f.color != "" && !hexColorPattern.MatchString(f.color) {
+			return fmt.Errorf("invalid server.branding.%s %q: must be a hex color such as %q", f.key, f.color, DefaultThemeColor)
 		}
 	}
 
-	// Validate server.cache (when enabled, ttl must be a valid positive duration, max_size > 0).
-	if c.Server.Cache.Enabled {
-		d, err := time.ParseDuration(c.Server.Cache.TTL)
-		if err != nil {
-			return fmt.Errorf("invalid server.cache.ttl %q: %w", c.Server.Cache.TTL, err)
+	// Validate server.base_url, which the sitemap needs for its absolute
+	// URLs.
+	baseURL := strings.TrimRight(strings.TrimSpace(c.Server.BaseURL), "/")
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid server.base_url %q: must be an absolute http(s) URL without path, e.g. %q", c.Server.BaseURL, "https://example.com")
 		}
-		if d <= 0 {
-			return fmt.Errorf("invalid server.cache.ttl %q: must be greater than 0", c.Server.Cache.TTL)
-		}
-		if c.Server.Cache.MaxSize <= 0 {
-			return fmt.Errorf("invalid server.cache.max_size %d: must be positive when caching is enabled", c.Server.Cache.MaxSize)
+	}
+	if c.Server.Sitemap.Enabled && baseURL == "" {
+		return fmt.Errorf("server.base_url is required when server.sitemap.enabled is true")
+	}
+	c.Server.BaseURL = baseURL
+
+	// Expand server.cors.preset, then reject what browsers refuse: a
+	// wildcard origin with credentials. Unset presets allow any origin
+	// outside release mode when no origins are listed.
+	cors := &c.Server.CORS
+	cors.Preset = strings.ToLower(strings.TrimSpace(cors.Preset))
+	switch cors.Preset {
+	case "", CORSPresetDisabled, CORSPresetSameOrigin, CORSPresetCustom:
+		// ok
+	case CORSPresetDevPermissive:
+		if c.Server.Mode == gin.ReleaseMode {
+			return fmt.Errorf("server.cors.preset %q is not allowed in release mode", CORSPresetDevPermissive)
 		}
+	default:
+		return fmt.Errorf("invalid server.cors.preset %q: must be one of %q, %q, %q, %q", c.Server.CORS.Preset,
+			CORSPresetDisabled, CORSPresetSameOrigin, CORSPresetDevPermissive, CORSPresetCustom)
+	}
+	if cors.Preset == CORSPresetCustom && len(cors.AllowOrigins) == 0 {
+		return fmt.Errorf("server.cors.allow_origins is required when server.cors.preset is %q", CORSPresetCustom)
+	}
+	cors.ApplyPreset(baseURL)
+	wildcard := slices.Contains(cors.AllowOrigins, "*") ||
+		(cors.Preset == "" && len(cors.AllowOrigins) == 0 && c.Server.Mode != gin.ReleaseMode)
+	if cors.AllowCredentials && wildcard {
+		return fmt.Errorf("server.cors.allow_credentials cannot be combined with a wildcard origin: list the allowed origins in server.cors.allow_origins")
+	}
+
+	headRequests := strings.ToLower(strings.TrimSpace(c.Server.HeadRequests))
+	switch headRequests {
+	case "":
+		headRequests = HeadRequestsGet
+	case HeadRequestsGet, HeadRequestsReject:
+		// ok
+	default:
+		return fmt.Errorf("invalid server.head_requests %q: must be one of %q, %q", c.Server.HeadRequests, HeadRequestsGet, HeadRequestsReject)
+	}
+	c.Server.HeadRequests = headRequests
+
+	// Validate server.api.json_naming.
+	jsonNaming := strings.ToLower(strings.TrimSpace(c.Server.API.JSONNaming))
+	switch jsonNaming {
+	case "":
+		jsonNaming = "snake"
+	case "snake", "camel":
+		// ok
+	default:
+		return fmt.Errorf("invalid server.api.json_naming %q: must be one of %q, %q", c.Server.API.JSONNaming, "snake", "camel")
+	}
+	c.Server.API.JSONNaming = jsonNaming
+
+	// Validate server.api.max_json_depth, max_json_tokens and max_json_bytes.
+	if c.Server.API.MaxJSONDepth < 0 {
+		return fmt.Errorf("invalid server.api.max_json_depth %d: must not be negative", c.Server.API.MaxJSONDepth)
+	}
+	if c.Server.API.MaxJSONTokens < 0 {
+		return fmt.Errorf("invalid server.api.max_json_tokens %d: must not be negative", c.Server.API.MaxJSONTokens)
+	}
+	if c.Server.API.MaxJSONBytes < 0 {
+		return fmt.Errorf("invalid server.api.max_json_bytes %d: must not be negative", c.Server.API.MaxJSONBytes)
 	}
 
 	// Validate auth config (when enabled).
 	if c.Auth.RBAC.Enabled && !c.Auth.Enabled {
 		return fmt.Errorf("auth.rbac.enabled requires auth.enabled to be true")
 	}
+	if c.Server.AdminRuntimeConfig.Enabled && !c.Auth.RBAC.Enabled {
+		return fmt.Errorf("server.admin_runtime_config.enabled requires auth.rbac.enabled to be true")
+	}
+	if c.Server.RateLimit.Overrides.Enabled && (!c.Server.RateLimit.Enabled || !c.Auth.RBAC.Enabled) {
+		return fmt.Errorf("server.rate_limit.overrides.enabled requires server.rate_limit.enabled and auth.rbac.enabled to be true")
+	}
+	if c.Server.WebSocket.Enabled && !c.Auth.Enabled {
+		return fmt.Errorf("server.websocket.enabled requires auth.enabled to be true")
+	}
+	if c.Server.WebSocket.SendBuffer < 0 {
+		return fmt.Errorf("invalid server.websocket.send_buffer %d: must not be negative", c.Server.WebSocket.SendBuffer)
+	}
+	c.Server.ControlSocket.Path = strings.TrimSpace(c.Server.ControlSocket.Path)
+	if len(c.Server.ControlSocket.Path) > maxControlSocketPath {
+		return fmt.Errorf("invalid server.control_socket.path %q: must be at most %d bytes", c.Server.ControlSocket.Path, maxControlSocketPath)
+	}
+	for i, p := range c.Server.HTTP.LongTransferPaths {
+		p = strings.TrimSpace(p)
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("invalid server.http.long_transfer_paths[%d] %q: must be a path starting with /", i, c.Server.HTTP.LongTransferPaths[i])
+		}
+		c.Server.HTTP.LongTransferPaths[i] = p
+	}
+	if w := c.Server.ErrorRates.Window; w.IsSet() && w.Std() < 30*time.Second {
+		return fmt.Errorf("invalid server.error_rates.window %q: must be at least 30s", w)
+	}
+	if r := c.Server.ErrorRates.WarnRatio; r < 0 || r > 1 {
+		return fmt.Errorf("invalid server.error_rates.warn_ratio %v: must be between 0 and 1", r)
+	}
+	if c.Server.ErrorRates.MinRequests < 0 {
+		return fmt.Errorf("invalid server.error_rates.min_requests %d: must be 0 (default %d) or greater", c.Server.ErrorRates.MinRequests, DefaultErrorRateMinRequests)
+	}
 
 	if c.Auth.Enabled {
-		jwtSecret := strings.TrimSpace(c.Auth.JWTSecret)
-		if jwtSecret == "" {
-			return fmt.Errorf("auth.jwt_secret is required when auth is enabled")
-		}
-		if len(jwtSecret) < 32 {
-			return fmt.Errorf("invalid auth.jwt_secret: must be at least 32 characters")
+		if err := c.validateJWTSecrets(); err != nil {
+			return err
 		}
-		c.Auth.JWTSecret = jwtSecret
 
-		tokenExpiry := strings.TrimSpace(c.Auth.TokenExpiry)
-		if tokenExpiry == "" {
+		if !c.Auth.TokenExpiry.IsSet() {
 			return fmt.Errorf("auth.token_expiry is required when auth is enabled")
 		}
-		td, err := time.ParseDuration(tokenExpiry)
-		if err != nil {
-			return fmt.Errorf("invalid auth.token_expiry %q: %w", c.Auth.TokenExpiry, err)
-		}
-		if td <= 0 {
+		if c.Auth.TokenExpiry < 0 {
 			return fmt.Errorf("invalid auth.token_expiry %q: must be greater than 0", c.Auth.TokenExpiry)
 		}
-		c.Auth.TokenExpiry = tokenExpiry
 
 		publicPaths := make([]string, 0, len(c.Auth.PublicPaths))
+		parsedPublicPaths := make([]PublicPath, 0, len(c.Auth.PublicPaths))
 		seenPublicPaths := make(map[string]struct{}, len(c.Auth.PublicPaths))
 		for idx, p := range c.Auth.PublicPaths {
-			normalizedPath := strings.TrimSpace(p)
-			if normalizedPath == "" {
+			if strings.TrimSpace(p) == "" {
 				return fmt.Errorf("auth.public_paths[%d] cannot be empty when auth is enabled", idx)
 			}
-			if !strings.HasPrefix(normalizedPath, "/") {
-				return fmt.Errorf("invalid auth.public_paths[%d] %q: must start with '/'", idx, p)
+			parsed, err := ParsePublicPath(p)
+			if err != nil {
+				return fmt.Errorf("invalid auth.public_paths[%d] %q: %w", idx, p, err)
 			}
+			normalizedPath := parsed.String()
 			if _, exists := seenPublicPaths[normalizedPath]; exists {
 				continue
 			}
 			seenPublicPaths[normalizedPath] = struct{}{}
 			publicPaths = append(publicPaths, normalizedPath)
+			parsedPublicPaths = append(parsedPublicPaths, parsed)
 		}
 		if len(publicPaths) == 0 {
 			return fmt.Errorf("auth.public_paths is required when auth is enabled")
 		}
 
-		requiredPublicPaths := []string{"/api/v1/auth/login", "/api/v1/auth/register"}
+		registrationMode := strings.ToLower(strings.TrimSpace(c.Auth.RegistrationMode))
+		switch registrationMode {
+		case "":
+			registrationMode = "open"
+		case "open", "invite", "disabled":
+			// ok
+		default:
+			return fmt.Errorf("invalid auth.registration_mode %q: must be one of %q, %q, %q", c.Auth.RegistrationMode, "open", "invite", "disabled")
+		}
+		if registrationMode == "invite" && !c.Auth.RBAC.Enabled {
+			return fmt.Errorf("auth.registration_mode %q requires auth.rbac.enabled to be true", registrationMode)
+		}
+		c.Auth.RegistrationMode = registrationMode
+
+		// Without a register route only login has to be reachable
+		// anonymously.
+		requiredPublicPaths := []string{"/api/v1/auth/login"}
+		if registrationMode != "disabled" {
+			requiredPublicPaths = append(requiredPublicPaths, "/api/v1/auth/register")
+		}
 		for _, requiredPath := range requiredPublicPaths {
-			if _, exists := seenPublicPaths[requiredPath]; !exists {
+			if !slices.ContainsFunc(parsedPublicPaths, func(p PublicPath) bool { return p.Matches(http.MethodPost, requiredPath) }) {
 				return fmt.Errorf("auth.public_paths must include %q when auth is enabled", requiredPath)
 			}
 		}
 		c.Auth.PublicPaths = publicPaths
 
-		if c.Server.Mode == gin.ReleaseMode {
-			if CountSecretClasses(jwtSecret) < 3 {
-				return fmt.Errorf("auth.jwt_secret must include at least 3 character classes (lowercase, uppercase, digit, symbol) in release mode")
-			}
+		conflictMode := strings.ToLower(strings.TrimSpace(c.Auth.RegistrationConflictMode))
+		switch conflictMode {
+		case "":
+			conflictMode = "explicit"
+		case "explicit", "opaque":
+			// ok
+		default:
+			return fmt.Errorf("invalid auth.registration_conflict_mode %q: must be one of %q, %q", c.Auth.RegistrationConflictMode, "explicit", "opaque")
 		}
+		c.Auth.RegistrationConflictMode = conflictMode
+
+		if c.Auth.BcryptCost == 0 {
+			c.Auth.BcryptCost = DefaultBcryptCost
+		} else if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+			return fmt.Errorf("invalid auth.bcrypt_cost %d: must be between 4 and 31", c.Auth.BcryptCost)
+		}
+		algorithm := strings.ToLower(strings.TrimSpace(c.Auth.PasswordAlgorithm))
+		switch algorithm {
+		case "":
+			algorithm = "bcrypt"
+		case "bcrypt", "argon2id":
+			// ok
+		default:
+			return fmt.Errorf("invalid auth.password_algorithm %q: must be one of %q, %q", c.Auth.PasswordAlgorithm, "bcrypt", "argon2id")
+		}
+		c.Auth.PasswordAlgorithm = algorithm
+
+		if err := c.validateAPIKeys(); err != nil {
+			return err
+		}
+	} else if len(c.Auth.APIKeys) > 0 {
+		return fmt.Errorf("auth.api_keys requires auth.enabled to be true")
 	}
 
 	// Validate RBAC cache config (when RBAC is enabled).
@@ -371,25 +1672,19 @@ func (c *Config) Validate() error {
 		// Validate cache TTL fields.
 		ttlFields := []struct {
 			name  string
-			value *string
+			value Duration
 		}{
-			{"auth.rbac.cache.role_ttl", &cacheCfg.RoleTTL},
-			{"auth.rbac.cache.user_role_ttl", &cacheCfg.UserRoleTTL},
-			{"auth.rbac.cache.permission_ttl", &cacheCfg.PermissionTTL},
+			{"auth.rbac.cache.role_ttl", cacheCfg.RoleTTL},
+			{"auth.rbac.cache.user_role_ttl", cacheCfg.UserRoleTTL},
+			{"auth.rbac.cache.permission_ttl", cacheCfg.PermissionTTL},
 		}
 		for _, f := range ttlFields {
-			v := strings.TrimSpace(*f.value)
-			if v == "" {
+			if !f.value.IsSet() {
 				return fmt.Errorf("%s is required when RBAC is enabled", f.name)
 			}
-			d, err := time.ParseDuration(v)
-			if err != nil {
-				return fmt.Errorf("invalid %s %q: %w", f.name, *f.value, err)
-			}
-			if d <= 0 {
-				return fmt.Errorf("invalid %s %q: must be greater than 0", f.name, *f.value)
+			if f.value < 0 {
+				return fmt.Errorf("invalid %s %q: must be greater than 0", f.name, f.value)
 			}
-			*f.value = v
 		}
 
 		// Validate max entries fields.
@@ -408,15 +1703,40 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	// Validate log.level.
-	level := strings.ToLower(strings.TrimSpace(c.Log.Level))
-	switch level {
-	case "debug", "info", "warn", "error":
-		c.Log.Level = level
+	// Validate tenancy, after server.base_url, which the subdomain
+	// resolver takes the parent host from.
+	t := &c.Tenancy
+	t.Resolver = strings.ToLower(strings.TrimSpace(t.Resolver))
+	switch t.Resolver {
+	case "":
+		t.Resolver = TenantResolverHeader
+	case TenantResolverHeader, TenantResolverSubdomain:
+		// ok
 	default:
-		return fmt.Errorf("invalid log.level %q: must be one of %q, %q, %q, %q", c.Log.Level, "debug", "info", "warn", "error")
+		return fmt.Errorf("invalid tenancy.resolver %q: must be %q or %q", t.Resolver, TenantResolverHeader, TenantResolverSubdomain)
+	}
+	t.Header = strings.TrimSpace(t.Header)
+	if t.Header != "" && !headerNamePattern.MatchString(t.Header) {
+		return fmt.Errorf("invalid tenancy.header %q: must be an HTTP header name", t.Header)
+	}
+	if t.Enabled && t.Resolver == TenantResolverSubdomain && c.Server.BaseURL == "" {
+		return fmt.Errorf("tenancy.resolver %q requires server.base_url", TenantResolverSubdomain)
+	}
+
+	// Validate feature flag names.
+	for name := range c.Features {
+		if !featureNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid features key %q: must be lowercase snake_case", name)
+		}
 	}
 
+	// Validate log.level.
+	level, err := NormalizeLogLevel(c.Log.Level)
+	if err != nil {
+		return err
+	}
+	c.Log.Level = level
+
 	// Validate log.format.
 	format := strings.ToLower(strings.TrimSpace(c.Log.Format))
 	switch format {
@@ -426,9 +1746,274 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log.format %q: must be one of %q, %q", c.Log.Format, "text", "json")
 	}
 
+	// Validate log.access_format.
+	switch accessFormat := strings.ToLower(strings.TrimSpace(c.Log.AccessFormat)); accessFormat {
+	case "", AccessFormatStructured:
+		c.Log.AccessFormat = AccessFormatStructured
+	case AccessFormatPretty:
+		if c.Server.Mode == gin.ReleaseMode {
+			return fmt.Errorf("log.access_format %q is for local development and not allowed in release mode", AccessFormatPretty)
+		}
+		c.Log.AccessFormat = accessFormat
+	default:
+		return fmt.Errorf("invalid log.access_format %q: must be one of %q, %q", c.Log.AccessFormat, AccessFormatStructured, AccessFormatPretty)
+	}
+
+	return nil
+}
+
+// FieldError is a validation error about a single key, returned by the
+// checks that the runtime config admin API shares with Validate so it can
+// report them per field.
+type FieldError struct {
+	Key    string // config key, e.g. "server.rate_limit.rps"
+	Value  string // the rejected value as printed in Error
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s %s: %s", e.Key, e.Value, e.Reason)
+}
+
+// ValidateRateLimit checks server.rate_limit: when enabled, rps and burst
+// must be positive. Errors are *FieldError.
+func ValidateRateLimit(rl RateLimitConfig) error {
+	if !rl.Enabled {
+		return nil
+	}
+	if rl.RPS <= 0 {
+		return &FieldError{Key: "server.rate_limit.rps", Value: fmt.Sprint(rl.RPS), Reason: "must be positive when rate limiting is enabled"}
+	}
+	if rl.Burst <= 0 {
+		return &FieldError{Key: "server.rate_limit.burst", Value: fmt.Sprint(rl.Burst), Reason: "must be positive when rate limiting is enabled"}
+	}
+	return nil
+}
+
+// NormalizeLogLevel returns level trimmed and lower-cased when it is one of
+// debug, info, warn and error, and a *FieldError for log.level otherwise.
+func NormalizeLogLevel(level string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(level))
+	switch normalized {
+	case "debug", "info", "warn", "error":
+		return normalized, nil
+	}
+	return "", &FieldError{
+		Key:    "log.level",
+		Value:  fmt.Sprintf("%q", level),
+		Reason: fmt.Sprintf("must be one of %q, %q, %q, %q", "debug", "info", "warn", "error"),
+	}
+}
+
+// validateStaticMounts checks server.static.mounts and trims them in place.
+// Prefixes must be clean absolute paths other than "/", stay clear of /api,
+// and not nest inside each other, which the router could not tell apart.
+// Collisions with page routes are only known once routes are registered.
+func validateStaticMounts(mounts []StaticMount) error {
+	for i := range mounts {
+		m := &mounts[i]
+		name := fmt.Sprintf("server.static.mounts[%d]", i)
+		m.URLPrefix = strings.TrimSuffix(strings.TrimSpace(m.URLPrefix), "/")
+		m.Dir = strings.TrimSpace(m.Dir)
+
+		prefix := m.URLPrefix
+		if !strings.HasPrefix(prefix, "/") || path.Clean(prefix) != prefix || strings.ContainsAny(prefix, ":*?#") {
+			return fmt.Errorf("invalid %s.url_prefix %q: must be a clean path starting with '/' other than '/'", name, prefix)
+		}
+		if lower := strings.ToLower(prefix); lower == "/api" || strings.HasPrefix(lower, "/api/") {
+			return fmt.Errorf("invalid %s.url_prefix %q: collides with /api", name, prefix)
+		}
+		for j, other := range mounts[:i] {
+			if prefix == other.URLPrefix || strings.HasPrefix(prefix, other.URLPrefix+"/") || strings.HasPrefix(other.URLPrefix, prefix+"/") {
+				return fmt.Errorf("invalid %s.url_prefix %q: collides with server.static.mounts[%d] %q", name, prefix, j, other.URLPrefix)
+			}
+		}
+		if m.Dir == "" {
+			return fmt.Errorf("%s.dir is required: a directory or %q", name, StaticEmbedded)
+		}
+		if m.CacheMaxAge < 0 {
+			return fmt.Errorf("invalid %s.cache_max_age %q: must not be negative", name, m.CacheMaxAge)
+		}
+	}
+	return nil
+}
+
+// validatePostgres checks the connection fields of pg, reporting errors under
+// name (e.g. "database.postgres"), and trims them in place. In release mode
+// sslmode must verify or at least require TLS.
+func validatePostgres(name string, pg *PostgresConfig, release bool) error {
+	host := strings.TrimSpace(pg.Host)
+	if host == "" {
+		return fmt.Errorf("%s.host is required when driver is postgres", name)
+	}
+	if pg.Port < 1 || pg.Port > 65535 {
+		return fmt.Errorf("invalid %s.port %d: must be between 1 and 65535", name, pg.Port)
+	}
+	user := strings.TrimSpace(pg.User)
+	if user == "" {
+		return fmt.Errorf("%s.user is required when driver is postgres", name)
+	}
+	dbName := strings.TrimSpace(pg.DBName)
+	if dbName == "" {
+		return fmt.Errorf("%s.dbname is required when driver is postgres", name)
+	}
+	sslMode := strings.TrimSpace(pg.SSLMode)
+
+	switch sslMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		// ok
+	default:
+		return fmt.Errorf("invalid %s.sslmode %q: must be one of %q, %q, %q, %q, %q, %q", name, pg.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	if release {
+		switch sslMode {
+		case "require", "verify-ca", "verify-full":
+			// ok
+		default:
+			return fmt.Errorf("invalid %s.sslmode %q for server.mode %q: must be one of %q, %q, %q", name, pg.SSLMode, gin.ReleaseMode, "require", "verify-ca", "verify-full")
+		}
+	}
+
+	pg.Host = host
+	pg.User = user
+	pg.DBName = dbName
+	pg.SSLMode = sslMode
+	return nil
+}
+
+// validateJWTSecrets checks auth.jwt_secret, or the auth.jwt_secrets ring
+// that replaces it: every secret meets the length rule (and, in release
+// mode, the character-class rule), key IDs are unique, and exactly one key
+// is primary. Secrets and key IDs are trimmed in place.
+func (c *Config) validateJWTSecrets() error {
+	release := c.Server.Mode == gin.ReleaseMode
+	if len(c.Auth.JWTSecrets) == 0 {
+		jwtSecret := strings.TrimSpace(c.Auth.JWTSecret)
+		if jwtSecret == "" {
+			return fmt.Errorf("auth.jwt_secret is required when auth is enabled")
+		}
+		if err := validateJWTSecret("auth.jwt_secret", jwtSecret, release); err != nil {
+			return err
+		}
+		c.Auth.JWTSecret = jwtSecret
+		return nil
+	}
+
+	if strings.TrimSpace(c.Auth.JWTSecret) != "" {
+		return fmt.Errorf("auth.jwt_secret and auth.jwt_secrets are mutually exclusive")
+	}
+	keys := make([]JWTKeyConfig, len(c.Auth.JWTSecrets))
+	seen := make(map[string]struct{}, len(keys))
+	primaries := 0
+	for idx, key := range c.Auth.JWTSecrets {
+		key.KID = strings.TrimSpace(key.KID)
+		key.Secret = strings.TrimSpace(key.Secret)
+		if key.KID == "" {
+			return fmt.Errorf("auth.jwt_secrets[%d].kid is required", idx)
+		}
+		if _, dup := seen[key.KID]; dup {
+			return fmt.Errorf("duplicate auth.jwt_secrets kid %q", key.KID)
+		}
+		seen[key.KID] = struct{}{}
+		name := fmt.Sprintf("auth.jwt_secrets[%d].secret", idx)
+		if key.Secret == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+		if err := validateJWTSecret(name, key.Secret, release); err != nil {
+			return err
+		}
+		if key.Primary {
+			primaries++
+		}
+		keys[idx] = key
+	}
+	if primaries != 1 {
+		return fmt.Errorf("auth.jwt_secrets must have exactly one primary key, got %d", primaries)
+	}
+	c.Auth.JWTSecrets = keys
+	return nil
+}
+
+// apiKeyNamePattern matches auth.api_keys names.
+var apiKeyNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// validateAPIKeys checks auth.api_keys and normalizes names, hashes (lower
+// case) and scopes in place.
+func (c *Config) validateAPIKeys() error {
+	seenNames := make(map[string]struct{}, len(c.Auth.APIKeys))
+	seenHashes := make(map[string]struct{}, len(c.Auth.APIKeys))
+	for idx := range c.Auth.APIKeys {
+		key := &c.Auth.APIKeys[idx]
+		key.Name = strings.TrimSpace(key.Name)
+		if !apiKeyNamePattern.MatchString(key.Name) {
+			return fmt.Errorf("invalid auth.api_keys[%d].name %q: must be 1-64 letters, digits, '_', '-' or '.'", idx, key.Name)
+		}
+		if _, dup := seenNames[key.Name]; dup {
+			return fmt.Errorf("duplicate auth.api_keys name %q", key.Name)
+		}
+		seenNames[key.Name] = struct{}{}
+
+		key.KeyHash = strings.ToLower(strings.TrimSpace(key.KeyHash))
+		if b, err := hex.DecodeString(key.KeyHash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid auth.api_keys[%d].key_hash: must be the SHA-256 hash of the key as 64 hex digits", idx)
+		}
+		if _, dup := seenHashes[key.KeyHash]; dup {
+			return fmt.Errorf("auth.api_keys[%d].key_hash: duplicate of another key's hash", idx)
+		}
+		seenHashes[key.KeyHash] = struct{}{}
+
+		for j, scope := range key.Scopes {
+			scope = strings.TrimSpace(scope)
+			i := strings.LastIndexByte(scope, ':')
+			if i <= 0 || i == len(scope)-1 {
+				return fmt.Errorf("invalid auth.api_keys[%d].scopes[%d] %q: must be \"resource:action\"", idx, j, scope)
+			}
+			key.Scopes[j] = scope
+		}
+	}
+	return nil
+}
+
+// validateJWTSecret applies the JWT secret rules to the value of key name.
+func validateJWTSecret(name, secret string, release bool) error {
+	if len(secret) < 32 {
+		return fmt.Errorf("invalid %s: must be at least 32 characters", name)
+	}
+	if release && CountSecretClasses(secret) < 3 {
+		return fmt.Errorf("%s must include at least 3 character classes (lowercase, uppercase, digit, symbol) in release mode", name)
+	}
 	return nil
 }
 
+// redactedValue replaces configured secrets in Redacted output.
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a shallow copy of the config with secret values (CSRF
+// secret, JWT secrets, database password) replaced by "[REDACTED]". Empty
+// secrets are left empty so the output still shows whether one was set.
+// Use it whenever configuration is logged or printed.
+func (c *Config) Redacted() Config {
+	out := *c
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redactedValue
+		}
+	}
+	redact(&out.Server.CSRFSecret)
+	redact(&out.Server.Health.Token)
+	redact(&out.Auth.JWTSecret)
+	out.Auth.JWTSecrets = slices.Clone(out.Auth.JWTSecrets)
+	for i := range out.Auth.JWTSecrets {
+		redact(&out.Auth.JWTSecrets[i].Secret)
+	}
+	redact(&out.Database.Postgres.Password)
+	out.Database.Replicas = slices.Clone(out.Database.Replicas)
+	for i := range out.Database.Replicas {
+		redact(&out.Database.Replicas[i].Password)
+	}
+	return out
+}
+
 // CountSecretClasses counts how many character classes (lowercase, uppercase,
 // digit, symbol) are present in the given secret string.
 func CountSecretClasses(secret string) int {
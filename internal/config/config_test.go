@@ -3,8 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 const testYAML = `server:
@@ -97,8 +100,8 @@ func TestLoad_FullYAML(t *testing.T) {
 	if cfg.Database.Pool.MaxOpenConns != 50 {
 		t.Errorf("Pool.MaxOpenConns = %d, want %d", cfg.Database.Pool.MaxOpenConns, 50)
 	}
-	if cfg.Database.Pool.ConnMaxLifetime != "30m" {
-		t.Errorf("Pool.ConnMaxLifetime = %q, want %q", cfg.Database.Pool.ConnMaxLifetime, "30m")
+	if cfg.Database.Pool.ConnMaxLifetime != Duration(30*time.Minute) {
+		t.Errorf("Pool.ConnMaxLifetime = %v, want %v", cfg.Database.Pool.ConnMaxLifetime, 30*time.Minute)
 	}
 
 	// Log
@@ -144,8 +147,8 @@ func TestLoad_EnvOverride(t *testing.T) {
 	if cfg.Database.Pool.MaxOpenConns != 200 {
 		t.Errorf("Pool.MaxOpenConns = %d, want %d (env override)", cfg.Database.Pool.MaxOpenConns, 200)
 	}
-	if cfg.Database.Pool.ConnMaxLifetime != "2h" {
-		t.Errorf("Pool.ConnMaxLifetime = %q, want %q (env override)", cfg.Database.Pool.ConnMaxLifetime, "2h")
+	if cfg.Database.Pool.ConnMaxLifetime != Duration(2*time.Hour) {
+		t.Errorf("Pool.ConnMaxLifetime = %v, want %v (env override)", cfg.Database.Pool.ConnMaxLifetime, 2*time.Hour)
 	}
 
 	// Non-overridden values should remain from YAML.
@@ -523,6 +526,84 @@ log:
 	}
 }
 
+func TestLoad_Replicas(t *testing.T) {
+	replicaYAML := func(mode, replicas string) string {
+		return `server:
+  host: "127.0.0.1"
+  port: 3000
+  mode: "` + mode + `"
+  csrf_secret: "Abcdefghijklmnopqrstuvwxyz123456!"
+database:
+  driver: "postgres"
+  postgres:
+    host: "primary"
+    port: 5432
+    user: "admin"
+    password: "secret"
+    dbname: "testdb"
+    sslmode: "require"
+  replicas:
+` + replicas + `log:
+  level: "info"
+  format: "json"
+`
+	}
+	const replica = `    - host: " replica1 "
+      port: 5433
+      user: "reader"
+      password: "secret"
+      dbname: "testdb"
+      sslmode: "require"
+      pool:
+        max_open_conns: 20
+`
+
+	t.Run("valid", func(t *testing.T) {
+		cfg, err := Load(writeTestConfig(t, replicaYAML("release", replica)))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.Database.Replicas) != 1 {
+			t.Fatalf("Replicas = %+v, want 1 entry", cfg.Database.Replicas)
+		}
+		got := cfg.Database.Replicas[0]
+		if got.Host != "replica1" || got.Port != 5433 || got.Pool.MaxOpenConns != 20 {
+			t.Errorf("replica = %+v, want trimmed host replica1:5433 with max_open_conns 20", got)
+		}
+		if red := cfg.Redacted(); red.Database.Replicas[0].Password != redactedValue || cfg.Database.Replicas[0].Password != "secret" {
+			t.Error("Redacted() must mask replica passwords without touching the original")
+		}
+	})
+
+	tests := []struct {
+		name        string
+		mode        string
+		replicas    string
+		wantContain string
+	}{
+		{"missing host", "debug", strings.Replace(replica, `" replica1 "`, `""`, 1), "database.replicas[0].host is required"},
+		{"bad port", "debug", strings.Replace(replica, "5433", "0", 1), "invalid database.replicas[0].port 0"},
+		{"missing dbname", "debug", strings.Replace(replica, `dbname: "testdb"`, `dbname: ""`, 1), "database.replicas[0].dbname is required"},
+		{"insecure sslmode in release", "release", strings.Replace(replica, `"require"`, `"disable"`, 1), `invalid database.replicas[0].sslmode "disable" for server.mode "release"`},
+		{"negative pool lifetime", "debug", replica + "        conn_max_lifetime: \"-1m\"\n", "database.replicas[0].pool.conn_max_lifetime"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, replicaYAML(tt.mode, tt.replicas)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+				t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+			}
+		})
+	}
+
+	t.Run("ignored under sqlite", func(t *testing.T) {
+		yaml := strings.Replace(validBaseYAML(""), "  pool:\n", "  replicas:\n    - host: \"\"\n  pool:\n", 1)
+		if _, err := Load(writeTestConfig(t, yaml)); err != nil {
+			t.Fatalf("Load() error = %v, want replicas left unvalidated under sqlite", err)
+		}
+	})
+}
+
 func TestLoad_NonPositiveDurations(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -634,14 +715,14 @@ log:
 		t.Fatalf("Load() error: %v", err)
 	}
 
-	if cfg.Server.Timeout != "" {
-		t.Errorf("Server.Timeout = %q, want empty string", cfg.Server.Timeout)
+	if cfg.Server.Timeout.IsSet() {
+		t.Errorf("Server.Timeout = %v, want unset", cfg.Server.Timeout)
 	}
-	if cfg.Server.CORS.MaxAge != "" {
-		t.Errorf("Server.CORS.MaxAge = %q, want empty string", cfg.Server.CORS.MaxAge)
+	if cfg.Server.CORS.MaxAge.IsSet() {
+		t.Errorf("Server.CORS.MaxAge = %v, want unset", cfg.Server.CORS.MaxAge)
 	}
-	if cfg.Database.Pool.ConnMaxLifetime != "" {
-		t.Errorf("Database.Pool.ConnMaxLifetime = %q, want empty string", cfg.Database.Pool.ConnMaxLifetime)
+	if cfg.Database.Pool.ConnMaxLifetime.IsSet() {
+		t.Errorf("Database.Pool.ConnMaxLifetime = %v, want unset", cfg.Database.Pool.ConnMaxLifetime)
 	}
 }
 
@@ -729,27 +810,838 @@ log:
 			name: "disabled skips validation",
 			cacheBlock: `  cache:
     enabled: false
+    ttl: ""
+    max_size: -1`,
+			wantErr: false,
+		},
+		{
+			name: "disabled still rejects malformed TTL",
+			cacheBlock: `  cache:
+    enabled: false
     ttl: "bad"
     max_size: -1`,
+			wantErr:     true,
+			wantContain: "server.cache.ttl",
+		},
+		{
+			name: "warm target outside /api",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    warm:
+      - path: "/users"`,
+			wantErr:     true,
+			wantContain: "server.cache.warm[0].path",
+		},
+		{
+			name: "warm target with query in path",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    warm:
+      - path: "/api/v1/users"
+      - path: "/api/v1/users?page=1"`,
+			wantErr:     true,
+			wantContain: "server.cache.warm[1].path",
+		},
+		{
+			name: "warm target with malformed query",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    warm:
+      - path: "/api/v1/users"
+        query: "page=%zz"`,
+			wantErr:     true,
+			wantContain: "server.cache.warm[0].query",
+		},
+		{
+			name: "negative warm budget",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    warm_budget: "-1s"`,
+			wantErr:     true,
+			wantContain: "server.cache.warm_budget",
+		},
+		{
+			name: "negative singleflight wait",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    singleflight_wait: "-1s"`,
+			wantErr:     true,
+			wantContain: "server.cache.singleflight_wait",
+		},
+		{
+			name: "negative stale ttl",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    stale_ttl: "-1s"`,
+			wantErr:     true,
+			wantContain: "server.cache.stale_ttl",
+		},
+		{
+			name: "negative page ttl",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    page_ttl: "-1s"`,
+			wantErr:     true,
+			wantContain: "server.cache.page_ttl",
+		},
+		{
+			name: "invalid vary header",
+			cacheBlock: `  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    vary_headers: ["Accept-Language", "Bad Header"]`,
+			wantErr:     true,
+			wantContain: "server.cache.vary_headers[1]",
+		},
+		{
+			name: "disabled skips warm target validation",
+			cacheBlock: `  cache:
+    enabled: false
+    warm:
+      - path: "/users"`,
 			wantErr: false,
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			path := writeTestConfig(t, base(tt.cacheBlock))
-			_, err := Load(path)
-			if tt.wantErr {
-				if err == nil {
-					t.Fatal("Load() expected error, got nil")
-				}
-				if !strings.Contains(err.Error(), tt.wantContain) {
-					t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
-				}
-			} else {
-				if err != nil {
-					t.Fatalf("Load() unexpected error: %v", err)
-				}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, base(tt.cacheBlock))
+			_, err := Load(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantContain) {
+					t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Load() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_CacheWarmTargets(t *testing.T) {
+	yaml := strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", `  mode: "debug"
+  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    warm_budget: "2s"
+    warm:
+      - path: "/api/v1/users"
+      - path: "/api/v1/users"
+        query: "page=1&page_size=20"
+`, 1)
+
+	cfg, err := Load(writeTestConfig(t, yaml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []CacheWarmTarget{{Path: "/api/v1/users"}, {Path: "/api/v1/users", Query: "page=1&page_size=20"}}
+	if !reflect.DeepEqual(cfg.Server.Cache.Warm, want) {
+		t.Errorf("Warm = %+v, want %+v", cfg.Server.Cache.Warm, want)
+	}
+	if got := cfg.Server.Cache.WarmBudget.Std(); got != 2*time.Second {
+		t.Errorf("WarmBudget = %v, want 2s", got)
+	}
+}
+
+func TestLoad_CacheVaryHeaders(t *testing.T) {
+	yaml := strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", `  mode: "debug"
+  cache:
+    enabled: true
+    ttl: "5m"
+    max_size: 100
+    vary_headers: ["accept-language", "X-TENANT"]
+`, 1)
+
+	cfg, err := Load(writeTestConfig(t, yaml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := []string{"Accept-Language", "X-Tenant"}
+	if !reflect.DeepEqual(cfg.Server.Cache.VaryHeaders, want) {
+		t.Errorf("VaryHeaders = %q, want canonical %q", cfg.Server.Cache.VaryHeaders, want)
+	}
+}
+
+func TestLoad_IdempotencyConfig(t *testing.T) {
+	withAPI := func(block string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n  api:\n    idempotency:\n"+block, 1)
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantErr     bool
+		wantContain string
+		wantTTL     time.Duration
+	}{
+		{name: "enabled with ttl", yaml: withAPI("      enabled: true\n      ttl: \"24h\"\n"), wantTTL: 24 * time.Hour},
+		{name: "disabled without ttl", yaml: withAPI("      enabled: false\n")},
+		{name: "enabled without ttl", yaml: withAPI("      enabled: true\n"), wantErr: true, wantContain: "server.api.idempotency.ttl"},
+		{name: "enabled with negative ttl", yaml: withAPI("      enabled: true\n      ttl: \"-1h\"\n"), wantErr: true, wantContain: "server.api.idempotency.ttl"},
+		{name: "malformed ttl", yaml: withAPI("      enabled: false\n      ttl: \"daily\"\n"), wantErr: true, wantContain: "server.api.idempotency.ttl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, tt.yaml))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+					t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if got := cfg.Server.API.Idempotency.TTL.Std(); got != tt.wantTTL {
+				t.Errorf("Idempotency.TTL = %v; want %v", got, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestLoad_DatabaseRetry(t *testing.T) {
+	withRetry := func(block string) string {
+		return strings.Replace(validBaseYAML(""), "  pool:\n", "  retry:\n"+block+"  pool:\n", 1)
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantErr     bool
+		wantContain string
+		want        RetryConfig
+	}{
+		{name: "unset uses defaults", yaml: validBaseYAML(""), want: RetryConfig{Attempts: 3, MaxBackoff: Duration(100 * time.Millisecond)}},
+		{name: "explicit", yaml: withRetry("    attempts: 5\n    max_backoff: \"250ms\"\n"), want: RetryConfig{Attempts: 5, MaxBackoff: Duration(250 * time.Millisecond)}},
+		{name: "single attempt", yaml: withRetry("    attempts: 1\n"), want: RetryConfig{Attempts: 1, MaxBackoff: Duration(100 * time.Millisecond)}},
+		{name: "negative attempts", yaml: withRetry("    attempts: -1\n"), wantErr: true, wantContain: "database.retry.attempts"},
+		{name: "too many attempts", yaml: withRetry("    attempts: 11\n"), wantErr: true, wantContain: "database.retry.attempts"},
+		{name: "negative max_backoff", yaml: withRetry("    max_backoff: \"-1s\"\n"), wantErr: true, wantContain: "database.retry.max_backoff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, tt.yaml))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+					t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if got := EffectiveRetry(cfg.Database.Retry); got != tt.want {
+				t.Errorf("EffectiveRetry() = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_TablePrefix(t *testing.T) {
+	withPrefix := func(prefix string) string {
+		return strings.Replace(validBaseYAML(""), "  pool:\n", "  table_prefix: \""+prefix+"\"\n  pool:\n", 1)
+	}
+
+	for _, prefix := range []string{"", "gobase_", "app2", "a_b_c_"} {
+		t.Run("accepts "+prefix, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, withPrefix(prefix)))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Database.TablePrefix != prefix {
+				t.Errorf("TablePrefix = %q, want %q", cfg.Database.TablePrefix, prefix)
+			}
+		})
+	}
+	for _, prefix := range []string{"Gobase_", "1app_", "_app", "go-base_", "app.", "abcdefghijklmnopqrstu"} {
+		t.Run("rejects "+prefix, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, withPrefix(prefix)))
+			if err == nil || !strings.Contains(err.Error(), "invalid database.table_prefix") {
+				t.Fatalf("Load() error = %v, want invalid database.table_prefix", err)
+			}
+		})
+	}
+}
+
+func TestLoad_GORMOptions(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.PrepareStmt || cfg.Database.SkipDefaultTransaction {
+		t.Errorf("defaults: PrepareStmt = %v, SkipDefaultTransaction = %v; want both false", cfg.Database.PrepareStmt, cfg.Database.SkipDefaultTransaction)
+	}
+
+	t.Setenv("APP__DATABASE__SKIP_DEFAULT_TRANSACTION", "true")
+	yml := strings.Replace(validBaseYAML(""), "  pool:\n", "  prepare_stmt: true\n  pool:\n", 1)
+	cfg, err = Load(writeTestConfig(t, yml))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Database.PrepareStmt || !cfg.Database.SkipDefaultTransaction {
+		t.Errorf("PrepareStmt = %v, SkipDefaultTransaction = %v; want both true", cfg.Database.PrepareStmt, cfg.Database.SkipDefaultTransaction)
+	}
+}
+
+func TestLoad_JSONNaming(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.API.JSONNaming != "snake" {
+		t.Errorf("default JSONNaming = %q, want %q", cfg.Server.API.JSONNaming, "snake")
+	}
+
+	t.Setenv("APP__SERVER__API__JSON_NAMING", " Camel ")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.API.JSONNaming != "camel" {
+		t.Errorf("JSONNaming = %q, want %q", cfg.Server.API.JSONNaming, "camel")
+	}
+
+	t.Setenv("APP__SERVER__API__JSON_NAMING", "kebab")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.api.json_naming") {
+		t.Errorf("Load() error = %v, want server.api.json_naming error", err)
+	}
+}
+
+func TestLoad_JSONLimits(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// Unset limits stay 0 so pkg.BindAndValidate applies its defaults.
+	if cfg.Server.API.MaxJSONDepth != 0 || cfg.Server.API.MaxJSONTokens != 0 {
+		t.Errorf("defaults = %d, %d; want 0, 0", cfg.Server.API.MaxJSONDepth, cfg.Server.API.MaxJSONTokens)
+	}
+
+	t.Setenv("APP__SERVER__API__MAX_JSON_DEPTH", "16")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.API.MaxJSONDepth != 16 {
+		t.Errorf("MaxJSONDepth = %d, want 16", cfg.Server.API.MaxJSONDepth)
+	}
+
+	t.Setenv("APP__SERVER__API__MAX_JSON_BYTES", "-1")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.api.max_json_bytes") {
+		t.Errorf("Load() error = %v, want server.api.max_json_bytes error", err)
+	}
+
+	t.Setenv("APP__SERVER__API__MAX_JSON_TOKENS", "-1")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.api.max_json_tokens") {
+		t.Errorf("Load() error = %v, want server.api.max_json_tokens error", err)
+	}
+}
+
+func TestLoad_AllowedHosts(t *testing.T) {
+	withHosts := func(mode, server string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \""+mode+"\"\n"+server, 1)
+	}
+	cfg, err := Load(writeTestConfig(t, withHosts("debug", "  allowed_hosts: [\" API.Example.com. \", \"*.Example.org\", \"[::1]\", \"192.0.2.1\"]\n")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"api.example.com", "*.example.org", "::1", "192.0.2.1"}; !slices.Equal(cfg.Server.AllowedHosts, want) {
+		t.Errorf("AllowedHosts = %q, want %q", cfg.Server.AllowedHosts, want)
+	}
+
+	for _, entry := range []string{"https://example.com", "example.com:8080", "*", "*.", "a.*.example.com", "example.com/path", ""} {
+		if _, err := Load(writeTestConfig(t, withHosts("debug", "  allowed_hosts: [\""+entry+"\"]\n"))); err == nil || !strings.Contains(err.Error(), "server.allowed_hosts[0]") {
+			t.Errorf("allowed_hosts %q: Load() error = %v, want server.allowed_hosts[0] error", entry, err)
+		}
+	}
+
+	release := "  csrf_secret: \"Abcd1234!Abcd1234!Abcd1234!Abcd1234!\"\n  meta:\n    security_contact: \"mailto:security@example.com\"\n"
+	if _, err := Load(writeTestConfig(t, withHosts("release", release))); err == nil || !strings.Contains(err.Error(), "server.allowed_hosts is required") {
+		t.Errorf("release with security.txt: Load() error = %v, want server.allowed_hosts is required", err)
+	}
+	if _, err := Load(writeTestConfig(t, withHosts("release", release+"  allowed_hosts: [\"example.com\"]\n"))); err != nil {
+		t.Errorf("release with allowed_hosts: Load() error = %v", err)
+	}
+}
+
+func TestLoad_Locales(t *testing.T) {
+	withLocales := func(locales string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n  locales: "+locales+"\n", 1)
+	}
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.EffectiveLocales(); !slices.Equal(got, DefaultLocales) {
+		t.Errorf("EffectiveLocales() = %q, want %q", got, DefaultLocales)
+	}
+
+	cfg, err = Load(writeTestConfig(t, withLocales(`[" en-US ", "zh-Hant-TW", "fr"]`)))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"en-US", "zh-Hant-TW", "fr"}; !slices.Equal(cfg.Server.EffectiveLocales(), want) {
+		t.Errorf("EffectiveLocales() = %q, want %q", cfg.Server.EffectiveLocales(), want)
+	}
+
+	for _, locales := range []string{`["en_US"]`, `["*"]`, `["en-"]`, `["1en"]`, `[""]`} {
+		if _, err := Load(writeTestConfig(t, withLocales(locales))); err == nil || !strings.Contains(err.Error(), "invalid server.locales[0]") {
+			t.Errorf("locales %s: Load() error = %v, want invalid server.locales[0]", locales, err)
+		}
+	}
+	if _, err := Load(writeTestConfig(t, withLocales(`["en", "EN"]`))); err == nil || !strings.Contains(err.Error(), "duplicate server.locales") {
+		t.Errorf("duplicate locales: Load() error = %v, want duplicate server.locales", err)
+	}
+}
+
+func TestLoad_Backup(t *testing.T) {
+	withBackup := func(lines string) string {
+		return strings.Replace(validBaseYAML(""), "database:\n", "database:\n"+lines, 1)
+	}
+	cfg, err := Load(writeTestConfig(t, withBackup("  backup_dir: \" data/backups \"\n  backup_retention: 7\n")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.BackupDir != "data/backups" || cfg.Database.BackupRetention != 7 {
+		t.Errorf("backup = %q, %d; want data/backups, 7", cfg.Database.BackupDir, cfg.Database.BackupRetention)
+	}
+	if _, err := Load(writeTestConfig(t, withBackup("  backup_retention: -1\n"))); err == nil || !strings.Contains(err.Error(), "invalid database.backup_retention") {
+		t.Errorf("negative retention: Load() error = %v, want invalid database.backup_retention", err)
+	}
+}
+
+func TestLoad_Sitemap(t *testing.T) {
+	t.Setenv("APP__SERVER__SITEMAP__ENABLED", "true")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.base_url is required") {
+		t.Errorf("Load() without base_url error = %v, want server.base_url is required", err)
+	}
+	for _, bad := range []string{"example.com", "ftp://example.com", "https://", "https://example.com/app", "https://example.com?x=1"} {
+		t.Setenv("APP__SERVER__BASE_URL", bad)
+		if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.base_url") {
+			t.Errorf("Load() with base_url %q error = %v, want invalid server.base_url", bad, err)
+		}
+	}
+
+	t.Setenv("APP__SERVER__BASE_URL", " https://example.com/ ")
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.BaseURL != "https://example.com" || cfg.Server.Sitemap.EffectiveCacheTTL() != DefaultSitemapCacheTTL {
+		t.Errorf("base_url = %q, cache ttl = %v; want https://example.com, %v", cfg.Server.BaseURL, cfg.Server.Sitemap.EffectiveCacheTTL(), DefaultSitemapCacheTTL)
+	}
+}
+
+func TestLoad_LoginHistoryRetention(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Auth.EffectiveLoginHistoryRetention(); got != DefaultLoginHistoryRetention {
+		t.Errorf("default retention = %v, want %v", got, DefaultLoginHistoryRetention)
+	}
+	t.Setenv("APP__AUTH__LOGIN_HISTORY_RETENTION", "-1h")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid auth.login_history_retention") {
+		t.Errorf("Load() error = %v, want invalid auth.login_history_retention", err)
+	}
+}
+
+func TestLoad_ChangeFeedRetention(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveChangeFeedRetention(); got != DefaultChangeFeedRetention {
+		t.Errorf("default retention = %v, want %v", got, DefaultChangeFeedRetention)
+	}
+	t.Setenv("APP__DATABASE__CHANGE_FEED_RETENTION", "168h")
+	if cfg, err = Load(writeTestConfig(t, validBaseYAML(""))); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveChangeFeedRetention(); got != 168*time.Hour {
+		t.Errorf("retention = %v, want 168h", got)
+	}
+	t.Setenv("APP__DATABASE__CHANGE_FEED_RETENTION", "-1h")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.change_feed_retention") {
+		t.Errorf("Load() error = %v, want invalid database.change_feed_retention", err)
+	}
+}
+
+func TestLoad_ChangePayloadMaxSize(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveChangePayloadMaxSize(); got != DefaultChangePayloadMaxSize {
+		t.Errorf("default cap = %d, want %d", got, DefaultChangePayloadMaxSize)
+	}
+	t.Setenv("APP__DATABASE__CHANGE_PAYLOAD_MAX_SIZE", "4096")
+	if cfg, err = Load(writeTestConfig(t, validBaseYAML(""))); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveChangePayloadMaxSize(); got != 4096 {
+		t.Errorf("cap = %d, want 4096", got)
+	}
+	for _, n := range []string{"512", "-1"} {
+		t.Setenv("APP__DATABASE__CHANGE_PAYLOAD_MAX_SIZE", n)
+		if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.change_payload_max_size") {
+			t.Errorf("cap %s: Load() error = %v, want invalid database.change_payload_max_size", n, err)
+		}
+	}
+}
+
+func TestLoad_Branding(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	b := cfg.Server.Branding
+	if b.EffectiveName() != DefaultBrandingName || b.EffectiveShortName() != DefaultBrandingName ||
+		b.EffectiveThemeColor() != DefaultThemeColor || b.EffectiveBackgroundColor() != DefaultBackgroundColor {
+		t.Errorf("default branding = %+v, want the defaults", b)
+	}
+	t.Setenv("APP__SERVER__BRANDING__NAME", "Acme Portal")
+	t.Setenv("APP__SERVER__BRANDING__THEME_COLOR", "#0af")
+	if cfg, err = Load(writeTestConfig(t, validBaseYAML(""))); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if b := cfg.Server.Branding; b.EffectiveShortName() != "Acme Portal" || b.EffectiveThemeColor() != "#0af" {
+		t.Errorf("branding = %+v, want name Acme Portal and theme color #0af", b)
+	}
+	t.Setenv("APP__SERVER__BRANDING__THEME_COLOR", "indigo")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.branding.theme_color") {
+		t.Errorf("Load() error = %v, want invalid server.branding.theme_color", err)
+	}
+}
+
+func TestLoad_AccessFormat(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Log.AccessFormat != AccessFormatStructured {
+		t.Errorf("default AccessFormat = %q, want %q", cfg.Log.AccessFormat, AccessFormatStructured)
+	}
+	t.Setenv("APP__LOG__ACCESS_FORMAT", "Pretty")
+	if cfg, err = Load(writeTestConfig(t, validBaseYAML(""))); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Log.AccessFormat != AccessFormatPretty {
+		t.Errorf("AccessFormat = %q, want %q", cfg.Log.AccessFormat, AccessFormatPretty)
+	}
+	if _, err := Load(writeTestConfig(t, validReleaseBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "not allowed in release mode") {
+		t.Errorf("Load(release) error = %v, want pretty rejected in release mode", err)
+	}
+	t.Setenv("APP__LOG__ACCESS_FORMAT", "apache")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid log.access_format") {
+		t.Errorf("Load() error = %v, want invalid log.access_format", err)
+	}
+}
+
+func TestLoad_HeadRequests(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.HeadRequests != HeadRequestsGet {
+		t.Errorf("default HeadRequests = %q, want %q", cfg.Server.HeadRequests, HeadRequestsGet)
+	}
+	t.Setenv("APP__SERVER__HEAD_REQUESTS", "405")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.head_requests") {
+		t.Errorf("Load() error = %v, want invalid server.head_requests", err)
+	}
+}
+
+func TestLoad_SchemaCheck(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.SchemaCheck != SchemaCheckWarn {
+		t.Errorf("default SchemaCheck = %q, want %q", cfg.Database.SchemaCheck, SchemaCheckWarn)
+	}
+
+	t.Setenv("APP__DATABASE__SCHEMA_CHECK", " Strict ")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.SchemaCheck != SchemaCheckStrict {
+		t.Errorf("SchemaCheck = %q, want %q", cfg.Database.SchemaCheck, SchemaCheckStrict)
+	}
+
+	t.Setenv("APP__DATABASE__SCHEMA_CHECK", "fail")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.schema_check") {
+		t.Errorf("Load() error = %v, want invalid database.schema_check", err)
+	}
+}
+
+func TestLoad_Health(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Health.Path != DefaultHealthPath || cfg.Server.Health.Token != "" || !cfg.Server.Health.Details() {
+		t.Errorf("default Health = %+v, want /health without token, with details", cfg.Server.Health)
+	}
+	if got := cfg.Server.Health.EffectiveTimeout(); got != DefaultHealthCheckTimeout {
+		t.Errorf("default health timeout = %v, want %v", got, DefaultHealthCheckTimeout)
+	}
+
+	t.Setenv("APP__SERVER__HEALTH__PATH", " /internal/healthz ")
+	t.Setenv("APP__SERVER__HEALTH__TOKEN", "probe-secret")
+	t.Setenv("APP__SERVER__HEALTH__EXPOSE_DETAILS", "false")
+	t.Setenv("APP__SERVER__HEALTH__TIMEOUT", "250ms")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Health.Path != "/internal/healthz" || cfg.Server.Health.Details() || cfg.Server.Health.EffectiveTimeout() != 250*time.Millisecond {
+		t.Errorf("Health = %+v, want relocated without details", cfg.Server.Health)
+	}
+	if got := cfg.Redacted().Server.Health.Token; got == "probe-secret" {
+		t.Error("Redacted() keeps server.health.token")
+	}
+
+	for _, path := range []string{"healthz", "/"} {
+		t.Setenv("APP__SERVER__HEALTH__PATH", path)
+		if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.health.path") {
+			t.Errorf("Load() with path %q error = %v, want server.health.path error", path, err)
+		}
+	}
+}
+
+func TestLoad_TemplateWatch(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.Templates.EffectiveWatchInterval(); got != DefaultTemplateWatchInterval || cfg.Server.Templates.LocaleDir != "" {
+		t.Errorf("default Templates = %+v (interval %v), want %v without locale bundles", cfg.Server.Templates, got, DefaultTemplateWatchInterval)
+	}
+
+	t.Setenv("APP__SERVER__TEMPLATES__WATCH_INTERVAL", "250ms")
+	t.Setenv("APP__SERVER__TEMPLATES__LOCALE_DIR", "locales")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Templates.EffectiveWatchInterval() != 250*time.Millisecond || cfg.Server.Templates.LocaleDir != "locales" {
+		t.Errorf("Templates = %+v, want a 250ms interval and locale_dir locales", cfg.Server.Templates)
+	}
+
+	t.Setenv("APP__SERVER__TEMPLATES__WATCH_INTERVAL", "-1s")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.templates.watch_interval") {
+		t.Errorf("Load() with a negative interval error = %v, want server.templates.watch_interval error", err)
+	}
+}
+
+func TestLoad_RateLimitOverrides(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if o := cfg.Server.RateLimit.Overrides; o.Enabled || o.EffectiveRefreshInterval() != DefaultRateLimitOverridesRefresh {
+		t.Errorf("default Overrides = %+v (interval %v), want disabled with %v", o, o.EffectiveRefreshInterval(), DefaultRateLimitOverridesRefresh)
+	}
+
+	t.Setenv("APP__SERVER__RATE_LIMIT__OVERRIDES__ENABLED", "true")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.rate_limit.overrides.enabled requires") {
+		t.Errorf("Load() with overrides but no RBAC error = %v, want server.rate_limit.overrides.enabled error", err)
+	}
+
+	t.Setenv("APP__SERVER__RATE_LIMIT__ENABLED", "true")
+	t.Setenv("APP__SERVER__RATE_LIMIT__RPS", "10")
+	t.Setenv("APP__SERVER__RATE_LIMIT__BURST", "20")
+	t.Setenv("APP__SERVER__RATE_LIMIT__OVERRIDES__REFRESH_INTERVAL", "5s")
+	withRBAC := validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n  rbac:\n    enabled: true\n    cache:\n      role_ttl: \"5m\"\n      user_role_ttl: \"5m\"\n      permission_ttl: \"5m\"\n      max_role_entries: 100\n      max_user_entries: 500\n      max_permission_entries: 200\n")
+	cfg, err = Load(writeTestConfig(t, withRBAC))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.RateLimit.Overrides.EffectiveRefreshInterval(); got != 5*time.Second {
+		t.Errorf("EffectiveRefreshInterval() = %v, want 5s", got)
+	}
+
+	t.Setenv("APP__SERVER__RATE_LIMIT__OVERRIDES__REFRESH_INTERVAL", "-1s")
+	if _, err := Load(writeTestConfig(t, withRBAC)); err == nil || !strings.Contains(err.Error(), "server.rate_limit.overrides.refresh_interval") {
+		t.Errorf("Load() with a negative interval error = %v, want server.rate_limit.overrides.refresh_interval error", err)
+	}
+}
+
+func TestLoad_RepeatedQueryThreshold(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveRepeatedQueryThreshold(); got != DefaultRepeatedQueryThreshold {
+		t.Errorf("default EffectiveRepeatedQueryThreshold() = %d, want %d", got, DefaultRepeatedQueryThreshold)
+	}
+
+	t.Setenv("APP__DATABASE__REPEATED_QUERY_THRESHOLD", "20")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.EffectiveRepeatedQueryThreshold(); got != 20 {
+		t.Errorf("EffectiveRepeatedQueryThreshold() = %d, want 20", got)
+	}
+
+	t.Setenv("APP__DATABASE__REPEATED_QUERY_THRESHOLD", "-1")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "database.repeated_query_threshold") {
+		t.Errorf("Load() with a negative threshold error = %v, want database.repeated_query_threshold error", err)
+	}
+}
+
+func TestLoad_Meta(t *testing.T) {
+	withMeta := func(block string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n  meta:\n"+block, 1)
+	}
+
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.Meta.Disallow(); !slices.Equal(got, DefaultRobotsDisallow) {
+		t.Errorf("default Disallow() = %v, want %v", got, DefaultRobotsDisallow)
+	}
+
+	expires := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	cfg, err = Load(writeTestConfig(t, withMeta("    robots_disallow: [\"/private\"]\n    security_contact: \"mailto:security@example.com\"\n    security_expires: \""+expires+"\"\n")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.Meta.Disallow(); !slices.Equal(got, []string{"/private"}) || cfg.Server.Meta.SecurityExpires != expires {
+		t.Errorf("Meta = %+v", cfg.Server.Meta)
+	}
+
+	tests := []struct{ block, want string }{
+		{"    security_expires: \"2020-01-01T00:00:00Z\"\n", "must be in the future"},
+		{"    security_expires: \"next year\"\n", "RFC 3339"},
+		{"    security_contact: \"security@example.com\"\n", "server.meta.security_contact"},
+		{"    robots_disallow: [\"api\"]\n", "server.meta.robots_disallow[0]"},
+	}
+	for _, tt := range tests {
+		if _, err := Load(writeTestConfig(t, withMeta(tt.block))); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.block, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_RequestID(t *testing.T) {
+	withServer := func(block string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n"+block, 1)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Setenv("APP__SERVER__REQUEST_ID__TRUSTED_HEADER", "X-Correlation-ID")
+		cfg, err := Load(writeTestConfig(t, withServer("  trusted_proxies: [\"10.0.0.0/8\", \"::1\"]\n  request_id:\n    accept_incoming: true\n")))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !cfg.Server.RequestID.AcceptIncoming || cfg.Server.RequestID.TrustedHeader != "X-Correlation-ID" {
+			t.Errorf("RequestID = %+v", cfg.Server.RequestID)
+		}
+		prefixes, err := ParseTrustedProxies(cfg.Server.TrustedProxies)
+		if err != nil || len(prefixes) != 2 || prefixes[1].String() != "::1/128" {
+			t.Errorf("ParseTrustedProxies() = %v, %v; want [10.0.0.0/8 ::1/128]", prefixes, err)
+		}
+	})
+
+	for name, block := range map[string]string{
+		"invalid server.trusted_proxies":           "  trusted_proxies: [\"proxy.internal\"]\n",
+		"invalid server.request_id.trusted_header": "  request_id:\n    trusted_header: \"X Request ID\"\n",
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, withServer(block)))
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Fatalf("Load() error = %v, want %s", err, name)
+			}
+		})
+	}
+}
+
+func TestLoad_StaticMounts(t *testing.T) {
+	withServer := func(block string) string {
+		return strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n"+block, 1)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		cfg, err := Load(writeTestConfig(t, withServer("  static:\n    mounts:\n"+
+			"      - { url_prefix: \"/static\", dir: \"embedded\", cache_max_age: \"24h\" }\n"+
+			"      - { url_prefix: \" /app/ \", dir: \"/app/dist\" }\n")))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		mounts := cfg.Server.Static.Mounts
+		if len(mounts) != 2 || mounts[0].CacheMaxAge.Std() != 24*time.Hour || mounts[1].URLPrefix != "/app" || mounts[1].CacheMaxAge != 0 {
+			t.Errorf("Mounts = %+v", mounts)
+		}
+	})
+
+	for name, mounts := range map[string]string{
+		"must be a clean path": "      - { url_prefix: \"static\", dir: \"embedded\" }\n",
+		"other than '/'":       "      - { url_prefix: \"/\", dir: \"embedded\" }\n",
+		"collides with /api":   "      - { url_prefix: \"/API/docs\", dir: \"embedded\" }\n",
+		"collides with server.static.mounts[0]": "      - { url_prefix: \"/static\", dir: \"embedded\" }\n" +
+			"      - { url_prefix: \"/static/vendor\", dir: \"/srv/vendor\" }\n",
+		"dir is required":      "      - { url_prefix: \"/static\" }\n",
+		"must not be negative": "      - { url_prefix: \"/static\", dir: \"embedded\", cache_max_age: \"-1s\" }\n",
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, withServer("  static:\n    mounts:\n"+mounts)))
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Fatalf("Load() error = %v, want %q", err, name)
+			}
+		})
+	}
+}
+
+func TestLoad_FeatureFlags(t *testing.T) {
+	withFeatures := func(block string) string {
+		return validBaseYAML("") + "features:\n" + block
+	}
+
+	t.Run("valid names with env override", func(t *testing.T) {
+		t.Setenv("APP__FEATURES__USER_SEARCH", "true")
+		cfg, err := Load(writeTestConfig(t, withFeatures("  user_search: false\n  bulk_delete2: true\n")))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := map[string]bool{"user_search": true, "bulk_delete2": true}
+		if !reflect.DeepEqual(cfg.Features, want) {
+			t.Errorf("Features = %v, want %v", cfg.Features, want)
+		}
+	})
+
+	for _, name := range []string{"UserSearch", "user-search", "_user", "user_", "user__search", "1user"} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := Load(writeTestConfig(t, withFeatures("  "+name+": true\n")))
+			if err == nil || !strings.Contains(err.Error(), "invalid features key") {
+				t.Fatalf("Load() error = %v, want invalid features key", err)
 			}
 		})
 	}
@@ -774,8 +1666,8 @@ func TestLoad_DefaultConfig(t *testing.T) {
 	if cfg.Database.Pool.MaxOpenConns != 100 {
 		t.Errorf("Pool.MaxOpenConns = %d, want %d", cfg.Database.Pool.MaxOpenConns, 100)
 	}
-	if cfg.Database.Pool.ConnMaxLifetime != "1h" {
-		t.Errorf("Pool.ConnMaxLifetime = %q, want %q", cfg.Database.Pool.ConnMaxLifetime, "1h")
+	if cfg.Database.Pool.ConnMaxLifetime != Duration(time.Hour) {
+		t.Errorf("Pool.ConnMaxLifetime = %v, want %v", cfg.Database.Pool.ConnMaxLifetime, time.Hour)
 	}
 }
 
@@ -876,8 +1768,8 @@ func TestLoad_DefaultConfig_AuthFieldsAccessible(t *testing.T) {
 		t.Fatalf("Load() error on project config: %v", err)
 	}
 
-	if cfg.Auth.TokenExpiry != "24h" {
-		t.Errorf("Auth.TokenExpiry = %q, want %q", cfg.Auth.TokenExpiry, "24h")
+	if cfg.Auth.TokenExpiry != Duration(24*time.Hour) {
+		t.Errorf("Auth.TokenExpiry = %v, want %v", cfg.Auth.TokenExpiry, 24*time.Hour)
 	}
 	if len(cfg.Auth.PublicPaths) == 0 {
 		t.Fatal("Auth.PublicPaths is empty, want non-empty")
@@ -885,8 +1777,8 @@ func TestLoad_DefaultConfig_AuthFieldsAccessible(t *testing.T) {
 	if cfg.Auth.PublicPaths[0] != "/api/v1/auth/login" {
 		t.Errorf("Auth.PublicPaths[0] = %q, want %q", cfg.Auth.PublicPaths[0], "/api/v1/auth/login")
 	}
-	if cfg.Auth.RBAC.Cache.RoleTTL != "5m" {
-		t.Errorf("Auth.RBAC.Cache.RoleTTL = %q, want %q", cfg.Auth.RBAC.Cache.RoleTTL, "5m")
+	if cfg.Auth.RBAC.Cache.RoleTTL != Duration(5*time.Minute) {
+		t.Errorf("Auth.RBAC.Cache.RoleTTL = %v, want %v", cfg.Auth.RBAC.Cache.RoleTTL, 5*time.Minute)
 	}
 }
 
@@ -917,6 +1809,35 @@ func TestCountSecretClasses(t *testing.T) {
 	}
 }
 
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &Config{
+		Server:   ServerConfig{Host: "127.0.0.1", CSRFSecret: "csrf-secret"},
+		Database: DatabaseConfig{Postgres: PostgresConfig{User: "app", Password: "db-password"}},
+		Auth:     AuthConfig{JWTSecret: "", JWTSecrets: []JWTKeyConfig{{KID: "2026", Secret: "ring-secret", Primary: true}}},
+	}
+
+	got := cfg.Redacted()
+
+	if got.Server.CSRFSecret != "[REDACTED]" {
+		t.Errorf("Server.CSRFSecret = %q; want [REDACTED]", got.Server.CSRFSecret)
+	}
+	if got.Database.Postgres.Password != "[REDACTED]" {
+		t.Errorf("Database.Postgres.Password = %q; want [REDACTED]", got.Database.Postgres.Password)
+	}
+	if got.Auth.JWTSecret != "" {
+		t.Errorf("Auth.JWTSecret = %q; want empty (unset secrets stay empty)", got.Auth.JWTSecret)
+	}
+	if got.Auth.JWTSecrets[0].Secret != "[REDACTED]" || got.Auth.JWTSecrets[0].KID != "2026" {
+		t.Errorf("Auth.JWTSecrets[0] = %+v; want secret redacted, kid kept", got.Auth.JWTSecrets[0])
+	}
+	if got.Server.Host != "127.0.0.1" || got.Database.Postgres.User != "app" {
+		t.Error("non-secret fields must be preserved")
+	}
+	if cfg.Server.CSRFSecret != "csrf-secret" || cfg.Database.Postgres.Password != "db-password" || cfg.Auth.JWTSecrets[0].Secret != "ring-secret" {
+		t.Error("Redacted must not modify the receiver")
+	}
+}
+
 // validBaseYAML returns a minimal valid YAML config string (sqlite, debug mode).
 func validBaseYAML(extras string) string {
 	return `server:
@@ -966,12 +1887,21 @@ func TestLoad_AuthConfig(t *testing.T) {
 	}{
 		{
 			name:    "auth disabled skips validation",
-			yaml:    validBaseYAML("auth:\n  enabled: false\n  jwt_secret: \"\"\n  token_expiry: \"bad\"\n"),
+			yaml:    validBaseYAML("auth:\n  enabled: false\n  jwt_secret: \"\"\n  token_expiry: \"\"\n"),
 			wantErr: false,
 		},
 		{
-			name:        "auth enabled with empty jwt_secret",
-			yaml:        validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"),
+			// Durations are parsed at load time, so malformed values are
+			// rejected even when the section is disabled.
+			name:        "auth disabled still rejects malformed token_expiry",
+			yaml:        validBaseYAML("auth:\n  enabled: false\n  jwt_secret: \"\"\n  token_expiry: \"bad\"\n"),
+			wantErr:     true,
+			wantContain: "auth.token_expiry",
+		},
+		{
+			// Outside release mode the dev secrets file supplies one.
+			name:        "auth enabled with empty jwt_secret in release mode",
+			yaml:        validReleaseBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"),
 			wantErr:     true,
 			wantContain: "auth.jwt_secret",
 		},
@@ -1044,6 +1974,35 @@ func TestLoad_AuthConfig(t *testing.T) {
 			yaml:    validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \" /api/v1/auth/login \"\n    - \"/api/v1/auth/register\"\n"),
 			wantErr: false,
 		},
+		{
+			name:    "public_paths with method qualifiers and patterns",
+			yaml:    validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"POST /api/v1/auth/*\"\n    - \"get /api/v1/users\"\n    - \"/api/v1/public/**\"\n"),
+			wantErr: false,
+		},
+		{
+			name:        "public_paths with an unknown method",
+			yaml:        validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n    - \"FETCH /api/v1/status\"\n"),
+			wantErr:     true,
+			wantContain: `invalid auth.public_paths[2] "FETCH /api/v1/status": unknown method "FETCH"`,
+		},
+		{
+			name:        "public_paths with a bad pattern",
+			yaml:        validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n    - \"/api/v1/[files\"\n"),
+			wantErr:     true,
+			wantContain: `bad pattern segment "[files"`,
+		},
+		{
+			name:        "public_paths with ** before the last segment",
+			yaml:        validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n    - \"/api/**/status\"\n"),
+			wantErr:     true,
+			wantContain: "'**' must be the whole last segment",
+		},
+		{
+			name:        "public_paths login entry must cover POST",
+			yaml:        validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"GET /api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"),
+			wantErr:     true,
+			wantContain: "/api/v1/auth/login",
+		},
 		{
 			name:        "release mode rejects jwt_secret with low complexity",
 			yaml:        validReleaseBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"),
@@ -1077,6 +2036,232 @@ func TestLoad_AuthConfig(t *testing.T) {
 	}
 }
 
+func TestLoad_JWTSecrets(t *testing.T) {
+	ringYAML := func(keys string) string {
+		return validBaseYAML("auth:\n  enabled: true\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n  jwt_secrets:\n" + keys)
+	}
+	const (
+		oldKey = "    - kid: \"2025\"\n      secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n"
+		newKey = "    - kid: \"2026\"\n      secret: \"zyxwvutsrqponmlkjihgfedcba654321\"\n      primary: true\n"
+	)
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantContain string
+	}{
+		{name: "valid ring", yaml: ringYAML(oldKey + newKey)},
+		{name: "duplicate kid", yaml: ringYAML(oldKey + strings.Replace(newKey, "2026", "2025", 1)), wantContain: `duplicate auth.jwt_secrets kid "2025"`},
+		{name: "no primary", yaml: ringYAML(oldKey), wantContain: "exactly one primary key, got 0"},
+		{name: "two primaries", yaml: ringYAML(strings.Replace(oldKey, "secret:", "primary: true\n      secret:", 1) + newKey), wantContain: "exactly one primary key, got 2"},
+		{name: "missing kid", yaml: ringYAML(strings.Replace(newKey, `"2026"`, `" "`, 1)), wantContain: "auth.jwt_secrets[0].kid is required"},
+		{name: "short secret", yaml: ringYAML(oldKey + strings.Replace(newKey, "zyxwvutsrqponmlkjihgfedcba654321", "short", 1)), wantContain: "invalid auth.jwt_secrets[1].secret"},
+		{
+			name:        "jwt_secret and jwt_secrets together",
+			yaml:        ringYAML(newKey) + "  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n",
+			wantContain: "mutually exclusive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, tt.yaml))
+			if tt.wantContain == "" {
+				if err != nil {
+					t.Fatalf("Load() unexpected error: %v", err)
+				}
+				keys := cfg.Auth.SigningKeys()
+				if len(keys) != 2 || keys[1].KID != "2026" || !keys[1].Primary {
+					t.Errorf("SigningKeys() = %+v, want the configured ring", keys)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+				t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestLoad_APIKeys(t *testing.T) {
+	keysYAML := func(keys string) string {
+		return validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n  api_keys:\n" + keys)
+	}
+	const (
+		hash   = "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824"
+		export = "    - name: \" export \"\n      key_hash: \"" + hash + "\"\n      scopes: [\"users:read\", \" reports:* \"]\n"
+	)
+
+	cfg, err := Load(writeTestConfig(t, keysYAML(export)))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := APIKeyConfig{Name: "export", KeyHash: strings.ToLower(hash), Scopes: []string{"users:read", "reports:*"}}
+	if len(cfg.Auth.APIKeys) != 1 || !reflect.DeepEqual(cfg.Auth.APIKeys[0], want) {
+		t.Errorf("APIKeys = %+v, want %+v", cfg.Auth.APIKeys, want)
+	}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		wantContain string
+	}{
+		{"bad name", keysYAML(strings.Replace(export, " export ", "bad name", 1)), "invalid auth.api_keys[0].name"},
+		{"duplicate name", keysYAML(export + strings.Replace(export, hash, strings.Repeat("ab", 32), 1)), `duplicate auth.api_keys name "export"`},
+		{"plain key instead of hash", keysYAML(strings.Replace(export, hash, "s3cret", 1)), "invalid auth.api_keys[0].key_hash"},
+		{"duplicate hash", keysYAML(export + strings.Replace(export, " export ", "other", 1)), "auth.api_keys[1].key_hash: duplicate"},
+		{"scope without action", keysYAML(strings.Replace(export, "users:read", "users:", 1)), "invalid auth.api_keys[0].scopes[0]"},
+		{"scope without resource", keysYAML(strings.Replace(export, "users:read", "read", 1)), "invalid auth.api_keys[0].scopes[0]"},
+		{"auth disabled", validBaseYAML("auth:\n  api_keys:\n" + export), "auth.api_keys requires auth.enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeTestConfig(t, tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+				t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestLoad_PasswordHashing(t *testing.T) {
+	authYAML := func(extra string) string {
+		return validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n" + extra)
+	}
+
+	cfg, err := Load(writeTestConfig(t, authYAML("")))
+	if err != nil {
+		t.Fatalf("Load() defaults error = %v", err)
+	}
+	if cfg.Auth.BcryptCost != DefaultBcryptCost || cfg.Auth.PasswordAlgorithm != "bcrypt" {
+		t.Errorf("defaults = %d, %q; want %d, bcrypt", cfg.Auth.BcryptCost, cfg.Auth.PasswordAlgorithm, DefaultBcryptCost)
+	}
+
+	cfg, err = Load(writeTestConfig(t, authYAML("  bcrypt_cost: 12\n  password_algorithm: \" Argon2id \"\n")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Auth.BcryptCost != 12 || cfg.Auth.PasswordAlgorithm != "argon2id" {
+		t.Errorf("got %d, %q; want 12, argon2id", cfg.Auth.BcryptCost, cfg.Auth.PasswordAlgorithm)
+	}
+
+	tests := []struct {
+		name        string
+		extra       string
+		wantContain string
+	}{
+		{"cost too low", "  bcrypt_cost: 3\n", "invalid auth.bcrypt_cost 3"},
+		{"cost too high", "  bcrypt_cost: 32\n", "invalid auth.bcrypt_cost 32"},
+		{"unknown algorithm", "  password_algorithm: \"scrypt\"\n", `invalid auth.password_algorithm "scrypt"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeTestConfig(t, authYAML(tt.extra))); err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+				t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestAuthConfig_SigningKeysFallsBackToJWTSecret(t *testing.T) {
+	auth := AuthConfig{JWTSecret: "abcdefghijklmnopqrstuvwxyz123456"}
+	keys := auth.SigningKeys()
+	if len(keys) != 1 || keys[0].KID != DefaultJWTKeyID || keys[0].Secret != auth.JWTSecret || !keys[0].Primary {
+		t.Errorf("SigningKeys() = %+v, want jwt_secret as the only primary key", keys)
+	}
+}
+
+func TestLoad_RegistrationMode(t *testing.T) {
+	authYAML := func(mode string, paths ...string) string {
+		y := "auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n"
+		for _, p := range paths {
+			y += "    - \"" + p + "\"\n"
+		}
+		if mode != "" {
+			y += "  registration_mode: \"" + mode + "\"\n"
+		}
+		y += "  rbac:\n    enabled: true\n    cache:\n      role_ttl: \"5m\"\n      user_role_ttl: \"5m\"\n      permission_ttl: \"5m\"\n      max_role_entries: 100\n      max_user_entries: 500\n      max_permission_entries: 200\n"
+		return validBaseYAML(y)
+	}
+	both := []string{"/api/v1/auth/login", "/api/v1/auth/register"}
+
+	tests := []struct {
+		name        string
+		yaml        string
+		want        string
+		wantContain string
+	}{
+		{name: "defaults to open", yaml: authYAML("", both...), want: "open"},
+		{name: "invite normalized", yaml: authYAML(" Invite ", both...), want: "invite"},
+		{name: "disabled without the register path", yaml: authYAML("disabled", "/api/v1/auth/login"), want: "disabled"},
+		{name: "disabled still requires login", yaml: authYAML("disabled", "/api/v1/auth/register"), wantContain: `must include "/api/v1/auth/login"`},
+		{name: "open requires register", yaml: authYAML("open", "/api/v1/auth/login"), wantContain: `must include "/api/v1/auth/register"`},
+		{name: "invite requires register", yaml: authYAML("invite", "/api/v1/auth/login"), wantContain: `must include "/api/v1/auth/register"`},
+		{name: "invalid", yaml: authYAML("closed", both...), wantContain: "invalid auth.registration_mode"},
+		{
+			name:        "invite without rbac",
+			yaml:        strings.Replace(authYAML("invite", both...), "    enabled: true\n    cache:", "    enabled: false\n    cache:", 1),
+			wantContain: "auth.registration_mode \"invite\" requires auth.rbac.enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, tt.yaml))
+			if tt.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantContain) {
+					t.Fatalf("Load() error = %v, want contains %q", err, tt.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if cfg.Auth.RegistrationMode != tt.want {
+				t.Errorf("RegistrationMode = %q; want %q", cfg.Auth.RegistrationMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_RegistrationConflictMode(t *testing.T) {
+	authYAML := func(mode string) string {
+		y := "auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"
+		if mode != "" {
+			y += "  registration_conflict_mode: \"" + mode + "\"\n"
+		}
+		return validBaseYAML(y)
+	}
+
+	tests := []struct {
+		name    string
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{name: "defaults to explicit", mode: "", want: "explicit"},
+		{name: "explicit", mode: "explicit", want: "explicit"},
+		{name: "opaque normalized", mode: " Opaque ", want: "opaque"},
+		{name: "invalid", mode: "silent", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeTestConfig(t, authYAML(tt.mode)))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "auth.registration_conflict_mode") {
+					t.Fatalf("Load() error = %v, want auth.registration_conflict_mode error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if cfg.Auth.RegistrationConflictMode != tt.want {
+				t.Errorf("RegistrationConflictMode = %q; want %q", cfg.Auth.RegistrationConflictMode, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad_RBACConfig(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1131,6 +2316,12 @@ func TestLoad_RBACConfig(t *testing.T) {
 			yaml:    validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n  rbac:\n    enabled: true\n    cache:\n      role_ttl: \"5m\"\n      user_role_ttl: \"5m\"\n      permission_ttl: \"10m\"\n      max_role_entries: 100\n      max_user_entries: 500\n      max_permission_entries: 200\n"),
 			wantErr: false,
 		},
+		{
+			name:        "admin runtime config without rbac",
+			yaml:        strings.Replace(validBaseYAML(""), "server:\n", "server:\n  admin_runtime_config:\n    enabled: true\n", 1),
+			wantErr:     true,
+			wantContain: "server.admin_runtime_config.enabled requires auth.rbac.enabled",
+		},
 		{
 			name:    "rbac disabled skips cache validation",
 			yaml:    validBaseYAML("auth:\n  enabled: true\n  jwt_secret: \"abcdefghijklmnopqrstuvwxyz123456\"\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n  rbac:\n    enabled: false\n    cache:\n      role_ttl: \"\"\n      max_role_entries: 0\n"),
@@ -1157,3 +2348,262 @@ func TestLoad_RBACConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_WebSocket(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	ws := cfg.Server.WebSocket
+	if ws.Enabled || ws.EffectivePingInterval() != DefaultWebSocketPingInterval || ws.EffectiveSendBuffer() != DefaultWebSocketSendBuffer {
+		t.Errorf("default websocket = %+v, want disabled with ping %v and buffer %d", ws, DefaultWebSocketPingInterval, DefaultWebSocketSendBuffer)
+	}
+
+	t.Setenv("APP__SERVER__WEBSOCKET__ENABLED", "true")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "server.websocket.enabled requires auth.enabled") {
+		t.Errorf("Load() without auth error = %v, want server.websocket.enabled requires auth.enabled", err)
+	}
+
+	t.Setenv("APP__SERVER__WEBSOCKET__ENABLED", "false")
+	t.Setenv("APP__SERVER__WEBSOCKET__SEND_BUFFER", "-1")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.websocket.send_buffer") {
+		t.Errorf("Load() with a negative send_buffer error = %v, want invalid server.websocket.send_buffer", err)
+	}
+}
+
+func TestLoad_CORSPresets(t *testing.T) {
+	withCORS := func(base, block string) string {
+		return strings.Replace(base, "  port: 3000\n", "  port: 3000\n  cors:\n"+block, 1)
+	}
+	explicit := "    allow_origins: [\"https://app.example.com\"]\n    allow_methods: [\"GET\"]\n    allow_credentials: true\n"
+
+	for _, tt := range []struct {
+		preset string
+		want   CORSConfig
+	}{
+		{"", CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowMethods: []string{"GET"}, AllowCredentials: true}},
+		{CORSPresetCustom, CORSConfig{Preset: CORSPresetCustom, AllowOrigins: []string{"https://app.example.com"}, AllowMethods: []string{"GET"}, AllowCredentials: true}},
+		{CORSPresetDisabled, CORSConfig{Preset: CORSPresetDisabled}},
+		{CORSPresetSameOrigin, CORSConfig{Preset: CORSPresetSameOrigin, AllowOrigins: []string{"https://example.com"}}},
+		{CORSPresetDevPermissive, CORSConfig{Preset: CORSPresetDevPermissive, AllowOrigins: []string{"*"}, AllowMethods: DevPermissiveCORSMethods, AllowHeaders: DevPermissiveCORSHeaders}},
+	} {
+		t.Run("preset "+tt.preset, func(t *testing.T) {
+			t.Setenv("APP__SERVER__BASE_URL", "https://example.com")
+			cfg, err := Load(writeTestConfig(t, withCORS(validBaseYAML(""), "    preset: \""+tt.preset+"\"\n"+explicit)))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Server.CORS, tt.want) {
+				t.Errorf("cors = %+v, want %+v", cfg.Server.CORS, tt.want)
+			}
+		})
+	}
+
+	t.Run("same-origin without base_url", func(t *testing.T) {
+		cfg, err := Load(writeTestConfig(t, withCORS(validBaseYAML(""), "    preset: \"same-origin\"\n")))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.Server.CORS.AllowOrigins) != 0 {
+			t.Errorf("allow_origins = %v, want none", cfg.Server.CORS.AllowOrigins)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"custom without origins", withCORS(validBaseYAML(""), "    preset: \"custom\"\n"), "server.cors.allow_origins is required"},
+		{"unknown preset", withCORS(validBaseYAML(""), "    preset: \"open\"\n"), "invalid server.cors.preset"},
+		{"dev-permissive in release", withCORS(validReleaseBaseYAML(""), "    preset: \"dev-permissive\"\n"), "not allowed in release mode"},
+		{"wildcard with credentials", withCORS(validBaseYAML(""), "    allow_origins: [\"*\"]\n    allow_credentials: true\n"), "allow_credentials cannot be combined with a wildcard origin"},
+		{"debug default with credentials", withCORS(validBaseYAML(""), "    allow_credentials: true\n"), "allow_credentials cannot be combined with a wildcard origin"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(writeTestConfig(t, tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Without a wildcard, credentials stay allowed, in release mode too.
+	if _, err := Load(writeTestConfig(t, withCORS(validReleaseBaseYAML(""), "    allow_credentials: true\n"))); err != nil {
+		t.Errorf("Load() release with credentials and no origins error = %v, want nil", err)
+	}
+}
+
+func TestLoad_ControlSocketPath(t *testing.T) {
+	t.Setenv("APP__SERVER__CONTROL_SOCKET__PATH", " /run/gobase/ctl.sock ")
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.ControlSocket.Path; got != "/run/gobase/ctl.sock" {
+		t.Errorf("ControlSocket.Path = %q, want trimmed /run/gobase/ctl.sock", got)
+	}
+
+	t.Setenv("APP__SERVER__CONTROL_SOCKET__PATH", "/"+strings.Repeat("a", maxControlSocketPath))
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.control_socket.path") {
+		t.Errorf("Load() with an overlong path error = %v, want invalid server.control_socket.path", err)
+	}
+}
+
+func TestLoad_HTTPTimeouts(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	h := cfg.Server.HTTP
+	if h.EffectiveReadTimeout() != 30*time.Second || h.EffectiveWriteTimeout() != 60*time.Second ||
+		h.EffectiveIdleTimeout() != 120*time.Second || h.EffectiveReadHeaderTimeout() != 10*time.Second {
+		t.Errorf("default timeouts = %v %v %v %v, want 30s 60s 120s 10s", h.EffectiveReadTimeout(),
+			h.EffectiveWriteTimeout(), h.EffectiveIdleTimeout(), h.EffectiveReadHeaderTimeout())
+	}
+
+	t.Setenv("APP__SERVER__HTTP__WRITE_TIMEOUT", "5m")
+	withPaths := strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n",
+		"  mode: \"debug\"\n  http:\n    long_transfer_paths: [\" /users/export \", \"/files/\"]\n", 1)
+	cfg, err = Load(writeTestConfig(t, withPaths))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Server.HTTP.EffectiveWriteTimeout(); got != 5*time.Minute {
+		t.Errorf("EffectiveWriteTimeout() = %v, want 5m", got)
+	}
+	if got := cfg.Server.HTTP.LongTransferPaths; !reflect.DeepEqual(got, []string{"/users/export", "/files/"}) {
+		t.Errorf("LongTransferPaths = %q, want trimmed entries", got)
+	}
+	if got := cfg.Server.HTTP.EffectiveLongTransferTimeout(); got != 30*time.Minute {
+		t.Errorf("EffectiveLongTransferTimeout() = %v, want the 30m default", got)
+	}
+
+	t.Setenv("APP__SERVER__HTTP__LONG_TRANSFER_TIMEOUT", "-1m")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.http.long_transfer_timeout") {
+		t.Errorf("Load() with a negative long transfer timeout error = %v, want invalid server.http.long_transfer_timeout", err)
+	}
+	t.Setenv("APP__SERVER__HTTP__LONG_TRANSFER_TIMEOUT", "")
+
+	t.Setenv("APP__SERVER__HTTP__WRITE_TIMEOUT", "-1s")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid server.http.write_timeout") {
+		t.Errorf("Load() with a negative write timeout error = %v, want invalid server.http.write_timeout", err)
+	}
+	t.Setenv("APP__SERVER__HTTP__WRITE_TIMEOUT", "")
+
+	relative := strings.Replace(withPaths, "\"/files/\"", "\"files\"", 1)
+	if _, err := Load(writeTestConfig(t, relative)); err == nil || !strings.Contains(err.Error(), "invalid server.http.long_transfer_paths[1]") {
+		t.Errorf("Load() with a relative path error = %v, want invalid server.http.long_transfer_paths[1]", err)
+	}
+}
+
+func TestLoad_ErrorRates(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	rates := cfg.Server.ErrorRates
+	if rates.EffectiveWindow() != DefaultErrorRateWindow || rates.EffectiveWarnRatio() != DefaultErrorRateWarnRatio || rates.EffectiveMinRequests() != DefaultErrorRateMinRequests {
+		t.Errorf("effective error rate settings = %v, %v, %d; want the defaults", rates.EffectiveWindow(), rates.EffectiveWarnRatio(), rates.EffectiveMinRequests())
+	}
+
+	for _, tt := range []struct{ env, value, want string }{
+		{"APP__SERVER__ERROR_RATES__WINDOW", "10s", "invalid server.error_rates.window"},
+		{"APP__SERVER__ERROR_RATES__WINDOW", "-1m", "invalid server.error_rates.window"},
+		{"APP__SERVER__ERROR_RATES__WARN_RATIO", "1.5", "invalid server.error_rates.warn_ratio"},
+		{"APP__SERVER__ERROR_RATES__MIN_REQUESTS", "-1", "invalid server.error_rates.min_requests"},
+	} {
+		t.Setenv(tt.env, tt.value)
+		if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load() with %s=%s error = %v, want %s", tt.env, tt.value, err, tt.want)
+		}
+		t.Setenv(tt.env, "")
+	}
+}
+
+func TestLoad_DatabaseSupervisor(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Database.Supervisor.EffectiveInterval(); got != DefaultSupervisorInterval {
+		t.Errorf("EffectiveInterval() = %v, want %v", got, DefaultSupervisorInterval)
+	}
+	if got := cfg.Database.Supervisor.EffectiveFailureThreshold(); got != DefaultSupervisorFailureThreshold {
+		t.Errorf("EffectiveFailureThreshold() = %d, want %d", got, DefaultSupervisorFailureThreshold)
+	}
+
+	t.Setenv("APP__DATABASE__SUPERVISOR__FAILURE_THRESHOLD", "-1")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.supervisor.failure_threshold") {
+		t.Errorf("Load() with a negative threshold error = %v, want invalid database.supervisor.failure_threshold", err)
+	}
+	t.Setenv("APP__DATABASE__SUPERVISOR__FAILURE_THRESHOLD", "")
+	t.Setenv("APP__DATABASE__SUPERVISOR__INTERVAL", "-5s")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.supervisor.interval") {
+		t.Errorf("Load() with a negative interval error = %v, want invalid database.supervisor.interval", err)
+	}
+}
+
+func TestLoad_Tenancy(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Tenancy.Enabled || cfg.Tenancy.Resolver != TenantResolverHeader || cfg.Tenancy.EffectiveHeader() != DefaultTenantHeader {
+		t.Errorf("tenancy defaults = %+v, want disabled header resolver on %s", cfg.Tenancy, DefaultTenantHeader)
+	}
+
+	t.Setenv("APP__TENANCY__ENABLED", "true")
+	t.Setenv("APP__TENANCY__RESOLVER", "Subdomain")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "requires server.base_url") {
+		t.Errorf("Load() with subdomain resolver and no base_url error = %v, want requires server.base_url", err)
+	}
+	t.Setenv("APP__SERVER__BASE_URL", "https://example.com")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() with subdomain resolver error = %v", err)
+	}
+	if cfg.Tenancy.Resolver != TenantResolverSubdomain {
+		t.Errorf("Resolver = %q, want %q", cfg.Tenancy.Resolver, TenantResolverSubdomain)
+	}
+
+	t.Setenv("APP__TENANCY__RESOLVER", "cookie")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid tenancy.resolver") {
+		t.Errorf("Load() with unknown resolver error = %v, want invalid tenancy.resolver", err)
+	}
+	t.Setenv("APP__TENANCY__RESOLVER", "")
+	t.Setenv("APP__TENANCY__HEADER", "X Tenant")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid tenancy.header") {
+		t.Errorf("Load() with bad header error = %v, want invalid tenancy.header", err)
+	}
+}
+
+func TestLoad_SQLiteIntegrityCheck(t *testing.T) {
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Database.SQLite.IntegrityCheck != SQLiteCheckFull || cfg.Database.SQLite.AutoRecover {
+		t.Errorf("sqlite = %+v, want a full check without auto recovery", cfg.Database.SQLite)
+	}
+
+	t.Setenv("APP__DATABASE__SQLITE__INTEGRITY_CHECK", "Quick")
+	t.Setenv("APP__DATABASE__SQLITE__AUTO_RECOVER", "true")
+	t.Setenv("APP__SERVER__MODE", "debug")
+	cfg, err = Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("Load() with quick check and auto recovery in debug mode error = %v", err)
+	}
+	if cfg.Database.SQLite.IntegrityCheck != SQLiteCheckQuick {
+		t.Errorf("IntegrityCheck = %q, want %q", cfg.Database.SQLite.IntegrityCheck, SQLiteCheckQuick)
+	}
+
+	t.Setenv("APP__SERVER__MODE", "release")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.sqlite.auto_recover") {
+		t.Errorf("Load() with auto recovery in release mode error = %v, want invalid database.sqlite.auto_recover", err)
+	}
+	t.Setenv("APP__DATABASE__SQLITE__AUTO_RECOVER", "false")
+	t.Setenv("APP__DATABASE__SQLITE__INTEGRITY_CHECK", "sometimes")
+	if _, err := Load(writeTestConfig(t, validBaseYAML(""))); err == nil || !strings.Contains(err.Error(), "invalid database.sqlite.integrity_check") {
+		t.Errorf("Load() with an unknown check error = %v, want invalid database.sqlite.integrity_check", err)
+	}
+}
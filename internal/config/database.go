@@ -2,8 +2,10 @@ package config
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 	"log/slog"
 	"net"
 	"net/url"
@@ -17,6 +19,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
 )
 
 // SetupDatabase initializes a GORM database connection based on the provided
@@ -40,7 +43,10 @@ func SetupDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*gorm.DB, error) {
 				return nil, fmt.Errorf("failed to create sqlite directory %q: %w", dir, err)
 			}
 		}
-		dialector = sqlite.Open(cfg.SQLite.Path)
+		if err := checkSQLiteFile(&cfg.SQLite, logger); err != nil {
+			return nil, err
+		}
+		dialector = sqlite.Open(SQLiteDSN(cfg.SQLite.Path))
 	case "postgres":
 		dsn := buildPostgresDSN(&cfg.Postgres)
 		dialector = postgres.Open(dsn)
@@ -55,13 +61,30 @@ func SetupDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*gorm.DB, error) {
 		logMode = gormlogger.Info
 	}
 
+	slowThreshold := defaultLogSlowThreshold
+	if cfg.LogSlowThreshold.IsSet() {
+		slowThreshold = cfg.LogSlowThreshold.Std()
+	}
+
 	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: gormlogger.Default.LogMode(logMode),
+		Logger:                 gormLogger(logMode, slowThreshold),
+		NamingStrategy:         schema.NamingStrategy{TablePrefix: cfg.TablePrefix},
+		PrepareStmt:            cfg.PrepareStmt,
+		SkipDefaultTransaction: cfg.SkipDefaultTransaction,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if cfg.ExplainSlow {
+		if err := db.Use(newSlowExplainer(slowThreshold, logger)); err != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+			return nil, fmt.Errorf("register slow query explainer: %w", err)
+		}
+	}
+
 	// ★ M2: Configure connection pool.
 	if err := configurePool(db, &cfg.Pool); err != nil {
 		// Close the already-opened connection before returning.
@@ -75,12 +98,103 @@ func SetupDatabase(cfg *DatabaseConfig, logger *slog.Logger) (*gorm.DB, error) {
 		slog.String("driver", cfg.Driver),
 		slog.Int("max_idle_conns", effectiveMaxIdleConns(cfg.Pool.MaxIdleConns)),
 		slog.Int("max_open_conns", effectiveMaxOpenConns(cfg.Pool.MaxOpenConns)),
-		slog.String("conn_max_lifetime", effectiveConnMaxLifetime(cfg.Pool.ConnMaxLifetime)),
+		slog.String("conn_max_lifetime", effectiveConnMaxLifetime(cfg.Pool.ConnMaxLifetime).String()),
+		slog.Bool("prepare_stmt", cfg.PrepareStmt),
+		slog.Bool("skip_default_transaction", cfg.SkipDefaultTransaction),
+		slog.String("log_slow_threshold", slowThreshold.String()),
+		slog.Bool("explain_slow", cfg.ExplainSlow),
 	)
 
+	if len(cfg.Replicas) > 0 {
+		if cfg.Driver != "postgres" {
+			logger.Warn("database.replicas ignored: read replicas require driver postgres",
+				slog.String("driver", cfg.Driver),
+				slog.Int("replicas", len(cfg.Replicas)),
+			)
+			return db, nil
+		}
+		if err := setupReplicas(db, cfg, gormLogger(logMode, slowThreshold), logger); err != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
 
+// sqliteForeignKeys is the DSN parameter that turns on foreign key
+// enforcement, which SQLite leaves off by default, for every connection
+// the pool opens.
+const sqliteForeignKeys = "_pragma=foreign_keys(1)"
+
+// SQLiteDSN returns path with foreign key enforcement added, unless the
+// path already sets the foreign_keys pragma itself.
+func SQLiteDSN(path string) string {
+	if strings.Contains(strings.ToLower(path), "foreign_keys") {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + sqliteForeignKeys
+}
+
+// setupReplicas connects to every database.replicas entry, applies its pool
+// settings, and registers the pools as db's read replicas.
+func setupReplicas(db *gorm.DB, cfg *DatabaseConfig, gormLog gormlogger.Interface, logger *slog.Logger) error {
+	pools := make([]*sql.DB, 0, len(cfg.Replicas))
+	closeAll := func() {
+		for _, pool := range pools {
+			pool.Close()
+		}
+	}
+	for i := range cfg.Replicas {
+		replica := &cfg.Replicas[i]
+		pg := replica.postgres()
+		rdb, err := gorm.Open(postgres.Open(buildPostgresDSN(&pg)), &gorm.Config{
+			Logger: gormLog,
+		})
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to connect to database.replicas[%d]: %w", i, err)
+		}
+		pool, err := rdb.DB()
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to get sql.DB for database.replicas[%d]: %w", i, err)
+		}
+		pools = append(pools, pool)
+		if err := configurePool(rdb, &replica.Pool); err != nil {
+			closeAll()
+			return fmt.Errorf("database.replicas[%d]: %w", i, err)
+		}
+		logger.Info("database replica connected",
+			slog.Int("index", i),
+			slog.String("host", replica.Host),
+			slog.Int("max_idle_conns", effectiveMaxIdleConns(replica.Pool.MaxIdleConns)),
+			slog.Int("max_open_conns", effectiveMaxOpenConns(replica.Pool.MaxOpenConns)),
+		)
+	}
+	if err := UseReplicas(db, pools); err != nil {
+		closeAll()
+		return err
+	}
+	return nil
+}
+
+// gormLogger is GORM's default logger at level with slowThreshold as its
+// slow query threshold.
+func gormLogger(level gormlogger.LogLevel, slowThreshold time.Duration) gormlogger.Interface {
+	return gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
+		SlowThreshold: slowThreshold,
+		LogLevel:      level,
+		Colorful:      true,
+	})
+}
+
 // configurePool sets connection pool parameters on the underlying sql.DB.
 // Zero/empty values are replaced with sensible defaults.
 func configurePool(db *gorm.DB, pool *PoolConfig) error {
@@ -92,18 +206,48 @@ func configurePool(db *gorm.DB, pool *PoolConfig) error {
 	sqlDB.SetMaxIdleConns(effectiveMaxIdleConns(pool.MaxIdleConns))
 	sqlDB.SetMaxOpenConns(effectiveMaxOpenConns(pool.MaxOpenConns))
 
-	lifetime, err := time.ParseDuration(effectiveConnMaxLifetime(pool.ConnMaxLifetime))
-	if err != nil {
-		return fmt.Errorf("invalid pool.conn_max_lifetime %q: %w", pool.ConnMaxLifetime, err)
-	}
-	if lifetime <= 0 {
+	lifetime := effectiveConnMaxLifetime(pool.ConnMaxLifetime)
+	if lifetime < 0 {
 		return fmt.Errorf("invalid pool.conn_max_lifetime %q: must be greater than 0", pool.ConnMaxLifetime)
 	}
-	sqlDB.SetConnMaxLifetime(lifetime)
+	sqlDB.SetConnMaxLifetime(lifetime.Std())
 
 	return nil
 }
 
+// EffectivePool returns pool with zero/empty values replaced by the defaults
+// that SetupDatabase applies, so callers can report the real pool settings.
+func EffectivePool(pool PoolConfig) PoolConfig {
+	return PoolConfig{
+		MaxIdleConns:    effectiveMaxIdleConns(pool.MaxIdleConns),
+		MaxOpenConns:    effectiveMaxOpenConns(pool.MaxOpenConns),
+		ConnMaxLifetime: effectiveConnMaxLifetime(pool.ConnMaxLifetime),
+	}
+}
+
+// maxRetryAttempts bounds database.retry.attempts so a misconfiguration
+// cannot hold a request in a retry loop.
+const maxRetryAttempts = 10
+
+// EffectiveRetry returns retry with zero/empty values replaced by the
+// defaults: 3 attempts and a 100ms maximum backoff.
+func EffectiveRetry(retry RetryConfig) RetryConfig {
+	if retry.Attempts <= 0 {
+		retry.Attempts = 3
+	}
+	if !retry.MaxBackoff.IsSet() {
+		retry.MaxBackoff = Duration(100 * time.Millisecond)
+	}
+	return retry
+}
+
+// RBACTablePrefix returns the table prefix for the rbac storage tables, which
+// are created through database/sql rather than GORM: database.table_prefix
+// followed by rbac's own "rbac_".
+func RBACTablePrefix(cfg *DatabaseConfig) string {
+	return cfg.TablePrefix + "rbac_"
+}
+
 func effectiveMaxIdleConns(v int) int {
 	if v <= 0 {
 		return 10
@@ -118,10 +262,9 @@ func effectiveMaxOpenConns(v int) int {
 	return v
 }
 
-func effectiveConnMaxLifetime(v string) string {
-	v = strings.TrimSpace(v)
-	if v == "" {
-		return "1h"
+func effectiveConnMaxLifetime(v Duration) Duration {
+	if !v.IsSet() {
+		return Duration(time.Hour)
 	}
 	return v
 }
@@ -1,11 +1,15 @@
 package config
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 func TestSetupDatabase_SQLite(t *testing.T) {
@@ -19,7 +23,7 @@ func TestSetupDatabase_SQLite(t *testing.T) {
 		Pool: PoolConfig{
 			MaxIdleConns:    5,
 			MaxOpenConns:    50,
-			ConnMaxLifetime: "30m",
+			ConnMaxLifetime: Duration(30 * time.Minute),
 		},
 	}
 
@@ -44,6 +48,36 @@ func TestSetupDatabase_SQLite(t *testing.T) {
 	}
 }
 
+func TestSetupDatabase_SQLiteForeignKeys(t *testing.T) {
+	cfg := &DatabaseConfig{Driver: "sqlite", SQLite: SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")}}
+	db, err := SetupDatabase(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("SetupDatabase() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	var enabled int
+	if err := db.Raw("PRAGMA foreign_keys").Scan(&enabled).Error; err != nil || enabled != 1 {
+		t.Errorf("PRAGMA foreign_keys = %d, %v; want 1", enabled, err)
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"data/app.db", "data/app.db?_pragma=foreign_keys(1)"},
+		{"file:x?mode=memory&cache=shared", "file:x?mode=memory&cache=shared&_pragma=foreign_keys(1)"},
+		{"data/app.db?_pragma=foreign_keys(0)", "data/app.db?_pragma=foreign_keys(0)"},
+	}
+	for _, tt := range tests {
+		if got := SQLiteDSN(tt.path); got != tt.want {
+			t.Errorf("SQLiteDSN(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestSetupDatabase_PoolDefaults(t *testing.T) {
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
@@ -72,6 +106,35 @@ func TestSetupDatabase_PoolDefaults(t *testing.T) {
 	}
 }
 
+func TestSetupDatabase_GORMOptions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, on := range []bool{false, true} {
+		cfg := &DatabaseConfig{
+			Driver:                 "sqlite",
+			SQLite:                 SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+			PrepareStmt:            on,
+			SkipDefaultTransaction: on,
+		}
+		db, err := SetupDatabase(cfg, logger)
+		if err != nil {
+			t.Fatalf("SetupDatabase() error = %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("db.DB() error = %v", err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+
+		_, prepared := db.ConnPool.(*gorm.PreparedStmtDB)
+		if prepared != on || db.Config.PrepareStmt != on {
+			t.Errorf("PrepareStmt %v: ConnPool %T, Config.PrepareStmt = %v", on, db.ConnPool, db.Config.PrepareStmt)
+		}
+		if db.Config.SkipDefaultTransaction != on {
+			t.Errorf("SkipDefaultTransaction = %v, want %v", db.Config.SkipDefaultTransaction, on)
+		}
+	}
+}
+
 func TestSetupDatabase_UnsupportedDriver(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
@@ -88,27 +151,6 @@ func TestSetupDatabase_UnsupportedDriver(t *testing.T) {
 	}
 }
 
-func TestSetupDatabase_InvalidConnMaxLifetime(t *testing.T) {
-	dir := t.TempDir()
-	dbPath := filepath.Join(dir, "test.db")
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-
-	cfg := &DatabaseConfig{
-		Driver: "sqlite",
-		SQLite: SQLiteConfig{Path: dbPath},
-		Pool: PoolConfig{
-			MaxIdleConns:    5,
-			MaxOpenConns:    50,
-			ConnMaxLifetime: "not-a-duration",
-		},
-	}
-
-	_, err := SetupDatabase(cfg, logger)
-	if err == nil {
-		t.Fatal("SetupDatabase() expected error for invalid duration, got nil")
-	}
-}
-
 func TestSetupDatabase_NonPositiveConnMaxLifetime(t *testing.T) {
 	dir := t.TempDir()
 	dbPath := filepath.Join(dir, "test.db")
@@ -120,7 +162,7 @@ func TestSetupDatabase_NonPositiveConnMaxLifetime(t *testing.T) {
 		Pool: PoolConfig{
 			MaxIdleConns:    5,
 			MaxOpenConns:    50,
-			ConnMaxLifetime: "-1s",
+			ConnMaxLifetime: Duration(-time.Second),
 		},
 	}
 
@@ -145,7 +187,7 @@ func TestSetupDatabase_DebugLogMode(t *testing.T) {
 		Pool: PoolConfig{
 			MaxIdleConns:    5,
 			MaxOpenConns:    20,
-			ConnMaxLifetime: "10m",
+			ConnMaxLifetime: Duration(10 * time.Minute),
 		},
 	}
 
@@ -180,13 +222,10 @@ func TestEffectiveDefaults(t *testing.T) {
 	if got := effectiveMaxOpenConns(50); got != 50 {
 		t.Errorf("effectiveMaxOpenConns(50) = %d; want 50", got)
 	}
-	if got := effectiveConnMaxLifetime(""); got != "1h" {
-		t.Errorf("effectiveConnMaxLifetime(\"\") = %q; want \"1h\"", got)
-	}
-	if got := effectiveConnMaxLifetime("   "); got != "1h" {
-		t.Errorf("effectiveConnMaxLifetime(\"   \") = %q; want \"1h\"", got)
+	if got := effectiveConnMaxLifetime(0); got != Duration(time.Hour) {
+		t.Errorf("effectiveConnMaxLifetime(0) = %v; want 1h", got)
 	}
-	if got := effectiveConnMaxLifetime("30m"); got != "30m" {
-		t.Errorf("effectiveConnMaxLifetime(\"30m\") = %q; want \"30m\"", got)
+	if got := effectiveConnMaxLifetime(Duration(30 * time.Minute)); got != Duration(30*time.Minute) {
+		t.Errorf("effectiveConnMaxLifetime(30m) = %v; want 30m", got)
 	}
 }
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultDevSecretsFile is dev_secrets_file when unset. Relative paths are
+// resolved against the working directory, like database.sqlite.path.
+const DefaultDevSecretsFile = ".local/secrets.json"
+
+// Keys of the secrets the dev secrets file can supply, as reported by
+// Config.FromDevSecrets.
+const (
+	DevSecretCSRF = "server.csrf_secret"
+	DevSecretJWT  = "auth.jwt_secret"
+)
+
+// devSecrets is the content of the dev secrets file.
+type devSecrets struct {
+	CSRFSecret string `json:"csrf_secret"`
+	JWTSecret  string `json:"jwt_secret"`
+}
+
+// IsPlaceholderCSRFSecret reports whether secret is empty or one of the
+// placeholders the sample configuration ships with, i.e. no real secret was
+// configured.
+func IsPlaceholderCSRFSecret(secret string) bool {
+	trimmed := strings.TrimSpace(secret)
+	if trimmed == "" {
+		return true
+	}
+
+	switch strings.ToLower(trimmed) {
+	case "change-me-to-a-random-secret", "change-me-in-env":
+		return true
+	default:
+		return false
+	}
+}
+
+// EffectiveDevSecretsFile returns DevSecretsFile, or DefaultDevSecretsFile
+// when it is unset.
+func (c *Config) EffectiveDevSecretsFile() string {
+	if path := strings.TrimSpace(c.DevSecretsFile); path != "" {
+		return path
+	}
+	return DefaultDevSecretsFile
+}
+
+// FromDevSecrets reports whether Load took the secret at key (DevSecretCSRF
+// or DevSecretJWT) from the dev secrets file.
+func (c *Config) FromDevSecrets(key string) bool {
+	return slices.Contains(c.devSecrets, key)
+}
+
+// applyDevSecrets fills in server.csrf_secret when only a placeholder is
+// configured, and auth.jwt_secret when auth is enabled without a key, from
+// the dev secrets file, so restarts keep the same values: open forms and
+// issued tokens stay valid. A missing file, or one lacking a secret, gets
+// freshly generated values written to it (0600, directory 0700). Nothing
+// is read or written when every secret is configured, and never in release
+// mode, which keeps requiring real secrets. Secret values are never logged.
+func (c *Config) applyDevSecrets() error {
+	if strings.TrimSpace(c.Server.Mode) == gin.ReleaseMode {
+		return nil
+	}
+	needCSRF := IsPlaceholderCSRFSecret(c.Server.CSRFSecret)
+	needJWT := c.Auth.Enabled && strings.TrimSpace(c.Auth.JWTSecret) == "" && len(c.Auth.JWTSecrets) == 0
+	if !needCSRF && !needJWT {
+		return nil
+	}
+
+	path := c.EffectiveDevSecretsFile()
+	secrets, err := readDevSecrets(path)
+	if err != nil {
+		return err
+	}
+	var generated []string
+	for _, s := range []struct {
+		key   string
+		value *string
+	}{
+		{DevSecretCSRF, &secrets.CSRFSecret},
+		{DevSecretJWT, &secrets.JWTSecret},
+	} {
+		if *s.value != "" {
+			continue
+		}
+		if *s.value, err = generateDevSecret(); err != nil {
+			return err
+		}
+		generated = append(generated, s.key)
+	}
+	if len(generated) > 0 {
+		if err := writeDevSecrets(path, secrets); err != nil {
+			return err
+		}
+	}
+
+	if needCSRF {
+		c.Server.CSRFSecret = secrets.CSRFSecret
+		c.devSecrets = append(c.devSecrets, DevSecretCSRF)
+	}
+	if needJWT {
+		c.Auth.JWTSecret = secrets.JWTSecret
+		c.devSecrets = append(c.devSecrets, DevSecretJWT)
+	}
+	slog.Info("using the dev secrets file for unset secrets (never in release mode)",
+		slog.String("file", path), slog.Any("keys", c.devSecrets), slog.Any("generated", generated))
+	return nil
+}
+
+// readDevSecrets reads the dev secrets file; a missing file reads as empty.
+func readDevSecrets(path string) (devSecrets, error) {
+	var secrets devSecrets
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return secrets, nil
+	}
+	if err != nil {
+		return secrets, fmt.Errorf("read dev secrets file: %w", err)
+	}
+	// The decoder's error never quotes the file's values.
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return secrets, fmt.Errorf("parse dev secrets file %s: not a JSON object of secrets; delete it to generate new ones", path)
+	}
+	return secrets, nil
+}
+
+// writeDevSecrets replaces the dev secrets file through a temporary file in
+// the same directory, so a crash never leaves it half written.
+func writeDevSecrets(path string, secrets devSecrets) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode dev secrets: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create dev secrets directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".secrets-*.json") // created 0600
+	if err != nil {
+		return fmt.Errorf("write dev secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write dev secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write dev secrets file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write dev secrets file: %w", err)
+	}
+	return nil
+}
+
+// generateDevSecret returns 32 random bytes, hex encoded.
+func generateDevSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate dev secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain points the dev secrets file of every Load in this package's
+// tests, most of which run in debug mode without a CSRF secret, away from
+// the source tree. Tests of the file set their own.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "config-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("APP__DEV_SECRETS_FILE", filepath.Join(dir, "secrets.json"))
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// devAuthYAML enables auth without a JWT secret, and sets no CSRF secret.
+var devAuthYAML = "auth:\n  enabled: true\n  token_expiry: \"24h\"\n  public_paths:\n    - \"/api/v1/auth/login\"\n    - \"/api/v1/auth/register\"\n"
+
+func TestLoad_DevSecrets_GeneratedOnceAndReused(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), ".local", "secrets.json")
+	t.Setenv("APP__DEV_SECRETS_FILE", secretsFile)
+	path := writeTestConfig(t, validBaseYAML(devAuthYAML))
+
+	first, err := Load(path)
+	if err != nil {
+		t.Fatalf("first Load() error = %v", err)
+	}
+	if len(first.Server.CSRFSecret) < 32 || len(first.Auth.JWTSecret) < 32 {
+		t.Fatalf("secrets not generated: csrf %d chars, jwt %d chars", len(first.Server.CSRFSecret), len(first.Auth.JWTSecret))
+	}
+	if !first.FromDevSecrets(DevSecretCSRF) || !first.FromDevSecrets(DevSecretJWT) {
+		t.Error("FromDevSecrets() = false, want both secrets from the file")
+	}
+
+	info, err := os.Stat(secretsFile)
+	if err != nil {
+		t.Fatalf("secrets file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("secrets file mode = %o, want 600", perm)
+	}
+	if dirInfo, err := os.Stat(filepath.Dir(secretsFile)); err != nil || dirInfo.Mode().Perm() != 0o700 {
+		t.Errorf("secrets directory = %v, %v; want mode 700", dirInfo.Mode(), err)
+	}
+
+	second, err := Load(path)
+	if err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	if second.Server.CSRFSecret != first.Server.CSRFSecret || second.Auth.JWTSecret != first.Auth.JWTSecret {
+		t.Error("second Load() generated new secrets, want the saved ones")
+	}
+}
+
+func TestLoad_DevSecrets_ConfiguredSecretsWin(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.json")
+	t.Setenv("APP__DEV_SECRETS_FILE", secretsFile)
+	const csrf = "configured-csrf-secret-0123456789"
+	path := writeTestConfig(t, strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n  csrf_secret: \""+csrf+"\"\n", 1))
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.CSRFSecret != csrf || cfg.FromDevSecrets(DevSecretCSRF) {
+		t.Errorf("CSRFSecret = %q (from file %v), want the configured one", cfg.Server.CSRFSecret, cfg.FromDevSecrets(DevSecretCSRF))
+	}
+	if _, err := os.Stat(secretsFile); !os.IsNotExist(err) {
+		t.Errorf("secrets file stat error = %v, want it never created", err)
+	}
+}
+
+func TestLoad_DevSecrets_ReleaseModeIgnoresFile(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.json")
+	t.Setenv("APP__DEV_SECRETS_FILE", secretsFile)
+	saved, _ := json.Marshal(devSecrets{
+		CSRFSecret: "Saved-CSRF-secret-0123456789-abcdef",
+		JWTSecret:  "Saved-JWT-secret-0123456789-abcdefg",
+	})
+	if err := os.WriteFile(secretsFile, saved, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(writeTestConfig(t, validReleaseBaseYAML(devAuthYAML)))
+	if err == nil || !strings.Contains(err.Error(), "auth.jwt_secret") {
+		t.Fatalf("Load() error = %v, want auth.jwt_secret still required", err)
+	}
+	if strings.Contains(err.Error(), "Saved-") {
+		t.Errorf("error %q leaks a saved secret", err)
+	}
+}
+
+func TestLoad_DevSecrets_CorruptFile(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.json")
+	t.Setenv("APP__DEV_SECRETS_FILE", secretsFile)
+	if err := os.WriteFile(secretsFile, []byte(`{"csrf_secret": "top-secret-value`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err == nil || !strings.Contains(err.Error(), secretsFile) {
+		t.Fatalf("Load() error = %v, want it to name the secrets file", err)
+	}
+	if strings.Contains(err.Error(), "top-secret-value") {
+		t.Errorf("error %q leaks the file's content", err)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Duration is a time.Duration that is configured as a Go duration string
+// (e.g. "30m", "24h", "1h30m") in YAML and APP__ environment variables.
+//
+// The string is parsed once while the config is loaded, so the rest of the
+// application works with typed values. An empty or whitespace-only string
+// decodes to 0, which optional fields treat as "unset"; an explicit zero such
+// as "0s" is rejected because it could not be told apart from unset.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler; koanf uses it when
+// decoding string values into Duration fields.
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("must be a valid duration (e.g. \"30s\", \"24h\"): %w", err)
+	}
+	if parsed == 0 {
+		return fmt.Errorf("%q must be greater than 0 (leave empty to unset)", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler so Duration values are
+// written back out in the same string form they are configured in.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Std returns d as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// IsSet reports whether a non-zero duration was configured.
+func (d Duration) IsSet() bool {
+	return d != 0
+}
+
+// String returns the normalized time.Duration form with trailing zero units
+// dropped, e.g. "30m" rather than "30m0s" and "1h" rather than "1h0m0s".
+func (d Duration) String() string {
+	s := time.Duration(d).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// durationType is the reflect.Type of Duration, used by durationDecodeHook.
+var durationType = reflect.TypeOf(Duration(0))
+
+// durationDecodeHook decodes string input into Duration via UnmarshalText and
+// rejects any other input kind. Without it a bare YAML number such as
+// "timeout: 30" would be assigned to the underlying int64 as 30ns.
+func durationDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if to != durationType {
+			return data, nil
+		}
+		if from.Kind() != reflect.String {
+			return nil, fmt.Errorf("must be a duration string (e.g. \"30s\", \"24h\"), got %v", data)
+		}
+		var d Duration
+		if err := d.UnmarshalText([]byte(reflect.ValueOf(data).String())); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+}
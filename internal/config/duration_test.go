@@ -0,0 +1,99 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuration_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Duration
+		wantErr string
+	}{
+		{name: "minutes", input: "30m", want: Duration(30 * time.Minute)},
+		{name: "compound", input: "1h30m", want: Duration(90 * time.Minute)},
+		{name: "surrounding whitespace", input: "  24h ", want: Duration(24 * time.Hour)},
+		{name: "negative parses (range checked by Validate)", input: "-1s", want: Duration(-time.Second)},
+		{name: "empty is unset", input: "", want: 0},
+		{name: "whitespace-only is unset", input: "   ", want: 0},
+		{name: "explicit zero rejected", input: "0s", wantErr: "greater than 0"},
+		{name: "missing unit", input: "30", wantErr: "must be a valid duration"},
+		{name: "garbage", input: "soon", wantErr: "must be a valid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Duration(time.Minute) // prove unset input overwrites a previous value
+			err := d.UnmarshalText([]byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("UnmarshalText(%q) error = %v, want contains %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnmarshalText(%q) unexpected error: %v", tt.input, err)
+			}
+			if d != tt.want {
+				t.Errorf("UnmarshalText(%q) = %v, want %v", tt.input, d, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration_StringNormalizes(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1500 * time.Millisecond, "1.5s"},
+		{30 * time.Second, "30s"},
+		{30 * time.Minute, "30m"},
+		{90 * time.Minute, "1h30m"},
+		{24 * time.Hour, "24h"},
+		{time.Hour + time.Second, "1h0m1s"},
+	}
+	for _, tt := range tests {
+		d := Duration(tt.in)
+		if got := d.String(); got != tt.want {
+			t.Errorf("Duration(%v).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		text, err := d.MarshalText()
+		if err != nil || string(text) != tt.want {
+			t.Errorf("Duration(%v).MarshalText() = %q, %v; want %q", tt.in, text, err, tt.want)
+		}
+		if got := d.Std(); got != tt.in {
+			t.Errorf("Duration(%v).Std() = %v", tt.in, got)
+		}
+	}
+}
+
+func TestLoad_DurationRejectsBareNumber(t *testing.T) {
+	yaml := strings.Replace(validBaseYAML(""), "  mode: \"debug\"\n", "  mode: \"debug\"\n  timeout: 30\n", 1)
+	path := writeTestConfig(t, yaml)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("Load() expected error for numeric duration, got nil")
+	}
+	if !strings.Contains(err.Error(), "server.timeout") {
+		t.Fatalf("Load() error = %v, want contains %q", err, "server.timeout")
+	}
+}
+
+func TestLoad_DurationEnvOverride(t *testing.T) {
+	t.Setenv("APP__SERVER__TIMEOUT", "45s")
+	path := writeTestConfig(t, validBaseYAML(""))
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Server.Timeout.Std() != 45*time.Second {
+		t.Errorf("Server.Timeout = %v, want 45s", cfg.Server.Timeout)
+	}
+}
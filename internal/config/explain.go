@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// explainPluginName is the key of the slow query explainer in
+// gorm.Config.Plugins.
+const explainPluginName = "gobase:explain_slow"
+
+// explainStartKey is the statement instance key holding the start time.
+const explainStartKey = "gobase:explain_start"
+
+// explainInterval is how often the plan of one statement is logged at most.
+const explainInterval = time.Minute
+
+// defaultLogSlowThreshold is database.log_slow_threshold when unset, GORM's
+// own slow query threshold.
+const defaultLogSlowThreshold = 200 * time.Millisecond
+
+// slowExplainer is a gorm plugin (database.explain_slow, debug mode only)
+// that logs the query plan of queries (Find, First, Count, ...) and raw SQL
+// (Exec) slower than threshold. Row and Rows are left out: their result set
+// is still open when the callback runs, and explaining on a one-connection
+// pool would wait for it.
+//
+// The plan comes from EXPLAIN QUERY PLAN on SQLite and EXPLAIN (ANALYZE
+// false) on PostgreSQL, run with the statement's bound parameters directly
+// on its connection so it passes through no callbacks. Each statement,
+// identified by its SQL text with placeholders, is explained at most once
+// per explainInterval.
+type slowExplainer struct {
+	threshold time.Duration
+	logger    *slog.Logger
+	now       func() time.Time
+
+	mu     sync.Mutex
+	logged map[uint64]time.Time // statement digest -> last explained
+}
+
+func newSlowExplainer(threshold time.Duration, logger *slog.Logger) *slowExplainer {
+	return &slowExplainer{threshold: threshold, logger: logger, now: time.Now, logged: make(map[uint64]time.Time)}
+}
+
+// Name implements gorm.Plugin.
+func (e *slowExplainer) Name() string { return explainPluginName }
+
+// Initialize implements gorm.Plugin.
+func (e *slowExplainer) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("gobase:explain_start_query", e.start); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("gobase:explain_query", e.explain); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("gobase:explain_start_raw", e.start); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("gobase:explain_raw", e.explain)
+}
+
+func (e *slowExplainer) start(db *gorm.DB) {
+	db.InstanceSet(explainStartKey, e.now())
+}
+
+// explain logs the plan of the statement that just ran when it was slow
+// and has not been explained recently.
+func (e *slowExplainer) explain(db *gorm.DB) {
+	v, ok := db.InstanceGet(explainStartKey)
+	if !ok || db.Error != nil {
+		return
+	}
+	elapsed := e.now().Sub(v.(time.Time))
+	stmt := db.Statement
+	if elapsed <= e.threshold || stmt.SQL.Len() == 0 {
+		return
+	}
+	sql := stmt.SQL.String()
+	prefix := explainPrefix(db.Dialector.Name())
+	if prefix == "" || !e.due(sql) {
+		return
+	}
+
+	plan, err := queryPlan(stmt.Context, stmt.ConnPool, prefix+sql, stmt.Vars)
+	attrs := []any{
+		slog.String("sql", db.Dialector.Explain(sql, stmt.Vars...)),
+		slog.Duration("duration", elapsed),
+		slog.Duration("threshold", e.threshold),
+	}
+	if err != nil {
+		e.logger.Warn("slow sql: explain failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	e.logger.Warn("slow sql", append(attrs, slog.String("plan", plan))...)
+}
+
+// due reports whether sql may be explained now and records it if so.
+func (e *slowExplainer) due(sql string) bool {
+	h := fnv.New64a()
+	h.Write([]byte(sql))
+	digest := h.Sum64()
+
+	now := e.now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if last, ok := e.logged[digest]; ok && now.Sub(last) < explainInterval {
+		return false
+	}
+	if len(e.logged) >= 1024 {
+		for d, last := range e.logged {
+			if now.Sub(last) >= explainInterval {
+				delete(e.logged, d)
+			}
+		}
+	}
+	e.logged[digest] = now
+	return true
+}
+
+// explainPrefix returns the EXPLAIN form for the dialect, or "" when it is
+// not supported.
+func explainPrefix(dialect string) string {
+	switch dialect {
+	case "sqlite":
+		return "EXPLAIN QUERY PLAN "
+	case "postgres":
+		return "EXPLAIN (ANALYZE false) "
+	}
+	return ""
+}
+
+// queryPlan runs the EXPLAIN statement and joins the last column of each
+// row (SQLite's detail, PostgreSQL's QUERY PLAN) into one line per step.
+func queryPlan(ctx context.Context, pool gorm.ConnPool, sql string, vars []any) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rows, err := pool.QueryContext(ctx, sql, vars...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	values := make([]any, len(cols))
+	for i := range values {
+		values[i] = new(any)
+	}
+	var lines []string
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return "", err
+		}
+		switch v := (*values[len(values)-1].(*any)).(type) {
+		case string:
+			lines = append(lines, v)
+		case []byte:
+			lines = append(lines, string(v))
+		}
+	}
+	return strings.Join(lines, "\n"), rows.Err()
+}
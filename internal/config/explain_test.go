@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetupDatabase_ExplainSlow(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	db, err := SetupDatabase(&DatabaseConfig{
+		Driver:           "sqlite",
+		SQLite:           SQLiteConfig{Path: filepath.Join(t.TempDir(), "explain.db")},
+		LogSlowThreshold: Duration(time.Nanosecond),
+		ExplainSlow:      true,
+	}, logger)
+	if err != nil {
+		t.Fatalf("SetupDatabase() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// An unindexed table large enough that every lookup is a full scan.
+	if err := db.Exec("CREATE TABLE events (kind INTEGER)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO events (kind)
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 20000)
+		SELECT i % 50 FROM n`).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	logs.Reset()
+
+	for range 3 {
+		var n int64
+		if err := db.Table("events").Where("kind = ?", 7).Count(&n).Error; err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if n != 400 {
+			t.Fatalf("count = %d, want 400", n)
+		}
+	}
+
+	out := logs.String()
+	if got := strings.Count(out, `msg="slow sql"`); got != 1 {
+		t.Fatalf("slow sql logged %d times, want once per statement: %s", got, out)
+	}
+	for _, want := range []string{"SCAN events", "kind = 7", "duration="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q: %s", want, out)
+		}
+	}
+}
+
+func TestSlowExplainer_Due(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := newSlowExplainer(time.Millisecond, slog.Default())
+	e.now = func() time.Time { return now }
+
+	if !e.due("SELECT 1") {
+		t.Fatal("first due() = false, want true")
+	}
+	if e.due("SELECT 1") {
+		t.Error("repeat within interval: due() = true, want false")
+	}
+	if !e.due("SELECT 2") {
+		t.Error("other statement: due() = false, want true")
+	}
+	now = now.Add(explainInterval)
+	if !e.due("SELECT 1") {
+		t.Error("after interval: due() = false, want true")
+	}
+}
+
+func TestValidate_ExplainSlowDebugOnly(t *testing.T) {
+	for _, mode := range []string{"release", "test"} {
+		t.Setenv("APP__SERVER__MODE", mode)
+		t.Setenv("APP__DATABASE__EXPLAIN_SLOW", "true")
+		_, err := Load(writeTestConfig(t, validBaseYAML("")))
+		if err == nil || !strings.Contains(err.Error(), "database.explain_slow") {
+			t.Errorf("mode %s: Load() error = %v, want database.explain_slow error", mode, err)
+		}
+	}
+
+	t.Setenv("APP__SERVER__MODE", "debug")
+	cfg, err := Load(writeTestConfig(t, validBaseYAML("")))
+	if err != nil {
+		t.Fatalf("debug: Load() error = %v", err)
+	}
+	if !cfg.Database.ExplainSlow {
+		t.Error("debug: ExplainSlow = false, want true")
+	}
+}
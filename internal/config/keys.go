@@ -0,0 +1,73 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// knownKeys holds the dotted keys of every Config field (see Schema), and
+// wildcardKeys those whose children are free-form: map fields such as
+// features, where any "features.<name>" is accepted.
+var knownKeys, wildcardKeys = collectKnownKeys()
+
+func collectKnownKeys() (known, wildcard map[string]bool) {
+	known = make(map[string]bool)
+	wildcard = make(map[string]bool)
+	for _, f := range Schema().Fields {
+		if strings.Contains(f.Key, "[]") {
+			// List elements are decoded from one list value, not from keys.
+			continue
+		}
+		known[f.Key] = true
+		if f.Type == "object" {
+			wildcard[f.Key] = true
+		}
+	}
+	return known, wildcard
+}
+
+// UnknownKeys returns the sorted subset of keys (flattened koanf keys, as
+// from koanf.Keys) that no Config field would read. A key is accepted when it
+// names a field, a section containing fields (e.g. "server" left empty in
+// YAML), or a child of a wildcard map field.
+func UnknownKeys(keys []string) []string {
+	var unknown []string
+	for _, key := range keys {
+		if !isKnownKey(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func isKnownKey(key string) bool {
+	if knownKeys[key] {
+		return true
+	}
+	for prefix := range wildcardKeys {
+		if strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	for known := range knownKeys {
+		if strings.HasPrefix(known, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// describeUnknownKeys labels each unknown key with where it came from: keys
+// absent from fileKeys were introduced by an APP__ environment variable.
+func describeUnknownKeys(unknown []string, fileKeys map[string]bool) []string {
+	described := make([]string, len(unknown))
+	for i, key := range unknown {
+		if fileKeys[key] {
+			described[i] = key
+			continue
+		}
+		described[i] = key + " (env APP__" + strings.ToUpper(strings.ReplaceAll(key, ".", "__")) + ")"
+	}
+	return described
+}
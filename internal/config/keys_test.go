@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// captureWarnings routes the default slog logger into a buffer for the
+// duration of the test.
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		env     map[string]string
+		unknown []string
+	}{
+		{
+			name:    "misspelled top-level section",
+			yaml:    validBaseYAML("databse:\n  driver: \"postgres\"\n"),
+			unknown: []string{"databse.driver"},
+		},
+		{
+			name:    "misspelled nested key",
+			yaml:    strings.Replace(validBaseYAML(""), "  port: 3000\n", "  port: 3000\n  rate_limti:\n    enabled: true\n", 1),
+			unknown: []string{"server.rate_limti.enabled"},
+		},
+		{
+			name:    "misspelled deeply nested key",
+			yaml:    validBaseYAML("auth:\n  rbac:\n    cahce:\n      role_ttl: \"5m\"\n"),
+			unknown: []string{"auth.rbac.cahce.role_ttl"},
+		},
+		{
+			name: "wildcard section accepts any child",
+			yaml: validBaseYAML("features:\n  user_search: true\n  brand_new_flag: false\n"),
+		},
+		{
+			name: "empty known section",
+			yaml: validBaseYAML("auth:\n"),
+		},
+		{
+			name:    "env-only key",
+			yaml:    validBaseYAML(""),
+			env:     map[string]string{"APP__SERVER__PROT": "9090"},
+			unknown: []string{"server.prot (env APP__SERVER__PROT)"},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			mode := "lenient"
+			if strict {
+				mode = "strict"
+			}
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				for k, v := range tt.env {
+					t.Setenv(k, v)
+				}
+				yaml := tt.yaml
+				if strict {
+					yaml += "strict_keys: true\n"
+				}
+				logs := captureWarnings(t)
+
+				_, err := Load(writeTestConfig(t, yaml))
+
+				switch {
+				case len(tt.unknown) == 0:
+					if err != nil {
+						t.Fatalf("Load() error = %v, want nil", err)
+					}
+					if logs.Len() != 0 {
+						t.Errorf("unexpected warning: %s", logs.String())
+					}
+				case strict:
+					if err == nil {
+						t.Fatal("Load() error = nil, want unknown keys error")
+					}
+					for _, key := range tt.unknown {
+						if !strings.Contains(err.Error(), key) {
+							t.Errorf("error %q does not mention %q", err, key)
+						}
+					}
+				default:
+					if err != nil {
+						t.Fatalf("Load() error = %v, want only a warning", err)
+					}
+					for _, key := range tt.unknown {
+						if !strings.Contains(logs.String(), key) {
+							t.Errorf("warning %q does not mention %q", logs.String(), key)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	got := UnknownKeys([]string{
+		"server.port",
+		"server",
+		"server.cache.warm",
+		"features.anything",
+		"features",
+		"zzz",
+		"server.typo",
+		"featuresx.flag",
+	})
+	want := []string{"featuresx.flag", "server.typo", "zzz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownKeys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/simp-lee/logger"
+)
+
+// logFailureWarnInterval is how often the degraded-logging warning is
+// written to the fallback at most.
+const logFailureWarnInterval = time.Minute
+
+// LogFailsafe keeps logging from failing requests when the configured
+// outputs break at runtime (disk full, log directory removed): records a
+// handler fails to write, or panics on, are written as JSON to the fallback
+// writer instead and counted. While failures continue, a warning about the
+// degraded state is written to the fallback at most once per minute.
+//
+// One LogFailsafe is shared by every logger the App builds, so Failures
+// covers the application, request and recovery loggers alike.
+type LogFailsafe struct {
+	fallback slog.Handler
+	now      func() time.Time
+
+	failures atomic.Uint64
+	lastWarn atomic.Int64 // unix nanoseconds of the last warning
+}
+
+// NewLogFailsafe returns a LogFailsafe writing to fallback, typically
+// os.Stderr.
+func NewLogFailsafe(fallback io.Writer) *LogFailsafe {
+	return &LogFailsafe{
+		fallback: slog.NewJSONHandler(fallback, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		now:      time.Now,
+	}
+}
+
+// Failures returns how many records could not be written by the configured
+// handlers.
+func (f *LogFailsafe) Failures() uint64 {
+	if f == nil {
+		return 0
+	}
+	return f.failures.Load()
+}
+
+// Middleware returns the logger.Middleware that wraps a handler with f. Add
+// it last with logger.WithMiddleware so it also guards the other middleware.
+func (f *LogFailsafe) Middleware() logger.Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &failsafeHandler{next: next, fallback: f.fallback, fs: f}
+	}
+}
+
+// fail counts a failed record, writes it to the fallback, and warns about
+// the degraded state when the last warning is old enough.
+func (f *LogFailsafe) fail(ctx context.Context, fallback slog.Handler, r slog.Record, cause error) {
+	n := f.failures.Add(1)
+	_ = fallback.Handle(ctx, r)
+
+	now := f.now()
+	last := f.lastWarn.Load()
+	if now.UnixNano()-last < int64(logFailureWarnInterval) || !f.lastWarn.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	warn := slog.NewRecord(now, slog.LevelWarn, "log output failing, writing to stderr", 0)
+	warn.AddAttrs(slog.Uint64("failures", n), slog.String("error", cause.Error()))
+	_ = f.fallback.Handle(ctx, warn)
+}
+
+// failsafeHandler is the slog.Handler installed by LogFailsafe.Middleware.
+// fallback carries the same attributes and groups as next.
+type failsafeHandler struct {
+	next     slog.Handler
+	fallback slog.Handler
+	fs       *LogFailsafe
+}
+
+func (h *failsafeHandler) Enabled(ctx context.Context, level slog.Level) (enabled bool) {
+	defer func() {
+		if recover() != nil {
+			enabled = true
+		}
+	}()
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle never returns an error: a failure is reported through the
+// fallback instead of to the caller.
+func (h *failsafeHandler) Handle(ctx context.Context, r slog.Record) error {
+	defer func() {
+		if p := recover(); p != nil {
+			h.fs.fail(ctx, h.fallback, r, fmt.Errorf("log handler panic: %v", p))
+		}
+	}()
+	if err := h.next.Handle(ctx, r.Clone()); err != nil {
+		h.fs.fail(ctx, h.fallback, r, err)
+	}
+	return nil
+}
+
+func (h *failsafeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &failsafeHandler{next: h.next.WithAttrs(attrs), fallback: h.fallback.WithAttrs(attrs), fs: h.fs}
+}
+
+func (h *failsafeHandler) WithGroup(name string) slog.Handler {
+	return &failsafeHandler{next: h.next.WithGroup(name), fallback: h.fallback.WithGroup(name), fs: h.fs}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/logger"
+)
+
+// brokenHandler stands in for an output that fails at runtime: it returns
+// an error, or panics when panics is set.
+type brokenHandler struct {
+	slog.Handler
+	panics bool
+}
+
+func (h brokenHandler) Handle(context.Context, slog.Record) error {
+	if h.panics {
+		panic("write on closed file")
+	}
+	return errors.New("no space left on device")
+}
+
+func (h brokenHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return brokenHandler{Handler: h.Handler.WithAttrs(attrs), panics: h.panics}
+}
+
+func newBrokenLogger(t *testing.T, fs *LogFailsafe, panics bool) *logger.Logger {
+	t.Helper()
+	log, err := logger.New(
+		logger.WithConsoleWriter(io.Discard),
+		logger.WithMiddleware(func(next slog.Handler) slog.Handler { return brokenHandler{Handler: next, panics: panics} }),
+		logger.WithMiddleware(fs.Middleware()),
+	)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return log
+}
+
+func TestLogFailsafe_FallsBackAndCounts(t *testing.T) {
+	var stderr bytes.Buffer
+	fs := NewLogFailsafe(&stderr)
+	now := time.Unix(1_700_000_000, 0)
+	fs.now = func() time.Time { return now }
+	log := newBrokenLogger(t, fs, false)
+
+	log.With("component", "test").Info("first", "k", "v")
+	log.Info("second")
+	if got := fs.Failures(); got != 2 {
+		t.Fatalf("Failures() = %d, want 2", got)
+	}
+	out := stderr.String()
+	for _, want := range []string{`"msg":"first"`, `"component":"test"`, `"k":"v"`, `"msg":"second"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("fallback missing %s: %s", want, out)
+		}
+	}
+	if got := strings.Count(out, "log output failing"); got != 1 {
+		t.Errorf("degraded warning written %d times, want once: %s", got, out)
+	}
+	if !strings.Contains(out, "no space left on device") {
+		t.Errorf("warning missing the cause: %s", out)
+	}
+
+	now = now.Add(logFailureWarnInterval)
+	log.Info("third")
+	if got := strings.Count(stderr.String(), "log output failing"); got != 2 {
+		t.Errorf("after the interval the warning was written %d times, want 2", got)
+	}
+}
+
+func TestLogFailsafe_RecoversHandlerPanic(t *testing.T) {
+	var stderr bytes.Buffer
+	fs := NewLogFailsafe(&stderr)
+	log := newBrokenLogger(t, fs, true)
+
+	log.Error("boom")
+	if got := fs.Failures(); got != 1 {
+		t.Errorf("Failures() = %d, want 1", got)
+	}
+	if out := stderr.String(); !strings.Contains(out, `"msg":"boom"`) || !strings.Contains(out, "log handler panic") {
+		t.Errorf("fallback = %s, want the record and the panic", out)
+	}
+}
+
+func TestLogFailsafe_HealthyOutputUntouched(t *testing.T) {
+	var out, stderr bytes.Buffer
+	fs := NewLogFailsafe(&stderr)
+	log, err := logger.New(
+		logger.WithConsoleWriter(&out),
+		logger.WithConsoleFormat(logger.FormatJSON),
+		logger.WithMiddleware(fs.Middleware()),
+	)
+	if err != nil {
+		t.Fatalf("logger.New() error = %v", err)
+	}
+	defer log.Close()
+
+	log.Info("hello")
+	if fs.Failures() != 0 || stderr.Len() != 0 {
+		t.Errorf("Failures() = %d, fallback = %q; want nothing", fs.Failures(), stderr.String())
+	}
+	if !strings.Contains(out.String(), "hello") {
+		t.Errorf("output = %q, want the record", out.String())
+	}
+	var nilFS *LogFailsafe
+	if nilFS.Failures() != 0 {
+		t.Error("nil Failures() != 0")
+	}
+}
@@ -17,7 +17,7 @@ func BuildLoggerOpts(cfg *LogConfig) []logger.Option {
 		return nil
 	}
 
-	level := parseLevel(cfg.Level)
+	level := ParseLogLevel(cfg.Level)
 
 	// Determine output format
 	var format logger.OutputFormat
@@ -66,17 +66,18 @@ func BuildLoggerOpts(cfg *LogConfig) []logger.Option {
 	return opts
 }
 
-// SetupLogger creates a *logger.Logger based on the provided LogConfig,
-// sets it as the global default via slog.SetDefault, and returns it.
+// SetupLogger creates a *logger.Logger based on the provided LogConfig and
+// extra options (such as a LogFailsafe middleware), sets it as the global
+// default via slog.SetDefault, and returns it.
 // The caller is responsible for calling Close() on the returned logger.
 // Invalid level values default to "info"; when called with an unchecked config,
 // invalid format values fall back to "custom".
-func SetupLogger(cfg *LogConfig) (*logger.Logger, error) {
+func SetupLogger(cfg *LogConfig, extra ...logger.Option) (*logger.Logger, error) {
 	if cfg == nil {
 		return nil, errors.New("log config is nil")
 	}
 
-	opts := BuildLoggerOpts(cfg)
+	opts := append(BuildLoggerOpts(cfg), extra...)
 
 	log, err := logger.New(opts...)
 	if err != nil {
@@ -87,9 +88,9 @@ func SetupLogger(cfg *LogConfig) (*logger.Logger, error) {
 	return log, nil
 }
 
-// parseLevel converts a string level name to the corresponding slog.Level.
+// ParseLogLevel converts a string level name to the corresponding slog.Level.
 // Unrecognized values default to slog.LevelInfo.
-func parseLevel(s string) slog.Level {
+func ParseLogLevel(s string) slog.Level {
 	switch strings.ToLower(s) {
 	case "debug":
 		return slog.LevelDebug
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// publicPathMethods are the methods an auth.public_paths entry may be
+// qualified with.
+var publicPathMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// PublicPath is a parsed auth.public_paths entry: a path, optionally
+// preceded by a method ("GET /api/v1/status"). A path segment may be a
+// path.Match pattern ("/api/v1/files/*.txt", "/api/v1/items/*"), and a final
+// "**" segment matches the rest of the path, however deep, including none
+// ("/api/v1/public/**").
+type PublicPath struct {
+	Method string // upper-case; empty for every method
+	Path   string
+}
+
+// ParsePublicPath parses an auth.public_paths entry.
+func ParsePublicPath(entry string) (PublicPath, error) {
+	fields := strings.Fields(entry)
+	var p PublicPath
+	switch len(fields) {
+	case 1:
+		p.Path = fields[0]
+	case 2:
+		p.Method, p.Path = strings.ToUpper(fields[0]), fields[1]
+		if !slices.Contains(publicPathMethods, p.Method) {
+			return PublicPath{}, fmt.Errorf("unknown method %q: must be one of %s", fields[0], strings.Join(publicPathMethods, ", "))
+		}
+	default:
+		return PublicPath{}, errors.New("must be a path, or a method and a path")
+	}
+	if !strings.HasPrefix(p.Path, "/") {
+		return PublicPath{}, errors.New("must start with '/'")
+	}
+	segments := strings.Split(p.Path, "/")
+	for i, seg := range segments {
+		if strings.Contains(seg, "**") {
+			if seg != "**" || i != len(segments)-1 {
+				return PublicPath{}, errors.New("'**' must be the whole last segment")
+			}
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return PublicPath{}, fmt.Errorf("bad pattern segment %q", seg)
+		}
+	}
+	return p, nil
+}
+
+// String returns the entry p was parsed from, normalized.
+func (p PublicPath) String() string {
+	if p.Method == "" {
+		return p.Path
+	}
+	return p.Method + " " + p.Path
+}
+
+// IsPattern reports whether p.Path matches other paths than itself.
+func (p PublicPath) IsPattern() bool {
+	return strings.ContainsAny(p.Path, `*?[\`)
+}
+
+// Matches reports whether a request for method and urlPath is covered by
+// p. An entry for GET covers HEAD as well. Paths are compared as given;
+// callers canonicalize both sides.
+func (p PublicPath) Matches(method, urlPath string) bool {
+	if p.Method != "" && p.Method != method && (p.Method != http.MethodGet || method != http.MethodHead) {
+		return false
+	}
+	if !p.IsPattern() {
+		return p.Path == urlPath
+	}
+	patterns := strings.Split(p.Path, "/")
+	segments := strings.Split(urlPath, "/")
+	for i, pattern := range patterns {
+		if pattern == "**" {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if ok, _ := path.Match(pattern, segments[i]); !ok {
+			return false
+		}
+	}
+	return len(segments) == len(patterns)
+}
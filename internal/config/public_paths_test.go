@@ -0,0 +1,66 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParsePublicPath(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    PublicPath
+		wantErr string
+	}{
+		{entry: "/api/v1/status", want: PublicPath{Path: "/api/v1/status"}},
+		{entry: " get  /api/v1/users ", want: PublicPath{Method: http.MethodGet, Path: "/api/v1/users"}},
+		{entry: "/api/v1/public/**", want: PublicPath{Path: "/api/v1/public/**"}},
+		{entry: "FETCH /api/v1/users", wantErr: "unknown method"},
+		{entry: "GET api/v1/users", wantErr: "must start with '/'"},
+		{entry: "GET /a /b", wantErr: "must be a path, or a method and a path"},
+		{entry: "/api/[v1", wantErr: "bad pattern segment"},
+		{entry: "/api/**/users", wantErr: "'**' must be the whole last segment"},
+		{entry: "/api/v1/a**", wantErr: "'**' must be the whole last segment"},
+	}
+	for _, tt := range tests {
+		got, err := ParsePublicPath(tt.entry)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParsePublicPath(%q) error = %v, want %q", tt.entry, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePublicPath(%q) = %+v, %v; want %+v", tt.entry, got, err, tt.want)
+		}
+	}
+}
+
+func TestPublicPath_Matches(t *testing.T) {
+	tests := []struct {
+		entry, method, path string
+		want                bool
+	}{
+		{"/api/v1/status", http.MethodPost, "/api/v1/status", true},
+		{"/api/v1/status", http.MethodGet, "/api/v1/status/x", false},
+		{"GET /api/v1/users", http.MethodHead, "/api/v1/users", true},
+		{"GET /api/v1/users", http.MethodPost, "/api/v1/users", false},
+		{"/api/v1/items/*", http.MethodGet, "/api/v1/items/7", true},
+		{"/api/v1/items/*", http.MethodGet, "/api/v1/items/7/tags", false},
+		{"/api/v1/items/*", http.MethodGet, "/api/v1/items", false},
+		{"/api/v1/files/*.txt", http.MethodGet, "/api/v1/files/a.txt", true},
+		{"/api/v1/files/*.txt", http.MethodGet, "/api/v1/files/a.json", false},
+		{"/api/v1/public/**", http.MethodGet, "/api/v1/public", true},
+		{"/api/v1/public/**", http.MethodGet, "/api/v1/public/a/b/c", true},
+		{"/api/v1/public/**", http.MethodGet, "/api/v1/publicity", false},
+	}
+	for _, tt := range tests {
+		p, err := ParsePublicPath(tt.entry)
+		if err != nil {
+			t.Fatalf("ParsePublicPath(%q) error = %v", tt.entry, err)
+		}
+		if got := p.Matches(tt.method, tt.path); got != tt.want {
+			t.Errorf("%q.Matches(%s %s) = %v, want %v", tt.entry, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
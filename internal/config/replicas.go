@@ -0,0 +1,97 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// replicasPluginName is the key of the read-replica resolver in
+// gorm.Config.Plugins.
+const replicasPluginName = "gobase:replicas"
+
+// readReplicas is a gorm plugin that sends reads to replica connection pools
+// round-robin. A statement is a read when it runs through the Query or Row
+// callbacks (Find, First, Count, Scan, ...) and, for raw SQL, starts with
+// SELECT. Everything else, statements inside a transaction, and SELECT ...
+// FOR UPDATE keep the primary pool, so repositories need no changes.
+type readReplicas struct {
+	pools []*sql.DB
+	next  atomic.Uint64
+}
+
+// Name implements gorm.Plugin.
+func (r *readReplicas) Name() string { return replicasPluginName }
+
+// Initialize implements gorm.Plugin.
+func (r *readReplicas) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("gobase:replica_query", r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register("gobase:replica_row", r.route)
+}
+
+// route points the statement at the next replica when it is a read that may
+// leave the primary.
+func (r *readReplicas) route(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || len(r.pools) == 0 || inTransaction(stmt.ConnPool) {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if stmt.SQL.Len() > 0 && !isSelect(stmt.SQL.String()) {
+		return
+	}
+	stmt.ConnPool = r.pools[(r.next.Add(1)-1)%uint64(len(r.pools))]
+}
+
+// inTransaction reports whether pool is a transaction (*sql.Tx, or its
+// prepared-statement wrapper), which must not be left mid-flight.
+func inTransaction(pool gorm.ConnPool) bool {
+	_, ok := pool.(gorm.TxCommitter)
+	return ok
+}
+
+func isSelect(query string) bool {
+	query = strings.TrimSpace(query)
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
+
+// UseReplicas registers pools as read replicas of db. SetupDatabase calls it
+// for database.replicas; tests can use it to attach SQLite pools.
+func UseReplicas(db *gorm.DB, pools []*sql.DB) error {
+	if err := db.Use(&readReplicas{pools: pools}); err != nil {
+		return fmt.Errorf("register read replicas: %w", err)
+	}
+	return nil
+}
+
+// Replicas returns the read replica pools SetupDatabase attached to db, in
+// database.replicas order, or nil when there are none.
+func Replicas(db *gorm.DB) []*sql.DB {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	if r, ok := db.Config.Plugins[replicasPluginName].(*readReplicas); ok {
+		return r.pools
+	}
+	return nil
+}
+
+// CloseReplicas closes the read replica pools of db. The primary pool is
+// left to the caller.
+func CloseReplicas(db *gorm.DB) error {
+	var errs []error
+	for i, pool := range Replicas(db) {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close database.replicas[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type replicaItem struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+// openReplicaTestDB opens a SQLite file with the replicaItem table.
+func openReplicaTestDB(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	if err := db.AutoMigrate(&replicaItem{}); err != nil {
+		t.Fatalf("migrate %s: %v", path, err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// newReplicatedDB returns a primary with one replica, each its own SQLite
+// file, and a func reporting which of the two the last query ran on.
+func newReplicatedDB(t *testing.T) (primary *gorm.DB, replicaPool *sql.DB, lastPool func() gorm.ConnPool) {
+	t.Helper()
+	dir := t.TempDir()
+	primary = openReplicaTestDB(t, filepath.Join(dir, "primary.db"))
+	replica := openReplicaTestDB(t, filepath.Join(dir, "replica.db"))
+	replicaPool, _ = replica.DB()
+
+	if err := UseReplicas(primary, []*sql.DB{replicaPool}); err != nil {
+		t.Fatalf("UseReplicas: %v", err)
+	}
+	var last gorm.ConnPool
+	capture := func(db *gorm.DB) { last = db.Statement.ConnPool }
+	if err := primary.Callback().Query().After("gorm:query").Register("test:capture_query", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Callback().Row().After("gorm:row").Register("test:capture_row", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := primary.Callback().Create().After("gorm:create").Register("test:capture_create", capture); err != nil {
+		t.Fatal(err)
+	}
+	return primary, replicaPool, func() gorm.ConnPool { return last }
+}
+
+func TestReadReplicas_RoutesReadsAndWrites(t *testing.T) {
+	primary, replicaPool, lastPool := newReplicatedDB(t)
+	primaryPool, _ := primary.DB()
+
+	if err := primary.Create(&replicaItem{Name: "written"}).Error; err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if lastPool() == gorm.ConnPool(replicaPool) {
+		t.Error("write ran on the replica")
+	}
+
+	// The replica file is empty: reads that go there see no rows.
+	var items []replicaItem
+	if err := primary.Find(&items).Error; err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if lastPool() != gorm.ConnPool(replicaPool) || len(items) != 0 {
+		t.Errorf("Find ran on %T and saw %d rows, want the replica with 0", lastPool(), len(items))
+	}
+
+	var count int64
+	if err := primary.Model(&replicaItem{}).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("Count = %d, %v; want 0 from the replica", count, err)
+	}
+	var name string
+	if err := primary.Raw("SELECT name FROM replica_items").Scan(&name).Error; err != nil || name != "" {
+		t.Errorf("raw SELECT = %q, %v; want no row from the replica", name, err)
+	}
+
+	// Only the routing matters here, not whether SQLite honours the lock.
+	primary.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&items)
+	if lastPool() != gorm.ConnPool(primaryPool) {
+		t.Errorf("SELECT ... FOR UPDATE ran on %T, want the primary", lastPool())
+	}
+}
+
+func TestReadReplicas_TransactionsPinToPrimary(t *testing.T) {
+	primary, replicaPool, lastPool := newReplicatedDB(t)
+
+	err := primary.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&replicaItem{Name: "in tx"}).Error; err != nil {
+			return err
+		}
+		var items []replicaItem
+		if err := tx.Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) != 1 {
+			t.Errorf("read in transaction saw %d rows, want its own write", len(items))
+		}
+		if lastPool() == gorm.ConnPool(replicaPool) {
+			t.Error("read in transaction ran on the replica")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+}
+
+func TestReadReplicas_RoundRobin(t *testing.T) {
+	dir := t.TempDir()
+	primary := openReplicaTestDB(t, filepath.Join(dir, "primary.db"))
+	var pools []*sql.DB
+	for _, name := range []string{"a.db", "b.db"} {
+		pool, _ := openReplicaTestDB(t, filepath.Join(dir, name)).DB()
+		pools = append(pools, pool)
+	}
+	if err := UseReplicas(primary, pools); err != nil {
+		t.Fatalf("UseReplicas: %v", err)
+	}
+	var seen []gorm.ConnPool
+	err := primary.Callback().Query().After("gorm:query").Register("test:capture", func(db *gorm.DB) {
+		seen = append(seen, db.Statement.ConnPool)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 4 {
+		var items []replicaItem
+		primary.Find(&items)
+	}
+	want := []gorm.ConnPool{pools[0], pools[1], pools[0], pools[1]}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("query %d ran on pool %v, want round-robin a, b, a, b", i, seen[i])
+		}
+	}
+	if got := Replicas(primary); len(got) != 2 {
+		t.Errorf("Replicas() = %d pools, want 2", len(got))
+	}
+}
+
+func TestSetupDatabase_SQLiteIgnoresReplicas(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	cfg := &DatabaseConfig{
+		Driver:   "sqlite",
+		SQLite:   SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db")},
+		Replicas: []ReplicaConfig{{Host: "replica", Port: 5432}},
+	}
+
+	db, err := SetupDatabase(cfg, logger)
+	if err != nil {
+		t.Fatalf("SetupDatabase() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if Replicas(db) != nil {
+		t.Error("replicas attached under sqlite")
+	}
+	if !strings.Contains(logs.String(), "database.replicas ignored") {
+		t.Errorf("missing warning, logs:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaField describes one configuration key in the document returned by
+// Schema. Key is the dotted koanf path; list elements use "[]", e.g.
+// "server.cache.warm[].path".
+type SchemaField struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// Format refines Type: "duration" for Duration strings such as "30s".
+	Format string `json:"format,omitempty"`
+	// Items is the element type of arrays and the value type of objects.
+	Items string `json:"items,omitempty"`
+	// Required is true when Validate rejects an empty value; RequiredWhen
+	// names the condition when that only applies in some configurations.
+	Required     bool   `json:"required,omitempty"`
+	RequiredWhen string `json:"required_when,omitempty"`
+	// Default is the value used when the key is unset. Keys without one
+	// default to their zero value.
+	Default any `json:"default,omitempty"`
+	// Env is the APP__ variable that overrides the key; list elements have
+	// none. For maps it ends in "__<NAME>", the upper-cased map key.
+	Env string `json:"env,omitempty"`
+}
+
+// ConfigSchema is the machine-readable description of Config.
+type ConfigSchema struct {
+	EnvPrefix string        `json:"env_prefix"`
+	Fields    []SchemaField `json:"fields"`
+}
+
+// schemaRule is the part of a SchemaField that reflection cannot see.
+type schemaRule struct {
+	required     bool
+	requiredWhen string
+	def          any
+}
+
+// defaultPool holds the pool settings SetupDatabase falls back to.
+var defaultPool = EffectivePool(PoolConfig{})
+
+// defaultRetry holds the retry policy used when database.retry is unset.
+var defaultRetry = EffectiveRetry(RetryConfig{})
+
+// schemaRules records required keys and code-level defaults. Keep it in sync
+// with Validate and the fallbacks applied where each key is consumed.
+var schemaRules = map[string]schemaRule{
+	"server.host":                                  {required: true},
+	"server.port":                                  {required: true},
+	"server.mode":                                  {required: true},
+	"server.csrf_secret":                           {required: true, requiredWhen: "server.mode=release"},
+	"server.timeout":                               {def: "30s"},
+	"server.request_id.trusted_header":             {def: "X-Request-ID"},
+	"server.maintenance_interval":                  {def: "5m"},
+	"server.templates.slow_render_threshold":       {def: "200ms"},
+	"server.templates.watch_interval":              {def: "1s"},
+	"server.health.path":                           {def: "/health"},
+	"server.health.expose_details":                 {def: true},
+	"server.health.timeout":                        {def: "1s"},
+	"server.meta.robots_disallow":                  {def: DefaultRobotsDisallow},
+	"server.branding.name":                         {def: DefaultBrandingName},
+	"server.branding.theme_color":                  {def: DefaultThemeColor},
+	"server.branding.background_color":             {def: DefaultBackgroundColor},
+	"server.locales":                               {def: DefaultLocales},
+	"server.base_url":                              {required: true, requiredWhen: "server.sitemap.enabled"},
+	"server.sitemap.cache_ttl":                     {def: "1h"},
+	"server.cors.allow_origins":                    {required: true, requiredWhen: "server.cors.preset=custom"},
+	"server.websocket.ping_interval":               {def: "30s"},
+	"server.websocket.send_buffer":                 {def: DefaultWebSocketSendBuffer},
+	"server.http.read_timeout":                     {def: "30s"},
+	"server.http.write_timeout":                    {def: "60s"},
+	"server.http.idle_timeout":                     {def: "120s"},
+	"server.http.read_header_timeout":              {def: "10s"},
+	"server.http.long_transfer_timeout":            {def: "30m"},
+	"server.error_rates.window":                    {def: "5m"},
+	"server.error_rates.warn_ratio":                {def: DefaultErrorRateWarnRatio},
+	"server.error_rates.min_requests":              {def: DefaultErrorRateMinRequests},
+	"server.head_requests":                         {def: HeadRequestsGet},
+	"server.allowed_hosts":                         {required: true, requiredWhen: "server.mode=release and server.meta.security_contact is set"},
+	"server.static.mounts[].url_prefix":            {required: true, requiredWhen: "server.static.mounts is set"},
+	"server.static.mounts[].dir":                   {required: true, requiredWhen: "server.static.mounts is set"},
+	"server.rate_limit.rps":                        {required: true, requiredWhen: "server.rate_limit.enabled"},
+	"server.rate_limit.burst":                      {required: true, requiredWhen: "server.rate_limit.enabled"},
+	"server.rate_limit.overrides.refresh_interval": {def: "30s"},
+	"server.cache.ttl":                             {required: true, requiredWhen: "server.cache.enabled"},
+	"server.cache.max_size":                        {required: true, requiredWhen: "server.cache.enabled"},
+	"server.cache.warm_budget":                     {def: "5s"},
+	"server.cache.singleflight_wait":               {def: "5s"},
+	"server.api.idempotency.ttl":                   {required: true, requiredWhen: "server.api.idempotency.enabled"},
+	"server.api.json_naming":                       {def: "snake"},
+	"server.api.max_json_depth":                    {def: 64},
+	"server.api.max_json_tokens":                   {def: 100000},
+	"server.api.max_json_bytes":                    {def: 1048576},
+	"database.driver":                              {required: true},
+	"database.log_slow_threshold":                  {def: "200ms"},
+	"database.repeated_query_threshold":            {def: DefaultRepeatedQueryThreshold},
+	"database.supervisor.interval":                 {def: "5s"},
+	"database.supervisor.failure_threshold":        {def: DefaultSupervisorFailureThreshold},
+	"database.wait.interval":                       {def: "1s"},
+	"database.sqlite.path":                         {required: true, requiredWhen: "database.driver=sqlite"},
+	"database.postgres.host":                       {required: true, requiredWhen: "database.driver=postgres"},
+	"database.postgres.port":                       {required: true, requiredWhen: "database.driver=postgres"},
+	"database.postgres.user":                       {required: true, requiredWhen: "database.driver=postgres"},
+	"database.postgres.dbname":                     {required: true, requiredWhen: "database.driver=postgres"},
+	"database.postgres.sslmode":                    {required: true, requiredWhen: "database.driver=postgres"},
+	"database.pool.max_idle_conns":                 {def: defaultPool.MaxIdleConns},
+	"database.pool.max_open_conns":                 {def: defaultPool.MaxOpenConns},
+	"database.pool.conn_max_lifetime":              {def: defaultPool.ConnMaxLifetime.String()},
+	"database.replicas[].host":                     {required: true, requiredWhen: "database.driver=postgres"},
+	"database.replicas[].port":                     {required: true, requiredWhen: "database.driver=postgres"},
+	"database.replicas[].user":                     {required: true, requiredWhen: "database.driver=postgres"},
+	"database.replicas[].dbname":                   {required: true, requiredWhen: "database.driver=postgres"},
+	"database.replicas[].sslmode":                  {required: true, requiredWhen: "database.driver=postgres"},
+	"database.replicas[].pool.max_idle_conns":      {def: defaultPool.MaxIdleConns},
+	"database.replicas[].pool.max_open_conns":      {def: defaultPool.MaxOpenConns},
+	"database.replicas[].pool.conn_max_lifetime":   {def: defaultPool.ConnMaxLifetime.String()},
+	"database.retry.attempts":                      {def: defaultRetry.Attempts},
+	"database.retry.max_backoff":                   {def: defaultRetry.MaxBackoff.String()},
+	"auth.jwt_secret":                              {required: true, requiredWhen: "auth.enabled and auth.jwt_secrets is unset"},
+	"auth.jwt_secrets[].kid":                       {required: true, requiredWhen: "auth.jwt_secrets is set"},
+	"auth.jwt_secrets[].secret":                    {required: true, requiredWhen: "auth.jwt_secrets is set"},
+	"auth.token_expiry":                            {required: true, requiredWhen: "auth.enabled"},
+	"auth.public_paths":                            {required: true, requiredWhen: "auth.enabled"},
+	"database.backup_retention":                    {def: 0},
+	"database.schema_check":                        {def: SchemaCheckWarn},
+	"database.sqlite.integrity_check":              {def: SQLiteCheckFull},
+	"database.change_feed_retention":               {def: "720h"},
+	"database.change_payload_max_size":             {def: DefaultChangePayloadMaxSize},
+	"auth.registration_conflict_mode":              {def: "explicit"},
+	"auth.registration_mode":                       {def: "open"},
+	"auth.bcrypt_cost":                             {def: DefaultBcryptCost},
+	"auth.password_algorithm":                      {def: "bcrypt"},
+	"auth.login_history_retention":                 {def: "2160h"},
+	"auth.api_keys[].name":                         {required: true, requiredWhen: "auth.api_keys is set"},
+	"auth.api_keys[].key_hash":                     {required: true, requiredWhen: "auth.api_keys is set"},
+	"auth.rbac.cache.role_ttl":                     {required: true, requiredWhen: "auth.rbac.enabled"},
+	"auth.rbac.cache.user_role_ttl":                {required: true, requiredWhen: "auth.rbac.enabled"},
+	"auth.rbac.cache.permission_ttl":               {required: true, requiredWhen: "auth.rbac.enabled"},
+	"auth.rbac.cache.max_role_entries":             {required: true, requiredWhen: "auth.rbac.enabled"},
+	"auth.rbac.cache.max_user_entries":             {required: true, requiredWhen: "auth.rbac.enabled"},
+	"auth.rbac.cache.max_permission_entries":       {required: true, requiredWhen: "auth.rbac.enabled"},
+	"tenancy.resolver":                             {def: TenantResolverHeader},
+	"tenancy.header":                               {def: DefaultTenantHeader},
+	"log.level":                                    {required: true},
+	"log.format":                                   {required: true},
+	"log.color":                                    {def: true},
+	"log.access_format":                            {def: AccessFormatStructured},
+	"dev_secrets_file":                             {def: DefaultDevSecretsFile},
+}
+
+// Schema describes every key of Config by reflecting over its koanf tags, in
+// declaration order. Tooling uses it to validate or generate configuration
+// without loading a file.
+func Schema() ConfigSchema {
+	var fields []SchemaField
+	schemaFields(reflect.TypeFor[Config](), "", true, &fields)
+	return ConfigSchema{EnvPrefix: "APP__", Fields: fields}
+}
+
+// schemaFields appends the fields of struct type t under prefix. env is false
+// inside list elements, which environment variables cannot address.
+func schemaFields(t reflect.Type, prefix string, env bool, out *[]SchemaField) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name := sf.Tag.Get("koanf")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			schemaFields(ft, key, env, out)
+			continue
+		}
+
+		field := SchemaField{Key: key}
+		field.Type, field.Format = schemaType(ft)
+		switch ft.Kind() {
+		case reflect.Slice:
+			elem := ft.Elem()
+			if elem.Kind() == reflect.Struct {
+				field.Items = "object"
+				*out = append(*out, withRule(field, env))
+				schemaFields(elem, key+"[]", false, out)
+				continue
+			}
+			field.Items, _ = schemaType(elem)
+		case reflect.Map:
+			field.Items, _ = schemaType(ft.Elem())
+		}
+		*out = append(*out, withRule(field, env))
+	}
+}
+
+// withRule fills in the env variable and the schemaRules entry for field.
+func withRule(field SchemaField, env bool) SchemaField {
+	if env {
+		field.Env = "APP__" + strings.ToUpper(strings.ReplaceAll(field.Key, ".", "__"))
+		if field.Type == "object" {
+			field.Env += "__<NAME>"
+		}
+	}
+	if rule, ok := schemaRules[field.Key]; ok {
+		field.Required = rule.required
+		field.RequiredWhen = rule.requiredWhen
+		field.Default = rule.def
+	}
+	return field
+}
+
+// schemaType maps a Go type to its JSON schema type name and format.
+func schemaType(t reflect.Type) (typ, format string) {
+	if t == durationType {
+		return "string", "duration"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", ""
+	case reflect.Float32, reflect.Float64:
+		return "number", ""
+	case reflect.Slice, reflect.Array:
+		return "array", ""
+	case reflect.Map, reflect.Struct:
+		return "object", ""
+	default:
+		return "string", ""
+	}
+}
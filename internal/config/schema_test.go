@@ -0,0 +1,58 @@
+package config
+
+import "testing"
+
+func TestSchema_KnownKeys(t *testing.T) {
+	fields := make(map[string]SchemaField)
+	for _, f := range Schema().Fields {
+		if _, dup := fields[f.Key]; dup {
+			t.Errorf("duplicate key %q", f.Key)
+		}
+		fields[f.Key] = f
+	}
+
+	tests := []struct {
+		key, typ, format, items, env string
+		required                     bool
+	}{
+		{key: "server.port", typ: "integer", env: "APP__SERVER__PORT", required: true},
+		{key: "server.timeout", typ: "string", format: "duration", env: "APP__SERVER__TIMEOUT"},
+		{key: "server.rate_limit.rps", typ: "number", env: "APP__SERVER__RATE_LIMIT__RPS", required: true},
+		{key: "server.cache.warm", typ: "array", items: "object", env: "APP__SERVER__CACHE__WARM"},
+		{key: "server.cache.warm[].path", typ: "string"},
+		{key: "auth.public_paths", typ: "array", items: "string", env: "APP__AUTH__PUBLIC_PATHS", required: true},
+		{key: "auth.jwt_secrets[].kid", typ: "string", required: true},
+		{key: "log.color", typ: "boolean", env: "APP__LOG__COLOR"},
+		{key: "features", typ: "object", items: "boolean", env: "APP__FEATURES__<NAME>"},
+	}
+	for _, tt := range tests {
+		f, ok := fields[tt.key]
+		if !ok {
+			t.Errorf("key %q missing from schema", tt.key)
+			continue
+		}
+		if f.Type != tt.typ || f.Format != tt.format || f.Items != tt.items || f.Env != tt.env || f.Required != tt.required {
+			t.Errorf("%s = %+v, want type=%s format=%s items=%s env=%s required=%v",
+				tt.key, f, tt.typ, tt.format, tt.items, tt.env, tt.required)
+		}
+	}
+
+	if got, want := fields["auth.jwt_secret"].RequiredWhen, "auth.enabled and auth.jwt_secrets is unset"; got != want {
+		t.Errorf("auth.jwt_secret required_when = %q, want %q", got, want)
+	}
+	if got := fields["database.pool.max_open_conns"].Default; got != 100 {
+		t.Errorf("database.pool.max_open_conns default = %v, want 100", got)
+	}
+}
+
+func TestSchema_RulesMatchConfigKeys(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, f := range Schema().Fields {
+		keys[f.Key] = true
+	}
+	for key := range schemaRules {
+		if !keys[key] {
+			t.Errorf("schemaRules has %q, which is not a Config key", key)
+		}
+	}
+}
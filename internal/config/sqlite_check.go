@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxIntegrityProblems is how many lines of integrity check output are
+// kept in the startup error.
+const maxIntegrityProblems = 10
+
+// corruptSuffixLayout formats the timestamp appended to a corrupt database
+// file moved aside by database.sqlite.auto_recover.
+const corruptSuffixLayout = "20060102T150405Z"
+
+// checkSQLiteFile verifies an existing SQLite database file before it is
+// opened, so a damaged or partially copied file fails startup with its
+// path and the check's findings rather than a "file is not a database"
+// error in the first query. A missing path (a new database) and DSNs that
+// are not plain files are skipped. It logs the file size and page count.
+//
+// With cfg.AutoRecover a file that fails is renamed, along with its -wal
+// and -shm files, to "<path>.corrupt-<UTC timestamp>", and SetupDatabase
+// goes on to create a fresh one.
+func checkSQLiteFile(cfg *SQLiteConfig, logger *slog.Logger) error {
+	if cfg.IntegrityCheck == SQLiteCheckOff {
+		return nil
+	}
+	info, err := os.Stat(cfg.Path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	pages, problems := inspectSQLiteFile(cfg.Path, cfg.IntegrityCheck == SQLiteCheckQuick)
+	logger.Info("sqlite database file",
+		slog.String("path", cfg.Path),
+		slog.Int64("size_bytes", info.Size()),
+		slog.Int64("page_count", pages),
+	)
+	if len(problems) == 0 {
+		return nil
+	}
+	report := strings.Join(problems, "; ")
+	if !cfg.AutoRecover {
+		return fmt.Errorf("sqlite database %q failed its integrity check: %s", cfg.Path, report)
+	}
+
+	moved := cfg.Path + ".corrupt-" + time.Now().UTC().Format(corruptSuffixLayout)
+	if err := os.Rename(cfg.Path, moved); err != nil {
+		return fmt.Errorf("sqlite database %q failed its integrity check (%s) and could not be moved aside: %w", cfg.Path, report, err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Rename(cfg.Path+suffix, moved+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("move aside %s: %w", cfg.Path+suffix, err)
+		}
+	}
+	logger.Warn("sqlite database failed its integrity check; moved it aside and starting with a fresh database",
+		slog.String("path", cfg.Path),
+		slog.String("moved_to", moved),
+		slog.String("problems", report),
+	)
+	return nil
+}
+
+// inspectSQLiteFile returns the page count of the database at path and
+// what PRAGMA integrity_check (quick_check when quick) found wrong with
+// it; problems is empty for a sound file. A file SQLite cannot read at all
+// is reported as a problem too.
+func inspectSQLiteFile(path string, quick bool) (pages int64, problems []string) {
+	// The connection is read-only so that checking never creates or
+	// modifies anything, e.g. a journal.
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, []string{err.Error()}
+	}
+	pragma := "PRAGMA integrity_check"
+	if quick {
+		pragma = "PRAGMA quick_check"
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("%s(%d)", pragma, maxIntegrityProblems))
+	if err != nil {
+		return pages, []string{err.Error()}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return pages, append(problems, err.Error())
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return pages, problems
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corruptSQLiteFile writes a database with some data to path, closes it and
+// cuts the file short, as an interrupted copy would.
+func corruptSQLiteFile(t *testing.T, path string) {
+	t.Helper()
+	db, err := SetupDatabase(&DatabaseConfig{Driver: "sqlite", SQLite: SQLiteConfig{Path: path}}, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("SetupDatabase() error = %v", err)
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, body TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := db.Exec("INSERT INTO items (body) VALUES (?)", strings.Repeat("x", 500)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()/2+100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupDatabase_SQLiteIntegrityCheckFails(t *testing.T) {
+	for _, check := range []string{SQLiteCheckFull, SQLiteCheckQuick} {
+		t.Run(check, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.db")
+			corruptSQLiteFile(t, path)
+
+			cfg := &DatabaseConfig{Driver: "sqlite", SQLite: SQLiteConfig{Path: path, IntegrityCheck: check}}
+			_, err := SetupDatabase(cfg, slog.New(slog.DiscardHandler))
+			if err == nil || !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "failed its integrity check") {
+				t.Fatalf("SetupDatabase() error = %v, want an integrity check failure naming %s", err, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("corrupt file was touched: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetupDatabase_SQLiteAutoRecover(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	corruptSQLiteFile(t, path)
+
+	var logs bytes.Buffer
+	cfg := &DatabaseConfig{Driver: "sqlite", SQLite: SQLiteConfig{Path: path, IntegrityCheck: SQLiteCheckFull, AutoRecover: true}}
+	db, err := SetupDatabase(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("SetupDatabase() error = %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	moved, _ := filepath.Glob(path + ".corrupt-*")
+	if len(moved) != 1 {
+		t.Fatalf("files moved aside = %v, want one", moved)
+	}
+	if !strings.Contains(logs.String(), "moved_to="+moved[0]) {
+		t.Errorf("logs = %q, want the new location of the corrupt file", logs.String())
+	}
+	if db.Migrator().HasTable("items") {
+		t.Error("recovered database still has the old tables, want a fresh one")
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Errorf("fresh database is not writable: %v", err)
+	}
+}
+
+func TestSetupDatabase_SQLiteLogsFileStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	cfg := &DatabaseConfig{Driver: "sqlite", SQLite: SQLiteConfig{Path: path}}
+	db, err := SetupDatabase(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)").Error; err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	var logs bytes.Buffer
+	db, err = SetupDatabase(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("SetupDatabase() on a sound file error = %v", err)
+	}
+	sqlDB, _ = db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if out := logs.String(); !strings.Contains(out, "size_bytes=") || !strings.Contains(out, "page_count=2") {
+		t.Errorf("logs = %q, want the file size and page count", out)
+	}
+}
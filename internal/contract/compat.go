@@ -0,0 +1,206 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Change is a difference between two golden files that can break a client:
+// a field present in the old version is missing from the new one (New is
+// empty), or its JSON type changed. Added fields are not changes.
+type Change struct {
+	Golden string // golden file name, set by CompareSets
+	Path   string // e.g. "body.data.items[].email"; empty when the whole golden file was removed
+	Old    string // JSON type in the old version
+	New    string // JSON type in the new version, or "" if removed
+}
+
+func (c Change) String() string {
+	where := c.Path
+	if c.Golden != "" {
+		where = strings.TrimSuffix(c.Golden+": "+c.Path, ": ")
+	}
+	if c.New == "" {
+		return where + ": removed"
+	}
+	return fmt.Sprintf("%s: type changed from %s to %s", where, c.Old, c.New)
+}
+
+// Compare reports the breaking changes from the golden file old to cur,
+// ordered by path. A field that was null in old may take any type in cur.
+// Fields inside array elements are only checked when the array in cur has
+// elements.
+func Compare(old, cur []byte) ([]Change, error) {
+	oldShape, err := shapeOf(old)
+	if err != nil {
+		return nil, fmt.Errorf("old version: %w", err)
+	}
+	newShape, err := shapeOf(cur)
+	if err != nil {
+		return nil, fmt.Errorf("new version: %w", err)
+	}
+
+	var changes []Change
+	for path, oldType := range oldShape {
+		if emptyArrayAncestor(path, newShape) {
+			continue
+		}
+		newType, ok := newShape[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Old: oldType})
+		case oldType != "null" && newType != oldType:
+			changes = append(changes, Change{Path: path, Old: oldType, New: newType})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Path, b.Path) })
+	return dropNested(changes), nil
+}
+
+// CompareSets compares two sets of golden files keyed by name (see LoadSet).
+// A golden file missing from cur is reported as one removed Change.
+func CompareSets(old, cur map[string][]byte) ([]Change, error) {
+	names := make([]string, 0, len(old))
+	for name := range old {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var changes []Change
+	for _, name := range names {
+		next, ok := cur[name]
+		if !ok {
+			changes = append(changes, Change{Golden: name, Old: "object"})
+			continue
+		}
+		diff, err := Compare(old[name], next)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, c := range diff {
+			c.Golden = name
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// LoadSet reads the *.json golden files in dir, keyed by file name without
+// the extension.
+func LoadSet(dir string) (map[string][]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		set[strings.TrimSuffix(filepath.Base(path), ".json")] = data
+	}
+	return set, nil
+}
+
+// shapeOf maps every path in a JSON document to its type. Array elements
+// share the path "<array>[]"; the first non-null type seen for a path wins.
+func shapeOf(data []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	shape := make(map[string]string)
+	collectShape(v, "", shape)
+	return shape, nil
+}
+
+func collectShape(v any, path string, shape map[string]string) {
+	typ := jsonType(v)
+	if path != "" {
+		if prev, ok := shape[path]; !ok || prev == "null" {
+			shape[path] = typ
+		}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			collectShape(val, child, shape)
+		}
+	case []any:
+		for _, val := range v {
+			collectShape(val, path+"[]", shape)
+		}
+	}
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+// emptyArrayAncestor reports whether path lies inside an array that has no
+// elements in shape, which says nothing about the element fields.
+func emptyArrayAncestor(path string, shape map[string]string) bool {
+	for i := 0; ; {
+		j := strings.Index(path[i:], "[]")
+		if j < 0 {
+			return false
+		}
+		array := path[:i+j]
+		if _, ok := shape[array+"[]"]; !ok && shape[array] == "array" {
+			return true
+		}
+		i += j + 2
+	}
+}
+
+// dropNested removes changes below a removed or retyped path, which the
+// parent change already covers. changes must be sorted by path.
+func dropNested(changes []Change) []Change {
+	out := changes[:0]
+	for _, c := range changes {
+		if n := len(out); n > 0 && isBelow(c.Path, out[n-1].Path) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func isBelow(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[]")
+}
+
+func formatChanges(changes []Change) string {
+	var b strings.Builder
+	for _, c := range changes {
+		b.WriteString("  ")
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
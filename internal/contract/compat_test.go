@@ -0,0 +1,103 @@
+package contract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	const base = `{"status":200,"body":{"data":{"id":0,"name":"a","tags":["x"],"profile":{"bio":"b"},"items":[{"email":"e"}],"note":null}}}`
+	tests := []struct {
+		name string
+		cur  string
+		want []Change
+	}{
+		{
+			name: "identical",
+			cur:  base,
+		},
+		{
+			name: "added field is compatible",
+			cur:  `{"status":200,"body":{"data":{"id":0,"name":"a","extra":true,"tags":["x"],"profile":{"bio":"b"},"items":[{"email":"e"}],"note":null}}}`,
+		},
+		{
+			name: "null may gain a type",
+			cur:  `{"status":200,"body":{"data":{"id":0,"name":"a","tags":["x"],"profile":{"bio":"b"},"items":[{"email":"e"}],"note":"n"}}}`,
+		},
+		{
+			name: "empty array says nothing about elements",
+			cur:  `{"status":200,"body":{"data":{"id":0,"name":"a","tags":[],"profile":{"bio":"b"},"items":[],"note":null}}}`,
+		},
+		{
+			name: "removed and renamed fields",
+			cur:  `{"status":200,"body":{"data":{"id":0,"full_name":"a","tags":["x"],"profile":{"bio":"b"},"items":[{"mail":"e"}],"note":null}}}`,
+			want: []Change{
+				{Path: "body.data.items[].email", Old: "string"},
+				{Path: "body.data.name", Old: "string"},
+			},
+		},
+		{
+			name: "type change",
+			cur:  `{"status":200,"body":{"data":{"id":"0","name":"a","tags":["x"],"profile":{"bio":"b"},"items":[{"email":"e"}],"note":null}}}`,
+			want: []Change{{Path: "body.data.id", Old: "number", New: "string"}},
+		},
+		{
+			name: "changes below a removed object are folded into it",
+			cur:  `{"status":200,"body":{"data":{"id":0,"name":"a","tags":["x"],"items":[{"email":"e"}],"note":null}}}`,
+			want: []Change{{Path: "body.data.profile", Old: "object"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compare([]byte(base), []byte(tt.cur))
+			if err != nil {
+				t.Fatalf("Compare: %v", err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSets(t *testing.T) {
+	old := map[string][]byte{
+		"user_get":    []byte(`{"status":200,"body":{"data":{"name":"a"}}}`),
+		"user_delete": []byte(`{"status":200,"body":null}`),
+	}
+	cur := map[string][]byte{
+		"user_get": []byte(`{"status":200,"body":{"data":{"name":1}}}`),
+		"user_new": []byte(`{"status":201,"body":{}}`),
+	}
+	got, err := CompareSets(old, cur)
+	if err != nil {
+		t.Fatalf("CompareSets: %v", err)
+	}
+	var lines []string
+	for _, c := range got {
+		lines = append(lines, c.String())
+	}
+	want := []string{"user_delete: removed", "user_get: body.data.name: type changed from string to number"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("CompareSets() = %q, want %q", lines, want)
+	}
+}
+
+func TestLoadSet(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`x`), 0o644)
+
+	set, err := LoadSet(dir)
+	if err != nil {
+		t.Fatalf("LoadSet: %v", err)
+	}
+	if len(set) != 1 || string(set["a"]) != `{}` {
+		t.Errorf("LoadSet() = %q, want only a.json", set)
+	}
+}
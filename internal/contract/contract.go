@@ -0,0 +1,159 @@
+// Package contract pins the JSON shape of API responses with golden files.
+//
+// Handler tests record a response and call Assert, which normalizes volatile
+// values (IDs, timestamps, tokens) and diffs the result against
+// testdata/contracts/<name>.json in the test's package. Setting UPDATE_GOLDEN
+// rewrites the files; Compare and CompareSets report changes between two
+// golden versions that would break existing clients.
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that makes Assert rewrite golden
+// files instead of comparing against them. "1" refuses to write a golden
+// file that is incompatible with the one it replaces; "breaking" writes it
+// anyway.
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Dir is where Assert keeps golden files, relative to the test's package.
+var Dir = filepath.Join("testdata", "contracts")
+
+// Placeholders substituted for volatile values by Normalize. Numbers become
+// 0 so the JSON type is kept for Compare.
+const (
+	IDPlaceholder        = "<id>"
+	TimestampPlaceholder = "<timestamp>"
+	TokenPlaceholder     = "<token>"
+)
+
+// Snapshot is the golden form of one response.
+type Snapshot struct {
+	Status int `json:"status"`
+	Body   any `json:"body"`
+}
+
+// Assert compares the response recorded in w with the golden file name.
+// The comparison is exact after normalization; on a mismatch the failure
+// also lists the changes Compare considers breaking.
+func Assert(t testing.TB, name string, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	got, err := Marshal(w.Code, w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("contract %s: %v", name, err)
+	}
+	path := filepath.Join(Dir, name+".json")
+	want, readErr := os.ReadFile(path)
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+
+	if mode := os.Getenv(UpdateEnv); mode != "" {
+		if readErr == nil && mode != "breaking" {
+			changes, err := Compare(want, got)
+			if err != nil {
+				t.Fatalf("contract %s: %v", name, err)
+			}
+			if len(changes) > 0 {
+				t.Fatalf("contract %s: refusing to write a breaking change (set %s=breaking to accept):\n%s", name, UpdateEnv, formatChanges(changes))
+			}
+		}
+		if err := os.MkdirAll(Dir, 0o755); err != nil {
+			t.Fatalf("contract %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("contract %s: %v", name, err)
+		}
+		return
+	}
+
+	if readErr != nil {
+		t.Fatalf("contract %s: %v (run with %s=1 to create it)", name, readErr, UpdateEnv)
+	}
+	if bytes.Equal(want, got) {
+		return
+	}
+	msg := fmt.Sprintf("contract %s: response differs from %s (run with %s=1 to update)\n--- want\n%s--- got\n%s", name, path, UpdateEnv, want, got)
+	if changes, err := Compare(want, got); err == nil && len(changes) > 0 {
+		msg += "breaking changes:\n" + formatChanges(changes)
+	}
+	t.Error(msg)
+}
+
+// Marshal returns the golden file content for a response: status and
+// normalized body as indented JSON with sorted keys. An empty body is null.
+func Marshal(status int, body []byte) ([]byte, error) {
+	var v any
+	if len(bytes.TrimSpace(body)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("response body is not JSON: %w", err)
+		}
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Snapshot{Status: status, Body: Normalize(v)}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Normalize replaces volatile values in a decoded JSON document, by key:
+// "id" and "*_id" become IDPlaceholder, "*_at" TimestampPlaceholder, and
+// "token" and "*_token" TokenPlaceholder (numbers become 0 instead). null
+// and containers are left alone.
+func Normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, val := range v {
+			if placeholder, ok := volatile(key); ok {
+				out[key] = replace(val, placeholder)
+				continue
+			}
+			out[key] = Normalize(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = Normalize(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func volatile(key string) (placeholder string, ok bool) {
+	switch {
+	case key == "id" || strings.HasSuffix(key, "_id"):
+		return IDPlaceholder, true
+	case strings.HasSuffix(key, "_at"):
+		return TimestampPlaceholder, true
+	case key == "token" || strings.HasSuffix(key, "_token"):
+		return TokenPlaceholder, true
+	}
+	return "", false
+}
+
+func replace(v any, placeholder string) any {
+	switch v.(type) {
+	case string:
+		return placeholder
+	case json.Number, float64:
+		return json.Number("0")
+	default:
+		return Normalize(v)
+	}
+}
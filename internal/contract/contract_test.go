@@ -0,0 +1,148 @@
+package contract
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMarshal_NormalizesVolatileFields(t *testing.T) {
+	body := `{"code":200,"data":{"id":42,"user_id":"7f0c","name":"Alice","created_at":"2026-01-02T03:04:05Z",` +
+		`"expires_at":1700000000,"token":"abc","refresh_token":"def","deleted_at":null,"items":[{"id":1}]},"request_id":"r-1"}`
+
+	got, err := Marshal(201, []byte(body))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{
+  "status": 201,
+  "body": {
+    "code": 200,
+    "data": {
+      "created_at": "<timestamp>",
+      "deleted_at": null,
+      "expires_at": 0,
+      "id": 0,
+      "items": [
+        {
+          "id": 0
+        }
+      ],
+      "name": "Alice",
+      "refresh_token": "<token>",
+      "token": "<token>",
+      "user_id": "<id>"
+    },
+    "request_id": "<id>"
+  }
+}
+`
+	if string(got) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarshal_EmptyBody(t *testing.T) {
+	got, err := Marshal(204, nil)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := "{\n  \"status\": 204,\n  \"body\": null\n}\n"; string(got) != want {
+		t.Errorf("Marshal() = %q, want %q", got, want)
+	}
+	if _, err := Marshal(200, []byte("<html>")); err == nil {
+		t.Error("Marshal(non-JSON) error = nil, want error")
+	}
+}
+
+func TestAssert_UpdateAndCompare(t *testing.T) {
+	dir := t.TempDir()
+	orig := Dir
+	Dir = filepath.Join(dir, "contracts")
+	t.Cleanup(func() { Dir = orig })
+
+	record := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		w.WriteHeader(200)
+		w.WriteString(body)
+		return w
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	Assert(t, "sample", record(`{"data":{"id":1,"name":"a"}}`))
+	written, err := os.ReadFile(filepath.Join(Dir, "sample.json"))
+	if err != nil || !strings.Contains(string(written), `"name": "a"`) {
+		t.Fatalf("golden file = %q, %v; want it written", written, err)
+	}
+
+	t.Setenv(UpdateEnv, "")
+	Assert(t, "sample", record(`{"data":{"id":99,"name":"a"}}`)) // only the id differs
+
+	// A removed field fails, both when comparing and when updating without
+	// UPDATE_GOLDEN=breaking.
+	for _, mode := range []string{"", "1"} {
+		t.Setenv(UpdateEnv, mode)
+		ft := assertFake("sample", record(`{"data":{"id":1}}`))
+		if !ft.failed || !strings.Contains(ft.msg, "body.data.name: removed") {
+			t.Errorf("%s=%q: failed = %v, msg = %q; want failure naming the removed field", UpdateEnv, mode, ft.failed, ft.msg)
+		}
+	}
+	t.Setenv(UpdateEnv, "breaking")
+	Assert(t, "sample", record(`{"data":{"id":1}}`))
+	t.Setenv(UpdateEnv, "")
+	Assert(t, "sample", record(`{"data":{"id":1}}`))
+}
+
+func TestAssert_MissingGolden(t *testing.T) {
+	orig := Dir
+	Dir = t.TempDir()
+	t.Cleanup(func() { Dir = orig })
+	t.Setenv(UpdateEnv, "")
+
+	ft := assertFake("absent", httptest.NewRecorder())
+	if !ft.failed || !strings.Contains(ft.msg, UpdateEnv+"=1") {
+		t.Errorf("failed = %v, msg = %q; want a hint to create the golden file", ft.failed, ft.msg)
+	}
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Error(args ...any) {
+	f.failed = true
+	f.msg = fmt.Sprint(args...)
+}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failed = true
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+// Fatalf stops the calling goroutine, as testing.T does.
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// assertFake runs Assert against a fakeT in its own goroutine, so Fatalf
+// can end it.
+func assertFake(name string, w *httptest.ResponseRecorder) *fakeT {
+	ft := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Assert(ft, name, w)
+	}()
+	<-done
+	return ft
+}
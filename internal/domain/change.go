@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Change operations.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Change is one entry of the change feed: entity EntityID of EntityType
+// (a table name such as "users") was created, updated or deleted. Entries
+// are appended in the transaction of the mutation, so the feed holds a
+// change exactly when it was committed, and their IDs order the feed. The
+// entity is referenced by ID only, so a delete stays readable after the
+// row is gone.
+//
+// Payload, when the writer recorded one, is a JSON snapshot of the change,
+// such as the fields an update changed. It is stored in PayloadData as
+// blob.Encode leaves it: capped in size, and gzip-compressed when
+// PayloadCompressed is set; the repository decodes it when reading.
+type Change struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	TenantScoped
+	EntityType        string          `gorm:"size:64;not null" json:"entity_type"`
+	EntityID          string          `gorm:"size:64;not null" json:"entity_id"`
+	Op                string          `gorm:"size:16;not null" json:"op"`
+	Payload           json.RawMessage `gorm:"-" json:"payload,omitempty"`
+	PayloadData       []byte          `json:"-"`
+	PayloadCompressed bool            `gorm:"not null;default:false" json:"-"`
+	ChangedAt         Time            `gorm:"autoCreateTime;index" json:"changed_at"`
+}
+
+// ChangeQuery selects a page of the change feed: up to Limit entries with
+// an ID greater than Since, of EntityType when it is set.
+type ChangeQuery struct {
+	Since      uint
+	EntityType string
+	Limit      int
+}
+
+// ChangePage is a page of the change feed. NextCursor is the Since of the
+// following page: the ID of the last entry, or the requested Since when
+// there are none yet. HasMore reports whether more entries are already
+// waiting.
+type ChangePage struct {
+	Items      []Change `json:"items"`
+	NextCursor uint     `json:"next_cursor"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// ChangeRepository defines the data access interface for the change feed.
+// Entries are written by the repositories of the entities, through
+// pkg.RecordChange.
+type ChangeRepository interface {
+	List(ctx context.Context, q ChangeQuery) ([]Change, error)
+	Prune(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ChangeService defines the business logic interface for the change feed.
+type ChangeService interface {
+	Since(ctx context.Context, q ChangeQuery) (*ChangePage, error)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "context"
+
+// ErasureReceipt records that a user was erased, without identifying them:
+// SubjectHash is the hex HMAC-SHA256 of "users:<id>" under a server key, so
+// the server can confirm the erasure of a known ID but the receipt alone
+// does not name it, and ErasedBy is the actor who requested it.
+type ErasureReceipt struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	TenantScoped
+	SubjectHash string `gorm:"size:64;not null;index" json:"subject_hash"`
+	ErasedBy    string `gorm:"size:100;not null" json:"erased_by"`
+	ErasedAt    Time   `gorm:"autoCreateTime" json:"erased_at"`
+}
+
+// UserExport is everything held about one user: the user row and, keyed by
+// store name, the records of every registered store.
+type UserExport struct {
+	User       *User          `json:"user"`
+	Records    map[string]any `json:"records"`
+	ExportedAt Time           `json:"exported_at"`
+}
+
+// ErasureRepository defines the data access interface for user erasure.
+type ErasureRepository interface {
+	// Export collects the export of user id.
+	Export(ctx context.Context, id uint) (*UserExport, error)
+	// Erase deletes user id and the records of every store in one
+	// transaction, which also stores receipt. It returns the rows removed
+	// per store.
+	Erase(ctx context.Context, id uint, receipt *ErasureReceipt) (map[string]int64, error)
+}
+
+// ErasureService defines the business logic interface for user erasure.
+type ErasureService interface {
+	ExportUser(ctx context.Context, id uint) (*UserExport, error)
+	// EraseUser erases user id on behalf of actor.
+	EraseUser(ctx context.Context, id uint, actor string) (*ErasureReceipt, error)
+}
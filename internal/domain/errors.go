@@ -13,6 +13,8 @@ const (
 	CodeInternal      = 4
 	CodeUnauthorized  = 5
 	CodeForbidden     = 6
+	CodeUnavailable   = 7
+	CodeConflict      = 8
 )
 
 // AppError represents a business logic error with a code, message, and optional wrapped error.
@@ -51,6 +53,8 @@ var (
 	ErrInternal      = &AppError{Code: CodeInternal, Message: "internal error"}
 	ErrUnauthorized  = &AppError{Code: CodeUnauthorized, Message: "unauthorized"}
 	ErrForbidden     = &AppError{Code: CodeForbidden, Message: "forbidden"}
+	ErrUnavailable   = &AppError{Code: CodeUnavailable, Message: "service unavailable"}
+	ErrConflict      = &AppError{Code: CodeConflict, Message: "conflict"}
 )
 
 // NewAppError creates a new AppError with the given code, message, and wrapped error.
@@ -92,6 +96,16 @@ func IsForbidden(err error) bool {
 	return hasCode(err, CodeForbidden)
 }
 
+// IsUnavailable reports whether err is or wraps an AppError with CodeUnavailable.
+func IsUnavailable(err error) bool {
+	return hasCode(err, CodeUnavailable)
+}
+
+// IsConflict reports whether err is or wraps an AppError with CodeConflict.
+func IsConflict(err error) bool {
+	return hasCode(err, CodeConflict)
+}
+
 // hasCode checks whether err is or wraps an *AppError with the given code.
 func hasCode(err error, code int) bool {
 	var appErr *AppError
@@ -119,6 +133,10 @@ func HTTPStatusCode(err error) int {
 			return http.StatusUnauthorized
 		case CodeForbidden:
 			return http.StatusForbidden
+		case CodeUnavailable:
+			return http.StatusServiceUnavailable
+		case CodeConflict:
+			return http.StatusConflict
 		}
 	}
 	return http.StatusInternalServerError
@@ -75,6 +75,8 @@ func TestPredefinedErrors(t *testing.T) {
 		{"ErrInternal", ErrInternal, IsInternal, CodeInternal},
 		{"ErrUnauthorized", ErrUnauthorized, IsUnauthorized, CodeUnauthorized},
 		{"ErrForbidden", ErrForbidden, IsForbidden, CodeForbidden},
+		{"ErrUnavailable", ErrUnavailable, IsUnavailable, CodeUnavailable},
+		{"ErrConflict", ErrConflict, IsConflict, CodeConflict},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -122,6 +124,12 @@ func TestIsCheckers_NonAppError(t *testing.T) {
 	if IsForbidden(plainErr) {
 		t.Error("IsForbidden should return false for non-AppError")
 	}
+	if IsUnavailable(plainErr) {
+		t.Error("IsUnavailable should return false for non-AppError")
+	}
+	if IsConflict(plainErr) {
+		t.Error("IsConflict should return false for non-AppError")
+	}
 }
 
 func TestHTTPStatusCode(t *testing.T) {
@@ -136,6 +144,8 @@ func TestHTTPStatusCode(t *testing.T) {
 		{"internal", ErrInternal, http.StatusInternalServerError},
 		{"unauthorized", ErrUnauthorized, http.StatusUnauthorized},
 		{"forbidden", ErrForbidden, http.StatusForbidden},
+		{"unavailable", ErrUnavailable, http.StatusServiceUnavailable},
+		{"conflict", ErrConflict, http.StatusConflict},
 		{"custom not found", NewAppError(CodeNotFound, "custom", nil), http.StatusNotFound},
 		{"unknown code", NewAppError(999, "unknown", nil), http.StatusInternalServerError},
 		{"non-AppError", errors.New("plain"), http.StatusInternalServerError},
@@ -0,0 +1,33 @@
+package domain
+
+import "context"
+
+// Event is a live change notice broadcast to the clients of the event
+// stream (GET /ws). Type names the change; Data is the affected record, or
+// an EventRef when the record is gone. Tenant is the tenant the change was
+// made in, empty without one; it is not sent.
+type Event struct {
+	Type   string `json:"type"`
+	Data   any    `json:"data,omitempty"`
+	Time   Time   `json:"time"`
+	Tenant string `json:"-"`
+}
+
+// EventRef identifies a deleted record in Event.Data.
+type EventRef struct {
+	ID uint `json:"id"`
+}
+
+// Event types published by the user service.
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// EventPublisher is what services depend on to broadcast an Event.
+// PublishEvent must not block: subscribers too slow to keep up are dropped
+// rather than delaying the caller.
+type EventPublisher interface {
+	PublishEvent(ctx context.Context, e Event)
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Invite is a single-use code that lets one user register when
+// auth.registration_mode is "invite". Only the SHA-256 hash of the code is
+// stored; the code itself is shown once, when the invite is created.
+type Invite struct {
+	BaseModel
+	CodeHash string `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	// Email, when set, is the only address the invite registers.
+	Email     string `gorm:"size:254" json:"email,omitempty"`
+	ExpiresAt Time   `gorm:"not null" json:"expires_at"`
+	UsedAt    *Time  `json:"used_at,omitempty"`
+}
+
+// InviteRepository defines the data access interface for invites.
+type InviteRepository interface {
+	Create(ctx context.Context, invite *Invite) error
+	GetByCodeHash(ctx context.Context, codeHash string) (*Invite, error)
+	// MarkUsed sets the used_at of invite id to at, unless it is already
+	// used, in which case it returns ErrNotFound.
+	MarkUsed(ctx context.Context, id uint, at time.Time) error
+	// Release clears the used_at of invite id, for a registration that
+	// failed after MarkUsed.
+	Release(ctx context.Context, id uint) error
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempt records one call to the login endpoint, successful or not.
+// UserID is nil when the email matched no user; Email keeps what was typed.
+type LoginAttempt struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	UserID    *uint  `gorm:"index" json:"-"`
+	Email     string `gorm:"size:255;not null" json:"-"`
+	IP        string `gorm:"size:45" json:"ip"`
+	UserAgent string `gorm:"size:512" json:"user_agent"`
+	Success   bool   `gorm:"not null" json:"success"`
+	CreatedAt Time   `gorm:"index" json:"created_at"`
+	// User declares the foreign key: deleting a user keeps their attempts,
+	// by email, for the security record.
+	User *User `gorm:"constraint:OnDelete:SET NULL" json:"-"`
+}
+
+// FailedLoginSummary describes the failed attempts on an account since its
+// previous successful login. LastIP and LastAt belong to the latest of them
+// and are empty when Count is 0.
+type FailedLoginSummary struct {
+	Count  int64  `json:"count"`
+	LastIP string `json:"last_ip,omitempty"`
+	LastAt *Time  `json:"last_at,omitempty"`
+}
+
+// LoginAttemptRepository defines the data access interface for the login
+// history.
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, a *LoginAttempt) error
+	// List returns a page of userID's attempts, newest first by default.
+	List(ctx context.Context, userID uint, req PageRequest) (*PageResult[LoginAttempt], error)
+	// FailedSinceLastSuccess summarizes userID's failed attempts recorded
+	// after their latest successful one.
+	FailedSinceLastSuccess(ctx context.Context, userID uint) (FailedLoginSummary, error)
+	// Prune deletes the attempts recorded before cutoff and returns how many
+	// were removed.
+	Prune(ctx context.Context, cutoff time.Time) (int64, error)
+}
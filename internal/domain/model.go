@@ -1,15 +1,54 @@
 package domain
 
-import "time"
+import (
+	"github.com/google/uuid"
+	"github.com/simp-lee/pagination"
+	"gorm.io/gorm"
+)
 
 // BaseModel is the common base struct for all domain models.
 // It replaces gorm.Model to avoid the implicit soft delete behavior of DeletedAt.
 type BaseModel struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint `gorm:"primaryKey" json:"id"`
+	CreatedAt Time `json:"created_at"`
+	UpdatedAt Time `json:"updated_at"`
 }
 
+// UUIDModel is the alternative to BaseModel for models with a string UUID
+// primary key instead of an auto-increment integer. The ID is generated in
+// BeforeCreate unless the caller has already set one. Because UUIDs carry no
+// ordering, list queries should sort by created_at (see pkg.ListOptions).
+type UUIDModel struct {
+	ID        string `gorm:"type:varchar(36);primaryKey" json:"id"`
+	CreatedAt Time   `json:"created_at"`
+	UpdatedAt Time   `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that assigns a random (version 4) UUID.
+func (m *UUIDModel) BeforeCreate(*gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+// PatchField is one field of a partial (PATCH) update. The zero value leaves
+// the field unchanged; Set with Null asks for it to be cleared; Set alone
+// replaces it with Value.
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// PageResult is the page of a list query returned by repositories and
+// services. It is an alias of the pagination library's result, which
+// pkg.PaginateGORM builds, so its JSON field names (items, current_page,
+// items_per_page, total_items, ...) are part of the API. Repositories
+// that run their own count and slice queries build one with
+// pkg.PageResultFrom.
+type PageResult[T any] = pagination.Pagination[T]
+
 // PageRequest holds pagination, sorting, and filtering parameters.
 type PageRequest struct {
 	Page     int
@@ -0,0 +1,29 @@
+package domain
+
+import "context"
+
+// Note is the example model for UUID primary keys (see UUIDModel); the user
+// module shows the auto-increment alternative.
+type Note struct {
+	UUIDModel
+	Title string `gorm:"size:200;not null" json:"title"`
+	Body  string `gorm:"type:text" json:"body"`
+}
+
+// NoteRepository defines the data access interface for notes.
+type NoteRepository interface {
+	Create(ctx context.Context, note *Note) error
+	GetByID(ctx context.Context, id string) (*Note, error)
+	List(ctx context.Context, req PageRequest) (*PageResult[Note], error)
+	Update(ctx context.Context, note *Note) error
+	Delete(ctx context.Context, id string) error
+}
+
+// NoteService defines the business logic interface for notes.
+type NoteService interface {
+	CreateNote(ctx context.Context, title, body string) (*Note, error)
+	GetNote(ctx context.Context, id string) (*Note, error)
+	ListNotes(ctx context.Context, req PageRequest) (*PageResult[Note], error)
+	UpdateNote(ctx context.Context, id, title, body string) (*Note, error)
+	DeleteNote(ctx context.Context, id string) error
+}
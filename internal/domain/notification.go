@@ -0,0 +1,39 @@
+package domain
+
+import "context"
+
+// Notification is a persistent in-app message for one user, kept until read
+// rather than disappearing like a toast. ReadAt is nil while it is unread.
+type Notification struct {
+	BaseModel
+	UserID uint   `gorm:"not null;index" json:"user_id"`
+	Type   string `gorm:"size:50;not null" json:"type"`
+	Title  string `gorm:"size:200;not null" json:"title"`
+	Body   string `gorm:"type:text" json:"body"`
+	ReadAt *Time  `json:"read_at"`
+	// User declares the foreign key: a user's notifications are deleted
+	// with them.
+	User *User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// NotificationRepository defines the data access interface for notifications.
+// Every read and update is scoped to the owning user.
+type NotificationRepository interface {
+	Create(ctx context.Context, n *Notification) error
+	List(ctx context.Context, userID uint, req PageRequest) (*PageResult[Notification], error)
+	MarkRead(ctx context.Context, userID, id uint) error
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+}
+
+// NotificationPublisher is what other services depend on to notify a user.
+type NotificationPublisher interface {
+	Publish(ctx context.Context, userID uint, kind, title, body string) error
+}
+
+// NotificationService defines the business logic interface for notifications.
+type NotificationService interface {
+	NotificationPublisher
+	ListNotifications(ctx context.Context, userID uint, req PageRequest) (*PageResult[Notification], error)
+	MarkRead(ctx context.Context, userID, id uint) error
+	UnreadCount(ctx context.Context, userID uint) (int64, error)
+}
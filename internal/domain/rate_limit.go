@@ -0,0 +1,45 @@
+package domain
+
+import "context"
+
+// Subject types of a RateLimitOverride.
+const (
+	// RateLimitSubjectUser names a user by ID, e.g. "42".
+	RateLimitSubjectUser = "user"
+	// RateLimitSubjectAPIKey names an API key from auth.api_keys, e.g.
+	// "nightly-export".
+	RateLimitSubjectAPIKey = "api_key"
+)
+
+// RateLimitOverride replaces the server.rate_limit budget of one
+// authenticated subject, e.g. a customer on a paid tier.
+type RateLimitOverride struct {
+	BaseModel
+	SubjectType string  `gorm:"size:20;not null;uniqueIndex:idx_rate_limit_overrides_subject" json:"subject_type"`
+	SubjectID   string  `gorm:"size:100;not null;uniqueIndex:idx_rate_limit_overrides_subject" json:"subject_id"`
+	RPS         float64 `gorm:"not null" json:"rps"`
+	Burst       int     `gorm:"not null" json:"burst"`
+}
+
+// RateLimitOverrideRepository defines the data access interface for rate
+// limit overrides.
+type RateLimitOverrideRepository interface {
+	Create(ctx context.Context, o *RateLimitOverride) error
+	GetByID(ctx context.Context, id uint) (*RateLimitOverride, error)
+	// All returns every override, for the in-memory lookup table.
+	All(ctx context.Context) ([]RateLimitOverride, error)
+	List(ctx context.Context, req PageRequest) (*PageResult[RateLimitOverride], error)
+	// UpdateLimits sets the rps and burst of override id.
+	UpdateLimits(ctx context.Context, id uint, rps float64, burst int) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// RateLimitOverrideService defines the business logic interface for rate
+// limit overrides.
+type RateLimitOverrideService interface {
+	CreateOverride(ctx context.Context, subjectType, subjectID string, rps float64, burst int) (*RateLimitOverride, error)
+	GetOverride(ctx context.Context, id uint) (*RateLimitOverride, error)
+	ListOverrides(ctx context.Context, req PageRequest) (*PageResult[RateLimitOverride], error)
+	UpdateOverride(ctx context.Context, id uint, rps float64, burst int) (*RateLimitOverride, error)
+	DeleteOverride(ctx context.Context, id uint) error
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// TenantScoped is embedded by models whose rows belong to one tenant. With
+// tenancy enabled, BeforeCreate stamps TenantID from the request context and
+// pkg.TenantScope limits queries to it; rows created without a tenant in
+// the context, as with tenancy disabled, keep the empty TenantID.
+type TenantScoped struct {
+	TenantID string `gorm:"size:64;not null;default:'';index" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that sets TenantID to the tenant of the
+// statement's context, overriding any value the caller set.
+func (t *TenantScoped) BeforeCreate(tx *gorm.DB) error {
+	stampTenant(tx, &t.TenantID)
+	return nil
+}
+
+// stampTenant sets *id to the tenant of tx's context, if it has one. Models
+// that declare the tenant_id column themselves, to make it part of a
+// composite index, call it from their own BeforeCreate.
+func stampTenant(tx *gorm.DB, id *string) {
+	if tenant, ok := TenantFromContext(tx.Statement.Context); ok {
+		*id = tenant
+	}
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying tenant id, as
+// middleware.Tenant does for each request.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFromContext returns the tenant WithTenant stored in ctx; ok is
+// false when there is none.
+func TenantFromContext(ctx context.Context) (id string, ok bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok = ctx.Value(tenantKey{}).(string)
+	return id, ok
+}
+
+// tenantRolePrefix marks the entry of a JWT's roles claim naming the tenant
+// the token was issued for. The jwt library has no custom claims, and RBAC
+// keeps roles in its own service, so the claim is otherwise unused.
+const tenantRolePrefix = "tenant:"
+
+// TokenRoles returns the roles claim of a token issued in ctx: the tenant
+// of ctx, when it has one, for TokenTenant to read back; nil otherwise.
+func TokenRoles(ctx context.Context) []string {
+	if id, ok := TenantFromContext(ctx); ok {
+		return []string{tenantRolePrefix + id}
+	}
+	return nil
+}
+
+// TokenTenant returns the tenant TokenRoles recorded in roles; ok is false
+// when the token was issued without one.
+func TokenTenant(roles []string) (id string, ok bool) {
+	for _, role := range roles {
+		if id, ok := strings.CutPrefix(role, tenantRolePrefix); ok {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// TimeLayout is how Time appears in JSON: RFC 3339 in UTC with exactly
+// millisecond precision, e.g. "2024-03-05T14:07:09.120Z".
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Time is a time.Time for externally visible timestamps. It is stored like
+// a time.Time, but always serializes as TimeLayout, whatever the server's
+// zone and the database driver's precision, so clients can parse API
+// timestamps strictly. The embedded time.Time keeps its methods available,
+// in Go code and in templates ({{ .CreatedAt.Format "2006-01-02" }}).
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps t.
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// TimePtr returns a *Time wrapping t, for optional timestamps.
+func TimePtr(t time.Time) *Time {
+	return &Time{Time: t}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Time) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, len(TimeLayout)+2)
+	b = append(b, '"')
+	b = t.UTC().AppendFormat(b, TimeLayout)
+	return append(b, '"'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts any RFC 3339
+// timestamp, with or without fractional seconds; null leaves t unchanged.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("domain.Time: %s is not a JSON string", data)
+	}
+	parsed, err := time.Parse(time.RFC3339, string(data[1:len(data)-1]))
+	if err != nil {
+		return fmt.Errorf("domain.Time: %w", err)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, storing the plain time.Time.
+func (t Time) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+// Scan implements sql.Scanner for the values drivers return for a
+// timestamp column.
+func (t *Time) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	case string:
+		return t.scanText(v)
+	case []byte:
+		return t.scanText(string(v))
+	default:
+		return fmt.Errorf("domain.Time: cannot scan %T", src)
+	}
+	return nil
+}
+
+// sqliteTimeLayout is how SQLite drivers write a time.Time as text.
+const sqliteTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+func (t *Time) scanText(s string) error {
+	for _, layout := range []string{time.RFC3339Nano, sqliteTimeLayout, time.DateTime} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("domain.Time: cannot parse %q", s)
+}
+
+// GormDataType gives Time the column type of a time.Time.
+func (Time) GormDataType() string {
+	return "time"
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTime_MarshalJSONFormat(t *testing.T) {
+	zone := time.FixedZone("UTC+8", 8*60*60)
+	instant := time.Date(2024, 3, 5, 22, 7, 9, 120456789, zone)
+
+	b, err := json.Marshal(struct {
+		At Time `json:"at"`
+	}{NewTime(instant)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"at":"2024-03-05T14:07:09.120Z"}`; string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+
+	// Whole seconds keep their three fraction digits.
+	b, _ = json.Marshal(NewTime(time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)))
+	if want := `"2024-03-05T14:07:09.000Z"`; string(b) != want {
+		t.Errorf("Marshal(whole second) = %s, want %s", b, want)
+	}
+}
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+	want := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"2024-03-05T14:07:09Z"`, want},
+		{`"2024-03-05T14:07:09.120Z"`, want.Add(120 * time.Millisecond)},
+		{`"2024-03-05T22:07:09+08:00"`, want},
+		{`"2024-03-05T14:07:09.123456789Z"`, want.Add(123456789)},
+	}
+	for _, tt := range tests {
+		var got Time
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"2024-03-05"`, `"yesterday"`, `1709647629`} {
+		var got Time
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("Unmarshal(%s) error = nil, want an error", in)
+		}
+	}
+
+	var ptr struct {
+		At *Time `json:"at"`
+	}
+	if err := json.Unmarshal([]byte(`{"at":null}`), &ptr); err != nil || ptr.At != nil {
+		t.Errorf("Unmarshal(null) = %v, %v; want nil", ptr.At, err)
+	}
+}
+
+func TestTime_RoundTrip(t *testing.T) {
+	orig := NewTime(time.Date(2024, 3, 5, 14, 7, 9, 120000000, time.UTC))
+	b, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Time
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", b, err)
+	}
+	if !got.Equal(orig.Time) {
+		t.Errorf("round trip = %v, want %v", got.Time, orig.Time)
+	}
+}
+
+func TestTime_Scan(t *testing.T) {
+	want := time.Date(2024, 3, 5, 14, 7, 9, 120000000, time.UTC)
+	for _, src := range []any{want, "2024-03-05 14:07:09.12+00:00", []byte("2024-03-05T14:07:09.12Z")} {
+		var got Time
+		if err := got.Scan(src); err != nil || !got.Equal(want) {
+			t.Errorf("Scan(%T %v) = %v, %v; want %v", src, src, got.Time, err, want)
+		}
+	}
+	var got Time
+	if err := got.Scan(nil); err != nil || !got.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v; want zero", got.Time, err)
+	}
+	if err := got.Scan(42); err == nil {
+		t.Error("Scan(int) error = nil, want an error")
+	}
+}
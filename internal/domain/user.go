@@ -3,15 +3,39 @@ package domain
 import (
 	"context"
 
-	"github.com/simp-lee/pagination"
+	"gorm.io/gorm"
+)
+
+// Length limits of user fields, in characters (runes). The column sizes of
+// User and the binding tags of the request structs must agree with them;
+// tags cannot reference constants, so guard tests compare the two.
+// EmailMaxLen is the longest address SMTP can deliver to (RFC 5321).
+const (
+	NameMinLen  = 2
+	NameMaxLen  = 100
+	EmailMaxLen = 254
+	BioMaxLen   = 2000
 )
 
 // User represents a user in the system.
+//
+// User is tenant-scoped like the models embedding TenantScoped, but declares
+// TenantID itself so that emails are unique per tenant rather than across
+// all of them: a tenant cannot learn from a conflict which addresses
+// another has registered.
 type User struct {
 	BaseModel
+	TenantID     string `gorm:"size:64;not null;default:'';index;uniqueIndex:idx_users_tenant_email,priority:1" json:"-"`
 	Name         string `gorm:"size:100;not null" json:"name"`
-	Email        string `gorm:"size:255;uniqueIndex;not null" json:"email"`
+	Email        string `gorm:"size:254;uniqueIndex:idx_users_tenant_email,priority:2;not null" json:"email"`
 	PasswordHash string `gorm:"size:255" json:"-"`
+	Bio          string `gorm:"type:text" json:"bio"`
+}
+
+// BeforeCreate is a GORM hook that stamps TenantID as TenantScoped does.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	stampTenant(tx, &u.TenantID)
+	return nil
 }
 
 // UserRepository defines the data access interface for users.
@@ -19,16 +43,34 @@ type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uint) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
-	List(ctx context.Context, req PageRequest) (*pagination.Pagination[User], error)
-	Update(ctx context.Context, user *User) error
+	List(ctx context.Context, req PageRequest) (*PageResult[User], error)
+	// Update writes user unless it equals the stored row, compared in the
+	// write transaction: then nothing is written, UpdatedAt keeps its value,
+	// user is filled from the row and changed is false.
+	Update(ctx context.Context, user *User) (changed bool, err error)
+	// UpdatePasswordHash replaces only the password hash of user id, leaving
+	// concurrent changes to other fields intact.
+	UpdatePasswordHash(ctx context.Context, id uint, hash string) error
 	Delete(ctx context.Context, id uint) error
 }
 
+// UserPatch is a partial user update; unset fields keep their value.
+type UserPatch struct {
+	Name  PatchField[string]
+	Email PatchField[string]
+	Bio   PatchField[string]
+}
+
 // UserService defines the business logic interface for users.
 type UserService interface {
-	CreateUser(ctx context.Context, name, email string) (*User, error)
+	CreateUser(ctx context.Context, name, email, bio string) (*User, error)
 	GetUser(ctx context.Context, id uint) (*User, error)
-	ListUsers(ctx context.Context, req PageRequest) (*pagination.Pagination[User], error)
-	UpdateUser(ctx context.Context, id uint, name, email string) (*User, error)
+	ListUsers(ctx context.Context, req PageRequest) (*PageResult[User], error)
+	// UpdateUser and PatchUser report whether the user was modified; an
+	// update with the stored values writes nothing and has no side effects.
+	UpdateUser(ctx context.Context, id uint, name, email, bio string) (user *User, modified bool, err error)
+	// PatchUser applies the fields set in patch. Null clears Bio and is a
+	// validation error for Name and Email.
+	PatchUser(ctx context.Context, id uint, patch UserPatch) (user *User, modified bool, err error)
 	DeleteUser(ctx context.Context, id uint) error
 }
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"encoding/json"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -51,3 +53,29 @@ func TestUserJSON_UnmarshalIgnoresPasswordHashField(t *testing.T) {
 		t.Fatalf("PasswordHash = %q, want empty", user.PasswordHash)
 	}
 }
+
+// TestUser_ColumnSizesMatchLimits fails when a column size drifts from the
+// length limit the service validates, which Postgres would report as a
+// value-too-long error instead of a validation error.
+func TestUser_ColumnSizesMatchLimits(t *testing.T) {
+	for field, want := range map[string]int{"Name": NameMaxLen, "Email": EmailMaxLen} {
+		f, ok := reflect.TypeOf(User{}).FieldByName(field)
+		if !ok {
+			t.Fatalf("User has no field %s", field)
+		}
+		size := -1
+		for _, part := range strings.Split(f.Tag.Get("gorm"), ";") {
+			if v, ok := strings.CutPrefix(part, "size:"); ok {
+				size, _ = strconv.Atoi(v)
+			}
+		}
+		if size != want {
+			t.Errorf("User.%s gorm size = %d, want %d", field, size, want)
+		}
+	}
+
+	// Bio is unbounded text; its limit is the service's alone.
+	if f, _ := reflect.TypeOf(User{}).FieldByName("Bio"); !strings.Contains(f.Tag.Get("gorm"), "type:text") {
+		t.Errorf("User.Bio gorm tag = %q, want type:text", f.Tag.Get("gorm"))
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// prettyLine is the layout of a PrettyAccessLog line without colors.
+var prettyLine = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}\.\d{3}) (\d{3}) (\S+) +(\S+) +(\S+) +(\d+B) (\S+)$`)
+
+func TestPrettyAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(RequestID("", nil, nil)).Use(PrettyAccessLog(&out, true)).Build())
+	r.GET("/api/v1/users", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	r.POST("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	r.NoRoute(func(c *gin.Context) { c.String(http.StatusNotFound, "not found") })
+
+	tests := []struct {
+		method, path, status, color, size string
+	}{
+		{http.MethodGet, "/api/v1/users?page=2", "200", ansiGreen, "5B"},
+		{http.MethodGet, "/missing", "404", ansiYellow, "9B"},
+		{http.MethodPost, "/fail", "500", ansiRed, "0B"},
+	}
+	for _, tt := range tests {
+		out.Reset()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		raw := strings.TrimSuffix(out.String(), "\n")
+		if strings.Count(out.String(), "\n") != 1 {
+			t.Fatalf("%s %s logged %q, want one line", tt.method, tt.path, out.String())
+		}
+		if !strings.Contains(raw, tt.color+tt.status+ansiReset) {
+			t.Errorf("%s %s: status not colored %q in %q", tt.method, tt.path, tt.color, raw)
+		}
+
+		line := ansiEscape.ReplaceAllString(raw, "")
+		m := prettyLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("line %q does not match the layout", line)
+		}
+		path, _, _ := strings.Cut(tt.path, "?")
+		if m[2] != tt.status || m[3] != tt.method || m[4] != path || m[6] != tt.size || m[7] != w.Header().Get(RequestIDHeader) {
+			t.Errorf("line %q = status %s method %s path %s size %s id %s; want %s %s %s %s %s",
+				line, m[2], m[3], m[4], m[6], m[7], tt.status, tt.method, path, tt.size, w.Header().Get(RequestIDHeader))
+		}
+		// The path column is padded, so the duration starts at a fixed
+		// offset for short paths.
+		if got, want := strings.Index(line, path)+accessPathWidth, len("00:00:00.000 200 GET     ")+accessPathWidth; got != want {
+			t.Errorf("path column ends at %d, want %d in %q", got, want, line)
+		}
+	}
+}
+
+func TestPrettyAccessLog_NoColor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var out bytes.Buffer
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(PrettyAccessLog(&out, false)).Build())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("line %q has color codes", out.String())
+	}
+	if !strings.Contains(out.String(), " 204 GET ") {
+		t.Errorf("line %q missing status and method", out.String())
+	}
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// hostnamePattern matches lower-case DNS host names.
+var hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// HostErrorHandler writes the response for a request AllowedHosts rejects.
+type HostErrorHandler func(c *gin.Context, status int, message string)
+
+// HostOption configures AllowedHosts.
+type HostOption func(*hostOptions)
+
+type hostOptions struct {
+	exempt  ginx.Condition
+	onError HostErrorHandler
+	trusted []netip.Prefix
+}
+
+// WithHostExempt skips the check, and records no origin, for requests
+// matching cond.
+func WithHostExempt(cond ginx.Condition) HostOption {
+	return func(o *hostOptions) {
+		o.exempt = cond
+	}
+}
+
+// WithHostErrorHandler replaces the default pkg.JSONError response, e.g.
+// to render an HTML error page for browsers.
+func WithHostErrorHandler(h HostErrorHandler) HostOption {
+	return func(o *hostOptions) {
+		o.onError = h
+	}
+}
+
+// WithForwardedProto takes the scheme of the recorded origin from
+// X-Forwarded-Proto when the direct peer's address is in trusted (see
+// config.ParseTrustedProxies), so a TLS-terminating proxy yields https URLs.
+func WithForwardedProto(trusted []netip.Prefix) HostOption {
+	return func(o *hostOptions) {
+		o.trusted = trusted
+	}
+}
+
+// AllowedHosts returns a ginx middleware that checks the Host header against
+// allowed, entries normalized by config.NormalizeAllowedHost: a host name or
+// IP address matches itself, "*.example.com" matches every subdomain of
+// example.com. The port is ignored. A request without a well-formed Host
+// gets 400, one whose host is not allowed 421 Misdirected Request. An empty
+// allowed accepts every well-formed host.
+//
+// Accepted requests have their origin recorded with pkg.SetRequestOrigin,
+// lower-cased and without the default port, so pkg.AbsoluteURL never builds
+// a URL on a host outside allowed.
+func AllowedHosts(allowed []string, opts ...HostOption) ginx.Middleware {
+	o := hostOptions{onError: pkg.JSONError}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if o.exempt != nil && o.exempt(c) {
+				next(c)
+				return
+			}
+			name, port, ok := parseHost(c.Request.Host)
+			if !ok {
+				o.onError(c, http.StatusBadRequest, "invalid host header")
+				c.Abort()
+				return
+			}
+			if len(allowed) > 0 && !hostAllowed(name, allowed) {
+				o.onError(c, http.StatusMisdirectedRequest, "host not allowed")
+				c.Abort()
+				return
+			}
+			scheme := requestScheme(c, o.trusted)
+			host := name
+			if strings.Contains(name, ":") {
+				host = "[" + name + "]"
+			}
+			if port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+				host = net.JoinHostPort(name, port)
+			}
+			pkg.SetRequestOrigin(c, scheme, host)
+			next(c)
+		}
+	}
+}
+
+// parseHost splits a Host header into its normalized name, in the form of
+// config.NormalizeAllowedHost, and port. It reports false for anything but
+// a host name, IPv4 address or bracketed IPv6 address with an optional
+// numeric port.
+func parseHost(raw string) (name, port string, ok bool) {
+	name = strings.ToLower(raw)
+	if i := strings.LastIndexByte(name, ':'); i >= 0 && !strings.HasSuffix(name, "]") {
+		name, port = name[:i], name[i+1:]
+		if port == "" || strings.Trim(port, "0123456789") != "" {
+			return "", "", false
+		}
+	}
+	if v6, ok := strings.CutPrefix(name, "["); ok {
+		addr, err := netip.ParseAddr(strings.TrimSuffix(v6, "]"))
+		if err != nil || !strings.HasSuffix(v6, "]") || !addr.Is6() {
+			return "", "", false
+		}
+		return addr.Unmap().String(), port, true
+	}
+	name = strings.TrimSuffix(name, ".")
+	if addr, err := netip.ParseAddr(name); err == nil && addr.Is4() {
+		return addr.String(), port, true
+	}
+	if !hostnamePattern.MatchString(name) {
+		return "", "", false
+	}
+	return name, port, true
+}
+
+// hostAllowed reports whether name matches one of allowed.
+func hostAllowed(name string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(name, suffix) {
+				return true
+			}
+			continue
+		}
+		if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// requestScheme returns "https" for TLS connections and, from a trusted
+// peer, for X-Forwarded-Proto: https; "http" otherwise.
+func requestScheme(c *gin.Context, trusted []netip.Prefix) string {
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	if proto, _, _ := strings.Cut(c.GetHeader("X-Forwarded-Proto"), ","); fromTrustedPeer(c, trusted) && strings.EqualFold(strings.TrimSpace(proto), "https") {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// allowedHostsRouter serves /url, which echoes pkg.AbsoluteURL for "/next"
+// or "-" when the request has no origin.
+func allowedHostsRouter(allowed []string, opts ...HostOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(AllowedHosts(allowed, opts...)).Build())
+	r.GET("/url", func(c *gin.Context) {
+		u, ok := pkg.AbsoluteURL(c, "/next")
+		if !ok {
+			u = "-"
+		}
+		c.String(http.StatusOK, u)
+	})
+	return r
+}
+
+func serveHost(r *gin.Engine, host string, edit func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/url", nil)
+	req.Host = host
+	if edit != nil {
+		edit(req)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAllowedHosts_Matching(t *testing.T) {
+	r := allowedHostsRouter([]string{"example.com", "*.example.org", "192.0.2.10", "::1"})
+
+	tests := []struct {
+		host string
+		code int
+		url  string
+	}{
+		{"example.com", http.StatusOK, "http://example.com/next"},
+		{"EXAMPLE.com.", http.StatusOK, "http://example.com/next"},
+		{"example.com:8080", http.StatusOK, "http://example.com:8080/next"},
+		{"example.com:80", http.StatusOK, "http://example.com/next"},
+		{"api.example.org", http.StatusOK, "http://api.example.org/next"},
+		{"a.b.example.org:443", http.StatusOK, "http://a.b.example.org:443/next"},
+		{"192.0.2.10", http.StatusOK, "http://192.0.2.10/next"},
+		{"[::1]:3000", http.StatusOK, "http://[::1]:3000/next"},
+
+		{"example.org", http.StatusMisdirectedRequest, ""},
+		{"evil.com", http.StatusMisdirectedRequest, ""},
+		{"example.com.evil.com", http.StatusMisdirectedRequest, ""},
+		{"evilexample.org", http.StatusMisdirectedRequest, ""},
+		{"www.example.com", http.StatusMisdirectedRequest, ""},
+
+		{"", http.StatusBadRequest, ""},
+		{"example.com:", http.StatusBadRequest, ""},
+		{"example.com:http", http.StatusBadRequest, ""},
+		{"exa mple.com", http.StatusBadRequest, ""},
+		{"user@example.com", http.StatusBadRequest, ""},
+		{"[example.com]", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			w := serveHost(r, tt.host, nil)
+			if w.Code != tt.code {
+				t.Fatalf("Host %q: status = %d, want %d", tt.host, w.Code, tt.code)
+			}
+			if tt.url != "" && w.Body.String() != tt.url {
+				t.Errorf("Host %q: AbsoluteURL = %q, want %q", tt.host, w.Body.String(), tt.url)
+			}
+		})
+	}
+}
+
+func TestAllowedHosts_EmptyAcceptsAnyHost(t *testing.T) {
+	r := allowedHostsRouter(nil)
+	if w := serveHost(r, "anything.test", nil); w.Code != http.StatusOK || w.Body.String() != "http://anything.test/next" {
+		t.Errorf("response = %d %q, want 200 with the request's host", w.Code, w.Body.String())
+	}
+	if w := serveHost(r, "bad host", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed host: status = %d, want 400", w.Code)
+	}
+}
+
+func TestAllowedHosts_Exempt(t *testing.T) {
+	r := allowedHostsRouter([]string{"example.com"}, WithHostExempt(ginx.PathIs("/url")))
+	if w := serveHost(r, "10.0.0.5", nil); w.Code != http.StatusOK || w.Body.String() != "-" {
+		t.Errorf("exempt request = %d %q, want 200 without an origin", w.Code, w.Body.String())
+	}
+}
+
+func TestAllowedHosts_ErrorHandler(t *testing.T) {
+	var got int
+	r := allowedHostsRouter([]string{"example.com"}, WithHostErrorHandler(func(c *gin.Context, status int, message string) {
+		got = status
+		c.String(status, message)
+	}))
+	if w := serveHost(r, "evil.com", nil); w.Body.String() != "host not allowed" || got != http.StatusMisdirectedRequest {
+		t.Errorf("handler got %d, body %q; want 421 host not allowed", got, w.Body.String())
+	}
+}
+
+func TestAllowedHosts_Scheme(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	r := allowedHostsRouter([]string{"example.com"}, WithForwardedProto(trusted))
+
+	tests := []struct {
+		name string
+		edit func(*http.Request)
+		want string
+	}{
+		{"plain", nil, "http://example.com/next"},
+		{"tls", func(req *http.Request) { req.TLS = &tls.ConnectionState{} }, "https://example.com/next"},
+		{"trusted proxy", func(req *http.Request) {
+			req.RemoteAddr = "10.1.2.3:4000"
+			req.Header.Set("X-Forwarded-Proto", "https, http")
+		}, "https://example.com/next"},
+		{"untrusted peer", func(req *http.Request) {
+			req.RemoteAddr = "203.0.113.9:4000"
+			req.Header.Set("X-Forwarded-Proto", "https")
+		}, "http://example.com/next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveHost(r, "example.com", tt.edit); w.Body.String() != tt.want {
+				t.Errorf("AbsoluteURL = %q, want %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+var testAPIKeys = []APIKey{
+	{Name: "export", Hash: sha256.Sum256([]byte("export-secret")), Scopes: []string{"users:read", "reports:*"}},
+	{Name: "ops", Hash: sha256.Sum256([]byte("ops-secret")), Scopes: []string{"*:read"}},
+}
+
+// apiKeyRouter serves /whoami behind APIKeyAuth, echoing the user ID and
+// "jwt" when the request fell through to the next authenticator.
+func apiKeyRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(APIKeyAuth(testAPIKeys)).
+		When(ginx.Not(IsAPIKeyRequest), func(next gin.HandlerFunc) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				ginx.SetUserID(c, "jwt")
+				next(c)
+			}
+		}).
+		Build())
+	r.GET("/whoami", func(c *gin.Context) {
+		id, _ := ginx.GetUserID(c)
+		c.String(http.StatusOK, id)
+	})
+	return r
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	r := apiKeyRouter()
+
+	tests := []struct {
+		name, key string
+		wantCode  int
+		wantBody  string
+	}{
+		{"first key", "export-secret", http.StatusOK, "apikey:export"},
+		{"second key", "ops-secret", http.StatusOK, "apikey:ops"},
+		{"unknown key", "guess", http.StatusUnauthorized, ""},
+		{"no header", "", http.StatusOK, "jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("user = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWithAPIKeyScopes(t *testing.T) {
+	svc := WithAPIKeyScopes(&userGrantsRBAC{grants: map[string][]string{"1": {"users:delete"}}}, testAPIKeys)
+
+	tests := []struct {
+		userID, resource, action string
+		want                     bool
+	}{
+		{"apikey:export", "users", "read", true},
+		{"apikey:export", "users", "delete", false},
+		{"apikey:export", "reports", "create", true},
+		{"apikey:export", "notes", "read", false},
+		{"apikey:ops", "notes", "read", true},
+		{"apikey:ops", "notes", "update", false},
+		{"apikey:unknown", "users", "read", false},
+		{"1", "users", "delete", true},
+		{"1", "users", "read", false},
+	}
+	for _, tt := range tests {
+		got, err := svc.HasPermission(tt.userID, tt.resource, tt.action)
+		if err != nil || got != tt.want {
+			t.Errorf("HasPermission(%q, %q, %q) = %v, %v; want %v", tt.userID, tt.resource, tt.action, got, err, tt.want)
+		}
+	}
+	if ok, _ := svc.HasRolePermission("apikey:export", "users", "read"); ok {
+		t.Error("HasRolePermission granted a scope, want keys to hold no roles")
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/jwt"
+
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// Auth returns ginx.Auth for jwtService, additionally recording the token's
+// subject with requestctx.SetUserID, so services that only receive the
+// request's context.Context see it (requestctx.UserID) too.
+func Auth(jwtService jwt.Service) ginx.Middleware {
+	auth := ginx.Auth(jwtService)
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return auth(func(c *gin.Context) {
+			if id, ok := ginx.GetUserID(c); ok {
+				requestctx.SetUserID(c, id)
+			}
+			next(c)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/jwt"
+
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestAuth_RecordsSubjectInRequestContext(t *testing.T) {
+	svc, err := jwt.New("test-secret-at-least-32-bytes-long!!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(Auth(svc)).Build())
+	r.GET("/me", func(c *gin.Context) {
+		// A service sees only the request's context.Context.
+		id, _ := requestctx.UserID(c.Request.Context())
+		c.String(http.StatusOK, id)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+testutil.MintToken(t, svc, 42))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "42" {
+		t.Errorf("GET /me = %d %q, want 200 42", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /me without a token = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// CanonicalPath returns a ginx middleware that redirects requests whose path
+// matched no route to the canonical form of that path.
+//
+// Canonical paths never end in "/" (except the root) and, when
+// caseInsensitiveAPI is true, /api paths are lowercase. API requests are
+// redirected with 308 Permanent Redirect so the method and body survive;
+// page requests keep gin's previous codes (301 for GET/HEAD, 307 otherwise).
+// Requests that matched a route, or whose path is already canonical, pass
+// through untouched and fall into NoRoute as usual.
+//
+// It replaces gin's RedirectTrailingSlash, which runs before any middleware
+// and therefore answers without CORS headers or a request ID. Register it
+// before Auth so non-canonical public paths are redirected, not rejected.
+func CanonicalPath(caseInsensitiveAPI bool) ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if c.FullPath() != "" {
+				next(c)
+				return
+			}
+
+			path := c.Request.URL.Path
+			canonical := CanonicalRequestPath(path, caseInsensitiveAPI)
+			if canonical == path {
+				next(c)
+				return
+			}
+
+			code := http.StatusPermanentRedirect
+			if !IsAPIPath(path) {
+				code = http.StatusTemporaryRedirect
+				if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+					code = http.StatusMovedPermanently
+				}
+			}
+
+			location := *c.Request.URL
+			location.Path = canonical
+			location.RawPath = ""
+			c.Redirect(code, location.RequestURI())
+			c.Abort()
+		}
+	}
+}
+
+// CanonicalRequestPath returns the canonical form of a request path: trailing
+// slashes are removed and, when caseInsensitiveAPI is true, /api paths are
+// lowercased. Leading slashes and backslashes are collapsed to a single "/"
+// so the result is always a same-origin path and never "//host".
+func CanonicalRequestPath(path string, caseInsensitiveAPI bool) string {
+	canonical := "/" + strings.TrimLeft(strings.TrimRight(path, "/"), "/\\")
+	if caseInsensitiveAPI && IsAPIPath(canonical) {
+		canonical = strings.ToLower(canonical)
+	}
+	return canonical
+}
+
+// IsAPIPath reports whether path starts with "/api/", ignoring case. The bare
+// "/api" path is not an API path.
+func IsAPIPath(path string) bool {
+	return len(path) >= len("/api/") && strings.EqualFold(path[:len("/api/")], "/api/")
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+func TestCanonicalRequestPath(t *testing.T) {
+	tests := []struct {
+		path            string
+		caseInsensitive bool
+		want            string
+	}{
+		{path: "/", want: "/"},
+		{path: "/api/v1/users", want: "/api/v1/users"},
+		{path: "/api/v1/users/", want: "/api/v1/users"},
+		{path: "/api/v1/users///", want: "/api/v1/users"},
+		{path: "/users/", want: "/users"},
+		{path: "/API/v1/Users", want: "/API/v1/Users"},
+		{path: "/API/v1/Users/", caseInsensitive: true, want: "/api/v1/users"},
+		{path: "/Users", caseInsensitive: true, want: "/Users"},
+		{path: "//evil.example/", want: "/evil.example"},
+		{path: "/\\evil.example/", want: "/evil.example"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalRequestPath(tt.path, tt.caseInsensitive); got != tt.want {
+			t.Errorf("CanonicalRequestPath(%q, %v) = %q, want %q", tt.path, tt.caseInsensitive, got, tt.want)
+		}
+	}
+}
+
+func TestIsAPIPath(t *testing.T) {
+	tests := map[string]bool{
+		"/api/v1/users": true,
+		"/API/v1/users": true,
+		"/Api/":         true,
+		"/api":          false,
+		"/apiary":       false,
+		"/users":        false,
+	}
+	for path, want := range tests {
+		if got := IsAPIPath(path); got != want {
+			t.Errorf("IsAPIPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func setupCanonicalPathRouter(caseInsensitive bool) *gin.Engine {
+	r := gin.New()
+	r.RedirectTrailingSlash = false
+	r.Use(ginx.NewChain().Use(CanonicalPath(caseInsensitive)).Build())
+	r.GET("/api/v1/users", func(c *gin.Context) { c.String(http.StatusOK, "api") })
+	r.GET("/static/*filepath", func(c *gin.Context) { c.String(http.StatusOK, "static") })
+	r.NoRoute(func(c *gin.Context) { c.String(http.StatusNotFound, "no route") })
+	return r
+}
+
+func TestCanonicalPath_Redirects(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		caseInsensitive bool
+		wantCode        int
+		wantLocation    string
+	}{
+		{name: "api GET trailing slash", method: http.MethodGet, target: "/api/v1/users/?page=2", wantCode: http.StatusPermanentRedirect, wantLocation: "/api/v1/users?page=2"},
+		{name: "api POST trailing slash", method: http.MethodPost, target: "/api/v1/users/", wantCode: http.StatusPermanentRedirect, wantLocation: "/api/v1/users"},
+		{name: "page GET trailing slash", method: http.MethodGet, target: "/users/", wantCode: http.StatusMovedPermanently, wantLocation: "/users"},
+		{name: "page POST trailing slash", method: http.MethodPost, target: "/users/", wantCode: http.StatusTemporaryRedirect, wantLocation: "/users"},
+		{name: "mixed case enabled", method: http.MethodGet, target: "/API/v1/Users", caseInsensitive: true, wantCode: http.StatusPermanentRedirect, wantLocation: "/api/v1/users"},
+		{name: "mixed case disabled", method: http.MethodGet, target: "/API/v1/Users", wantCode: http.StatusNotFound},
+		{name: "protocol-relative stays local", method: http.MethodGet, target: "//evil.example/", wantCode: http.StatusMovedPermanently, wantLocation: "/evil.example"},
+		{name: "matched route untouched", method: http.MethodGet, target: "/api/v1/users", caseInsensitive: true, wantCode: http.StatusOK},
+		{name: "matched wildcard untouched", method: http.MethodGet, target: "/static/css/", wantCode: http.StatusOK},
+		{name: "root untouched", method: http.MethodGet, target: "/", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupCanonicalPathRouter(tt.caseInsensitive)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
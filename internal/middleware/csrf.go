@@ -13,10 +13,38 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// CSRFFormField and CSRFHeaderName are where CSRF looks for the request
+// token on unsafe methods; templates emit them with csrfField and hxCSRF.
+const (
+	CSRFFormField  = "_csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// CSRFErrorHandler writes the response for a rejected request; CSRF aborts
+// the context after it returns.
+type CSRFErrorHandler func(c *gin.Context, status int, message string)
+
+// CSRFOption configures CSRF.
+type CSRFOption func(*csrfOptions)
+
+type csrfOptions struct {
+	onError CSRFErrorHandler
+}
+
+// WithCSRFErrorHandler replaces the default {"error": message} JSON
+// response, e.g. to render an HTML error page for browsers.
+func WithCSRFErrorHandler(h CSRFErrorHandler) CSRFOption {
+	return func(o *csrfOptions) {
+		o.onError = h
+	}
+}
+
+func csrfJSONError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{"error": message})
+}
+
 const (
 	csrfCookieName = "_csrf_token"
-	csrfFormField  = "_csrf_token"
-	csrfHeaderName = "X-CSRF-Token"
 	csrfContextKey = "CSRFToken"
 )
 
@@ -31,16 +59,24 @@ const (
 //
 // For POST/PUT/PATCH/DELETE requests, the token is read from the form field "_csrf_token"
 // or the header "X-CSRF-Token" and validated against the cookie value using constant-time
-// comparison. On failure, a 403 Forbidden JSON response is returned.
+// comparison. On failure, a 403 Forbidden JSON response is returned, or
+// whatever WithCSRFErrorHandler writes instead.
 //
 // API routes should be exempted by not registering this middleware on their route groups.
-func CSRF(secret string) gin.HandlerFunc {
+func CSRF(secret string, opts ...CSRFOption) gin.HandlerFunc {
+	o := csrfOptions{onError: csrfJSONError}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	fail := func(c *gin.Context, status int, message string) {
+		o.onError(c, status, message)
+		c.Abort()
+	}
+
 	secret = strings.TrimSpace(secret)
 	if secret == "" {
 		return func(c *gin.Context) {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error": "csrf secret is required",
-			})
+			fail(c, http.StatusInternalServerError, "csrf secret is required")
 		}
 	}
 
@@ -52,9 +88,7 @@ func CSRF(secret string) gin.HandlerFunc {
 			if err != nil || token == "" || !validToken(token, secret) {
 				token, err = generateToken(secret)
 				if err != nil {
-					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-						"error": "failed to generate CSRF token",
-					})
+					fail(c, http.StatusInternalServerError, "failed to generate CSRF token")
 					return
 				}
 				setCSRFCookie(c, token, secure)
@@ -65,34 +99,26 @@ func CSRF(secret string) gin.HandlerFunc {
 		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
 			cookieToken, err := c.Cookie(csrfCookieName)
 			if err != nil || cookieToken == "" {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": "CSRF token missing",
-				})
+				fail(c, http.StatusForbidden, "CSRF token missing")
 				return
 			}
 
-			requestToken := c.PostForm(csrfFormField)
+			requestToken := c.PostForm(CSRFFormField)
 			if requestToken == "" {
-				requestToken = c.GetHeader(csrfHeaderName)
+				requestToken = c.GetHeader(CSRFHeaderName)
 			}
 			if requestToken == "" {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": "CSRF token missing",
-				})
+				fail(c, http.StatusForbidden, "CSRF token missing")
 				return
 			}
 
 			if !validToken(cookieToken, secret) || !validToken(requestToken, secret) {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": "CSRF token invalid",
-				})
+				fail(c, http.StatusForbidden, "CSRF token invalid")
 				return
 			}
 
 			if !tokensMatch(cookieToken, requestToken) {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
-					"error": "CSRF token invalid",
-				})
+				fail(c, http.StatusForbidden, "CSRF token invalid")
 				return
 			}
 
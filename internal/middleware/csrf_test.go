@@ -459,3 +459,22 @@ func mustGenerateToken(secret string) string {
 	}
 	return token
 }
+
+func TestCSRF_CustomErrorHandler(t *testing.T) {
+	r := gin.New()
+	r.Use(CSRF(testCSRFSecret, WithCSRFErrorHandler(func(c *gin.Context, status int, message string) {
+		c.String(status, "custom:"+message)
+	})))
+	reached := false
+	r.POST("/form", func(c *gin.Context) { reached = true })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/form", nil))
+
+	if w.Code != http.StatusForbidden || w.Body.String() != "custom:CSRF token missing" {
+		t.Errorf("status = %d, body = %q; want 403 from the custom handler", w.Code, w.Body.String())
+	}
+	if reached {
+		t.Error("handler ran after a rejected request")
+	}
+}
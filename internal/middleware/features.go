@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// FeatureOverrideHeader lets QA toggle feature flags for a single request,
+// e.g. "X-Feature-Override: user_search=true, bulk_delete=false". A bare
+// name means true.
+const FeatureOverrideHeader = "X-Feature-Override"
+
+// FeatureFlags returns a ginx middleware that stores the configured feature
+// flags in gin.Context (see pkg.FeatureEnabled and pkg.GetFeatures).
+//
+// When allowOverride is true (debug mode only), X-Feature-Override entries
+// replace the configured values for that request. Only flags declared in
+// flags can be overridden; unknown names and unparsable values are ignored.
+// Otherwise the header has no effect.
+func FeatureFlags(flags map[string]bool, allowOverride bool) ginx.Middleware {
+	base := pkg.NewFeatures(flags)
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			features := base
+			if header := c.GetHeader(FeatureOverrideHeader); allowOverride && header != "" {
+				features = pkg.NewFeatures(applyFeatureOverrides(flags, header))
+			}
+			pkg.SetFeatures(c, features)
+			next(c)
+		}
+	}
+}
+
+// applyFeatureOverrides returns a copy of flags with the entries of an
+// X-Feature-Override header applied.
+func applyFeatureOverrides(flags map[string]bool, header string) map[string]bool {
+	merged := make(map[string]bool, len(flags))
+	for name, on := range flags {
+		merged[name] = on
+	}
+	for entry := range strings.SplitSeq(header, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.TrimSpace(name)
+		if _, known := flags[name]; !known {
+			if name != "" {
+				slog.Debug("ignoring override for unknown feature flag", slog.String("flag", name))
+			}
+			continue
+		}
+		on := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				slog.Debug("ignoring invalid feature flag override", slog.String("flag", name), slog.String("value", value))
+				continue
+			}
+			on = parsed
+		}
+		merged[name] = on
+	}
+	return merged
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// featureFlagsRouter serves /flags, which reports the user_search and
+// bulk_delete flags as "search,bulk".
+func featureFlagsRouter(flags map[string]bool, allowOverride bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(FeatureFlags(flags, allowOverride)).Build())
+	r.GET("/flags", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(pkg.FeatureEnabled(c, "user_search"))+","+
+			strconv.FormatBool(pkg.FeatureEnabled(c, "bulk_delete")))
+	})
+	return r
+}
+
+func TestFeatureFlags_Override(t *testing.T) {
+	flags := map[string]bool{"user_search": false, "bulk_delete": true}
+
+	tests := []struct {
+		name          string
+		allowOverride bool
+		header        string
+		want          string
+	}{
+		{name: "configured values", allowOverride: true, want: "false,true"},
+		{name: "bare name enables", allowOverride: true, header: "user_search", want: "true,true"},
+		{name: "explicit values", allowOverride: true, header: "user_search=1, bulk_delete=false", want: "true,false"},
+		{name: "unknown flag ignored", allowOverride: true, header: "other=true,user_search=true", want: "true,true"},
+		{name: "invalid value ignored", allowOverride: true, header: "bulk_delete=maybe", want: "false,true"},
+		{name: "override disabled", allowOverride: false, header: "user_search=true,bulk_delete=false", want: "false,true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := featureFlagsRouter(flags, tt.allowOverride)
+			req := httptest.NewRequest(http.MethodGet, "/flags", nil)
+			if tt.header != "" {
+				req.Header.Set(FeatureOverrideHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("flags = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Overrides are per request and never leak into the configured values.
+	if flags["user_search"] || !flags["bulk_delete"] {
+		t.Errorf("configured flags mutated: %v", flags)
+	}
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// headRoutedKey holds the headWriter of a HEAD request that Head routes as
+// GET.
+type headRoutedKey struct{}
+
+// Head wraps a gin engine so HEAD requests are answered by the GET route of
+// their path; gin itself only routes HEAD to handlers registered for HEAD
+// and answers the rest 405. The request is routed as GET, and RestoreHead,
+// first in the middleware chain, turns it back into HEAD, so caches, logs
+// and handlers see the method the client sent. Explicit HEAD routes are
+// never reached; use CheapHead instead.
+//
+// Whatever body the handler writes is discarded. When the handler finishes
+// without flushing, the response carries a Content-Length equal to the body
+// GET would have sent (unless the handler set one itself, as file serving
+// does). A handler that flushes, such as a stream, commits the headers
+// without Content-Length, and its later writes fail with
+// http.ErrBodyNotAllowed so it stops.
+func Head(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		hw := &headWriter{ResponseWriter: w}
+		routed := r.Clone(context.WithValue(r.Context(), headRoutedKey{}, hw))
+		routed.Method = http.MethodGet
+		next.ServeHTTP(hw, routed)
+		hw.commit(true)
+	})
+}
+
+// RestoreHead returns a ginx middleware that restores the HEAD method of a
+// request Head routed as GET. It must come first in the chain.
+func RestoreHead() ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if _, routed := c.Request.Context().Value(headRoutedKey{}).(*headWriter); routed {
+				c.Request.Method = http.MethodHead
+			}
+			next(c)
+		}
+	}
+}
+
+// CheapHead is a route handler that answers HEAD requests with 200,
+// contentType and the headers earlier middleware set, without running the
+// rest of the route: put it before handlers that render a template or run
+// a query only to have the body discarded. The response has no
+// Content-Length, since the body is never produced. Other methods pass
+// through.
+//
+//	r.GET("/", middleware.CheapHead("text/html; charset=utf-8"), home)
+func CheapHead(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodHead {
+			return
+		}
+		if hw, ok := c.Request.Context().Value(headRoutedKey{}).(*headWriter); ok {
+			hw.unknownLength = true
+		}
+		c.Header("Content-Type", contentType)
+		c.AbortWithStatus(http.StatusOK)
+	}
+}
+
+// headWriter discards and counts the body of a HEAD request served by a GET
+// route, holding the headers back until the handler is done so the count
+// can become the Content-Length.
+type headWriter struct {
+	http.ResponseWriter
+	status    int
+	size      int
+	committed bool
+	// unknownLength is set by CheapHead: no body was produced to count.
+	unknownLength bool
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	if w.status == 0 && !w.committed {
+		w.status = code
+	}
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if w.committed {
+		return 0, http.ErrBodyNotAllowed
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(p)
+	return len(p), nil
+}
+
+// Flush sends the headers now, without Content-Length.
+func (w *headWriter) Flush() {
+	w.commit(false)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the connection's writer.
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// commit writes the held headers once; final adds the Content-Length of
+// the discarded body when the status allows one.
+func (w *headWriter) commit(final bool) {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	h := w.Header()
+	bodyAllowed := w.status >= 200 && w.status != http.StatusNoContent && w.status != http.StatusNotModified
+	if final && bodyAllowed && !w.unknownLength && h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" {
+		h.Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+func TestHead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls int
+	var methods []string
+	var streamErr error
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(RestoreHead()).Build())
+	r.GET("/items", func(c *gin.Context) {
+		calls++
+		methods = append(methods, c.Request.Method)
+		c.Header("Cache-Control", "no-cache")
+		c.JSON(http.StatusOK, gin.H{"items": []int{1, 2, 3}})
+	})
+	r.GET("/cheap", CheapHead("text/html; charset=utf-8"), func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, "expensive")
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, "first")
+		c.Writer.Flush()
+		_, streamErr = c.Writer.Write([]byte("second"))
+	})
+	r.POST("/form", func(c *gin.Context) {})
+	h := Head(r)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	get := serve(http.MethodGet, "/items")
+	head := serve(http.MethodHead, "/items")
+	if calls != 2 || methods[1] != http.MethodHead {
+		t.Fatalf("handler calls = %d, methods = %v; want the GET route run for HEAD, seeing HEAD", calls, methods)
+	}
+	if head.Code != http.StatusOK || head.Body.Len() != 0 {
+		t.Errorf("HEAD /items = %d with %d body bytes, want 200 without body", head.Code, head.Body.Len())
+	}
+	if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+		t.Errorf("HEAD Content-Length = %q, want %q (the GET body length)", got, want)
+	}
+	for _, name := range []string{"Content-Type", "Cache-Control"} {
+		if head.Header().Get(name) != get.Header().Get(name) {
+			t.Errorf("HEAD %s = %q, GET %s = %q", name, head.Header().Get(name), name, get.Header().Get(name))
+		}
+	}
+
+	calls = 0
+	cheap := serve(http.MethodHead, "/cheap")
+	if calls != 0 || cheap.Code != http.StatusOK || cheap.Body.Len() != 0 {
+		t.Errorf("HEAD /cheap: calls = %d, status = %d, body = %q; want 200 without running the handler", calls, cheap.Code, cheap.Body.String())
+	}
+	if ct, cl := cheap.Header().Get("Content-Type"), cheap.Header().Get("Content-Length"); ct != "text/html; charset=utf-8" || cl != "" {
+		t.Errorf("HEAD /cheap Content-Type = %q, Content-Length = %q", ct, cl)
+	}
+	if w := serve(http.MethodGet, "/cheap"); calls != 1 || w.Body.String() != "expensive" {
+		t.Errorf("GET /cheap = %q, calls = %d; want the handler", w.Body.String(), calls)
+	}
+
+	stream := serve(http.MethodHead, "/stream")
+	if stream.Body.Len() != 0 || stream.Header().Get("Content-Length") != "" || !errors.Is(streamErr, http.ErrBodyNotAllowed) {
+		t.Errorf("HEAD /stream: body = %q, Content-Length = %q, write after flush error = %v", stream.Body.String(), stream.Header().Get("Content-Length"), streamErr)
+	}
+
+	if w := serve(http.MethodHead, "/form"); w.Code != http.StatusMethodNotAllowed && w.Code != http.StatusNotFound {
+		t.Errorf("HEAD /form status = %d, want no route", w.Code)
+	}
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client-chosen key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set to "true" on responses served from a
+	// stored result instead of running the handler again.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyPrefix    = "idempotency:"
+	maxIdempotencyKeyLength = 255
+	// maxIdempotencyBodySize caps the request body read to fingerprint it.
+	maxIdempotencyBodySize = 1 << 20
+)
+
+// idempotencyRecord is stored per key. A record with done == false marks a
+// request that is still being processed. Records are never mutated after they
+// are stored; completion replaces the pending record with a new one.
+type idempotencyRecord struct {
+	fingerprint string
+	done        bool
+	status      int
+	contentType string
+	body        []byte
+}
+
+// Idempotency returns a ginx middleware that makes retried requests safe.
+//
+// Requests carrying an "Idempotency-Key" header are executed at most once per
+// key within ttl. The first request runs normally and its response (status,
+// Content-Type, body) is stored in store. A retry with the same key and the
+// same request body receives the stored response with the header
+// "Idempotent-Replayed: true" and the handler is not run again. Reusing a key
+// with a different body, or while the first request is still in flight, is
+// rejected with 409 Conflict. A body over 1 MiB is rejected with 413.
+//
+// Keys are scoped by method, path, tenant and caller: the authenticated
+// principal (requestctx.UserID, "apikey:<name>" for an API key), or the
+// client IP for an anonymous request, so different callers cannot read each
+// other's stored responses. 5xx responses are not
+// stored, allowing the client to retry. Requests without the header pass
+// through untouched. Apply it to POST/PUT API routes only.
+func Idempotency(store cache.CacheInterface, ttl time.Duration) ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			key := c.GetHeader(IdempotencyKeyHeader)
+			if key == "" || store == nil {
+				next(c)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				abortIdempotency(c, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotencyBodySize))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					abortIdempotency(c, http.StatusRequestEntityTooLarge, "request body must be at most 1 MiB")
+					return
+				}
+				abortIdempotency(c, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := idempotencyStoreKey(c, key)
+			fingerprint := sha256Hex(body)
+
+			pending := &idempotencyRecord{fingerprint: fingerprint}
+			got := store.GetOrSetFuncWithExpiration(storeKey, func() interface{} { return pending }, ttl)
+
+			if rec, ok := got.(*idempotencyRecord); ok && rec != pending {
+				switch {
+				case rec.fingerprint != fingerprint:
+					abortIdempotency(c, http.StatusConflict, "Idempotency-Key was already used with a different request body")
+				case !rec.done:
+					abortIdempotency(c, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+				default:
+					c.Header(IdempotentReplayedHeader, "true")
+					c.Data(rec.status, rec.contentType, rec.body)
+					c.Abort()
+				}
+				return
+			}
+
+			// This request owns the key. Release it if the handler does not
+			// produce a storable response (5xx or panic) so retries can run.
+			completed := false
+			defer func() {
+				if !completed {
+					store.Delete(storeKey)
+				}
+			}()
+
+			w := &bodyCaptureWriter{ResponseWriter: c.Writer}
+			c.Writer = w
+			next(c)
+			c.Writer = w.ResponseWriter
+
+			status := w.Status()
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			store.SetWithExpiration(storeKey, &idempotencyRecord{
+				fingerprint: fingerprint,
+				done:        true,
+				status:      status,
+				contentType: w.Header().Get("Content-Type"),
+				body:        w.body.Bytes(),
+			}, ttl)
+			completed = true
+		}
+	}
+}
+
+// idempotencyStoreKey builds the cache key for a client key, scoped to the
+// request method, path, tenant and caller.
+func idempotencyStoreKey(c *gin.Context, key string) string {
+	caller := "ip:" + c.ClientIP()
+	if userID, ok := requestctx.UserID(c); ok {
+		caller = "user:" + userID
+	}
+	tenant, _ := requestctx.Tenant(c)
+	scope := sha256Hex([]byte("tenant:" + tenant + "\n" + caller))
+	return idempotencyKeyPrefix + c.Request.Method + " " + c.Request.URL.Path + ":" + scope + ":" + key
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func abortIdempotency(c *gin.Context, status int, message string) {
+	pkg.JSONError(c, status, message)
+	c.Abort()
+}
+
+// bodyCaptureWriter records the response body while writing it through.
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// setupIdempotencyRouter mounts Idempotency through a ginx chain, the same way
+// app.New does, after authentication, in front of handlers that count their
+// invocations. testAPIKeys authenticate X-API-Key; "Authorization: Bearer
+// <id>" stands in for a JWT of user <id>, and X-Tenant-ID sets the tenant.
+func setupIdempotencyRouter(t *testing.T, ttl time.Duration) (*gin.Engine, *int32) {
+	t.Helper()
+	store := cache.NewCache(cache.Options{DefaultExpiration: ttl, CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+
+	var calls int32
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(func(next gin.HandlerFunc) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if tenant := c.GetHeader("X-Tenant-ID"); tenant != "" {
+					requestctx.SetTenant(c, tenant)
+				}
+				next(c)
+			}
+		}).
+		Use(APIKeyAuth(testAPIKeys)).
+		When(ginx.Not(IsAPIKeyRequest), func(next gin.HandlerFunc) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if id, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+					requestctx.SetUserID(c, id)
+				}
+				next(c)
+			}
+		}).
+		Use(Idempotency(store, ttl)).
+		Build())
+	r.POST("/items", func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+	r.POST("/fail", func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+	return r, &calls
+}
+
+func doIdempotent(r *gin.Engine, path, key, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysSameKeyAndBody(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	first := doIdempotent(r, "/items", "key-1", `{"name":"a"}`)
+	second := doIdempotent(r, "/items", "key-1", `{"name":"a"}`)
+
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("status = %d, %d; want both %d", first.Code, second.Code, http.StatusCreated)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("replayed body = %q, want %q", second.Body.String(), first.Body.String())
+	}
+	if got := second.Header().Get("Content-Type"); got != first.Header().Get("Content-Type") {
+		t.Errorf("replayed Content-Type = %q, want %q", got, first.Header().Get("Content-Type"))
+	}
+	if first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("first response should not be marked as replayed")
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("%s = %q, want %q", IdempotentReplayedHeader, second.Header().Get(IdempotentReplayedHeader), "true")
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestIdempotency_DifferentBodyConflicts(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	doIdempotent(r, "/items", "key-1", `{"name":"a"}`)
+	w := doIdempotent(r, "/items", "key-1", `{"name":"b"}`)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if !strings.Contains(w.Body.String(), "different request body") {
+		t.Errorf("body = %q, want conflict message", w.Body.String())
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestIdempotency_ExpiredKeyRunsFresh(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, 50*time.Millisecond)
+
+	doIdempotent(r, "/items", "key-1", `{"name":"a"}`)
+	time.Sleep(100 * time.Millisecond)
+	w := doIdempotent(r, "/items", "key-1", `{"name":"b"}`)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("response after expiry should not be replayed")
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotency_KeysAreScoped(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	doIdempotent(r, "/items", "key-1", `{}`, "Authorization", "Bearer alice")
+	w := doIdempotent(r, "/items", "key-1", `{}`, "Authorization", "Bearer bob")
+
+	if w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("a different caller must not receive another caller's stored response")
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotency_KeysAreScopedByCaller(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		first, other []string // headers of the two callers
+	}{
+		{"api keys", []string{APIKeyHeader, "export-secret"}, []string{APIKeyHeader, "ops-secret"}},
+		{"tenants", []string{"Authorization", "Bearer alice", "X-Tenant-ID", "acme"}, []string{"Authorization", "Bearer alice", "X-Tenant-ID", "globex"}},
+		{"anonymous", []string{"X-Forwarded-For", "192.0.2.1"}, []string{"X-Forwarded-For", "192.0.2.2"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r, calls := setupIdempotencyRouter(t, time.Minute)
+
+			doIdempotent(r, "/items", "key-1", `{}`, tt.first...)
+			if w := doIdempotent(r, "/items", "key-1", `{}`, tt.other...); w.Header().Get(IdempotentReplayedHeader) != "" {
+				t.Error("a different caller must not receive another caller's stored response")
+			}
+			if w := doIdempotent(r, "/items", "key-1", `{}`, tt.first...); w.Header().Get(IdempotentReplayedHeader) != "true" {
+				t.Error("the same caller's retry was not replayed")
+			}
+			if *calls != 2 {
+				t.Errorf("handler called %d times, want 2", *calls)
+			}
+		})
+	}
+}
+
+func TestIdempotency_ServerErrorNotStored(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	doIdempotent(r, "/fail", "key-1", `{}`)
+	w := doIdempotent(r, "/fail", "key-1", `{}`)
+
+	if w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("5xx responses must not be replayed")
+	}
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotency_WithoutHeaderPassesThrough(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	doIdempotent(r, "/items", "", `{}`)
+	doIdempotent(r, "/items", "", `{}`)
+
+	if *calls != 2 {
+		t.Errorf("handler called %d times, want 2", *calls)
+	}
+}
+
+func TestIdempotency_RejectsOverlongKey(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	w := doIdempotent(r, "/items", strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if *calls != 0 {
+		t.Errorf("handler called %d times, want 0", *calls)
+	}
+
+	w = doIdempotent(r, "/items", strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`, "Accept", pkg.ProblemContentType)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, pkg.ProblemContentType) {
+		t.Errorf("Content-Type = %q, want %s when the client asks for it", ct, pkg.ProblemContentType)
+	}
+}
+
+func TestIdempotency_InFlightKeyConflicts(t *testing.T) {
+	store := cache.NewCache(cache.Options{CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(Idempotency(store, time.Minute)).Build())
+	r.POST("/slow", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- doIdempotent(r, "/slow", "key-1", `{}`) }()
+	<-entered
+
+	w := doIdempotent(r, "/slow", "key-1", `{}`)
+	close(release)
+	<-done
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if !strings.Contains(w.Body.String(), "still in progress") {
+		t.Errorf("body = %q, want in-progress message", w.Body.String())
+	}
+}
+
+func TestIdempotency_RejectsOversizedBody(t *testing.T) {
+	r, calls := setupIdempotencyRouter(t, time.Minute)
+
+	w := doIdempotent(r, "/items", "key-1", `"`+strings.Repeat("x", maxIdempotencyBodySize)+`"`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if *calls != 0 {
+		t.Errorf("handler called %d times, want 0", *calls)
+	}
+}
+
+func TestIdempotency_RestoresWriter(t *testing.T) {
+	store := cache.NewCache(cache.Options{CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+
+	var before, after gin.ResponseWriter
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		before = c.Writer
+		c.Next()
+		after = c.Writer
+	})
+	r.Use(ginx.NewChain().Use(Idempotency(store, time.Minute)).Build())
+	r.POST("/items", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	doIdempotent(r, "/items", "key-1", `{}`)
+
+	if after != before {
+		t.Errorf("c.Writer after Idempotency = %T, want the original %T", after, before)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// LocaleQueryParam overrides Accept-Language for one request, e.g. ?lang=en.
+const LocaleQueryParam = "lang"
+
+// Locale returns a gin middleware that picks the request's locale from
+// supported, whose first entry is the default, and stores it with
+// requestctx.SetLocale. A ?lang value matching a supported locale wins; otherwise
+// the Accept-Language preferences are tried in quality order (see
+// pkg.MatchLocale). An unknown ?lang or a malformed header falls through
+// silently. The choice is sent back as Content-Language, and responses
+// vary on Accept-Language, so register it only on the routes that render
+// localized templates.
+func Locale(supported []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(supported) == 0 {
+			return
+		}
+		locale, ok := "", false
+		if lang := c.Query(LocaleQueryParam); lang != "" {
+			locale, ok = pkg.MatchLocale([]pkg.LanguageRange{{Tag: lang, Q: 1}}, supported)
+		}
+		if !ok {
+			locale, ok = pkg.MatchLocale(pkg.ParseAcceptLanguage(c.GetHeader("Accept-Language")), supported)
+		}
+		if !ok {
+			locale = supported[0]
+		}
+		requestctx.SetLocale(c, locale)
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+	}
+}
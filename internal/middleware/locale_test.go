@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+func TestLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Locale([]string{"zh-CN", "en"}))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, requestctx.Locale(c))
+	})
+
+	tests := []struct {
+		name, target, acceptLanguage, want string
+	}{
+		{"default", "/", "", "zh-CN"},
+		{"header q values", "/", "zh-CN;q=0.8, en;q=0.9", "en"},
+		{"language to region", "/", "zh", "zh-CN"},
+		{"query wins", "/?lang=en", "zh-CN", "en"},
+		{"query language to region", "/?lang=zh", "en", "zh-CN"},
+		{"unknown query ignored", "/?lang=fr", "en", "en"},
+		{"malformed header", "/", "en;q=abc", "zh-CN"},
+		{"no supported match", "/", "fr, de;q=0.5", "zh-CN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("locale = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.want {
+				t.Errorf("Content-Language = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Language" {
+				t.Errorf("Vary = %q, want Accept-Language", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// LongTransferPaths returns a ginx condition matching requests to paths,
+// the server.http.long_transfer_paths entries: one ending in "/" matches
+// every path below it, others match exactly.
+func LongTransferPaths(paths []string) ginx.Condition {
+	return func(c *gin.Context) bool {
+		p := c.Request.URL.Path
+		for _, entry := range paths {
+			if p == entry || strings.HasSuffix(entry, "/") && strings.HasPrefix(p, entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LongTransfer returns a ginx middleware that moves the connection's read
+// and write deadlines to timeout from now (see ExtendDeadlines), so an
+// upload or download is not cut off by the server's ReadTimeout and
+// WriteTimeout but still cannot hold the connection forever. Use it under
+// LongTransferPaths. When the connection does not support deadlines the
+// request goes on with the server's timeouts.
+func LongTransfer(timeout time.Duration) ginx.Middleware {
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			_ = ExtendDeadlines(c.Writer, timeout)
+			next(c)
+		}
+	}
+}
+
+// ExtendDeadlines moves the read and write deadlines of w's connection d
+// from now, or removes them when d is 0, through http.ResponseController.
+// Handlers that stream for a long time can call it between chunks to keep
+// a bounded deadline instead of none. It returns http.ErrNotSupported when
+// w does not reach a connection that supports deadlines.
+func ExtendDeadlines(w http.ResponseWriter, d time.Duration) error {
+	var deadline time.Time
+	if d > 0 {
+		deadline = time.Now().Add(d)
+	}
+	rc := http.NewResponseController(w)
+	return errors.Join(rc.SetReadDeadline(deadline), rc.SetWriteDeadline(deadline))
+}
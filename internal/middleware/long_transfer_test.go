@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLongTransferPaths(t *testing.T) {
+	match := LongTransferPaths([]string{"/users/export", "/files/"})
+	for path, want := range map[string]bool{
+		"/users/export":       true,
+		"/users/export/extra": false,
+		"/users":              false,
+		"/files/":             true,
+		"/files/a/b.zip":      true,
+		"/files":              false,
+		"/filesystem":         false,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		if got := match(c); got != want {
+			t.Errorf("LongTransferPaths(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestExtendDeadlines_NotSupported(t *testing.T) {
+	if err := ExtendDeadlines(httptest.NewRecorder(), 0); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("ExtendDeadlines(recorder) error = %v, want http.ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const unreadContextKey = "UnreadNotifications"
+
+// UnreadCounter returns how many unread notifications the user has.
+type UnreadCounter func(ctx context.Context, userID string) (int64, error)
+
+// Unread is the current page user's unread notification count, looked up the
+// first time a template asks for it and at most once per request.
+type Unread struct {
+	ctx    context.Context
+	count  UnreadCounter
+	userID string
+
+	once sync.Once
+	n    int64
+}
+
+// UnreadNotifications returns a gin middleware for page routes that stores
+// an Unread snapshot for the current user in gin.Context. Mount it only when
+// auth is enabled: without it GetUnread returns nil and the nav hides the
+// badge.
+func UnreadNotifications(count UnreadCounter, identify IdentityFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u := &Unread{ctx: c.Request.Context(), count: count}
+		if identify != nil {
+			u.userID, _ = identify(c)
+		}
+		c.Set(unreadContextKey, u)
+		c.Next()
+	}
+}
+
+// GetUnread retrieves the snapshot stored by UnreadNotifications, or nil if
+// the middleware did not run. Templates receive it as .Unread.
+func GetUnread(c *gin.Context) *Unread {
+	if v, exists := c.Get(unreadContextKey); exists {
+		if u, ok := v.(*Unread); ok {
+			return u
+		}
+	}
+	return nil
+}
+
+// Count returns the number of unread notifications. Anonymous users, a nil
+// *Unread, and failed lookups count as zero.
+func (u *Unread) Count() int64 {
+	if u == nil || u.count == nil || u.userID == "" {
+		return 0
+	}
+	u.once.Do(func() {
+		n, err := u.count(u.ctx, u.userID)
+		if err != nil {
+			slog.Warn("unread notification count failed",
+				slog.String("user_id", u.userID),
+				slog.Any("error", err),
+			)
+			return
+		}
+		u.n = n
+	})
+	return u.n
+}
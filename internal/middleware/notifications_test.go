@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runUnreadNotifications passes a request through UnreadNotifications and
+// returns the snapshot it stored.
+func runUnreadNotifications(t *testing.T, count UnreadCounter, userID string) *Unread {
+	t.Helper()
+	var identify IdentityFunc = func(*gin.Context) (string, bool) { return userID, userID != "" }
+	var got *Unread
+	r := gin.New()
+	r.GET("/", UnreadNotifications(count, identify), func(c *gin.Context) {
+		got = GetUnread(c)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got == nil {
+		t.Fatal("GetUnread returned nil after UnreadNotifications ran")
+	}
+	return got
+}
+
+func TestUnread_CountsOncePerRequest(t *testing.T) {
+	calls := 0
+	count := func(_ context.Context, userID string) (int64, error) {
+		calls++
+		if userID != "7" {
+			t.Errorf("userID = %q, want 7", userID)
+		}
+		return 3, nil
+	}
+	u := runUnreadNotifications(t, count, "7")
+
+	if got := u.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+	u.Count()
+	if calls != 1 {
+		t.Errorf("counter called %d times, want 1", calls)
+	}
+}
+
+func TestUnread_ZeroWithoutUserOrOnError(t *testing.T) {
+	failing := func(context.Context, string) (int64, error) { return 5, errors.New("db down") }
+	if got := runUnreadNotifications(t, failing, "7").Count(); got != 0 {
+		t.Errorf("Count() with failing counter = %d, want 0", got)
+	}
+
+	called := false
+	counter := func(context.Context, string) (int64, error) { called = true; return 5, nil }
+	if got := runUnreadNotifications(t, counter, "").Count(); got != 0 || called {
+		t.Errorf("anonymous Count() = %d (counter called: %v), want 0 without a lookup", got, called)
+	}
+
+	var none *Unread
+	if none.Count() != 0 {
+		t.Error("nil Unread reported a count")
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if GetUnread(c) != nil {
+		t.Error("GetUnread without the middleware should be nil")
+	}
+}
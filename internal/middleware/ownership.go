@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+)
+
+// RequirePermissionOrSelf is ginx.RequirePermission that also lets users act
+// on themselves: the request passes when the authenticated user holds the
+// resource:action permission or is the user named by the idParam path
+// parameter (e.g. "id" for /api/v1/users/:id). It must run after ginx.Auth.
+//
+// A request without an authenticated user is answered 401, one denied both
+// ways 403, and a failed permission lookup 500, matching ginx.RequirePermission.
+func RequirePermissionOrSelf(svc rbac.Service, resource, action, idParam string) ginx.Middleware {
+	if svc == nil {
+		panic("middleware.RequirePermissionOrSelf: rbac service must not be nil")
+	}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			userID, ok := ginx.GetUserIDOrAbort(c)
+			if !ok {
+				return
+			}
+			if sameUserID(userID, c.Param(idParam)) {
+				next(c)
+				return
+			}
+
+			allowed, err := svc.HasPermission(userID, resource, action)
+			if err != nil {
+				ginx.AbortWithError(c, http.StatusInternalServerError, "permission check failed")
+				return
+			}
+			if !allowed {
+				ginx.AbortWithError(c, http.StatusForbidden, "permission denied")
+				return
+			}
+			next(c)
+		}
+	}
+}
+
+// sameUserID reports whether the token subject userID names the user in the
+// path parameter param. Numeric IDs are compared by value, so "/users/007"
+// matches subject "7"; an empty param never matches.
+func sameUserID(userID, param string) bool {
+	if param == "" || userID == "" {
+		return false
+	}
+	a, errA := strconv.ParseUint(userID, 10, 64)
+	b, errB := strconv.ParseUint(param, 10, 64)
+	if errA == nil && errB == nil {
+		return a == b
+	}
+	return userID == param
+}
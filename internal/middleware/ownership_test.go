@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+)
+
+// userGrantsRBAC grants each user the "resource:action" permissions listed
+// for it.
+type userGrantsRBAC struct {
+	rbac.Service
+	grants map[string][]string
+	err    error
+}
+
+func (f *userGrantsRBAC) HasPermission(userID, resource, action string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	for _, p := range f.grants[userID] {
+		if p == resource+":"+action {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ownershipRouter serves PUT /users/:id behind RequirePermissionOrSelf; the
+// X-Test-User header stands in for ginx.Auth.
+func ownershipRouter(svc rbac.Service) *gin.Engine {
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(func(next gin.HandlerFunc) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if id := c.GetHeader("X-Test-User"); id != "" {
+					ginx.SetUserID(c, id)
+				}
+				next(c)
+			}
+		}).
+		Use(RequirePermissionOrSelf(svc, "users", "update", "id")).
+		Build())
+	r.PUT("/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+func TestRequirePermissionOrSelf(t *testing.T) {
+	r := ownershipRouter(&userGrantsRBAC{grants: map[string][]string{"1": {"users:update"}}})
+
+	tests := []struct {
+		name   string
+		user   string
+		target string
+		want   int
+	}{
+		{"self without permission", "7", "7", http.StatusNoContent},
+		{"self with leading zeros", "7", "007", http.StatusNoContent},
+		{"other user without permission", "7", "8", http.StatusForbidden},
+		{"admin on anyone", "1", "8", http.StatusNoContent},
+		{"unauthenticated", "", "7", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/users/"+tt.target, nil)
+			if tt.user != "" {
+				req.Header.Set("X-Test-User", tt.user)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("PUT /users/%s as %q status = %d, want %d", tt.target, tt.user, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionOrSelf_LookupFailure(t *testing.T) {
+	r := ownershipRouter(&userGrantsRBAC{err: errors.New("storage down")})
+	serve := func(target string) int {
+		req := httptest.NewRequest(http.MethodPut, "/users/"+target, nil)
+		req.Header.Set("X-Test-User", "7")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if got := serve("8"); got != http.StatusInternalServerError {
+		t.Errorf("other user status = %d, want 500", got)
+	}
+	if got := serve("7"); got != http.StatusNoContent {
+		t.Errorf("self status = %d, want 204 without a lookup", got)
+	}
+}
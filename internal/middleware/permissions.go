@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/rbac"
+)
+
+const permissionsContextKey = "Permissions"
+
+// NavItem is one navigation link. Permission is a "resource:action" pair the
+// current user must hold for the link to be shown; empty means always shown.
+type NavItem struct {
+	Label      string
+	URL        string
+	Permission string
+}
+
+// IdentityFunc resolves the user ID behind a page request. It returns false
+// for anonymous requests.
+type IdentityFunc func(c *gin.Context) (userID string, ok bool)
+
+// Permissions is a per-request snapshot of what the current page user may do.
+// Lookups go to the rbac.Service at most once per permission per request.
+//
+// A nil *Permissions, or one built without an rbac.Service, allows
+// everything: UI hiding only applies when RBAC is enabled. Hiding is a
+// convenience, not an access control — the API enforces permissions itself.
+type Permissions struct {
+	svc    rbac.Service
+	userID string
+	nav    []NavItem
+
+	mu      sync.Mutex
+	checked map[string]bool
+}
+
+// PagePermissions returns a gin middleware for page routes that stores a
+// Permissions snapshot for the current user in gin.Context. svc may be nil
+// (auth or RBAC disabled), in which case every check passes. identify may be
+// nil when there is no way to identify page users. nav is the full
+// navigation; GetNav returns the subset the user may see.
+func PagePermissions(svc rbac.Service, identify IdentityFunc, nav []NavItem) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := &Permissions{svc: svc, nav: nav, checked: make(map[string]bool)}
+		if svc != nil && identify != nil {
+			p.userID, _ = identify(c)
+		}
+		c.Set(permissionsContextKey, p)
+		c.Next()
+	}
+}
+
+// GetPermissions retrieves the snapshot stored by PagePermissions, or nil if
+// the middleware did not run. Templates receive it as .Perms and query it
+// with the can function.
+func GetPermissions(c *gin.Context) *Permissions {
+	if v, exists := c.Get(permissionsContextKey); exists {
+		if p, ok := v.(*Permissions); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// GetNav returns the navigation items the current user may see, or nil if
+// PagePermissions did not run.
+func GetNav(c *gin.Context) []NavItem {
+	return GetPermissions(c).Nav()
+}
+
+// Can reports whether the user holds permission, given as "resource:action"
+// (e.g. "users:delete"). An empty permission is always granted; a malformed
+// one, an anonymous user, or a failed lookup is denied.
+func (p *Permissions) Can(permission string) bool {
+	if p == nil || p.svc == nil || permission == "" {
+		return true
+	}
+	if p.userID == "" {
+		return false
+	}
+	resource, action, ok := strings.Cut(permission, ":")
+	if !ok || resource == "" || action == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if allowed, ok := p.checked[permission]; ok {
+		return allowed
+	}
+	allowed, err := p.svc.HasPermission(p.userID, resource, action)
+	if err != nil {
+		slog.Warn("page permission check failed",
+			slog.String("user_id", p.userID),
+			slog.String("permission", permission),
+			slog.Any("error", err),
+		)
+		allowed = false
+	}
+	p.checked[permission] = allowed
+	return allowed
+}
+
+// Nav returns the navigation items whose permission the user holds.
+func (p *Permissions) Nav() []NavItem {
+	if p == nil {
+		return nil
+	}
+	items := make([]NavItem, 0, len(p.nav))
+	for _, item := range p.nav {
+		if p.Can(item.Permission) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
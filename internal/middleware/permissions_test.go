@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/rbac"
+)
+
+// countingRBAC grants the listed "resource:action" permissions to every user
+// and counts HasPermission calls.
+type countingRBAC struct {
+	rbac.Service
+	grants map[string]bool
+	err    error
+	calls  int
+}
+
+func (f *countingRBAC) HasPermission(_, resource, action string) (bool, error) {
+	f.calls++
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.grants[resource+":"+action], nil
+}
+
+// runPagePermissions passes a request through PagePermissions and returns
+// the snapshot it stored.
+func runPagePermissions(t *testing.T, svc rbac.Service, userID string, nav []NavItem) *Permissions {
+	t.Helper()
+	var identify IdentityFunc = func(*gin.Context) (string, bool) { return userID, userID != "" }
+	var got *Permissions
+	r := gin.New()
+	r.GET("/", PagePermissions(svc, identify, nav), func(c *gin.Context) {
+		got = GetPermissions(c)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if got == nil {
+		t.Fatal("PagePermissions stored no snapshot")
+	}
+	return got
+}
+
+func TestPermissions_Can(t *testing.T) {
+	svc := &countingRBAC{grants: map[string]bool{"users:read": true}}
+	p := runPagePermissions(t, svc, "7", nil)
+
+	tests := []struct {
+		permission string
+		want       bool
+	}{
+		{"users:read", true},
+		{"users:delete", false},
+		{"", true},
+		{"users", false},
+		{":read", false},
+	}
+	for _, tt := range tests {
+		if got := p.Can(tt.permission); got != tt.want {
+			t.Errorf("Can(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+	}
+
+	calls := svc.calls
+	p.Can("users:read")
+	p.Can("users:delete")
+	if svc.calls != calls {
+		t.Errorf("repeated checks hit rbac %d more times, want cached", svc.calls-calls)
+	}
+}
+
+func TestPermissions_AllowAllWithoutRBAC(t *testing.T) {
+	var nilSnapshot *Permissions
+	if !nilSnapshot.Can("users:delete") {
+		t.Error("nil snapshot should allow everything")
+	}
+	if p := runPagePermissions(t, nil, "", nil); !p.Can("users:delete") {
+		t.Error("snapshot without rbac service should allow everything")
+	}
+}
+
+func TestPermissions_DeniesAnonymousAndLookupErrors(t *testing.T) {
+	grants := map[string]bool{"users:read": true}
+	if p := runPagePermissions(t, &countingRBAC{grants: grants}, "", nil); p.Can("users:read") {
+		t.Error("anonymous user granted users:read")
+	}
+	if p := runPagePermissions(t, &countingRBAC{grants: grants, err: errors.New("db down")}, "7", nil); p.Can("users:read") {
+		t.Error("failed lookup granted users:read")
+	}
+}
+
+func TestPermissions_Nav(t *testing.T) {
+	nav := []NavItem{
+		{Label: "Dashboard", URL: "/dashboard"},
+		{Label: "Users", URL: "/users", Permission: "users:read"},
+		{Label: "Audit", URL: "/audit", Permission: "audit:read"},
+	}
+	p := runPagePermissions(t, &countingRBAC{grants: map[string]bool{"users:read": true}}, "7", nav)
+
+	want := nav[:2]
+	if got := p.Nav(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Nav() = %+v, want %+v", got, want)
+	}
+	if got := runPagePermissions(t, nil, "", nav).Nav(); !reflect.DeepEqual(got, nav) {
+		t.Errorf("Nav() without rbac = %+v, want all items", got)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy declares the RBAC permission guarding a group of API routes: every
+// route under PathPrefix (for Method, or every method when empty) requires
+// Resource:Action. With AllowSelf, requests routed to PathPrefix+"/:id" also
+// pass when :id is the caller (see RequirePermissionOrSelf).
+//
+// A Policy without Resource installs no permission check; it records that
+// the routes are deliberately open to every authenticated caller, such as
+// handlers that scope data to the caller themselves.
+//
+// Route, used instead of PathPrefix, limits the policy to the one route
+// with that gin pattern, e.g. "/api/v1/users/:id/erase", for a route that
+// needs more than the routes sharing its prefix. It cannot AllowSelf.
+type Policy struct {
+	PathPrefix string
+	Route      string
+	Method     string
+	Resource   string
+	Action     string
+	AllowSelf  bool
+}
+
+// Covers reports whether p applies to the route method and path pattern.
+func (p Policy) Covers(method, path string) bool {
+	if p.Method != "" && !strings.EqualFold(p.Method, method) {
+		return false
+	}
+	if p.Route != "" {
+		return path == p.Route
+	}
+	return strings.HasPrefix(path, p.PathPrefix)
+}
+
+// Matches reports whether p guards the request c was routed for: by the
+// request path for a PathPrefix policy, by the gin route for a Route one.
+func (p Policy) Matches(c *gin.Context) bool {
+	if p.Method != "" && !strings.EqualFold(p.Method, c.Request.Method) {
+		return false
+	}
+	if p.Route != "" {
+		return c.FullPath() == p.Route
+	}
+	return strings.HasPrefix(c.Request.URL.Path, p.PathPrefix)
+}
+
+// String renders p as one line of the policy matrix, e.g.
+// "PUT /api/v1/users -> users:update (or self)".
+func (p Policy) String() string {
+	method := strings.ToUpper(p.Method)
+	if method == "" {
+		method = "*"
+	}
+	perm := "authenticated"
+	if p.Resource != "" {
+		perm = p.Resource + ":" + p.Action
+	}
+	if p.AllowSelf {
+		perm += " (or self)"
+	}
+	path := p.PathPrefix
+	if p.Route != "" {
+		path = p.Route
+	}
+	return method + " " + path + " -> " + perm
+}
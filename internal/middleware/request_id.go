@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"net/netip"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+const (
+	// RequestIDHeader is the response header that always carries the request
+	// ID, whichever header an inbound ID was read from.
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// requestIDPattern is the character set accepted for inbound request IDs:
+// enough for UUIDs, hex, base64, and W3C traceparent values, nothing that
+// could break a log line or a header.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._~:+/=-]+$`)
+
+// RequestID returns a ginx middleware that assigns every request an ID,
+// stores it with ginx.SetRequestID and in the request context
+// (requestctx.RequestID), passes it to inject, and echoes it in
+// the X-Request-ID response header before any later middleware can answer
+// (429, 408, 404, ...).
+//
+// The ID sent in header is kept when the direct peer's address is in
+// trusted (see config.ParseTrustedProxies) and the value is at most 128
+// safe characters (see ValidRequestID); otherwise a new ID is generated, so
+// clients cannot choose the IDs written to the logs. A nil trusted always
+// generates.
+func RequestID(header string, trusted []netip.Prefix, inject ginx.ContextInjector) ginx.Middleware {
+	if header == "" {
+		header = RequestIDHeader
+	}
+	assign := ginx.RequestID(ginx.WithRequestIDHeader(header), ginx.WithContextInjector(func(ctx context.Context, id string) context.Context {
+		ctx = requestctx.WithRequestID(ctx, id)
+		if inject != nil {
+			ctx = inject(ctx, id)
+		}
+		return ctx
+	}))
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		echo := func(c *gin.Context) {
+			if header != RequestIDHeader {
+				id, _ := requestctx.RequestID(c)
+				c.Header(RequestIDHeader, id)
+			}
+			next(c)
+		}
+		handle := assign(echo)
+		return func(c *gin.Context) {
+			// ginx.RequestID takes any non-empty inbound value; drop the ones
+			// we do not trust so it generates instead.
+			if id := c.GetHeader(header); id != "" && !(fromTrustedPeer(c, trusted) && ValidRequestID(id)) {
+				c.Request.Header.Del(header)
+			}
+			handle(c)
+		}
+	}
+}
+
+// ValidRequestID reports whether an inbound request ID may be propagated: 1
+// to 128 characters from A-Z, a-z, 0-9 and "._~:+/=-".
+func ValidRequestID(id string) bool {
+	return len(id) <= maxRequestIDLength && requestIDPattern.MatchString(id)
+}
+
+// fromTrustedPeer reports whether the connection's remote address, not a
+// forwarded client IP, lies in trusted.
+func fromTrustedPeer(c *gin.Context, trusted []netip.Prefix) bool {
+	if len(trusted) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// requestIDRouter serves /id, which echoes the ID stored in the context.
+func requestIDRouter(header string, trusted []netip.Prefix) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(RequestID(header, trusted, nil)).Build())
+	r.GET("/id", func(c *gin.Context) {
+		id, _ := ginx.GetRequestID(c)
+		c.String(http.StatusOK, id)
+	})
+	return r
+}
+
+func TestRequestID_InboundIDs(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name    string
+		trusted []netip.Prefix
+		remote  string
+		inbound string
+		keep    bool
+	}{
+		{name: "trusted proxy", trusted: trusted, remote: "10.1.2.3:4000", inbound: "gw-7f3a.1", keep: true},
+		{name: "trusted proxy over IPv4-mapped IPv6", trusted: trusted, remote: "[::ffff:10.1.2.3]:4000", inbound: "gw-7f3a.1", keep: true},
+		{name: "untrusted remote", trusted: trusted, remote: "203.0.113.9:4000", inbound: "gw-7f3a.1"},
+		{name: "accept_incoming off", remote: "10.1.2.3:4000", inbound: "gw-7f3a.1"},
+		{name: "invalid characters", trusted: trusted, remote: "10.1.2.3:4000", inbound: "id with spaces"},
+		{name: "log injection", trusted: trusted, remote: "10.1.2.3:4000", inbound: "abc\" level=ERROR"},
+		{name: "too long", trusted: trusted, remote: "10.1.2.3:4000", inbound: strings.Repeat("a", 129)},
+		{name: "max length", trusted: trusted, remote: "10.1.2.3:4000", inbound: strings.Repeat("a", 128), keep: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := requestIDRouter("", tt.trusted)
+			req := httptest.NewRequest(http.MethodGet, "/id", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set(RequestIDHeader, tt.inbound)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != w.Body.String() {
+				t.Fatalf("response header %q, context ID %q; want the same non-empty ID", got, w.Body.String())
+			}
+			if (got == tt.inbound) != tt.keep {
+				t.Errorf("request ID = %q, want inbound kept = %v", got, tt.keep)
+			}
+		})
+	}
+}
+
+func TestRequestID_CustomTrustedHeader(t *testing.T) {
+	r := requestIDRouter("X-Correlation-ID", []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")})
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil) // RemoteAddr 192.0.2.1
+	req.Header.Set("X-Correlation-ID", "corr-42")
+	req.Header.Set(RequestIDHeader, "ignored")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "corr-42" {
+		t.Errorf("X-Request-ID = %q, want the X-Correlation-ID value", got)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"":                                 false,
+		"0af7651916cd43dd8448eb211c80319c": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": true,
+		"a/b+c=":      true,
+		"line\nbreak": false,
+		"日本":          false,
+	} {
+		if got := ValidRequestID(id); got != want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
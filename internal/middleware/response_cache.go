@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// ResponseCacheKey returns the response cache key for a request. Keys start
+// with the method and path so a whole resource can be purged with a single
+// DeletePrefix. The host is deliberately not part of the key: the app serves
+// one site, and requests replayed internally by the cache warmer must produce
+// entries that real clients hit regardless of the Host they send.
+func ResponseCacheKey(method, path, rawQuery string) string {
+	key := method + " " + path
+	if rawQuery != "" {
+		key += "?" + rawQuery
+	}
+	return key
+}
+
+// CacheKey is a ginx.CacheKeyFunc built on ResponseCacheKey. The query is
+// sorted by parameter name (see SortedQuery), so ?page=2 and ?page=1 never
+// share an entry while reordered parameters do. camelCase responses (see
+// pkg.WantsCamelJSON) are cached under a suffixed key, which still matches
+// the resource's prefix, so the two namings never mix.
+func CacheKey(c *gin.Context) string {
+	key := ResponseCacheKey(c.Request.Method, c.Request.URL.Path, SortedQuery(c.Request.URL.RawQuery))
+	if pkg.WantsCamelJSON(c) {
+		key += " json=camel"
+	}
+	return key
+}
+
+// SortedQuery returns rawQuery re-encoded with its parameters sorted by
+// name; the values of a repeated parameter keep their order. A query that
+// does not parse is returned unchanged rather than losing the bad pairs.
+func SortedQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// ResponseCacheOption configures CountingCache, SingleFlight and
+// CacheInvalidation.
+type ResponseCacheOption func(*responseCacheOptions)
+
+type responseCacheOptions struct {
+	key    ginx.CacheKeyFunc
+	prefix func(path string) string
+}
+
+// WithCacheKey replaces CacheKey as the key of cached and collapsed
+// requests. Keys must start with CacheKey(c) so CacheInvalidation still
+// purges them; pass the same function to both middlewares.
+func WithCacheKey(fn ginx.CacheKeyFunc) ResponseCacheOption {
+	return func(o *responseCacheOptions) {
+		if fn != nil {
+			o.key = fn
+		}
+	}
+}
+
+// WithInvalidationPrefix replaces InvalidationPrefix as the mapping from a
+// write's path to the prefix CacheInvalidation purges, e.g.
+// PageInvalidationPrefix for the HTML pages.
+func WithInvalidationPrefix(fn func(path string) string) ResponseCacheOption {
+	return func(o *responseCacheOptions) {
+		if fn != nil {
+			o.prefix = fn
+		}
+	}
+}
+
+func buildResponseCacheOptions(opts []ResponseCacheOption) responseCacheOptions {
+	o := responseCacheOptions{key: CacheKey, prefix: InvalidationPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// cacheLookupKey marks a request whose response cache lookup has not yet
+// been resolved as a miss.
+const cacheLookupKey = "middleware.cache_lookup"
+
+// CacheCounters counts response cache hits and misses. The cache's own
+// statistics also count internal lookups (such as the expired-entry purge),
+// so the dashboard reads these instead. The zero value is ready to use.
+type CacheCounters struct {
+	hits, misses atomic.Uint64
+}
+
+// Hits returns the number of requests answered from the cache.
+func (c *CacheCounters) Hits() uint64 { return c.hits.Load() }
+
+// Misses returns the number of cacheable requests that ran the handler.
+func (c *CacheCounters) Misses() uint64 { return c.misses.Load() }
+
+// CountingCache is ginx.CacheWithOptions keyed by CacheKey (or WithCacheKey)
+// that records each lookup in counters. Requests the cache bypasses
+// (Authorization, Cookie, Range) are not counted.
+func CountingCache(store cache.CacheInterface, counters *CacheCounters, opts ...ResponseCacheOption) ginx.Middleware {
+	key := buildResponseCacheOptions(opts).key
+	cached := ginx.CacheWithOptions(store, ginx.WithCacheKeyFunc(func(c *gin.Context) string {
+		c.Set(cacheLookupKey, true)
+		return key(c)
+	}))
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		// The cache only calls inner on a miss.
+		inner := cached(func(c *gin.Context) {
+			if c.GetBool(cacheLookupKey) {
+				c.Set(cacheLookupKey, false)
+				counters.misses.Add(1)
+			}
+			next(c)
+		})
+		return func(c *gin.Context) {
+			inner(c)
+			if c.GetBool(cacheLookupKey) {
+				c.Set(cacheLookupKey, false)
+				counters.hits.Add(1)
+			}
+		}
+	}
+}
+
+// InvalidationPrefix returns the resource collection a write to path affects:
+// its first three segments, e.g. "/api/v1/users" for both POST /api/v1/users
+// and PUT /api/v1/users/7, since either changes the list responses. Shorter
+// paths are returned unchanged.
+func InvalidationPrefix(path string) string {
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 4)
+	if len(segments) > 3 {
+		segments = segments[:3]
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// PageInvalidationPrefix is InvalidationPrefix for the HTML pages, whose
+// resource is the first segment: "/users" for POST /users and PUT /users/7.
+func PageInvalidationPrefix(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + segment
+}
+
+// skipInvalidationKey marks a write request that changed nothing.
+const skipInvalidationKey = "middleware.skip_invalidation"
+
+// SkipCacheInvalidation tells CacheInvalidation that the current write
+// request changed nothing, e.g. an update with the stored values, so the
+// cached responses are still valid and are kept.
+func SkipCacheInvalidation(c *gin.Context) {
+	c.Set(skipInvalidationKey, true)
+}
+
+// CacheInvalidation returns a ginx middleware that purges cached GET/HEAD
+// responses under InvalidationPrefix(path) after a successful (2xx) POST,
+// PUT, PATCH, or DELETE, so writes are visible before the cache TTL expires,
+// unless the handler called SkipCacheInvalidation. WithInvalidationPrefix
+// replaces the path-to-prefix mapping; other options are ignored.
+// onPurge, when non-nil, is called with the purged prefix after the entries
+// are removed; app.New uses it to re-warm the cache. Keys must have been built
+// with CacheKey. The prefix match is textual, so /api/v1/users also purges
+// /api/v1/users_archive; over-purging only costs a cache miss.
+func CacheInvalidation(store cache.CacheInterface, onPurge func(prefix string), opts ...ResponseCacheOption) ginx.Middleware {
+	invalidationPrefix := buildResponseCacheOptions(opts).prefix
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			next(c)
+
+			switch c.Request.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				return
+			}
+			if status := c.Writer.Status(); status < 200 || status >= 300 {
+				return
+			}
+			if c.GetBool(skipInvalidationKey) {
+				return
+			}
+
+			prefix := invalidationPrefix(c.Request.URL.Path)
+			store.DeletePrefix(ResponseCacheKey(http.MethodGet, prefix, ""))
+			store.DeletePrefix(ResponseCacheKey(http.MethodHead, prefix, ""))
+			if onPurge != nil {
+				onPurge(prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	if got := ResponseCacheKey(http.MethodGet, "/api/v1/users", ""); got != "GET /api/v1/users" {
+		t.Errorf("without query = %q", got)
+	}
+	if got := ResponseCacheKey(http.MethodGet, "/api/v1/users", "page=2"); got != "GET /api/v1/users?page=2" {
+		t.Errorf("with query = %q", got)
+	}
+}
+
+func TestCacheKey_SeparatesCamelJSON(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users?page=2", nil)
+	snake := CacheKey(c)
+	c.Request.Header.Set(pkg.JSONNamingHeader, "camel")
+	camel := CacheKey(c)
+	if snake != "GET /api/v1/users?page=2" || camel == snake || !strings.HasPrefix(camel, ResponseCacheKey(http.MethodGet, "/api/v1/users", "")) {
+		t.Errorf("CacheKey() = %q (snake), %q (camel); want distinct keys under the resource prefix", snake, camel)
+	}
+}
+
+func TestInvalidationPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/users":         "/api/v1/users",
+		"/api/v1/users/7":       "/api/v1/users",
+		"/api/v1/users/7/roles": "/api/v1/users",
+		"/api/v1":               "/api/v1",
+		"/":                     "/",
+	}
+	for path, want := range tests {
+		if got := InvalidationPrefix(path); got != want {
+			t.Errorf("InvalidationPrefix(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestPageInvalidationPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/users":        "/users",
+		"/users/7":      "/users",
+		"/users/7/undo": "/users",
+		"/":             "/",
+	}
+	for path, want := range tests {
+		if got := PageInvalidationPrefix(path); got != want {
+			t.Errorf("PageInvalidationPrefix(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCountingCache(t *testing.T) {
+	store := cache.NewCache(cache.Options{DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+
+	counters := &CacheCounters{}
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(CountingCache(store, counters)).Build())
+	r.GET("/api/v1/users", func(c *gin.Context) { c.String(http.StatusOK, "list") })
+
+	serve := func(headers ...string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve()                          // miss, stored
+	serve()                          // hit
+	serve()                          // hit
+	serve("Authorization", "Bearer") // bypassed, not counted
+	store.Get("GET /api/v1/users")   // direct lookups are not requests
+
+	if counters.Hits() != 2 || counters.Misses() != 1 {
+		t.Errorf("hits, misses = %d, %d; want 2, 1", counters.Hits(), counters.Misses())
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	store := cache.NewCache(cache.Options{DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+
+	var purged []string
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(ginx.CacheWithOptions(store, ginx.WithCacheKeyFunc(CacheKey))).
+		Use(CacheInvalidation(store, func(prefix string) { purged = append(purged, prefix) })).
+		Build())
+	r.GET("/api/v1/users", func(c *gin.Context) { c.String(http.StatusOK, "list") })
+	r.GET("/api/v1/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "one") })
+	r.GET("/api/v1/posts", func(c *gin.Context) { c.String(http.StatusOK, "posts") })
+	r.PUT("/api/v1/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PATCH("/api/v1/users/:id", func(c *gin.Context) {
+		SkipCacheInvalidation(c) // nothing changed
+		c.Status(http.StatusOK)
+	})
+	r.POST("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	r.POST("/api/v1/posts/bulk", func(c *gin.Context) { c.Status(http.StatusMultiStatus) })
+
+	serve := func(method, path string) {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+	for _, p := range []string{"/api/v1/users", "/api/v1/users?page=2", "/api/v1/users/7", "/api/v1/posts"} {
+		serve(http.MethodGet, p)
+	}
+	if n := store.Count(); n != 4 {
+		t.Fatalf("cached entries = %d, want 4", n)
+	}
+
+	serve(http.MethodPost, "/api/v1/users")
+	if n := store.Count(); n != 4 || len(purged) != 0 {
+		t.Fatalf("failed write purged entries: count = %d, purged = %v", n, purged)
+	}
+
+	serve(http.MethodPatch, "/api/v1/users/7")
+	if n := store.Count(); n != 4 || len(purged) != 0 {
+		t.Fatalf("write that changed nothing purged entries: count = %d, purged = %v", n, purged)
+	}
+
+	serve(http.MethodPut, "/api/v1/users/7")
+	if !store.Has("GET /api/v1/posts") {
+		t.Error("write to users purged an unrelated resource")
+	}
+	for _, key := range []string{"GET /api/v1/users", "GET /api/v1/users?page=2", "GET /api/v1/users/7"} {
+		if store.Has(key) {
+			t.Errorf("%s still cached after PUT", key)
+		}
+	}
+	if len(purged) != 1 || purged[0] != "/api/v1/users" {
+		t.Errorf("onPurge calls = %v, want [/api/v1/users]", purged)
+	}
+
+	// A partly failed bulk write (207) still changed the collection.
+	serve(http.MethodPost, "/api/v1/posts/bulk")
+	if store.Has("GET /api/v1/posts") {
+		t.Error("GET /api/v1/posts still cached after a 207 bulk write")
+	}
+}
+
+func TestCacheInvalidation_WithInvalidationPrefix(t *testing.T) {
+	store := cache.NewCache(cache.Options{DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+	store.Set(ResponseCacheKey(http.MethodGet, "/users", "page=2"), "fragment")
+
+	var purged []string
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(CacheInvalidation(store, func(prefix string) { purged = append(purged, prefix) }, WithInvalidationPrefix(PageInvalidationPrefix))).
+		Build())
+	r.PUT("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/7", nil))
+	if store.Count() != 0 || len(purged) != 1 || purged[0] != "/users" {
+		t.Errorf("after PUT /users/7: count = %d, purged = %v; want the /users list purged", store.Count(), purged)
+	}
+}
+
+func TestSortedQuery(t *testing.T) {
+	for _, tt := range []struct{ raw, want string }{
+		{"", ""},
+		{"page=2", "page=2"},
+		{"page_size=5&page=1", "page=1&page_size=5"},
+		{"tag=b&tag=a", "tag=b&tag=a"},
+		{"q=%zz&a=1", "q=%zz&a=1"},
+	} {
+		if got := SortedQuery(tt.raw); got != tt.want {
+			t.Errorf("SortedQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// sharedResponseHeaders are the leader's response headers copied to waiters.
+// Everything else (X-Request-ID, CORS headers, ...) is per request and was
+// already set on each waiter's own response by earlier middleware.
+var sharedResponseHeaders = []string{
+	"Content-Type",
+	"Content-Language",
+	"Content-Encoding",
+	"Cache-Control",
+	"Expires",
+	"ETag",
+	"Last-Modified",
+	"Vary",
+}
+
+// flightResponse is the part of a leader's response that waiters replay.
+type flightResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// flight is one in-progress execution. resp stays nil when the leader's
+// response could not be shared, in which case waiters run independently.
+type flight struct {
+	done    chan struct{}
+	waiters int
+	resp    *flightResponse
+}
+
+// singleFlight deduplicates identical concurrent requests by key.
+type singleFlight struct {
+	wait time.Duration
+	key  ginx.CacheKeyFunc
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// SingleFlight returns a ginx middleware that collapses identical concurrent
+// GET and HEAD requests (same CacheKey, or the WithCacheKey function: method,
+// path, and query) into one handler execution. The first request runs
+// normally; the others wait for it and receive a copy of its status, body,
+// and content headers (Content-Type, Cache-Control, ETag, ...), while
+// per-request headers such as X-Request-ID stay their own.
+//
+// Only 2xx responses without Set-Cookie are shared; for anything else, or
+// when a waiter has waited longer than wait, the waiter runs the handler
+// itself. Requests with Authorization, Cookie, or Range headers bypass it,
+// matching the rules ginx.Cache uses, so user-specific responses are never
+// shared. Mount it after ginx.Cache so cache hits never reach it.
+func SingleFlight(wait time.Duration, opts ...ResponseCacheOption) ginx.Middleware {
+	return newSingleFlight(wait, opts...).middleware
+}
+
+func newSingleFlight(wait time.Duration, opts ...ResponseCacheOption) *singleFlight {
+	return &singleFlight{wait: wait, key: buildResponseCacheOptions(opts).key, flights: make(map[string]*flight)}
+}
+
+func (s *singleFlight) middleware(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !shareableRequest(c.Request) {
+			next(c)
+			return
+		}
+		key := s.key(c)
+
+		s.mu.Lock()
+		if f, ok := s.flights[key]; ok {
+			f.waiters++
+			s.mu.Unlock()
+			s.await(c, f, next)
+			return
+		}
+		f := &flight{done: make(chan struct{})}
+		s.flights[key] = f
+		s.mu.Unlock()
+
+		s.lead(c, key, f, next)
+	}
+}
+
+// lead runs the handler for f and publishes its response. The flight is
+// released even if the handler panics, so waiters fall back instead of
+// hanging.
+func (s *singleFlight) lead(c *gin.Context, key string, f *flight, next gin.HandlerFunc) {
+	w := &flightWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	defer func() {
+		c.Writer = w.ResponseWriter
+		s.mu.Lock()
+		delete(s.flights, key)
+		s.mu.Unlock()
+		close(f.done)
+	}()
+
+	next(c)
+
+	status := w.Status()
+	if status < 200 || status >= 300 || w.Header().Get("Set-Cookie") != "" {
+		return
+	}
+	header := make(http.Header, len(sharedResponseHeaders))
+	for _, name := range sharedResponseHeaders {
+		if values := w.Header().Values(name); len(values) > 0 {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+	f.resp = &flightResponse{status: status, header: header, body: w.body}
+}
+
+// await waits for the leader of f and replays its response, or runs next
+// itself when the response is not shareable or the wait times out.
+func (s *singleFlight) await(c *gin.Context, f *flight, next gin.HandlerFunc) {
+	timer := time.NewTimer(s.wait)
+	defer timer.Stop()
+
+	select {
+	case <-f.done:
+	case <-timer.C:
+		next(c)
+		return
+	case <-c.Request.Context().Done():
+		next(c)
+		return
+	}
+
+	if f.resp == nil {
+		next(c)
+		return
+	}
+	for name, values := range f.resp.header {
+		c.Writer.Header()[name] = values
+	}
+	c.Writer.WriteHeader(f.resp.status)
+	if c.Request.Method != http.MethodHead {
+		_, _ = c.Writer.Write(f.resp.body)
+	}
+	c.Abort()
+}
+
+// waiting reports how many requests are waiting on the flight for key.
+func (s *singleFlight) waiting(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.flights[key]; ok {
+		return f.waiters
+	}
+	return 0
+}
+
+// shareableRequest applies ginx.Cache's bypass rules: only anonymous,
+// non-ranged GET and HEAD requests may share a response.
+func shareableRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return r.Header.Get("Authorization") == "" && r.Header.Get("Cookie") == "" && r.Header.Get("Range") == ""
+}
+
+// flightWriter tees the leader's body so it can be replayed to waiters.
+// failed records a write error, after which body may be truncated.
+type flightWriter struct {
+	gin.ResponseWriter
+	body   []byte
+	failed bool
+}
+
+func (w *flightWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	w.body = append(w.body, data[:n]...)
+	if err != nil {
+		w.failed = true
+	}
+	return n, err
+}
+
+func (w *flightWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
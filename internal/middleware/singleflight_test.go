@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+)
+
+// setupSingleFlightRouter mounts SingleFlight behind ginx.RequestID, the way
+// app.New orders them, in front of a /items handler that counts executions
+// and blocks until release is closed.
+func setupSingleFlightRouter(t *testing.T, wait time.Duration) (*gin.Engine, *singleFlight, *int32, chan struct{}) {
+	t.Helper()
+	sf := newSingleFlight(wait)
+	release := make(chan struct{})
+	var calls int32
+
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(ginx.RequestID()).Use(sf.middleware).Build())
+	r.GET("/items", func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, gin.H{"items": []int{1, 2, 3}})
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+	return r, sf, &calls, release
+}
+
+// fireParallel sends n copies of the request built by newReq and releases the
+// handler once n-1 of them are waiting on the leader.
+func fireParallel(t *testing.T, r http.Handler, sf *singleFlight, key string, n int, release chan struct{}, newReq func() *http.Request) []*httptest.ResponseRecorder {
+	t.Helper()
+	recs := make([]*httptest.ResponseRecorder, n)
+	var wg sync.WaitGroup
+	for i := range recs {
+		recs[i] = httptest.NewRecorder()
+		wg.Add(1)
+		go func(w *httptest.ResponseRecorder) {
+			defer wg.Done()
+			r.ServeHTTP(w, newReq())
+		}(recs[i])
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sf.waiting(key) < n-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d requests joined the flight", sf.waiting(key), n-1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	return recs
+}
+
+func TestSingleFlight_CollapsesIdenticalRequests(t *testing.T) {
+	r, sf, calls, release := setupSingleFlightRouter(t, 5*time.Second)
+
+	const n = 50
+	recs := fireParallel(t, r, sf, "GET /items?page=1", n, release, func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/items?page=1", nil)
+	})
+
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("handler ran %d times, want 1", got)
+	}
+	ids := make(map[string]bool, n)
+	want := recs[0].Body.String()
+	for i, w := range recs {
+		if w.Code != http.StatusOK {
+			t.Errorf("response %d status = %d, want 200", i, w.Code)
+		}
+		if w.Body.String() != want {
+			t.Errorf("response %d body = %q, want %q", i, w.Body.String(), want)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("response %d Content-Type = %q", i, got)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "public, max-age=60" {
+			t.Errorf("response %d Cache-Control = %q", i, got)
+		}
+		ids[w.Header().Get("X-Request-ID")] = true
+	}
+	if len(ids) != n || ids[""] {
+		t.Errorf("got %d distinct X-Request-ID values, want %d (each caller keeps its own)", len(ids), n)
+	}
+}
+
+func TestSingleFlight_ErrorResponsesAreNotShared(t *testing.T) {
+	r, sf, calls, release := setupSingleFlightRouter(t, 5*time.Second)
+
+	const n = 5
+	recs := fireParallel(t, r, sf, "GET /fail", n, release, func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/fail", nil)
+	})
+
+	if got := atomic.LoadInt32(calls); got != n {
+		t.Errorf("handler ran %d times, want %d (waiters retry on error)", got, n)
+	}
+	for i, w := range recs {
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("response %d status = %d, want 500", i, w.Code)
+		}
+	}
+}
+
+func TestSingleFlight_WaitTimeoutRunsIndependently(t *testing.T) {
+	r, sf, calls, release := setupSingleFlightRouter(t, 10*time.Millisecond)
+
+	leader := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(leader, httptest.NewRequest(http.MethodGet, "/items", nil))
+	}()
+	for atomic.LoadInt32(calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The waiter gives up after 10ms and runs the handler itself, which
+	// blocks on release like the leader; release both.
+	waiter := httptest.NewRecorder()
+	waiterDone := make(chan struct{})
+	go func() {
+		defer close(waiterDone)
+		r.ServeHTTP(waiter, httptest.NewRequest(http.MethodGet, "/items", nil))
+	}()
+	for atomic.LoadInt32(calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-done
+	<-waiterDone
+
+	if waiter.Code != http.StatusOK || leader.Code != http.StatusOK {
+		t.Errorf("statuses = %d/%d, want 200/200", leader.Code, waiter.Code)
+	}
+	if sf.waiting("GET /items") != 0 {
+		t.Error("flight not released")
+	}
+}
+
+func TestSingleFlight_Bypass(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"authorization", "Authorization", "Bearer token"},
+		{"cookie", "Cookie", "session=abc"},
+		{"range", "Range", "bytes=0-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, calls, release := setupSingleFlightRouter(t, 5*time.Second)
+			close(release)
+
+			var wg sync.WaitGroup
+			for range 5 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodGet, "/items", nil)
+					req.Header.Set(tt.header, tt.value)
+					r.ServeHTTP(httptest.NewRecorder(), req)
+				}()
+			}
+			wg.Wait()
+
+			if got := atomic.LoadInt32(calls); got != 5 {
+				t.Errorf("handler ran %d times, want 5", got)
+			}
+		})
+	}
+}
+
+func TestSingleFlight_DifferentQueriesDoNotShare(t *testing.T) {
+	r, _, calls, release := setupSingleFlightRouter(t, 5*time.Second)
+	close(release)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?page=1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items?page=2", nil))
+
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("handler ran %d times, want 2", got)
+	}
+}
+
+func TestSingleFlight_LeaderPanicReleasesWaiters(t *testing.T) {
+	sf := newSingleFlight(5 * time.Second)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(ginx.Recovery()).Use(sf.middleware).Build())
+	r.GET("/panic", func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+			panic("boom")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	go r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	<-started
+
+	waiter := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(waiter, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+	for sf.waiting("GET /panic") < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiter still blocked after leader panicked")
+	}
+	if waiter.Code != http.StatusOK || waiter.Body.String() != "ok" {
+		t.Errorf("waiter = %d %q, want 200 ok from its own execution", waiter.Code, waiter.Body.String())
+	}
+}
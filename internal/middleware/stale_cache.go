@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+)
+
+// CacheStatusHeader tells clients how a cached response was produced. It
+// is only set on stale responses, as "STALE".
+const CacheStatusHeader = "X-Cache"
+
+// staleRefreshKey marks the context of a StaleCache background refresh,
+// which must run the handler rather than be answered from the cache.
+type staleRefreshKey struct{}
+
+// staleEntry is a response stored by StaleCache. It is fresh until
+// freshUntil and may be served stale until the store expires it.
+type staleEntry struct {
+	Status     int
+	Header     http.Header
+	Body       []byte
+	FreshUntil time.Time
+}
+
+// StaleCache is a response cache with stale-while-revalidate: an entry is
+// fresh for ttl and then served stale for up to staleTTL more, while one
+// background request per key re-runs the handler and replaces it. Past
+// ttl+staleTTL the store has dropped the entry and the request is a miss.
+//
+// It applies ginx.Cache's rules: requests with Authorization, Cookie or
+// Range bypass it, and only 2xx responses other than 206 without
+// Set-Cookie, Content-Range or a no-store, no-cache, private,
+// must-revalidate or max-age=0 Cache-Control are stored. Entries use the
+// same keys (CacheKey, or WithCacheKey), so CacheInvalidation purges them.
+type StaleCache struct {
+	handler  http.Handler
+	store    cache.CacheInterface
+	counters *CacheCounters
+	key      ginx.CacheKeyFunc
+	ttl      time.Duration
+	staleTTL time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	closed     bool
+	refreshing map[string]bool
+	wg         sync.WaitGroup
+}
+
+// NewStaleCache returns a StaleCache that stores entries in store and
+// records lookups in counters like CountingCache. Refreshes replay the
+// stale request against handler, normally the app's engine, so they pass
+// through the same middleware chain as the request itself, including
+// SingleFlight, which collapses a refresh with concurrent misses. Call
+// Close before the handler's resources go away.
+func NewStaleCache(handler http.Handler, store cache.CacheInterface, counters *CacheCounters, ttl, staleTTL time.Duration, opts ...ResponseCacheOption) *StaleCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StaleCache{
+		handler:    handler,
+		store:      store,
+		counters:   counters,
+		key:        buildResponseCacheOptions(opts).key,
+		ttl:        ttl,
+		staleTTL:   staleTTL,
+		ctx:        ctx,
+		cancel:     cancel,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// Middleware is the ginx middleware serving and storing the entries.
+func (s *StaleCache) Middleware(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !shareableRequest(c.Request) {
+			next(c)
+			return
+		}
+		key := s.key(c)
+
+		if c.Request.Context().Value(staleRefreshKey{}) == nil {
+			if entry, ok := cache.GetTyped[staleEntry](s.store, key); ok {
+				s.counters.hits.Add(1)
+				stale := time.Now().After(entry.FreshUntil)
+				if stale {
+					s.refresh(key, c.Request)
+				}
+				s.serve(c, entry, stale)
+				return
+			}
+			s.counters.misses.Add(1)
+		}
+
+		w := &flightWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		next(c)
+
+		// A response cut short by a canceled request, such as a refresh
+		// stopped by Close, may be incomplete.
+		if w.failed || c.Request.Context().Err() != nil || !storableResponse(w.Status(), w.Header()) {
+			return
+		}
+		header := make(http.Header, len(w.Header()))
+		for name, values := range w.Header() {
+			header[name] = append([]string(nil), values...)
+		}
+		s.store.SetWithExpiration(key, staleEntry{
+			Status:     w.Status(),
+			Header:     header,
+			Body:       w.body,
+			FreshUntil: time.Now().Add(s.ttl),
+		}, s.ttl+s.staleTTL)
+	}
+}
+
+// serve writes entry as the response, marked as stale when it is. Headers
+// earlier middleware already set, such as X-Request-ID, stay this
+// request's own.
+func (s *StaleCache) serve(c *gin.Context, entry staleEntry, stale bool) {
+	for name, values := range entry.Header {
+		if _, ok := c.Writer.Header()[name]; !ok {
+			c.Writer.Header()[name] = append([]string(nil), values...)
+		}
+	}
+	if stale {
+		c.Writer.Header().Set(CacheStatusHeader, "STALE")
+	}
+	c.Writer.WriteHeader(entry.Status)
+	if c.Request.Method != http.MethodHead {
+		if _, err := c.Writer.Write(entry.Body); err != nil {
+			_ = c.Error(err)
+		}
+	}
+	c.Abort()
+}
+
+// refresh replays r in the background to replace the entry for key, unless
+// a refresh of key is already running or the cache is closed. A refresh
+// that does not produce a storable response leaves the stale entry, and the
+// next stale hit tries again.
+func (s *StaleCache) refresh(key string, r *http.Request) {
+	s.mu.Lock()
+	if s.closed || s.refreshing[key] {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing[key] = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	// Clone now: r belongs to a request that is about to finish.
+	req := r.Clone(context.WithValue(s.ctx, staleRefreshKey{}, true))
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.refreshing, key)
+			s.mu.Unlock()
+		}()
+		s.handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+}
+
+// Close cancels the context of running refreshes and waits for them to
+// return. Stale hits after Close are served without a refresh. It is safe
+// to call on a nil StaleCache.
+func (s *StaleCache) Close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cancel()
+	s.wg.Wait()
+}
+
+// storableResponse applies ginx.Cache's rules for which responses may be
+// stored.
+func storableResponse(status int, header http.Header) bool {
+	if status < 200 || status >= 300 || status == http.StatusPartialContent {
+		return false
+	}
+	if header.Get("Set-Cookie") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	for _, directive := range strings.Split(strings.ToLower(header.Get("Cache-Control")), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.TrimSpace(name) {
+		case "no-store", "no-cache", "private", "must-revalidate":
+			return false
+		case "max-age":
+			if strings.Trim(strings.TrimSpace(value), `"`) == "0" {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
+	"github.com/simp-lee/ginx"
+)
+
+// setupStaleCacheRouter mounts a StaleCache behind ginx.RequestID in front
+// of /items, which answers with its execution count. Executions after the
+// first call hold, when it is non-nil, before answering.
+func setupStaleCacheRouter(t *testing.T, ttl, staleTTL time.Duration, hold func(c *gin.Context)) (*StaleCache, http.Handler, *atomic.Int32, *CacheCounters) {
+	t.Helper()
+	store := cache.NewCache(cache.Options{DefaultExpiration: ttl})
+	t.Cleanup(func() { store.Close() })
+	counters := &CacheCounters{}
+	var calls atomic.Int32
+
+	r := gin.New()
+	sc := NewStaleCache(r, store, counters, ttl, staleTTL)
+	t.Cleanup(sc.Close)
+	r.Use(ginx.NewChain().Use(ginx.RequestID()).Use(sc.Middleware).Build())
+	r.GET("/items", func(c *gin.Context) {
+		n := calls.Add(1)
+		if n > 1 && hold != nil {
+			hold(c)
+		}
+		c.String(http.StatusOK, strconv.Itoa(int(n)))
+	})
+	return sc, r, &calls, counters
+}
+
+func getItems(r http.Handler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+	return w
+}
+
+// waitRefreshed waits until no refresh of sc is running.
+func waitRefreshed(t *testing.T, sc *StaleCache) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sc.mu.Lock()
+		n := len(sc.refreshing)
+		sc.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("refresh did not finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStaleCache_ServesStaleAndRefreshesOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	release := make(chan struct{})
+	sc, r, calls, counters := setupStaleCacheRouter(t, 30*time.Millisecond, time.Minute, func(*gin.Context) { <-release })
+
+	if w := getItems(r); w.Body.String() != "1" || w.Header().Get(CacheStatusHeader) != "" {
+		t.Fatalf("first GET = %q (X-Cache %q), want a miss answered by the handler", w.Body.String(), w.Header().Get(CacheStatusHeader))
+	}
+	if w := getItems(r); w.Body.String() != "1" || w.Header().Get(CacheStatusHeader) != "" {
+		t.Fatalf("fresh GET = %q (X-Cache %q), want the cached body unmarked", w.Body.String(), w.Header().Get(CacheStatusHeader))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The refresh holds in the handler, so every request below sees the
+	// stale entry while it runs, and none of them waits for it.
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, 10)
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recs[i] = getItems(r)
+		}()
+	}
+	wg.Wait()
+	for _, w := range recs {
+		if w.Code != http.StatusOK || w.Body.String() != "1" || w.Header().Get(CacheStatusHeader) != "STALE" {
+			t.Errorf("stale GET = %d %q (X-Cache %q), want 200 \"1\" marked STALE", w.Code, w.Body.String(), w.Header().Get(CacheStatusHeader))
+		}
+	}
+
+	close(release)
+	waitRefreshed(t, sc)
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("handler ran %d times, want 2 (one miss, one refresh)", got)
+	}
+	if w := getItems(r); w.Body.String() != "2" || w.Header().Get(CacheStatusHeader) != "" {
+		t.Errorf("GET after refresh = %q (X-Cache %q), want the refreshed body unmarked", w.Body.String(), w.Header().Get(CacheStatusHeader))
+	}
+	if counters.Hits() != 12 || counters.Misses() != 1 {
+		t.Errorf("hits/misses = %d/%d, want 12/1; refreshes are not counted", counters.Hits(), counters.Misses())
+	}
+}
+
+func TestStaleCache_HardExpiryIsMiss(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, r, calls, counters := setupStaleCacheRouter(t, 20*time.Millisecond, 20*time.Millisecond, nil)
+
+	getItems(r)
+	time.Sleep(60 * time.Millisecond)
+
+	w := getItems(r)
+	if w.Body.String() != "2" || w.Header().Get(CacheStatusHeader) != "" {
+		t.Errorf("GET past ttl+stale_ttl = %q (X-Cache %q), want the handler's answer unmarked", w.Body.String(), w.Header().Get(CacheStatusHeader))
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler ran %d times, want 2", got)
+	}
+	if counters.Misses() != 2 {
+		t.Errorf("misses = %d, want 2", counters.Misses())
+	}
+}
+
+func TestStaleCache_CloseCancelsRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started := make(chan struct{})
+	var refreshErr atomic.Value
+	sc, r, calls, _ := setupStaleCacheRouter(t, 20*time.Millisecond, time.Minute, func(c *gin.Context) {
+		close(started)
+		<-c.Request.Context().Done()
+		refreshErr.Store(c.Request.Context().Err())
+	})
+
+	getItems(r)
+	time.Sleep(40 * time.Millisecond)
+	if w := getItems(r); w.Header().Get(CacheStatusHeader) != "STALE" {
+		t.Fatalf("GET past ttl X-Cache = %q, want STALE", w.Header().Get(CacheStatusHeader))
+	}
+	<-started
+
+	sc.Close()
+	if err, _ := refreshErr.Load().(error); err != context.Canceled {
+		t.Fatalf("refresh context error = %v, want context.Canceled once Close returns", err)
+	}
+
+	// After Close stale entries are still served, without a refresh.
+	if w := getItems(r); w.Body.String() != "1" || w.Header().Get(CacheStatusHeader) != "STALE" {
+		t.Errorf("GET after Close = %q (X-Cache %q), want the stale body", w.Body.String(), w.Header().Get(CacheStatusHeader))
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler ran %d times, want 2", got)
+	}
+}
+
+func TestStorableResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"ok", http.StatusOK, http.Header{"Cache-Control": {"public, max-age=60"}}, true},
+		{"server error", http.StatusInternalServerError, nil, false},
+		{"partial content", http.StatusPartialContent, nil, false},
+		{"set-cookie", http.StatusOK, http.Header{"Set-Cookie": {"a=b"}}, false},
+		{"content-range", http.StatusOK, http.Header{"Content-Range": {"bytes 0-1/2"}}, false},
+		{"no-store", http.StatusOK, http.Header{"Cache-Control": {"No-Store"}}, false},
+		{"private", http.StatusOK, http.Header{"Cache-Control": {"private, max-age=60"}}, false},
+		{"max-age zero", http.StatusOK, http.Header{"Cache-Control": {"max-age=0"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := storableResponse(tt.status, tt.header); got != tt.want {
+				t.Errorf("storableResponse(%d, %v) = %v, want %v", tt.status, tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// TenantResolver finds the tenant a request names; ok is false when it
+// names none.
+type TenantResolver func(c *gin.Context) (id string, ok bool)
+
+// TenantFromHeader resolves the tenant from the request header name.
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *gin.Context) (string, bool) {
+		id := strings.TrimSpace(c.GetHeader(name))
+		return id, id != ""
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the first label of a Host
+// one level below parent, e.g. "acme" from "acme.example.com" for parent
+// "example.com". The port is ignored; parent itself and deeper subdomains
+// name no tenant.
+func TenantFromSubdomain(parent string) TenantResolver {
+	suffix := "." + strings.ToLower(parent)
+	return func(c *gin.Context) (string, bool) {
+		host := c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		label, found := strings.CutSuffix(strings.ToLower(host), suffix)
+		if !found || label == "" || strings.Contains(label, ".") {
+			return "", false
+		}
+		return label, true
+	}
+}
+
+// Tenant returns a ginx middleware that records the tenant resolve finds
+// with requestctx.SetTenant, where domain.TenantScoped and
+// pkg.TenantScope confine the request's data to it. A request naming no
+// tenant, or one that does not match pkg.TenantIDPattern, gets 400 from
+// onError (pkg.JSONError when nil).
+func Tenant(resolve TenantResolver, onError func(c *gin.Context, status int, message string)) ginx.Middleware {
+	if onError == nil {
+		onError = pkg.JSONError
+	}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			id, ok := resolve(c)
+			if !ok {
+				onError(c, http.StatusBadRequest, "tenant required")
+				c.Abort()
+				return
+			}
+			if !pkg.TenantIDPattern.MatchString(id) {
+				onError(c, http.StatusBadRequest, "invalid tenant")
+				c.Abort()
+				return
+			}
+			requestctx.SetTenant(c, id)
+			next(c)
+		}
+	}
+}
+
+// TenantToken returns a ginx middleware, for after Auth, that rejects with
+// 403 from onError (pkg.JSONError when nil) a request whose JWT was not
+// issued for the tenant Tenant resolved (domain.TokenTenant), so a token
+// cannot be used against another tenant by changing the tenant header or
+// subdomain. Tokens issued without a tenant are rejected too. Requests
+// Auth did not authenticate, such as API key ones, pass.
+func TenantToken(onError func(c *gin.Context, status int, message string)) ginx.Middleware {
+	if onError == nil {
+		onError = pkg.JSONError
+	}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if _, ok := ginx.GetUserID(c); !ok {
+				next(c)
+				return
+			}
+			roles, _ := ginx.GetUserRoles(c)
+			claimed, ok := domain.TokenTenant(roles)
+			if tenant, _ := requestctx.Tenant(c); !ok || claimed != tenant {
+				onError(c, http.StatusForbidden, "token was not issued for this tenant")
+				c.Abort()
+				return
+			}
+			next(c)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// tenantRouter serves /whoami, which echoes the request's tenant.
+func tenantRouter(resolve TenantResolver) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().Use(Tenant(resolve, nil)).Build())
+	r.GET("/whoami", func(c *gin.Context) {
+		id, _ := domain.TenantFromContext(c.Request.Context())
+		c.String(http.StatusOK, id)
+	})
+	return r
+}
+
+func TestTenant_Header(t *testing.T) {
+	r := tenantRouter(TenantFromHeader("X-Tenant-ID"))
+
+	tests := []struct {
+		name   string
+		header string
+		code   int
+		body   string
+	}{
+		{"present", "acme", http.StatusOK, "acme"},
+		{"trimmed", "  acme ", http.StatusOK, "acme"},
+		{"missing", "", http.StatusBadRequest, ""},
+		{"invalid", "../acme", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.code, w.Body)
+			}
+			if tt.code == http.StatusOK && w.Body.String() != tt.body {
+				t.Errorf("tenant = %q, want %q", w.Body, tt.body)
+			}
+		})
+	}
+}
+
+func TestTenantFromSubdomain(t *testing.T) {
+	resolve := TenantFromSubdomain("Example.com")
+	for host, want := range map[string]string{
+		"acme.example.com":      "acme",
+		"ACME.example.com:8080": "acme",
+		"example.com":           "",
+		"a.b.example.com":       "",
+		"acme.example.org":      "",
+		"acmeexample.com":       "",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Host = host
+		got, ok := resolve(c)
+		if got != want || ok != (want != "") {
+			t.Errorf("TenantFromSubdomain(%q) = %q, %v; want %q", host, got, ok, want)
+		}
+	}
+}
+
+func TestTenantToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(Tenant(TenantFromHeader("X-Tenant-ID"), nil)).
+		Use(func(next gin.HandlerFunc) gin.HandlerFunc {
+			// Stands in for Auth: X-Test-Roles is the token's roles claim.
+			return func(c *gin.Context) {
+				if roles, ok := c.Request.Header["X-Test-Roles"]; ok {
+					ginx.SetUserID(c, "1")
+					ginx.SetUserRoles(c, roles)
+				}
+				next(c)
+			}
+		}).
+		Use(TenantToken(nil)).
+		Build())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	acme := domain.TokenRoles(domain.WithTenant(context.Background(), "acme"))
+	tests := []struct {
+		name  string
+		roles []string // nil: anonymous
+		code  int
+	}{
+		{"same tenant", acme, http.StatusNoContent},
+		{"other tenant", domain.TokenRoles(domain.WithTenant(context.Background(), "globex")), http.StatusForbidden},
+		{"no tenant claim", []string{"admin"}, http.StatusForbidden},
+		{"anonymous", nil, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Tenant-ID", "acme")
+			for _, role := range tt.roles {
+				req.Header.Add("X-Test-Roles", role)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// WhoamiHeader, sent as "1", asks Whoami to explain how the server sees
+// the request, e.g. curl -H "X-Debug-Whoami: 1".
+const WhoamiHeader = "X-Debug-Whoami"
+
+// Response headers set by Whoami.
+const (
+	// DebugSubjectHeader is the authenticated user ID, "apikey:<name>" for
+	// an API key, or "anonymous".
+	DebugSubjectHeader = "X-Debug-Subject"
+	// DebugRolesHeader lists the subject's RBAC roles, comma-separated, or
+	// "none". It is only set with WithWhoamiRBAC.
+	DebugRolesHeader = "X-Debug-Roles"
+	// DebugPermissionCheckHeader lists the policies guarding the route and
+	// their outcome, e.g. "users:delete=denied", or "none".
+	DebugPermissionCheckHeader = "X-Debug-Permission-Check"
+	// DebugMatchedRouteHeader is the gin route pattern, e.g.
+	// "/api/v1/users/:id", or "none" for an unmatched path.
+	DebugMatchedRouteHeader = "X-Debug-Matched-Route"
+)
+
+// IsWhoamiRequest reports whether the request asks for the Whoami headers.
+func IsWhoamiRequest(c *gin.Context) bool {
+	return c.GetHeader(WhoamiHeader) == "1"
+}
+
+// WhoamiOption configures Whoami.
+type WhoamiOption func(*whoami)
+
+// WithWhoamiRBAC reports the subject's roles from svc and evaluates the
+// policies installed for the route with it, as installed (svc wrapped by
+// WithAPIKeyScopes when API keys have scopes).
+func WithWhoamiRBAC(svc rbac.Service, policies []Policy) WhoamiOption {
+	return func(w *whoami) {
+		w.rbac = svc
+		w.policies = policies
+	}
+}
+
+// WithWhoamiGate explains only the requests allow accepts, such as those
+// of administrators in release mode. userID is empty for anonymous
+// requests.
+func WithWhoamiGate(allow func(c *gin.Context, userID string) bool) WhoamiOption {
+	return func(w *whoami) { w.allow = allow }
+}
+
+type whoami struct {
+	rbac     rbac.Service
+	policies []Policy
+	allow    func(c *gin.Context, userID string) bool
+}
+
+// Whoami returns a ginx middleware that adds the X-Debug-* headers above
+// to the response of a request IsWhoamiRequest reports, for troubleshooting
+// authentication and RBAC denials with curl. It must run before the Auth
+// middleware, which may reject the request: the headers are worked out
+// when the response is written, once authentication has run, and so are
+// on 401 and 403 responses too. Other requests are untouched.
+//
+// Header values are ASCII; other bytes are replaced by '?'.
+func Whoami(opts ...WhoamiOption) ginx.Middleware {
+	w := &whoami{}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if !IsWhoamiRequest(c) {
+				next(c)
+				return
+			}
+			ww := &whoamiWriter{ResponseWriter: c.Writer, c: c, w: w}
+			c.Writer = ww
+			next(c)
+			// A handler that writes no body leaves the header to gin,
+			// which writes it through the original writer.
+			ww.explain()
+			c.Writer = ww.ResponseWriter
+		}
+	}
+}
+
+// whoamiWriter sets the Whoami headers just before the response header is
+// written.
+type whoamiWriter struct {
+	gin.ResponseWriter
+	c    *gin.Context
+	w    *whoami
+	done bool
+}
+
+func (ww *whoamiWriter) WriteHeader(code int) {
+	ww.explain()
+	ww.ResponseWriter.WriteHeader(code)
+}
+
+func (ww *whoamiWriter) WriteHeaderNow() {
+	ww.explain()
+	ww.ResponseWriter.WriteHeaderNow()
+}
+
+func (ww *whoamiWriter) Write(b []byte) (int, error) {
+	ww.explain()
+	return ww.ResponseWriter.Write(b)
+}
+
+func (ww *whoamiWriter) WriteString(s string) (int, error) {
+	ww.explain()
+	return ww.ResponseWriter.WriteString(s)
+}
+
+// explain sets the headers, once, unless the header is already out.
+func (ww *whoamiWriter) explain() {
+	if ww.done || ww.ResponseWriter.Written() {
+		return
+	}
+	ww.done = true
+	c, w := ww.c, ww.w
+	userID, _ := requestctx.UserID(c)
+	if w.allow != nil && !w.allow(c, userID) {
+		return
+	}
+	h := ww.Header()
+	subject := userID
+	if subject == "" {
+		subject = "anonymous"
+	}
+	h.Set(DebugSubjectHeader, asciiHeaderValue(subject))
+	route := c.FullPath()
+	if route == "" {
+		route = "none"
+	}
+	h.Set(DebugMatchedRouteHeader, asciiHeaderValue(route))
+	if w.rbac != nil {
+		h.Set(DebugRolesHeader, asciiHeaderValue(w.roles(userID)))
+	}
+	h.Set(DebugPermissionCheckHeader, asciiHeaderValue(w.permissionChecks(c, userID)))
+}
+
+// roles lists the roles of userID.
+func (w *whoami) roles(userID string) string {
+	if userID == "" {
+		return "none"
+	}
+	roles, err := w.rbac.GetUserRoles(userID)
+	switch {
+	case err != nil:
+		return "error"
+	case len(roles) == 0:
+		return "none"
+	}
+	return strings.Join(roles, ",")
+}
+
+// permissionChecks evaluates the policies guarding the request like
+// ginx.RequirePermission and RequirePermissionOrSelf do.
+func (w *whoami) permissionChecks(c *gin.Context, userID string) string {
+	var checks []string
+	for _, p := range w.policies {
+		if !p.Matches(c) {
+			continue
+		}
+		if p.Resource == "" {
+			checks = append(checks, "authenticated")
+			continue
+		}
+		perm := p.Resource + ":" + p.Action
+		var outcome string
+		switch {
+		case userID == "":
+			outcome = "denied"
+		case p.AllowSelf && c.FullPath() == p.PathPrefix+"/:id" && sameUserID(userID, c.Param("id")):
+			outcome = "granted (self)"
+		default:
+			allowed, err := w.rbac.HasPermission(userID, p.Resource, p.Action)
+			switch {
+			case err != nil:
+				outcome = "error"
+			case allowed:
+				outcome = "granted"
+			default:
+				outcome = "denied"
+			}
+		}
+		checks = append(checks, perm+"="+outcome)
+	}
+	if len(checks) == 0 {
+		return "none"
+	}
+	return strings.Join(checks, ", ")
+}
+
+// asciiHeaderValue replaces the bytes of s that are not printable ASCII
+// with '?'.
+func asciiHeaderValue(s string) string {
+	b := []byte(s)
+	for i, ch := range b {
+		if ch < 0x20 || ch > 0x7e {
+			b[i] = '?'
+		}
+	}
+	return string(b)
+}
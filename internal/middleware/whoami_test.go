@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+	"github.com/simp-lee/rbac"
+)
+
+// whoamiRBAC grants nothing and knows no roles.
+type whoamiRBAC struct{ rbac.Service }
+
+func (whoamiRBAC) GetUserRoles(string) ([]string, error)              { return nil, nil }
+func (whoamiRBAC) HasPermission(string, string, string) (bool, error) { return false, nil }
+
+// whoamiRouter serves /items/:id, answering 204 without a body, behind
+// Whoami and a stand-in for Auth that trusts X-Test-User.
+func whoamiRouter(opts ...WhoamiOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ginx.NewChain().
+		Use(Whoami(opts...)).
+		Use(func(next gin.HandlerFunc) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if id := c.GetHeader("X-Test-User"); id != "" {
+					ginx.SetUserID(c, id)
+				}
+				next(c)
+			}
+		}).
+		Build())
+	r.DELETE("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+func whoamiRequest(r http.Handler, user string, trigger bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	if trigger {
+		req.Header.Set(WhoamiHeader, "1")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestWhoami_HeadersWithoutBody(t *testing.T) {
+	r := whoamiRouter(WithWhoamiRBAC(whoamiRBAC{}, []Policy{{PathPrefix: "/items", Method: http.MethodDelete, Resource: "items", Action: "delete"}}))
+
+	w := whoamiRequest(r, "rené\r\nX-Injected: 1", true)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", w.Code)
+	}
+	for name, want := range map[string]string{
+		DebugSubjectHeader:         "ren????X-Injected: 1",
+		DebugRolesHeader:           "none",
+		DebugPermissionCheckHeader: "items:delete=denied",
+		DebugMatchedRouteHeader:    "/items/:id",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if w.Header().Get("X-Injected") != "" {
+		t.Error("subject injected a header")
+	}
+
+	if w := whoamiRequest(r, "7", false); w.Header().Get(DebugSubjectHeader) != "" {
+		t.Errorf("without %s: %s = %q, want none", WhoamiHeader, DebugSubjectHeader, w.Header().Get(DebugSubjectHeader))
+	}
+}
+
+func TestWhoami_WithoutRBAC(t *testing.T) {
+	w := whoamiRequest(whoamiRouter(), "", true)
+	if got := w.Header().Get(DebugSubjectHeader); got != "anonymous" {
+		t.Errorf("%s = %q, want anonymous", DebugSubjectHeader, got)
+	}
+	if _, ok := w.Header()[DebugRolesHeader]; ok {
+		t.Errorf("%s set without RBAC", DebugRolesHeader)
+	}
+	if got := w.Header().Get(DebugPermissionCheckHeader); got != "none" {
+		t.Errorf("%s = %q, want none", DebugPermissionCheckHeader, got)
+	}
+}
+
+func TestWhoami_Gate(t *testing.T) {
+	r := whoamiRouter(WithWhoamiGate(func(_ *gin.Context, userID string) bool { return userID == "admin" }))
+	if w := whoamiRequest(r, "7", true); w.Header().Get(DebugSubjectHeader) != "" {
+		t.Errorf("gated caller: %s = %q, want none", DebugSubjectHeader, w.Header().Get(DebugSubjectHeader))
+	}
+	if w := whoamiRequest(r, "admin", true); w.Header().Get(DebugSubjectHeader) != "admin" {
+		t.Errorf("allowed caller: %s = %q, want admin", DebugSubjectHeader, w.Header().Get(DebugSubjectHeader))
+	}
+}
@@ -0,0 +1,16 @@
+package auth
+
+import "github.com/simp-lee/gobase/internal/tsgen"
+
+// APITypes lists the request and response bodies of the auth API, for
+// the generated TypeScript definitions (see app.TypeScriptDefinitions).
+func APITypes() []tsgen.Type {
+	return []tsgen.Type{
+		{Value: LoginRequest{}},
+		{Value: RegisterRequest{}},
+		{Value: TokenResponse{}},
+		{Value: RegisterResponse{}},
+		{Value: CreateInviteRequest{}},
+		{Value: InviteResponse{}},
+	}
+}
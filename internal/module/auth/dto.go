@@ -1,30 +1,54 @@
 package auth
 
-import "time"
+import "github.com/simp-lee/gobase/internal/domain"
 
 // LoginRequest represents the input for user login.
 type LoginRequest struct {
-	Email    string `json:"email" form:"email" binding:"required,email"`
+	Email    string `json:"email" form:"email" binding:"required,email,max=254"`
 	Password string `json:"password" form:"password" binding:"required,min=8"`
 }
 
-// RegisterRequest represents the input for user registration.
+// RegisterRequest represents the input for user registration. Name and
+// Email take the domain length limits; handler_test.go checks the tags.
 type RegisterRequest struct {
-	Name     string `json:"name" form:"name" binding:"required,min=1,max=100"`
-	Email    string `json:"email" form:"email" binding:"required,email"`
+	Name     string `json:"name" form:"name" binding:"required,min=2,max=100"`
+	Email    string `json:"email" form:"email" binding:"required,email,max=254"`
 	Password string `json:"password" form:"password" binding:"required,min=8,max=72"`
+	// InviteCode is required when auth.registration_mode is "invite".
+	InviteCode string `json:"invite_code" form:"invite_code" binding:"omitempty,max=100"`
 }
 
 // TokenResponse represents the authentication token returned after login or registration.
 type TokenResponse struct {
 	Token     string `json:"token"`
 	ExpiresAt int64  `json:"expires_at"`
+	// FailedAttemptsSinceLastLogin is set on login when the history is
+	// recorded, so clients can warn about failures the user did not make.
+	FailedAttemptsSinceLastLogin *domain.FailedLoginSummary `json:"failed_attempts_since_last_login,omitempty"`
+}
+
+// CreateInviteRequest represents the input for minting an invite code.
+type CreateInviteRequest struct {
+	// Email, when set, is the only address the invite registers.
+	Email string `json:"email" binding:"omitempty,email,max=254"`
+	// ExpiresInHours defaults to DefaultInviteExpiry.
+	ExpiresInHours int `json:"expires_in_hours" binding:"omitempty,min=1,max=8760"`
+}
+
+// InviteResponse represents a newly minted invite. Code is only ever
+// returned here.
+type InviteResponse struct {
+	ID        uint        `json:"id"`
+	Code      string      `json:"code"`
+	Email     string      `json:"email,omitempty"`
+	ExpiresAt domain.Time `json:"expires_at"`
+	CreatedAt domain.Time `json:"created_at"`
 }
 
 // RegisterResponse represents the public user data returned after registration.
 type RegisterResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint        `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	CreatedAt domain.Time `json:"created_at"`
 }
@@ -1,21 +1,41 @@
 package auth
 
 import (
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/simp-lee/gobase/internal/domain"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 )
 
+// opaqueRegisterMessage is the only response ConflictModeOpaque registration
+// ever returns, whether or not the email was already registered.
+const opaqueRegisterMessage = "registration received, please check your email to continue"
+
+// DefaultInviteExpiry is how long an invite is valid when the request does
+// not say.
+const DefaultInviteExpiry = 7 * 24 * time.Hour
+
 // AuthHandler handles REST API requests for authentication.
 type AuthHandler struct {
-	svc Service
+	svc          Service
+	conflictMode ConflictMode
 }
 
 // NewHandler creates a new AuthHandler with the given service.
-func NewHandler(svc Service) *AuthHandler {
-	return &AuthHandler{svc: svc}
+// conflictMode must match the mode the service was created with; any value
+// other than ConflictModeOpaque is treated as ConflictModeExplicit.
+func NewHandler(svc Service, conflictMode ConflictMode) *AuthHandler {
+	if conflictMode != ConflictModeOpaque {
+		conflictMode = ConflictModeExplicit
+	}
+	return &AuthHandler{svc: svc, conflictMode: conflictMode}
 }
 
 // Login handles POST /api/v1/auth/login.
@@ -25,7 +45,63 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	tokenResp, err := h.svc.Login(c.Request.Context(), req.Email, req.Password)
+	// ClientIP only believes X-Forwarded-For from server.trusted_proxies.
+	client := ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	tokenResp, err := h.svc.Login(c.Request.Context(), req.Email, req.Password, client)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, tokenResp)
+}
+
+// LoginHistory handles GET /api/v1/auth/login-history: a page of the
+// current user's login attempts, newest first by default.
+func (h *AuthHandler) LoginHistory(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		pkg.Error(c, domain.ErrUnauthorized)
+		return
+	}
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.svc.LoginHistory(c.Request.Context(), userID, req)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.List(c, result)
+}
+
+// currentUserID returns the authenticated user's ID set by ginx.Auth.
+func currentUserID(c *gin.Context) (uint, bool) {
+	raw, ok := requestctx.UserID(c)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// Refresh handles POST /api/v1/auth/refresh. The current token is read from
+// the "Authorization: Bearer <token>" header and exchanged for a new one.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	token, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		pkg.Error(c, domain.ErrUnauthorized)
+		return
+	}
+
+	tokenResp, err := h.svc.Refresh(c.Request.Context(), token)
 	if err != nil {
 		pkg.Error(c, err)
 		return
@@ -34,6 +110,16 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	pkg.Success(c, tokenResp)
 }
 
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header value.
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 // Register handles POST /api/v1/auth/register.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -41,13 +127,23 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.svc.Register(c.Request.Context(), req.Name, req.Email, req.Password)
+	user, err := h.svc.Register(c.Request.Context(), req.Name, req.Email, req.Password, req.InviteCode)
 	if err != nil {
 		pkg.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, pkg.Response{
+	// Opaque mode: identical body for new and existing emails, no user data.
+	if h.conflictMode == ConflictModeOpaque {
+		pkg.JSON(c, http.StatusOK, pkg.Response{
+			Code:    http.StatusOK,
+			Message: opaqueRegisterMessage,
+			Data:    nil,
+		})
+		return
+	}
+
+	pkg.JSON(c, http.StatusCreated, pkg.Response{
 		Code:    http.StatusCreated,
 		Message: "user registered successfully",
 		Data: RegisterResponse{
@@ -58,3 +154,36 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		},
 	})
 }
+
+// CreateInvite handles POST /api/v1/admin/invites.
+func (h *AuthHandler) CreateInvite(c *gin.Context) {
+	var req CreateInviteRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+	ttl := DefaultInviteExpiry
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	invite, err := h.svc.CreateInvite(c.Request.Context(), req.Email, ttl)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	// The code is a credential and stays out of the log.
+	by := requestctx.Actor(c)
+	slog.InfoContext(c.Request.Context(), "invite created",
+		slog.String("by", by),
+		slog.Uint64("id", uint64(invite.ID)),
+		slog.String("email", invite.Email),
+		slog.Time("expires_at", invite.ExpiresAt.Time),
+	)
+
+	pkg.JSON(c, http.StatusCreated, pkg.Response{
+		Code:    http.StatusCreated,
+		Message: "invite created",
+		Data:    invite,
+	})
+}
@@ -1,17 +1,23 @@
 package auth
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/simp-lee/gobase/internal/contract"
 	"github.com/simp-lee/gobase/internal/domain"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
 )
 
 // mockService implements Service for handler testing.
@@ -20,16 +26,32 @@ type mockService struct {
 	loginErr    error
 	registerRes *domain.User
 	registerErr error
+	refreshResp *TokenResponse
+	refreshErr  error
+	refreshArg  string
 }
 
-func (m *mockService) Login(_ context.Context, _, _ string) (*TokenResponse, error) {
+func (m *mockService) Login(_ context.Context, _, _ string, _ ClientInfo) (*TokenResponse, error) {
 	return m.loginResp, m.loginErr
 }
 
-func (m *mockService) Register(_ context.Context, _, _, _ string) (*domain.User, error) {
+func (m *mockService) LoginHistory(context.Context, uint, domain.PageRequest) (*domain.PageResult[domain.LoginAttempt], error) {
+	return nil, nil
+}
+
+func (m *mockService) Register(_ context.Context, _, _, _, _ string) (*domain.User, error) {
 	return m.registerRes, m.registerErr
 }
 
+func (m *mockService) CreateInvite(context.Context, string, time.Duration) (*InviteResponse, error) {
+	return nil, nil
+}
+
+func (m *mockService) Refresh(_ context.Context, token string) (*TokenResponse, error) {
+	m.refreshArg = token
+	return m.refreshResp, m.refreshErr
+}
+
 func setupAuthRouter(h *AuthHandler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -42,14 +64,11 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	svc := &mockService{
 		loginResp: &TokenResponse{Token: "tok-123", ExpiresAt: 1700000000},
 	}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	body := `{"email":"alice@example.com","password":"secret1234"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/login", body))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
@@ -82,15 +101,12 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 
 func TestAuthHandler_Login_ValidationError(t *testing.T) {
 	svc := &mockService{}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	// Missing required fields
 	body := `{"email":"","password":""}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/login", body))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
@@ -109,14 +125,11 @@ func TestAuthHandler_Login_ServiceError(t *testing.T) {
 	svc := &mockService{
 		loginErr: domain.ErrUnauthorized,
 	}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	body := `{"email":"alice@example.com","password":"wrongpassword"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/login", body))
 
 	if w.Code != http.StatusUnauthorized {
 		t.Fatalf("expected status 401, got %d", w.Code)
@@ -131,14 +144,11 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 			Email:     "alice@example.com",
 		},
 	}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", body))
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("expected status 201, got %d", w.Code)
@@ -179,15 +189,12 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 
 func TestAuthHandler_Register_ValidationError(t *testing.T) {
 	svc := &mockService{}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	// Missing required fields
 	body := `{"name":"","email":"","password":""}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", body))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
@@ -206,16 +213,192 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 	svc := &mockService{
 		registerErr: domain.NewAppError(domain.CodeAlreadyExists, "email already exists", nil),
 	}
-	h := NewHandler(svc)
+	h := NewHandler(svc, ConflictModeExplicit)
 	r := setupAuthRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", body))
 
 	if w.Code != http.StatusConflict {
 		t.Fatalf("expected status 409, got %d", w.Code)
 	}
 }
+
+func postRegister(t *testing.T, r *gin.Engine, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/auth/register", body))
+	return w
+}
+
+func TestAuthHandler_Register_ExplicitMode_DuplicateEmail(t *testing.T) {
+	svc := NewService(&fakeJWTService{}, &fakeUserRepo{createErr: domain.ErrAlreadyExists}, time.Hour)
+	r := setupAuthRouter(NewHandler(svc, ConflictModeExplicit))
+
+	w := postRegister(t, r, `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Register_OpaqueMode_IdenticalResponses(t *testing.T) {
+	body := `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`
+
+	newSvc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, WithConflictMode(ConflictModeOpaque))
+	wNew := postRegister(t, setupAuthRouter(NewHandler(newSvc, ConflictModeOpaque)), body)
+
+	existingSvc := NewService(&fakeJWTService{}, &fakeUserRepo{createErr: domain.ErrAlreadyExists}, time.Hour, WithConflictMode(ConflictModeOpaque))
+	wExisting := postRegister(t, setupAuthRouter(NewHandler(existingSvc, ConflictModeOpaque)), body)
+
+	if wNew.Code != http.StatusOK || wExisting.Code != http.StatusOK {
+		t.Fatalf("expected status 200 for both, got new=%d existing=%d", wNew.Code, wExisting.Code)
+	}
+	if !bytes.Equal(wNew.Body.Bytes(), wExisting.Body.Bytes()) {
+		t.Fatalf("opaque responses differ:\nnew:      %s\nexisting: %s", wNew.Body.String(), wExisting.Body.String())
+	}
+	if strings.Contains(wNew.Body.String(), "alice@example.com") {
+		t.Errorf("opaque response must not echo the email, got %s", wNew.Body.String())
+	}
+
+	var resp pkg.Response
+	if err := json.Unmarshal(wNew.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Message != opaqueRegisterMessage || resp.Data != nil {
+		t.Errorf("unexpected opaque response: %+v", resp)
+	}
+}
+
+func TestAuthHandler_Register_OpaqueMode_ValidationStillReported(t *testing.T) {
+	svc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, WithConflictMode(ConflictModeOpaque))
+	r := setupAuthRouter(NewHandler(svc, ConflictModeOpaque))
+
+	w := postRegister(t, r, `{"name":"","email":"","password":""}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Refresh_Success(t *testing.T) {
+	svc := &mockService{refreshResp: &TokenResponse{Token: "tok-new", ExpiresAt: 1700000000}}
+	r := setupAuthRouter(NewHandler(svc, ConflictModeExplicit))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req.Header.Set("Authorization", "Bearer tok-old")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if svc.refreshArg != "tok-old" {
+		t.Errorf("service received token %q, want %q", svc.refreshArg, "tok-old")
+	}
+	if !strings.Contains(w.Body.String(), `"token":"tok-new"`) {
+		t.Errorf("expected new token in response, got %s", w.Body.String())
+	}
+}
+
+func TestAuthHandler_Refresh_Unauthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		svcErr error
+	}{
+		{"missing header", "", nil},
+		{"wrong scheme", "Basic dXNlcjpwYXNz", nil},
+		{"empty token", "Bearer ", nil},
+		{"service rejects token", "Bearer tok-old", domain.ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{refreshErr: tt.svcErr}
+			r := setupAuthRouter(NewHandler(svc, ConflictModeExplicit))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status 401, got %d", w.Code)
+			}
+		})
+	}
+}
+
+// TestAuthHandler_Contracts pins the response shape of the auth endpoints to
+// testdata/contracts (see internal/contract).
+func TestAuthHandler_Contracts(t *testing.T) {
+	user := &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+	token := &TokenResponse{Token: "tok-123", ExpiresAt: 1700000000}
+	tests := []struct {
+		name string
+		path string
+		body string
+		auth string
+		svc  *mockService
+		mode ConflictMode
+	}{
+		{name: "auth_login", path: "/api/v1/auth/login", body: `{"email":"alice@example.com","password":"secret1234"}`, svc: &mockService{loginResp: token}},
+		{name: "auth_login_validation_error", path: "/api/v1/auth/login", body: `{"email":"","password":""}`, svc: &mockService{}},
+		{name: "auth_login_unauthorized", path: "/api/v1/auth/login", body: `{"email":"alice@example.com","password":"wrongpassword"}`, svc: &mockService{loginErr: domain.ErrUnauthorized}},
+		{name: "auth_register", path: "/api/v1/auth/register", body: `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`, svc: &mockService{registerRes: user}},
+		{name: "auth_register_validation_error", path: "/api/v1/auth/register", body: `{"name":"","email":"","password":""}`, svc: &mockService{}},
+		{name: "auth_register_conflict", path: "/api/v1/auth/register", body: `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`,
+			svc: &mockService{registerErr: domain.NewAppError(domain.CodeAlreadyExists, "email already exists", nil)}},
+		{name: "auth_register_opaque", path: "/api/v1/auth/register", body: `{"name":"Alice","email":"alice@example.com","password":"secret1234"}`,
+			svc: &mockService{registerErr: domain.NewAppError(domain.CodeAlreadyExists, "email already exists", nil)}, mode: ConflictModeOpaque},
+		{name: "auth_refresh", path: "/api/v1/auth/refresh", auth: "Bearer tok-old", svc: &mockService{refreshResp: token}},
+		{name: "auth_refresh_unauthorized", path: "/api/v1/auth/refresh", svc: &mockService{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := tt.mode
+			if mode == "" {
+				mode = ConflictModeExplicit
+			}
+			r := setupAuthRouter(NewHandler(tt.svc, mode))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			contract.Assert(t, tt.name, testutil.Serve(r, req))
+		})
+	}
+}
+
+func TestRequestBindingTags_MatchDomainLimits(t *testing.T) {
+	tests := []struct {
+		v           any
+		field, rule string
+		want        int
+	}{
+		{RegisterRequest{}, "Name", "min", domain.NameMinLen},
+		{RegisterRequest{}, "Name", "max", domain.NameMaxLen},
+		{RegisterRequest{}, "Email", "max", domain.EmailMaxLen},
+		{LoginRequest{}, "Email", "max", domain.EmailMaxLen},
+		{CreateInviteRequest{}, "Email", "max", domain.EmailMaxLen},
+	}
+	for _, tt := range tests {
+		f, ok := reflect.TypeOf(tt.v).FieldByName(tt.field)
+		if !ok {
+			t.Fatalf("%T has no field %s", tt.v, tt.field)
+		}
+		got := -1
+		for _, part := range strings.Split(f.Tag.Get("binding"), ",") {
+			if s, ok := strings.CutPrefix(part, tt.rule+"="); ok {
+				got, _ = strconv.Atoi(s)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%T.%s binding %s = %d, want %d", tt.v, tt.field, tt.rule, got, tt.want)
+		}
+	}
+}
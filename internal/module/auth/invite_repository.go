@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// inviteRepository implements domain.InviteRepository using GORM.
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository creates a new InviteRepository backed by the given GORM database.
+func NewInviteRepository(db *gorm.DB) domain.InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// Create inserts a new invite.
+func (r *inviteRepository) Create(ctx context.Context, invite *domain.Invite) error {
+	if err := r.db.WithContext(ctx).Create(invite).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// GetByCodeHash retrieves the invite whose code hashes to codeHash.
+func (r *inviteRepository) GetByCodeHash(ctx context.Context, codeHash string) (*domain.Invite, error) {
+	var invite domain.Invite
+	if err := r.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&invite).Error; err != nil {
+		return nil, mapError(err)
+	}
+	return &invite, nil
+}
+
+// MarkUsed sets used_at in a single conditional update, so of two
+// registrations racing for one invite only the first succeeds.
+func (r *inviteRepository) MarkUsed(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&domain.Invite{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return mapError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Release clears used_at.
+func (r *inviteRepository) Release(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Model(&domain.Invite{}).Where("id = ?", id).
+		Update("used_at", nil).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
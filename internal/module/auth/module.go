@@ -1,24 +1,119 @@
 package auth
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// RegistrationMode controls who may call POST /api/v1/auth/register.
+type RegistrationMode string
+
+const (
+	// RegistrationOpen lets anyone register.
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationInvite requires an invite code minted through
+	// POST /api/v1/admin/invites; the service must be created WithInvites.
+	RegistrationInvite RegistrationMode = "invite"
+	// RegistrationDisabled does not register the route at all.
+	RegistrationDisabled RegistrationMode = "disabled"
+)
+
+// invitesPath is the invite admin API.
+const invitesPath = "/api/v1/admin/invites"
 
 // AuthModule implements the app.Module interface for the auth domain.
 type AuthModule struct {
-	handler *AuthHandler
+	handler          *AuthHandler
+	registrationMode RegistrationMode
+}
+
+// ModuleOption configures optional auth module behavior.
+type ModuleOption func(*AuthModule)
+
+// WithRegistrationMode sets which registration routes are registered.
+// Unknown values are ignored and the default RegistrationOpen is kept.
+func WithRegistrationMode(mode RegistrationMode) ModuleOption {
+	return func(m *AuthModule) {
+		switch mode {
+		case RegistrationOpen, RegistrationInvite, RegistrationDisabled:
+			m.registrationMode = mode
+		}
+	}
 }
 
 // NewModule creates a new AuthModule with the given handler.
 // Panics if h is nil.
-func NewModule(h *AuthHandler) *AuthModule {
+func NewModule(h *AuthHandler, opts ...ModuleOption) *AuthModule {
 	if h == nil {
 		panic("auth.NewModule: handler must not be nil")
 	}
-	return &AuthModule{handler: h}
+	m := &AuthModule{handler: h, registrationMode: RegistrationOpen}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // RegisterRoutes registers auth API routes.
 func (m *AuthModule) RegisterRoutes(api *gin.RouterGroup, pages *gin.RouterGroup) {
 	auth := api.Group("/auth")
 	auth.POST("/login", m.handler.Login)
-	auth.POST("/register", m.handler.Register)
+	if m.registrationMode != RegistrationDisabled {
+		auth.POST("/register", m.handler.Register)
+	}
+	auth.POST("/refresh", m.handler.Refresh)
+	auth.GET("/login-history", m.handler.LoginHistory)
+	if m.registrationMode == RegistrationInvite {
+		api.POST("/admin/invites", m.handler.CreateInvite)
+	}
+}
+
+// Models returns the login history table model, and the invites table in
+// RegistrationInvite, for migration and the startup schema check.
+func (m *AuthModule) Models() []any {
+	models := []any{&domain.LoginAttempt{}}
+	if m.registrationMode == RegistrationInvite {
+		models = append(models, &domain.Invite{})
+	}
+	return models
+}
+
+// Erasables returns the user's login history, including attempts that
+// typed their email without matching the account, and in
+// RegistrationInvite the invites sent to their email, for their data
+// export and erasure.
+func (m *AuthModule) Erasables() []pkg.Erasable {
+	stores := []pkg.Erasable{
+		pkg.UserRecords[domain.LoginAttempt]("login_attempts", "user_id = ? OR email = ?", func(u *domain.User) []any { return []any{u.ID, u.Email} }),
+	}
+	if m.registrationMode == RegistrationInvite {
+		stores = append(stores, pkg.UserRecords[domain.Invite]("invites", "email = ?", func(u *domain.User) []any { return []any{u.Email} }))
+	}
+	return stores
+}
+
+// UserDependents returns the login history, which outlives the users it
+// refers to without the reference.
+func (m *AuthModule) UserDependents() []pkg.UserDependent {
+	return []pkg.UserDependent{{Name: "login_attempts", Model: &domain.LoginAttempt{}}}
+}
+
+// Policies needs no permission for token refresh or the caller's own login
+// history; login and register are public paths and skip authentication
+// altogether. Minting invites requires admin:update, on top of the
+// admin:read every /api/v1/admin route needs.
+func (m *AuthModule) Policies() []middleware.Policy {
+	policies := []middleware.Policy{
+		{PathPrefix: "/api/v1/auth", Method: http.MethodPost},
+		{PathPrefix: "/api/v1/auth/login-history", Method: http.MethodGet},
+	}
+	if m.registrationMode == RegistrationInvite {
+		policies = append(policies, middleware.Policy{PathPrefix: invitesPath, Method: http.MethodPost, Resource: "admin", Action: "update"})
+	}
+	return policies
 }
@@ -25,6 +25,8 @@ func TestAuthModuleRegisterRoutes(t *testing.T) {
 	}{
 		{http.MethodPost, "/api/auth/login"},
 		{http.MethodPost, "/api/auth/register"},
+		{http.MethodPost, "/api/auth/refresh"},
+		{http.MethodGet, "/api/auth/login-history"},
 	}
 
 	routes := r.Routes()
@@ -41,6 +43,51 @@ func TestAuthModuleRegisterRoutes(t *testing.T) {
 	}
 }
 
+func TestAuthModule_RegistrationModes(t *testing.T) {
+	tests := []struct {
+		mode         RegistrationMode
+		wantRegister bool
+		wantInvites  bool
+	}{
+		{RegistrationOpen, true, false},
+		{RegistrationInvite, true, true},
+		{RegistrationDisabled, false, false},
+		{"closed", true, false}, // unknown: the default is kept
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			mod := NewModule(&AuthHandler{}, WithRegistrationMode(tt.mode))
+			mod.RegisterRoutes(r.Group("/api/v1"), r.Group("/"))
+
+			registered := make(map[string]bool)
+			for _, ri := range r.Routes() {
+				registered[ri.Method+":"+ri.Path] = true
+			}
+			if got := registered["POST:/api/v1/auth/register"]; got != tt.wantRegister {
+				t.Errorf("register route registered = %v, want %v", got, tt.wantRegister)
+			}
+			if !registered["POST:/api/v1/auth/login"] {
+				t.Error("login route not registered")
+			}
+			if got := registered["POST:"+invitesPath]; got != tt.wantInvites {
+				t.Errorf("invites route registered = %v, want %v", got, tt.wantInvites)
+			}
+			if got := len(mod.Models()) == 2; got != tt.wantInvites {
+				t.Errorf("Models() = %d models, want the invites table %v", len(mod.Models()), tt.wantInvites)
+			}
+			guarded := false
+			for _, p := range mod.Policies() {
+				guarded = guarded || (p.PathPrefix == invitesPath && p.Resource == "admin" && p.Action == "update")
+			}
+			if guarded != tt.wantInvites {
+				t.Errorf("invites admin:update policy = %v, want %v", guarded, tt.wantInvites)
+			}
+		})
+	}
+}
+
 func TestNewModule_PanicsOnNilHandler(t *testing.T) {
 	defer func() {
 		if recover() == nil {
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordAlgorithm selects how new password hashes are computed. Stored
+// hashes of either algorithm keep verifying; they are tagged by prefix
+// ("$2a$"/"$2b$" for bcrypt, "$argon2id$" for argon2id).
+type PasswordAlgorithm string
+
+const (
+	// PasswordBcrypt hashes with bcrypt at the configured cost (default).
+	PasswordBcrypt PasswordAlgorithm = "bcrypt"
+	// PasswordArgon2id hashes with argon2id in the PHC string format, e.g.
+	// "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>".
+	PasswordArgon2id PasswordAlgorithm = "argon2id"
+)
+
+// argon2Params are the argon2id cost parameters: memory in KiB, passes and
+// lanes. New hashes use defaultArgon2Params, the OWASP minimum.
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+var defaultArgon2Params = argon2Params{memory: 19 * 1024, time: 2, threads: 1}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+	argon2Prefix  = "$argon2id$"
+)
+
+// passwordHasher hashes passwords with the configured algorithm and
+// verifies hashes of every supported algorithm.
+type passwordHasher struct {
+	algorithm  PasswordAlgorithm
+	bcryptCost int
+}
+
+// hash returns the encoded hash of password.
+func (h passwordHasher) hash(password string) (string, error) {
+	if h.algorithm == PasswordArgon2id {
+		return hashArgon2id(password, defaultArgon2Params)
+	}
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	return string(b), err
+}
+
+// verify reports whether password matches encoded and, when it does,
+// whether encoded is weaker than what hash would produce now: another
+// algorithm, a lower bcrypt cost or smaller argon2id parameters.
+func (h passwordHasher) verify(encoded, password string) (ok, rehash bool) {
+	if strings.HasPrefix(encoded, argon2Prefix) {
+		params, salt, key, err := parseArgon2id(encoded)
+		if err != nil {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false
+		}
+		want := defaultArgon2Params
+		weaker := params.memory < want.memory || params.time < want.time || params.threads < want.threads
+		return true, h.algorithm != PasswordArgon2id || weaker
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) != nil {
+		return false, false
+	}
+	if h.algorithm != PasswordBcrypt {
+		return true, true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return true, err == nil && cost < h.bcryptCost
+}
+
+// hashArgon2id hashes password with a random salt and params.
+func hashArgon2id(password string, params argon2Params) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// parseArgon2id decodes a hash written by hashArgon2id.
+func parseArgon2id(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+	if params.memory == 0 || params.time == 0 || params.threads == 0 {
+		return params, nil, nil, fmt.Errorf("malformed argon2id parameters %q", parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("malformed argon2id key")
+	}
+	return params, salt, key, nil
+}
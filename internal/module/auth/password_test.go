@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher_Verify(t *testing.T) {
+	const pw = "correct horse"
+	bcryptLow := passwordHasher{PasswordBcrypt, bcrypt.MinCost}
+	bcryptHigh := passwordHasher{PasswordBcrypt, bcrypt.MinCost + 1}
+	argon := passwordHasher{PasswordArgon2id, bcrypt.MinCost}
+
+	lowHash, err := bcryptLow.hash(pw)
+	if err != nil {
+		t.Fatalf("bcrypt hash: %v", err)
+	}
+	argonHash, err := argon.hash(pw)
+	if err != nil {
+		t.Fatalf("argon2id hash: %v", err)
+	}
+	weakArgon, err := hashArgon2id(pw, argon2Params{memory: 8 * 1024, time: 1, threads: 1})
+	if err != nil {
+		t.Fatalf("weak argon2id hash: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		hasher     passwordHasher
+		encoded    string
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"bcrypt same cost", bcryptLow, lowHash, pw, true, false},
+		{"bcrypt lower cost", bcryptHigh, lowHash, pw, true, true},
+		{"bcrypt higher cost than configured", passwordHasher{PasswordBcrypt, bcrypt.MinCost - 1}, lowHash, pw, true, false},
+		{"bcrypt wrong password", bcryptHigh, lowHash, "wrong", false, false},
+		{"bcrypt to argon2id", argon, lowHash, pw, true, true},
+		{"argon2id current", argon, argonHash, pw, true, false},
+		{"argon2id weaker params", argon, weakArgon, pw, true, true},
+		{"argon2id to bcrypt", bcryptLow, argonHash, pw, true, true},
+		{"argon2id wrong password", argon, argonHash, "wrong", false, false},
+		{"empty hash", argon, "", pw, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash := tt.hasher.verify(tt.encoded, tt.password)
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("verify() = %v, %v; want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestParseArgon2id_Malformed(t *testing.T) {
+	valid, err := hashArgon2id("pw", defaultArgon2Params)
+	if err != nil {
+		t.Fatalf("hashArgon2id: %v", err)
+	}
+	if _, _, _, err := parseArgon2id(valid); err != nil {
+		t.Fatalf("parseArgon2id(valid) error = %v", err)
+	}
+	parts := strings.Split(valid, "$")
+
+	tests := map[string]string{
+		"too few fields":  "$argon2id$v=19$m=19456,t=2,p=1$salt",
+		"wrong variant":   strings.Replace(valid, "$argon2id$", "$argon2i$", 1),
+		"unknown version": strings.Replace(valid, "v=19", "v=16", 1),
+		"zero memory":     strings.Replace(valid, "m=19456", "m=0", 1),
+		"bad params":      strings.Replace(valid, parts[3], "m=x,t=2,p=1", 1),
+		"bad salt":        strings.Replace(valid, parts[4], "!!", 1),
+		"empty key":       strings.TrimSuffix(valid, parts[5]),
+	}
+	for name, encoded := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, _, err := parseArgon2id(encoded); err == nil {
+				t.Errorf("parseArgon2id(%q) error = nil, want error", encoded)
+			}
+			if ok, _ := (passwordHasher{PasswordArgon2id, bcrypt.DefaultCost}).verify(encoded, "pw"); ok {
+				t.Errorf("verify(%q) = true, want false", encoded)
+			}
+		})
+	}
+}
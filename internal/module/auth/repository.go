@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"gorm.io/gorm"
+)
+
+// allowedSortFields are the fields the login history may be sorted by.
+var allowedSortFields = []string{"id", "created_at"}
+
+// defaultSort lists newest first; IDs are auto-increment, so unlike
+// created_at they never tie.
+const defaultSort = "id:desc"
+
+// loginAttemptRepository implements domain.LoginAttemptRepository using GORM.
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository backed by the given GORM database.
+func NewLoginAttemptRepository(db *gorm.DB) domain.LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// Create inserts a new login attempt.
+func (r *loginAttemptRepository) Create(ctx context.Context, a *domain.LoginAttempt) error {
+	if err := r.db.WithContext(ctx).Create(a).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// List returns a page of userID's login attempts, newest first by default.
+func (r *loginAttemptRepository) List(ctx context.Context, userID uint, req domain.PageRequest) (*domain.PageResult[domain.LoginAttempt], error) {
+	db := r.db.WithContext(ctx).Model(&domain.LoginAttempt{}).Where("user_id = ?", userID)
+	result, err := pkg.PaginateGORM[domain.LoginAttempt](ctx, db, req, pkg.ListOptions{
+		SortFields:  allowedSortFields,
+		DefaultSort: defaultSort,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return result, nil
+}
+
+// FailedSinceLastSuccess counts userID's failed attempts after their latest
+// successful one (all of them if there is none) and reports the latest.
+func (r *loginAttemptRepository) FailedSinceLastSuccess(ctx context.Context, userID uint) (domain.FailedLoginSummary, error) {
+	var summary domain.FailedLoginSummary
+
+	var last []domain.LoginAttempt
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND success = ?", userID, true).
+		Order("created_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return summary, mapError(err)
+	}
+	failed := r.db.WithContext(ctx).Model(&domain.LoginAttempt{}).Where("user_id = ? AND success = ?", userID, false)
+	if len(last) > 0 {
+		failed = failed.Where("created_at > ?", last[0].CreatedAt)
+	}
+	failed = failed.Session(&gorm.Session{})
+
+	if err := failed.Count(&summary.Count).Error; err != nil {
+		return summary, mapError(err)
+	}
+	if summary.Count == 0 {
+		return summary, nil
+	}
+	var latest domain.LoginAttempt
+	if err := failed.Order("created_at DESC").Order("id DESC").Take(&latest).Error; err != nil {
+		return summary, mapError(err)
+	}
+	summary.LastIP, summary.LastAt = latest.IP, &latest.CreatedAt
+	return summary, nil
+}
+
+// Prune deletes the attempts recorded before cutoff.
+func (r *loginAttemptRepository) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&domain.LoginAttempt{})
+	if result.Error != nil {
+		return 0, mapError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
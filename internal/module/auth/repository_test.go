@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// seedAttempts records one attempt per entry of success for userID, a
+// minute apart starting at start.
+func seedAttempts(t *testing.T, repo domain.LoginAttemptRepository, userID uint, start time.Time, success ...bool) {
+	t.Helper()
+	for i, ok := range success {
+		a := domain.LoginAttempt{
+			UserID:    &userID,
+			Email:     "alice@example.com",
+			IP:        "203.0.113." + strconv.Itoa(i+1),
+			Success:   ok,
+			CreatedAt: domain.NewTime(start.Add(time.Duration(i) * time.Minute)),
+		}
+		if err := repo.Create(context.Background(), &a); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+}
+
+func TestLoginAttemptFailedSinceLastSuccess(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, domain.User{}, domain.User{})
+	repo := NewLoginAttemptRepository(db)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+
+	summary, err := repo.FailedSinceLastSuccess(ctx, 1)
+	if err != nil || summary.Count != 0 || summary.LastAt != nil {
+		t.Fatalf("FailedSinceLastSuccess() without history = %+v, %v; want zero", summary, err)
+	}
+
+	// fail, success, fail, fail: only the two after the success count.
+	seedAttempts(t, repo, 1, start, false, true, false, false)
+	seedAttempts(t, repo, 2, start, false)
+	summary, err = repo.FailedSinceLastSuccess(ctx, 1)
+	if err != nil {
+		t.Fatalf("FailedSinceLastSuccess: %v", err)
+	}
+	if summary.Count != 2 || summary.LastIP != "203.0.113.4" || summary.LastAt == nil || !summary.LastAt.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("summary = %+v, want 2 failures, the last from 203.0.113.4 at %v", summary, start.Add(3*time.Minute))
+	}
+
+	// Without any success every failure counts.
+	if summary, _ := repo.FailedSinceLastSuccess(ctx, 2); summary.Count != 1 {
+		t.Errorf("user 2 summary = %+v, want 1 failure", summary)
+	}
+}
+
+func TestLoginAttemptListAndPrune(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, domain.User{}, domain.User{})
+	repo := NewLoginAttemptRepository(db)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	seedAttempts(t, repo, 1, start, false, true, true)
+	seedAttempts(t, repo, 2, start, true)
+
+	result, err := repo.List(ctx, 1, domain.PageRequest{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if result.TotalItems != 3 || len(result.Items) != 3 || result.Items[0].IP != "203.0.113.3" {
+		t.Errorf("List = %d items %+v, want user 1's 3 attempts newest first", result.TotalItems, result.Items)
+	}
+
+	removed, err := repo.Prune(ctx, start.Add(90*time.Second))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Prune removed %d, want 3 (two of user 1, one of user 2)", removed)
+	}
+	if result, _ := repo.List(ctx, 1, domain.PageRequest{Page: 1, PageSize: 10}); result.TotalItems != 1 {
+		t.Errorf("after Prune user 1 has %d attempts, want 1", result.TotalItems)
+	}
+}
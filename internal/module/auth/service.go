@@ -2,6 +2,12 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/mail"
 	"strconv"
 	"strings"
@@ -17,50 +23,282 @@ import (
 
 // Service defines the authentication operations.
 type Service interface {
-	Login(ctx context.Context, email, password string) (*TokenResponse, error)
-	Register(ctx context.Context, name, email, password string) (*domain.User, error)
+	// Login authenticates email and password. client describes the caller
+	// for the login history.
+	Login(ctx context.Context, email, password string, client ClientInfo) (*TokenResponse, error)
+	// Register creates a new user. In ConflictModeOpaque an already registered
+	// email is not reported as an error: Register returns (nil, nil) so callers
+	// cannot tell it apart from a successful registration. inviteCode is
+	// only read by a service created WithInvites, which requires it.
+	Register(ctx context.Context, name, email, password, inviteCode string) (*domain.User, error)
+	// CreateInvite mints a single-use invite code valid for ttl, bound to
+	// email unless it is empty.
+	CreateInvite(ctx context.Context, email string, ttl time.Duration) (*InviteResponse, error)
+	// Refresh exchanges a still-valid token for a new one with the same claims
+	// and a fresh expiry. The old token is revoked.
+	Refresh(ctx context.Context, token string) (*TokenResponse, error)
+	// LoginHistory returns a page of userID's recorded login attempts.
+	LoginHistory(ctx context.Context, userID uint, req domain.PageRequest) (*domain.PageResult[domain.LoginAttempt], error)
 }
 
+// ClientInfo identifies the client of a login attempt.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// ConflictMode controls how registration reports an already registered email.
+type ConflictMode string
+
+const (
+	// ConflictModeExplicit reports the conflict as ErrEmailTaken (HTTP 409).
+	ConflictModeExplicit ConflictMode = "explicit"
+	// ConflictModeOpaque hides the conflict behind a generic success response
+	// so the endpoint cannot be used to enumerate registered addresses.
+	ConflictModeOpaque ConflictMode = "opaque"
+)
+
+// ErrEmailTaken is returned by Register in ConflictModeExplicit when the email
+// is already registered. It carries CodeAlreadyExists, so domain.IsAlreadyExists
+// and pkg.Error treat it as a 409 conflict.
+var ErrEmailTaken = &domain.AppError{Code: domain.CodeAlreadyExists, Message: "email already registered"}
+
+// passwordUpgradeTimeout bounds the background write of an upgraded hash.
+const passwordUpgradeTimeout = 10 * time.Second
+
+// loginRecordTimeout bounds the background write of a login attempt.
+const loginRecordTimeout = 5 * time.Second
+
+// maxUserAgentLength is the size of domain.LoginAttempt.UserAgent.
+const maxUserAgentLength = 512
+
+// errLoginHistoryDisabled is returned by LoginHistory when the service was
+// created without WithLoginHistory.
+var errLoginHistoryDisabled = domain.NewAppError(domain.CodeNotFound, "login history is not recorded", nil)
+
+// errInvitesDisabled is returned by CreateInvite when the service was
+// created without WithInvites.
+var errInvitesDisabled = domain.NewAppError(domain.CodeNotFound, "registration is not invite-only", nil)
+
+// errInviteRequired is returned by Register WithInvites when no code is given.
+var errInviteRequired = domain.NewAppError(domain.CodeValidation, "invite_code is required", nil)
+
+// ErrInvalidInvite is returned by Register WithInvites for an unknown, used
+// or expired invite code, or one bound to another email. The cases are not
+// told apart.
+var ErrInvalidInvite = &domain.AppError{Code: domain.CodeForbidden, Message: "invalid or expired invite code"}
+
+// releaseInviteTimeout bounds giving an invite back after a failed
+// registration.
+const releaseInviteTimeout = 5 * time.Second
+
 // authService implements Service.
 type authService struct {
-	jwtSvc      jwt.Service
-	userRepo    domain.UserRepository
-	tokenExpiry time.Duration
+	jwtSvc       jwt.Service
+	userRepo     domain.UserRepository
+	tokenExpiry  time.Duration
+	conflictMode ConflictMode
+	passwords    passwordHasher
+	history      domain.LoginAttemptRepository // nil: attempts are not recorded
+	invites      domain.InviteRepository       // nil: registration is open
+}
+
+// ServiceOption configures optional auth service behavior.
+type ServiceOption func(*authService)
+
+// WithConflictMode sets how Register reports an already registered email.
+// Unknown values are ignored and the default ConflictModeExplicit is kept.
+func WithConflictMode(mode ConflictMode) ServiceOption {
+	return func(s *authService) {
+		if mode == ConflictModeExplicit || mode == ConflictModeOpaque {
+			s.conflictMode = mode
+		}
+	}
+}
+
+// WithBcryptCost sets the bcrypt cost of new password hashes. Values outside
+// bcrypt.MinCost..bcrypt.MaxCost are ignored and bcrypt.DefaultCost is kept.
+func WithBcryptCost(cost int) ServiceOption {
+	return func(s *authService) {
+		if cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+			s.passwords.bcryptCost = cost
+		}
+	}
+}
+
+// WithPasswordAlgorithm sets the algorithm of new password hashes. Unknown
+// values are ignored and the default PasswordBcrypt is kept.
+func WithPasswordAlgorithm(alg PasswordAlgorithm) ServiceOption {
+	return func(s *authService) {
+		if alg == PasswordBcrypt || alg == PasswordArgon2id {
+			s.passwords.algorithm = alg
+		}
+	}
+}
+
+// WithLoginHistory records every login attempt in repo and reports the
+// failures since the previous successful login in the login response.
+func WithLoginHistory(repo domain.LoginAttemptRepository) ServiceOption {
+	return func(s *authService) {
+		s.history = repo
+	}
+}
+
+// WithInvites makes registration invite-only: Register needs an unused,
+// unexpired invite code from repo, which it consumes on success.
+func WithInvites(repo domain.InviteRepository) ServiceOption {
+	return func(s *authService) {
+		s.invites = repo
+	}
 }
 
 // NewService creates a new auth Service.
-func NewService(jwtSvc jwt.Service, userRepo domain.UserRepository, tokenExpiry time.Duration) Service {
-	return &authService{
-		jwtSvc:      jwtSvc,
-		userRepo:    userRepo,
-		tokenExpiry: tokenExpiry,
+func NewService(jwtSvc jwt.Service, userRepo domain.UserRepository, tokenExpiry time.Duration, opts ...ServiceOption) Service {
+	s := &authService{
+		jwtSvc:       jwtSvc,
+		userRepo:     userRepo,
+		tokenExpiry:  tokenExpiry,
+		conflictMode: ConflictModeExplicit,
+		passwords:    passwordHasher{algorithm: PasswordBcrypt, bcryptCost: bcrypt.DefaultCost},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Login authenticates a user by email and password and returns a JWT token.
-func (s *authService) Login(ctx context.Context, email, password string) (*TokenResponse, error) {
+// A stored hash weaker than the configured algorithm and cost is replaced in
+// the background once the password is known to be right; see
+// upgradePasswordHash.
+//
+// With WithLoginHistory every attempt is recorded in the background, and a
+// successful login reports the failed attempts since the previous one.
+func (s *authService) Login(ctx context.Context, email, password string, client ClientInfo) (*TokenResponse, error) {
+	attempt := domain.LoginAttempt{
+		Email:     email,
+		IP:        client.IP,
+		UserAgent: truncateUTF8(client.UserAgent, maxUserAgentLength),
+		CreatedAt: domain.NewTime(time.Now()),
+	}
+
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		// Don't reveal whether the user exists — always return unauthorized.
 		if domain.IsNotFound(err) {
+			s.recordAttempt(ctx, attempt)
 			return nil, domain.ErrUnauthorized
 		}
 		return nil, err
 	}
+	attempt.UserID = &user.ID
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	ok, rehash := s.passwords.verify(user.PasswordHash, password)
+	if !ok {
+		s.recordAttempt(ctx, attempt)
 		return nil, domain.ErrUnauthorized
 	}
+	if rehash {
+		go s.upgradePasswordHash(context.WithoutCancel(ctx), user.ID, password)
+	}
 
 	token, err := s.jwtSvc.GenerateToken(
 		strconv.FormatUint(uint64(user.ID), 10),
-		nil, // roles — RBAC uses a separate service
+		domain.TokenRoles(ctx), // only the tenant — RBAC uses a separate service
 		s.tokenExpiry,
 	)
 	if err != nil {
 		return nil, domain.NewAppError(domain.CodeInternal, "failed to generate token", err)
 	}
 
+	resp, err := s.tokenResponse(token)
+	if err != nil {
+		return nil, err
+	}
+	if s.history != nil {
+		// Summarize before this success is recorded, or it would hide them.
+		summary, err := s.history.FailedSinceLastSuccess(ctx, user.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "summarize failed logins failed", slog.Uint64("user_id", uint64(user.ID)), slog.Any("error", err))
+		} else {
+			resp.FailedAttemptsSinceLastLogin = &summary
+		}
+		attempt.Success = true
+		s.recordAttempt(ctx, attempt)
+	}
+	return resp, nil
+}
+
+// LoginHistory returns a page of userID's recorded login attempts.
+func (s *authService) LoginHistory(ctx context.Context, userID uint, req domain.PageRequest) (*domain.PageResult[domain.LoginAttempt], error) {
+	if s.history == nil {
+		return nil, errLoginHistoryDisabled
+	}
+	return s.history.List(ctx, userID, req)
+}
+
+// Refresh exchanges token for a new token with the same subject and lifetime.
+// Invalid, expired, or revoked tokens are reported as domain.ErrUnauthorized.
+func (s *authService) Refresh(_ context.Context, token string) (*TokenResponse, error) {
+	if token == "" {
+		return nil, domain.ErrUnauthorized
+	}
+
+	newToken, err := s.jwtSvc.RefreshToken(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenCreation) || errors.Is(err, jwt.ErrServiceClosed) {
+			return nil, domain.NewAppError(domain.CodeInternal, "failed to refresh token", err)
+		}
+		return nil, domain.ErrUnauthorized
+	}
+
+	return s.tokenResponse(newToken)
+}
+
+// upgradePasswordHash re-hashes password with the current settings and
+// stores it for user id. It is best-effort: a failure is logged and the old
+// hash keeps working until the next login tries again.
+func (s *authService) upgradePasswordHash(ctx context.Context, id uint, password string) {
+	ctx, cancel := context.WithTimeout(ctx, passwordUpgradeTimeout)
+	defer cancel()
+
+	hash, err := s.passwords.hash(password)
+	if err == nil {
+		err = s.userRepo.UpdatePasswordHash(ctx, id, hash)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "upgrade password hash failed", slog.Uint64("user_id", uint64(id)), slog.Any("error", err))
+	}
+}
+
+// recordAttempt stores attempt in the background, so a slow or failing
+// history table never delays or fails the login. A failure is logged.
+func (s *authService) recordAttempt(ctx context.Context, attempt domain.LoginAttempt) {
+	if s.history == nil {
+		return
+	}
+	go func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, loginRecordTimeout)
+		defer cancel()
+		if err := s.history.Create(ctx, &attempt); err != nil {
+			slog.WarnContext(ctx, "record login attempt failed", slog.Bool("success", attempt.Success), slog.Any("error", err))
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// tokenResponse builds the TokenResponse for a freshly issued token.
+func (s *authService) tokenResponse(token string) (*TokenResponse, error) {
 	parsedToken, parseErr := s.jwtSvc.ParseToken(token)
 	if parseErr != nil {
 		return nil, domain.NewAppError(domain.CodeInternal, "failed to parse generated token", parseErr)
@@ -79,13 +317,19 @@ func validateRegisterInput(name, email, password string) error {
 	if nameLen == 0 {
 		return domain.NewAppError(domain.CodeValidation, "name is required", nil)
 	}
-	if nameLen > 100 {
-		return domain.NewAppError(domain.CodeValidation, "name must not exceed 100 characters", nil)
+	if nameLen < domain.NameMinLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("name must be at least %d characters", domain.NameMinLen), nil)
+	}
+	if nameLen > domain.NameMaxLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("name must not exceed %d characters", domain.NameMaxLen), nil)
 	}
 	trimmedEmail := strings.TrimSpace(email)
 	if len(trimmedEmail) == 0 {
 		return domain.NewAppError(domain.CodeValidation, "email is required", nil)
 	}
+	if utf8.RuneCountInString(trimmedEmail) > domain.EmailMaxLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("email must not exceed %d characters", domain.EmailMaxLen), nil)
+	}
 	addr, err := mail.ParseAddress(trimmedEmail)
 	if err != nil || addr.Name != "" || addr.Address != trimmedEmail {
 		return domain.NewAppError(domain.CodeValidation, "email must be a valid email address", nil)
@@ -99,28 +343,131 @@ func validateRegisterInput(name, email, password string) error {
 	return nil
 }
 
-// Register creates a new user with the given credentials.
-func (s *authService) Register(ctx context.Context, name, email, password string) (*domain.User, error) {
+// Register creates a new user with the given credentials. WithInvites the
+// invite is consumed before the user is created and given back should that
+// fail, so one code never registers two users.
+func (s *authService) Register(ctx context.Context, name, email, password, inviteCode string) (*domain.User, error) {
 	name = strings.TrimSpace(name)
 	email = strings.TrimSpace(email)
+	inviteCode = strings.TrimSpace(inviteCode)
 	if err := validateRegisterInput(name, email, password); err != nil {
 		return nil, err
 	}
+	if s.invites != nil && inviteCode == "" {
+		return nil, errInviteRequired
+	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	// Always hash before touching the repository, so new and existing emails
+	// spend the same hashing time and the conflict cannot be inferred by timing.
+	hash, err := s.passwords.hash(password)
 	if err != nil {
 		return nil, domain.NewAppError(domain.CodeInternal, "failed to hash password", err)
 	}
 
+	var invite *domain.Invite
+	if s.invites != nil {
+		invite, err = s.useInvite(ctx, inviteCode, email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	user := domain.User{
 		Name:         name,
 		Email:        email,
-		PasswordHash: string(hash),
+		PasswordHash: hash,
 	}
 
 	if err := s.userRepo.Create(ctx, &user); err != nil {
+		if invite != nil {
+			s.releaseInvite(ctx, invite.ID)
+		}
+		if domain.IsAlreadyExists(err) {
+			if s.conflictMode == ConflictModeOpaque {
+				return nil, nil
+			}
+			return nil, ErrEmailTaken
+		}
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+// useInvite marks the invite with code as used, provided it exists, has not
+// expired and is not bound to an email other than email.
+func (s *authService) useInvite(ctx context.Context, code, email string) (*domain.Invite, error) {
+	invite, err := s.invites.GetByCodeHash(ctx, hashInviteCode(code))
+	if err != nil {
+		if domain.IsNotFound(err) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if invite.UsedAt != nil || !now.Before(invite.ExpiresAt.Time) ||
+		(invite.Email != "" && !strings.EqualFold(invite.Email, email)) {
+		return nil, ErrInvalidInvite
+	}
+	if err := s.invites.MarkUsed(ctx, invite.ID, now); err != nil {
+		if domain.IsNotFound(err) {
+			return nil, ErrInvalidInvite // used meanwhile
+		}
+		return nil, err
+	}
+	return invite, nil
+}
+
+// releaseInvite gives invite id back after the user could not be created.
+// A failure is logged; the invite then stays used.
+func (s *authService) releaseInvite(ctx context.Context, id uint) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseInviteTimeout)
+	defer cancel()
+	if err := s.invites.Release(ctx, id); err != nil {
+		slog.WarnContext(ctx, "release invite failed", slog.Uint64("invite_id", uint64(id)), slog.Any("error", err))
+	}
+}
+
+// CreateInvite stores a new invite and returns it with its code, which is
+// not recoverable afterwards.
+func (s *authService) CreateInvite(ctx context.Context, email string, ttl time.Duration) (*InviteResponse, error) {
+	if s.invites == nil {
+		return nil, errInvitesDisabled
+	}
+	email = strings.TrimSpace(email)
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Name != "" || addr.Address != email {
+			return nil, domain.NewAppError(domain.CodeValidation, "email must be a valid email address", nil)
+		}
+	}
+	if ttl <= 0 {
+		return nil, domain.NewAppError(domain.CodeValidation, "invite expiry must be positive", nil)
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, domain.NewAppError(domain.CodeInternal, "failed to generate invite code", err)
+	}
+	code := hex.EncodeToString(raw)
+	invite := domain.Invite{
+		CodeHash:  hashInviteCode(code),
+		Email:     email,
+		ExpiresAt: domain.NewTime(time.Now().Add(ttl)),
+	}
+	if err := s.invites.Create(ctx, &invite); err != nil {
+		return nil, err
+	}
+	return &InviteResponse{
+		ID:        invite.ID,
+		Code:      code,
+		Email:     invite.Email,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}, nil
+}
+
+// hashInviteCode returns the stored form of an invite code.
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
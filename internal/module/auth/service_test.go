@@ -14,6 +14,8 @@ import (
 	"github.com/simp-lee/pagination"
 
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
 )
 
 // --- fakes ---
@@ -24,6 +26,10 @@ type fakeJWTService struct {
 	err         error
 	parsedToken *jwt.Token
 	parseErr    error
+
+	refreshToken string
+	refreshErr   error
+	refreshedArg string
 }
 
 func (f *fakeJWTService) GenerateToken(_ string, _ []string, _ time.Duration) (string, error) {
@@ -31,7 +37,6 @@ func (f *fakeJWTService) GenerateToken(_ string, _ []string, _ time.Duration) (s
 }
 func (f *fakeJWTService) ValidateToken(string) (*jwt.Token, error)                 { return nil, nil }
 func (f *fakeJWTService) ValidateAndParse(string) (*jwt.Token, error)              { return nil, nil }
-func (f *fakeJWTService) RefreshToken(string) (string, error)                      { return "", nil }
 func (f *fakeJWTService) RefreshTokenExtend(string, time.Duration) (string, error) { return "", nil }
 func (f *fakeJWTService) RevokeToken(string) error                                 { return nil }
 func (f *fakeJWTService) IsTokenRevoked(string) bool                               { return false }
@@ -44,6 +49,10 @@ func (f *fakeJWTService) ParseToken(string) (*jwt.Token, error) {
 	}
 	return &jwt.Token{ExpiresAt: time.Now().Add(time.Hour)}, nil
 }
+func (f *fakeJWTService) RefreshToken(token string) (string, error) {
+	f.refreshedArg = token
+	return f.refreshToken, f.refreshErr
+}
 func (f *fakeJWTService) RevokeAllUserTokens(string) error { return nil }
 func (f *fakeJWTService) Close()                           {}
 
@@ -66,6 +75,8 @@ type fakeUserRepo struct {
 	user      *domain.User
 	getErr    error
 	createErr error
+	// rehashed, when set, receives the hash of every UpdatePasswordHash call.
+	rehashed chan string
 }
 
 func (f *fakeUserRepo) Create(_ context.Context, u *domain.User) error {
@@ -85,8 +96,14 @@ func (f *fakeUserRepo) GetByID(context.Context, uint) (*domain.User, error) { re
 func (f *fakeUserRepo) List(context.Context, domain.PageRequest) (*pagination.Pagination[domain.User], error) {
 	return nil, nil
 }
-func (f *fakeUserRepo) Update(context.Context, *domain.User) error { return nil }
-func (f *fakeUserRepo) Delete(context.Context, uint) error         { return nil }
+func (f *fakeUserRepo) Update(context.Context, *domain.User) (bool, error) { return true, nil }
+func (f *fakeUserRepo) UpdatePasswordHash(_ context.Context, _ uint, hash string) error {
+	if f.rehashed != nil {
+		f.rehashed <- hash
+	}
+	return nil
+}
+func (f *fakeUserRepo) Delete(context.Context, uint) error { return nil }
 
 // --- helpers ---
 
@@ -112,7 +129,7 @@ func TestLogin_Success(t *testing.T) {
 		time.Hour,
 	)
 
-	resp, err := svc.Login(context.Background(), "alice@example.com", pw)
+	resp, err := svc.Login(context.Background(), "alice@example.com", pw, ClientInfo{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -131,7 +148,7 @@ func TestLogin_UserNotFound(t *testing.T) {
 		time.Hour,
 	)
 
-	_, err := svc.Login(context.Background(), "nobody@example.com", "password")
+	_, err := svc.Login(context.Background(), "nobody@example.com", "password", ClientInfo{})
 	if !domain.IsUnauthorized(err) {
 		t.Errorf("expected unauthorized error, got: %v", err)
 	}
@@ -147,7 +164,7 @@ func TestLogin_WrongPassword(t *testing.T) {
 		time.Hour,
 	)
 
-	_, err := svc.Login(context.Background(), "alice@example.com", "wrong")
+	_, err := svc.Login(context.Background(), "alice@example.com", "wrong", ClientInfo{})
 	if !domain.IsUnauthorized(err) {
 		t.Errorf("expected unauthorized error, got: %v", err)
 	}
@@ -164,7 +181,7 @@ func TestLogin_JWTError(t *testing.T) {
 		time.Hour,
 	)
 
-	_, err := svc.Login(context.Background(), "alice@example.com", pw)
+	_, err := svc.Login(context.Background(), "alice@example.com", pw, ClientInfo{})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -178,7 +195,7 @@ func TestLogin_GenerateTokenReceivesCorrectArgs(t *testing.T) {
 	fake := &capturingJWTService{token: "tok"}
 	svc := NewService(fake, &fakeUserRepo{user: user}, time.Hour)
 
-	_, err := svc.Login(context.Background(), "bob@example.com", pw)
+	_, err := svc.Login(context.Background(), "bob@example.com", pw, ClientInfo{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -190,6 +207,60 @@ func TestLogin_GenerateTokenReceivesCorrectArgs(t *testing.T) {
 	if fake.capturedRoles != nil {
 		t.Errorf("roles passed to GenerateToken = %v; want nil", fake.capturedRoles)
 	}
+
+	// With a tenant, the token is bound to it.
+	if _, err := svc.Login(domain.WithTenant(context.Background(), "acme"), "bob@example.com", pw, ClientInfo{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id, ok := domain.TokenTenant(fake.capturedRoles); !ok || id != "acme" {
+		t.Errorf("roles passed to GenerateToken = %v; want the acme tenant", fake.capturedRoles)
+	}
+}
+
+func TestLogin_UpgradesWeakerHash(t *testing.T) {
+	pw := "secret1234"
+	weak, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &domain.User{Email: "alice@example.com", PasswordHash: string(weak)}
+	user.ID = 7
+
+	tests := []struct {
+		name  string
+		opts  []ServiceOption
+		check func(t *testing.T, hash string)
+	}{
+		{"higher bcrypt cost", []ServiceOption{WithBcryptCost(bcrypt.MinCost + 1)}, func(t *testing.T, hash string) {
+			if cost, err := bcrypt.Cost([]byte(hash)); err != nil || cost != bcrypt.MinCost+1 {
+				t.Errorf("upgraded cost = %d, %v; want %d", cost, err, bcrypt.MinCost+1)
+			}
+		}},
+		{"argon2id", []ServiceOption{WithPasswordAlgorithm(PasswordArgon2id)}, func(t *testing.T, hash string) {
+			if !strings.HasPrefix(hash, argon2Prefix) {
+				t.Errorf("upgraded hash = %q, want argon2id", hash)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepo{user: user, rehashed: make(chan string, 1)}
+			svc := NewService(&fakeJWTService{token: "tok"}, repo, time.Hour, tt.opts...)
+
+			if _, err := svc.Login(context.Background(), user.Email, pw, ClientInfo{}); err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			select {
+			case hash := <-repo.rehashed:
+				if ok, _ := (passwordHasher{}).verify(hash, pw); !ok {
+					t.Error("upgraded hash does not verify the password")
+				}
+				tt.check(t, hash)
+			case <-time.After(5 * time.Second):
+				t.Fatal("UpdatePasswordHash was not called")
+			}
+		})
+	}
 }
 
 func TestLogin_ParseTokenError(t *testing.T) {
@@ -203,7 +274,7 @@ func TestLogin_ParseTokenError(t *testing.T) {
 		time.Hour,
 	)
 
-	_, err := svc.Login(context.Background(), "alice@example.com", pw)
+	_, err := svc.Login(context.Background(), "alice@example.com", pw, ClientInfo{})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -216,6 +287,98 @@ func TestLogin_ParseTokenError(t *testing.T) {
 	}
 }
 
+// --- Refresh tests ---
+
+func TestRefresh_Success(t *testing.T) {
+	fake := &fakeJWTService{refreshToken: "jwt-token-new"}
+	svc := NewService(fake, &fakeUserRepo{}, time.Hour)
+
+	resp, err := svc.Refresh(context.Background(), "jwt-token-old")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.refreshedArg != "jwt-token-old" {
+		t.Errorf("RefreshToken received %q; want %q", fake.refreshedArg, "jwt-token-old")
+	}
+	if resp.Token != "jwt-token-new" {
+		t.Errorf("token = %q; want %q", resp.Token, "jwt-token-new")
+	}
+	if resp.ExpiresAt == 0 {
+		t.Error("ExpiresAt should be non-zero")
+	}
+}
+
+func TestRefresh_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		err      error
+		wantCode int
+	}{
+		{"empty token", "", nil, domain.CodeUnauthorized},
+		{"invalid token", "tok", jwt.ErrInvalidToken, domain.CodeUnauthorized},
+		{"expired token", "tok", jwt.ErrExpiredToken, domain.CodeUnauthorized},
+		{"revoked token", "tok", jwt.ErrRevokedToken, domain.CodeUnauthorized},
+		{"creation failure", "tok", jwt.ErrTokenCreation, domain.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(&fakeJWTService{refreshErr: tt.err}, &fakeUserRepo{}, time.Hour)
+
+			_, err := svc.Refresh(context.Background(), tt.token)
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("expected *domain.AppError, got %T (%v)", err, err)
+			}
+			if appErr.Code != tt.wantCode {
+				t.Errorf("code = %v; want %v", appErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRefresh_TokenExpiresOnFakeClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := pkg.NewFakeClock(start)
+	jwtSvc, err := pkg.NewJWTKeyRing(
+		[]pkg.JWTKey{{ID: "k1", Secret: "abcdefghijklmnopqrstuvwxyz123456", Primary: true}},
+		jwt.WithClock(clock),
+	)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing: %v", err)
+	}
+	t.Cleanup(jwtSvc.Close)
+
+	pw := "secret1234"
+	user := &domain.User{Email: "alice@example.com", PasswordHash: hashPassword(t, pw)}
+	user.ID = 42
+	svc := NewService(jwtSvc, &fakeUserRepo{user: user}, time.Hour)
+
+	login, err := svc.Login(context.Background(), user.Email, pw, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if want := start.Add(time.Hour).Unix(); login.ExpiresAt != want {
+		t.Errorf("ExpiresAt = %d, want %d", login.ExpiresAt, want)
+	}
+
+	clock.Advance(30 * time.Minute)
+	refreshed, err := svc.Refresh(context.Background(), login.Token)
+	if err != nil {
+		t.Fatalf("Refresh() within lifetime error = %v", err)
+	}
+	if want := start.Add(90 * time.Minute).Unix(); refreshed.ExpiresAt != want {
+		t.Errorf("refreshed ExpiresAt = %d, want %d", refreshed.ExpiresAt, want)
+	}
+
+	// Past expiry plus the validation leeway the token is rejected.
+	clock.Advance(time.Hour + jwt.DefaultLeeway + time.Second)
+	if _, err := svc.Refresh(context.Background(), refreshed.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Refresh() after expiry error = %v, want ErrUnauthorized", err)
+	}
+}
+
 // --- Register tests ---
 
 func TestRegister_Success(t *testing.T) {
@@ -225,7 +388,7 @@ func TestRegister_Success(t *testing.T) {
 		time.Hour,
 	)
 
-	user, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123")
+	user, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -244,6 +407,41 @@ func TestRegister_Success(t *testing.T) {
 	}
 }
 
+func TestRegister_PasswordOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ServiceOption
+		wantCost int
+	}{
+		{"default cost", nil, bcrypt.DefaultCost},
+		{"configured cost", []ServiceOption{WithBcryptCost(bcrypt.MinCost)}, bcrypt.MinCost},
+		{"cost below range ignored", []ServiceOption{WithBcryptCost(bcrypt.MinCost - 1)}, bcrypt.DefaultCost},
+		{"cost above range ignored", []ServiceOption{WithBcryptCost(bcrypt.MaxCost + 1)}, bcrypt.DefaultCost},
+		{"unknown algorithm ignored", []ServiceOption{WithPasswordAlgorithm("md5"), WithBcryptCost(bcrypt.MinCost)}, bcrypt.MinCost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, tt.opts...)
+			user, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", "")
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			if cost, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil || cost != tt.wantCost {
+				t.Errorf("cost = %d, %v; want %d", cost, err, tt.wantCost)
+			}
+		})
+	}
+
+	svc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, WithPasswordAlgorithm(PasswordArgon2id))
+	user, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("Register() argon2id error = %v", err)
+	}
+	if !strings.HasPrefix(user.PasswordHash, argon2Prefix) {
+		t.Errorf("PasswordHash = %q, want argon2id", user.PasswordHash)
+	}
+}
+
 func TestRegister_DuplicateEmail(t *testing.T) {
 	svc := NewService(
 		&fakeJWTService{},
@@ -251,10 +449,144 @@ func TestRegister_DuplicateEmail(t *testing.T) {
 		time.Hour,
 	)
 
-	_, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123")
+	_, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", "")
 	if !domain.IsAlreadyExists(err) {
 		t.Errorf("expected already-exists error, got: %v", err)
 	}
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken, got: %v", err)
+	}
+}
+
+func TestRegister_DuplicateEmail_OpaqueMode(t *testing.T) {
+	svc := NewService(
+		&fakeJWTService{},
+		&fakeUserRepo{createErr: domain.ErrAlreadyExists},
+		time.Hour,
+		WithConflictMode(ConflictModeOpaque),
+	)
+
+	user, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", "")
+	if err != nil {
+		t.Fatalf("expected conflict to be hidden in opaque mode, got: %v", err)
+	}
+	if user != nil {
+		t.Errorf("expected nil user for hidden conflict, got %+v", user)
+	}
+}
+
+func TestRegister_OpaqueMode_PropagatesOtherErrors(t *testing.T) {
+	svc := NewService(
+		&fakeJWTService{},
+		&fakeUserRepo{createErr: domain.ErrInternal},
+		time.Hour,
+		WithConflictMode(ConflictModeOpaque),
+	)
+
+	if _, err := svc.Register(context.Background(), "Alice", "alice@example.com", "password123", ""); !domain.IsInternal(err) {
+		t.Errorf("expected internal error, got: %v", err)
+	}
+}
+
+// --- invite tests ---
+
+func TestRegister_Invites(t *testing.T) {
+	ctx := context.Background()
+	invites := NewInviteRepository(testutil.NewTestDB(t))
+	svc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, WithBcryptCost(bcrypt.MinCost), WithInvites(invites))
+
+	if _, err := svc.Register(ctx, "Alice", "alice@example.com", "password123", ""); !domain.IsValidation(err) {
+		t.Errorf("Register() without a code error = %v, want a validation error", err)
+	}
+	if _, err := svc.Register(ctx, "Alice", "alice@example.com", "password123", "not-a-code"); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register() with an unknown code error = %v, want ErrInvalidInvite", err)
+	}
+
+	invite, err := svc.CreateInvite(ctx, "", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+	if user, err := svc.Register(ctx, "Alice", "alice@example.com", "password123", invite.Code); err != nil || user == nil {
+		t.Fatalf("Register() with the invite = %+v, %v; want a user", user, err)
+	}
+	stored, err := invites.GetByCodeHash(ctx, hashInviteCode(invite.Code))
+	if err != nil || stored.UsedAt == nil {
+		t.Fatalf("invite after registering = %+v, %v; want it marked used", stored, err)
+	}
+	if _, err := svc.Register(ctx, "Bob", "bob@example.com", "password123", invite.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register() with a used code error = %v, want ErrInvalidInvite", err)
+	}
+
+	expired := domain.Invite{CodeHash: hashInviteCode("expired-code"), ExpiresAt: domain.NewTime(time.Now().Add(-time.Minute))}
+	if err := invites.Create(ctx, &expired); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Register(ctx, "Bob", "bob@example.com", "password123", "expired-code"); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register() with an expired code error = %v, want ErrInvalidInvite", err)
+	}
+
+	bound, err := svc.CreateInvite(ctx, "carol@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+	if _, err := svc.Register(ctx, "Bob", "bob@example.com", "password123", bound.Code); !errors.Is(err, ErrInvalidInvite) {
+		t.Errorf("Register() with another email's code error = %v, want ErrInvalidInvite", err)
+	}
+	if _, err := svc.Register(ctx, "Carol", "Carol@Example.com", "password123", bound.Code); err != nil {
+		t.Errorf("Register() with the bound email error = %v", err)
+	}
+}
+
+func TestRegister_InviteReleasedOnFailure(t *testing.T) {
+	ctx := context.Background()
+	invites := NewInviteRepository(testutil.NewTestDB(t))
+	users := &fakeUserRepo{createErr: domain.ErrAlreadyExists}
+	svc := NewService(&fakeJWTService{}, users, time.Hour, WithBcryptCost(bcrypt.MinCost), WithInvites(invites))
+
+	invite, err := svc.CreateInvite(ctx, "", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+	if _, err := svc.Register(ctx, "Alice", "alice@example.com", "password123", invite.Code); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("Register() error = %v, want ErrEmailTaken", err)
+	}
+	users.createErr = nil
+	if _, err := svc.Register(ctx, "Alice", "alice2@example.com", "password123", invite.Code); err != nil {
+		t.Errorf("Register() with the released invite error = %v", err)
+	}
+}
+
+func TestCreateInvite(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour).CreateInvite(ctx, "", time.Hour); !domain.IsNotFound(err) {
+		t.Errorf("CreateInvite() without WithInvites error = %v, want not found", err)
+	}
+
+	invites := NewInviteRepository(testutil.NewTestDB(t))
+	svc := NewService(&fakeJWTService{}, &fakeUserRepo{}, time.Hour, WithInvites(invites))
+	if _, err := svc.CreateInvite(ctx, "not-an-email", time.Hour); !domain.IsValidation(err) {
+		t.Errorf("CreateInvite() with a bad email error = %v, want a validation error", err)
+	}
+
+	before := time.Now()
+	a, err := svc.CreateInvite(ctx, "alice@example.com", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+	b, err := svc.CreateInvite(ctx, "", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite() error = %v", err)
+	}
+	if len(a.Code) != 32 || a.Code == b.Code {
+		t.Errorf("codes = %q, %q; want two distinct 32-digit codes", a.Code, b.Code)
+	}
+	if a.Email != "alice@example.com" || a.ExpiresAt.Before(before.Add(24*time.Hour)) {
+		t.Errorf("invite = %+v, want bound to alice and valid for 24h", a)
+	}
+	stored, err := invites.GetByCodeHash(ctx, hashInviteCode(a.Code))
+	if err != nil || stored.ID != a.ID || stored.CodeHash == a.Code {
+		t.Errorf("stored invite = %+v, %v; want it found by the code's hash", stored, err)
+	}
 }
 
 // --- validateRegisterInput tests ---
@@ -279,6 +611,9 @@ func TestValidateRegisterInput(t *testing.T) {
 		{"password exactly 72 chars", "Alice", "alice@example.com", strings.Repeat("A", 72), false},
 		{"name exceeds 100 characters", strings.Repeat("A", 101), "alice@example.com", "password123", true},
 		{"name exactly 100 characters", strings.Repeat("A", 100), "alice@example.com", "password123", false},
+		{"name under 2 characters", "A", "alice@example.com", "password123", true},
+		{"email exceeds 254 characters", "Alice", testutil.EmailOfLength(255), "password123", true},
+		{"email exactly 254 characters", "Alice", testutil.EmailOfLength(254), "password123", false},
 		{"display-name format rejected", "Alice", "Alice <alice@example.com>", "password123", true},
 		{"angle-bracket format rejected", "Alice", "<alice@example.com>", "password123", true},
 	}
@@ -0,0 +1,59 @@
+package changefeed
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// maxEntityTypeLength matches the entity_type column size.
+const maxEntityTypeLength = 64
+
+// ChangeHandler handles REST API requests for the change feed.
+type ChangeHandler struct {
+	svc domain.ChangeService
+}
+
+// NewChangeHandler creates a new ChangeHandler with the given service.
+func NewChangeHandler(svc domain.ChangeService) *ChangeHandler {
+	return &ChangeHandler{svc: svc}
+}
+
+// List handles GET /api/v1/changes?since=<id>&entity_type=<table>&limit=<n>.
+// since defaults to 0, the start of the feed; clients pass the next_cursor
+// of the previous page to resume.
+func (h *ChangeHandler) List(c *gin.Context) {
+	var q domain.ChangeQuery
+	if raw := c.Query("since"); raw != "" {
+		since, err := strconv.ParseUint(raw, 10, 0)
+		if err != nil {
+			pkg.ParamError(c, "since", "Must be a non-negative integer")
+			return
+		}
+		q.Since = uint(since)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			pkg.ParamError(c, "limit", "Must be a positive integer")
+			return
+		}
+		q.Limit = limit
+	}
+	q.EntityType = c.Query("entity_type")
+	if len(q.EntityType) > maxEntityTypeLength {
+		pkg.ParamError(c, "entity_type", "Must be at most 64 characters")
+		return
+	}
+
+	page, err := h.svc.Since(c.Request.Context(), q)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, page)
+}
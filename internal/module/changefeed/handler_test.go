@@ -0,0 +1,69 @@
+package changefeed
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// setupAPIRouter wires the real change feed stack on a test database.
+func setupAPIRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := testutil.NewTestDB(t)
+	r := gin.New()
+	NewModule(NewChangeHandler(NewChangeService(NewChangeRepository(db)))).RegisterRoutes(r.Group("/api"), nil)
+	return r, db
+}
+
+func TestChangeHandler_List(t *testing.T) {
+	r, db := setupAPIRouter(t)
+	ctx := t.Context()
+	recordChanges(t, ctx, db, "users", 1, domain.ChangeCreate)
+	recordChanges(t, ctx, db, "notes", 1, domain.ChangeCreate)
+	recordChanges(t, ctx, db, "users", 1, domain.ChangeDelete)
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/changes?since=1&entity_type=users&limit=1", ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Items []struct {
+				ID         uint   `json:"id"`
+				EntityType string `json:"entity_type"`
+				EntityID   string `json:"entity_id"`
+				Op         string `json:"op"`
+				ChangedAt  string `json:"changed_at"`
+			} `json:"items"`
+			NextCursor uint `json:"next_cursor"`
+			HasMore    bool `json:"has_more"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	items := resp.Data.Items
+	if len(items) != 1 || items[0].ID != 3 || items[0].EntityID != "1" || items[0].Op != domain.ChangeDelete || items[0].ChangedAt == "" {
+		t.Fatalf("items = %+v, want the delete of user 1", items)
+	}
+	if resp.Data.NextCursor != 3 || resp.Data.HasMore {
+		t.Errorf("next_cursor = %d, has_more = %v; want 3, false", resp.Data.NextCursor, resp.Data.HasMore)
+	}
+}
+
+func TestChangeHandler_InvalidParams(t *testing.T) {
+	r, _ := setupAPIRouter(t)
+	for _, query := range []string{"since=-1", "since=abc", "limit=0", "limit=x"} {
+		w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/changes?"+query, ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET ?%s status = %d, want 400; body = %s", query, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package changefeed
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// ChangeModule implements the app.Module interface for the change feed,
+// which sync clients poll for what was created, updated or deleted since
+// their last cursor instead of re-fetching whole lists.
+type ChangeModule struct {
+	handler *ChangeHandler
+}
+
+// NewModule creates a new ChangeModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *ChangeHandler) *ChangeModule {
+	if h == nil {
+		panic("changefeed.NewModule: handler must not be nil")
+	}
+	return &ChangeModule{handler: h}
+}
+
+// RegisterRoutes registers the change feed API route.
+func (m *ChangeModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.GET("/changes", m.handler.List)
+}
+
+// Models returns the changes table model for migration and the startup
+// schema check.
+func (m *ChangeModule) Models() []any {
+	return []any{&domain.Change{}}
+}
+
+// Policies requires the changes read permission: the feed reveals every
+// mutation of the entities it covers.
+func (m *ChangeModule) Policies() []middleware.Policy {
+	return []middleware.Policy{
+		{PathPrefix: "/api/v1/changes", Method: http.MethodGet, Resource: "changes", Action: "read"},
+	}
+}
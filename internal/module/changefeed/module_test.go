@@ -0,0 +1,29 @@
+package changefeed
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChangeModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&ChangeHandler{}).RegisterRoutes(r.Group("/api"), r.Group("/"))
+
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Method != http.MethodGet || routes[0].Path != "/api/changes" {
+		t.Errorf("routes = %+v, want only GET /api/changes", routes)
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
@@ -0,0 +1,67 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/blob"
+	"gorm.io/gorm"
+)
+
+// changeRepository implements domain.ChangeRepository using GORM.
+type changeRepository struct {
+	db *gorm.DB
+}
+
+// NewChangeRepository creates a new ChangeRepository backed by the given GORM database.
+func NewChangeRepository(db *gorm.DB) domain.ChangeRepository {
+	return &changeRepository{db: db}
+}
+
+// List returns up to q.Limit entries after q.Since in feed order, of the
+// request's tenant when tenancy is on, with their payloads decoded.
+func (r *changeRepository) List(ctx context.Context, q domain.ChangeQuery) ([]domain.Change, error) {
+	db := r.db.WithContext(ctx).Model(&domain.Change{}).Scopes(pkg.TenantScope(ctx)).Where("id > ?", q.Since)
+	if q.EntityType != "" {
+		db = db.Where("entity_type = ?", q.EntityType)
+	}
+	var changes []domain.Change
+	if err := db.Order("id").Limit(q.Limit).Find(&changes).Error; err != nil {
+		return nil, mapError(err)
+	}
+	for i := range changes {
+		payload, err := blob.Decode(changes[i].PayloadData, changes[i].PayloadCompressed)
+		if err != nil {
+			return nil, domain.NewAppError(domain.CodeInternal, "corrupt change payload", err)
+		}
+		changes[i].Payload = payload
+	}
+	return changes, nil
+}
+
+// Prune deletes the entries recorded before cutoff and returns how many
+// were removed.
+func (r *changeRepository) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("changed_at < ?", cutoff).Delete(&domain.Change{})
+	if result.Error != nil {
+		return 0, mapError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
@@ -0,0 +1,139 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// recordChanges appends one change per op for entity id of entityType.
+func recordChanges(t *testing.T, ctx context.Context, db *gorm.DB, entityType string, id uint, ops ...string) {
+	t.Helper()
+	for _, op := range ops {
+		if err := pkg.RecordChange(db.WithContext(ctx), entityType, id, op); err != nil {
+			t.Fatalf("RecordChange: %v", err)
+		}
+	}
+}
+
+func TestChangeList_SinceAndEntityType(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewChangeRepository(db)
+	ctx := context.Background()
+	recordChanges(t, ctx, db, "users", 1, domain.ChangeCreate, domain.ChangeUpdate)
+	recordChanges(t, ctx, db, "notes", 1, domain.ChangeCreate)
+	recordChanges(t, ctx, db, "users", 1, domain.ChangeDelete)
+
+	all, err := repo.List(ctx, domain.ChangeQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("List = %d changes, want 4", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].ID <= all[i-1].ID {
+			t.Errorf("List not in ID order: %+v", all)
+		}
+	}
+
+	users, err := repo.List(ctx, domain.ChangeQuery{Since: all[0].ID, EntityType: "users", Limit: 10})
+	if err != nil {
+		t.Fatalf("List(users): %v", err)
+	}
+	if len(users) != 2 || users[0].Op != domain.ChangeUpdate || users[1].Op != domain.ChangeDelete {
+		t.Errorf("List(users since first) = %+v, want update, delete", users)
+	}
+}
+
+func TestChangeList_ScopedToTenant(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewChangeRepository(db)
+	acme := domain.WithTenant(context.Background(), "acme")
+	recordChanges(t, acme, db, "users", 1, domain.ChangeCreate)
+	recordChanges(t, domain.WithTenant(context.Background(), "globex"), db, "users", 2, domain.ChangeCreate)
+
+	got, err := repo.List(acme, domain.ChangeQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].EntityID != "1" {
+		t.Errorf("List(acme) = %+v, want only acme's change", got)
+	}
+}
+
+func TestChangePrune(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewChangeRepository(db)
+	ctx := context.Background()
+	recordChanges(t, ctx, db, "users", 1, domain.ChangeCreate, domain.ChangeUpdate, domain.ChangeDelete)
+
+	now := time.Now()
+	if err := db.Model(&domain.Change{}).Where("op <> ?", domain.ChangeDelete).
+		Update("changed_at", now.Add(-48*time.Hour)).Error; err != nil {
+		t.Fatalf("age changes: %v", err)
+	}
+
+	removed, err := repo.Prune(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune removed %d, want 2", removed)
+	}
+	left, err := repo.List(ctx, domain.ChangeQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(left) != 1 || left[0].Op != domain.ChangeDelete {
+		t.Errorf("left = %+v, want only the recent delete", left)
+	}
+}
+
+func TestChangeList_DecodesPayloads(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewChangeRepository(db)
+	ctx := context.Background()
+
+	big := map[string]string{"bio": strings.Repeat("lorem ipsum ", 500)}
+	if err := pkg.RecordChangePayload(db, "users", 1, domain.ChangeUpdate, big, 0); err != nil {
+		t.Fatalf("RecordChangePayload: %v", err)
+	}
+	// An entry written before payloads were compressed: plain JSON, no flag.
+	legacy := &domain.Change{EntityType: "users", EntityID: "2", Op: domain.ChangeUpdate, PayloadData: []byte(`{"fields":["name"]}`)}
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatalf("create legacy change: %v", err)
+	}
+	recordChanges(t, ctx, db, "users", 3, domain.ChangeDelete)
+
+	changes, err := repo.List(ctx, domain.ChangeQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("List = %d changes, want 3", len(changes))
+	}
+	var got map[string]string
+	if err := json.Unmarshal(changes[0].Payload, &got); err != nil || got["bio"] != big["bio"] {
+		t.Errorf("compressed payload = %.60s, %v; want the bio back", changes[0].Payload, err)
+	}
+	if string(changes[1].Payload) != `{"fields":["name"]}` {
+		t.Errorf("legacy payload = %s, want it as stored", changes[1].Payload)
+	}
+	if changes[2].Payload != nil {
+		t.Errorf("payload of an entry without one = %s, want none", changes[2].Payload)
+	}
+
+	body, _ := json.Marshal(changes[2])
+	if strings.Contains(string(body), "payload") {
+		t.Errorf("JSON of an entry without a payload = %s, want no payload fields", body)
+	}
+}
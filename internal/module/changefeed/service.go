@@ -0,0 +1,56 @@
+package changefeed
+
+import (
+	"context"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Page sizes of the change feed.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 500
+)
+
+// changeService implements domain.ChangeService.
+type changeService struct {
+	repo domain.ChangeRepository
+}
+
+// NewChangeService creates a new ChangeService with the given repository.
+func NewChangeService(repo domain.ChangeRepository) domain.ChangeService {
+	return &changeService{repo: repo}
+}
+
+// Since returns the page of entries after q.Since. A Limit of 0 means
+// DefaultLimit; larger ones are capped at MaxLimit.
+func (s *changeService) Since(ctx context.Context, q domain.ChangeQuery) (*domain.ChangePage, error) {
+	switch {
+	case q.Limit < 0:
+		return nil, domain.NewAppError(domain.CodeValidation, "limit must not be negative", nil)
+	case q.Limit == 0:
+		q.Limit = DefaultLimit
+	case q.Limit > MaxLimit:
+		q.Limit = MaxLimit
+	}
+
+	// One extra entry tells whether another page is already waiting.
+	limit := q.Limit
+	q.Limit++
+	items, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.ChangePage{Items: items, NextCursor: q.Since}
+	if len(items) > limit {
+		page.Items, page.HasMore = items[:limit], true
+	}
+	if page.Items == nil {
+		page.Items = []domain.Change{}
+	}
+	if n := len(page.Items); n > 0 {
+		page.NextCursor = page.Items[n-1].ID
+	}
+	return page, nil
+}
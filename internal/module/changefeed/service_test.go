@@ -0,0 +1,87 @@
+package changefeed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestChangeService_CursorResumption(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	svc := NewChangeService(NewChangeRepository(db))
+	ctx := context.Background()
+	for id := uint(1); id <= 5; id++ {
+		recordChanges(t, ctx, db, "users", id, domain.ChangeCreate)
+	}
+
+	var seen []string
+	cursor := uint(0)
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("cursor did not advance")
+		}
+		page, err := svc.Since(ctx, domain.ChangeQuery{Since: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("Since(%d): %v", cursor, err)
+		}
+		for _, c := range page.Items {
+			seen = append(seen, c.EntityID)
+		}
+		cursor = page.NextCursor
+		if !page.HasMore {
+			break
+		}
+	}
+	if got := len(seen); got != 5 || seen[0] != "1" || seen[4] != "5" {
+		t.Errorf("seen = %v, want 1..5 once each", seen)
+	}
+
+	// Polling at the end returns nothing and keeps the cursor, until the next
+	// change arrives.
+	page, err := svc.Since(ctx, domain.ChangeQuery{Since: cursor})
+	if err != nil {
+		t.Fatalf("Since(end): %v", err)
+	}
+	if len(page.Items) != 0 || page.HasMore || page.NextCursor != cursor {
+		t.Errorf("Since(end) = %+v, want an empty page at cursor %d", page, cursor)
+	}
+	recordChanges(t, ctx, db, "users", 3, domain.ChangeDelete)
+	page, err = svc.Since(ctx, domain.ChangeQuery{Since: cursor})
+	if err != nil {
+		t.Fatalf("Since(after delete): %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].EntityID != "3" || page.Items[0].Op != domain.ChangeDelete {
+		t.Errorf("Since(after delete) = %+v, want the delete of user 3", page.Items)
+	}
+}
+
+func TestChangeService_LimitDefaultsAndCap(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	svc := NewChangeService(NewChangeRepository(db))
+	ctx := context.Background()
+	for id := uint(1); id <= MaxLimit+1; id++ {
+		recordChanges(t, ctx, db, "users", id, domain.ChangeCreate)
+	}
+
+	page, err := svc.Since(ctx, domain.ChangeQuery{})
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(page.Items) != DefaultLimit || !page.HasMore {
+		t.Errorf("default page = %d items, has_more %v; want %d, true", len(page.Items), page.HasMore, DefaultLimit)
+	}
+
+	page, err = svc.Since(ctx, domain.ChangeQuery{Limit: 10 * MaxLimit})
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(page.Items) != MaxLimit || !page.HasMore {
+		t.Errorf("capped page = %d items, has_more %v; want %d, true", len(page.Items), page.HasMore, MaxLimit)
+	}
+
+	if _, err := svc.Since(ctx, domain.ChangeQuery{Limit: -1}); !domain.IsValidation(err) {
+		t.Errorf("Since(limit -1) error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,13 @@
+package note
+
+// CreateNoteRequest represents the input for creating a new note.
+type CreateNoteRequest struct {
+	Title string `json:"title" form:"title" binding:"required,max=200"`
+	Body  string `json:"body" form:"body" binding:"max=10000"`
+}
+
+// UpdateNoteRequest represents the input for updating an existing note.
+type UpdateNoteRequest struct {
+	Title string `json:"title" form:"title" binding:"required,max=200"`
+	Body  string `json:"body" form:"body" binding:"max=10000"`
+}
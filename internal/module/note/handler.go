@@ -0,0 +1,112 @@
+package note
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// NoteHandler handles REST API requests for the note resource.
+type NoteHandler struct {
+	svc domain.NoteService
+}
+
+// NewNoteHandler creates a new NoteHandler with the given service.
+func NewNoteHandler(svc domain.NoteService) *NoteHandler {
+	return &NoteHandler{svc: svc}
+}
+
+// Create handles POST /api/v1/notes.
+func (h *NoteHandler) Create(c *gin.Context) {
+	var req CreateNoteRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	note, err := h.svc.CreateNote(c.Request.Context(), req.Title, req.Body)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.JSON(c, http.StatusCreated, pkg.Response{
+		Code:    http.StatusCreated,
+		Message: "success",
+		Data:    note,
+	})
+}
+
+// Get handles GET /api/v1/notes/:id.
+func (h *NoteHandler) Get(c *gin.Context) {
+	id, err := pkg.ParseUUIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidUUIDMessage)
+		return
+	}
+
+	note, err := h.svc.GetNote(c.Request.Context(), id)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, note)
+}
+
+// List handles GET /api/v1/notes.
+func (h *NoteHandler) List(c *gin.Context) {
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.svc.ListNotes(c.Request.Context(), req)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.List(c, result)
+}
+
+// Update handles PUT /api/v1/notes/:id.
+func (h *NoteHandler) Update(c *gin.Context) {
+	id, err := pkg.ParseUUIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidUUIDMessage)
+		return
+	}
+
+	var req UpdateNoteRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	note, err := h.svc.UpdateNote(c.Request.Context(), id, req.Title, req.Body)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, note)
+}
+
+// Delete handles DELETE /api/v1/notes/:id.
+func (h *NoteHandler) Delete(c *gin.Context) {
+	id, err := pkg.ParseUUIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidUUIDMessage)
+		return
+	}
+
+	if err := h.svc.DeleteNote(c.Request.Context(), id); err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, nil)
+}
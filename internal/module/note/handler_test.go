@@ -0,0 +1,111 @@
+package note
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// setupAPIRouter wires the real note stack on a test database.
+func setupAPIRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewNoteHandler(NewNoteService(NewNoteRepository(testutil.NewTestDB(t))))
+	NewModule(h).RegisterRoutes(r.Group("/api"), nil)
+	return r
+}
+
+// noteResponse mirrors pkg.Response with a typed Note payload.
+type noteResponse struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    domain.Note `json:"data"`
+}
+
+func decodeNote(t *testing.T, body []byte) noteResponse {
+	t.Helper()
+	var resp noteResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp
+}
+
+func TestNoteHandler_CRUD(t *testing.T) {
+	r := setupAPIRouter(t)
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/notes", `{"title":"Hello","body":"World"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, body = %s", w.Code, w.Body.String())
+	}
+	created := decodeNote(t, w.Body.Bytes()).Data
+	if len(created.ID) != 36 {
+		t.Fatalf("created ID = %q, want a UUID string", created.ID)
+	}
+
+	// Lookups accept any letter case and normalize it.
+	w = testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/notes/"+strings.ToUpper(created.ID), ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("get status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := decodeNote(t, w.Body.Bytes()).Data; got.ID != created.ID || got.Title != "Hello" {
+		t.Errorf("get = %+v, want ID %s and title Hello", got, created.ID)
+	}
+
+	w = testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPut, "/api/notes/"+created.ID, `{"title":"Updated"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := decodeNote(t, w.Body.Bytes()).Data; got.Title != "Updated" || got.Body != "" {
+		t.Errorf("update = %+v, want title Updated and empty body", got)
+	}
+
+	w = testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodDelete, "/api/notes/"+created.ID, ""))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body = %s", w.Code, w.Body.String())
+	}
+	w = testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/notes/"+created.ID, ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get after delete status = %d, want 404", w.Code)
+	}
+}
+
+func TestNoteHandler_InvalidUUID(t *testing.T) {
+	r := setupAPIRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"numeric id", http.MethodGet, "/api/notes/1"},
+		{"not hex", http.MethodGet, "/api/notes/zzzzzzzz-zzzz-zzzz-zzzz-zzzzzzzzzzzz"},
+		{"braced", http.MethodGet, "/api/notes/{7d444840-9dc0-11d1-b245-5ffdce74fad2}"},
+		{"update", http.MethodPut, "/api/notes/abc"},
+		{"delete", http.MethodDelete, "/api/notes/abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Serve(r, testutil.NewJSONRequest(t, tt.method, tt.path, `{"title":"x"}`))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400; body = %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNoteHandler_CreateValidation(t *testing.T) {
+	r := setupAPIRouter(t)
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/notes", `{"title":""}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
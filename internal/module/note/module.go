@@ -0,0 +1,44 @@
+package note
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// NoteModule implements the app.Module interface for the note domain. It is
+// the UUID primary key counterpart of the user module and has no pages.
+type NoteModule struct {
+	handler *NoteHandler
+}
+
+// NewModule creates a new NoteModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *NoteHandler) *NoteModule {
+	if h == nil {
+		panic("note.NewModule: handler must not be nil")
+	}
+	return &NoteModule{handler: h}
+}
+
+// RegisterRoutes registers note API routes.
+func (m *NoteModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.POST("/notes", m.handler.Create)
+	api.GET("/notes/:id", m.handler.Get)
+	api.GET("/notes", m.handler.List)
+	api.PUT("/notes/:id", m.handler.Update)
+	api.DELETE("/notes/:id", m.handler.Delete)
+}
+
+// Models returns the notes table model for migration and the startup
+// schema check.
+func (m *NoteModule) Models() []any {
+	return []any{&domain.Note{}}
+}
+
+// Policies leaves notes open to every authenticated caller; they carry no
+// owner to check.
+func (m *NoteModule) Policies() []middleware.Policy {
+	return []middleware.Policy{{PathPrefix: "/api/v1/notes"}}
+}
@@ -0,0 +1,41 @@
+package note
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNoteModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&NoteHandler{}).RegisterRoutes(r.Group("/api"), r.Group("/"))
+
+	expected := map[string]bool{
+		http.MethodPost + " /api/notes":       true,
+		http.MethodGet + " /api/notes/:id":    true,
+		http.MethodGet + " /api/notes":        true,
+		http.MethodPut + " /api/notes/:id":    true,
+		http.MethodDelete + " /api/notes/:id": true,
+	}
+	routes := r.Routes()
+	if len(routes) != len(expected) {
+		t.Errorf("registered %d routes, want %d (no page routes)", len(routes), len(expected))
+	}
+	for _, route := range routes {
+		if !expected[route.Method+" "+route.Path] {
+			t.Errorf("unexpected route %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
@@ -0,0 +1,93 @@
+package note
+
+import (
+	"context"
+	"errors"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"gorm.io/gorm"
+)
+
+// Allowed fields for sorting and filtering in List queries. "id" is not
+// sortable: UUIDs are random, so lists default to newest first instead.
+var (
+	allowedSortFields   = []string{"title", "created_at", "updated_at"}
+	allowedFilterFields = []string{"title"}
+)
+
+const defaultSort = "created_at:desc"
+
+// noteRepository implements domain.NoteRepository using GORM.
+type noteRepository struct {
+	db *gorm.DB
+}
+
+// NewNoteRepository creates a new NoteRepository backed by the given GORM database.
+func NewNoteRepository(db *gorm.DB) domain.NoteRepository {
+	return &noteRepository{db: db}
+}
+
+// Create inserts a new note; domain.UUIDModel assigns its ID.
+func (r *noteRepository) Create(ctx context.Context, note *domain.Note) error {
+	if err := r.db.WithContext(ctx).Create(note).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// GetByID retrieves a note by its UUID.
+func (r *noteRepository) GetByID(ctx context.Context, id string) (*domain.Note, error) {
+	var note domain.Note
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&note).Error; err != nil {
+		return nil, mapError(err)
+	}
+	return &note, nil
+}
+
+// List returns a paginated, sorted, and filtered list of notes.
+func (r *noteRepository) List(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.Note], error) {
+	result, err := pkg.PaginateGORM[domain.Note](ctx, r.db.WithContext(ctx).Model(&domain.Note{}), req, pkg.ListOptions{
+		SortFields:   allowedSortFields,
+		FilterFields: allowedFilterFields,
+		DefaultSort:  defaultSort,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return result, nil
+}
+
+// Update saves changes to an existing note.
+func (r *noteRepository) Update(ctx context.Context, note *domain.Note) error {
+	if err := r.db.WithContext(ctx).Save(note).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// Delete removes a note by UUID.
+func (r *noteRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&domain.Note{})
+	if result.Error != nil {
+		return mapError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
@@ -0,0 +1,116 @@
+package note
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestCreate_GeneratesUUID(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewNoteRepository(db)
+	ctx := context.Background()
+
+	note := &domain.Note{Title: "First"}
+	if err := repo.Create(ctx, note); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := uuid.Parse(note.ID); err != nil {
+		t.Fatalf("ID = %q, want a generated UUID: %v", note.ID, err)
+	}
+
+	got, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Title != "First" {
+		t.Errorf("Title = %q, want First", got.Title)
+	}
+}
+
+func TestCreate_KeepsExplicitID(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewNoteRepository(db)
+
+	const id = "7d444840-9dc0-11d1-b245-5ffdce74fad2"
+	note := &domain.Note{UUIDModel: domain.UUIDModel{ID: id}, Title: "Imported"}
+	if err := repo.Create(context.Background(), note); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if note.ID != id {
+		t.Errorf("ID = %q, want %q", note.ID, id)
+	}
+}
+
+func TestNoteGetByID_NotFound(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewNoteRepository(db)
+
+	_, err := repo.GetByID(context.Background(), "00000000-0000-0000-0000-000000000000")
+	if !domain.IsNotFound(err) {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+}
+
+func TestNoteList_DefaultsToNewestFirst(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewNoteRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, title := range []string{"oldest", "middle", "newest"} {
+		note := &domain.Note{Title: title}
+		note.CreatedAt = domain.NewTime(base.Add(time.Duration(i) * time.Hour))
+		if err := repo.Create(ctx, note); err != nil {
+			t.Fatalf("Create %s: %v", title, err)
+		}
+	}
+
+	result, err := repo.List(ctx, domain.PageRequest{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var titles []string
+	for _, n := range result.Items {
+		titles = append(titles, n.Title)
+	}
+	want := []string{"newest", "middle", "oldest"}
+	if len(titles) != len(want) {
+		t.Fatalf("titles = %v, want %v", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("titles = %v, want %v", titles, want)
+		}
+	}
+
+	result, err = repo.List(ctx, domain.PageRequest{Page: 1, PageSize: 10, Sort: "title:asc"})
+	if err != nil {
+		t.Fatalf("List sorted: %v", err)
+	}
+	if result.Items[0].Title != "middle" {
+		t.Errorf("first by title = %q, want middle", result.Items[0].Title)
+	}
+}
+
+func TestNoteDelete(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewNoteRepository(db)
+	ctx := context.Background()
+
+	note := &domain.Note{Title: "Doomed"}
+	if err := repo.Create(ctx, note); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(ctx, note.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, note.ID); !domain.IsNotFound(err) {
+		t.Errorf("second Delete: expected not-found error, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package note
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Length limits for note fields, mirrored by the DTO binding tags.
+const (
+	maxTitleLength = 200
+	maxBodyLength  = 10000
+)
+
+// noteService implements domain.NoteService.
+type noteService struct {
+	repo domain.NoteRepository
+}
+
+// NewNoteService creates a new NoteService with the given repository.
+func NewNoteService(repo domain.NoteRepository) domain.NoteService {
+	return &noteService{repo: repo}
+}
+
+// CreateNote validates input, builds a Note, and persists it via the repository.
+func (s *noteService) CreateNote(ctx context.Context, title, body string) (*domain.Note, error) {
+	title = strings.TrimSpace(title)
+	body = strings.TrimSpace(body)
+	if err := validateNote(title, body); err != nil {
+		return nil, err
+	}
+
+	note := &domain.Note{Title: title, Body: body}
+	if err := s.repo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// GetNote retrieves a note by UUID.
+func (s *noteService) GetNote(ctx context.Context, id string) (*domain.Note, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListNotes returns a paginated list of notes.
+func (s *noteService) ListNotes(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.Note], error) {
+	return s.repo.List(ctx, req)
+}
+
+// UpdateNote loads the existing note, applies changes, and persists them.
+func (s *noteService) UpdateNote(ctx context.Context, id, title, body string) (*domain.Note, error) {
+	title = strings.TrimSpace(title)
+	body = strings.TrimSpace(body)
+	if err := validateNote(title, body); err != nil {
+		return nil, err
+	}
+
+	note, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	note.Title = title
+	note.Body = body
+	if err := s.repo.Update(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// DeleteNote removes a note by UUID.
+func (s *noteService) DeleteNote(ctx context.Context, id string) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// validateNote checks the title and body lengths.
+func validateNote(title, body string) error {
+	if title == "" {
+		return domain.NewAppError(domain.CodeValidation, "title is required", nil)
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return domain.NewAppError(domain.CodeValidation, "title must be at most 200 characters", nil)
+	}
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		return domain.NewAppError(domain.CodeValidation, "body must be at most 10000 characters", nil)
+	}
+	return nil
+}
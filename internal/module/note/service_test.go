@@ -0,0 +1,66 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func TestNoteService_CreateValidation(t *testing.T) {
+	svc := NewNoteService(NewNoteRepository(testutil.NewTestDB(t)))
+
+	tests := []struct {
+		name  string
+		title string
+		body  string
+	}{
+		{"blank title", "   ", ""},
+		{"title too long", strings.Repeat("a", maxTitleLength+1), ""},
+		{"body too long", "ok", strings.Repeat("a", maxBodyLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateNote(context.Background(), tt.title, tt.body)
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Code != domain.CodeValidation {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNoteService_UpdateTrimsAndPersists(t *testing.T) {
+	svc := NewNoteService(NewNoteRepository(testutil.NewTestDB(t)))
+	ctx := context.Background()
+
+	note, err := svc.CreateNote(ctx, "Draft", "")
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	if _, err := svc.UpdateNote(ctx, note.ID, "  Final  ", " text "); err != nil {
+		t.Fatalf("UpdateNote: %v", err)
+	}
+	got, err := svc.GetNote(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+	if got.Title != "Final" || got.Body != "text" {
+		t.Errorf("got %+v, want trimmed title and body", got)
+	}
+	if !got.CreatedAt.Equal(note.CreatedAt.Time) {
+		t.Errorf("CreatedAt changed on update: %v -> %v", note.CreatedAt, got.CreatedAt)
+	}
+}
+
+func TestNoteService_UpdateNotFound(t *testing.T) {
+	svc := NewNoteService(NewNoteRepository(testutil.NewTestDB(t)))
+
+	_, err := svc.UpdateNote(context.Background(), "00000000-0000-0000-0000-000000000000", "x", "")
+	if !domain.IsNotFound(err) {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+}
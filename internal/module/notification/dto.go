@@ -0,0 +1,6 @@
+package notification
+
+// UnreadCountResponse is the payload of GET /api/v1/notifications/unread-count.
+type UnreadCountResponse struct {
+	Count int64 `json:"count"`
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// NotificationHandler handles REST API requests for the current user's
+// notifications.
+type NotificationHandler struct {
+	svc domain.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler with the given service.
+func NewNotificationHandler(svc domain.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+// List handles GET /api/v1/notifications.
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		pkg.Error(c, domain.ErrUnauthorized)
+		return
+	}
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.svc.ListNotifications(c.Request.Context(), userID, req)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.List(c, result)
+}
+
+// MarkRead handles POST /api/v1/notifications/:id/read.
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		pkg.Error(c, domain.ErrUnauthorized)
+		return
+	}
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	if err := h.svc.MarkRead(c.Request.Context(), userID, id); err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, nil)
+}
+
+// UnreadCount handles GET /api/v1/notifications/unread-count.
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		pkg.Error(c, domain.ErrUnauthorized)
+		return
+	}
+
+	count, err := h.svc.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, UnreadCountResponse{Count: count})
+}
+
+// currentUserID returns the authenticated user's ID. Tokens carry it as the
+// decimal string set by the auth module. The routes sit behind ginx.Auth, so
+// a missing ID only happens when they are mounted without it.
+func currentUserID(c *gin.Context) (uint, bool) {
+	raw, ok := requestctx.UserID(c)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
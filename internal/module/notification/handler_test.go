@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// setupAPIRouter wires the real notification stack on a test database. A
+// stand-in for ginx.Auth takes the user ID from the X-Test-User header.
+func setupAPIRouter(t *testing.T) (*gin.Engine, domain.NotificationService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	svc := NewNotificationService(NewNotificationRepository(newTestDB(t)))
+	r := gin.New()
+	api := r.Group("/api")
+	api.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-User"); id != "" {
+			ginx.SetUserID(c, id)
+		}
+	})
+	NewModule(NewNotificationHandler(svc)).RegisterRoutes(api, nil)
+	return r, svc
+}
+
+func userRequest(t *testing.T, method, path string, userID uint) *http.Request {
+	t.Helper()
+	req := testutil.NewJSONRequest(t, method, path, "")
+	req.Header.Set("X-Test-User", strconv.FormatUint(uint64(userID), 10))
+	return req
+}
+
+func TestNotificationHandler_ListReadAndCount(t *testing.T) {
+	r, svc := setupAPIRouter(t)
+	for _, title := range []string{"Import finished", "User deleted"} {
+		if err := svc.Publish(t.Context(), 7, "test", title, ""); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	w := testutil.Serve(r, userRequest(t, http.MethodGet, "/api/notifications", 7))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data struct {
+			Items []domain.Notification `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("unmarshal list: %v", err)
+	}
+	if len(list.Data.Items) != 2 || list.Data.Items[0].Title != "User deleted" {
+		t.Fatalf("items = %+v, want 2 newest first", list.Data.Items)
+	}
+
+	newest := list.Data.Items[0].ID
+	w = testutil.Serve(r, userRequest(t, http.MethodPost, "/api/notifications/"+strconv.FormatUint(uint64(newest), 10)+"/read", 7))
+	if w.Code != http.StatusOK {
+		t.Fatalf("mark read status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = testutil.Serve(r, userRequest(t, http.MethodGet, "/api/notifications/unread-count", 7))
+	var count struct {
+		Data UnreadCountResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &count); err != nil {
+		t.Fatalf("unmarshal count: %v", err)
+	}
+	if w.Code != http.StatusOK || count.Data.Count != 1 {
+		t.Errorf("unread-count = %d %s, want 200 with count 1", w.Code, w.Body.String())
+	}
+}
+
+func TestNotificationHandler_MarkReadOtherUser(t *testing.T) {
+	r, svc := setupAPIRouter(t)
+	if err := svc.Publish(t.Context(), 7, "test", "Private", ""); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	w := testutil.Serve(r, userRequest(t, http.MethodPost, "/api/notifications/1/read", 8))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 for another user's notification", w.Code)
+	}
+}
+
+func TestNotificationHandler_Errors(t *testing.T) {
+	r, _ := setupAPIRouter(t)
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/notifications", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous list status = %d, want 401", w.Code)
+	}
+	w = testutil.Serve(r, userRequest(t, http.MethodPost, "/api/notifications/abc/read", 7))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid id status = %d, want 400", w.Code)
+	}
+}
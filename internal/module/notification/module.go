@@ -0,0 +1,58 @@
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// NotificationModule implements the app.Module interface for the current
+// user's notifications. It has no pages; the nav badge is rendered from
+// middleware.UnreadNotifications.
+type NotificationModule struct {
+	handler *NotificationHandler
+}
+
+// NewModule creates a new NotificationModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *NotificationHandler) *NotificationModule {
+	if h == nil {
+		panic("notification.NewModule: handler must not be nil")
+	}
+	return &NotificationModule{handler: h}
+}
+
+// RegisterRoutes registers notification API routes.
+func (m *NotificationModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.GET("/notifications", m.handler.List)
+	api.GET("/notifications/unread-count", m.handler.UnreadCount)
+	api.POST("/notifications/:id/read", m.handler.MarkRead)
+}
+
+// Models returns the notifications table model for migration and the
+// startup schema check.
+func (m *NotificationModule) Models() []any {
+	return []any{&domain.Notification{}}
+}
+
+// Erasables returns the user's notifications, for their data export and
+// erasure.
+func (m *NotificationModule) Erasables() []pkg.Erasable {
+	return []pkg.Erasable{
+		pkg.UserRecords[domain.Notification]("notifications", "user_id = ?", func(u *domain.User) []any { return []any{u.ID} }),
+	}
+}
+
+// UserDependents returns the notifications table, whose rows are deleted
+// with their owner.
+func (m *NotificationModule) UserDependents() []pkg.UserDependent {
+	return []pkg.UserDependent{{Name: "notifications", Model: &domain.Notification{}}}
+}
+
+// Policies needs no permission: the handlers only ever read and mark the
+// caller's own notifications.
+func (m *NotificationModule) Policies() []middleware.Policy {
+	return []middleware.Policy{{PathPrefix: "/api/v1/notifications"}}
+}
@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotificationModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&NotificationHandler{}).RegisterRoutes(r.Group("/api"), r.Group("/"))
+
+	expected := map[string]bool{
+		http.MethodGet + " /api/notifications":              true,
+		http.MethodGet + " /api/notifications/unread-count": true,
+		http.MethodPost + " /api/notifications/:id/read":    true,
+	}
+	routes := r.Routes()
+	if len(routes) != len(expected) {
+		t.Errorf("registered %d routes, want %d (no page routes)", len(routes), len(expected))
+	}
+	for _, route := range routes {
+		if !expected[route.Method+" "+route.Path] {
+			t.Errorf("unexpected route %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
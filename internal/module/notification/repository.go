@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"gorm.io/gorm"
+)
+
+// Allowed fields for sorting and filtering in List queries.
+var (
+	allowedSortFields   = []string{"id", "created_at"}
+	allowedFilterFields = []string{"type"}
+)
+
+// defaultSort lists newest first; IDs are auto-increment, so unlike
+// created_at they never tie.
+const defaultSort = "id:desc"
+
+// notificationRepository implements domain.NotificationRepository using GORM.
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository backed by the given GORM database.
+func NewNotificationRepository(db *gorm.DB) domain.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create inserts a new notification.
+func (r *notificationRepository) Create(ctx context.Context, n *domain.Notification) error {
+	if err := r.db.WithContext(ctx).Create(n).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// List returns a page of userID's notifications, newest first by default.
+func (r *notificationRepository) List(ctx context.Context, userID uint, req domain.PageRequest) (*domain.PageResult[domain.Notification], error) {
+	db := r.db.WithContext(ctx).Model(&domain.Notification{}).Where("user_id = ?", userID)
+	result, err := pkg.PaginateGORM[domain.Notification](ctx, db, req, pkg.ListOptions{
+		SortFields:   allowedSortFields,
+		FilterFields: allowedFilterFields,
+		DefaultSort:  defaultSort,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return result, nil
+}
+
+// MarkRead sets read_at on one of userID's notifications. Marking an already
+// read notification is a no-op; another user's notification is not found.
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return mapError(result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return mapError(err)
+	}
+	if count == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// CountUnread returns how many of userID's notifications are unread.
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, mapError(err)
+	}
+	return count, nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newTestDB opens a test database with users 1 to 8, the owners the tests'
+// notifications reference through their foreign key.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	testutil.SeedUsers(t, db, make([]domain.User, 8)...)
+	return db
+}
+
+// seedNotifications creates one notification per title for userID, oldest
+// first.
+func seedNotifications(t *testing.T, repo domain.NotificationRepository, userID uint, titles ...string) []domain.Notification {
+	t.Helper()
+	out := make([]domain.Notification, len(titles))
+	for i, title := range titles {
+		out[i] = domain.Notification{UserID: userID, Type: "test", Title: title}
+		if err := repo.Create(context.Background(), &out[i]); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	return out
+}
+
+func TestNotificationList_NewestFirstAndScopedToUser(t *testing.T) {
+	repo := NewNotificationRepository(newTestDB(t))
+	seedNotifications(t, repo, 1, "first", "second", "third")
+	seedNotifications(t, repo, 2, "other user")
+
+	result, err := repo.List(context.Background(), 1, domain.PageRequest{Page: 1, PageSize: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if result.TotalItems != 3 {
+		t.Errorf("TotalItems = %d, want 3 (other users' notifications excluded)", result.TotalItems)
+	}
+	if len(result.Items) != 2 || result.Items[0].Title != "third" || result.Items[1].Title != "second" {
+		t.Errorf("Items = %+v, want third, second", result.Items)
+	}
+}
+
+func TestNotificationMarkRead(t *testing.T) {
+	repo := NewNotificationRepository(newTestDB(t))
+	ctx := context.Background()
+	seeded := seedNotifications(t, repo, 1, "a", "b")
+
+	if err := repo.MarkRead(ctx, 1, seeded[0].ID); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if err := repo.MarkRead(ctx, 1, seeded[0].ID); err != nil {
+		t.Errorf("MarkRead twice: %v, want no-op", err)
+	}
+	count, err := repo.CountUnread(ctx, 1)
+	if err != nil {
+		t.Fatalf("CountUnread: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("unread = %d, want 1", count)
+	}
+
+	result, err := repo.List(ctx, 1, domain.PageRequest{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, n := range result.Items {
+		if (n.ID == seeded[0].ID) != (n.ReadAt != nil) {
+			t.Errorf("notification %d ReadAt = %v", n.ID, n.ReadAt)
+		}
+	}
+}
+
+func TestNotificationMarkRead_OtherUserNotFound(t *testing.T) {
+	repo := NewNotificationRepository(newTestDB(t))
+	seeded := seedNotifications(t, repo, 1, "mine")
+
+	if err := repo.MarkRead(context.Background(), 2, seeded[0].ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("MarkRead by another user: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.MarkRead(context.Background(), 1, 9999); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("MarkRead missing ID: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Length limits for notification fields, matching the column sizes.
+const (
+	maxTypeLength  = 50
+	maxTitleLength = 200
+)
+
+// notificationService implements domain.NotificationService.
+type notificationService struct {
+	repo domain.NotificationRepository
+}
+
+// NewNotificationService creates a new NotificationService with the given repository.
+func NewNotificationService(repo domain.NotificationRepository) domain.NotificationService {
+	return &notificationService{repo: repo}
+}
+
+// Publish stores a new unread notification for userID. kind is a short
+// machine-readable category such as "user_deleted".
+func (s *notificationService) Publish(ctx context.Context, userID uint, kind, title, body string) error {
+	kind = strings.TrimSpace(kind)
+	title = strings.TrimSpace(title)
+	body = strings.TrimSpace(body)
+	if userID == 0 {
+		return domain.NewAppError(domain.CodeValidation, "user_id is required", nil)
+	}
+	if kind == "" || utf8.RuneCountInString(kind) > maxTypeLength {
+		return domain.NewAppError(domain.CodeValidation, "type is required and must be at most 50 characters", nil)
+	}
+	if title == "" || utf8.RuneCountInString(title) > maxTitleLength {
+		return domain.NewAppError(domain.CodeValidation, "title is required and must be at most 200 characters", nil)
+	}
+
+	return s.repo.Create(ctx, &domain.Notification{UserID: userID, Type: kind, Title: title, Body: body})
+}
+
+// ListNotifications returns a paginated list of userID's notifications.
+func (s *notificationService) ListNotifications(ctx context.Context, userID uint, req domain.PageRequest) (*domain.PageResult[domain.Notification], error) {
+	return s.repo.List(ctx, userID, req)
+}
+
+// MarkRead marks one of userID's notifications as read.
+func (s *notificationService) MarkRead(ctx context.Context, userID, id uint) error {
+	return s.repo.MarkRead(ctx, userID, id)
+}
+
+// UnreadCount returns how many of userID's notifications are unread.
+func (s *notificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+func TestNotificationService_PublishValidation(t *testing.T) {
+	svc := NewNotificationService(NewNotificationRepository(newTestDB(t)))
+
+	tests := []struct {
+		name   string
+		userID uint
+		kind   string
+		title  string
+	}{
+		{"no user", 0, "import_finished", "Import finished"},
+		{"blank type", 1, "  ", "Import finished"},
+		{"type too long", 1, strings.Repeat("a", maxTypeLength+1), "Import finished"},
+		{"blank title", 1, "import_finished", " "},
+		{"title too long", 1, "import_finished", strings.Repeat("a", maxTitleLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.Publish(context.Background(), tt.userID, tt.kind, tt.title, "")
+			var appErr *domain.AppError
+			if !errors.As(err, &appErr) || appErr.Code != domain.CodeValidation {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNotificationService_PublishAndCount(t *testing.T) {
+	svc := NewNotificationService(NewNotificationRepository(newTestDB(t)))
+	ctx := context.Background()
+
+	if err := svc.Publish(ctx, 1, " import_finished ", " Import finished ", " 42 users imported "); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	result, err := svc.ListNotifications(ctx, 1, domain.PageRequest{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("ListNotifications: %v", err)
+	}
+	if len(result.Items) != 1 {
+		t.Fatalf("Items = %+v, want 1 notification", result.Items)
+	}
+	n := result.Items[0]
+	if n.Type != "import_finished" || n.Title != "Import finished" || n.Body != "42 users imported" || n.ReadAt != nil {
+		t.Errorf("notification = %+v, want trimmed unread fields", n)
+	}
+
+	count, err := svc.UnreadCount(ctx, 1)
+	if err != nil || count != 1 {
+		t.Fatalf("UnreadCount = %d, %v; want 1", count, err)
+	}
+	if err := svc.MarkRead(ctx, 1, n.ID); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if count, _ := svc.UnreadCount(ctx, 1); count != 0 {
+		t.Errorf("UnreadCount after MarkRead = %d, want 0", count)
+	}
+}
@@ -0,0 +1,54 @@
+package privacy
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// ErasureHandler handles the user data export and erasure API.
+type ErasureHandler struct {
+	svc domain.ErasureService
+}
+
+// NewErasureHandler creates a new ErasureHandler with the given service.
+func NewErasureHandler(svc domain.ErasureService) *ErasureHandler {
+	return &ErasureHandler{svc: svc}
+}
+
+// Export handles GET /api/v1/users/:id/export.
+func (h *ErasureHandler) Export(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	export, err := h.svc.ExportUser(c.Request.Context(), id)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	pkg.Success(c, export)
+}
+
+// Erase handles DELETE /api/v1/users/:id/erase.
+func (h *ErasureHandler) Erase(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	receipt, err := h.svc.EraseUser(c.Request.Context(), id, requestctx.Actor(c))
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, receipt)
+}
@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// Gin patterns of the export and erasure routes, which their policies
+// name.
+const (
+	exportRoute = "/api/v1/users/:id/export"
+	eraseRoute  = "/api/v1/users/:id/erase"
+)
+
+// PrivacyModule implements the app.Module interface for the data export
+// and erasure of users (GDPR access and erasure requests). Erasure is
+// separate from DELETE /api/v1/users/:id: it also removes what the other
+// modules hold about the user, and cannot be undone.
+type PrivacyModule struct {
+	handler *ErasureHandler
+}
+
+// NewModule creates a new PrivacyModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *ErasureHandler) *PrivacyModule {
+	if h == nil {
+		panic("privacy.NewModule: handler must not be nil")
+	}
+	return &PrivacyModule{handler: h}
+}
+
+// RegisterRoutes registers the export and erasure API routes.
+func (m *PrivacyModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.GET("/users/:id/export", m.handler.Export)
+	api.DELETE("/users/:id/erase", m.handler.Erase)
+}
+
+// Models returns the erasure receipts table model for migration and the
+// startup schema check.
+func (m *PrivacyModule) Models() []any {
+	return []any{&domain.ErasureReceipt{}}
+}
+
+// Policies requires users:export and users:erase, on top of the users:read
+// and users:delete the user module's policies ask for under /api/v1/users.
+// Neither is self-service.
+func (m *PrivacyModule) Policies() []middleware.Policy {
+	return []middleware.Policy{
+		{Route: exportRoute, Method: http.MethodGet, Resource: "users", Action: "export"},
+		{Route: eraseRoute, Method: http.MethodDelete, Resource: "users", Action: "erase"},
+	}
+}
@@ -0,0 +1,50 @@
+package privacy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPrivacyModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&ErasureHandler{}).RegisterRoutes(r.Group("/api/v1"), r.Group("/"))
+
+	expected := map[string]bool{
+		http.MethodGet + " " + exportRoute:   true,
+		http.MethodDelete + " " + eraseRoute: true,
+	}
+	routes := r.Routes()
+	if len(routes) != len(expected) {
+		t.Errorf("registered %d routes, want %d (no page routes)", len(routes), len(expected))
+	}
+	for _, route := range routes {
+		if !expected[route.Method+" "+route.Path] {
+			t.Errorf("unexpected route %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestPrivacyModulePolicies(t *testing.T) {
+	m := NewModule(&ErasureHandler{})
+	for _, p := range m.Policies() {
+		if p.AllowSelf || p.Resource != "users" {
+			t.Errorf("policy %s, want a users permission without self-service", p)
+		}
+	}
+	if p := m.Policies(); len(p) != 2 || !p[1].Covers(http.MethodDelete, eraseRoute) || p[1].Covers(http.MethodDelete, "/api/v1/users/:id") {
+		t.Errorf("policies = %v, want erase guarded apart from delete", p)
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
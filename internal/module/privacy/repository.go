@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"gorm.io/gorm"
+)
+
+// erasureRepository implements domain.ErasureRepository using GORM.
+type erasureRepository struct {
+	db     *gorm.DB
+	stores []pkg.Erasable
+}
+
+// NewErasureRepository creates a new ErasureRepository over the users table
+// and stores, the dependent stores the modules registered.
+func NewErasureRepository(db *gorm.DB, stores []pkg.Erasable) domain.ErasureRepository {
+	return &erasureRepository{db: db, stores: stores}
+}
+
+// Export reads the user and the records of every store in one transaction,
+// so the bundle is a consistent snapshot.
+func (r *erasureRepository) Export(ctx context.Context, id uint) (*domain.UserExport, error) {
+	export := &domain.UserExport{Records: make(map[string]any, len(r.stores))}
+	err := pkg.WithTx(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		user, err := findUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		export.User = user
+		for _, store := range r.stores {
+			records, err := store.ExportUser(tx, user)
+			if err != nil {
+				return err
+			}
+			export.Records[store.ErasureName()] = records
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	export.ExportedAt = domain.NewTime(time.Now())
+	return export, nil
+}
+
+// Erase deletes the records of every store, then the user row, and stores
+// receipt, in one transaction: a failure anywhere erases nothing.
+func (r *erasureRepository) Erase(ctx context.Context, id uint, receipt *domain.ErasureReceipt) (map[string]int64, error) {
+	var removed map[string]int64
+	err := pkg.WithTx(r.db.WithContext(ctx), func(tx *gorm.DB) error {
+		user, err := findUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		removed = make(map[string]int64, len(r.stores))
+		for _, store := range r.stores {
+			n, err := store.EraseUser(tx, user)
+			if err != nil {
+				return err
+			}
+			removed[store.ErasureName()] = n
+		}
+		if err := tx.Delete(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(receipt).Error
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return removed, nil
+}
+
+func findUser(ctx context.Context, tx *gorm.DB, id uint) (*domain.User, error) {
+	var user domain.User
+	if err := tx.Scopes(pkg.TenantScope(ctx)).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
@@ -0,0 +1,190 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// testStores mirror the stores the notification, auth and user modules
+// register.
+func testStores() []pkg.Erasable {
+	return []pkg.Erasable{
+		pkg.UserRecords[domain.Notification]("notifications", "user_id = ?", func(u *domain.User) []any { return []any{u.ID} }),
+		pkg.UserRecords[domain.LoginAttempt]("login_attempts", "user_id = ? OR email = ?", func(u *domain.User) []any { return []any{u.ID, u.Email} }),
+		pkg.UserRecords[domain.Change]("changes", "entity_type = ? AND entity_id = ?", func(u *domain.User) []any {
+			return []any{"users", strconv.FormatUint(uint64(u.ID), 10)}
+		}),
+	}
+}
+
+// seedStores gives each user two notifications, a successful login, a
+// failed one by email only, and a change feed entry.
+func seedStores(t *testing.T, db *gorm.DB, users ...domain.User) {
+	t.Helper()
+	for _, u := range users {
+		id := u.ID
+		records := []any{
+			&domain.Notification{UserID: u.ID, Type: "test", Title: "hello " + u.Name},
+			&domain.Notification{UserID: u.ID, Type: "test", Title: "again " + u.Name},
+			&domain.LoginAttempt{UserID: &id, Email: u.Email, IP: "192.0.2.1", Success: true},
+			&domain.LoginAttempt{Email: u.Email, IP: "192.0.2.2"},
+			&domain.Change{EntityType: "users", EntityID: strconv.FormatUint(uint64(u.ID), 10), Op: domain.ChangeCreate},
+		}
+		for _, r := range records {
+			if err := db.Create(r).Error; err != nil {
+				t.Fatalf("seed %T: %v", r, err)
+			}
+		}
+	}
+}
+
+func countRows(t *testing.T, db *gorm.DB, model any, query string, args ...any) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Where(query, args...).Count(&n).Error; err != nil {
+		t.Fatalf("count %T: %v", model, err)
+	}
+	return n
+}
+
+func TestErasureRepository_ExportCoversEveryStore(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	users := testutil.SeedUsers(t, db, domain.User{Name: "Alice", Email: "alice@example.com"}, domain.User{Name: "Bob", Email: "bob@example.com"})
+	seedStores(t, db, users...)
+	repo := NewErasureRepository(db, testStores())
+
+	export, err := repo.Export(context.Background(), users[0].ID)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if export.User == nil || export.User.Email != "alice@example.com" {
+		t.Errorf("export user = %+v, want alice", export.User)
+	}
+	want := map[string]int{"notifications": 2, "login_attempts": 2, "changes": 1}
+	for name, n := range want {
+		rows, ok := export.Records[name].([]map[string]any)
+		if !ok || len(rows) != n {
+			t.Errorf("records[%q] = %v, want %d rows", name, export.Records[name], n)
+		}
+	}
+	if len(export.Records) != len(want) {
+		t.Errorf("records stores = %d, want %d", len(export.Records), len(want))
+	}
+	// The email-only attempt is exported with the column the JSON of
+	// domain.LoginAttempt leaves out.
+	for _, row := range export.Records["login_attempts"].([]map[string]any) {
+		if row["email"] != "alice@example.com" {
+			t.Errorf("login attempt %v, want alice's email", row)
+		}
+	}
+
+	if _, err := repo.Export(context.Background(), 999); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Export(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestErasureRepository_EraseRemovesEverythingButTheReceipt(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	users := testutil.SeedUsers(t, db, domain.User{Name: "Alice", Email: "alice@example.com"}, domain.User{Name: "Bob", Email: "bob@example.com"})
+	seedStores(t, db, users...)
+	repo := NewErasureRepository(db, testStores())
+	alice, bob := users[0], users[1]
+
+	receipt := &domain.ErasureReceipt{SubjectHash: SubjectHash(testSubjectKey, alice.ID), ErasedBy: "7"}
+	removed, err := repo.Erase(context.Background(), alice.ID, receipt)
+	if err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if removed["notifications"] != 2 || removed["login_attempts"] != 2 || removed["changes"] != 1 {
+		t.Errorf("removed = %v, want 2 notifications, 2 login attempts, 1 change", removed)
+	}
+
+	aliceID := strconv.FormatUint(uint64(alice.ID), 10)
+	for _, c := range []struct {
+		model any
+		query string
+		args  []any
+	}{
+		{&domain.User{}, "id = ? OR email = ?", []any{alice.ID, alice.Email}},
+		{&domain.Notification{}, "user_id = ?", []any{alice.ID}},
+		{&domain.LoginAttempt{}, "user_id = ? OR email = ?", []any{alice.ID, alice.Email}},
+		{&domain.Change{}, "entity_type = ? AND entity_id = ?", []any{"users", aliceID}},
+	} {
+		if n := countRows(t, db, c.model, c.query, c.args...); n != 0 {
+			t.Errorf("%T rows of the erased user = %d, want 0", c.model, n)
+		}
+	}
+	if n := countRows(t, db, &domain.Notification{}, "user_id = ?", bob.ID); n != 2 {
+		t.Errorf("bob's notifications = %d, want them kept", n)
+	}
+
+	var receipts []domain.ErasureReceipt
+	if err := db.Find(&receipts).Error; err != nil {
+		t.Fatalf("find receipts: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0].SubjectHash != SubjectHash(testSubjectKey, alice.ID) || receipts[0].ErasedBy != "7" || receipts[0].ErasedAt.IsZero() {
+		t.Errorf("receipts = %+v, want one for alice by 7", receipts)
+	}
+
+	if _, err := repo.Erase(context.Background(), alice.ID, &domain.ErasureReceipt{SubjectHash: SubjectHash(testSubjectKey, alice.ID)}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("second Erase() error = %v, want ErrNotFound", err)
+	}
+}
+
+// failingStore is an Erasable whose erasure fails.
+type failingStore struct{}
+
+func (failingStore) ErasureName() string { return "failing" }
+
+func (failingStore) ExportUser(*gorm.DB, *domain.User) (any, error) { return nil, nil }
+
+func (failingStore) EraseUser(*gorm.DB, *domain.User) (int64, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func TestErasureRepository_EraseIsAllOrNothing(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	users := testutil.SeedUsers(t, db, domain.User{Name: "Alice", Email: "alice@example.com"})
+	seedStores(t, db, users...)
+	repo := NewErasureRepository(db, append(testStores(), failingStore{}))
+
+	if _, err := repo.Erase(context.Background(), users[0].ID, &domain.ErasureReceipt{SubjectHash: SubjectHash(testSubjectKey, users[0].ID)}); err == nil {
+		t.Fatal("Erase() error = nil, want the store's error")
+	}
+	if n := countRows(t, db, &domain.Notification{}, "user_id = ?", users[0].ID); n != 2 {
+		t.Errorf("notifications after failed erase = %d, want 2", n)
+	}
+	if n := countRows(t, db, &domain.User{}, "id = ?", users[0].ID); n != 1 {
+		t.Errorf("user rows after failed erase = %d, want 1", n)
+	}
+	if n := countRows(t, db, &domain.ErasureReceipt{}, "1 = 1"); n != 0 {
+		t.Errorf("receipts after failed erase = %d, want 0", n)
+	}
+}
+
+var testSubjectKey = SubjectKey("test-secret")
+
+func TestSubjectHash(t *testing.T) {
+	got := SubjectHash(testSubjectKey, 7)
+	if len(got) != 64 || got == SubjectHash(testSubjectKey, 8) {
+		t.Errorf("SubjectHash(7) = %q, want 64 hex digits distinct from user 8's", got)
+	}
+	if got != SubjectHash(SubjectKey("test-secret"), 7) {
+		t.Error("SubjectHash(7) changed between calls with the same secret")
+	}
+	// The unkeyed hash, sha256("users:7"), is what anyone could compute.
+	if got == "bad73d17a7f933943efefc7568a7861748896736428acd04694fdb424a1ea232" {
+		t.Error("SubjectHash(7) is the plain SHA-256 of the ID")
+	}
+	if got == SubjectHash(SubjectKey("other-secret"), 7) {
+		t.Error("SubjectHash(7) does not depend on the secret")
+	}
+}
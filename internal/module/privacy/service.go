@@ -0,0 +1,65 @@
+package privacy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// erasureService implements domain.ErasureService.
+type erasureService struct {
+	repo       domain.ErasureRepository
+	subjectKey []byte
+}
+
+// NewErasureService creates a new ErasureService with the given repository.
+// Receipts name the subject by SubjectHash under a key derived from secret,
+// the primary JWT signing secret.
+func NewErasureService(repo domain.ErasureRepository, secret string) domain.ErasureService {
+	return &erasureService{repo: repo, subjectKey: SubjectKey(secret)}
+}
+
+// ExportUser returns everything held about user id.
+func (s *erasureService) ExportUser(ctx context.Context, id uint) (*domain.UserExport, error) {
+	return s.repo.Export(ctx, id)
+}
+
+// EraseUser erases user id and returns the receipt. The log line names
+// the subject by its hash only.
+func (s *erasureService) EraseUser(ctx context.Context, id uint, actor string) (*domain.ErasureReceipt, error) {
+	receipt := &domain.ErasureReceipt{SubjectHash: SubjectHash(s.subjectKey, id), ErasedBy: actor}
+	removed, err := s.repo.Erase(ctx, id, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []any{slog.String("by", actor), slog.String("subject_hash", receipt.SubjectHash)}
+	for store, n := range removed {
+		attrs = append(attrs, slog.Int64(store, n))
+	}
+	slog.InfoContext(ctx, "user erased", attrs...)
+	return receipt, nil
+}
+
+// SubjectKey derives the SubjectHash key from secret, so the secret itself
+// is not used for anything but signing tokens.
+func SubjectKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("gobase erasure receipt subject"))
+	return mac.Sum(nil)
+}
+
+// SubjectHash is the ErasureReceipt.SubjectHash of user id: the hex
+// HMAC-SHA256 of "users:<id>" under key. User IDs are sequential, so a
+// plain hash would be reversed by hashing each ID in turn; without the key
+// the receipt does not identify the user.
+func SubjectHash(key []byte, id uint) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("users:" + strconv.FormatUint(uint64(id), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
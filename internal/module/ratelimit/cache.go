@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// subject identifies whom an override applies to.
+type subject struct {
+	kind string // domain.RateLimitSubjectUser or domain.RateLimitSubjectAPIKey
+	id   string
+}
+
+// OverrideCache holds every override in memory for the rate limiter, which
+// looks one up on each request. It is reloaded from the repository every
+// refresh interval, so changes made by another instance (or directly in the
+// database) apply within that interval, and right away after a write through
+// this instance's service.
+type OverrideCache struct {
+	repo     domain.RateLimitOverrideRepository
+	interval time.Duration
+	clock    pkg.Clock
+	log      *slog.Logger
+
+	current atomic.Pointer[map[subject]domain.RateLimitOverride]
+
+	ctx       context.Context // cancelled by Close
+	cancel    context.CancelFunc
+	started   bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewOverrideCache creates an OverrideCache over repo refreshing every
+// interval. It is empty until the first Refresh.
+func NewOverrideCache(repo domain.RateLimitOverrideRepository, interval time.Duration, clock pkg.Clock, log *slog.Logger) *OverrideCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OverrideCache{
+		repo:     repo,
+		interval: interval,
+		clock:    clock,
+		log:      log,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Refresh reloads every override. On error the previous ones stay in use.
+func (c *OverrideCache) Refresh(ctx context.Context) error {
+	overrides, err := c.repo.All(ctx)
+	if err != nil {
+		return err
+	}
+	next := make(map[subject]domain.RateLimitOverride, len(overrides))
+	for _, o := range overrides {
+		next[subject{kind: o.SubjectType, id: o.SubjectID}] = o
+	}
+	c.current.Store(&next)
+	return nil
+}
+
+// Start launches the periodic refresh; call Close to stop it.
+func (c *OverrideCache) Start() {
+	c.started = true
+	go c.loop()
+}
+
+func (c *OverrideCache) loop() {
+	defer close(c.done)
+	timer := c.clock.NewTimer(c.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			if err := c.Refresh(c.ctx); err != nil && c.ctx.Err() == nil {
+				c.log.Error("refresh rate limit overrides", slog.Any("error", err))
+			}
+			timer.Reset(c.interval)
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the periodic refresh and waits for it to return.
+func (c *OverrideCache) Close() {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		if c.started {
+			<-c.done
+		}
+	})
+}
+
+// Lookup returns the override of the subject with the ginx user ID userID:
+// an API key for IDs with middleware.APIKeyUserIDPrefix, a user otherwise.
+// ok is false when the subject has none.
+func (c *OverrideCache) Lookup(userID string) (domain.RateLimitOverride, bool) {
+	m := c.current.Load()
+	if m == nil {
+		return domain.RateLimitOverride{}, false
+	}
+	s := subject{kind: domain.RateLimitSubjectUser, id: userID}
+	if name, isKey := strings.CutPrefix(userID, middleware.APIKeyUserIDPrefix); isKey {
+		s = subject{kind: domain.RateLimitSubjectAPIKey, id: name}
+	}
+	o, ok := (*m)[s]
+	return o, ok
+}
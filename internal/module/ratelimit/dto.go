@@ -0,0 +1,17 @@
+package ratelimit
+
+// CreateOverrideRequest represents the input for creating a rate limit
+// override.
+type CreateOverrideRequest struct {
+	SubjectType string  `json:"subject_type" binding:"required,oneof=user api_key"`
+	SubjectID   string  `json:"subject_id" binding:"required,max=100"`
+	RPS         float64 `json:"rps" binding:"required,gt=0"`
+	Burst       int     `json:"burst" binding:"required,gt=0"`
+}
+
+// UpdateOverrideRequest represents the input for replacing the limits of an
+// override.
+type UpdateOverrideRequest struct {
+	RPS   float64 `json:"rps" binding:"required,gt=0"`
+	Burst int     `json:"burst" binding:"required,gt=0"`
+}
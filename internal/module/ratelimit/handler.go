@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// OverrideHandler handles the admin API for rate limit overrides.
+type OverrideHandler struct {
+	svc domain.RateLimitOverrideService
+}
+
+// NewOverrideHandler creates a new OverrideHandler with the given service.
+func NewOverrideHandler(svc domain.RateLimitOverrideService) *OverrideHandler {
+	return &OverrideHandler{svc: svc}
+}
+
+// Create handles POST /api/v1/admin/rate-limits.
+func (h *OverrideHandler) Create(c *gin.Context) {
+	var req CreateOverrideRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	o, err := h.svc.CreateOverride(c.Request.Context(), req.SubjectType, req.SubjectID, req.RPS, req.Burst)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+	logChange(c, "rate limit override created", o)
+
+	pkg.JSON(c, http.StatusCreated, pkg.Response{
+		Code:    http.StatusCreated,
+		Message: "success",
+		Data:    o,
+	})
+}
+
+// Get handles GET /api/v1/admin/rate-limits/:id.
+func (h *OverrideHandler) Get(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	o, err := h.svc.GetOverride(c.Request.Context(), id)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.Success(c, o)
+}
+
+// List handles GET /api/v1/admin/rate-limits.
+func (h *OverrideHandler) List(c *gin.Context) {
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
+
+	result, err := h.svc.ListOverrides(c.Request.Context(), req)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	pkg.List(c, result)
+}
+
+// Update handles PUT /api/v1/admin/rate-limits/:id.
+func (h *OverrideHandler) Update(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	var req UpdateOverrideRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	o, err := h.svc.UpdateOverride(c.Request.Context(), id, req.RPS, req.Burst)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+	logChange(c, "rate limit override updated", o)
+
+	pkg.Success(c, o)
+}
+
+// Delete handles DELETE /api/v1/admin/rate-limits/:id.
+func (h *OverrideHandler) Delete(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	if err := h.svc.DeleteOverride(c.Request.Context(), id); err != nil {
+		pkg.Error(c, err)
+		return
+	}
+	by := requestctx.Actor(c)
+	slog.InfoContext(c.Request.Context(), "rate limit override deleted", slog.String("by", by), slog.Uint64("id", uint64(id)))
+
+	pkg.Success(c, nil)
+}
+
+// logChange audit-logs a created or updated override and who made it.
+func logChange(c *gin.Context, msg string, o *domain.RateLimitOverride) {
+	by := requestctx.Actor(c)
+	slog.InfoContext(c.Request.Context(), msg,
+		slog.String("by", by),
+		slog.Uint64("id", uint64(o.ID)),
+		slog.String("subject_type", o.SubjectType),
+		slog.String("subject_id", o.SubjectID),
+		slog.Float64("rps", o.RPS),
+		slog.Int("burst", o.Burst),
+	)
+}
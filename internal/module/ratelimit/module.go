@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// OverrideModule implements the app.Module interface for the rate limit
+// override admin API. It is only registered when
+// server.rate_limit.overrides is enabled, which requires RBAC.
+type OverrideModule struct {
+	handler *OverrideHandler
+}
+
+// NewModule creates a new OverrideModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *OverrideHandler) *OverrideModule {
+	if h == nil {
+		panic("ratelimit.NewModule: handler must not be nil")
+	}
+	return &OverrideModule{handler: h}
+}
+
+// RegisterRoutes registers the override admin API routes.
+func (m *OverrideModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.GET("/admin/rate-limits", m.handler.List)
+	api.POST("/admin/rate-limits", m.handler.Create)
+	api.GET("/admin/rate-limits/:id", m.handler.Get)
+	api.PUT("/admin/rate-limits/:id", m.handler.Update)
+	api.DELETE("/admin/rate-limits/:id", m.handler.Delete)
+}
+
+// Models returns the overrides table model for migration and the startup
+// schema check.
+func (m *OverrideModule) Models() []any {
+	return []any{&domain.RateLimitOverride{}}
+}
+
+// Erasables returns the user's override, for their data export and
+// erasure.
+func (m *OverrideModule) Erasables() []pkg.Erasable {
+	return []pkg.Erasable{
+		pkg.UserRecords[domain.RateLimitOverride]("rate_limit_overrides", "subject_type = ? AND subject_id = ?", func(u *domain.User) []any {
+			return []any{domain.RateLimitSubjectUser, strconv.FormatUint(uint64(u.ID), 10)}
+		}),
+	}
+}
+
+// Policies requires admin:update for changes, on top of the admin:read
+// every /api/v1/admin route needs.
+func (m *OverrideModule) Policies() []middleware.Policy {
+	return []middleware.Policy{
+		{PathPrefix: "/api/v1/admin/rate-limits", Method: http.MethodPost, Resource: "admin", Action: "update"},
+		{PathPrefix: "/api/v1/admin/rate-limits", Method: http.MethodPut, Resource: "admin", Action: "update"},
+		{PathPrefix: "/api/v1/admin/rate-limits", Method: http.MethodDelete, Resource: "admin", Action: "update"},
+	}
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOverrideModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&OverrideHandler{}).RegisterRoutes(r.Group("/api"), r.Group("/"))
+
+	got := map[string]bool{}
+	for _, route := range r.Routes() {
+		got[route.Method+" "+route.Path] = true
+	}
+	for _, want := range []string{
+		"GET /api/admin/rate-limits",
+		"POST /api/admin/rate-limits",
+		"GET /api/admin/rate-limits/:id",
+		"PUT /api/admin/rate-limits/:id",
+		"DELETE /api/admin/rate-limits/:id",
+	} {
+		if !got[want] {
+			t.Errorf("route %s not registered", want)
+		}
+	}
+}
+
+func TestOverrideModulePolicies(t *testing.T) {
+	for _, p := range NewModule(&OverrideHandler{}).Policies() {
+		if p.Method == http.MethodGet || p.Resource != "admin" || p.Action != "update" {
+			t.Errorf("policy %+v, want admin:update on writes only", p)
+		}
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// Allowed fields for sorting and filtering in List queries.
+var (
+	allowedSortFields   = []string{"id", "subject_type", "subject_id", "rps", "updated_at"}
+	allowedFilterFields = []string{"subject_type", "subject_id"}
+)
+
+// overrideRepository implements domain.RateLimitOverrideRepository using
+// GORM.
+type overrideRepository struct {
+	db *gorm.DB
+}
+
+// NewOverrideRepository creates a new RateLimitOverrideRepository backed by
+// the given GORM database.
+func NewOverrideRepository(db *gorm.DB) domain.RateLimitOverrideRepository {
+	return &overrideRepository{db: db}
+}
+
+// Create inserts a new override. A second override for the same subject is
+// rejected as already existing.
+func (r *overrideRepository) Create(ctx context.Context, o *domain.RateLimitOverride) error {
+	if err := r.db.WithContext(ctx).Create(o).Error; err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// GetByID retrieves an override by its primary key.
+func (r *overrideRepository) GetByID(ctx context.Context, id uint) (*domain.RateLimitOverride, error) {
+	var o domain.RateLimitOverride
+	if err := r.db.WithContext(ctx).First(&o, id).Error; err != nil {
+		return nil, mapError(err)
+	}
+	return &o, nil
+}
+
+// All returns every override.
+func (r *overrideRepository) All(ctx context.Context) ([]domain.RateLimitOverride, error) {
+	var overrides []domain.RateLimitOverride
+	if err := r.db.WithContext(ctx).Find(&overrides).Error; err != nil {
+		return nil, mapError(err)
+	}
+	return overrides, nil
+}
+
+// List returns a page of overrides, by ID by default.
+func (r *overrideRepository) List(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.RateLimitOverride], error) {
+	db := r.db.WithContext(ctx).Model(&domain.RateLimitOverride{})
+	result, err := pkg.PaginateGORM[domain.RateLimitOverride](ctx, db, req, pkg.ListOptions{
+		SortFields:   allowedSortFields,
+		FilterFields: allowedFilterFields,
+		DefaultSort:  "id",
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return result, nil
+}
+
+// UpdateLimits sets the rps and burst of override id, bumping updated_at.
+func (r *overrideRepository) UpdateLimits(ctx context.Context, id uint, rps float64, burst int) error {
+	result := r.db.WithContext(ctx).Model(&domain.RateLimitOverride{BaseModel: domain.BaseModel{ID: id}}).
+		Updates(map[string]any{"rps": rps, "burst": burst})
+	if result.Error != nil {
+		return mapError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes override id.
+func (r *overrideRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.RateLimitOverride{}, id)
+	if result.Error != nil {
+		return mapError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// mapError converts GORM errors to domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if domain.IsUnavailable(err) {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrNotFound
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) || isDuplicateKeyError(err) {
+		return domain.NewAppError(domain.CodeAlreadyExists, "an override for this subject already exists", err)
+	}
+	return domain.NewAppError(domain.CodeInternal, "database error", err)
+}
+
+// isDuplicateKeyError detects unique constraint violations by examining the
+// error message, as not every dialector returns gorm.ErrDuplicatedKey.
+func isDuplicateKeyError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique constraint") ||
+		strings.Contains(msg, "duplicate key") ||
+		strings.Contains(msg, "duplicate entry")
+}
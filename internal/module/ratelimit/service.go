@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// maxSubjectIDLength matches the subject_id column size.
+const maxSubjectIDLength = 100
+
+// overrideService implements domain.RateLimitOverrideService.
+type overrideService struct {
+	repo  domain.RateLimitOverrideRepository
+	cache *OverrideCache
+}
+
+// NewOverrideService creates a new RateLimitOverrideService with the given
+// repository. Every successful write refreshes cache, so the rate limiter
+// of this instance applies it to the next request.
+func NewOverrideService(repo domain.RateLimitOverrideRepository, cache *OverrideCache) domain.RateLimitOverrideService {
+	return &overrideService{repo: repo, cache: cache}
+}
+
+// CreateOverride stores the limits of one subject: a user ID or an API key
+// name.
+func (s *overrideService) CreateOverride(ctx context.Context, subjectType, subjectID string, rps float64, burst int) (*domain.RateLimitOverride, error) {
+	subjectID = strings.TrimSpace(subjectID)
+	switch subjectType {
+	case domain.RateLimitSubjectUser:
+		if id, err := strconv.ParseUint(subjectID, 10, 64); err != nil || id == 0 {
+			return nil, domain.NewAppError(domain.CodeValidation, "subject_id must be a user ID", nil)
+		}
+	case domain.RateLimitSubjectAPIKey:
+		if subjectID == "" || utf8.RuneCountInString(subjectID) > maxSubjectIDLength {
+			return nil, domain.NewAppError(domain.CodeValidation, "subject_id must be an API key name of at most 100 characters", nil)
+		}
+	default:
+		return nil, domain.NewAppError(domain.CodeValidation, "subject_type must be user or api_key", nil)
+	}
+	if err := validateLimits(rps, burst); err != nil {
+		return nil, err
+	}
+
+	o := &domain.RateLimitOverride{SubjectType: subjectType, SubjectID: subjectID, RPS: rps, Burst: burst}
+	if err := s.repo.Create(ctx, o); err != nil {
+		return nil, err
+	}
+	s.refresh(ctx)
+	return o, nil
+}
+
+// GetOverride retrieves an override by ID.
+func (s *overrideService) GetOverride(ctx context.Context, id uint) (*domain.RateLimitOverride, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListOverrides returns a paginated list of overrides.
+func (s *overrideService) ListOverrides(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.RateLimitOverride], error) {
+	return s.repo.List(ctx, req)
+}
+
+// UpdateOverride replaces the limits of override id; its subject is fixed.
+func (s *overrideService) UpdateOverride(ctx context.Context, id uint, rps float64, burst int) (*domain.RateLimitOverride, error) {
+	if err := validateLimits(rps, burst); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateLimits(ctx, id, rps, burst); err != nil {
+		return nil, err
+	}
+	s.refresh(ctx)
+	return s.repo.GetByID(ctx, id)
+}
+
+// DeleteOverride removes override id; its subject falls back to
+// server.rate_limit.
+func (s *overrideService) DeleteOverride(ctx context.Context, id uint) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.refresh(ctx)
+	return nil
+}
+
+// refresh reloads the cache after a write. The write has succeeded either
+// way; should the reload fail, the periodic refresh picks the change up.
+func (s *overrideService) refresh(ctx context.Context) {
+	if s.cache != nil {
+		_ = s.cache.Refresh(ctx)
+	}
+}
+
+// validateLimits checks rps and burst the way config.ValidateRateLimit
+// checks server.rate_limit.
+func validateLimits(rps float64, burst int) error {
+	if rps <= 0 {
+		return domain.NewAppError(domain.CodeValidation, "rps must be positive", nil)
+	}
+	if burst <= 0 {
+		return domain.NewAppError(domain.CodeValidation, "burst must be positive", nil)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func newTestService(t *testing.T) (domain.RateLimitOverrideService, *OverrideCache) {
+	t.Helper()
+	repo := NewOverrideRepository(testutil.NewTestDB(t))
+	cache := NewOverrideCache(repo, time.Minute, pkg.NewFakeClock(time.Now()), nil)
+	return NewOverrideService(repo, cache), cache
+}
+
+func TestOverrideService_CreateValidation(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	tests := []struct {
+		name        string
+		subjectType string
+		subjectID   string
+		rps         float64
+		burst       int
+	}{
+		{"unknown subject type", "team", "7", 1, 1},
+		{"non-numeric user", domain.RateLimitSubjectUser, "alice", 1, 1},
+		{"zero user", domain.RateLimitSubjectUser, "0", 1, 1},
+		{"blank api key", domain.RateLimitSubjectAPIKey, " ", 1, 1},
+		{"zero rps", domain.RateLimitSubjectUser, "7", 0, 1},
+		{"zero burst", domain.RateLimitSubjectUser, "7", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateOverride(context.Background(), tt.subjectType, tt.subjectID, tt.rps, tt.burst)
+			if !domain.IsValidation(err) {
+				t.Errorf("CreateOverride() error = %v, want a validation error", err)
+			}
+		})
+	}
+}
+
+func TestOverrideService_WritesRefreshCache(t *testing.T) {
+	svc, cache := newTestService(t)
+	ctx := context.Background()
+
+	o, err := svc.CreateOverride(ctx, domain.RateLimitSubjectUser, " 7 ", 1, 2)
+	if err != nil {
+		t.Fatalf("CreateOverride: %v", err)
+	}
+	if o.SubjectID != "7" {
+		t.Errorf("SubjectID = %q, want it trimmed", o.SubjectID)
+	}
+	if got, ok := cache.Lookup("7"); !ok || got.RPS != 1 || got.Burst != 2 {
+		t.Errorf("Lookup(7) after create = %+v, %v; want rps 1, burst 2", got, ok)
+	}
+	if _, err := svc.CreateOverride(ctx, domain.RateLimitSubjectUser, "7", 5, 5); !domain.IsAlreadyExists(err) {
+		t.Errorf("second CreateOverride() error = %v, want already exists", err)
+	}
+
+	if _, err := svc.UpdateOverride(ctx, o.ID, 50, 100); err != nil {
+		t.Fatalf("UpdateOverride: %v", err)
+	}
+	if got, _ := cache.Lookup("7"); got.RPS != 50 || got.Burst != 100 {
+		t.Errorf("Lookup(7) after update = %+v, want rps 50, burst 100", got)
+	}
+
+	if err := svc.DeleteOverride(ctx, o.ID); err != nil {
+		t.Fatalf("DeleteOverride: %v", err)
+	}
+	if _, ok := cache.Lookup("7"); ok {
+		t.Error("Lookup(7) still finds the deleted override")
+	}
+	if err := svc.DeleteOverride(ctx, o.ID); !domain.IsNotFound(err) {
+		t.Errorf("second DeleteOverride() error = %v, want not found", err)
+	}
+}
+
+func TestOverrideCache_LookupBySubjectType(t *testing.T) {
+	svc, cache := newTestService(t)
+	ctx := context.Background()
+	if _, err := svc.CreateOverride(ctx, domain.RateLimitSubjectUser, "7", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreateOverride(ctx, domain.RateLimitSubjectAPIKey, "7", 9, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := cache.Lookup("apikey:7"); !ok || got.SubjectType != domain.RateLimitSubjectAPIKey {
+		t.Errorf("Lookup(apikey:7) = %+v, %v; want the API key's override", got, ok)
+	}
+	if got, ok := cache.Lookup("7"); !ok || got.SubjectType != domain.RateLimitSubjectUser {
+		t.Errorf("Lookup(7) = %+v, %v; want the user's override", got, ok)
+	}
+	if _, ok := cache.Lookup("8"); ok {
+		t.Error("Lookup(8) found an override for a user without one")
+	}
+}
+
+func TestOverrideCache_PeriodicRefresh(t *testing.T) {
+	repo := NewOverrideRepository(testutil.NewTestDB(t))
+	clock := pkg.NewFakeClock(time.Now())
+	cache := NewOverrideCache(repo, time.Minute, clock, nil)
+	cache.Start()
+	defer cache.Close()
+
+	// Written around the service, as another instance would.
+	if err := repo.Create(context.Background(), &domain.RateLimitOverride{SubjectType: domain.RateLimitSubjectUser, SubjectID: "7", RPS: 3, Burst: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup("7"); ok {
+		t.Fatal("Lookup(7) found the override before a refresh")
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1) // the loop re-arms its timer once the refresh is done
+	if _, ok := cache.Lookup("7"); !ok {
+		t.Error("Lookup(7) missed the override after the refresh interval")
+	}
+}
@@ -0,0 +1,233 @@
+package rbacsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/yaml"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// Limits and character sets of the rbac library, checked up front so a
+// document is rejected as a whole instead of failing halfway through Sync.
+const (
+	maxRoleIDLength   = 128
+	maxResourceLength = 256
+	maxActionLength   = 64
+)
+
+var (
+	roleIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_\-.]+$`)
+	resourcePattern = regexp.MustCompile(`^[A-Za-z0-9_\-./:*]+$`)
+	actionPattern   = regexp.MustCompile(`^[A-Za-z0-9_\-.*]+$`)
+)
+
+// Document is the desired RBAC state accepted by POST
+// /api/v1/admin/rbac/sync, as JSON or YAML.
+type Document struct {
+	Roles []RoleSpec `json:"roles"`
+	// Assignments, when given, lists users by email with the roles they
+	// must hold. Users not listed keep their roles.
+	Assignments []AssignmentSpec `json:"assignments"`
+}
+
+// RoleSpec is one role of a Document with all of its permissions.
+type RoleSpec struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Permissions maps a resource to its actions.
+	Permissions map[string][]string `json:"permissions"`
+}
+
+// AssignmentSpec gives the roles of the user with Email. Every role must be
+// declared in the same Document.
+type AssignmentSpec struct {
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+// RoleChange describes a created or updated role.
+type RoleChange struct {
+	ID string `json:"id"`
+	// Added and Removed are the permissions granted and revoked, by
+	// resource; a created role lists all of its permissions as Added.
+	Added   map[string][]string `json:"added,omitempty"`
+	Removed map[string][]string `json:"removed,omitempty"`
+	// DetailsChanged is set when the name or description was updated.
+	DetailsChanged bool `json:"details_changed,omitempty"`
+}
+
+// AssignmentChange is a role assigned to or removed from a user.
+type AssignmentChange struct {
+	Email  string `json:"email"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// Result reports the changes a sync made, or with DryRun would make. Every
+// list is empty when the current state already matches the document.
+type Result struct {
+	DryRun     bool               `json:"dry_run"`
+	Created    []RoleChange       `json:"created"`
+	Updated    []RoleChange       `json:"updated"`
+	Removed    []string           `json:"removed"`
+	Assigned   []AssignmentChange `json:"assigned"`
+	Unassigned []AssignmentChange `json:"unassigned"`
+	// Warnings lists assignments that were skipped, such as unknown emails.
+	Warnings []string `json:"warnings"`
+}
+
+// ParseDocument decodes a YAML (by contentType) or JSON document and
+// validates it. Resources are normalized the way the rbac library stores
+// them and actions are deduplicated and sorted, so an unchanged document
+// always produces an empty diff. Invalid documents return pkg.ParamErrors
+// keyed by field path, e.g. "roles[1].permissions.users[0]".
+func ParseDocument(body []byte, contentType string) (*Document, error) {
+	if isYAML(contentType) {
+		m, err := yaml.Parser().Unmarshal(body)
+		if err != nil {
+			return nil, pkg.ParamErrors{"body": "Must be a valid YAML document"}
+		}
+		if body, err = json.Marshal(m); err != nil {
+			return nil, pkg.ParamErrors{"body": "Must be a valid YAML document"}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, pkg.ParamErrors{fieldPath(typeErr.Field): "Has the wrong type"}
+		}
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, pkg.ParamErrors{strings.Trim(field, `"`): "Unknown field"}
+		}
+		return nil, pkg.ParamErrors{"body": "Must be a JSON object"}
+	}
+	if errs := doc.normalize(); len(errs) > 0 {
+		return nil, errs
+	}
+	return &doc, nil
+}
+
+// normalize validates doc in place and returns its errors by field path.
+func (doc *Document) normalize() pkg.ParamErrors {
+	errs := pkg.ParamErrors{}
+	declared := make(map[string]bool, len(doc.Roles))
+	for i := range doc.Roles {
+		role := &doc.Roles[i]
+		path := fmt.Sprintf("roles[%d]", i)
+		role.ID = strings.TrimSpace(role.ID)
+		switch {
+		case role.ID == "":
+			errs[path+".id"] = "This field is required"
+		case len(role.ID) > maxRoleIDLength || !roleIDPattern.MatchString(role.ID):
+			errs[path+".id"] = "Must be at most 128 letters, digits, '_', '-' or '.'"
+		case declared[role.ID]:
+			errs[path+".id"] = "Duplicate role id"
+		}
+		declared[role.ID] = true
+
+		perms := make(map[string][]string, len(role.Permissions))
+		for resource, actions := range role.Permissions {
+			key := path + ".permissions." + resource
+			normalized, ok := normalizeResource(resource)
+			if !ok {
+				errs[key] = "Must be at most 256 letters, digits or '_-./:*', without '.' or '..' segments"
+				continue
+			}
+			for j, action := range actions {
+				if action == "" || len(action) > maxActionLength || !actionPattern.MatchString(action) {
+					errs[fmt.Sprintf("%s[%d]", key, j)] = "Must be at most 64 letters, digits or '_-.*'"
+				}
+			}
+			if len(actions) > 0 {
+				perms[normalized] = append(perms[normalized], actions...)
+			}
+		}
+		for resource, actions := range perms {
+			slices.Sort(actions)
+			perms[resource] = slices.Compact(actions)
+		}
+		role.Permissions = perms
+	}
+
+	seen := make(map[string]bool, len(doc.Assignments))
+	for i := range doc.Assignments {
+		a := &doc.Assignments[i]
+		path := fmt.Sprintf("assignments[%d]", i)
+		a.Email = strings.TrimSpace(a.Email)
+		switch {
+		case a.Email == "":
+			errs[path+".email"] = "This field is required"
+		case seen[a.Email]:
+			errs[path+".email"] = "Duplicate email"
+		}
+		seen[a.Email] = true
+		for j, role := range a.Roles {
+			if !declared[role] {
+				errs[fmt.Sprintf("%s.roles[%d]", path, j)] = "Must be a role declared in roles"
+			}
+		}
+		slices.Sort(a.Roles)
+		a.Roles = slices.Compact(a.Roles)
+	}
+	return errs
+}
+
+// normalizeResource applies the rbac library's normalization (collapsed
+// and trailing slashes stripped) and validation to a resource.
+func normalizeResource(resource string) (string, bool) {
+	for strings.Contains(resource, "//") {
+		resource = strings.ReplaceAll(resource, "//", "/")
+	}
+	if len(resource) > 1 {
+		resource = strings.TrimSuffix(resource, "/")
+	}
+	if resource == "" || len(resource) > maxResourceLength || !resourcePattern.MatchString(resource) {
+		return "", false
+	}
+	for _, segment := range strings.Split(resource, "/") {
+		if segment == "." || segment == ".." {
+			return "", false
+		}
+	}
+	return resource, true
+}
+
+// fieldPath rewrites a json.UnmarshalTypeError field such as
+// "roles.0.permissions" in the "roles[0].permissions" form of normalize.
+func fieldPath(field string) string {
+	var b strings.Builder
+	for i, segment := range strings.Split(field, ".") {
+		if strings.Trim(segment, "0123456789") == "" {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// isYAML reports whether contentType names a YAML media type.
+func isYAML(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package rbacsync
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+func TestParseDocument_YAML(t *testing.T) {
+	body := `
+roles:
+  - id: editor
+    name: Editor
+    permissions:
+      notes: [update, read, read]
+      /users//: [read]
+assignments:
+  - email: " alice@example.com "
+    roles: [editor]
+`
+	doc, err := ParseDocument([]byte(body), "application/yaml; charset=utf-8")
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	want := map[string][]string{"notes": {"read", "update"}, "/users": {"read"}}
+	if !reflect.DeepEqual(doc.Roles[0].Permissions, want) {
+		t.Errorf("permissions = %v, want %v", doc.Roles[0].Permissions, want)
+	}
+	if doc.Assignments[0].Email != "alice@example.com" {
+		t.Errorf("email = %q, want it trimmed", doc.Assignments[0].Email)
+	}
+}
+
+func TestParseDocument_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		wantKeys    []string
+	}{
+		{"not an object", `[]`, "application/json", []string{"body"}},
+		{"invalid yaml", "roles: [", "text/yaml", []string{"body"}},
+		{"unknown field", `{"roles":[],"users":[]}`, "application/json", []string{"users"}},
+		{"wrong type", `{"roles":[{"id":"a","permissions":{"notes":"read"}}]}`, "application/json", []string{"roles[0].permissions.notes"}},
+		{"bad role", `{"roles":[{"id":"ok"},{"id":""},{"id":"a b"},{"id":"ok"}]}`, "application/json",
+			[]string{"roles[1].id", "roles[2].id", "roles[3].id"}},
+		{"bad permission", `{"roles":[{"id":"a","permissions":{"notes/..":["read"],"users":["read","re ad"]}}]}`, "application/json",
+			[]string{"roles[0].permissions.notes/..", "roles[0].permissions.users[1]"}},
+		{"bad assignment", `{"roles":[{"id":"a"}],"assignments":[{"email":"x@example.com","roles":["a","b"]},{"email":""},{"email":"x@example.com"}]}`, "application/json",
+			[]string{"assignments[0].roles[1]", "assignments[1].email", "assignments[2].email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDocument([]byte(tt.body), tt.contentType)
+			var errs pkg.ParamErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("error = %v, want pkg.ParamErrors", err)
+			}
+			if len(errs) != len(tt.wantKeys) {
+				t.Errorf("errors = %v, want keys %v", errs, tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if errs[key] == "" {
+					t.Errorf("errors = %v, want one for %q", errs, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package rbacsync
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
+)
+
+// maxDocumentSize caps the policy document read by Sync.
+const maxDocumentSize = 1 << 20
+
+// SyncHandler handles the RBAC policy sync API.
+type SyncHandler struct {
+	svc Service
+}
+
+// NewSyncHandler creates a new SyncHandler with the given service.
+func NewSyncHandler(svc Service) *SyncHandler {
+	return &SyncHandler{svc: svc}
+}
+
+// Sync handles POST /api/v1/admin/rbac/sync. The body is a Document in JSON
+// or, with a YAML Content-Type, YAML. The query parameters prune and
+// dry_run set the matching Options.
+func (h *SyncHandler) Sync(c *gin.Context) {
+	opts, errs := parseOptions(c)
+	if len(errs) > 0 {
+		pkg.ValidationError(c, errs)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxDocumentSize))
+	if err != nil {
+		pkg.ValidationError(c, pkg.ParamErrors{"body": "Must be a document of at most 1 MiB"})
+		return
+	}
+	doc, err := ParseDocument(body, c.ContentType())
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
+
+	res, err := h.svc.Sync(c.Request.Context(), doc, opts)
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+	if !opts.DryRun {
+		by := requestctx.Actor(c)
+		slog.InfoContext(c.Request.Context(), "rbac policy synced",
+			slog.String("by", by),
+			slog.Bool("prune", opts.Prune),
+			slog.Int("created", len(res.Created)),
+			slog.Int("updated", len(res.Updated)),
+			slog.Int("removed", len(res.Removed)),
+			slog.Int("assigned", len(res.Assigned)),
+			slog.Int("unassigned", len(res.Unassigned)),
+		)
+	}
+	pkg.Success(c, res)
+}
+
+// parseOptions reads the prune and dry_run query parameters.
+func parseOptions(c *gin.Context) (Options, pkg.ParamErrors) {
+	var opts Options
+	errs := pkg.ParamErrors{}
+	for name, dst := range map[string]*bool{"prune": &opts.Prune, "dry_run": &opts.DryRun} {
+		raw, ok := c.GetQuery(name)
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs[name] = "Must be true or false"
+			continue
+		}
+		*dst = v
+	}
+	return opts, errs
+}
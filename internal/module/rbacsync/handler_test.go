@@ -0,0 +1,85 @@
+package rbacsync
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+func setupSyncRouter(t *testing.T) (*fakeRBAC, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, svc := newTestSync()
+	r := gin.New()
+	NewModule(NewSyncHandler(svc)).RegisterRoutes(r.Group("/api"), nil)
+	return store, r
+}
+
+func decodeResult(t *testing.T, body []byte) Result {
+	t.Helper()
+	var resp struct {
+		Data Result `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp.Data
+}
+
+func TestSyncHandler_YAMLDryRunThenApply(t *testing.T) {
+	store, r := setupSyncRouter(t)
+	body := "roles:\n  - id: viewer\n    permissions:\n      notes: [read]\n"
+
+	req := testutil.NewJSONRequest(t, http.MethodPost, "/api/admin/rbac/sync?dry_run=true", body)
+	req.Header.Set("Content-Type", "application/yaml")
+	w := testutil.Serve(r, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if res := decodeResult(t, w.Body.Bytes()); !res.DryRun || len(res.Created) != 1 {
+		t.Errorf("dry run = %+v, want viewer created", res)
+	}
+	if len(store.roles) != 0 {
+		t.Fatalf("dry run created roles %v", store.roles)
+	}
+
+	req = testutil.NewJSONRequest(t, http.MethodPost, "/api/admin/rbac/sync", body)
+	req.Header.Set("Content-Type", "application/yaml")
+	if w := testutil.Serve(r, req); w.Code != http.StatusOK || store.roles["viewer"] == nil {
+		t.Errorf("apply status = %d, roles = %v; want viewer created", w.Code, store.roles)
+	}
+}
+
+func TestSyncHandler_ValidationErrors(t *testing.T) {
+	_, r := setupSyncRouter(t)
+
+	tests := []struct {
+		name, path, body string
+		wantKey          string
+	}{
+		{"bad option", "/api/admin/rbac/sync?prune=maybe", `{"roles":[]}`, "prune"},
+		{"bad document", "/api/admin/rbac/sync", `{"roles":[{"id":""}]}`, "roles[0].id"},
+		{"too large", "/api/admin/rbac/sync", `{"roles":[],"x":"` + strings.Repeat("a", maxDocumentSize) + `"}`, "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, tt.path, tt.body))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+			var resp pkg.ValidationErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Errors[tt.wantKey] == "" {
+				t.Errorf("errors = %v, want one for %q", resp.Errors, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package rbacsync
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/middleware"
+)
+
+// SyncModule implements the app.Module interface for the RBAC policy sync
+// API. It is only registered when RBAC is enabled.
+type SyncModule struct {
+	handler *SyncHandler
+}
+
+// NewModule creates a new SyncModule with the given handler.
+// Panics if h is nil.
+func NewModule(h *SyncHandler) *SyncModule {
+	if h == nil {
+		panic("rbacsync.NewModule: handler must not be nil")
+	}
+	return &SyncModule{handler: h}
+}
+
+// RegisterRoutes registers the sync API route.
+func (m *SyncModule) RegisterRoutes(api *gin.RouterGroup, _ *gin.RouterGroup) {
+	api.POST("/admin/rbac/sync", m.handler.Sync)
+}
+
+// Policies requires admin:update, on top of the admin:read every
+// /api/v1/admin route needs, as a sync can rewrite every role.
+func (m *SyncModule) Policies() []middleware.Policy {
+	return []middleware.Policy{
+		{PathPrefix: "/api/v1/admin/rbac", Method: http.MethodPost, Resource: "admin", Action: "update"},
+	}
+}
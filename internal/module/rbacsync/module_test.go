@@ -0,0 +1,36 @@
+package rbacsync
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSyncModuleRegisterRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	NewModule(&SyncHandler{}).RegisterRoutes(r.Group("/api"), r.Group("/"))
+
+	routes := r.Routes()
+	if len(routes) != 1 || routes[0].Method != http.MethodPost || routes[0].Path != "/api/admin/rbac/sync" {
+		t.Errorf("routes = %+v, want only POST /api/admin/rbac/sync", routes)
+	}
+}
+
+func TestSyncModulePolicies(t *testing.T) {
+	policies := NewModule(&SyncHandler{}).Policies()
+	if len(policies) != 1 || policies[0].Resource != "admin" || policies[0].Action != "update" || policies[0].Method != http.MethodPost {
+		t.Errorf("Policies() = %+v, want POST admin:update", policies)
+	}
+}
+
+func TestNewModule_NilHandlerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for nil handler")
+		}
+	}()
+	NewModule(nil)
+}
@@ -0,0 +1,216 @@
+package rbacsync
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Options controls how Sync applies a Document.
+type Options struct {
+	// Prune deletes roles missing from the document and, for every user in
+	// its assignments, removes roles not listed for them.
+	Prune bool
+	// DryRun computes the changes without applying them.
+	DryRun bool
+}
+
+// Service reconciles the RBAC store with a declarative Document.
+type Service interface {
+	// Sync makes the roles, their permissions and the listed users'
+	// assignments match doc, which must come from ParseDocument. Roles and
+	// permissions not in doc are kept unless opts.Prune is set. Unknown
+	// emails are reported as warnings, not errors. Applying the same
+	// document twice yields an empty Result the second time.
+	Sync(ctx context.Context, doc *Document, opts Options) (*Result, error)
+}
+
+// syncService implements Service.
+type syncService struct {
+	rbac     rbac.Service
+	userRepo domain.UserRepository
+}
+
+// NewService creates a new Service on the given RBAC store, resolving
+// assignment emails through userRepo.
+func NewService(rbacSvc rbac.Service, userRepo domain.UserRepository) Service {
+	return &syncService{rbac: rbacSvc, userRepo: userRepo}
+}
+
+// Sync implements Service. Changes are applied in document order and stop
+// at the first storage error; the document is validated up front, so such
+// an error means the store failed, and re-running the sync completes it.
+func (s *syncService) Sync(ctx context.Context, doc *Document, opts Options) (*Result, error) {
+	res := &Result{
+		DryRun:     opts.DryRun,
+		Created:    []RoleChange{},
+		Updated:    []RoleChange{},
+		Removed:    []string{},
+		Assigned:   []AssignmentChange{},
+		Unassigned: []AssignmentChange{},
+		Warnings:   []string{},
+	}
+
+	existing, err := s.rbac.ListRoles()
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	current := make(map[string]*rbac.Role, len(existing))
+	for _, role := range existing {
+		current[role.ID] = role
+	}
+
+	for _, spec := range doc.Roles {
+		if err := s.syncRole(spec, current[spec.ID], opts.DryRun, res); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Prune {
+		declared := make(map[string]bool, len(doc.Roles))
+		for _, spec := range doc.Roles {
+			declared[spec.ID] = true
+		}
+		for _, id := range slices.Sorted(maps.Keys(current)) {
+			if declared[id] {
+				continue
+			}
+			if !opts.DryRun {
+				if err := s.rbac.DeleteRole(id); err != nil {
+					return nil, fmt.Errorf("delete role %q: %w", id, err)
+				}
+			}
+			res.Removed = append(res.Removed, id)
+		}
+	}
+
+	for _, a := range doc.Assignments {
+		if err := s.syncAssignment(ctx, a, opts, res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// syncRole creates spec or updates cur, which is nil for a new role, to
+// match it and records the change in res.
+func (s *syncService) syncRole(spec RoleSpec, cur *rbac.Role, dryRun bool, res *Result) error {
+	if cur == nil {
+		if !dryRun {
+			if err := s.rbac.CreateRole(spec.ID, spec.Name, spec.Description); err != nil {
+				return fmt.Errorf("create role %q: %w", spec.ID, err)
+			}
+			for _, resource := range slices.Sorted(maps.Keys(spec.Permissions)) {
+				if err := s.rbac.AddRolePermissions(spec.ID, resource, spec.Permissions[resource]); err != nil {
+					return fmt.Errorf("add permissions of role %q on %q: %w", spec.ID, resource, err)
+				}
+			}
+		}
+		res.Created = append(res.Created, RoleChange{ID: spec.ID, Added: spec.Permissions})
+		return nil
+	}
+
+	change := RoleChange{
+		ID:             spec.ID,
+		Added:          diffPermissions(spec.Permissions, cur.Permissions),
+		Removed:        diffPermissions(cur.Permissions, spec.Permissions),
+		DetailsChanged: spec.Name != cur.Name || spec.Description != cur.Description,
+	}
+	if len(change.Added) == 0 && len(change.Removed) == 0 && !change.DetailsChanged {
+		return nil
+	}
+	if !dryRun {
+		if change.DetailsChanged {
+			if err := s.rbac.UpdateRole(spec.ID, spec.Name, spec.Description); err != nil {
+				return fmt.Errorf("update role %q: %w", spec.ID, err)
+			}
+		}
+		for _, resource := range slices.Sorted(maps.Keys(change.Added)) {
+			if err := s.rbac.AddRolePermissions(spec.ID, resource, change.Added[resource]); err != nil {
+				return fmt.Errorf("add permissions of role %q on %q: %w", spec.ID, resource, err)
+			}
+		}
+		for _, resource := range slices.Sorted(maps.Keys(change.Removed)) {
+			for _, action := range change.Removed[resource] {
+				if err := s.rbac.RemoveRolePermission(spec.ID, resource, action); err != nil {
+					return fmt.Errorf("remove permission %s:%s of role %q: %w", resource, action, spec.ID, err)
+				}
+			}
+		}
+	}
+	res.Updated = append(res.Updated, change)
+	return nil
+}
+
+// syncAssignment gives the user of a the roles it lists and, with
+// opts.Prune, takes away the others.
+func (s *syncService) syncAssignment(ctx context.Context, a AssignmentSpec, opts Options, res *Result) error {
+	user, err := s.userRepo.GetByEmail(ctx, a.Email)
+	if err != nil {
+		if domain.IsNotFound(err) {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("assignments: no user with email %q, skipped", a.Email))
+			return nil
+		}
+		return fmt.Errorf("look up user %q: %w", a.Email, err)
+	}
+	userID := strconv.FormatUint(uint64(user.ID), 10)
+
+	held, err := s.rbac.GetUserRoles(userID)
+	if err != nil {
+		return fmt.Errorf("get roles of user %s: %w", userID, err)
+	}
+	for _, role := range a.Roles {
+		if slices.Contains(held, role) {
+			continue
+		}
+		if !opts.DryRun {
+			if err := s.rbac.AssignRole(userID, role); err != nil {
+				return fmt.Errorf("assign role %q to user %s: %w", role, userID, err)
+			}
+		}
+		res.Assigned = append(res.Assigned, AssignmentChange{Email: a.Email, UserID: userID, Role: role})
+	}
+	if !opts.Prune {
+		return nil
+	}
+	slices.Sort(held)
+	for _, role := range held {
+		if slices.Contains(a.Roles, role) {
+			continue
+		}
+		if !opts.DryRun {
+			if err := s.rbac.UnassignRole(userID, role); err != nil {
+				return fmt.Errorf("unassign role %q from user %s: %w", role, userID, err)
+			}
+		}
+		res.Unassigned = append(res.Unassigned, AssignmentChange{Email: a.Email, UserID: userID, Role: role})
+	}
+	return nil
+}
+
+// diffPermissions returns the actions in a that are not in b, by resource,
+// or nil when there are none.
+func diffPermissions(a, b map[string][]string) map[string][]string {
+	var diff map[string][]string
+	for resource, actions := range a {
+		for _, action := range actions {
+			if slices.Contains(b[resource], action) {
+				continue
+			}
+			if diff == nil {
+				diff = make(map[string][]string)
+			}
+			diff[resource] = append(diff[resource], action)
+		}
+	}
+	for _, actions := range diff {
+		slices.Sort(actions)
+	}
+	return diff
+}
@@ -0,0 +1,263 @@
+package rbacsync
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/rbac"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// --- fakes ---
+
+// fakeRBAC is an in-memory rbac.Service covering the methods Sync uses. It
+// records every mutating call so tests can assert nothing was written.
+type fakeRBAC struct {
+	rbac.Service
+	roles     map[string]*rbac.Role
+	userRoles map[string][]string
+	calls     []string
+}
+
+func newFakeRBAC() *fakeRBAC {
+	return &fakeRBAC{roles: map[string]*rbac.Role{}, userRoles: map[string][]string{}}
+}
+
+func (f *fakeRBAC) ListRoles() ([]*rbac.Role, error) {
+	roles := make([]*rbac.Role, 0, len(f.roles))
+	for _, id := range slices.Sorted(maps.Keys(f.roles)) {
+		r := *f.roles[id]
+		r.Permissions = maps.Clone(r.Permissions)
+		roles = append(roles, &r)
+	}
+	return roles, nil
+}
+
+func (f *fakeRBAC) CreateRole(id, name, description string) error {
+	f.calls = append(f.calls, "CreateRole "+id)
+	f.roles[id] = &rbac.Role{ID: id, Name: name, Description: description, Permissions: map[string][]string{}}
+	return nil
+}
+
+func (f *fakeRBAC) UpdateRole(id, name, description string) error {
+	f.calls = append(f.calls, "UpdateRole "+id)
+	f.roles[id].Name, f.roles[id].Description = name, description
+	return nil
+}
+
+func (f *fakeRBAC) DeleteRole(id string) error {
+	f.calls = append(f.calls, "DeleteRole "+id)
+	delete(f.roles, id)
+	for user, roles := range f.userRoles {
+		f.userRoles[user] = slices.DeleteFunc(roles, func(r string) bool { return r == id })
+	}
+	return nil
+}
+
+func (f *fakeRBAC) AddRolePermissions(id, resource string, actions []string) error {
+	f.calls = append(f.calls, "AddRolePermissions "+id+" "+resource+" "+strings.Join(actions, ","))
+	perms := f.roles[id].Permissions
+	for _, a := range actions {
+		if !slices.Contains(perms[resource], a) {
+			perms[resource] = append(perms[resource], a)
+		}
+	}
+	return nil
+}
+
+func (f *fakeRBAC) RemoveRolePermission(id, resource, action string) error {
+	f.calls = append(f.calls, "RemoveRolePermission "+id+" "+resource+" "+action)
+	perms := f.roles[id].Permissions
+	perms[resource] = slices.DeleteFunc(perms[resource], func(a string) bool { return a == action })
+	if len(perms[resource]) == 0 {
+		delete(perms, resource)
+	}
+	return nil
+}
+
+func (f *fakeRBAC) GetUserRoles(userID string) ([]string, error) {
+	return slices.Clone(f.userRoles[userID]), nil
+}
+
+func (f *fakeRBAC) AssignRole(userID, roleID string) error {
+	f.calls = append(f.calls, "AssignRole "+userID+" "+roleID)
+	f.userRoles[userID] = append(f.userRoles[userID], roleID)
+	return nil
+}
+
+func (f *fakeRBAC) UnassignRole(userID, roleID string) error {
+	f.calls = append(f.calls, "UnassignRole "+userID+" "+roleID)
+	f.userRoles[userID] = slices.DeleteFunc(f.userRoles[userID], func(r string) bool { return r == roleID })
+	return nil
+}
+
+// fakeUserRepo resolves emails from a fixed set of users.
+type fakeUserRepo struct {
+	domain.UserRepository
+	users map[string]uint
+}
+
+func (f *fakeUserRepo) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	id, ok := f.users[email]
+	if !ok {
+		return nil, domain.NewAppError(domain.CodeNotFound, "user not found", nil)
+	}
+	u := &domain.User{Email: email}
+	u.ID = id
+	return u, nil
+}
+
+// --- helpers ---
+
+const samplePolicy = `{
+	"roles": [
+		{"id": "editor", "name": "Editor", "permissions": {"notes": ["update", "read", "create"]}},
+		{"id": "viewer", "name": "Viewer", "permissions": {"notes": ["read"], "users/": ["read"]}}
+	],
+	"assignments": [
+		{"email": "alice@example.com", "roles": ["editor"]},
+		{"email": "ghost@example.com", "roles": ["viewer"]}
+	]
+}`
+
+func newTestSync() (*fakeRBAC, Service) {
+	store := newFakeRBAC()
+	users := &fakeUserRepo{users: map[string]uint{"alice@example.com": 1, "bob@example.com": 2}}
+	return store, NewService(store, users)
+}
+
+func mustParse(t *testing.T, body string) *Document {
+	t.Helper()
+	doc, err := ParseDocument([]byte(body), "application/json")
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	return doc
+}
+
+func mustSync(t *testing.T, svc Service, body string, opts Options) *Result {
+	t.Helper()
+	res, err := svc.Sync(context.Background(), mustParse(t, body), opts)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	return res
+}
+
+func isEmpty(res *Result) bool {
+	return len(res.Created)+len(res.Updated)+len(res.Removed)+len(res.Assigned)+len(res.Unassigned) == 0
+}
+
+// --- tests ---
+
+func TestSync_CreatesRolesAndAssignments(t *testing.T) {
+	store, svc := newTestSync()
+	res := mustSync(t, svc, samplePolicy, Options{})
+
+	if len(res.Created) != 2 || res.Created[0].ID != "editor" || res.Created[1].ID != "viewer" {
+		t.Fatalf("Created = %+v, want editor and viewer", res.Created)
+	}
+	if got := store.roles["editor"].Permissions["notes"]; !reflect.DeepEqual(got, []string{"create", "read", "update"}) {
+		t.Errorf("editor notes actions = %v", got)
+	}
+	if _, ok := store.roles["viewer"].Permissions["users"]; !ok {
+		t.Errorf("viewer permissions = %v, want the normalized resource users", store.roles["viewer"].Permissions)
+	}
+	if want := []AssignmentChange{{Email: "alice@example.com", UserID: "1", Role: "editor"}}; !reflect.DeepEqual(res.Assigned, want) {
+		t.Errorf("Assigned = %+v, want %+v", res.Assigned, want)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "ghost@example.com") {
+		t.Errorf("Warnings = %v, want one for the unknown email", res.Warnings)
+	}
+}
+
+func TestSync_ReapplyIsEmpty(t *testing.T) {
+	store, svc := newTestSync()
+	mustSync(t, svc, samplePolicy, Options{Prune: true})
+	store.calls = nil
+
+	res := mustSync(t, svc, samplePolicy, Options{Prune: true})
+	if !isEmpty(res) {
+		t.Errorf("second sync = %+v, want no changes", res)
+	}
+	if len(store.calls) != 0 {
+		t.Errorf("second sync wrote %v", store.calls)
+	}
+}
+
+func TestSync_UpdatesWithoutPruneKeepsExtras(t *testing.T) {
+	store, svc := newTestSync()
+	mustSync(t, svc, samplePolicy, Options{})
+	store.userRoles["1"] = append(store.userRoles["1"], "legacy")
+	store.roles["legacy"] = &rbac.Role{ID: "legacy", Permissions: map[string][]string{}}
+
+	res := mustSync(t, svc, `{"roles":[{"id":"editor","name":"Editors","permissions":{"notes":["read","delete"]}}],
+		"assignments":[{"email":"alice@example.com","roles":["editor"]}]}`, Options{})
+
+	want := RoleChange{
+		ID:             "editor",
+		Added:          map[string][]string{"notes": {"delete"}},
+		Removed:        map[string][]string{"notes": {"create", "update"}},
+		DetailsChanged: true,
+	}
+	if len(res.Updated) != 1 || !reflect.DeepEqual(res.Updated[0], want) {
+		t.Fatalf("Updated = %+v, want %+v", res.Updated, want)
+	}
+	if store.roles["editor"].Name != "Editors" {
+		t.Errorf("editor name = %q, want Editors", store.roles["editor"].Name)
+	}
+	if store.roles["viewer"] == nil || store.roles["legacy"] == nil || !slices.Contains(store.userRoles["1"], "legacy") {
+		t.Error("roles or assignments outside the document were removed without prune")
+	}
+}
+
+func TestSync_Prune(t *testing.T) {
+	store, svc := newTestSync()
+	mustSync(t, svc, samplePolicy, Options{})
+	store.userRoles["1"] = append(store.userRoles["1"], "viewer")
+	store.userRoles["2"] = []string{"viewer"}
+
+	res := mustSync(t, svc, `{"roles":[{"id":"editor","name":"Editor","permissions":{"notes":["create","read","update"]}}],
+		"assignments":[{"email":"alice@example.com","roles":["editor"]}]}`, Options{Prune: true})
+
+	if !reflect.DeepEqual(res.Removed, []string{"viewer"}) {
+		t.Errorf("Removed = %v, want [viewer]", res.Removed)
+	}
+	if store.roles["viewer"] != nil {
+		t.Error("viewer role not deleted")
+	}
+	// Deleting viewer already took it from alice, so nothing is left to
+	// unassign; bob is not in the document and lost it the same way.
+	if len(res.Unassigned) != 0 {
+		t.Errorf("Unassigned = %+v, want none", res.Unassigned)
+	}
+
+	store.roles["legacy"] = &rbac.Role{ID: "legacy", Permissions: map[string][]string{}}
+	store.userRoles["1"] = append(store.userRoles["1"], "legacy")
+	res = mustSync(t, svc, `{"roles":[{"id":"editor","name":"Editor","permissions":{"notes":["create","read","update"]}},{"id":"legacy"}],
+		"assignments":[{"email":"alice@example.com","roles":["editor"]}]}`, Options{Prune: true})
+	if want := []AssignmentChange{{Email: "alice@example.com", UserID: "1", Role: "legacy"}}; !reflect.DeepEqual(res.Unassigned, want) {
+		t.Errorf("Unassigned = %+v, want %+v", res.Unassigned, want)
+	}
+}
+
+func TestSync_DryRunWritesNothing(t *testing.T) {
+	store, svc := newTestSync()
+	mustSync(t, svc, samplePolicy, Options{})
+	store.calls = nil
+
+	res := mustSync(t, svc, `{"roles":[{"id":"admin","permissions":{"users":["*"]}}],
+		"assignments":[{"email":"bob@example.com","roles":["admin"]}]}`, Options{Prune: true, DryRun: true})
+
+	if !res.DryRun || len(res.Created) != 1 || len(res.Removed) != 2 || len(res.Assigned) != 1 {
+		t.Errorf("dry run = %+v, want admin created, two roles removed and bob assigned", res)
+	}
+	if len(store.calls) != 0 {
+		t.Errorf("dry run wrote %v", store.calls)
+	}
+}
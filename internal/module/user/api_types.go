@@ -0,0 +1,20 @@
+package user
+
+import (
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/tsgen"
+)
+
+// APITypes lists the request and response bodies of the user API, for
+// the generated TypeScript definitions (see app.TypeScriptDefinitions).
+// List responses are PageResult<User>.
+func APITypes() []tsgen.Type {
+	return []tsgen.Type{
+		{Value: domain.User{}},
+		{Value: CreateUserRequest{}},
+		{Value: BulkCreateUsersRequest{}},
+		{Value: UpdateUserRequest{}},
+		{Value: PatchUserRequest{}},
+		{Value: UpdateUserResponse{}},
+	}
+}
@@ -1,13 +1,46 @@
 package user
 
+import (
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
 // CreateUserRequest represents the input for creating a new user.
+// The min and max values of the request structs mirror the domain length
+// limits, which tags cannot reference; dto_test.go keeps them in sync.
 type CreateUserRequest struct {
 	Name  string `json:"name" form:"name" binding:"required,min=2,max=100"`
-	Email string `json:"email" form:"email" binding:"required,email"`
+	Email string `json:"email" form:"email" binding:"required,email,max=254"`
+	Bio   string `json:"bio" form:"bio" binding:"max=2000"`
+}
+
+// BulkCreateUsersRequest represents the input for creating several users in
+// one request. Each entry is validated on its own, so one bad entry does not
+// reject the others.
+type BulkCreateUsersRequest struct {
+	Users []CreateUserRequest `json:"users" binding:"required,min=1,max=100"`
 }
 
 // UpdateUserRequest represents the input for updating an existing user.
 type UpdateUserRequest struct {
 	Name  string `json:"name" form:"name" binding:"required,min=2,max=100"`
-	Email string `json:"email" form:"email" binding:"required,email"`
+	Email string `json:"email" form:"email" binding:"required,email,max=254"`
+	Bio   string `json:"bio" form:"bio" binding:"max=2000"`
+}
+
+// PatchUserRequest represents the input for partially updating a user.
+// Omitted fields are left unchanged; "bio": null clears the bio, while a null
+// name or email is rejected by the service.
+type PatchUserRequest struct {
+	Name  pkg.Optional[string] `json:"name" binding:"omitempty,min=2,max=100"`
+	Email pkg.Optional[string] `json:"email" binding:"omitempty,email,max=254"`
+	Bio   pkg.Optional[string] `json:"bio" binding:"omitempty,max=2000"`
+}
+
+// UpdateUserResponse is the data of PUT and PATCH /api/v1/users/:id: the
+// user and whether the request modified it. Values equal to the stored ones
+// are not written, so UpdatedAt keeps its value and modified is false.
+type UpdateUserResponse struct {
+	*domain.User
+	Modified bool `json:"modified"`
 }
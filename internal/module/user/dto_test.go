@@ -0,0 +1,48 @@
+package user
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// bindingLimit returns the value of rule (e.g. "max") in the binding tag of
+// field of v, or -1 when the tag has none.
+func bindingLimit(t *testing.T, v any, field, rule string) int {
+	t.Helper()
+	f, ok := reflect.TypeOf(v).FieldByName(field)
+	if !ok {
+		t.Fatalf("%T has no field %s", v, field)
+	}
+	for _, part := range strings.Split(f.Tag.Get("binding"), ",") {
+		if s, ok := strings.CutPrefix(part, rule+"="); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				t.Fatalf("%T.%s binding %s=%q: %v", v, field, rule, s, err)
+			}
+			return n
+		}
+	}
+	return -1
+}
+
+func TestRequestBindingTags_MatchDomainLimits(t *testing.T) {
+	for _, v := range []any{CreateUserRequest{}, UpdateUserRequest{}, PatchUserRequest{}} {
+		for _, tc := range []struct {
+			field, rule string
+			want        int
+		}{
+			{"Name", "min", domain.NameMinLen},
+			{"Name", "max", domain.NameMaxLen},
+			{"Email", "max", domain.EmailMaxLen},
+			{"Bio", "max", domain.BioMaxLen},
+		} {
+			if got := bindingLimit(t, v, tc.field, tc.rule); got != tc.want {
+				t.Errorf("%T.%s binding %s = %d, want %d", v, tc.field, tc.rule, got, tc.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package user
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// exportBatchSize is how many users ExportUsers loads per query.
+const exportBatchSize = 100
+
+// exportHeader is the CSV header row written by ExportUsers.
+var exportHeader = []string{"id", "name", "email", "bio", "created_at"}
+
+// ExportUsers downloads the users matching the list page's filters and sort
+// as CSV, or as a JSON array with format=json. page and page_size are
+// ignored: the export covers every matching user, loaded exportBatchSize at
+// a time and flushed to the client after each batch.
+//
+// An htmx request only validates the query and answers with HX-Redirect to
+// the same URL, since htmx cannot swap a file download; the browser then
+// fetches the file with a plain GET.
+// GET /users/export
+func (h *UserPageHandler) ExportUsers(c *gin.Context) {
+	req, err := pkg.ParsePageRequest(c)
+	format := c.DefaultQuery("format", "csv")
+	valid := err == nil && (format == "csv" || format == "json")
+	delete(req.Filter, "format")
+
+	if c.GetHeader("HX-Request") == "true" {
+		if !valid {
+			c.Header("HX-Reswap", "none")
+			setShowToastHeader(c, "导出参数无效", "error")
+			c.Status(http.StatusOK)
+			return
+		}
+		c.Header("HX-Redirect", c.Request.URL.RequestURI())
+		c.Status(http.StatusOK)
+		return
+	}
+	if !valid {
+		c.HTML(http.StatusBadRequest, "errors/400.html", pageData(c, gin.H{}))
+		return
+	}
+
+	// Load the first batch before writing anything, so a failing query can
+	// still get an error page.
+	req.Page, req.PageSize = 1, exportBatchSize
+	ctx := c.Request.Context()
+	result, err := h.svc.ListUsers(ctx, req)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
+		return
+	}
+
+	filename := "users-" + h.clock.Now().Format("2006-01-02") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Status(http.StatusOK)
+
+	w := newExportWriter(c.Writer, format)
+	for {
+		h.hidePending(result)
+		if err := w.write(result.Items); err != nil {
+			slog.Warn("export users: write failed", slog.Any("error", err))
+			return
+		}
+		c.Writer.Flush()
+		if req.Page >= result.TotalPages || len(result.Items) == 0 {
+			break
+		}
+		req.Page++
+		if result, err = h.svc.ListUsers(ctx, req); err != nil {
+			// The status is already sent; a truncated file is all that is left.
+			slog.Error("export users: list failed", slog.Int("page", req.Page), slog.Any("error", err))
+			return
+		}
+	}
+	if err := w.close(); err != nil {
+		slog.Warn("export users: write failed", slog.Any("error", err))
+	}
+}
+
+// exportURL returns the ExportUsers URL for a list page query: the same
+// filters and sort, without the page selection.
+func exportURL(query url.Values) string {
+	q := url.Values{}
+	for key, values := range query {
+		switch key {
+		case "page", "page_size", "cursor":
+			continue
+		}
+		q[key] = values
+	}
+	if len(q) == 0 {
+		return "/users/export"
+	}
+	return "/users/export?" + q.Encode()
+}
+
+// exportWriter writes users in the format of an export.
+type exportWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer // nil for JSON
+	started bool
+	rows    int
+}
+
+func newExportWriter(w http.ResponseWriter, format string) *exportWriter {
+	e := &exportWriter{w: w}
+	if format == "csv" {
+		e.csv = csv.NewWriter(w)
+	}
+	return e
+}
+
+// write appends users, starting with the CSV header row or the opening
+// bracket of the JSON array on the first call.
+func (e *exportWriter) write(users []domain.User) error {
+	first := !e.started
+	e.started = true
+	if e.csv != nil {
+		if first {
+			if err := e.csv.Write(exportHeader); err != nil {
+				return err
+			}
+		}
+		for _, u := range users {
+			if err := e.csv.Write([]string{
+				strconv.FormatUint(uint64(u.ID), 10),
+				csvSafe(u.Name),
+				csvSafe(u.Email),
+				csvSafe(u.Bio),
+				u.CreatedAt.UTC().Format(time.RFC3339),
+			}); err != nil {
+				return err
+			}
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	var b bytes.Buffer
+	if first {
+		b.WriteByte('[')
+	}
+	for _, u := range users {
+		if e.rows > 0 {
+			b.WriteByte(',')
+		}
+		row, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		b.Write(row)
+		e.rows++
+	}
+	_, err := e.w.Write(b.Bytes())
+	return err
+}
+
+// close ends the export with the closing bracket of the JSON array.
+func (e *exportWriter) close() error {
+	if e.csv != nil {
+		return nil
+	}
+	_, err := e.w.Write([]byte("]"))
+	return err
+}
+
+// csvSafe keeps spreadsheet applications from evaluating a cell as a
+// formula by prefixing values that start with one of its trigger characters.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
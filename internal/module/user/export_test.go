@@ -0,0 +1,148 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// newExportHandler returns a page handler over a database seeded with n
+// users named "User <i>" plus Alice and Bob, on a clock reading 2024-03-05.
+func newExportHandler(t *testing.T, n int) *UserPageHandler {
+	t.Helper()
+	svc := NewUserService(NewUserRepository(testutil.NewTestDB(t)))
+	ctx := context.Background()
+	for i := 1; i <= n; i++ {
+		if _, err := svc.CreateUser(ctx, fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i), ""); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	for _, name := range []string{"Alice", "Bob"} {
+		if _, err := svc.CreateUser(ctx, name, strings.ToLower(name)+"@example.com", "=HYPERLINK(\"x\")"); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	return NewUserPageHandler(svc, WithClock(pkg.NewFakeClock(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC))))
+}
+
+func TestExportUsers_CSVHonorsFilters(t *testing.T) {
+	r := setupTestRouter(newExportHandler(t, 3))
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users/export?name__like=ali&page=3", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="users-2024-03-05.csv"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(rows) != 2 || strings.Join(rows[0], ",") != "id,name,email,bio,created_at" {
+		t.Fatalf("rows = %q, want the header and Alice", rows)
+	}
+	if rows[1][1] != "Alice" || rows[1][2] != "alice@example.com" {
+		t.Errorf("row = %q, want Alice", rows[1])
+	}
+	if rows[1][3] != `'=HYPERLINK("x")` {
+		t.Errorf("bio = %q, want the formula escaped", rows[1][3])
+	}
+}
+
+func TestExportUsers_StreamsEveryBatch(t *testing.T) {
+	r := setupTestRouter(newExportHandler(t, 2*exportBatchSize+5))
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users/export?format=json&sort=id:asc&name__like=User", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.HasSuffix(got, `users-2024-03-05.json"`) {
+		t.Errorf("Content-Disposition = %q, want a .json filename", got)
+	}
+	var users []domain.User
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(users) != 2*exportBatchSize+5 {
+		t.Fatalf("exported %d users, want %d", len(users), 2*exportBatchSize+5)
+	}
+	for i, u := range users {
+		if u.ID != uint(i+1) {
+			t.Fatalf("users[%d].ID = %d, want the requested id order", i, u.ID)
+		}
+	}
+}
+
+func TestExportUsers_EmptyJSON(t *testing.T) {
+	r := setupTestRouter(newExportHandler(t, 0))
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users/export?format=json&name__like=nobody", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Errorf("response = %d %q, want 200 []", w.Code, w.Body.String())
+	}
+}
+
+func TestExportUsers_InvalidQuery(t *testing.T) {
+	r := setupTestRouter(newExportHandler(t, 0))
+
+	w := testutil.Serve(r, httptest.NewRequest(http.MethodGet, "/users/export?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/export?cursor=a&page=2", nil)
+	req.Header.Set("HX-Request", "true")
+	w = testutil.Serve(r, req)
+	if w.Code != http.StatusOK || w.Header().Get("HX-Redirect") != "" || !strings.Contains(w.Header().Get("HX-Trigger"), "error") {
+		t.Errorf("htmx response = %d %v, want an error toast and no redirect", w.Code, w.Header())
+	}
+}
+
+func TestExportUsers_HTMXRedirectPassesCSRF(t *testing.T) {
+	h := newExportHandler(t, 0)
+	r := setupTestRouter(h)
+	r.Use(middleware.CSRF("test-csrf-secret-that-is-long-enough"))
+	r.GET("/csrf/users/export", h.ExportUsers)
+
+	req := httptest.NewRequest(http.MethodGet, "/csrf/users/export?name__like=ali", nil)
+	req.Header.Set("HX-Request", "true")
+	w := testutil.Serve(r, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 without a CSRF token", w.Code)
+	}
+	if got, want := w.Header().Get("HX-Redirect"), "/csrf/users/export?name__like=ali"; got != want {
+		t.Errorf("HX-Redirect = %q, want %q", got, want)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("body = %q, want none before the redirect", w.Body.String())
+	}
+}
+
+func TestExportURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                  "/users/export",
+		"page=2&page_size=5":                "/users/export",
+		"name__like=a&sort=name:asc&page=3": "/users/export?name__like=a&sort=name%3Aasc",
+	}
+	for query, want := range tests {
+		q, _ := url.ParseQuery(query)
+		if got := exportURL(q); got != want {
+			t.Errorf("exportURL(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
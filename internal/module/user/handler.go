@@ -1,22 +1,43 @@
 package user
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 )
 
 // UserHandler handles REST API requests for the user resource.
 type UserHandler struct {
-	svc domain.UserService
+	svc      domain.UserService
+	notifier domain.NotificationPublisher
+}
+
+// HandlerOption configures optional UserHandler behavior.
+type HandlerOption func(*UserHandler)
+
+// WithNotifier makes Delete publish a "user_deleted" notification to the
+// authenticated user who deleted the account.
+func WithNotifier(n domain.NotificationPublisher) HandlerOption {
+	return func(h *UserHandler) {
+		h.notifier = n
+	}
 }
 
 // NewUserHandler creates a new UserHandler with the given service.
-func NewUserHandler(svc domain.UserService) *UserHandler {
-	return &UserHandler{svc: svc}
+func NewUserHandler(svc domain.UserService, opts ...HandlerOption) *UserHandler {
+	h := &UserHandler{svc: svc}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Create handles POST /api/v1/users.
@@ -26,24 +47,50 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
-	user, err := h.svc.CreateUser(c.Request.Context(), req.Name, req.Email)
+	user, err := h.svc.CreateUser(c.Request.Context(), req.Name, req.Email, req.Bio)
 	if err != nil {
 		pkg.Error(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, pkg.Response{
+	pkg.JSON(c, http.StatusCreated, pkg.Response{
 		Code:    http.StatusCreated,
 		Message: "success",
 		Data:    user,
 	})
 }
 
+// BulkCreate handles POST /api/v1/users/bulk: every entry is created or
+// fails on its own, reported through pkg.Bulk.
+func (h *UserHandler) BulkCreate(c *gin.Context) {
+	var req BulkCreateUsersRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	result := pkg.NewBulkResult(len(req.Users))
+	for i := range req.Users {
+		entry := &req.Users[i]
+		if err := pkg.ValidateBulkItem(entry); err != nil {
+			result.Fail(i, nil, err)
+			continue
+		}
+		user, err := h.svc.CreateUser(c.Request.Context(), entry.Name, entry.Email, entry.Bio)
+		if err != nil {
+			result.Fail(i, nil, err)
+			continue
+		}
+		result.Succeed(i, user.ID)
+	}
+
+	pkg.Bulk(c, result)
+}
+
 // Get handles GET /api/v1/users/:id.
 func (h *UserHandler) Get(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
-		pkg.Error(c, domain.NewAppError(domain.CodeValidation, err.Error(), nil))
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
 		return
 	}
 
@@ -58,7 +105,11 @@ func (h *UserHandler) Get(c *gin.Context) {
 
 // List handles GET /api/v1/users.
 func (h *UserHandler) List(c *gin.Context) {
-	req := pkg.ParsePageRequest(c)
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		pkg.ValidationError(c, err)
+		return
+	}
 
 	result, err := h.svc.ListUsers(c.Request.Context(), req)
 	if err != nil {
@@ -71,9 +122,9 @@ func (h *UserHandler) List(c *gin.Context) {
 
 // Update handles PUT /api/v1/users/:id.
 func (h *UserHandler) Update(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
-		pkg.Error(c, domain.NewAppError(domain.CodeValidation, err.Error(), nil))
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
 		return
 	}
 
@@ -82,20 +133,55 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.svc.UpdateUser(c.Request.Context(), id, req.Name, req.Email)
+	user, modified, err := h.svc.UpdateUser(c.Request.Context(), id, req.Name, req.Email, req.Bio)
 	if err != nil {
 		pkg.Error(c, err)
 		return
 	}
 
-	pkg.Success(c, user)
+	respondUpdated(c, user, modified)
+}
+
+// Patch handles PATCH /api/v1/users/:id.
+func (h *UserHandler) Patch(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+		return
+	}
+
+	var req PatchUserRequest
+	if !pkg.BindAndValidate(c, &req) {
+		return
+	}
+
+	user, modified, err := h.svc.PatchUser(c.Request.Context(), id, domain.UserPatch{
+		Name:  req.Name.Patch(),
+		Email: req.Email.Patch(),
+		Bio:   req.Bio.Patch(),
+	})
+	if err != nil {
+		pkg.Error(c, err)
+		return
+	}
+
+	respondUpdated(c, user, modified)
+}
+
+// respondUpdated sends the result of an update. An update that modified
+// nothing keeps the cached user responses.
+func respondUpdated(c *gin.Context, user *domain.User, modified bool) {
+	if !modified {
+		middleware.SkipCacheInvalidation(c)
+	}
+	pkg.Success(c, UpdateUserResponse{User: user, Modified: modified})
 }
 
 // Delete handles DELETE /api/v1/users/:id.
 func (h *UserHandler) Delete(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
-		pkg.Error(c, domain.NewAppError(domain.CodeValidation, err.Error(), nil))
+		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
 		return
 	}
 
@@ -103,6 +189,29 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		pkg.Error(c, err)
 		return
 	}
+	h.notifyDeleted(c, id)
 
 	pkg.Success(c, nil)
 }
+
+// notifyDeleted tells the requesting user that user id was deleted. It is
+// best-effort: anonymous requests are skipped and failures only logged, so
+// the deletion itself is never reported as failed.
+func (h *UserHandler) notifyDeleted(c *gin.Context, id uint) {
+	if h.notifier == nil {
+		return
+	}
+	raw, ok := requestctx.UserID(c)
+	if !ok {
+		return
+	}
+	actor, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil || actor == 0 {
+		return
+	}
+	ctx := c.Request.Context()
+	err = h.notifier.Publish(ctx, uint(actor), "user_deleted", "User deleted", fmt.Sprintf("User #%d was deleted.", id))
+	if err != nil {
+		slog.WarnContext(ctx, "publish user_deleted notification failed", slog.Uint64("user_id", uint64(id)), slog.Any("error", err))
+	}
+}
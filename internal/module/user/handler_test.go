@@ -1,16 +1,21 @@
 package user
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
 
+	"github.com/simp-lee/gobase/internal/contract"
 	"github.com/simp-lee/gobase/internal/domain"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/testutil"
 )
 
 // setupAPIRouter creates a gin engine with REST API routes for handler testing.
@@ -20,9 +25,11 @@ func setupAPIRouter(h *UserHandler) *gin.Engine {
 
 	api := r.Group("/api/v1/users")
 	api.POST("", h.Create)
+	api.POST("/bulk", h.BulkCreate)
 	api.GET("", h.List)
 	api.GET("/:id", h.Get)
 	api.PUT("/:id", h.Update)
+	api.PATCH("/:id", h.Patch)
 	api.DELETE("/:id", h.Delete)
 
 	return r
@@ -34,10 +41,7 @@ func TestUserHandler_Create(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", body))
 
 	if w.Code != http.StatusCreated {
 		t.Fatalf("expected status 201, got %d", w.Code)
@@ -62,10 +66,7 @@ func TestUserHandler_Create_ValidationError(t *testing.T) {
 
 	// Missing required fields
 	body := `{"name":"","email":""}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", body))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
@@ -99,16 +100,61 @@ func TestUserHandler_Create_ServiceError(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com"}`
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users", body))
 
 	if w.Code != http.StatusConflict {
 		t.Fatalf("expected status 409, got %d", w.Code)
 	}
 }
 
+func TestUserHandler_BulkCreate(t *testing.T) {
+	svc := newMockService()
+	r := setupAPIRouter(NewUserHandler(svc))
+
+	body := `{"users":[{"name":"Alice","email":"alice@example.com"},{"name":"B","email":"not-an-email"},{"name":"Carol","email":"carol@example.com"}]}`
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users/bulk", body))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status 207, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data pkg.BulkResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Data.Total != 3 || resp.Data.Succeeded != 2 || resp.Data.Failed != 1 {
+		t.Fatalf("counts = %d/%d/%d, want 3 total, 2 succeeded, 1 failed", resp.Data.Total, resp.Data.Succeeded, resp.Data.Failed)
+	}
+	failed := resp.Data.Items[1]
+	if failed.Index != 1 || failed.Status != pkg.BulkItemFailed || failed.Error == nil ||
+		failed.Error.Fields["name"] == "" || failed.Error.Fields["email"] == "" {
+		t.Errorf("item 1 = %+v, want name and email field errors", failed)
+	}
+	if len(svc.users) != 2 {
+		t.Errorf("created %d users, want 2", len(svc.users))
+	}
+}
+
+func TestUserHandler_BulkCreate_AllFailed(t *testing.T) {
+	svc := newMockService()
+	svc.createErr = domain.NewAppError(domain.CodeAlreadyExists, "email already exists", nil)
+	r := setupAPIRouter(NewUserHandler(svc))
+
+	body := `{"users":[{"name":"Alice","email":"alice@example.com"}]}`
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users/bulk", body))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+
+	// An empty list is rejected before any item is tried.
+	w = testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPost, "/api/v1/users/bulk", `{"users":[]}`))
+	var resp pkg.ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusBadRequest || resp.Errors["users"] == "" {
+		t.Errorf("empty list: status %d, errors %v; want a users validation error", w.Code, resp.Errors)
+	}
+}
+
 func TestUserHandler_Get(t *testing.T) {
 	svc := newMockService()
 	// Seed a user
@@ -120,9 +166,7 @@ func TestUserHandler_Get(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users/1", nil))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
@@ -142,9 +186,7 @@ func TestUserHandler_Get_NotFound(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/999", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users/999", nil))
 
 	if w.Code != http.StatusNotFound {
 		t.Fatalf("expected status 404, got %d", w.Code)
@@ -156,12 +198,19 @@ func TestUserHandler_Get_InvalidID(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/abc", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	for _, id := range []string{"abc", "0"} {
+		w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users/"+id, nil))
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("expected status 400, got %d", w.Code)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("id %q: expected status 400, got %d", id, w.Code)
+		}
+		var resp pkg.ValidationErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("id %q: unmarshal: %v", id, err)
+		}
+		if resp.Message != "validation error" || len(resp.Errors) != 1 || resp.Errors["id"] != pkg.InvalidIDMessage {
+			t.Errorf("id %q: body = %+v, want a validation error for id", id, resp)
+		}
 	}
 }
 
@@ -180,9 +229,7 @@ func TestUserHandler_List(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?page=1&page_size=10", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users?page=1&page_size=10", nil))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
@@ -197,6 +244,26 @@ func TestUserHandler_List(t *testing.T) {
 	}
 }
 
+func TestUserHandler_List_ConflictingParams(t *testing.T) {
+	svc := newMockService()
+	h := NewUserHandler(svc)
+	r := setupAPIRouter(h)
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users?page=1&page=2&sort=name:asc,name:desc", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+
+	var resp pkg.ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Errors["page"] == "" || resp.Errors["sort"] == "" {
+		t.Errorf("expected per-parameter errors for page and sort, got %v", resp.Errors)
+	}
+}
+
 func TestUserHandler_List_PaginationParams(t *testing.T) {
 	svc := newMockService()
 	for i := uint(1); i <= 10; i++ {
@@ -209,9 +276,7 @@ func TestUserHandler_List_PaginationParams(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?page=2&page_size=5", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users?page=2&page_size=5", nil))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
@@ -240,9 +305,7 @@ func TestUserHandler_List_ServiceError(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodGet, "/api/v1/users", nil))
 
 	if w.Code != http.StatusInternalServerError {
 		t.Fatalf("expected status 500, got %d", w.Code)
@@ -260,10 +323,7 @@ func TestUserHandler_Update(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"Alice Updated","email":"alice2@example.com"}`
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/1", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPut, "/api/v1/users/1", body))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
@@ -284,10 +344,7 @@ func TestUserHandler_Update_InvalidID(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com"}`
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/abc", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPut, "/api/v1/users/abc", body))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
@@ -300,10 +357,7 @@ func TestUserHandler_Update_ValidationError(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"","email":"invalid"}`
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/1", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPut, "/api/v1/users/1", body))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
@@ -330,10 +384,57 @@ func TestUserHandler_Update_NotFound(t *testing.T) {
 	r := setupAPIRouter(h)
 
 	body := `{"name":"Alice","email":"alice@example.com"}`
-	req := httptest.NewRequest(http.MethodPut, "/api/v1/users/999", strings.NewReader(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPut, "/api/v1/users/999", body))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_Patch(t *testing.T) {
+	svc := NewUserService(newMockRepo())
+	created, err := svc.CreateUser(context.Background(), "Alice", "alice@example.com", "hello")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	r := setupAPIRouter(NewUserHandler(svc))
+	path := "/api/v1/users/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	patch := func(body string) (*httptest.ResponseRecorder, *domain.User) {
+		w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPatch, path, body))
+		u, _ := svc.GetUser(context.Background(), created.ID)
+		return w, u
+	}
+
+	w, u := patch(`{"name":"Alice Patched"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch name: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if u.Name != "Alice Patched" || u.Email != "alice@example.com" || u.Bio != "hello" {
+		t.Errorf("patch name: user = %+v; want only name changed", u)
+	}
+
+	w, u = patch(`{"bio":null}`)
+	if w.Code != http.StatusOK || u.Bio != "" {
+		t.Errorf("null bio: status = %d, bio = %q; want 200 and cleared", w.Code, u.Bio)
+	}
+
+	w, u = patch(`{}`)
+	if w.Code != http.StatusOK || u.Name != "Alice Patched" {
+		t.Errorf("empty patch: status = %d, user = %+v; want 200 and unchanged", w.Code, u)
+	}
+
+	for _, body := range []string{`{"name":null}`, `{"email":null}`, `{"name":"A"}`, `{"email":"invalid"}`} {
+		if w, _ := patch(body); w.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestUserHandler_Patch_NotFound(t *testing.T) {
+	r := setupAPIRouter(NewUserHandler(newMockService()))
+
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodPatch, "/api/v1/users/999", `{"bio":null}`))
 
 	if w.Code != http.StatusNotFound {
 		t.Fatalf("expected status 404, got %d", w.Code)
@@ -350,23 +451,72 @@ func TestUserHandler_Delete(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/1", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodDelete, "/api/v1/users/1", nil))
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", w.Code)
 	}
 }
 
+// recordingPublisher records Publish calls and returns err.
+type recordingPublisher struct {
+	userIDs []uint
+	kinds   []string
+	err     error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, userID uint, kind, _, _ string) error {
+	p.userIDs = append(p.userIDs, userID)
+	p.kinds = append(p.kinds, kind)
+	return p.err
+}
+
+func TestUserHandler_Delete_NotifiesActor(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     string
+		publisher *recordingPublisher
+		wantCalls int
+	}{
+		{"authenticated", "42", &recordingPublisher{}, 1},
+		{"anonymous", "", &recordingPublisher{}, 0},
+		{"publish failure keeps the delete", "42", &recordingPublisher{err: errors.New("db down")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newMockService()
+			svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+			h := NewUserHandler(svc, WithNotifier(tt.publisher))
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.DELETE("/api/v1/users/:id", func(c *gin.Context) {
+				if tt.actor != "" {
+					ginx.SetUserID(c, tt.actor)
+				}
+				h.Delete(c)
+			})
+
+			w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodDelete, "/api/v1/users/1", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			if len(tt.publisher.userIDs) != tt.wantCalls {
+				t.Fatalf("Publish called %d times, want %d", len(tt.publisher.userIDs), tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && (tt.publisher.userIDs[0] != 42 || tt.publisher.kinds[0] != "user_deleted") {
+				t.Errorf("Publish(%d, %q), want (42, user_deleted)", tt.publisher.userIDs[0], tt.publisher.kinds[0])
+			}
+		})
+	}
+}
+
 func TestUserHandler_Delete_NotFound(t *testing.T) {
 	svc := newMockService()
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/999", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodDelete, "/api/v1/users/999", nil))
 
 	if w.Code != http.StatusNotFound {
 		t.Fatalf("expected status 404, got %d", w.Code)
@@ -378,11 +528,57 @@ func TestUserHandler_Delete_InvalidID(t *testing.T) {
 	h := NewUserHandler(svc)
 	r := setupAPIRouter(h)
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/abc", nil)
-	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	w := testutil.Serve(r, testutil.NewJSONRequest(t, http.MethodDelete, "/api/v1/users/abc", nil))
 
 	if w.Code != http.StatusBadRequest {
 		t.Fatalf("expected status 400, got %d", w.Code)
 	}
 }
+
+// TestUserHandler_Contracts pins the response shape of every user endpoint
+// to testdata/contracts (see internal/contract).
+func TestUserHandler_Contracts(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		setup  func(*mockUserService)
+	}{
+		{name: "user_create", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"Alice","email":"alice@example.com","bio":"hi"}`},
+		{name: "user_create_validation_error", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"","email":"bad"}`},
+		{name: "user_create_conflict", method: http.MethodPost, path: "/api/v1/users", body: `{"name":"Alice","email":"alice@example.com"}`,
+			setup: func(m *mockUserService) {
+				m.createErr = domain.NewAppError(domain.CodeAlreadyExists, "email already exists", nil)
+			}},
+		{name: "user_get", method: http.MethodGet, path: "/api/v1/users/1"},
+		{name: "user_get_not_found", method: http.MethodGet, path: "/api/v1/users/99"},
+		{name: "user_get_invalid_id", method: http.MethodGet, path: "/api/v1/users/abc"},
+		{name: "user_list", method: http.MethodGet, path: "/api/v1/users?page=1&page_size=10"},
+		{name: "user_update", method: http.MethodPut, path: "/api/v1/users/1", body: `{"name":"Alice Smith","email":"alice@example.com"}`},
+		{name: "user_update_not_found", method: http.MethodPut, path: "/api/v1/users/99", body: `{"name":"Alice Smith","email":"alice@example.com"}`},
+		{name: "user_delete", method: http.MethodDelete, path: "/api/v1/users/1"},
+		{name: "user_internal_error", method: http.MethodGet, path: "/api/v1/users/1",
+			setup: func(m *mockUserService) { m.getErr = errors.New("connection refused") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newMockService()
+			if _, err := svc.CreateUser(context.Background(), "Alice", "alice@example.com", ""); err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+			if tt.setup != nil {
+				tt.setup(svc)
+			}
+			r := setupAPIRouter(NewUserHandler(svc))
+
+			var w *httptest.ResponseRecorder
+			if tt.body != "" {
+				w = testutil.Serve(r, testutil.NewJSONRequest(t, tt.method, tt.path, tt.body))
+			} else {
+				w = testutil.Serve(r, httptest.NewRequest(tt.method, tt.path, nil))
+			}
+			contract.Assert(t, tt.name, w)
+		})
+	}
+}
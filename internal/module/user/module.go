@@ -1,6 +1,17 @@
 package user
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/middleware"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/blob"
+)
 
 // UserModule implements the app.Module interface for the user domain.
 type UserModule struct {
@@ -24,16 +35,92 @@ func NewModule(h *UserHandler, ph *UserPageHandler) *UserModule {
 func (m *UserModule) RegisterRoutes(api *gin.RouterGroup, pages *gin.RouterGroup) {
 	// API routes
 	api.POST("/users", m.handler.Create)
+	api.POST("/users/bulk", m.handler.BulkCreate)
 	api.GET("/users/:id", m.handler.Get)
 	api.GET("/users", m.handler.List)
 	api.PUT("/users/:id", m.handler.Update)
+	api.PATCH("/users/:id", m.handler.Patch)
 	api.DELETE("/users/:id", m.handler.Delete)
 
 	// Page routes
 	pages.GET("/users", m.pageHandler.ListPage)
 	pages.GET("/users/new", m.pageHandler.NewPage)
+	pages.GET("/users/export", m.pageHandler.ExportUsers)
+	pages.GET("/users/:id", m.pageHandler.DetailPage)
 	pages.GET("/users/:id/edit", m.pageHandler.EditPage)
+	pages.GET("/users/:id/confirm-delete", m.pageHandler.ConfirmDeleteFragment)
 	pages.POST("/users", m.pageHandler.CreateHTMX)
 	pages.PUT("/users/:id", m.pageHandler.UpdateHTMX)
 	pages.DELETE("/users/:id", m.pageHandler.DeleteHTMX)
+	pages.POST("/users/:id/undo", m.pageHandler.UndoDeleteHTMX)
+}
+
+// Models returns the users table model for migration and the startup
+// schema check.
+func (m *UserModule) Models() []any {
+	return []any{&domain.User{}}
+}
+
+// Erasables returns the change feed entries of the user, for their data
+// export and erasure. The user row itself is the privacy module's.
+// Erased users leave the feed without a delete entry, since the entry
+// would name them.
+func (m *UserModule) Erasables() []pkg.Erasable {
+	return []pkg.Erasable{
+		changeRecords{pkg.UserRecords[domain.Change]("changes", "entity_type = ? AND entity_id = ?", func(u *domain.User) []any {
+			return []any{changeEntityType, strconv.FormatUint(uint64(u.ID), 10)}
+		})},
+	}
+}
+
+// changeRecords exports change feed entries with their payloads decoded,
+// in place of the stored, possibly compressed, bytes.
+type changeRecords struct {
+	pkg.Erasable
+}
+
+func (c changeRecords) ExportUser(tx *gorm.DB, user *domain.User) (any, error) {
+	records, err := c.Erasable.ExportUser(tx, user)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := records.([]map[string]any)
+	for _, row := range rows {
+		data, _ := row["payload_data"].([]byte)
+		// Drivers scan the flag as a bool or, in SQLite, an integer.
+		var compressed bool
+		switch v := row["payload_compressed"].(type) {
+		case bool:
+			compressed = v
+		case int64:
+			compressed = v != 0
+		}
+		payload, err := blob.Decode(data, compressed)
+		if err != nil {
+			return nil, err
+		}
+		delete(row, "payload_data")
+		delete(row, "payload_compressed")
+		row["payload"] = payload
+	}
+	return records, nil
+}
+
+// Sitemap lists the user pages. They show nothing useful to visitors
+// without users:read, so with RBAC enabled they stay out of the sitemap.
+func (m *UserModule) Sitemap() []pkg.SitemapSource {
+	return []pkg.SitemapSource{{Entries: m.pageHandler.SitemapEntries, Permission: "users:read"}}
+}
+
+// Policies guards the user API: listing, creating and deleting need the
+// users permission, while users may read and update (PUT or PATCH) their
+// own record without it.
+func (m *UserModule) Policies() []middleware.Policy {
+	return []middleware.Policy{
+		{PathPrefix: "/api/v1/users", Method: http.MethodGet, Resource: "users", Action: "read", AllowSelf: true},
+		{PathPrefix: "/api/v1/users", Method: http.MethodPost, Resource: "users", Action: "create"},
+		{PathPrefix: "/api/v1/users", Method: http.MethodPut, Resource: "users", Action: "update", AllowSelf: true},
+		{PathPrefix: "/api/v1/users", Method: http.MethodPatch, Resource: "users", Action: "update", AllowSelf: true},
+		{PathPrefix: "/api/v1/users", Method: http.MethodDelete, Resource: "users", Action: "delete"},
+	}
 }
@@ -30,13 +30,16 @@ func TestUserModuleRegisterRoutes(t *testing.T) {
 	}{
 		// API routes
 		{http.MethodPost, "/api/users"},
+		{http.MethodPost, "/api/users/bulk"},
 		{http.MethodGet, "/api/users/:id"},
 		{http.MethodGet, "/api/users"},
 		{http.MethodPut, "/api/users/:id"},
+		{http.MethodPatch, "/api/users/:id"},
 		{http.MethodDelete, "/api/users/:id"},
 		// Page routes
 		{http.MethodGet, "/users"},
 		{http.MethodGet, "/users/new"},
+		{http.MethodGet, "/users/:id"},
 		{http.MethodGet, "/users/:id/edit"},
 		{http.MethodPost, "/users"},
 		{http.MethodPut, "/users/:id"},
@@ -3,103 +3,244 @@ package user
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
 
 	"github.com/simp-lee/gobase/internal/domain"
 	"github.com/simp-lee/gobase/internal/middleware"
 	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 )
 
 // UserPageHandler handles page rendering and htmx endpoints for the user module.
 type UserPageHandler struct {
-	svc domain.UserService
+	svc        domain.UserService
+	undoWindow time.Duration
+	clock      pkg.Clock
+	pending    *pendingDeletes
+
+	formTokenTTL time.Duration
+	formTokens   *pkg.FormTokens
+
+	listCache    cache.CacheInterface // nil without WithListCache
+	listCacheTTL time.Duration
+}
+
+// PageHandlerOption configures optional UserPageHandler behavior.
+type PageHandlerOption func(*UserPageHandler)
+
+// WithUndoWindow defers DeleteHTMX by window so the success toast can offer
+// an undo (POST /users/:id/undo). The handler must then be closed to stop
+// the cleanup goroutine. Without it, or with window <= 0, deletes run
+// immediately.
+func WithUndoWindow(window time.Duration) PageHandlerOption {
+	return func(h *UserPageHandler) {
+		h.undoWindow = window
+	}
+}
+
+// WithClock sets the clock the undo window is measured on (default
+// pkg.RealClock).
+func WithClock(clock pkg.Clock) PageHandlerOption {
+	return func(h *UserPageHandler) {
+		if clock != nil {
+			h.clock = clock
+		}
+	}
+}
+
+// WithFormTokenTTL sets how long a rendered create form can be submitted
+// (default pkg.DefaultFormTokenTTL). Past it CreateHTMX re-renders the form
+// and asks for another submit.
+func WithFormTokenTTL(ttl time.Duration) PageHandlerOption {
+	return func(h *UserPageHandler) {
+		h.formTokenTTL = ttl
+	}
+}
+
+// WithListCache keeps the users ListPage shows anonymous visitors in store
+// for ttl, keyed by the query string (sorted by name) and tenant, so repeat
+// views skip the database. Only the list is cached: the page around it is
+// rendered per request, and the form, edit and detail pages never are.
+// Keys start with middleware.ResponseCacheKey(GET, "/users", ""), so
+// middleware.CacheInvalidation with PageInvalidationPrefix purges them on
+// every write; app.New also purges them on writes to /api/v1/users.
+func WithListCache(store cache.CacheInterface, ttl time.Duration) PageHandlerOption {
+	return func(h *UserPageHandler) {
+		if store != nil && ttl > 0 {
+			h.listCache = store
+			h.listCacheTTL = ttl
+		}
+	}
 }
 
 // NewUserPageHandler creates a new UserPageHandler with the given service.
-func NewUserPageHandler(svc domain.UserService) *UserPageHandler {
-	return &UserPageHandler{svc: svc}
+func NewUserPageHandler(svc domain.UserService, opts ...PageHandlerOption) *UserPageHandler {
+	h := &UserPageHandler{svc: svc, clock: pkg.RealClock}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.undoWindow > 0 {
+		h.pending = newPendingDeletes(svc, h.undoWindow, h.clock)
+	}
+	h.formTokens = pkg.NewFormTokens(h.formTokenTTL, h.clock)
+	return h
+}
+
+// Close stops the undo cleanup goroutine and carries out the deletes still
+// waiting for their undo window. It is a no-op without WithUndoWindow.
+func (h *UserPageHandler) Close() {
+	if h.pending != nil {
+		h.pending.Close()
+	}
 }
 
+// featureUserSearch gates the name search box on the user list page.
+const featureUserSearch = "user_search"
+
 // ListPage renders the user list page with pagination.
 // GET /users
 func (h *UserPageHandler) ListPage(c *gin.Context) {
-	req := pkg.ParsePageRequest(c)
+	req, err := pkg.ParsePageRequest(c)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "errors/400.html", pageData(c, gin.H{}))
+		return
+	}
+	// Prefill the search box; name__like filters either way.
+	search := ""
+	if pkg.FeatureEnabled(c, featureUserSearch) {
+		search = req.Filter["name__like"]
+	}
 
-	result, err := h.svc.ListUsers(c.Request.Context(), req)
+	result, err := h.listUsers(c, req)
 	if err != nil {
-		c.HTML(http.StatusInternalServerError, "errors/500.html", gin.H{})
+		c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
 		return
 	}
+	h.hidePending(result)
 
-	c.HTML(http.StatusOK, "user/list.html", gin.H{
+	c.HTML(http.StatusOK, "user/list.html", pageData(c, gin.H{
 		"Users":      result.Items,
 		"Pagination": result,
+		"Pager":      pkg.BuildPager("/users", c.Request.URL.Query(), result.CurrentPage, result.TotalPages),
 		"BaseURL":    "/users",
-		"CSRFToken":  middleware.GetCSRFToken(c),
-	})
+		"ExportURL":  exportURL(c.Request.URL.Query()),
+		"Search":     search,
+		"Flash":      nil,
+	}))
 }
 
 // NewPage renders the new user form.
 // GET /users/new
 func (h *UserPageHandler) NewPage(c *gin.Context) {
-	c.HTML(http.StatusOK, "user/form.html", gin.H{
+	h.renderCreateForm(c, nil, "")
+}
+
+// renderCreateForm renders the new user form prefilled with user (nil for
+// an empty form) and errMsg, if any. Every render issues a fresh one-time
+// form token, keyed by the client's CSRF cookie, for CreateHTMX to consume.
+func (h *UserPageHandler) renderCreateForm(c *gin.Context, user *domain.User, errMsg string) {
+	csrfToken := middleware.GetCSRFToken(c)
+	formToken, err := h.formTokens.Issue(csrfToken)
+	if err != nil {
+		slog.Error("issue form token failed", "error", err)
+		c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
+		return
+	}
+	c.HTML(http.StatusOK, "user/form.html", formData(c, gin.H{
 		"IsEdit":    false,
-		"CSRFToken": middleware.GetCSRFToken(c),
-	})
+		"FormToken": formToken,
+		"User":      user,
+		"Error":     errMsg,
+	}))
+}
+
+// DetailPage renders a single user's profile, including the markdown bio.
+// GET /users/:id
+func (h *UserPageHandler) DetailPage(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "errors/400.html", pageData(c, gin.H{}))
+		return
+	}
+
+	user, err := h.svc.GetUser(c.Request.Context(), id)
+	if err != nil {
+		if domain.IsNotFound(err) {
+			c.HTML(http.StatusNotFound, "errors/404.html", pageData(c, gin.H{}))
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
+		return
+	}
+
+	c.HTML(http.StatusOK, "user/detail.html", pageData(c, gin.H{
+		"User": user,
+	}))
 }
 
 // EditPage renders the edit user form.
 // GET /users/:id/edit
 func (h *UserPageHandler) EditPage(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
-		c.HTML(http.StatusBadRequest, "errors/400.html", gin.H{})
+		c.HTML(http.StatusBadRequest, "errors/400.html", pageData(c, gin.H{}))
 		return
 	}
 
 	user, err := h.svc.GetUser(c.Request.Context(), id)
 	if err != nil {
 		if domain.IsNotFound(err) {
-			c.HTML(http.StatusNotFound, "errors/404.html", gin.H{})
+			c.HTML(http.StatusNotFound, "errors/404.html", pageData(c, gin.H{}))
 			return
 		}
-		c.HTML(http.StatusInternalServerError, "errors/500.html", gin.H{})
+		c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
 		return
 	}
 
-	c.HTML(http.StatusOK, "user/form.html", gin.H{
-		"User":      user,
-		"IsEdit":    true,
-		"CSRFToken": middleware.GetCSRFToken(c),
-	})
+	c.HTML(http.StatusOK, "user/form.html", formData(c, gin.H{
+		"User":   user,
+		"IsEdit": true,
+		"Error":  "",
+	}))
 }
 
 // CreateHTMX handles user creation via htmx form submission.
 // POST /users
+//
+// The form's one-time token is consumed before anything else, so of two
+// posts of one render (a double click, a replay) only the first creates a
+// user; the other, like a post of an expired form, gets the form back with
+// the submitted values and a fresh token.
 func (h *UserPageHandler) CreateHTMX(c *gin.Context) {
 	var req CreateUserRequest
-	if err := c.ShouldBind(&req); err != nil {
-		slog.Debug("create user: bind error", "error", err)
-		c.HTML(http.StatusOK, "user/form.html", gin.H{
-			"IsEdit":    false,
-			"Error":     "请检查输入格式",
-			"CSRFToken": middleware.GetCSRFToken(c),
-		})
+	bindErr := c.ShouldBind(&req)
+	submitted := &domain.User{Name: req.Name, Email: req.Email, Bio: req.Bio}
+
+	if err := h.formTokens.Consume(middleware.GetCSRFToken(c), c.PostForm(pkg.FormTokenField)); err != nil {
+		msg := "表单已过期，请确认后重新提交"
+		if errors.Is(err, pkg.ErrFormTokenUsed) {
+			msg = "表单已提交，请勿重复提交"
+		}
+		h.renderCreateForm(c, submitted, msg)
+		return
+	}
+	if bindErr != nil {
+		slog.Debug("create user: bind error", "error", bindErr)
+		h.renderCreateForm(c, submitted, "请检查输入格式")
 		return
 	}
 
-	_, err := h.svc.CreateUser(c.Request.Context(), req.Name, req.Email)
+	_, err := h.svc.CreateUser(c.Request.Context(), req.Name, req.Email, req.Bio)
 	if err != nil {
-		c.HTML(http.StatusOK, "user/form.html", gin.H{
-			"IsEdit":    false,
-			"Error":     safePageErrorMessage(err, "创建用户失败，请稍后重试"),
-			"CSRFToken": middleware.GetCSRFToken(c),
-		})
+		h.renderCreateForm(c, submitted, safePageErrorMessage(err, "创建用户失败，请稍后重试"))
 		return
 	}
 
@@ -111,9 +252,9 @@ func (h *UserPageHandler) CreateHTMX(c *gin.Context) {
 // UpdateHTMX handles user update via htmx form submission.
 // PUT /users/:id
 func (h *UserPageHandler) UpdateHTMX(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
-		c.HTML(http.StatusBadRequest, "errors/400.html", gin.H{})
+		c.HTML(http.StatusBadRequest, "errors/400.html", pageData(c, gin.H{}))
 		return
 	}
 
@@ -123,41 +264,42 @@ func (h *UserPageHandler) UpdateHTMX(c *gin.Context) {
 		user, getErr := h.svc.GetUser(c.Request.Context(), id)
 		if getErr != nil {
 			if domain.IsNotFound(getErr) {
-				c.HTML(http.StatusNotFound, "errors/404.html", gin.H{})
+				c.HTML(http.StatusNotFound, "errors/404.html", pageData(c, gin.H{}))
 				return
 			}
-			c.HTML(http.StatusInternalServerError, "errors/500.html", gin.H{})
+			c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
 			return
 		}
-		c.HTML(http.StatusOK, "user/form.html", gin.H{
-			"User":      user,
-			"IsEdit":    true,
-			"Error":     "请检查输入格式",
-			"CSRFToken": middleware.GetCSRFToken(c),
-		})
+		c.HTML(http.StatusOK, "user/form.html", formData(c, gin.H{
+			"User":   user,
+			"IsEdit": true,
+			"Error":  "请检查输入格式",
+		}))
 		return
 	}
 
-	_, err = h.svc.UpdateUser(c.Request.Context(), id, req.Name, req.Email)
+	_, modified, err := h.svc.UpdateUser(c.Request.Context(), id, req.Name, req.Email, req.Bio)
 	if err != nil {
 		user, getErr := h.svc.GetUser(c.Request.Context(), id)
 		if getErr != nil {
 			if domain.IsNotFound(getErr) {
-				c.HTML(http.StatusNotFound, "errors/404.html", gin.H{})
+				c.HTML(http.StatusNotFound, "errors/404.html", pageData(c, gin.H{}))
 				return
 			}
-			c.HTML(http.StatusInternalServerError, "errors/500.html", gin.H{})
+			c.HTML(http.StatusInternalServerError, "errors/500.html", pageData(c, gin.H{}))
 			return
 		}
-		c.HTML(http.StatusOK, "user/form.html", gin.H{
-			"User":      user,
-			"IsEdit":    true,
-			"Error":     safePageErrorMessage(err, "更新用户失败，请稍后重试"),
-			"CSRFToken": middleware.GetCSRFToken(c),
-		})
+		c.HTML(http.StatusOK, "user/form.html", formData(c, gin.H{
+			"User":   user,
+			"IsEdit": true,
+			"Error":  safePageErrorMessage(err, "更新用户失败，请稍后重试"),
+		}))
 		return
 	}
 
+	if !modified {
+		middleware.SkipCacheInvalidation(c)
+	}
 	setShowToastHeader(c, "用户更新成功", "success")
 	c.Header("HX-Redirect", "/users")
 	c.Status(http.StatusOK)
@@ -166,7 +308,7 @@ func (h *UserPageHandler) UpdateHTMX(c *gin.Context) {
 // DeleteHTMX handles user deletion via htmx.
 // DELETE /users/:id
 func (h *UserPageHandler) DeleteHTMX(c *gin.Context) {
-	id, err := parseID(c)
+	id, err := pkg.ParseIDParam(c, "id")
 	if err != nil {
 		c.Header("HX-Reswap", "none")
 		setShowToastHeader(c, "无效的用户ID", "error")
@@ -174,34 +316,175 @@ func (h *UserPageHandler) DeleteHTMX(c *gin.Context) {
 		return
 	}
 
-	if err := h.svc.DeleteUser(c.Request.Context(), id); err != nil {
-		if domain.IsNotFound(err) {
-			c.Header("HX-Reswap", "none")
-			setShowToastHeader(c, "用户不存在或已删除", "error")
+	if h.pending != nil && h.pending.isPending(id) {
+		c.Header("HX-Reswap", "none")
+		setShowToastHeader(c, "用户不存在或已删除", "error")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	if h.pending != nil {
+		if _, err := h.svc.GetUser(c.Request.Context(), id); err != nil {
+			h.deleteFailed(c, err)
+			return
+		}
+		if h.pending.schedule(id) {
+			setUndoToastHeader(c, "用户已删除", "/users/"+strconv.FormatUint(uint64(id), 10)+"/undo", h.undoWindow)
 			c.Status(http.StatusOK)
 			return
 		}
-		c.Header("HX-Reswap", "none")
+		// The pending store is full: fall through to an immediate delete.
+	}
+
+	if err := h.svc.DeleteUser(c.Request.Context(), id); err != nil {
+		h.deleteFailed(c, err)
+		return
+	}
+
+	setShowToastHeader(c, "用户删除成功", "success")
+	c.Status(http.StatusOK)
+}
+
+// deleteFailed reports a failed DeleteHTMX as an error toast, leaving the row.
+func (h *UserPageHandler) deleteFailed(c *gin.Context, err error) {
+	c.Header("HX-Reswap", "none")
+	switch {
+	case domain.IsNotFound(err):
+		setShowToastHeader(c, "用户不存在或已删除", "error")
+	case domain.IsConflict(err):
+		setShowToastHeader(c, safePageErrorMessage(err, "用户仍被其他记录引用，无法删除"), "error")
+	default:
 		setShowToastHeader(c, "删除失败，请稍后重试", "error")
+	}
+	c.Status(http.StatusOK)
+}
+
+// ConfirmDeleteFragment renders the delete confirmation modal for htmx.
+// GET /users/:id/confirm-delete
+func (h *UserPageHandler) ConfirmDeleteFragment(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		c.Header("HX-Reswap", "none")
+		setShowToastHeader(c, "无效的用户ID", "error")
 		c.Status(http.StatusOK)
 		return
 	}
 
-	setShowToastHeader(c, "用户删除成功", "success")
+	user, err := h.svc.GetUser(c.Request.Context(), id)
+	if err == nil && h.pending != nil && h.pending.isPending(id) {
+		err = domain.ErrNotFound
+	}
+	if err != nil {
+		h.deleteFailed(c, err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "user/confirm_delete.html", pageData(c, gin.H{
+		"User":        user,
+		"UndoSeconds": int(h.undoWindow.Seconds()),
+	}))
+}
+
+// UndoDeleteHTMX cancels a deferred DeleteHTMX within its undo window and
+// reloads the page so the row reappears. Once the window has passed it
+// answers 410 Gone with an error toast.
+// POST /users/:id/undo
+func (h *UserPageHandler) UndoDeleteHTMX(c *gin.Context) {
+	id, err := pkg.ParseIDParam(c, "id")
+	if err != nil {
+		c.Header("HX-Reswap", "none")
+		setShowToastHeader(c, "无效的用户ID", "error")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	if h.pending == nil || !h.pending.undo(id) {
+		c.Header("HX-Reswap", "none")
+		setShowToastHeader(c, "撤销时间已过，用户已被删除", "error")
+		c.Status(http.StatusGone)
+		return
+	}
+
+	setShowToastHeader(c, "已撤销删除", "success")
+	c.Header("HX-Refresh", "true")
 	c.Status(http.StatusOK)
 }
 
-// parseID extracts and validates the "id" URL parameter.
-func parseID(c *gin.Context) (uint, error) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil || id == 0 {
-		return 0, fmt.Errorf("invalid id: %s", idStr)
+// listUsers returns a page of users for ListPage, from the list cache when
+// it is on and the request is anonymous. The result is the caller's to
+// modify.
+func (h *UserPageHandler) listUsers(c *gin.Context, req domain.PageRequest) (*domain.PageResult[domain.User], error) {
+	ctx := c.Request.Context()
+	if h.listCache == nil {
+		return h.svc.ListUsers(ctx, req)
+	}
+	if _, signedIn := requestctx.UserID(c); signedIn {
+		return h.svc.ListUsers(ctx, req)
+	}
+
+	key := middleware.ResponseCacheKey(http.MethodGet, "/users", middleware.SortedQuery(c.Request.URL.RawQuery))
+	if tenant, ok := requestctx.Tenant(c); ok {
+		key += " tenant=" + url.QueryEscape(tenant)
+	}
+	if v, ok := h.listCache.Get(key); ok {
+		if cached, ok := v.(*domain.PageResult[domain.User]); ok {
+			result := *cached
+			result.Items = slices.Clone(cached.Items)
+			return &result, nil
+		}
+	}
+	result, err := h.svc.ListUsers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	stored := *result
+	stored.Items = slices.Clone(result.Items)
+	h.listCache.SetWithExpiration(key, &stored, h.listCacheTTL)
+	return result, nil
+}
+
+// hidePending drops users awaiting a deferred delete from a list page.
+func (h *UserPageHandler) hidePending(result *domain.PageResult[domain.User]) {
+	if h.pending == nil {
+		return
 	}
-	if id > uint64(^uint(0)) {
-		return 0, fmt.Errorf("invalid id: %s", idStr)
+	kept := result.Items[:0]
+	for _, u := range result.Items {
+		if h.pending.isPending(u.ID) {
+			result.TotalItems--
+			continue
+		}
+		kept = append(kept, u)
 	}
-	return uint(id), nil
+	result.Items = kept
+}
+
+// pageData adds the keys the base layout and its partials read to a page's
+// own data, so every render passes strict templates.
+func pageData(c *gin.Context, data gin.H) gin.H {
+	data["CSRFToken"] = middleware.GetCSRFToken(c)
+	data["Perms"] = middleware.GetPermissions(c)
+	data["Nav"] = middleware.GetNav(c)
+	data["Features"] = pkg.GetFeatures(c)
+	data["Locale"] = requestctx.Locale(c)
+	data["Unread"] = middleware.GetUnread(c)
+	return data
+}
+
+// userFormLimits are the length limits user/form.html sets as minlength and
+// maxlength, so the browser enforces what the service validates.
+var userFormLimits = map[string]int{
+	"NameMin":  domain.NameMinLen,
+	"NameMax":  domain.NameMaxLen,
+	"EmailMax": domain.EmailMaxLen,
+	"BioMax":   domain.BioMaxLen,
+}
+
+// formData is pageData for user/form.html, which also reads the field
+// length limits.
+func formData(c *gin.Context, data gin.H) gin.H {
+	data["Limits"] = userFormLimits
+	return pageData(c, data)
 }
 
 // setShowToastHeader sets the HX-Trigger response header with a showToast event.
@@ -215,6 +498,24 @@ func setShowToastHeader(c *gin.Context, message, toastType string) {
 	c.Header("HX-Trigger", string(trigger))
 }
 
+// setUndoToastHeader sets a success showToast event carrying an undo action:
+// app.js renders a "撤销" button that POSTs to undoURL and keeps the toast
+// open for window.
+func setUndoToastHeader(c *gin.Context, message, undoURL string, window time.Duration) {
+	trigger, _ := json.Marshal(map[string]any{
+		"showToast": map[string]any{
+			"message":  message,
+			"type":     "success",
+			"duration": window.Milliseconds(),
+			"action": map[string]string{
+				"label": "撤销",
+				"url":   undoURL,
+			},
+		},
+	})
+	c.Header("HX-Trigger", string(trigger))
+}
+
 // safePageErrorMessage extracts a user-safe error message from an AppError.
 // Only messages from user-facing error codes (NotFound, AlreadyExists, Validation)
 // are returned. Internal or unknown error codes always return the fallback to
@@ -223,7 +524,7 @@ func safePageErrorMessage(err error, fallback string) string {
 	var appErr *domain.AppError
 	if errors.As(err, &appErr) && appErr.Message != "" {
 		switch appErr.Code {
-		case domain.CodeNotFound, domain.CodeAlreadyExists, domain.CodeValidation:
+		case domain.CodeNotFound, domain.CodeAlreadyExists, domain.CodeValidation, domain.CodeConflict:
 			return appErr.Message
 		}
 	}
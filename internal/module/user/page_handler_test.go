@@ -5,18 +5,22 @@ import (
 	"encoding/json"
 	"errors"
 	"html/template"
-	"math/bits"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	cache "github.com/simp-lee/cache"
 	"github.com/simp-lee/pagination"
 
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/requestctx"
 )
 
 // --- mock service for page handler tests ---
@@ -36,7 +40,7 @@ func newMockService() *mockUserService {
 	return &mockUserService{users: make(map[uint]*domain.User), nextID: 1}
 }
 
-func (m *mockUserService) CreateUser(_ context.Context, name, email string) (*domain.User, error) {
+func (m *mockUserService) CreateUser(_ context.Context, name, email, bio string) (*domain.User, error) {
 	if m.createErr != nil {
 		return nil, m.createErr
 	}
@@ -44,6 +48,7 @@ func (m *mockUserService) CreateUser(_ context.Context, name, email string) (*do
 		BaseModel: domain.BaseModel{ID: m.nextID},
 		Name:      name,
 		Email:     email,
+		Bio:       bio,
 	}
 	m.users[u.ID] = u
 	m.nextID++
@@ -74,21 +79,42 @@ func (m *mockUserService) ListUsers(_ context.Context, req domain.PageRequest) (
 		TotalItems:   int64(len(items)),
 		CurrentPage:  req.Page,
 		ItemsPerPage: req.PageSize,
-		TotalPages:   1,
+		TotalPages:   max(1, (len(items)+req.PageSize-1)/req.PageSize),
 	}, nil
 }
 
-func (m *mockUserService) UpdateUser(_ context.Context, id uint, name, email string) (*domain.User, error) {
+func (m *mockUserService) UpdateUser(_ context.Context, id uint, name, email, bio string) (*domain.User, bool, error) {
 	if m.updateErr != nil {
-		return nil, m.updateErr
+		return nil, false, m.updateErr
 	}
 	u, ok := m.users[id]
 	if !ok {
-		return nil, domain.ErrNotFound
+		return nil, false, domain.ErrNotFound
 	}
 	u.Name = name
 	u.Email = email
-	return u, nil
+	u.Bio = bio
+	return u, true, nil
+}
+
+func (m *mockUserService) PatchUser(_ context.Context, id uint, patch domain.UserPatch) (*domain.User, bool, error) {
+	if m.updateErr != nil {
+		return nil, false, m.updateErr
+	}
+	u, ok := m.users[id]
+	if !ok {
+		return nil, false, domain.ErrNotFound
+	}
+	if patch.Name.Set {
+		u.Name = patch.Name.Value
+	}
+	if patch.Email.Set {
+		u.Email = patch.Email.Value
+	}
+	if patch.Bio.Set {
+		u.Bio = patch.Bio.Value
+	}
+	return u, true, nil
 }
 
 func (m *mockUserService) DeleteUser(_ context.Context, id uint) error {
@@ -113,8 +139,10 @@ func setupTestRouter(h *UserPageHandler) *gin.Engine {
 
 	// Stub templates so c.HTML() calls don't panic.
 	tmpl := template.Must(template.New("").Parse(
-		`{{define "user/list.html"}}list:BaseURL={{.BaseURL}}:HasPagination={{if .Pagination}}yes{{else}}no{{end}}{{end}}` +
-			`{{define "user/form.html"}}form{{if .Error}}:{{.Error}}{{end}}{{end}}` +
+		`{{define "user/list.html"}}list:BaseURL={{.BaseURL}}:HasPagination={{if .Pagination}}yes{{else}}no{{end}}:Next={{.Pager.NextURL}}:Users={{len .Users}}{{end}}` +
+			`{{define "user/form.html"}}form{{if .FormToken}}:token={{.FormToken}}{{end}}{{with .User}}:name={{.Name}}{{end}}{{if .Error}}:{{.Error}}{{end}}{{end}}` +
+			`{{define "user/detail.html"}}detail:{{.User.Name}}{{end}}` +
+			`{{define "user/confirm_delete.html"}}confirm:{{.User.ID}}:{{.User.Name}}:{{.UndoSeconds}}{{end}}` +
 			`{{define "errors/400.html"}}400{{end}}` +
 			`{{define "errors/404.html"}}404{{end}}` +
 			`{{define "errors/500.html"}}500{{end}}`,
@@ -124,14 +152,47 @@ func setupTestRouter(h *UserPageHandler) *gin.Engine {
 	// Register routes matching the real app.
 	r.GET("/users", h.ListPage)
 	r.GET("/users/new", h.NewPage)
+	r.GET("/users/export", h.ExportUsers)
+	r.GET("/users/:id", h.DetailPage)
 	r.GET("/users/:id/edit", h.EditPage)
+	r.GET("/users/:id/confirm-delete", h.ConfirmDeleteFragment)
 	r.POST("/users", h.CreateHTMX)
 	r.PUT("/users/:id", h.UpdateHTMX)
 	r.DELETE("/users/:id", h.DeleteHTMX)
+	r.POST("/users/:id/undo", h.UndoDeleteHTMX)
 
 	return r
 }
 
+// newFormToken renders /users/new and returns the form token it carries.
+func newFormToken(t *testing.T, r *gin.Engine) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/new", nil)
+	r.ServeHTTP(w, req)
+	return formTokenIn(t, w.Body.String())
+}
+
+// formTokenIn returns the form token of a stub form render.
+func formTokenIn(t *testing.T, body string) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(body, ":token=")
+	if !ok {
+		t.Fatalf("no form token in %q", body)
+	}
+	token, _, _ := strings.Cut(rest, ":")
+	return token
+}
+
+// postCreateForm posts form to /users.
+func postCreateForm(r *gin.Engine, form url.Values) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	return w
+}
+
 // --- tests ---
 
 func TestNewUserPageHandler(t *testing.T) {
@@ -171,6 +232,80 @@ func TestListPage_Success(t *testing.T) {
 	}
 }
 
+func TestListPage_PagerPreservesQuery(t *testing.T) {
+	svc := newMockService()
+	for id := uint(1); id <= 3; id++ {
+		svc.users[id] = &domain.User{BaseModel: domain.BaseModel{ID: id}, Name: "User " + strconv.Itoa(int(id))}
+	}
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users?page=2&page_size=1&sort=name:asc&name__like=User", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	want := "Next=/users?name__like=User&amp;page=3&amp;page_size=1&amp;sort=name%3Aasc"
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected pager next URL to keep filters and sort, want %q in %q", want, w.Body.String())
+	}
+}
+
+func TestListPage_ListCache(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice"}
+	store := cache.NewCache(cache.Options{DefaultExpiration: time.Minute, CleanupInterval: time.Minute})
+	t.Cleanup(store.Close)
+	h := NewUserPageHandler(svc, WithListCache(store, time.Minute))
+	r := setupTestRouter(h)
+	r.GET("/signed-in/users", func(c *gin.Context) {
+		requestctx.SetUserID(c, "7")
+		h.ListPage(c)
+	})
+	list := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := list("/users?page_size=5&page=1"); !strings.Contains(w.Body.String(), "Users=1") {
+		t.Fatalf("first GET /users = %d %q, want one user", w.Code, w.Body.String())
+	}
+	svc.users[2] = &domain.User{BaseModel: domain.BaseModel{ID: 2}, Name: "Bob"}
+
+	// Reordered parameters hit the same entry; other queries do not.
+	if w := list("/users?page=1&page_size=5"); !strings.Contains(w.Body.String(), "Users=1") {
+		t.Errorf("cached GET /users = %q, want the cached page", w.Body.String())
+	}
+	if w := list("/users?page=1&page_size=10"); !strings.Contains(w.Body.String(), "Users=2") {
+		t.Errorf("GET /users with another page size = %q, want a fresh page", w.Body.String())
+	}
+	if w := list("/signed-in/users?page=1&page_size=5"); !strings.Contains(w.Body.String(), "Users=2") {
+		t.Errorf("signed-in GET /users = %q, want an uncached page", w.Body.String())
+	}
+
+	store.DeletePrefix("GET /users")
+	if w := list("/users?page=1&page_size=5"); !strings.Contains(w.Body.String(), "Users=2") {
+		t.Errorf("GET /users after a purge = %q, want a fresh page", w.Body.String())
+	}
+}
+
+func TestListPage_ConflictingParams(t *testing.T) {
+	svc := newMockService()
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users?cursor=abc&page=2", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
 func TestListPage_ServiceError(t *testing.T) {
 	svc := newMockService()
 	svc.listErr = errors.New("db connection lost")
@@ -197,6 +332,7 @@ func TestCreateHTMX_Success(t *testing.T) {
 	form := url.Values{}
 	form.Set("name", "Alice")
 	form.Set("email", "alice@example.com")
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
@@ -247,6 +383,7 @@ func TestCreateHTMX_ServiceError(t *testing.T) {
 	form := url.Values{}
 	form.Set("name", "Bob")
 	form.Set("email", "bob@example.com")
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
@@ -272,6 +409,7 @@ func TestCreateHTMX_InternalError(t *testing.T) {
 	form := url.Values{}
 	form.Set("name", "Bob")
 	form.Set("email", "bob@example.com")
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
@@ -287,6 +425,105 @@ func TestCreateHTMX_InternalError(t *testing.T) {
 	}
 }
 
+func TestCreateHTMX_DuplicateSubmitCreatesOnce(t *testing.T) {
+	svc := newMockService()
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	form := url.Values{}
+	form.Set("name", "Alice")
+	form.Set("email", "alice@example.com")
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
+
+	first := postCreateForm(r, form)
+	second := postCreateForm(r, form)
+
+	if got := first.Header().Get("HX-Redirect"); got != "/users" {
+		t.Errorf("first post: HX-Redirect = %q, want /users", got)
+	}
+	if got := second.Header().Get("HX-Redirect"); got != "" {
+		t.Errorf("second post: HX-Redirect = %q, want none", got)
+	}
+	body := second.Body.String()
+	if !strings.Contains(body, "表单已提交，请勿重复提交") || !strings.Contains(body, "name=Alice") {
+		t.Errorf("second post body = %q, want the form with the submitted data and a duplicate notice", body)
+	}
+	if fresh := formTokenIn(t, body); fresh == form.Get(pkg.FormTokenField) {
+		t.Error("re-rendered form reuses the consumed token")
+	}
+	if len(svc.users) != 1 {
+		t.Errorf("users created = %d, want 1", len(svc.users))
+	}
+}
+
+func TestCreateHTMX_ConcurrentSubmitsCreateOnce(t *testing.T) {
+	svc := newMockService()
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	form := url.Values{}
+	form.Set("name", "Alice")
+	form.Set("email", "alice@example.com")
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
+
+	var wg sync.WaitGroup
+	redirects := make(chan string, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			redirects <- postCreateForm(r, form).Header().Get("HX-Redirect")
+		}()
+	}
+	wg.Wait()
+	close(redirects)
+
+	created := 0
+	for redirect := range redirects {
+		if redirect != "" {
+			created++
+		}
+	}
+	if created != 1 {
+		t.Errorf("posts that created a user = %d, want 1", created)
+	}
+}
+
+func TestCreateHTMX_ExpiredOrMissingFormToken(t *testing.T) {
+	svc := newMockService()
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := NewUserPageHandler(svc, WithClock(clock), WithFormTokenTTL(time.Minute))
+	r := setupTestRouter(h)
+
+	form := url.Values{}
+	form.Set("name", "Alice")
+	form.Set("email", "alice@example.com")
+
+	w := postCreateForm(r, form)
+	if !strings.Contains(w.Body.String(), "表单已过期，请确认后重新提交") {
+		t.Errorf("post without a token: body = %q, want the expired-form notice", w.Body.String())
+	}
+
+	form.Set(pkg.FormTokenField, newFormToken(t, r))
+	clock.Advance(time.Minute)
+	w = postCreateForm(r, form)
+	if w.Code != http.StatusOK || w.Header().Get("HX-Redirect") != "" {
+		t.Errorf("expired post: status %d, HX-Redirect %q; want 200 and none", w.Code, w.Header().Get("HX-Redirect"))
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "表单已过期，请确认后重新提交") || !strings.Contains(body, "name=Alice") {
+		t.Errorf("expired post body = %q, want the form with the submitted data and an expiry notice", body)
+	}
+	if len(svc.users) != 0 {
+		t.Fatalf("users created = %d, want 0", len(svc.users))
+	}
+
+	form.Set(pkg.FormTokenField, formTokenIn(t, body))
+	if w := postCreateForm(r, form); w.Header().Get("HX-Redirect") != "/users" {
+		t.Errorf("resubmit with the fresh token: HX-Redirect = %q, want /users", w.Header().Get("HX-Redirect"))
+	}
+}
+
 func TestUpdateHTMX_ServiceError_RendersErrorMessage(t *testing.T) {
 	svc := newMockService()
 	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Old", Email: "old@example.com"}
@@ -419,6 +656,76 @@ func TestEditPage_InvalidID(t *testing.T) {
 	}
 }
 
+func TestDetailPage_Success(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com", Bio: "**hi**"}
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "detail:Alice") {
+		t.Fatalf("expected detail template body, got %q", w.Body.String())
+	}
+}
+
+func TestDetailPage_NotFound(t *testing.T) {
+	svc := newMockService()
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/99", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+	if w.Body.String() != "404" {
+		t.Fatalf("expected 404 template body, got %q", w.Body.String())
+	}
+}
+
+func TestDetailPage_InternalError_DoesNotLeakMessage(t *testing.T) {
+	svc := newMockService()
+	svc.getErr = errors.New("db connection lost: dial tcp 10.0.0.5:5432")
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", w.Code)
+	}
+	if w.Body.String() != "500" {
+		t.Fatalf("expected 500 template body, got %q", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "db connection lost") {
+		t.Fatalf("internal error message leaked into response: %q", w.Body.String())
+	}
+}
+
+func TestDetailPage_InvalidID(t *testing.T) {
+	svc := newMockService()
+	h := NewUserPageHandler(svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/abc", nil)
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+}
+
 func TestUpdateHTMX_BindError_GetUserInternalError(t *testing.T) {
 	svc := newMockService()
 	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Old", Email: "old@example.com"}
@@ -598,67 +905,25 @@ func TestDeleteHTMX_InternalError(t *testing.T) {
 	}
 }
 
-func TestParseID(t *testing.T) {
-	tests := []struct {
-		name    string
-		param   string
-		wantID  uint
-		wantErr bool
-	}{
-		{"valid", "1", 1, false},
-		{"large", "42", 42, false},
-		{"zero", "0", 0, true},
-		{"negative", "-1", 0, true},
-		{"non-numeric", "abc", 0, true},
-		{"empty", "", 0, true},
-		{"max-uint", strconv.FormatUint(uint64(^uint(0)), 10), uint(^uint(0)), false},
-		{"overflow-uint64", "18446744073709551616", 0, true},
-	}
+func TestDeleteHTMX_Conflict(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Test", Email: "test@example.com"}
+	svc.deleteErr = domain.NewAppError(domain.CodeConflict, "user is referenced by records that must be kept: invoices (2)", nil)
+	r := setupTestRouter(NewUserPageHandler(svc))
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gin.SetMode(gin.TestMode)
-			w := httptest.NewRecorder()
-			c, _ := gin.CreateTestContext(w)
-			c.Params = gin.Params{{Key: "id", Value: tt.param}}
-
-			id, err := parseID(c)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseID() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if id != tt.wantID {
-				t.Errorf("parseID() = %v, want %v", id, tt.wantID)
-			}
-		})
-	}
-}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+	r.ServeHTTP(w, req)
 
-func TestParseID_ArchitectureBoundary(t *testing.T) {
-	tests := []struct {
-		name    string
-		param   string
-		wantErr bool
-	}{
-		{
-			name:    "over-uint32-boundary",
-			param:   "4294967296",
-			wantErr: bits.UintSize == 32,
-		},
+	if got := w.Header().Get("HX-Reswap"); got != "none" {
+		t.Errorf("expected HX-Reswap 'none', got %q", got)
 	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gin.SetMode(gin.TestMode)
-			w := httptest.NewRecorder()
-			c, _ := gin.CreateTestContext(w)
-			c.Params = gin.Params{{Key: "id", Value: tt.param}}
-
-			_, err := parseID(c)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseID() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
+	var triggerData map[string]map[string]string
+	if err := json.Unmarshal([]byte(w.Header().Get("HX-Trigger")), &triggerData); err != nil {
+		t.Fatalf("failed to parse HX-Trigger: %v", err)
+	}
+	if toast := triggerData["showToast"]; toast["type"] != "error" || toast["message"] != "user is referenced by records that must be kept: invoices (2)" {
+		t.Errorf("toast = %v, want an error naming the blocking records", toast)
 	}
 }
 
@@ -715,3 +980,135 @@ func Test_safePageErrorMessage(t *testing.T) {
 		})
 	}
 }
+
+// --- delete confirmation and undo ---
+
+// newUndoHandler returns a handler with the default undo window and a clock
+// the test advances by hand.
+func newUndoHandler(t *testing.T, svc *mockUserService) (*UserPageHandler, func(time.Duration)) {
+	t.Helper()
+	clock := pkg.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := NewUserPageHandler(svc, WithUndoWindow(DefaultUndoWindow), WithClock(clock))
+	t.Cleanup(h.Close)
+	return h, clock.Advance
+}
+
+func parseToast(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var data map[string]map[string]any
+	if err := json.Unmarshal([]byte(w.Header().Get("HX-Trigger")), &data); err != nil {
+		t.Fatalf("failed to parse HX-Trigger %q: %v", w.Header().Get("HX-Trigger"), err)
+	}
+	return data["showToast"]
+}
+
+func TestConfirmDeleteFragment(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+	h, _ := newUndoHandler(t, svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/1/confirm-delete", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "confirm:1:Alice:30" {
+		t.Errorf("status = %d, body = %q; want the modal for user 1", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/users/999/confirm-delete", nil)
+	r.ServeHTTP(w, req)
+	if w.Header().Get("HX-Reswap") != "none" || parseToast(t, w)["message"] != "用户不存在或已删除" {
+		t.Errorf("missing user: HX-Reswap = %q, toast = %v", w.Header().Get("HX-Reswap"), parseToast(t, w))
+	}
+}
+
+func TestDeleteHTMX_UndoWithinWindowRestoresUser(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+	h, advance := newUndoHandler(t, svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, want 200", w.Code)
+	}
+	toast := parseToast(t, w)
+	action, _ := toast["action"].(map[string]any)
+	if toast["type"] != "success" || action["url"] != "/users/1/undo" || toast["duration"] != float64(30000) {
+		t.Fatalf("toast = %v, want success with an undo action for /users/1/undo", toast)
+	}
+	if _, ok := svc.users[1]; !ok {
+		t.Fatal("user deleted before the undo window passed")
+	}
+
+	// Hidden from the list while pending.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/users", nil)
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), ":Users=0") {
+		t.Errorf("list body = %q, want the pending user hidden", w.Body.String())
+	}
+
+	advance(DefaultUndoWindow - time.Second)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/users/1/undo", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("HX-Refresh") != "true" || parseToast(t, w)["type"] != "success" {
+		t.Fatalf("undo: status = %d, HX-Refresh = %q, toast = %v", w.Code, w.Header().Get("HX-Refresh"), parseToast(t, w))
+	}
+
+	// The window passing after the undo no longer deletes the user.
+	advance(time.Minute)
+	h.pending.sweep(false)
+	h.Close()
+	if _, ok := svc.users[1]; !ok {
+		t.Error("user deleted despite the undo")
+	}
+}
+
+func TestUndoDeleteHTMX_AfterWindowReturnsGone(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+	h, advance := newUndoHandler(t, svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+	r.ServeHTTP(w, req)
+
+	advance(DefaultUndoWindow)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/users/1/undo", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusGone {
+		t.Errorf("undo status = %d, want 410", w.Code)
+	}
+	if toast := parseToast(t, w); toast["type"] != "error" || toast["message"] != "撤销时间已过，用户已被删除" {
+		t.Errorf("toast = %v, want the expired-undo error", toast)
+	}
+
+	h.pending.sweep(false)
+	h.Close()
+	if _, ok := svc.users[1]; ok {
+		t.Error("user still present after the undo window passed")
+	}
+}
+
+func TestUserPageHandler_CloseRunsPendingDeletes(t *testing.T) {
+	svc := newMockService()
+	svc.users[1] = &domain.User{BaseModel: domain.BaseModel{ID: 1}, Name: "Alice", Email: "alice@example.com"}
+	h, _ := newUndoHandler(t, svc)
+	r := setupTestRouter(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+	r.ServeHTTP(w, req)
+
+	h.Close()
+	if len(svc.users) != 0 {
+		t.Errorf("expected 0 users after Close, got %d", len(svc.users))
+	}
+}
@@ -0,0 +1,134 @@
+package user
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+const (
+	// DefaultUndoWindow is how long a user deleted from the HTML UI can be
+	// restored with "撤销".
+	DefaultUndoWindow = 30 * time.Second
+
+	// maxPendingDeletes bounds the deletes waiting out their undo window.
+	// Once full, further page deletes run immediately without an undo.
+	maxPendingDeletes = 1000
+
+	// maxSweepInterval caps how often the janitor looks for expired deletes.
+	maxSweepInterval = time.Second
+)
+
+// pendingDeletes defers user deletion for an undo window. Users are only
+// hidden from the list page meanwhile; the janitor goroutine deletes them
+// through the service once the window has passed, and Close deletes the
+// rest. There is no soft delete, so the state lives in memory per App.
+type pendingDeletes struct {
+	svc    domain.UserService
+	window time.Duration
+	clock  pkg.Clock
+
+	mu       sync.Mutex
+	deadline map[uint]time.Time
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newPendingDeletes starts the janitor; callers must Close the result.
+func newPendingDeletes(svc domain.UserService, window time.Duration, clock pkg.Clock) *pendingDeletes {
+	p := &pendingDeletes{
+		svc:      svc,
+		window:   window,
+		clock:    clock,
+		deadline: make(map[uint]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run(min(window/2, maxSweepInterval))
+	return p
+}
+
+// schedule marks id for deletion after the undo window. It reports false
+// when id is already pending or the store is full.
+func (p *pendingDeletes) schedule(id uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.deadline[id]; ok || len(p.deadline) >= maxPendingDeletes {
+		return false
+	}
+	p.deadline[id] = p.clock.Now().Add(p.window)
+	return true
+}
+
+// undo cancels the pending delete of id. It reports false when the window
+// has passed or id was never scheduled.
+func (p *pendingDeletes) undo(id uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	deadline, ok := p.deadline[id]
+	if !ok || !p.clock.Now().Before(deadline) {
+		return false
+	}
+	delete(p.deadline, id)
+	return true
+}
+
+// isPending reports whether id is waiting out its undo window.
+func (p *pendingDeletes) isPending(id uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.deadline[id]
+	return ok
+}
+
+func (p *pendingDeletes) run(interval time.Duration) {
+	defer close(p.done)
+	timer := p.clock.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			p.sweep(false)
+			timer.Reset(interval)
+		case <-p.stop:
+			p.sweep(true)
+			return
+		}
+	}
+}
+
+// sweep deletes the users whose undo window has passed, or every pending
+// user when all is set.
+func (p *pendingDeletes) sweep(all bool) {
+	var due []uint
+	p.mu.Lock()
+	now := p.clock.Now()
+	for id, deadline := range p.deadline {
+		if all || !now.Before(deadline) {
+			due = append(due, id)
+			delete(p.deadline, id)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, id := range due {
+		if err := p.svc.DeleteUser(context.Background(), id); err != nil && !domain.IsNotFound(err) {
+			slog.Error("deferred user delete failed", "id", id, "error", err)
+		}
+	}
+}
+
+// Close stops the janitor and deletes the users still pending, so a
+// shutdown does not silently keep them.
+func (p *pendingDeletes) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+}
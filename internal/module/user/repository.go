@@ -4,32 +4,99 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/simp-lee/gobase/internal/domain"
 	"github.com/simp-lee/gobase/internal/pkg"
-	"github.com/simp-lee/pagination"
 	"gorm.io/gorm"
 )
 
-// Allowed fields for sorting and filtering in List queries.
+// changeEntityType names users in the change feed.
+const changeEntityType = "users"
+
+// Allowed fields for sorting and filtering in List queries, by the names
+// clients send, and the columns userFieldMap resolves them to. The
+// camelCase names match the keys of camelCase JSON responses.
 var (
-	allowedSortFields   = []string{"id", "name", "email", "created_at", "updated_at"}
+	allowedSortFields   = []string{"id", "name", "email", "created_at", "createdAt", "updated_at", "updatedAt"}
 	allowedFilterFields = []string{"name", "email"}
+	userFieldMap        = map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"email":      "email",
+		"created_at": "created_at",
+		"createdAt":  "created_at",
+		"updated_at": "updated_at",
+		"updatedAt":  "updated_at",
+	}
 )
 
 // userRepository implements domain.UserRepository using GORM.
 type userRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	attempts   int
+	maxBackoff time.Duration
+	payloadMax int // cap of change feed payloads; 0 is blob.DefaultMaxSize
+	dependents func() []pkg.UserDependent
+}
+
+// RepositoryOption configures a userRepository.
+type RepositoryOption func(*userRepository)
+
+// WithRetry retries writes that fail with a transient lock or serialization
+// error, up to attempts tries in total with backoff capped at maxBackoff (see
+// pkg.RetryTx). Without it every write is tried once.
+func WithRetry(attempts int, maxBackoff time.Duration) RepositoryOption {
+	return func(r *userRepository) {
+		r.attempts = attempts
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithChangePayloadMaxSize caps the JSON snapshot recorded with each change
+// feed entry at n bytes (database.change_payload_max_size); larger ones are
+// replaced by a truncation marker. A non-positive n keeps
+// blob.DefaultMaxSize.
+func WithChangePayloadMaxSize(n int) RepositoryOption {
+	return func(r *userRepository) {
+		r.payloadMax = n
+	}
+}
+
+// WithDependents makes Delete refuse, with a CodeConflict error naming
+// them, to delete a user referenced by rows of a dependent table whose
+// foreign key restricts deletes (see pkg.UserDependent). deps is called on
+// each delete, so the tables can be collected after the repository is
+// built.
+func WithDependents(deps func() []pkg.UserDependent) RepositoryOption {
+	return func(r *userRepository) {
+		r.dependents = deps
+	}
 }
 
 // NewUserRepository creates a new UserRepository backed by the given GORM database.
-func NewUserRepository(db *gorm.DB) domain.UserRepository {
-	return &userRepository{db: db}
+func NewUserRepository(db *gorm.DB, opts ...RepositoryOption) domain.UserRepository {
+	r := &userRepository{db: db, attempts: 1}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// write runs fn in a transaction under the repository's retry policy.
+func (r *userRepository) write(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return pkg.RetryTx(ctx, r.db, r.attempts, fn, pkg.WithMaxBackoff(r.maxBackoff))
 }
 
 // Create inserts a new user into the database.
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
-	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+	err := r.write(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return r.recordChange(tx, user.ID, domain.ChangeCreate, diffFields(&domain.User{}, user))
+	})
+	if err != nil {
 		return mapError(err)
 	}
 	return nil
@@ -38,7 +105,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 // GetByID retrieves a user by its primary key.
 func (r *userRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
-	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(pkg.TenantScope(ctx)).First(&user, id).Error; err != nil {
 		return nil, mapError(err)
 	}
 	return &user, nil
@@ -47,17 +114,18 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*domain.User, er
 // GetByEmail retrieves a user by email address.
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(pkg.TenantScope(ctx)).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, mapError(err)
 	}
 	return &user, nil
 }
 
 // List returns a paginated, sorted, and filtered list of users.
-func (r *userRepository) List(ctx context.Context, req domain.PageRequest) (*pagination.Pagination[domain.User], error) {
+func (r *userRepository) List(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.User], error) {
 	result, err := pkg.PaginateGORM[domain.User](ctx, r.db.WithContext(ctx).Model(&domain.User{}), req, pkg.ListOptions{
 		SortFields:   allowedSortFields,
 		FilterFields: allowedFilterFields,
+		FieldMap:     userFieldMap,
 	})
 	if err != nil {
 		return nil, mapError(err)
@@ -65,21 +133,141 @@ func (r *userRepository) List(ctx context.Context, req domain.PageRequest) (*pag
 	return result, nil
 }
 
-// Update saves changes to an existing user.
-func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+// Update saves changes to an existing user. Unlike Save, it never inserts:
+// a user that does not exist, or belongs to another tenant, is not found.
+// The stored row is read in the same transaction; when none of its fields
+// differ, no UPDATE is issued and no change is recorded, so UpdatedAt keeps
+// its value, and user is filled from the row.
+func (r *userRepository) Update(ctx context.Context, user *domain.User) (bool, error) {
+	var changed bool
+	var affected int64
+	err := r.write(ctx, func(tx *gorm.DB) error {
+		var stored domain.User
+		if err := tx.Scopes(pkg.TenantScope(ctx)).First(&stored, user.ID).Error; err != nil {
+			return err
+		}
+		if sameFields(&stored, user) {
+			*user = stored
+			return nil
+		}
+		changed = true
+		result := tx.Scopes(pkg.TenantScope(ctx)).Select("*").Updates(user)
+		affected = result.RowsAffected
+		if result.Error != nil || affected == 0 {
+			return result.Error
+		}
+		return r.recordChange(tx, user.ID, domain.ChangeUpdate, diffFields(&stored, user))
+	})
+	if err != nil {
+		return false, mapError(err)
+	}
+	if changed && affected == 0 {
+		return false, domain.ErrNotFound
+	}
+	return changed, nil
+}
+
+// sameFields reports whether user would leave the columns Update writes as
+// they are in stored; the timestamps and tenant are maintained by GORM.
+func sameFields(stored, user *domain.User) bool {
+	return stored.Name == user.Name &&
+		stored.Email == user.Email &&
+		stored.Bio == user.Bio &&
+		stored.PasswordHash == user.PasswordHash
+}
+
+// changePayload is the change feed snapshot of a user write: the fields
+// it changed, with their values before and after for the profile fields.
+// A password change is listed but its hash is never recorded.
+type changePayload struct {
+	Fields []string          `json:"fields"`
+	Before map[string]string `json:"before,omitempty"`
+	After  map[string]string `json:"after,omitempty"`
+}
+
+// diffFields returns the payload of writing user over stored; for a create,
+// stored is the zero User, so every field set is listed with no before
+// values.
+func diffFields(stored, user *domain.User) changePayload {
+	var p changePayload
+	for _, f := range []struct {
+		name          string
+		before, after string
+	}{
+		{"name", stored.Name, user.Name},
+		{"email", stored.Email, user.Email},
+		{"bio", stored.Bio, user.Bio},
+	} {
+		if f.before == f.after {
+			continue
+		}
+		p.Fields = append(p.Fields, f.name)
+		if stored.ID != 0 {
+			if p.Before == nil {
+				p.Before = map[string]string{}
+			}
+			p.Before[f.name] = f.before
+		}
+		if p.After == nil {
+			p.After = map[string]string{}
+		}
+		p.After[f.name] = f.after
+	}
+	if stored.PasswordHash != user.PasswordHash {
+		p.Fields = append(p.Fields, "password")
+	}
+	return p
+}
+
+// recordChange appends the change feed entry of a write with its payload.
+func (r *userRepository) recordChange(tx *gorm.DB, id uint, op string, payload changePayload) error {
+	return pkg.RecordChangePayload(tx, changeEntityType, id, op, payload, r.payloadMax)
+}
+
+// UpdatePasswordHash sets the password hash of user id.
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, id uint, hash string) error {
+	var affected int64
+	err := r.write(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&domain.User{}).Scopes(pkg.TenantScope(ctx)).Where("id = ?", id).Update("password_hash", hash)
+		affected = result.RowsAffected
+		if result.Error != nil || affected == 0 {
+			return result.Error
+		}
+		return r.recordChange(tx, id, domain.ChangeUpdate, changePayload{Fields: []string{"password"}})
+	})
+	if err != nil {
 		return mapError(err)
 	}
+	if affected == 0 {
+		return domain.ErrNotFound
+	}
 	return nil
 }
 
 // Delete removes a user by ID.
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&domain.User{}, id)
-	if result.Error != nil {
-		return mapError(result.Error)
+	var affected int64
+	err := r.write(ctx, func(tx *gorm.DB) error {
+		if r.dependents != nil {
+			counts, err := pkg.CountDependents(tx, r.dependents(), id)
+			if err != nil {
+				return err
+			}
+			if err := pkg.DependentsConflict(counts); err != nil {
+				return err
+			}
+		}
+		result := tx.Scopes(pkg.TenantScope(ctx)).Delete(&domain.User{}, id)
+		affected = result.RowsAffected
+		if result.Error != nil || affected == 0 {
+			return result.Error
+		}
+		return pkg.RecordChange(tx, changeEntityType, id, domain.ChangeDelete)
+	})
+	if err != nil {
+		return mapError(err)
 	}
-	if result.RowsAffected == 0 {
+	if affected == 0 {
 		return domain.ErrNotFound
 	}
 	return nil
@@ -90,9 +278,15 @@ func mapError(err error) error {
 	if err == nil {
 		return nil
 	}
+	if domain.IsUnavailable(err) || domain.IsConflict(err) {
+		return err
+	}
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return domain.ErrNotFound
 	}
+	if pkg.IsForeignKeyError(err) {
+		return domain.NewAppError(domain.CodeConflict, "user is referenced by records that must be kept", err)
+	}
 	if errors.Is(err, gorm.ErrDuplicatedKey) || isDuplicateKeyError(err) {
 		return domain.NewAppError(domain.CodeAlreadyExists, "already exists", err)
 	}
@@ -2,30 +2,23 @@ package user
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/glebarez/sqlite"
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+	"github.com/simp-lee/gobase/internal/pkg/blob"
+	"github.com/simp-lee/gobase/internal/testutil"
 	"gorm.io/gorm"
 )
 
-// setupTestDB creates an in-memory SQLite database with the User table.
-func setupTestDB(t *testing.T) *gorm.DB {
-	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("open test db: %v", err)
-	}
-	if err := db.AutoMigrate(&domain.User{}); err != nil {
-		t.Fatalf("migrate: %v", err)
-	}
-	return db
-}
-
 func TestCreateAndGetByID(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -47,7 +40,7 @@ func TestCreateAndGetByID(t *testing.T) {
 }
 
 func TestGetByID_NotFound(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 
 	_, err := repo.GetByID(context.Background(), 999)
@@ -57,7 +50,7 @@ func TestGetByID_NotFound(t *testing.T) {
 }
 
 func TestGetByEmail(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -79,7 +72,7 @@ func TestGetByEmail(t *testing.T) {
 }
 
 func TestGetByEmail_NotFound(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 
 	_, err := repo.GetByEmail(context.Background(), "nobody@example.com")
@@ -89,7 +82,7 @@ func TestGetByEmail_NotFound(t *testing.T) {
 }
 
 func TestCreate_DuplicateEmail(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -106,7 +99,7 @@ func TestCreate_DuplicateEmail(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -116,7 +109,7 @@ func TestUpdate(t *testing.T) {
 	}
 
 	user.Name = "Alice Updated"
-	if err := repo.Update(ctx, user); err != nil {
+	if _, err := repo.Update(ctx, user); err != nil {
 		t.Fatalf("Update: %v", err)
 	}
 
@@ -126,8 +119,32 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdatePasswordHash(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "old"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if err := repo.UpdatePasswordHash(ctx, user.ID, "new"); err != nil {
+		t.Fatalf("UpdatePasswordHash: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, user.ID)
+	if got.PasswordHash != "new" || got.Name != "Alice" {
+		t.Errorf("got PasswordHash=%q Name=%q; want new, Alice", got.PasswordHash, got.Name)
+	}
+
+	if err := repo.UpdatePasswordHash(ctx, 999, "new"); !domain.IsNotFound(err) {
+		t.Errorf("expected ErrNotFound for missing user, got %v", err)
+	}
+}
+
 func TestDelete(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -147,7 +164,7 @@ func TestDelete(t *testing.T) {
 }
 
 func TestDelete_NotFound(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 
 	err := repo.Delete(context.Background(), 999)
@@ -157,7 +174,7 @@ func TestDelete_NotFound(t *testing.T) {
 }
 
 func TestList_Basic(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -192,7 +209,7 @@ func TestList_Basic(t *testing.T) {
 }
 
 func TestList_Filter(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -225,7 +242,7 @@ func TestList_Filter(t *testing.T) {
 }
 
 func TestList_Empty(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 
 	result, err := repo.List(context.Background(), domain.PageRequest{
@@ -245,7 +262,7 @@ func TestList_Empty(t *testing.T) {
 }
 
 func TestList_Pagination25(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -289,7 +306,7 @@ func TestList_Pagination25(t *testing.T) {
 }
 
 func TestList_Sort(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -311,6 +328,8 @@ func TestList_Sort(t *testing.T) {
 		{"name_desc", "name:desc", "Charlie", "Alice"},
 		{"email_asc", "email:asc", "Alice", "Charlie"},
 		{"id_desc", "id:desc", "Bob", "Charlie"},
+		{"camel_alias", "createdAt:desc", "Bob", "Charlie"},
+		{"unlisted_ignored", "bio:asc", "Charlie", "Bob"},
 	}
 
 	for _, tt := range tests {
@@ -335,7 +354,7 @@ func TestList_Sort(t *testing.T) {
 }
 
 func TestList_FilterLike(t *testing.T) {
-	db := setupTestDB(t)
+	db := testutil.NewTestDB(t)
 	repo := NewUserRepository(db)
 	ctx := context.Background()
 
@@ -392,3 +411,468 @@ func TestList_FilterLike(t *testing.T) {
 		t.Errorf("TotalItems=%d; want 0", result.TotalItems)
 	}
 }
+
+// TestRepository_PrepareStmt runs the repository against a handle with
+// database.prepare_stmt and database.skip_default_transaction on, including
+// transactional retries and LIKE filters whose values change per call.
+func TestRepository_PrepareStmt(t *testing.T) {
+	db := testutil.NewTestDB(t).Session(&gorm.Session{PrepareStmt: true, SkipDefaultTransaction: true})
+	repo := NewUserRepository(db, WithRetry(3, time.Millisecond))
+	ctx := context.Background()
+
+	users := []domain.User{
+		{Name: "50% Alice", Email: "alice@example.com"},
+		{Name: "Bob_Smith", Email: "bob@example.com"},
+		{Name: "Bobby Smith", Email: "bobby@example.com"},
+	}
+	for i := range users {
+		if err := repo.Create(ctx, &users[i]); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &domain.User{Name: "Dup", Email: "bob@example.com"}); !domain.IsAlreadyExists(err) {
+		t.Errorf("duplicate Create: got %v, want ErrAlreadyExists", err)
+	}
+
+	for _, tt := range []struct {
+		filter map[string]string
+		want   int64
+	}{
+		{map[string]string{"name__like": "50%"}, 1},
+		{map[string]string{"name__like": "Bob_"}, 1},
+		{map[string]string{"name__like": "Bob"}, 2},
+		{map[string]string{"name__like": "Smith", "email__like": "bobby"}, 1},
+		{map[string]string{"email__like": "example"}, 3},
+	} {
+		result, err := repo.List(ctx, domain.PageRequest{Page: 1, PageSize: 20, Sort: "id:asc", Filter: tt.filter})
+		if err != nil {
+			t.Fatalf("List(%v): %v", tt.filter, err)
+		}
+		if result.TotalItems != tt.want || int64(len(result.Items)) != tt.want {
+			t.Errorf("List(%v) = %d total, %d items; want %d", tt.filter, result.TotalItems, len(result.Items), tt.want)
+		}
+	}
+
+	users[0].Name = "Alice"
+	if _, err := repo.Update(ctx, &users[0]); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, users[0].ID); err != nil || got.Name != "Alice" {
+		t.Errorf("GetByID after Update = %+v, %v", got, err)
+	}
+	if err := repo.Delete(ctx, users[1].ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, users[1].ID); !domain.IsNotFound(err) {
+		t.Errorf("GetByID after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+// busyError mimics the SQLite driver's SQLITE_BUSY error.
+type busyError struct{}
+
+func (busyError) Error() string { return "database is locked" }
+func (busyError) Code() int     { return 5 }
+
+func TestCreate_RetriesBusyDatabase(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	failures := 2
+	err := db.Callback().Create().Before("gorm:create").Register("test:busy", func(tx *gorm.DB) {
+		if failures > 0 {
+			failures--
+			tx.AddError(busyError{})
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	ctx := context.Background()
+
+	repo := NewUserRepository(db, WithRetry(3, time.Millisecond))
+	user := &domain.User{Name: "Alice", Email: "alice@example.com"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if failures != 0 {
+		t.Errorf("failures left = %d, want 0", failures)
+	}
+	if _, err := repo.GetByID(ctx, user.ID); err != nil {
+		t.Errorf("GetByID after retried Create: %v", err)
+	}
+}
+
+func TestCreate_NoRetryByDefault(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	calls := 0
+	err := db.Callback().Create().Before("gorm:create").Register("test:busy", func(tx *gorm.DB) {
+		calls++
+		tx.AddError(busyError{})
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	repo := NewUserRepository(db)
+	err = repo.Create(context.Background(), &domain.User{Name: "Alice", Email: "alice@example.com"})
+	if err == nil {
+		t.Fatal("Create: want error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	a := &domain.User{Name: "Alice", Email: "alice@acme.test"}
+	if err := repo.Create(acme, a); err != nil {
+		t.Fatalf("Create(acme): %v", err)
+	}
+	// A tenant set by the caller is overridden by the context's.
+	b := &domain.User{Name: "Bob", Email: "bob@globex.test", TenantID: "acme"}
+	if err := repo.Create(globex, b); err != nil {
+		t.Fatalf("Create(globex): %v", err)
+	}
+	if a.TenantID != "acme" || b.TenantID != "globex" {
+		t.Fatalf("stamped tenants = %q, %q; want acme, globex", a.TenantID, b.TenantID)
+	}
+
+	list, err := repo.List(acme, domain.PageRequest{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("List(acme): %v", err)
+	}
+	if list.TotalItems != 1 || len(list.Items) != 1 || list.Items[0].ID != a.ID {
+		t.Errorf("List(acme) = %d items %+v; want only Alice", list.TotalItems, list.Items)
+	}
+
+	// Every access to the other tenant's user is not found, never forbidden.
+	if _, err := repo.GetByID(acme, b.ID); !domain.IsNotFound(err) {
+		t.Errorf("GetByID(acme, globex user) error = %v; want not found", err)
+	}
+	if _, err := repo.GetByEmail(acme, b.Email); !domain.IsNotFound(err) {
+		t.Errorf("GetByEmail(acme, globex user) error = %v; want not found", err)
+	}
+	stolen := *b
+	stolen.Name = "Mallory"
+	if _, err := repo.Update(acme, &stolen); !domain.IsNotFound(err) {
+		t.Errorf("Update(acme, globex user) error = %v; want not found", err)
+	}
+	if err := repo.UpdatePasswordHash(acme, b.ID, "x"); !domain.IsNotFound(err) {
+		t.Errorf("UpdatePasswordHash(acme, globex user) error = %v; want not found", err)
+	}
+	if err := repo.Delete(acme, b.ID); !domain.IsNotFound(err) {
+		t.Errorf("Delete(acme, globex user) error = %v; want not found", err)
+	}
+	got, err := repo.GetByID(globex, b.ID)
+	if err != nil || got.Name != "Bob" || got.PasswordHash != "" {
+		t.Fatalf("GetByID(globex) = %+v, %v; want Bob unchanged", got, err)
+	}
+
+	// Within its own tenant the user can be changed and removed.
+	got.Name = "Bobby"
+	if _, err := repo.Update(globex, got); err != nil {
+		t.Errorf("Update(globex): %v", err)
+	}
+	if err := repo.Delete(globex, b.ID); err != nil {
+		t.Errorf("Delete(globex): %v", err)
+	}
+
+	// Without a tenant in the context, as in background jobs, nothing is scoped.
+	if all, err := repo.List(context.Background(), domain.PageRequest{Page: 1, PageSize: 10}); err != nil || all.TotalItems != 1 {
+		t.Errorf("List(no tenant) = %v, %v; want Alice", all, err)
+	}
+
+	// Emails are unique per tenant: another tenant may register the same
+	// address without learning that it is taken elsewhere.
+	if err := repo.Create(globex, &domain.User{Name: "Alice", Email: a.Email}); err != nil {
+		t.Errorf("Create(globex) with acme's email: %v; want it accepted", err)
+	}
+	if err := repo.Create(acme, &domain.User{Name: "Alice", Email: a.Email}); !domain.IsAlreadyExists(err) {
+		t.Errorf("Create(acme) with a taken email error = %v; want already exists", err)
+	}
+}
+
+func TestTimestamps_StoredUnchangedAndSerializedAsUTCMillis(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &domain.User{Name: "Alice", Email: "alice@example.com"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+		t.Fatalf("timestamps not set on create: %+v", user.BaseModel)
+	}
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.CreatedAt.Equal(user.CreatedAt.Time) {
+		t.Errorf("stored CreatedAt = %v, want %v", got.CreatedAt.Time, user.CreatedAt.Time)
+	}
+
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`"created_at":"\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{3}Z"`)
+	if !pattern.Match(b) {
+		t.Errorf("user JSON = %s, want created_at in UTC RFC 3339 with milliseconds", b)
+	}
+}
+
+func TestChangeFeed_RecordsMutationsInOrder(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := &domain.User{Name: "Alice", Email: "alice@example.com"}
+	bob := &domain.User{Name: "Bob", Email: "bob@example.com"}
+	for _, u := range []*domain.User{alice, bob} {
+		if err := repo.Create(ctx, u); err != nil {
+			t.Fatalf("Create(%s): %v", u.Name, err)
+		}
+	}
+	alice.Name = "Alicia"
+	if _, err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.Delete(ctx, bob.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.UpdatePasswordHash(ctx, alice.ID, "hash"); err != nil {
+		t.Fatalf("UpdatePasswordHash: %v", err)
+	}
+
+	var changes []domain.Change
+	if err := db.Order("id").Find(&changes).Error; err != nil {
+		t.Fatalf("read changes: %v", err)
+	}
+	want := []struct {
+		id uint
+		op string
+	}{
+		{alice.ID, domain.ChangeCreate},
+		{bob.ID, domain.ChangeCreate},
+		{alice.ID, domain.ChangeUpdate},
+		{bob.ID, domain.ChangeDelete},
+		{alice.ID, domain.ChangeUpdate},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes %+v, want %d", len(changes), changes, len(want))
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.EntityType != "users" || c.EntityID != fmt.Sprint(w.id) || c.Op != w.op || c.ChangedAt.IsZero() {
+			t.Errorf("change %d = %+v; want users %d %s", i, c, w.id, w.op)
+		}
+	}
+}
+
+func TestChangeFeed_FailedMutationWritesNoChange(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := &domain.User{Name: "Alice", Email: "alice@example.com"}
+	if err := repo.Create(ctx, alice); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	countChanges := func() int64 {
+		t.Helper()
+		var n int64
+		if err := db.Model(&domain.Change{}).Count(&n).Error; err != nil {
+			t.Fatalf("count changes: %v", err)
+		}
+		return n
+	}
+
+	// Mutations that fail, or match no row, leave the feed alone.
+	if err := repo.Create(ctx, &domain.User{Name: "Dup", Email: "alice@example.com"}); !domain.IsAlreadyExists(err) {
+		t.Fatalf("Create(duplicate) error = %v; want already exists", err)
+	}
+	if _, err := repo.Update(ctx, &domain.User{BaseModel: domain.BaseModel{ID: 999}, Name: "Ghost", Email: "ghost@example.com"}); !domain.IsNotFound(err) {
+		t.Fatalf("Update(missing) error = %v; want not found", err)
+	}
+	if err := repo.Delete(ctx, 999); !domain.IsNotFound(err) {
+		t.Fatalf("Delete(missing) error = %v; want not found", err)
+	}
+	if n := countChanges(); n != 1 {
+		t.Errorf("changes = %d, want 1 (only the successful create)", n)
+	}
+
+	// A change that cannot be recorded rolls back the mutation with it.
+	if err := db.Migrator().DropTable(&domain.Change{}); err != nil {
+		t.Fatalf("drop changes: %v", err)
+	}
+	if err := repo.Create(ctx, &domain.User{Name: "Bob", Email: "bob@example.com"}); err == nil {
+		t.Fatal("Create without a changes table succeeded; want an error")
+	}
+	if _, err := repo.GetByEmail(ctx, "bob@example.com"); !domain.IsNotFound(err) {
+		t.Errorf("GetByEmail(bob) error = %v; want not found after the rollback", err)
+	}
+	if err := repo.Delete(ctx, alice.ID); err == nil {
+		t.Fatal("Delete without a changes table succeeded; want an error")
+	}
+	if _, err := repo.GetByID(ctx, alice.ID); err != nil {
+		t.Errorf("GetByID(alice) error = %v; want the user kept after the rollback", err)
+	}
+}
+
+// changePayloads returns the decoded payloads of the change feed in order.
+func changePayloads(t *testing.T, db *gorm.DB) []changePayload {
+	t.Helper()
+	var changes []domain.Change
+	if err := db.Order("id").Find(&changes).Error; err != nil {
+		t.Fatalf("read changes: %v", err)
+	}
+	payloads := make([]changePayload, len(changes))
+	for i, c := range changes {
+		raw, err := blob.Decode(c.PayloadData, c.PayloadCompressed)
+		if err != nil {
+			t.Fatalf("decode payload %d: %v", i, err)
+		}
+		if err := json.Unmarshal(raw, &payloads[i]); err != nil {
+			t.Fatalf("unmarshal payload %d %s: %v", i, raw, err)
+		}
+	}
+	return payloads
+}
+
+func TestChangeFeed_PayloadsRecordChangedFields(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+
+	alice := &domain.User{Name: "Alice", Email: "alice@example.com", PasswordHash: "h1"}
+	if err := repo.Create(ctx, alice); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	alice.Name, alice.Bio = "Alicia", "Hello"
+	if _, err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.UpdatePasswordHash(ctx, alice.ID, "h2"); err != nil {
+		t.Fatalf("UpdatePasswordHash: %v", err)
+	}
+
+	got := changePayloads(t, db)
+	want := []changePayload{
+		{Fields: []string{"name", "email", "password"}, After: map[string]string{"name": "Alice", "email": "alice@example.com"}},
+		{Fields: []string{"name", "bio"}, Before: map[string]string{"name": "Alice", "bio": ""}, After: map[string]string{"name": "Alicia", "bio": "Hello"}},
+		{Fields: []string{"password"}},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("payloads = %+v\nwant %+v", got, want)
+	}
+	for i, p := range got {
+		if strings.Contains(fmt.Sprint(p), "h1") || strings.Contains(fmt.Sprint(p), "h2") {
+			t.Errorf("payload %d %+v records a password hash", i, p)
+		}
+	}
+}
+
+func TestChangeFeed_LargePayloadsCompressedAndCapped(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db, WithChangePayloadMaxSize(8<<10))
+	ctx := context.Background()
+
+	// A 5KB bio fits under the cap and is stored compressed.
+	alice := &domain.User{Name: "Alice", Email: "alice@example.com", Bio: strings.Repeat("bio ", 1250)}
+	if err := repo.Create(ctx, alice); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// Its replacement makes the before/after diff about 10KB, over the cap.
+	alice.Bio = strings.Repeat("new ", 1250)
+	if _, err := repo.Update(ctx, alice); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	var changes []domain.Change
+	if err := db.Order("id").Find(&changes).Error; err != nil {
+		t.Fatalf("read changes: %v", err)
+	}
+	if len(changes) != 2 || !changes[0].PayloadCompressed || len(changes[0].PayloadData) >= 1<<10 {
+		t.Fatalf("create payload: compressed %v, %d bytes; want compressed below 1KB", changes[0].PayloadCompressed, len(changes[0].PayloadData))
+	}
+	created, err := blob.Decode(changes[0].PayloadData, true)
+	if err != nil || !strings.Contains(string(created), "bio bio bio") {
+		t.Errorf("create payload = %.80s, %v; want the bio", created, err)
+	}
+	updated, err := blob.Decode(changes[1].PayloadData, changes[1].PayloadCompressed)
+	if err != nil {
+		t.Fatalf("decode update payload: %v", err)
+	}
+	var marker struct {
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.Unmarshal(updated, &marker); err != nil || !marker.Truncated {
+		t.Errorf("update payload = %.80s, want the truncation marker", updated)
+	}
+}
+
+// retainedRecord references users with a key that restricts deletes.
+type retainedRecord struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint
+	User   *domain.User `gorm:"constraint:OnDelete:RESTRICT"`
+}
+
+func TestDelete_Dependents(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	if err := db.AutoMigrate(&retainedRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	deps := []pkg.UserDependent{
+		{Name: "notifications", Model: &domain.Notification{}},
+		{Name: "login_attempts", Model: &domain.LoginAttempt{}},
+		{Name: "retained_records", Model: &retainedRecord{}},
+	}
+	repo := NewUserRepository(db, WithDependents(func() []pkg.UserDependent { return deps }))
+	ctx := context.Background()
+	users := testutil.SeedUsers(t, db, domain.User{}, domain.User{})
+	alice, bob := users[0], users[1]
+	for _, rec := range []any{
+		&domain.Notification{UserID: alice.ID, Type: "test", Title: "hi"},
+		&domain.LoginAttempt{UserID: &alice.ID, Email: alice.Email, Success: true},
+		&retainedRecord{UserID: bob.ID},
+	} {
+		if err := db.Create(rec).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	// Notifications cascade and login attempts lose the reference.
+	if err := repo.Delete(ctx, alice.ID); err != nil {
+		t.Fatalf("Delete(alice): %v", err)
+	}
+	var notifications int64
+	db.Model(&domain.Notification{}).Count(&notifications)
+	if notifications != 0 {
+		t.Errorf("notifications after delete = %d, want 0", notifications)
+	}
+	var attempt domain.LoginAttempt
+	if err := db.First(&attempt).Error; err != nil || attempt.UserID != nil || attempt.Email != alice.Email {
+		t.Errorf("login attempt after delete = %+v, %v; want it kept with a nil user", attempt, err)
+	}
+
+	// A restricting row keeps the user, with a conflict naming it.
+	err := repo.Delete(ctx, bob.ID)
+	if !domain.IsConflict(err) || domain.HTTPStatusCode(err) != http.StatusConflict || !strings.Contains(err.Error(), "retained_records (1)") {
+		t.Errorf("Delete(bob) = %v, want a 409 conflict naming retained_records (1)", err)
+	}
+	if _, err := repo.GetByID(ctx, bob.ID); err != nil {
+		t.Errorf("GetByID(bob) after refused delete: %v", err)
+	}
+
+	// Without the pre-check the foreign key still refuses, as a conflict.
+	err = NewUserRepository(db).Delete(ctx, bob.ID)
+	if !domain.IsConflict(err) {
+		t.Errorf("Delete(bob) without dependents = %v, want a conflict", err)
+	}
+}
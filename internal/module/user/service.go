@@ -2,41 +2,70 @@ package user
 
 import (
 	"context"
+	"fmt"
 	"net/mail"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/simp-lee/gobase/internal/domain"
-	"github.com/simp-lee/pagination"
 )
 
 // userService implements domain.UserService.
 type userService struct {
-	repo domain.UserRepository
+	repo   domain.UserRepository
+	events domain.EventPublisher
+}
+
+// ServiceOption configures optional userService behavior.
+type ServiceOption func(*userService)
+
+// WithEvents publishes a user.created, user.updated or user.deleted event
+// to pub after each successful write, for the live event stream.
+func WithEvents(pub domain.EventPublisher) ServiceOption {
+	return func(s *userService) {
+		s.events = pub
+	}
 }
 
 // NewUserService creates a new UserService with the given repository.
-func NewUserService(repo domain.UserRepository) domain.UserService {
-	return &userService{repo: repo}
+func NewUserService(repo domain.UserRepository, opts ...ServiceOption) domain.UserService {
+	s := &userService{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// publish sends an event of type kind if WithEvents is set.
+func (s *userService) publish(ctx context.Context, kind string, data any) {
+	if s.events != nil {
+		s.events.PublishEvent(ctx, domain.Event{Type: kind, Data: data})
+	}
 }
 
 // CreateUser validates input, builds a User, and persists it via the repository.
-func (s *userService) CreateUser(ctx context.Context, name, email string) (*domain.User, error) {
+func (s *userService) CreateUser(ctx context.Context, name, email, bio string) (*domain.User, error) {
 	name = strings.TrimSpace(name)
 	email = strings.TrimSpace(email)
+	bio = strings.TrimSpace(bio)
 
 	if err := validateNameEmail(name, email); err != nil {
 		return nil, err
 	}
+	if err := validateBio(bio); err != nil {
+		return nil, err
+	}
 
 	user := &domain.User{
 		Name:  name,
 		Email: email,
+		Bio:   bio,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	s.publish(ctx, domain.EventUserCreated, user)
 
 	return user, nil
 }
@@ -47,58 +76,131 @@ func (s *userService) GetUser(ctx context.Context, id uint) (*domain.User, error
 }
 
 // ListUsers returns a paginated list of users.
-func (s *userService) ListUsers(ctx context.Context, req domain.PageRequest) (*pagination.Pagination[domain.User], error) {
+func (s *userService) ListUsers(ctx context.Context, req domain.PageRequest) (*domain.PageResult[domain.User], error) {
 	return s.repo.List(ctx, req)
 }
 
 // UpdateUser loads the existing user, applies changes, and persists them.
-func (s *userService) UpdateUser(ctx context.Context, id uint, name, email string) (*domain.User, error) {
+// Values equal to the stored ones are not written and publish no event.
+func (s *userService) UpdateUser(ctx context.Context, id uint, name, email, bio string) (*domain.User, bool, error) {
 	name = strings.TrimSpace(name)
 	email = strings.TrimSpace(email)
+	bio = strings.TrimSpace(bio)
 
 	if err := validateNameEmail(name, email); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if err := validateBio(bio); err != nil {
+		return nil, false, err
 	}
 
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	user.Name = name
 	user.Email = email
+	user.Bio = bio
 
-	if err := s.repo.Update(ctx, user); err != nil {
-		return nil, err
+	return s.update(ctx, user)
+}
+
+// PatchUser loads the existing user, applies the fields set in patch, and
+// persists the result. A null bio clears it; a null name or email is rejected
+// because both are required. As for UpdateUser, a patch that changes nothing
+// is not written.
+func (s *userService) PatchUser(ctx context.Context, id uint, patch domain.UserPatch) (*domain.User, bool, error) {
+	if patch.Name.Null {
+		return nil, false, domain.NewAppError(domain.CodeValidation, "name cannot be null", nil)
+	}
+	if patch.Email.Null {
+		return nil, false, domain.NewAppError(domain.CodeValidation, "email cannot be null", nil)
 	}
 
-	return user, nil
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	name, email, bio := user.Name, user.Email, user.Bio
+	if patch.Name.Set {
+		name = strings.TrimSpace(patch.Name.Value)
+	}
+	if patch.Email.Set {
+		email = strings.TrimSpace(patch.Email.Value)
+	}
+	if patch.Bio.Set {
+		bio = strings.TrimSpace(patch.Bio.Value) // "" when null
+	}
+
+	if err := validateNameEmail(name, email); err != nil {
+		return nil, false, err
+	}
+	if err := validateBio(bio); err != nil {
+		return nil, false, err
+	}
+
+	user.Name = name
+	user.Email = email
+	user.Bio = bio
+
+	return s.update(ctx, user)
+}
+
+// update persists user and publishes user.updated, unless the repository
+// found nothing to change.
+func (s *userService) update(ctx context.Context, user *domain.User) (*domain.User, bool, error) {
+	changed, err := s.repo.Update(ctx, user)
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		s.publish(ctx, domain.EventUserUpdated, user)
+	}
+	return user, changed, nil
 }
 
 // DeleteUser removes a user by ID.
 func (s *userService) DeleteUser(ctx context.Context, id uint) error {
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.publish(ctx, domain.EventUserDeleted, domain.EventRef{ID: id})
+	return nil
 }
 
-// validateNameEmail checks that name and email are non-empty.
+// validateNameEmail checks that name and email are set, valid, and within
+// the domain length limits.
 func validateNameEmail(name, email string) error {
 	trimmedName := strings.TrimSpace(name)
 	if trimmedName == "" {
 		return domain.NewAppError(domain.CodeValidation, "name is required", nil)
 	}
-	if utf8.RuneCountInString(trimmedName) < 2 {
-		return domain.NewAppError(domain.CodeValidation, "name must be at least 2 characters", nil)
+	if utf8.RuneCountInString(trimmedName) < domain.NameMinLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("name must be at least %d characters", domain.NameMinLen), nil)
 	}
-	if utf8.RuneCountInString(trimmedName) > 100 {
-		return domain.NewAppError(domain.CodeValidation, "name must be at most 100 characters", nil)
+	if utf8.RuneCountInString(trimmedName) > domain.NameMaxLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("name must be at most %d characters", domain.NameMaxLen), nil)
 	}
 
 	trimmedEmail := strings.TrimSpace(email)
 	if trimmedEmail == "" {
 		return domain.NewAppError(domain.CodeValidation, "email is required", nil)
 	}
+	if utf8.RuneCountInString(trimmedEmail) > domain.EmailMaxLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("email must be at most %d characters", domain.EmailMaxLen), nil)
+	}
 	if _, err := mail.ParseAddress(trimmedEmail); err != nil {
 		return domain.NewAppError(domain.CodeValidation, "email must be a valid email address", nil)
 	}
 	return nil
 }
+
+// validateBio checks that the markdown bio does not exceed domain.BioMaxLen.
+func validateBio(bio string) error {
+	if utf8.RuneCountInString(bio) > domain.BioMaxLen {
+		return domain.NewAppError(domain.CodeValidation, fmt.Sprintf("bio must be at most %d characters", domain.BioMaxLen), nil)
+	}
+	return nil
+}
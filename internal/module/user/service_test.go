@@ -3,11 +3,15 @@ package user
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/simp-lee/pagination"
 
 	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/testutil"
 )
 
 // --- mock repository ---
@@ -66,14 +70,23 @@ func (m *mockUserRepo) List(_ context.Context, req domain.PageRequest) (*paginat
 	}, nil
 }
 
-func (m *mockUserRepo) Update(_ context.Context, user *domain.User) error {
+func (m *mockUserRepo) Update(_ context.Context, user *domain.User) (bool, error) {
 	if m.updateErr != nil {
-		return m.updateErr
+		return false, m.updateErr
 	}
 	if _, ok := m.users[user.ID]; !ok {
-		return domain.ErrNotFound
+		return false, domain.ErrNotFound
 	}
 	m.users[user.ID] = user
+	return true, nil
+}
+
+func (m *mockUserRepo) UpdatePasswordHash(_ context.Context, id uint, hash string) error {
+	u, ok := m.users[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	u.PasswordHash = hash
 	return nil
 }
 
@@ -114,7 +127,7 @@ func TestCreateUser(t *testing.T) {
 			repo.createErr = tt.createErr
 			svc := NewUserService(repo)
 
-			user, err := svc.CreateUser(context.Background(), tt.userName, tt.email)
+			user, err := svc.CreateUser(context.Background(), tt.userName, tt.email, "")
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -148,7 +161,7 @@ func TestGetUser(t *testing.T) {
 	svc := NewUserService(repo)
 
 	// seed
-	created, err := svc.CreateUser(context.Background(), "Bob", "bob@example.com")
+	created, err := svc.CreateUser(context.Background(), "Bob", "bob@example.com", "")
 	if err != nil {
 		t.Fatalf("setup: %v", err)
 	}
@@ -176,8 +189,8 @@ func TestListUsers(t *testing.T) {
 		repo := newMockRepo()
 		svc := NewUserService(repo)
 
-		_, _ = svc.CreateUser(context.Background(), "Al", "a@example.com")
-		_, _ = svc.CreateUser(context.Background(), "Bo", "b@example.com")
+		_, _ = svc.CreateUser(context.Background(), "Al", "a@example.com", "")
+		_, _ = svc.CreateUser(context.Background(), "Bo", "b@example.com", "")
 
 		result, err := svc.ListUsers(context.Background(), domain.PageRequest{Page: 1, PageSize: 10})
 		if err != nil {
@@ -211,7 +224,7 @@ func TestListUsers(t *testing.T) {
 		repo := newMockRepo()
 		svc := NewUserService(repo)
 
-		_, _ = svc.CreateUser(context.Background(), "Al", "a@example.com")
+		_, _ = svc.CreateUser(context.Background(), "Al", "a@example.com", "")
 
 		result, err := svc.ListUsers(context.Background(), domain.PageRequest{Page: 3, PageSize: 25})
 		if err != nil {
@@ -230,10 +243,10 @@ func TestUpdateUser(t *testing.T) {
 	repo := newMockRepo()
 	svc := NewUserService(repo)
 
-	created, _ := svc.CreateUser(context.Background(), "Old", "old@example.com")
+	created, _ := svc.CreateUser(context.Background(), "Old", "old@example.com", "")
 
 	t.Run("success", func(t *testing.T) {
-		updated, err := svc.UpdateUser(context.Background(), created.ID, "New", "new@example.com")
+		updated, _, err := svc.UpdateUser(context.Background(), created.ID, "New", "new@example.com", "")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -246,42 +259,42 @@ func TestUpdateUser(t *testing.T) {
 	})
 
 	t.Run("empty name", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "", "new@example.com")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "", "new@example.com", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
 	})
 
 	t.Run("whitespace name", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "   ", "new@example.com")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "   ", "new@example.com", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
 	})
 
 	t.Run("empty email", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "New", "")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "New", "", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
 	})
 
 	t.Run("short name", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "A", "new@example.com")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "A", "new@example.com", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
 	})
 
 	t.Run("invalid email format", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "New", "not-an-email")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "New", "not-an-email", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
 	})
 
 	t.Run("whitespace email", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), created.ID, "New", "   ")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "New", "   ", "")
 		if !domain.IsValidation(err) {
 			t.Errorf("expected validation error, got %v", err)
 		}
@@ -289,7 +302,7 @@ func TestUpdateUser(t *testing.T) {
 
 	t.Run("repo update error", func(t *testing.T) {
 		repo.updateErr = errors.New("db error")
-		_, err := svc.UpdateUser(context.Background(), created.ID, "New", "new@example.com")
+		_, _, err := svc.UpdateUser(context.Background(), created.ID, "New", "new@example.com", "")
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
@@ -297,7 +310,76 @@ func TestUpdateUser(t *testing.T) {
 	})
 
 	t.Run("not found", func(t *testing.T) {
-		_, err := svc.UpdateUser(context.Background(), 9999, "Xi", "x@example.com")
+		_, _, err := svc.UpdateUser(context.Background(), 9999, "Xi", "x@example.com", "")
+		if !domain.IsNotFound(err) {
+			t.Errorf("expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestPatchUser(t *testing.T) {
+	repo := newMockRepo()
+	svc := NewUserService(repo)
+	ctx := context.Background()
+
+	created, _ := svc.CreateUser(ctx, "Old", "old@example.com", "old bio")
+
+	t.Run("present value replaces", func(t *testing.T) {
+		updated, _, err := svc.PatchUser(ctx, created.ID, domain.UserPatch{
+			Name: domain.PatchField[string]{Set: true, Value: "  New  "},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if updated.Name != "New" {
+			t.Errorf("name = %q; want New", updated.Name)
+		}
+		if updated.Email != "old@example.com" || updated.Bio != "old bio" {
+			t.Errorf("absent fields changed: email = %q, bio = %q", updated.Email, updated.Bio)
+		}
+	})
+
+	t.Run("absent keeps everything", func(t *testing.T) {
+		updated, _, err := svc.PatchUser(ctx, created.ID, domain.UserPatch{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if updated.Name != "New" || updated.Email != "old@example.com" || updated.Bio != "old bio" {
+			t.Errorf("user = %+v; want unchanged", updated)
+		}
+	})
+
+	t.Run("null bio clears", func(t *testing.T) {
+		updated, _, err := svc.PatchUser(ctx, created.ID, domain.UserPatch{
+			Bio: domain.PatchField[string]{Set: true, Null: true},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if updated.Bio != "" {
+			t.Errorf("bio = %q; want cleared", updated.Bio)
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		patch domain.UserPatch
+	}{
+		{"null name", domain.UserPatch{Name: domain.PatchField[string]{Set: true, Null: true}}},
+		{"null email", domain.UserPatch{Email: domain.PatchField[string]{Set: true, Null: true}}},
+		{"empty name", domain.UserPatch{Name: domain.PatchField[string]{Set: true}}},
+		{"invalid email", domain.UserPatch{Email: domain.PatchField[string]{Set: true, Value: "nope"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := svc.PatchUser(ctx, created.ID, tc.patch)
+			if !domain.IsValidation(err) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, _, err := svc.PatchUser(ctx, 9999, domain.UserPatch{})
 		if !domain.IsNotFound(err) {
 			t.Errorf("expected not found error, got %v", err)
 		}
@@ -308,7 +390,7 @@ func TestDeleteUser(t *testing.T) {
 	repo := newMockRepo()
 	svc := NewUserService(repo)
 
-	created, _ := svc.CreateUser(context.Background(), "Del", "del@example.com")
+	created, _ := svc.CreateUser(context.Background(), "Del", "del@example.com", "")
 
 	t.Run("success", func(t *testing.T) {
 		err := svc.DeleteUser(context.Background(), created.ID)
@@ -342,7 +424,7 @@ func TestCreateUser_TrimsWhitespace(t *testing.T) {
 	repo := newMockRepo()
 	svc := NewUserService(repo)
 
-	user, err := svc.CreateUser(context.Background(), "  Alice  ", "  alice@example.com  ")
+	user, err := svc.CreateUser(context.Background(), "  Alice  ", "  alice@example.com  ", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -358,9 +440,9 @@ func TestUpdateUser_TrimsWhitespace(t *testing.T) {
 	repo := newMockRepo()
 	svc := NewUserService(repo)
 
-	created, _ := svc.CreateUser(context.Background(), "Old", "old@example.com")
+	created, _ := svc.CreateUser(context.Background(), "Old", "old@example.com", "")
 
-	updated, err := svc.UpdateUser(context.Background(), created.ID, "  New  ", "  new@example.com  ")
+	updated, _, err := svc.UpdateUser(context.Background(), created.ID, "  New  ", "  new@example.com  ", "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -371,3 +453,122 @@ func TestUpdateUser_TrimsWhitespace(t *testing.T) {
 		t.Errorf("email = %q; want %q", updated.Email, "new@example.com")
 	}
 }
+
+func TestCreateUser_Bio(t *testing.T) {
+	repo := newMockRepo()
+	svc := NewUserService(repo)
+
+	t.Run("trimmed and stored", func(t *testing.T) {
+		user, err := svc.CreateUser(context.Background(), "Alice", "alice@example.com", "  **hello**  ")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if user.Bio != "**hello**" {
+			t.Errorf("bio = %q; want %q", user.Bio, "**hello**")
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := svc.CreateUser(context.Background(), "Bob", "bob@example.com", strings.Repeat("x", domain.BioMaxLen+1))
+		if !domain.IsValidation(err) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+	})
+}
+
+// eventRecorder collects the events published to it.
+type eventRecorder struct{ events []domain.Event }
+
+func (p *eventRecorder) PublishEvent(_ context.Context, e domain.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestUserService_PublishesEvents(t *testing.T) {
+	repo := newMockRepo()
+	pub := &eventRecorder{}
+	svc := NewUserService(repo, WithEvents(pub))
+	ctx := context.Background()
+
+	created, err := svc.CreateUser(ctx, "Ev", "ev@example.com", "")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, _, err := svc.UpdateUser(ctx, created.ID, "Ev2", "ev@example.com", ""); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if err := svc.DeleteUser(ctx, 9999); !domain.IsNotFound(err) {
+		t.Fatalf("DeleteUser(missing) error = %v, want not found", err)
+	}
+	if err := svc.DeleteUser(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+
+	var types []string
+	for _, e := range pub.events {
+		types = append(types, e.Type)
+	}
+	want := []string{domain.EventUserCreated, domain.EventUserUpdated, domain.EventUserDeleted}
+	if !slices.Equal(types, want) {
+		t.Fatalf("published %v, want %v (failed writes publish nothing)", types, want)
+	}
+	if ref, ok := pub.events[2].Data.(domain.EventRef); !ok || ref.ID != created.ID {
+		t.Errorf("delete event data = %#v, want EventRef{ID: %d}", pub.events[2].Data, created.ID)
+	}
+}
+
+func TestUserService_UnchangedUpdateHasNoSideEffects(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	pub := &eventRecorder{}
+	svc := NewUserService(NewUserRepository(db), WithEvents(pub))
+	ctx := context.Background()
+
+	created, err := svc.CreateUser(ctx, "Alice", "alice@example.com", "Hello")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	countChanges := func() int64 {
+		t.Helper()
+		var n int64
+		if err := db.Model(&domain.Change{}).Count(&n).Error; err != nil {
+			t.Fatalf("count changes: %v", err)
+		}
+		return n
+	}
+	pub.events = nil
+	time.Sleep(5 * time.Millisecond) // a write would move UpdatedAt
+
+	// The same values after normalization, as a full update and as a patch.
+	got, modified, err := svc.UpdateUser(ctx, created.ID, " Alice ", "alice@example.com ", "Hello")
+	if err != nil || modified {
+		t.Fatalf("UpdateUser(same) = modified %v, error %v; want unmodified", modified, err)
+	}
+	if !got.UpdatedAt.Equal(created.UpdatedAt.Time) || got.Name != "Alice" {
+		t.Errorf("UpdateUser(same) = %+v; want the stored user with UpdatedAt %v", got, created.UpdatedAt)
+	}
+	name := domain.PatchField[string]{Set: true, Value: "Alice"}
+	if got, modified, err = svc.PatchUser(ctx, created.ID, domain.UserPatch{Name: name}); err != nil || modified {
+		t.Fatalf("PatchUser(same) = modified %v, error %v; want unmodified", modified, err)
+	}
+	if _, modified, err = svc.PatchUser(ctx, created.ID, domain.UserPatch{}); err != nil || modified {
+		t.Fatalf("PatchUser(empty) = modified %v, error %v; want unmodified", modified, err)
+	}
+	stored, err := svc.GetUser(ctx, created.ID)
+	if err != nil || !stored.UpdatedAt.Equal(created.UpdatedAt.Time) {
+		t.Errorf("stored UpdatedAt = %v, %v; want unchanged %v", stored.UpdatedAt, err, created.UpdatedAt)
+	}
+	if len(pub.events) != 0 || countChanges() != 1 {
+		t.Errorf("events = %v, changes = %d; want none beyond the create", pub.events, countChanges())
+	}
+
+	// A real change still goes through everything.
+	got, modified, err = svc.PatchUser(ctx, created.ID, domain.UserPatch{Bio: domain.PatchField[string]{Set: true, Value: "Bye"}})
+	if err != nil || !modified {
+		t.Fatalf("PatchUser(bio) = modified %v, error %v; want modified", modified, err)
+	}
+	if !got.UpdatedAt.After(created.UpdatedAt.Time) {
+		t.Errorf("UpdatedAt = %v, want after %v", got.UpdatedAt, created.UpdatedAt)
+	}
+	if len(pub.events) != 1 || pub.events[0].Type != domain.EventUserUpdated || countChanges() != 2 {
+		t.Errorf("events = %v, changes = %d; want one user.updated and a recorded change", pub.events, countChanges())
+	}
+}
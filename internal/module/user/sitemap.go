@@ -0,0 +1,44 @@
+package user
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg"
+)
+
+// sitemapBatchSize is how many users SitemapEntries loads per query.
+const sitemapBatchSize = 100
+
+// SitemapEntries lists the user list page, last modified when the most
+// recently updated user was, and the detail page of every user with its
+// UpdatedAt, up to pkg.SitemapMaxURLs. Users awaiting a deferred delete are
+// left out.
+func (h *UserPageHandler) SitemapEntries(ctx context.Context) ([]pkg.SitemapEntry, error) {
+	req := domain.PageRequest{Page: 1, PageSize: sitemapBatchSize, Sort: "id:asc"}
+	list := pkg.SitemapEntry{Path: "/users"}
+	entries := []pkg.SitemapEntry{list}
+	for len(entries) < pkg.SitemapMaxURLs {
+		result, err := h.svc.ListUsers(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		h.hidePending(result)
+		for _, u := range result.Items {
+			entries = append(entries, pkg.SitemapEntry{Path: "/users/" + strconv.FormatUint(uint64(u.ID), 10), LastMod: u.UpdatedAt.Time})
+			if u.UpdatedAt.After(list.LastMod) {
+				list.LastMod = u.UpdatedAt.Time
+			}
+		}
+		if req.Page >= result.TotalPages || len(result.Items) == 0 {
+			break
+		}
+		req.Page++
+	}
+	entries[0] = list
+	if len(entries) > pkg.SitemapMaxURLs {
+		entries = entries[:pkg.SitemapMaxURLs]
+	}
+	return entries, nil
+}
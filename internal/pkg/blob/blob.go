@@ -0,0 +1,112 @@
+// Package blob stores JSON payloads, such as the snapshots of change feed
+// entries, in a bounded amount of space: Encode marshals a value, replaces
+// it with a truncation marker when it exceeds a hard cap, and gzips it when
+// it is large enough to gain from compression; Decode reverses that. The
+// caller keeps the compressed flag Encode returns next to the bytes, in a
+// column of its own.
+package blob
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxSize is the cap on an encoded payload's JSON when none is
+// configured.
+const DefaultMaxSize = 64 << 10
+
+// CompressThreshold is the JSON size above which Encode compresses a
+// payload; smaller ones rarely shrink enough to be worth it.
+const CompressThreshold = 1 << 10
+
+// Encode marshals v to JSON, truncates it to maxSize (DefaultMaxSize when
+// not positive) and compresses it when it is larger than
+// CompressThreshold and gzip makes it smaller. It reports whether the
+// returned bytes are compressed.
+func Encode(v any, maxSize int) (data []byte, compressed bool, err error) {
+	data, err = json.Marshal(v)
+	if err != nil {
+		return nil, false, fmt.Errorf("marshal payload: %w", err)
+	}
+	data, _ = Truncate(data, maxSize)
+	if len(data) <= CompressThreshold {
+		return data, false, nil
+	}
+	packed, err := Compress(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(packed) >= len(data) {
+		return data, false, nil
+	}
+	return packed, true, nil
+}
+
+// Decode returns the JSON of a payload Encode produced, decompressing it
+// when compressed is set. Rows stored before compression existed have the
+// flag unset and are returned as they are; empty data decodes to nil.
+func Decode(data []byte, compressed bool) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if !compressed {
+		return json.RawMessage(data), nil
+	}
+	raw, err := Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(raw), nil
+}
+
+// Compress gzips data.
+func Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("compress payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress payload: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress gunzips data.
+func Decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompress payload: %w", err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress payload: %w", err)
+	}
+	return raw, nil
+}
+
+// truncatedPayload replaces a payload over the cap.
+type truncatedPayload struct {
+	Truncated bool `json:"truncated"`
+	Size      int  `json:"size"`
+}
+
+// Truncate returns data unchanged when it is at most maxSize bytes
+// (DefaultMaxSize when not positive), and otherwise the marker
+// {"truncated":true,"size":<len(data)>} in its place, reporting whether it
+// did. Cutting JSON short would leave it unreadable, so nothing of the
+// original is kept.
+func Truncate(data []byte, maxSize int) ([]byte, bool) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if len(data) <= maxSize {
+		return data, false
+	}
+	marker, _ := json.Marshal(truncatedPayload{Truncated: true, Size: len(data)})
+	return marker, true
+}
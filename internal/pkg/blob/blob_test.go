@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode_SmallPayloadStaysPlain(t *testing.T) {
+	v := map[string]string{"name": "Alice"}
+	data, compressed, err := Encode(v, 0)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if compressed || string(data) != `{"name":"Alice"}` {
+		t.Errorf("Encode() = %s, compressed %v; want plain JSON", data, compressed)
+	}
+	got, err := Decode(data, compressed)
+	if err != nil || string(got) != `{"name":"Alice"}` {
+		t.Errorf("Decode() = %s, %v", got, err)
+	}
+}
+
+func TestEncodeDecode_LargePayloadIsCompressed(t *testing.T) {
+	v := map[string]string{"bio": strings.Repeat("lorem ipsum ", 4000)}
+	want, _ := json.Marshal(v)
+	data, compressed, err := Encode(v, 0)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !compressed || len(data) >= len(want) {
+		t.Fatalf("Encode() = %d bytes, compressed %v; want fewer than %d compressed", len(data), compressed, len(want))
+	}
+	got, err := Decode(data, compressed)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Decode() did not round-trip the payload")
+	}
+}
+
+func TestEncode_OverCapIsTruncated(t *testing.T) {
+	v := map[string]string{"bio": strings.Repeat("x", 2000)}
+	data, compressed, err := Encode(v, 1024)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := Decode(data, compressed)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var marker struct {
+		Truncated bool `json:"truncated"`
+		Size      int  `json:"size"`
+	}
+	if err := json.Unmarshal(got, &marker); err != nil || !marker.Truncated || marker.Size != 2010 {
+		t.Errorf("Decode() = %s, want a truncation marker for 2010 bytes", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got, truncated := Truncate([]byte(`{"a":1}`), 7); truncated || string(got) != `{"a":1}` {
+		t.Errorf("Truncate(at cap) = %s, %v; want unchanged", got, truncated)
+	}
+	if got, truncated := Truncate([]byte(`{"a":12}`), 7); !truncated || string(got) != `{"truncated":true,"size":8}` {
+		t.Errorf("Truncate(over cap) = %s, %v; want the marker", got, truncated)
+	}
+	big := bytes.Repeat([]byte("a"), DefaultMaxSize+1)
+	if _, truncated := Truncate(big, 0); !truncated {
+		t.Error("Truncate(maxSize 0) should apply DefaultMaxSize")
+	}
+}
+
+func TestDecode_LegacyAndEmptyRows(t *testing.T) {
+	// Rows written before compression carry plain JSON and no flag.
+	if got, err := Decode([]byte(`{"old":true}`), false); err != nil || string(got) != `{"old":true}` {
+		t.Errorf("Decode(legacy) = %s, %v", got, err)
+	}
+	if got, err := Decode(nil, false); err != nil || got != nil {
+		t.Errorf("Decode(nil) = %s, %v; want nil", got, err)
+	}
+	if _, err := Decode([]byte("not gzip"), true); err == nil {
+		t.Error("Decode(corrupt) error = nil, want an error")
+	}
+}
@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Item statuses of a BulkItemResult.
+const (
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+)
+
+// BulkResult is the data of every bulk endpoint's response: one item per
+// input, in input order, each either succeeded or failed on its own.
+// Endpoints build it with Succeed and Fail and send it with Bulk.
+type BulkResult struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// BulkItemResult is the outcome of one input of a bulk operation. Index is
+// its position in the request; ID, when known, is the record it concerns.
+type BulkItemResult struct {
+	Index  int            `json:"index"`
+	ID     any            `json:"id,omitempty"`
+	Status string         `json:"status"`
+	Error  *BulkItemError `json:"error,omitempty"`
+}
+
+// BulkItemError describes why one item failed. Code is the HTTP status the
+// item would have got as a single request, and Fields holds per-field
+// messages as in ValidationErrorResponse.
+type BulkItemError struct {
+	Code    int               `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// NewBulkResult returns an empty BulkResult for total inputs.
+func NewBulkResult(total int) *BulkResult {
+	return &BulkResult{Total: total, Items: make([]BulkItemResult, 0, total)}
+}
+
+// Succeed records input index as done; id may be nil.
+func (r *BulkResult) Succeed(index int, id any) {
+	r.Succeeded++
+	r.Items = append(r.Items, BulkItemResult{Index: index, ID: id, Status: BulkItemSucceeded})
+}
+
+// Fail records input index as failed with err, mapped as Error maps it:
+// ParamErrors (see ValidateBulkItem) become a 400 with Fields, an AppError
+// its code's status and message, anything else a logged 500 "internal
+// error". id may be nil.
+func (r *BulkResult) Fail(index int, id any, err error) {
+	r.Failed++
+	r.Items = append(r.Items, BulkItemResult{Index: index, ID: id, Status: BulkItemFailed, Error: bulkItemError(err)})
+}
+
+func bulkItemError(err error) *BulkItemError {
+	var pe ParamErrors
+	if errors.As(err, &pe) {
+		return &BulkItemError{Code: http.StatusBadRequest, Message: "validation error", Fields: pe}
+	}
+	var appErr *domain.AppError
+	if errors.As(err, &appErr) {
+		return &BulkItemError{Code: domain.HTTPStatusCode(err), Message: appErr.Message}
+	}
+	slog.Error("bulk item failed", slog.Any("error", err))
+	return &BulkItemError{Code: http.StatusInternalServerError, Message: "internal error"}
+}
+
+// ValidateBulkItem runs the binding rules of obj, one element of a bulk
+// request, and returns the violations as ParamErrors named by JSON tag, so
+// Fail reports them like BindAndValidate would for a single request.
+func ValidateBulkItem(obj any) error {
+	err := binding.Validator.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) {
+		return err
+	}
+	jsonTags := buildJSONTagMap(obj)
+	fields := make(ParamErrors, len(ve))
+	for _, fe := range ve {
+		name, ok := jsonTags[fe.StructField()]
+		if !ok {
+			name = strings.ToLower(fe.Field())
+		}
+		fields[name] = friendlyMessage(fe)
+	}
+	return fields
+}
+
+// Bulk sends result in the standard envelope. The status is 200 when no
+// item failed, 207 Multi-Status when some did, and when all did 500 if any
+// item failed with a 5xx, 400 otherwise. Every status carries the full
+// result, so problem+json is not used; clients read the per-item errors.
+// A 207 is a 2xx, so writes reported with it still purge the response
+// cache. Field error names follow the negotiated naming, like
+// BindAndValidate's.
+func Bulk(c *gin.Context, result *BulkResult) {
+	status, message := http.StatusOK, "success"
+	switch {
+	case result.Failed == 0:
+	case result.Succeeded > 0:
+		status = http.StatusMultiStatus
+		message = fmt.Sprintf("%d of %d items failed", result.Failed, result.Total)
+	default:
+		status = http.StatusBadRequest
+		for _, item := range result.Items {
+			if item.Error != nil && item.Error.Code >= http.StatusInternalServerError {
+				status = http.StatusInternalServerError
+				break
+			}
+		}
+		message = fmt.Sprintf("all %d items failed", result.Failed)
+	}
+	if WantsCamelJSON(c) {
+		result = camelBulkFields(result)
+	}
+	JSON(c, status, Response{Code: status, Message: message, Data: result})
+}
+
+// camelBulkFields returns a copy of result with the field error names in
+// camelCase; JSON keeps the keys of the Fields maps as they are.
+func camelBulkFields(result *BulkResult) *BulkResult {
+	out := *result
+	out.Items = slices.Clone(result.Items)
+	for i, item := range out.Items {
+		if item.Error == nil || len(item.Error.Fields) == 0 {
+			continue
+		}
+		e := *item.Error
+		e.Fields = make(map[string]string, len(item.Error.Fields))
+		for name, msg := range item.Error.Fields {
+			e.Fields[CamelCase(name)] = msg
+		}
+		out.Items[i].Error = &e
+	}
+	return &out
+}
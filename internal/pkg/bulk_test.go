@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+type bulkEntry struct {
+	FullName string `json:"full_name" binding:"required,min=2"`
+	Email    string `json:"email" binding:"required,email"`
+}
+
+func sendBulk(t *testing.T, result *BulkResult) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/things/bulk", nil)
+	Bulk(c, result)
+	return w
+}
+
+func TestBulk_StatusSelection(t *testing.T) {
+	notFound := domain.NewAppError(domain.CodeNotFound, "thing not found", nil)
+	tests := []struct {
+		name        string
+		build       func(r *BulkResult)
+		total       int
+		wantStatus  int
+		wantMessage string
+	}{
+		{"empty", func(*BulkResult) {}, 0, http.StatusOK, "success"},
+		{"all succeeded", func(r *BulkResult) { r.Succeed(0, 1); r.Succeed(1, 2) }, 2, http.StatusOK, "success"},
+		{"partial", func(r *BulkResult) { r.Succeed(0, 1); r.Fail(1, 2, notFound); r.Fail(2, nil, notFound) }, 3, http.StatusMultiStatus, "2 of 3 items failed"},
+		{"all failed on the client", func(r *BulkResult) { r.Fail(0, 1, notFound); r.Fail(1, nil, ParamErrors{"email": "x"}) }, 2, http.StatusBadRequest, "all 2 items failed"},
+		{"all failed with a server error", func(r *BulkResult) { r.Fail(0, 1, notFound); r.Fail(1, 2, errors.New("disk full")) }, 2, http.StatusInternalServerError, "all 2 items failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewBulkResult(tt.total)
+			tt.build(r)
+			w := sendBulk(t, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp struct {
+				Code    int        `json:"code"`
+				Message string     `json:"message"`
+				Data    BulkResult `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Code != tt.wantStatus || resp.Message != tt.wantMessage {
+				t.Errorf("envelope = %d %q, want %d %q", resp.Code, resp.Message, tt.wantStatus, tt.wantMessage)
+			}
+			if resp.Data.Total != tt.total || resp.Data.Succeeded+resp.Data.Failed != tt.total || len(resp.Data.Items) != tt.total {
+				t.Errorf("data = %+v, want %d counted items", resp.Data, tt.total)
+			}
+		})
+	}
+}
+
+func TestBulk_ItemSerialization(t *testing.T) {
+	r := NewBulkResult(4)
+	r.Succeed(0, uint(7))
+	r.Fail(1, nil, ValidateBulkItem(&bulkEntry{FullName: "A", Email: "nope"}))
+	r.Fail(2, uint(9), domain.NewAppError(domain.CodeAlreadyExists, "email already taken", nil))
+	r.Fail(3, nil, errors.New("connection reset"))
+
+	w := sendBulk(t, r)
+	var resp struct {
+		Data struct {
+			Items []map[string]any `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	items := resp.Data.Items
+	if len(items) != 4 {
+		t.Fatalf("items = %v, want 4", items)
+	}
+	if items[0]["status"] != BulkItemSucceeded || items[0]["id"] != float64(7) || items[0]["error"] != nil {
+		t.Errorf("item 0 = %v, want succeeded with id 7 and no error", items[0])
+	}
+	if _, ok := items[1]["id"]; ok {
+		t.Errorf("item 1 = %v, want no id", items[1])
+	}
+	invalid, _ := items[1]["error"].(map[string]any)
+	fields, _ := invalid["fields"].(map[string]any)
+	if invalid["code"] != float64(http.StatusBadRequest) || fields["full_name"] != "Must be at least 2 characters" || fields["email"] != "Must be a valid email address" {
+		t.Errorf("item 1 error = %v, want a 400 with full_name and email field errors", invalid)
+	}
+	conflict, _ := items[2]["error"].(map[string]any)
+	if items[2]["status"] != BulkItemFailed || conflict["code"] != float64(http.StatusConflict) || conflict["message"] != "email already taken" {
+		t.Errorf("item 2 = %v, want a 409 with the AppError message", items[2])
+	}
+	if _, ok := conflict["fields"]; ok {
+		t.Errorf("item 2 error = %v, want no fields", conflict)
+	}
+	internal, _ := items[3]["error"].(map[string]any)
+	if internal["code"] != float64(http.StatusInternalServerError) || internal["message"] != "internal error" {
+		t.Errorf("item 3 error = %v, want a 500 hiding the cause", internal)
+	}
+}
+
+func TestBulk_CamelJSON(t *testing.T) {
+	r := NewBulkResult(2)
+	r.Succeed(0, nil)
+	r.Fail(1, nil, ValidateBulkItem(&bulkEntry{Email: "a@example.com"}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/things/bulk", nil)
+	ForceCamelJSON(c)
+	Bulk(c, r)
+
+	var resp struct {
+		Data struct {
+			Items []struct {
+				Error *struct {
+					Fields map[string]string `json:"fields"`
+				} `json:"error"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if e := resp.Data.Items[1].Error; e == nil || e.Fields["fullName"] != "This field is required" {
+		t.Errorf("camelCase item error = %+v, want a fullName field error", e)
+	}
+}
+
+func TestValidateBulkItem(t *testing.T) {
+	if err := ValidateBulkItem(&bulkEntry{FullName: "Alice", Email: "alice@example.com"}); err != nil {
+		t.Errorf("ValidateBulkItem(valid) = %v, want nil", err)
+	}
+	var pe ParamErrors
+	if err := ValidateBulkItem(&bulkEntry{}); !errors.As(err, &pe) || len(pe) != 2 {
+		t.Errorf("ValidateBulkItem(empty) = %v, want ParamErrors for both fields", err)
+	}
+}
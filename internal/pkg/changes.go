@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+	"github.com/simp-lee/gobase/internal/pkg/blob"
+)
+
+// RecordChange appends a domain.Change for entity id of entityType to the
+// change feed. Call it with the transaction of the mutation, so the entry is
+// committed or rolled back with it:
+//
+//	err := pkg.RetryTx(ctx, db, attempts, func(tx *gorm.DB) error {
+//		if err := tx.Create(user).Error; err != nil {
+//			return err
+//		}
+//		return pkg.RecordChange(tx, "users", user.ID, domain.ChangeCreate)
+//	})
+func RecordChange(tx *gorm.DB, entityType string, id any, op string) error {
+	return tx.Create(&domain.Change{EntityType: entityType, EntityID: fmt.Sprint(id), Op: op}).Error
+}
+
+// RecordChangePayload is RecordChange with payload, a snapshot of the change
+// such as {"before": {...}, "after": {...}}, stored as JSON no larger than
+// maxSize bytes (see blob.Encode).
+func RecordChangePayload(tx *gorm.DB, entityType string, id any, op string, payload any, maxSize int) error {
+	data, compressed, err := blob.Encode(payload, maxSize)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&domain.Change{
+		EntityType:        entityType,
+		EntityID:          fmt.Sprint(id),
+		Op:                op,
+		PayloadData:       data,
+		PayloadCompressed: compressed,
+	}).Error
+}
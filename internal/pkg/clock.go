@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for code with deadlines: token expiry, undo
+// windows, scheduled jobs. Production code uses RealClock; tests pass a
+// FakeClock and advance it instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the subset of *time.Timer a Clock hands out.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock backed by package time.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// FakeClock is a Clock that only moves when Advance is called. Timers whose
+// deadline is reached fire during Advance; their channels are buffered, so
+// Advance never blocks on a receiver.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiting []*fakeTimer // armed timers
+}
+
+// NewFakeClock returns a FakeClock reading now.
+func NewFakeClock(now time.Time) *FakeClock {
+	f := &FakeClock{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now implements Clock.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After implements Clock.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer implements Clock.
+func (f *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.arm(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d and fires, in deadline order, every
+// timer due by the new time.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	for {
+		next := -1
+		for i, t := range f.waiting {
+			if !t.deadline.After(f.now) && (next < 0 || t.deadline.Before(f.waiting[next].deadline)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return
+		}
+		f.fire(f.waiting[next])
+	}
+}
+
+// BlockUntil waits until n timers are armed, so a test can advance the
+// clock once a goroutine is known to be waiting on it.
+func (f *FakeClock) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiting) < n {
+		f.cond.Wait()
+	}
+}
+
+// arm schedules t to fire d from now; f.mu must be held.
+func (f *FakeClock) arm(t *fakeTimer, d time.Duration) {
+	t.deadline = f.now.Add(d)
+	if d <= 0 {
+		f.fire(t)
+		return
+	}
+	if !t.armed {
+		t.armed = true
+		f.waiting = append(f.waiting, t)
+		f.cond.Broadcast()
+	}
+}
+
+// fire delivers the current time on t unless a value is still unread, like
+// time.Timer, and disarms it; f.mu must be held.
+func (f *FakeClock) fire(t *fakeTimer) {
+	f.disarm(t)
+	select {
+	case t.c <- f.now:
+	default:
+	}
+}
+
+// disarm removes t from the armed timers and reports whether it was armed;
+// f.mu must be held.
+func (f *FakeClock) disarm(t *fakeTimer) bool {
+	if !t.armed {
+		return false
+	}
+	t.armed = false
+	for i, w := range f.waiting {
+		if w == t {
+			f.waiting = append(f.waiting[:i], f.waiting[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time // guarded by clock.mu
+	armed    bool      // guarded by clock.mu
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.disarm(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasArmed := t.armed
+	t.clock.arm(t, d)
+	return wasArmed
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_AdvanceFiresDueTimers(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	short := c.NewTimer(time.Second)
+	long := c.After(time.Minute)
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-short.C():
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	c.Advance(time.Millisecond)
+	if got := <-short.C(); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("fired at %v, want %v", got, start.Add(time.Second))
+	}
+	if short.Stop() {
+		t.Error("Stop() after firing = true, want false")
+	}
+
+	c.Advance(time.Hour)
+	<-long
+	if got := c.Now(); !got.Equal(start.Add(time.Hour + time.Second)) {
+		t.Errorf("Now() = %v", got)
+	}
+}
+
+func TestFakeClock_StopAndReset(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	tm := c.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Fatal("Stop() on an armed timer = false")
+	}
+	c.Advance(time.Minute)
+	select {
+	case <-tm.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if tm.Reset(time.Second) {
+		t.Error("Reset() on a stopped timer = true, want false")
+	}
+	c.Advance(time.Second)
+	<-tm.C()
+}
+
+func TestFakeClock_BlockUntil(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	fired := make(chan struct{})
+	go func() {
+		<-c.After(time.Minute)
+		close(fired)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	<-fired
+}
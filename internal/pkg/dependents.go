@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// ON DELETE actions of the foreign key of a UserDependent.
+const (
+	OnDeleteCascade  = "CASCADE"
+	OnDeleteSetNull  = "SET NULL"
+	OnDeleteRestrict = "RESTRICT"
+)
+
+// UserDependent is a table whose rows reference users.id through a foreign
+// key. The key and its ON DELETE action are declared on Model, as a
+// belongs-to association with a constraint tag, so AutoMigrate creates
+// them:
+//
+//	UserID uint         `gorm:"not null;index"`
+//	User   *domain.User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+//
+// OnDeleteCascade deletes the rows with the user; OnDeleteSetNull keeps
+// them without the reference (the column must be nullable);
+// OnDeleteRestrict, for records that must be retained, keeps the user from
+// being deleted while any exist, and so does a key without an action.
+// Modules list their tables with UserDependents (see
+// app.DependentProvider).
+type UserDependent struct {
+	// Name identifies the table in conflict errors, e.g. "notifications".
+	Name  string
+	Model any
+}
+
+// DependentCount is how many rows of a UserDependent reference one user.
+type DependentCount struct {
+	Name     string
+	OnDelete string // the declared action, upper-cased; empty when none
+	Count    int64
+}
+
+// Blocks reports whether the rows keep the user from being deleted.
+func (d DependentCount) Blocks() bool {
+	return d.OnDelete != OnDeleteCascade && d.OnDelete != OnDeleteSetNull
+}
+
+// CountDependents returns the dependents in deps with rows referencing
+// user userID, with their number and ON DELETE action. Counts are not
+// scoped to a tenant: the foreign key holds across tenants.
+func CountDependents(tx *gorm.DB, deps []UserDependent, userID uint) ([]DependentCount, error) {
+	var counts []DependentCount
+	for _, d := range deps {
+		column, onDelete, err := d.foreignKey(tx)
+		if err != nil {
+			return nil, err
+		}
+		var n int64
+		err = tx.Model(d.Model).Where(clause.Eq{Column: clause.Column{Name: column}, Value: userID}).Count(&n).Error
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", d.Name, err)
+		}
+		if n > 0 {
+			counts = append(counts, DependentCount{Name: d.Name, OnDelete: onDelete, Count: n})
+		}
+	}
+	return counts, nil
+}
+
+// DependentsConflict returns the CodeConflict error for deleting a user
+// that the blocking counts reference, naming each table and its rows, or
+// nil when none of counts blocks.
+func DependentsConflict(counts []DependentCount) error {
+	var names []string
+	for _, c := range counts {
+		if c.Blocks() {
+			names = append(names, fmt.Sprintf("%s (%d)", c.Name, c.Count))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return domain.NewAppError(domain.CodeConflict, "user is referenced by records that must be kept: "+strings.Join(names, ", "), nil)
+}
+
+// userType is the model a UserDependent's foreign key references.
+var userType = reflect.TypeOf(domain.User{})
+
+// foreignKey returns the column of d's foreign key to users and its ON
+// DELETE action.
+func (d UserDependent) foreignKey(db *gorm.DB) (column, onDelete string, err error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(d.Model); err != nil {
+		return "", "", fmt.Errorf("parse %s model: %w", d.Name, err)
+	}
+	for _, rel := range stmt.Schema.Relationships.BelongsTo {
+		if rel.FieldSchema.ModelType != userType || len(rel.References) != 1 {
+			continue
+		}
+		if c := rel.ParseConstraint(); c != nil {
+			onDelete = strings.ToUpper(strings.TrimSpace(c.OnDelete))
+		}
+		return rel.References[0].ForeignKey.DBName, onDelete, nil
+	}
+	return "", "", errors.New(d.Name + " model has no belongs-to association with domain.User")
+}
+
+// IsForeignKeyError reports whether err is a foreign key violation from
+// SQLite or PostgreSQL.
+func IsForeignKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "foreign key constraint")
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// invoiceTestRecord must be kept: its key restricts deleting the user.
+type invoiceTestRecord struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint
+	User   *domain.User `gorm:"constraint:OnDelete:RESTRICT"`
+}
+
+// inboxTestRecord is deleted with its user.
+type inboxTestRecord struct {
+	ID     uint `gorm:"primaryKey"`
+	UserID uint
+	User   *domain.User `gorm:"constraint:OnDelete:cascade"`
+}
+
+func TestCountDependents(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&domain.User{}, &invoiceTestRecord{}, &inboxTestRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	alice := domain.User{Name: "Alice", Email: "alice@example.com"}
+	bob := domain.User{Name: "Bob", Email: "bob@example.com"}
+	for _, u := range []*domain.User{&alice, &bob} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("seed user: %v", err)
+		}
+	}
+	for _, rec := range []any{
+		&invoiceTestRecord{UserID: alice.ID}, &invoiceTestRecord{UserID: alice.ID},
+		&inboxTestRecord{UserID: alice.ID}, &inboxTestRecord{UserID: bob.ID},
+	} {
+		if err := db.Create(rec).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	deps := []UserDependent{{Name: "invoices", Model: &invoiceTestRecord{}}, {Name: "inbox", Model: &inboxTestRecord{}}}
+
+	counts, err := CountDependents(db, deps, alice.ID)
+	if err != nil {
+		t.Fatalf("CountDependents() error = %v", err)
+	}
+	want := []DependentCount{{Name: "invoices", OnDelete: OnDeleteRestrict, Count: 2}, {Name: "inbox", OnDelete: OnDeleteCascade, Count: 1}}
+	if len(counts) != len(want) || counts[0] != want[0] || counts[1] != want[1] {
+		t.Fatalf("CountDependents(alice) = %+v, want %+v", counts, want)
+	}
+	err = DependentsConflict(counts)
+	if !domain.IsConflict(err) || !strings.HasSuffix(err.Error(), ": invoices (2)") {
+		t.Errorf("DependentsConflict(alice) = %v, want a conflict naming invoices (2) only", err)
+	}
+
+	counts, err = CountDependents(db, deps, bob.ID)
+	if err != nil {
+		t.Fatalf("CountDependents() error = %v", err)
+	}
+	if err := DependentsConflict(counts); err != nil {
+		t.Errorf("DependentsConflict(bob) = %v, want nil: cascaded rows do not block", err)
+	}
+
+	// The foreign key backs the check up.
+	err = db.Delete(&domain.User{}, alice.ID).Error
+	if !IsForeignKeyError(err) {
+		t.Errorf("deleting alice = %v, want a foreign key violation", err)
+	}
+
+	if _, err := CountDependents(db, []UserDependent{{Name: "users", Model: &domain.User{}}}, alice.ID); err == nil {
+		t.Error("CountDependents(model without a key to users) error = nil, want an error")
+	}
+}
+
+func TestDependentCount_Blocks(t *testing.T) {
+	tests := []struct {
+		onDelete string
+		want     bool
+	}{
+		{OnDeleteCascade, false},
+		{OnDeleteSetNull, false},
+		{OnDeleteRestrict, true},
+		{"NO ACTION", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if got := (DependentCount{OnDelete: tt.onDelete}).Blocks(); got != tt.want {
+			t.Errorf("Blocks() with %q = %v, want %v", tt.onDelete, got, tt.want)
+		}
+	}
+}
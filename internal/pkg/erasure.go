@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Erasable is a store that holds records about users. A user's data export
+// includes its records, and erasing the user deletes them, in the one
+// transaction that also deletes the user row. Modules list their stores
+// with Erasables (see app.ErasureProvider), so a new module's data is
+// covered without changing the erasure itself.
+//
+// tx carries the request context; stores scope their queries to its
+// tenant like any other (see TenantScope).
+type Erasable interface {
+	// ErasureName is the key of the store's records in the export, e.g.
+	// "notifications".
+	ErasureName() string
+	// ExportUser returns every record held about user.
+	ExportUser(tx *gorm.DB, user *domain.User) (any, error)
+	// EraseUser deletes them and returns how many rows were removed.
+	EraseUser(tx *gorm.DB, user *domain.User) (int64, error)
+}
+
+// UserRecords returns the Erasable for the rows of model T matching query,
+// a WHERE clause whose arguments args derives from the user, e.g.
+//
+//	pkg.UserRecords[domain.Notification]("notifications", "user_id = ?",
+//		func(u *domain.User) []any { return []any{u.ID} })
+//
+// Rows are exported with every column, in ID order, rather than through
+// T's JSON encoding, which may leave fields out.
+func UserRecords[T any](name, query string, args func(user *domain.User) []any) Erasable {
+	return userRecords[T]{name: name, query: query, args: args}
+}
+
+type userRecords[T any] struct {
+	name  string
+	query string
+	args  func(user *domain.User) []any
+}
+
+func (s userRecords[T]) ErasureName() string { return s.name }
+
+func (s userRecords[T]) scope(tx *gorm.DB, user *domain.User) *gorm.DB {
+	return tx.Model(new(T)).Scopes(TenantScope(tx.Statement.Context)).Where(s.query, s.args(user)...)
+}
+
+func (s userRecords[T]) ExportUser(tx *gorm.DB, user *domain.User) (any, error) {
+	rows := []map[string]any{}
+	if err := s.scope(tx, user).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (s userRecords[T]) EraseUser(tx *gorm.DB, user *domain.User) (int64, error) {
+	result := s.scope(tx, user).Delete(new(T))
+	return result.RowsAffected, result.Error
+}
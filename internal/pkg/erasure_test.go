@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// erasureTestRecord is a tenant-scoped row about a user.
+type erasureTestRecord struct {
+	ID uint `gorm:"primaryKey"`
+	domain.TenantScoped
+	UserID uint
+	Secret string `json:"-"`
+}
+
+func TestUserRecords_ScopedToQueryAndTenant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&erasureTestRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	acme := domain.WithTenant(context.Background(), "acme")
+	other := domain.WithTenant(context.Background(), "other")
+	for _, seed := range []struct {
+		ctx    context.Context
+		userID uint
+	}{{acme, 1}, {acme, 1}, {acme, 2}, {other, 1}} {
+		if err := db.WithContext(seed.ctx).Create(&erasureTestRecord{UserID: seed.userID, Secret: "s"}).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	store := UserRecords[erasureTestRecord]("records", "user_id = ?", func(u *domain.User) []any { return []any{u.ID} })
+	user := &domain.User{BaseModel: domain.BaseModel{ID: 1}}
+	tx := db.WithContext(acme)
+
+	exported, err := store.ExportUser(tx, user)
+	if err != nil {
+		t.Fatalf("ExportUser() error = %v", err)
+	}
+	rows, _ := exported.([]map[string]any)
+	if len(rows) != 2 {
+		t.Fatalf("exported %v, want user 1's two acme rows", exported)
+	}
+	if rows[0]["secret"] != "s" {
+		t.Errorf("exported row %v, want every column including secret", rows[0])
+	}
+
+	n, err := store.EraseUser(tx, user)
+	if err != nil || n != 2 {
+		t.Fatalf("EraseUser() = %d, %v; want 2 rows", n, err)
+	}
+	var left int64
+	if err := db.Model(&erasureTestRecord{}).Count(&left).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if left != 2 {
+		t.Errorf("rows left = %d, want user 2's and the other tenant's", left)
+	}
+}
@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+var (
+	// ErrSlowSubscriber ends a subscription whose buffer was full when an
+	// event was published.
+	ErrSlowSubscriber = errors.New("event subscriber too slow")
+	// ErrBrokerClosed ends every subscription when the broker is closed.
+	ErrBrokerClosed = errors.New("event broker closed")
+)
+
+// EventBroker fans domain events out to in-process subscribers, such as
+// the WebSocket connections of GET /ws. Publishing never blocks: each
+// subscriber has its own buffer, and one that is full is ended with
+// ErrSlowSubscriber instead of holding up the publisher or the others.
+type EventBroker struct {
+	clock Clock
+
+	mu     sync.Mutex
+	subs   map[*EventSubscription]struct{}
+	closed bool
+}
+
+// EventSubscription receives the events published after Subscribe until it
+// is closed, dropped as too slow, or the broker closes.
+type EventSubscription struct {
+	broker *EventBroker
+	events chan domain.Event
+	done   chan struct{}
+	err    error // set before done is closed
+
+	tenant string // with scoped, the only tenant whose events are delivered
+	scoped bool
+}
+
+// NewEventBroker returns a broker that stamps events without a Time with
+// clock (RealClock when nil), and those without a Tenant with the tenant of
+// the publishing context.
+func NewEventBroker(clock Clock) *EventBroker {
+	if clock == nil {
+		clock = RealClock
+	}
+	return &EventBroker{clock: clock, subs: make(map[*EventSubscription]struct{})}
+}
+
+// Subscribe returns a subscription buffering up to buffer events (at
+// least 1) of every tenant. On a closed broker it is returned already
+// ended.
+func (b *EventBroker) Subscribe(buffer int) *EventSubscription {
+	return b.subscribe(&EventSubscription{}, buffer)
+}
+
+// SubscribeTenant is Subscribe for the events of tenant only; events of
+// other tenants do not count against its buffer.
+func (b *EventBroker) SubscribeTenant(tenant string, buffer int) *EventSubscription {
+	return b.subscribe(&EventSubscription{tenant: tenant, scoped: true}, buffer)
+}
+
+func (b *EventBroker) subscribe(s *EventSubscription, buffer int) *EventSubscription {
+	s.broker = b
+	s.events = make(chan domain.Event, max(buffer, 1))
+	s.done = make(chan struct{})
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		s.end(ErrBrokerClosed)
+		return s
+	}
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// PublishEvent implements domain.EventPublisher.
+func (b *EventBroker) PublishEvent(ctx context.Context, e domain.Event) {
+	if e.Time.IsZero() {
+		e.Time = domain.NewTime(b.clock.Now())
+	}
+	if e.Tenant == "" {
+		e.Tenant, _ = domain.TenantFromContext(ctx)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		if s.scoped && s.tenant != e.Tenant {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			delete(b.subs, s)
+			s.end(ErrSlowSubscriber)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *EventBroker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close ends every subscription with ErrBrokerClosed; later subscriptions
+// start ended and publishing becomes a no-op.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for s := range b.subs {
+		delete(b.subs, s)
+		s.end(ErrBrokerClosed)
+	}
+}
+
+// Events delivers the subscription's events. It is never closed; select on
+// Done as well.
+func (s *EventSubscription) Events() <-chan domain.Event { return s.events }
+
+// Done is closed when the subscription ends; Err then tells why.
+func (s *EventSubscription) Done() <-chan struct{} { return s.done }
+
+// Err returns why the subscription ended: ErrSlowSubscriber,
+// ErrBrokerClosed, or nil when it was closed by its owner or is active.
+func (s *EventSubscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *EventSubscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		s.end(nil)
+	}
+}
+
+// end records err and closes done. Callers hold the broker's mu and have
+// removed s from subs, so it runs once.
+func (s *EventSubscription) end(err error) {
+	s.err = err
+	close(s.done)
+}
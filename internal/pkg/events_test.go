@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+func TestEventBroker_Publish(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewEventBroker(NewFakeClock(now))
+	fast := b.Subscribe(2)
+	slow := b.Subscribe(1)
+
+	b.PublishEvent(context.Background(), domain.Event{Type: domain.EventUserCreated})
+	if e := <-fast.Events(); e.Type != domain.EventUserCreated || !e.Time.Equal(now) {
+		t.Errorf("event = %+v, want %s stamped %v", e, domain.EventUserCreated, now)
+	}
+
+	// slow still holds the first event, so the second ends it.
+	b.PublishEvent(context.Background(), domain.Event{Type: domain.EventUserDeleted})
+	select {
+	case <-slow.Done():
+	default:
+		t.Fatal("slow subscriber still active after its buffer overflowed")
+	}
+	if !errors.Is(slow.Err(), ErrSlowSubscriber) {
+		t.Errorf("slow Err() = %v, want ErrSlowSubscriber", slow.Err())
+	}
+	if e := <-fast.Events(); e.Type != domain.EventUserDeleted {
+		t.Errorf("fast subscriber got %q, want %q", e.Type, domain.EventUserDeleted)
+	}
+	if got := b.Subscribers(); got != 1 {
+		t.Errorf("Subscribers() = %d, want 1", got)
+	}
+
+	fast.Close()
+	fast.Close()
+	if fast.Err() != nil || b.Subscribers() != 0 {
+		t.Errorf("after Close: Err() = %v, Subscribers() = %d; want nil, 0", fast.Err(), b.Subscribers())
+	}
+}
+
+func TestEventBroker_Close(t *testing.T) {
+	b := NewEventBroker(nil)
+	s := b.Subscribe(1)
+	b.Close()
+	<-s.Done()
+	if !errors.Is(s.Err(), ErrBrokerClosed) {
+		t.Errorf("Err() = %v, want ErrBrokerClosed", s.Err())
+	}
+	late := b.Subscribe(1)
+	<-late.Done()
+	if !errors.Is(late.Err(), ErrBrokerClosed) {
+		t.Errorf("Subscribe after Close: Err() = %v, want ErrBrokerClosed", late.Err())
+	}
+	b.PublishEvent(context.Background(), domain.Event{Type: domain.EventUserCreated}) // must not panic
+}
+
+func TestEventBroker_SubscribeTenant(t *testing.T) {
+	b := NewEventBroker(nil)
+	acme := b.SubscribeTenant("acme", 1)
+	all := b.Subscribe(2)
+
+	b.PublishEvent(domain.WithTenant(context.Background(), "globex"), domain.Event{Type: domain.EventUserCreated})
+	b.PublishEvent(domain.WithTenant(context.Background(), "acme"), domain.Event{Type: domain.EventUserDeleted})
+
+	// globex's event neither reached acme nor filled its buffer.
+	if e := <-acme.Events(); e.Type != domain.EventUserDeleted || e.Tenant != "acme" {
+		t.Errorf("acme got %+v, want %s stamped acme", e, domain.EventUserDeleted)
+	}
+	if acme.Err() != nil {
+		t.Errorf("acme Err() = %v, want nil", acme.Err())
+	}
+	if e := <-all.Events(); e.Tenant != "globex" {
+		t.Errorf("unscoped subscriber got tenant %q first, want globex", e.Tenant)
+	}
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import "github.com/gin-gonic/gin"
+
+// featuresKey is the gin.Context key holding the request's *Features.
+const featuresKey = "pkg.features"
+
+// Features is the set of feature flags in effect for one request: the
+// configured features map, plus any X-Feature-Override values applied by
+// middleware.FeatureFlags. Unknown flags are off. A nil *Features has every
+// flag off, so templates and handlers can query it unconditionally.
+type Features struct {
+	flags map[string]bool
+}
+
+// NewFeatures returns a Features snapshot of flags. The map is copied.
+func NewFeatures(flags map[string]bool) *Features {
+	copied := make(map[string]bool, len(flags))
+	for name, on := range flags {
+		copied[name] = on
+	}
+	return &Features{flags: copied}
+}
+
+// Get reports whether the named flag is enabled. Templates call it as
+// {{ if .Features.Get "user_search" }}.
+func (f *Features) Get(name string) bool {
+	if f == nil {
+		return false
+	}
+	return f.flags[name]
+}
+
+// SetFeatures stores f as the feature flags for the request.
+func SetFeatures(c *gin.Context, f *Features) {
+	c.Set(featuresKey, f)
+}
+
+// GetFeatures returns the feature flags stored by SetFeatures, or nil when
+// none were set. Page handlers pass it to templates as Features.
+func GetFeatures(c *gin.Context) *Features {
+	if v, exists := c.Get(featuresKey); exists {
+		if f, ok := v.(*Features); ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// FeatureEnabled reports whether the named flag is enabled for the request.
+func FeatureEnabled(c *gin.Context, name string) bool {
+	return GetFeatures(c).Get(name)
+}
@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatures_Get(t *testing.T) {
+	flags := map[string]bool{"user_search": true, "bulk_delete": false}
+	f := NewFeatures(flags)
+	flags["bulk_delete"] = true
+
+	if !f.Get("user_search") {
+		t.Error("user_search = false, want true")
+	}
+	if f.Get("bulk_delete") {
+		t.Error("bulk_delete = true after mutating the source map, want the snapshot value false")
+	}
+	if f.Get("missing") {
+		t.Error("unknown flag = true, want false")
+	}
+
+	var none *Features
+	if none.Get("user_search") {
+		t.Error("nil Features reported a flag as enabled")
+	}
+}
+
+func TestFeatureEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if FeatureEnabled(c, "user_search") {
+		t.Error("FeatureEnabled without SetFeatures = true, want false")
+	}
+	SetFeatures(c, NewFeatures(map[string]bool{"user_search": true}))
+	if !FeatureEnabled(c, "user_search") {
+		t.Error("FeatureEnabled = false, want true")
+	}
+}
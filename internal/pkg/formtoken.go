@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// FormTokenField is the form field carrying a one-time form token; the
+// page templates emit it with formTokenField.
+const FormTokenField = "_form_token"
+
+const (
+	// DefaultFormTokenTTL is how long a rendered form can still be
+	// submitted.
+	DefaultFormTokenTTL = 30 * time.Minute
+
+	// maxFormTokens bounds the tokens held across all clients, and
+	// maxFormTokensPerKey those of one client (open tabs). Past either
+	// limit the oldest token is dropped, so its form reports as expired.
+	maxFormTokens       = 10000
+	maxFormTokensPerKey = 20
+)
+
+var (
+	// ErrFormTokenUsed is returned by Consume for a token already
+	// consumed: the form was submitted twice.
+	ErrFormTokenUsed = errors.New("form already submitted")
+	// ErrFormTokenInvalid is returned by Consume for a token that expired,
+	// was evicted, belongs to another client or was never issued.
+	ErrFormTokenInvalid = errors.New("form token expired or invalid")
+)
+
+// FormTokens guards HTML forms against duplicate submission. Each render
+// of a form Issues a fresh token for the client's key (its CSRF cookie)
+// and the handler Consumes it before acting, so a double click or a
+// replayed post is turned away instead of creating the record twice.
+// Unlike the CSRF token, which is reused for a whole session, a form token
+// is good for one submission only.
+//
+// Tokens live in memory, per App, and are dropped lazily once their TTL
+// has passed; consumed tokens are kept until then so a replay can be told
+// apart from an expired form.
+type FormTokens struct {
+	ttl   time.Duration
+	clock Clock
+
+	mu     sync.Mutex
+	tokens map[string]*formToken
+	perKey map[string]int
+}
+
+type formToken struct {
+	key     string
+	expires time.Time
+	used    bool
+}
+
+// NewFormTokens returns an empty store whose tokens expire after ttl
+// (DefaultFormTokenTTL when ttl <= 0) on clock (RealClock when nil).
+func NewFormTokens(ttl time.Duration, clock Clock) *FormTokens {
+	if ttl <= 0 {
+		ttl = DefaultFormTokenTTL
+	}
+	if clock == nil {
+		clock = RealClock
+	}
+	return &FormTokens{
+		ttl:    ttl,
+		clock:  clock,
+		tokens: make(map[string]*formToken),
+		perKey: make(map[string]int),
+	}
+}
+
+// Issue returns a new token for the client identified by key.
+func (s *FormTokens) Issue(key string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if len(s.tokens) >= maxFormTokens {
+		s.sweep(now)
+	}
+	if len(s.tokens) >= maxFormTokens {
+		s.evictOldest("", false)
+	}
+	if s.perKey[key] >= maxFormTokensPerKey {
+		s.evictOldest(key, true)
+	}
+	s.tokens[token] = &formToken{key: key, expires: now.Add(s.ttl)}
+	s.perKey[key]++
+	return token, nil
+}
+
+// Consume marks token as used by the client identified by key. It returns
+// ErrFormTokenUsed when the token was already consumed and
+// ErrFormTokenInvalid when it is unknown, expired or issued to another
+// key. Of two concurrent calls with one token only the first succeeds.
+func (s *FormTokens) Consume(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[token]
+	if !ok || t.key != key {
+		return ErrFormTokenInvalid
+	}
+	if !s.clock.Now().Before(t.expires) {
+		s.remove(token, t)
+		return ErrFormTokenInvalid
+	}
+	if t.used {
+		return ErrFormTokenUsed
+	}
+	t.used = true
+	return nil
+}
+
+// sweep drops the tokens expired at now. Callers hold s.mu.
+func (s *FormTokens) sweep(now time.Time) {
+	for token, t := range s.tokens {
+		if !now.Before(t.expires) {
+			s.remove(token, t)
+		}
+	}
+}
+
+// evictOldest drops the token expiring first, of key only when byKey is
+// set. Callers hold s.mu.
+func (s *FormTokens) evictOldest(key string, byKey bool) {
+	var oldest string
+	var oldestToken *formToken
+	for token, t := range s.tokens {
+		if byKey && t.key != key {
+			continue
+		}
+		if oldestToken == nil || t.expires.Before(oldestToken.expires) {
+			oldest, oldestToken = token, t
+		}
+	}
+	if oldestToken != nil {
+		s.remove(oldest, oldestToken)
+	}
+}
+
+func (s *FormTokens) remove(token string, t *formToken) {
+	delete(s.tokens, token)
+	if s.perKey[t.key]--; s.perKey[t.key] <= 0 {
+		delete(s.perKey, t.key)
+	}
+}
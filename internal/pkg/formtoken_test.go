@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFormTokens_Consume(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewFormTokens(time.Minute, clock)
+
+	token, err := s.Issue("client-a")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if err := s.Consume("client-b", token); !errors.Is(err, ErrFormTokenInvalid) {
+		t.Errorf("Consume(other key) = %v, want ErrFormTokenInvalid", err)
+	}
+	if err := s.Consume("client-a", token); err != nil {
+		t.Errorf("Consume() = %v, want nil", err)
+	}
+	if err := s.Consume("client-a", token); !errors.Is(err, ErrFormTokenUsed) {
+		t.Errorf("second Consume() = %v, want ErrFormTokenUsed", err)
+	}
+	if err := s.Consume("client-a", "never-issued"); !errors.Is(err, ErrFormTokenInvalid) {
+		t.Errorf("Consume(unknown) = %v, want ErrFormTokenInvalid", err)
+	}
+
+	expiring, _ := s.Issue("client-a")
+	clock.Advance(time.Minute)
+	if err := s.Consume("client-a", expiring); !errors.Is(err, ErrFormTokenInvalid) {
+		t.Errorf("Consume(expired) = %v, want ErrFormTokenInvalid", err)
+	}
+}
+
+func TestFormTokens_Bounded(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := NewFormTokens(time.Hour, clock)
+
+	first, _ := s.Issue("tabs")
+	for range maxFormTokensPerKey {
+		clock.Advance(time.Second)
+		if _, err := s.Issue("tabs"); err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+	}
+	if got := s.perKey["tabs"]; got != maxFormTokensPerKey {
+		t.Errorf("tokens held for one key = %d, want %d", got, maxFormTokensPerKey)
+	}
+	if err := s.Consume("tabs", first); !errors.Is(err, ErrFormTokenInvalid) {
+		t.Errorf("Consume(evicted) = %v, want ErrFormTokenInvalid", err)
+	}
+
+	for i := range maxFormTokens {
+		if _, err := s.Issue(strconv.Itoa(i)); err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+	}
+	if len(s.tokens) != maxFormTokens {
+		t.Errorf("tokens held = %d, want %d", len(s.tokens), maxFormTokens)
+	}
+}
@@ -0,0 +1,40 @@
+package pkg
+
+import "context"
+
+// HealthChecker is a component reported under "components" on the health
+// check. Check should return promptly once ctx is done; the health check
+// gives up on it after the per-checker timeout either way.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CriticalHealthChecker is a HealthChecker that may declare itself critical:
+// only the failure of a critical checker degrades the overall status (and
+// answers 503). Other failures are reported per component.
+type CriticalHealthChecker interface {
+	HealthChecker
+	Critical() bool
+}
+
+// IsCriticalHealthChecker reports whether hc is marked critical.
+func IsCriticalHealthChecker(hc HealthChecker) bool {
+	c, ok := hc.(CriticalHealthChecker)
+	return ok && c.Critical()
+}
+
+// NewHealthCheck returns a HealthChecker named name that runs check.
+func NewHealthCheck(name string, critical bool, check func(ctx context.Context) error) HealthChecker {
+	return healthCheck{name: name, critical: critical, check: check}
+}
+
+type healthCheck struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+func (h healthCheck) Name() string                    { return h.name }
+func (h healthCheck) Critical() bool                  { return h.critical }
+func (h healthCheck) Check(ctx context.Context) error { return h.check(ctx) }
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default limits of JSON request bodies bound by BindAndValidate, used when
+// the request carries no JSONLimits (server.api.max_json_depth,
+// server.api.max_json_tokens and server.api.max_json_bytes).
+const (
+	DefaultMaxJSONDepth  = 64
+	DefaultMaxJSONTokens = 100_000
+	DefaultMaxJSONBytes  = 1 << 20
+)
+
+// jsonLimitsKey holds the JSONLimits of a request.
+const jsonLimitsKey = "pkg.json_limits"
+
+// JSONLimits bounds the shape of a JSON request body. A zero field uses its
+// default.
+type JSONLimits struct {
+	// MaxDepth is the deepest allowed nesting of objects and arrays.
+	MaxDepth int
+	// MaxTokens is the most tokens (delimiters, keys and values) allowed.
+	MaxTokens int
+	// MaxBytes is the largest allowed body, in bytes.
+	MaxBytes int64
+}
+
+// SetJSONLimits sets the limits BindAndValidate enforces on JSON bodies of
+// this request.
+func SetJSONLimits(c *gin.Context, limits JSONLimits) {
+	c.Set(jsonLimitsKey, limits)
+}
+
+// jsonLimits returns the limits for c with defaults filled in.
+func jsonLimits(c *gin.Context) JSONLimits {
+	limits, _ := c.Value(jsonLimitsKey).(JSONLimits)
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMaxJSONDepth
+	}
+	if limits.MaxTokens <= 0 {
+		limits.MaxTokens = DefaultMaxJSONTokens
+	}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = DefaultMaxJSONBytes
+	}
+	return limits
+}
+
+// jsonLimitError reports a body that exceeds its JSONLimits. Its message is
+// sent to the client as the "body" validation error.
+type jsonLimitError struct{ msg string }
+
+func (e *jsonLimitError) Error() string { return e.msg }
+
+// checkJSONLimits scans raw token by token and stops at the first limit
+// exceeded, before anything is decoded into memory. Malformed JSON is not
+// its concern: it returns nil and leaves the error to the binder.
+func checkJSONLimits(raw []byte, limits JSONLimits) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	depth, tokens := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// io.EOF after the last value, or a syntax error.
+			return nil
+		}
+		if tokens++; tokens > limits.MaxTokens {
+			return &jsonLimitError{fmt.Sprintf("JSON body must not have more than %d tokens", limits.MaxTokens)}
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			if depth++; depth > limits.MaxDepth {
+				return &jsonLimitError{fmt.Sprintf("JSON body must not nest deeper than %d levels", limits.MaxDepth)}
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type limitsInput struct {
+	Name string `json:"name" binding:"required"`
+	Tags []int  `json:"tags"`
+	Meta any    `json:"meta"`
+}
+
+func decodeValidationResponse(t *testing.T, body []byte) ValidationErrorResponse {
+	t.Helper()
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestBindAndValidate_RejectsDeepNesting(t *testing.T) {
+	body := `{"name":"a","meta":` + strings.Repeat("[", 10000) + strings.Repeat("]", 10000) + `}`
+	c, w := newResponseTestContextWithBody(body)
+
+	var input limitsInput
+	if BindAndValidate(c, &input) {
+		t.Fatal("BindAndValidate() = true, want false for a 10000-deep body")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	resp := decodeValidationResponse(t, w.Body.Bytes())
+	if resp.Message != "validation error" || !strings.Contains(resp.Errors["body"], "deeper than 64 levels") {
+		t.Errorf("response = %+v, want a body depth error", resp)
+	}
+}
+
+func TestBindAndValidate_RejectsTooManyTokens(t *testing.T) {
+	c, w := newResponseTestContextWithBody(`{"name":"a","tags":[` + strings.Repeat("1,", 20) + `1]}`)
+	SetJSONLimits(c, JSONLimits{MaxTokens: 10})
+
+	var input limitsInput
+	if BindAndValidate(c, &input) {
+		t.Fatal("BindAndValidate() = true, want false over the token limit")
+	}
+	resp := decodeValidationResponse(t, w.Body.Bytes())
+	if w.Code != http.StatusBadRequest || !strings.Contains(resp.Errors["body"], "more than 10 tokens") {
+		t.Errorf("response = %d %+v, want 400 with a body token error", w.Code, resp)
+	}
+}
+
+func TestBindAndValidate_LimitErrorAsProblem(t *testing.T) {
+	c, w := newResponseTestContextWithBody(`{"name":"a","meta":[[[1]]]}`)
+	c.Request.Header.Set("Accept", ProblemContentType)
+	SetJSONLimits(c, JSONLimits{MaxDepth: 2})
+
+	var input limitsInput
+	if BindAndValidate(c, &input) {
+		t.Fatal("BindAndValidate() = true, want false")
+	}
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "body" {
+		t.Errorf("invalid-params = %+v, want one body entry", p.InvalidParams)
+	}
+}
+
+func TestBindAndValidate_WithinLimitsUnchanged(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"valid", `{"name":"a","tags":[1,2,3],"meta":{"a":{"b":[1,{"c":null}]}}}`, true},
+		{"camelCase keys", `{"name":"a","meta":{"nestedKey":true}}`, true},
+		{"missing field", `{"tags":[1]}`, false},
+		{"malformed", `{"name":"a",`, false},
+		{"exactly at depth", `{"name":"a","meta":` + strings.Repeat("[", 63) + strings.Repeat("]", 63) + `}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newResponseTestContextWithBody(tt.body)
+			var input limitsInput
+			if got := BindAndValidate(c, &input); got != tt.ok {
+				t.Fatalf("BindAndValidate() = %v, want %v (body %s)", got, tt.ok, w.Body.String())
+			}
+			if !tt.ok && strings.Contains(w.Body.String(), `"body"`) {
+				t.Errorf("response = %s, want the binder's error, not a limit error", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCheckJSONLimits_IgnoresBracketsInStrings(t *testing.T) {
+	raw := []byte(`{"name":"` + strings.Repeat("[", 100) + `"}`)
+	if err := checkJSONLimits(raw, JSONLimits{MaxDepth: 2, MaxTokens: 10}); err != nil {
+		t.Errorf("checkJSONLimits() = %v, want nil for brackets inside a string", err)
+	}
+}
+
+// BenchmarkCheckJSONLimits measures the pre-scan on a typical request body,
+// next to the decode it precedes.
+func BenchmarkCheckJSONLimits(b *testing.B) {
+	raw := []byte(`{"name":"Alice","email":"alice@example.com","tags":[1,2,3,4,5],"meta":{"address":{"city":"Berlin","postalCode":"10115"},"roles":["admin","editor"]}}`)
+	limits := JSONLimits{MaxDepth: DefaultMaxJSONDepth, MaxTokens: DefaultMaxJSONTokens}
+	b.Run("scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := checkJSONLimits(raw, limits); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var v any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestBindAndValidate_RejectsLargeBody(t *testing.T) {
+	c, w := newResponseTestContextWithBody(`{"name":"` + strings.Repeat("a", 100) + `"}`)
+	SetJSONLimits(c, JSONLimits{MaxBytes: 64})
+
+	var input limitsInput
+	if BindAndValidate(c, &input) {
+		t.Fatal("BindAndValidate() = true, want false over the size limit")
+	}
+	resp := decodeValidationResponse(t, w.Body.Bytes())
+	if w.Code != http.StatusBadRequest || !strings.Contains(resp.Errors["body"], "larger than 64 bytes") {
+		t.Errorf("response = %d %+v, want 400 with a body size error", w.Code, resp)
+	}
+}
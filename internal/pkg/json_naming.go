@@ -0,0 +1,309 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSONNamingHeader is the request header a client sends with the value
+// "camel" to receive camelCase JSON keys instead of snake_case.
+const JSONNamingHeader = "X-JSON-Naming"
+
+// camelJSONKey marks a request whose JSON responses must use camelCase keys
+// regardless of its X-JSON-Naming header (server.api.json_naming).
+const camelJSONKey = "pkg.camel_json"
+
+// ForceCamelJSON makes every JSON response for this request use camelCase
+// keys, as if the client had asked for them.
+func ForceCamelJSON(c *gin.Context) {
+	c.Set(camelJSONKey, true)
+}
+
+// WantsCamelJSON reports whether JSON responses for c should use camelCase
+// keys: the request was marked by ForceCamelJSON or its X-JSON-Naming header
+// is "camel".
+func WantsCamelJSON(c *gin.Context) bool {
+	if c.GetBool(camelJSONKey) {
+		return true
+	}
+	return strings.EqualFold(strings.TrimSpace(c.GetHeader(JSONNamingHeader)), "camel")
+}
+
+// JSON sends obj as JSON with the given status, converting the object keys
+// that name struct fields to camelCase when WantsCamelJSON; map keys are
+// data and are sent as they are. Without camel naming the output is
+// exactly c.JSON's. Handlers that need a status other than 200 use it instead
+// of c.JSON so they honor the negotiated naming.
+func JSON(c *gin.Context, status int, obj any) {
+	if !WantsCamelJSON(c) {
+		c.JSON(status, obj)
+		return
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		// Let c.JSON surface the marshalling error as it would have.
+		c.JSON(status, obj)
+		return
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		c.JSON(status, obj)
+		return
+	}
+	c.JSON(status, renameKeys(v, reflect.ValueOf(obj), CamelCase))
+}
+
+// normalizeJSONBody rewrites a JSON request body bound to obj so the
+// camelCase keys of obj's struct fields become snake_case, letting DTOs
+// with snake_case tags bind either convention; the keys of map fields are
+// data and are kept. A key already present in snake_case wins over its
+// camelCase spelling. Bodies that are not JSON or fail to decode are left untouched
+// for the binder to handle. A body exceeding the request's JSONLimits is
+// rejected before it is decoded, with a *jsonLimitError; one over MaxBytes
+// is not read past the limit.
+func normalizeJSONBody(c *gin.Context, obj any) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(c.ContentType()); err != nil || mediaType != gin.MIMEJSON {
+		return nil
+	}
+	limits := jsonLimits(c)
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBytes))
+	_ = c.Request.Body.Close()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &jsonLimitError{fmt.Sprintf("JSON body must not be larger than %d bytes", limits.MaxBytes)}
+	}
+	if err != nil {
+		// Hand the binder an erroring body so it reports the read failure.
+		c.Request.Body = io.NopCloser(errReader{err})
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+	if err := checkJSONLimits(raw, limits); err != nil {
+		return err
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	normalized, err := json.Marshal(renameKeys(v, reflect.ValueOf(obj), SnakeCase))
+	if err != nil {
+		slog.Warn("normalize JSON body failed", slog.Any("error", err))
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(normalized))
+	return nil
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// renameKeys returns a copy of the decoded JSON document doc, the encoding
+// of v (for a request body, the value it is decoded into), with the object
+// keys that name struct fields passed through rename. Map keys are data and
+// are kept, as are keys that match no field; so is everything under a nil
+// interface, whose type is unknown, or a type with its own JSON encoding.
+// When two keys rename to the same name, the one that was already in that
+// form is kept.
+func renameKeys(doc any, v reflect.Value, rename func(string) string) any {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && !encodesItself(v.Type()) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem() // invalid for a nil interface
+	}
+	if !v.IsValid() || encodesItself(v.Type()) {
+		return doc
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return doc
+		}
+		fields := jsonFields(v)
+		out := make(map[string]any, len(obj))
+		for key, val := range obj {
+			name := rename(key)
+			f, ok := fields[key]
+			if !ok {
+				f, ok = fields[name]
+			}
+			if !ok {
+				out[key] = val
+				continue
+			}
+			if _, exists := obj[name]; exists && name != key {
+				continue
+			}
+			out[name] = renameKeys(val, f, rename)
+		}
+		return out
+	case reflect.Map:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return doc
+		}
+		t := v.Type()
+		out := make(map[string]any, len(obj))
+		for key, val := range obj {
+			elem := reflect.New(t.Elem()).Elem()
+			if t.Key().Kind() == reflect.String {
+				if e := v.MapIndex(reflect.ValueOf(key).Convert(t.Key())); e.IsValid() {
+					elem = e
+				}
+			}
+			out[key] = renameKeys(val, elem, rename)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		arr, ok := doc.([]any)
+		if !ok {
+			return doc
+		}
+		out := make([]any, len(arr))
+		for i, val := range arr {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if i < v.Len() {
+				elem = v.Index(i)
+			}
+			out[i] = renameKeys(val, elem, rename)
+		}
+		return out
+	}
+	return doc
+}
+
+// jsonFields returns the fields of struct value v by JSON name, with those
+// of embedded structs without a name promoted as encoding/json does; a
+// field hides the fields of the same name embedded deeper than it.
+func jsonFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	depths := make(map[string]int)
+	var walk func(v reflect.Value, depth int)
+	walk = func(v reflect.Value, depth int) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			fv := v.Field(i)
+			if sf.Anonymous && name == "" {
+				ev := fv
+				if ev.Kind() == reflect.Pointer {
+					if ev.IsNil() {
+						ev = reflect.New(ev.Type().Elem())
+					}
+					ev = ev.Elem()
+				}
+				if ev.Kind() == reflect.Struct && !encodesItself(ev.Type()) {
+					walk(ev, depth+1)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if d, ok := depths[name]; ok && d <= depth {
+				continue
+			}
+			fields[name], depths[name] = fv, depth
+		}
+	}
+	walk(v, 0)
+	return fields
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// encodesItself reports whether t, or a pointer to it, has its own JSON
+// encoding or decoding, such as time.Time and Optional.
+func encodesItself(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+	for _, m := range []reflect.Type{jsonMarshalerType, jsonUnmarshalerType, textMarshalerType, textUnmarshalerType} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// CamelCase converts a snake_case name to camelCase: "created_at" becomes
+// "createdAt". Names without underscores, and leading or trailing ones, are
+// kept as they are.
+func CamelCase(name string) string {
+	if !strings.Contains(strings.Trim(name, "_"), "_") {
+		return name
+	}
+	var b strings.Builder
+	b.Grow(len(name))
+	upper := false
+	for i, r := range name {
+		switch {
+		case r == '_' && i > 0 && i < len(name)-1 && b.Len() > 0:
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// SnakeCase converts a camelCase name to snake_case: "createdAt" becomes
+// "created_at" and "userID" "user_id". Names without upper-case letters are
+// kept as they are.
+func SnakeCase(name string) string {
+	if strings.IndexFunc(name, unicode.IsUpper) < 0 {
+		return name
+	}
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,208 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type namingInput struct {
+	FirstName string `json:"first_name" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Address   struct {
+		PostalCode string `json:"postal_code"`
+	} `json:"home_address"`
+}
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"created_at":          "createdAt",
+		"first_page_in_range": "firstPageInRange",
+		"email":               "email",
+		"invalid-params":      "invalid-params",
+		"_private":            "_private",
+		"trailing_":           "trailing_",
+		"a__b":                "aB",
+	}
+	for in, want := range tests {
+		if got := CamelCase(in); got != want {
+			t.Errorf("CamelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"createdAt":        "created_at",
+		"firstPageInRange": "first_page_in_range",
+		"userID":           "user_id",
+		"HTTPStatus":       "http_status",
+		"page2Size":        "page2_size",
+		"created_at":       "created_at",
+		"email":            "email",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestJSON_DefaultOutputUnchanged(t *testing.T) {
+	c, w := newResponseTestContext()
+	JSON(c, http.StatusCreated, Response{Code: http.StatusCreated, Message: "success", Data: map[string]any{"created_at": 1}})
+
+	want := `{"code":201,"message":"success","data":{"created_at":1}}`
+	if w.Code != http.StatusCreated || w.Body.String() != want {
+		t.Errorf("JSON() = %d %s, want 201 %s", w.Code, w.Body.String(), want)
+	}
+}
+
+func TestJSON_CamelHeaderConvertsNestedKeys(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.Header.Set(JSONNamingHeader, "Camel")
+	type item struct {
+		CreatedAt string `json:"created_at"`
+		UserID    int64  `json:"user_id"`
+	}
+	List(c, struct {
+		Items      []any `json:"items"`
+		TotalItems int   `json:"total_items"`
+	}{[]any{item{"2024-01-01T00:00:00Z", 12345678901234567}}, 1})
+
+	body := w.Body.String()
+	for _, key := range []string{`"totalItems":1`, `"createdAt"`, `"userId":12345678901234567`, `"code":200`} {
+		if !strings.Contains(body, key) {
+			t.Errorf("body = %s, want %s", body, key)
+		}
+	}
+	if strings.Contains(body, "_") {
+		t.Errorf("body = %s, want no snake_case keys", body)
+	}
+}
+
+func TestForceCamelJSON(t *testing.T) {
+	c, w := newResponseTestContext()
+	ForceCamelJSON(c)
+	if !WantsCamelJSON(c) {
+		t.Fatal("WantsCamelJSON() = false after ForceCamelJSON")
+	}
+	Success(c, struct {
+		PageSize int `json:"page_size"`
+	}{20})
+	if !strings.Contains(w.Body.String(), `"pageSize":20`) {
+		t.Errorf("body = %s, want pageSize", w.Body.String())
+	}
+}
+
+// mapKeys holds maps keyed by data, such as permissions by resource.
+type mapKeys struct {
+	ByResource map[string][]string `json:"by_resource"`
+	Nested     []map[string]any    `json:"nested"`
+}
+
+func TestJSON_CamelKeepsMapKeys(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.Header.Set(JSONNamingHeader, "camel")
+	Success(c, mapKeys{
+		ByResource: map[string][]string{"user_profile": {"read"}, "auditLog": {"read"}},
+		Nested:     []map[string]any{{"snake_key": map[string]int{"inner_key": 1}}},
+	})
+
+	want := `"data":{"byResource":{"auditLog":["read"],"user_profile":["read"]},"nested":[{"snake_key":{"inner_key":1}}]}`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
+
+func TestBindAndValidate_KeepsMapKeys(t *testing.T) {
+	c, w := newResponseTestContextWithBody(`{"byResource":{"user_profile":["read"],"auditLog":["read"]},"nested":[{"snakeKey":1}]}`)
+	var req mapKeys
+	if !BindAndValidate(c, &req) {
+		t.Fatalf("BindAndValidate() = false, body %s", w.Body.String())
+	}
+	if len(req.ByResource) != 2 || req.ByResource["user_profile"] == nil || req.ByResource["auditLog"] == nil {
+		t.Errorf("ByResource = %v, want the keys user_profile and auditLog as sent", req.ByResource)
+	}
+	if len(req.Nested) != 1 || req.Nested[0]["snakeKey"] == nil {
+		t.Errorf("Nested = %v, want the key snakeKey as sent", req.Nested)
+	}
+}
+
+func TestBindAndValidate_AcceptsBothNamings(t *testing.T) {
+	for _, body := range []string{
+		`{"first_name":"Ada","email":"ada@example.com","home_address":{"postal_code":"10115"}}`,
+		`{"firstName":"Ada","email":"ada@example.com","homeAddress":{"postalCode":"10115"}}`,
+	} {
+		c, w := newResponseTestContextWithBody(body)
+		var req namingInput
+		if !BindAndValidate(c, &req) {
+			t.Fatalf("BindAndValidate(%s) = false, body %s", body, w.Body.String())
+		}
+		if req.FirstName != "Ada" || req.Address.PostalCode != "10115" {
+			t.Errorf("BindAndValidate(%s) bound %+v", body, req)
+		}
+	}
+}
+
+func TestBindAndValidate_SnakeKeyWinsOverCamel(t *testing.T) {
+	c, _ := newResponseTestContextWithBody(`{"firstName":"camel","first_name":"snake","email":"ada@example.com"}`)
+	var req namingInput
+	if !BindAndValidate(c, &req) {
+		t.Fatal("BindAndValidate() = false")
+	}
+	if req.FirstName != "snake" {
+		t.Errorf("FirstName = %q, want %q", req.FirstName, "snake")
+	}
+}
+
+func TestBindAndValidate_ValidationFieldsFollowNaming(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"", "first_name"},
+		{"camel", "firstName"},
+	} {
+		c, w := newResponseTestContextWithBody(`{"email":"ada@example.com"}`)
+		if tc.header != "" {
+			c.Request.Header.Set(JSONNamingHeader, tc.header)
+		}
+		var req namingInput
+		if BindAndValidate(c, &req) {
+			t.Fatal("BindAndValidate() = true, want validation failure")
+		}
+		var resp ValidationErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if _, ok := resp.Errors[tc.want]; !ok || len(resp.Errors) != 1 {
+			t.Errorf("header %q: errors = %v, want only %s", tc.header, resp.Errors, tc.want)
+		}
+	}
+
+	c, w := newResponseTestContextWithBody(`{"email":"ada@example.com"}`)
+	c.Request.Header.Set(JSONNamingHeader, "camel")
+	c.Request.Header.Set("Accept", ProblemContentType)
+	var req namingInput
+	BindAndValidate(c, &req)
+	p := decodeProblem(t, w.Body.Bytes())
+	if len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "firstName" {
+		t.Errorf("invalid-params = %+v, want firstName", p.InvalidParams)
+	}
+}
+
+func TestValidationError_ParamErrorsKeepQueryNames(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.Header.Set(JSONNamingHeader, "camel")
+	ValidationError(c, ParamErrors{"page_size": "must be a positive integer"})
+
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := resp.Errors["page_size"]; !ok {
+		t.Errorf("errors = %v, want page_size (query parameters stay snake_case)", resp.Errors)
+	}
+}
@@ -0,0 +1,266 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/simp-lee/jwt"
+)
+
+// JWTKey is one signing key of a key ring. ID is written to the "kid"
+// header of tokens signed with it.
+type JWTKey struct {
+	ID      string
+	Secret  string
+	Primary bool
+}
+
+// jwtKeyRing is a jwt.Service over several HMAC keys. Tokens are signed with
+// the primary key and carry its kid; validation tries the key named by the
+// token's kid first and then the rest of the ring, so tokens minted before a
+// rotation (or before kids existed) stay valid until their key is removed.
+//
+// Each key is backed by its own jwt.Service, which owns the revocation list
+// for the tokens it verifies. RevokeAllUserTokens is applied to every key.
+type jwtKeyRing struct {
+	primary  JWTKey
+	keys     []JWTKey // primary first
+	services map[string]jwt.Service
+}
+
+var _ jwt.Service = (*jwtKeyRing)(nil)
+
+// NewJWTKeyRing builds a jwt.Service from keys, which must contain exactly
+// one primary key and no duplicate IDs. opts are applied to every key's
+// underlying service.
+func NewJWTKeyRing(keys []JWTKey, opts ...jwt.Option) (jwt.Service, error) {
+	if len(keys) == 0 {
+		return nil, jwt.ErrMissingSecretKey
+	}
+	r := &jwtKeyRing{services: make(map[string]jwt.Service, len(keys))}
+	primaries := 0
+	for _, key := range keys {
+		if key.Primary {
+			primaries++
+			r.primary = key
+		}
+	}
+	if primaries != 1 {
+		return nil, fmt.Errorf("jwt key ring: want exactly one primary key, got %d", primaries)
+	}
+
+	r.keys = append(r.keys, r.primary)
+	for _, key := range keys {
+		if !key.Primary {
+			r.keys = append(r.keys, key)
+		}
+	}
+	for _, key := range r.keys {
+		if key.ID == "" {
+			r.Close()
+			return nil, errors.New("jwt key ring: key ID cannot be empty")
+		}
+		if _, dup := r.services[key.ID]; dup {
+			r.Close()
+			return nil, fmt.Errorf("jwt key ring: duplicate key ID %q", key.ID)
+		}
+		svc, err := jwt.New(key.Secret, opts...)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("jwt key %q: %w", key.ID, err)
+		}
+		r.services[key.ID] = svc
+	}
+	return r, nil
+}
+
+// GenerateToken signs a token with the primary key.
+func (r *jwtKeyRing) GenerateToken(userID string, roles []string, expiresIn time.Duration) (string, error) {
+	token, err := r.services[r.primary.ID].GenerateToken(userID, roles, expiresIn)
+	if err != nil {
+		return "", err
+	}
+	return withKeyID(token, r.primary)
+}
+
+// ValidateToken validates tokenString with the key that signed it.
+func (r *jwtKeyRing) ValidateToken(tokenString string) (*jwt.Token, error) {
+	svc, err := r.owner(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ValidateToken(tokenString)
+}
+
+// ValidateAndParse is ValidateToken.
+//
+// Deprecated: use ValidateToken, as jwt.Service does.
+func (r *jwtKeyRing) ValidateAndParse(tokenString string) (*jwt.Token, error) {
+	return r.ValidateToken(tokenString)
+}
+
+// RefreshToken revokes tokenString and issues a replacement with the same
+// lifetime, signed with the primary key.
+func (r *jwtKeyRing) RefreshToken(tokenString string) (string, error) {
+	return r.refresh(tokenString, 0)
+}
+
+// RefreshTokenExtend revokes tokenString and issues a replacement that
+// expires extendsIn from now, signed with the primary key.
+func (r *jwtKeyRing) RefreshTokenExtend(tokenString string, extendsIn time.Duration) (string, error) {
+	if extendsIn <= 0 {
+		return "", fmt.Errorf("%w: requested duration %v must be > 0", jwt.ErrTokenCreation, extendsIn)
+	}
+	return r.refresh(tokenString, extendsIn)
+}
+
+// refresh implements both refresh variants; expiresIn 0 keeps the old
+// token's lifetime. The old token is revoked only once the new one exists.
+func (r *jwtKeyRing) refresh(tokenString string, expiresIn time.Duration) (string, error) {
+	svc, err := r.owner(tokenString)
+	if err != nil {
+		return "", err
+	}
+	old, err := svc.ValidateToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+	if expiresIn == 0 {
+		expiresIn = old.ExpiresAt.Sub(old.IssuedAt)
+	}
+	token, err := r.GenerateToken(old.UserID, old.Roles, expiresIn)
+	if err != nil {
+		return "", err
+	}
+	if err := svc.RevokeToken(tokenString); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken revokes tokenString with the key that signed it.
+func (r *jwtKeyRing) RevokeToken(tokenString string) error {
+	svc, err := r.owner(tokenString)
+	if err != nil {
+		return err
+	}
+	return svc.RevokeToken(tokenString)
+}
+
+// IsTokenRevoked reports whether any key has revoked tokenID.
+func (r *jwtKeyRing) IsTokenRevoked(tokenID string) bool {
+	for _, key := range r.keys {
+		if r.services[key.ID].IsTokenRevoked(tokenID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseToken parses tokenString with the key that signed it.
+func (r *jwtKeyRing) ParseToken(tokenString string) (*jwt.Token, error) {
+	svc, err := r.owner(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return svc.ParseToken(tokenString)
+}
+
+// RevokeAllUserTokens revokes the user's tokens under every key.
+func (r *jwtKeyRing) RevokeAllUserTokens(userID string) error {
+	for _, key := range r.keys {
+		if err := r.services[key.ID].RevokeAllUserTokens(userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every key's service.
+func (r *jwtKeyRing) Close() {
+	for _, svc := range r.services {
+		svc.Close()
+	}
+}
+
+// owner returns the service whose key verifies tokenString's signature,
+// trying the key named by its kid header first. When no key matches it
+// returns the first key's error (jwt.ErrInvalidToken for a bad signature).
+func (r *jwtKeyRing) owner(tokenString string) (jwt.Service, error) {
+	kid := tokenKeyID(tokenString)
+	candidates := make([]string, 0, len(r.keys))
+	if _, ok := r.services[kid]; ok {
+		candidates = append(candidates, kid)
+	}
+	for _, key := range r.keys {
+		if key.ID != kid {
+			candidates = append(candidates, key.ID)
+		}
+	}
+
+	var firstErr error
+	for _, id := range candidates {
+		svc := r.services[id]
+		_, err := svc.ParseToken(tokenString)
+		if err == nil {
+			return svc, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// jwtHeader is the JOSE header of tokens issued by the key ring.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// withKeyID re-signs token, an HS256 JWT signed with key, under a header
+// that also carries key.ID. The claims segment is kept as is.
+func withKeyID(token string, key JWTKey) (string, error) {
+	_, rest, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", fmt.Errorf("%w: malformed token", jwt.ErrTokenCreation)
+	}
+	claims, _, ok := strings.Cut(rest, ".")
+	if !ok {
+		return "", fmt.Errorf("%w: malformed token", jwt.ErrTokenCreation)
+	}
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", jwt.ErrTokenCreation, err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + claims
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// tokenKeyID returns the kid header of tokenString, or "" when it has none
+// or cannot be decoded. The value is only a lookup hint: the signature is
+// still verified by the key's service.
+func tokenKeyID(tokenString string) string {
+	encoded, _, ok := strings.Cut(tokenString, ".")
+	if !ok {
+		return ""
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ""
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return ""
+	}
+	return header.Kid
+}
@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/simp-lee/jwt"
+)
+
+const (
+	oldSecret = "abcdefghijklmnopqrstuvwxyz123456"
+	newSecret = "zyxwvutsrqponmlkjihgfedcba654321"
+)
+
+func newTestKeyRing(t *testing.T, keys ...JWTKey) jwt.Service {
+	t.Helper()
+	svc, err := NewJWTKeyRing(keys)
+	if err != nil {
+		t.Fatalf("NewJWTKeyRing: %v", err)
+	}
+	t.Cleanup(svc.Close)
+	return svc
+}
+
+func TestJWTKeyRing_SignsWithPrimaryKID(t *testing.T) {
+	ring := newTestKeyRing(t,
+		JWTKey{ID: "2025", Secret: oldSecret},
+		JWTKey{ID: "2026", Secret: newSecret, Primary: true},
+	)
+	token, err := ring.GenerateToken("1", []string{"admin"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if kid := tokenKeyID(token); kid != "2026" {
+		t.Errorf("kid = %q, want 2026", kid)
+	}
+	got, err := ring.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if got.UserID != "1" || len(got.Roles) != 1 || got.Roles[0] != "admin" {
+		t.Errorf("token = %+v, want user 1 with role admin", got)
+	}
+}
+
+func TestJWTKeyRing_Rotation(t *testing.T) {
+	before := newTestKeyRing(t, JWTKey{ID: "2025", Secret: oldSecret, Primary: true})
+	oldToken, err := before.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	rotated := newTestKeyRing(t,
+		JWTKey{ID: "2025", Secret: oldSecret},
+		JWTKey{ID: "2026", Secret: newSecret, Primary: true},
+	)
+	if _, err := rotated.ValidateToken(oldToken); err != nil {
+		t.Errorf("token under the old key rejected after rotation: %v", err)
+	}
+	refreshed, err := rotated.RefreshToken(oldToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if kid := tokenKeyID(refreshed); kid != "2026" {
+		t.Errorf("refreshed kid = %q, want the primary 2026", kid)
+	}
+	if _, err := rotated.ValidateToken(oldToken); !errors.Is(err, jwt.ErrRevokedToken) {
+		t.Errorf("old token after refresh: err = %v, want ErrRevokedToken", err)
+	}
+
+	retired := newTestKeyRing(t, JWTKey{ID: "2026", Secret: newSecret, Primary: true})
+	if _, err := retired.ValidateToken(oldToken); !errors.Is(err, jwt.ErrInvalidToken) {
+		t.Errorf("token under a removed key: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := retired.ValidateToken(refreshed); err != nil {
+		t.Errorf("token under the primary key rejected: %v", err)
+	}
+}
+
+func TestJWTKeyRing_AcceptsTokensWithoutKID(t *testing.T) {
+	plain, err := jwt.New(oldSecret)
+	if err != nil {
+		t.Fatalf("jwt.New: %v", err)
+	}
+	defer plain.Close()
+	token, err := plain.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	ring := newTestKeyRing(t,
+		JWTKey{ID: "2026", Secret: newSecret, Primary: true},
+		JWTKey{ID: "default", Secret: oldSecret},
+	)
+	if _, err := ring.ValidateToken(token); err != nil {
+		t.Errorf("token without kid rejected: %v", err)
+	}
+}
+
+func TestJWTKeyRing_RevokeAllUserTokensCoversEveryKey(t *testing.T) {
+	before := newTestKeyRing(t, JWTKey{ID: "2025", Secret: oldSecret, Primary: true})
+	oldToken, err := before.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	ring := newTestKeyRing(t,
+		JWTKey{ID: "2025", Secret: oldSecret},
+		JWTKey{ID: "2026", Secret: newSecret, Primary: true},
+	)
+	newToken, err := ring.GenerateToken("1", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	if err := ring.RevokeAllUserTokens("1"); err != nil {
+		t.Fatalf("RevokeAllUserTokens: %v", err)
+	}
+	for name, token := range map[string]string{"old key": oldToken, "primary": newToken} {
+		if _, err := ring.ValidateToken(token); !errors.Is(err, jwt.ErrRevokedToken) {
+			t.Errorf("%s token: err = %v, want ErrRevokedToken", name, err)
+		}
+	}
+}
+
+func TestNewJWTKeyRing_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []JWTKey
+	}{
+		{"empty", nil},
+		{"no primary", []JWTKey{{ID: "a", Secret: oldSecret}}},
+		{"two primaries", []JWTKey{{ID: "a", Secret: oldSecret, Primary: true}, {ID: "b", Secret: newSecret, Primary: true}}},
+		{"duplicate id", []JWTKey{{ID: "a", Secret: oldSecret, Primary: true}, {ID: "a", Secret: newSecret}}},
+		{"empty id", []JWTKey{{Secret: oldSecret, Primary: true}}},
+		{"weak secret", []JWTKey{{ID: "a", Secret: "short", Primary: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if svc, err := NewJWTKeyRing(tt.keys); err == nil {
+				svc.Close()
+				t.Fatal("NewJWTKeyRing() error = nil, want error")
+			}
+		})
+	}
+}
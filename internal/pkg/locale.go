@@ -0,0 +1,152 @@
+package pkg
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LanguageRange is one entry of an Accept-Language header: a language tag
+// such as "zh-CN", or "*", with its quality value.
+type LanguageRange struct {
+	Tag string
+	Q   float64
+}
+
+// ParseAcceptLanguage parses an Accept-Language header (RFC 9110 section
+// 12.5.4) into its ranges, highest quality first; ranges of equal quality
+// keep their header order. Malformed entries are skipped, so a garbled
+// header yields fewer or no ranges rather than an error. Ranges with q=0
+// are kept: they mark a language as not acceptable, which MatchLocale
+// honors for "*".
+func ParseAcceptLanguage(header string) []LanguageRange {
+	var ranges []LanguageRange
+	for entry := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		tag = strings.TrimSpace(tag)
+		if !isLanguageRange(tag) {
+			continue
+		}
+		q, ok := parseQuality(params)
+		if !ok {
+			continue
+		}
+		ranges = append(ranges, LanguageRange{Tag: tag, Q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].Q > ranges[j].Q })
+	return ranges
+}
+
+// isLanguageRange reports whether s is "*" or a language tag of 1-8 letter
+// subtag followed by 1-8 alphanumeric subtags, separated by "-".
+func isLanguageRange(s string) bool {
+	if s == "*" {
+		return true
+	}
+	for i, sub := range strings.Split(s, "-") {
+		if len(sub) == 0 || len(sub) > 8 {
+			return false
+		}
+		for _, r := range sub {
+			letter := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+			if !letter && (i == 0 || r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseQuality returns the q parameter of an Accept-Language entry (1 when
+// absent) and whether the parameters are well formed.
+func parseQuality(params string) (float64, bool) {
+	params = strings.TrimSpace(params)
+	if params == "" {
+		return 1, true
+	}
+	name, value, ok := strings.Cut(params, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+		return 0, false
+	}
+	value = strings.TrimSpace(value)
+	// RFC 9110 allows at most three decimals: "0", "0.5", "1.000".
+	if len(value) == 0 || len(value) > 5 || (value[0] != '0' && value[0] != '1') {
+		return 0, false
+	}
+	q, err := strconv.ParseFloat(value, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0, false
+	}
+	return q, true
+}
+
+// MatchLocale returns the first of supported that satisfies ranges, trying
+// the ranges in order. A range matches a supported tag equal to it, then
+// one it is a prefix of ("zh" matches "zh-CN"), then one that is a prefix
+// of it ("en-US" matches "en"), then one with the same primary language
+// ("en-US" matches "en-GB"); tags compare case-insensitively. "*" matches
+// the first supported tag no q=0 range excludes. It reports false when
+// nothing matches, leaving the default to the caller.
+func MatchLocale(ranges []LanguageRange, supported []string) (string, bool) {
+	var excluded []string
+	for _, r := range ranges {
+		if r.Q == 0 && r.Tag != "*" {
+			excluded = append(excluded, r.Tag)
+		}
+	}
+	isExcluded := func(tag string) bool {
+		for _, ex := range excluded {
+			if strings.EqualFold(ex, tag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range ranges {
+		if r.Q == 0 {
+			continue
+		}
+		if r.Tag == "*" {
+			for _, tag := range supported {
+				if !isExcluded(tag) {
+					return tag, true
+				}
+			}
+			continue
+		}
+		if tag, ok := matchTag(r.Tag, supported, isExcluded); ok {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// matchTag applies the MatchLocale rules for one language range, skipping
+// excluded supported tags.
+func matchTag(want string, supported []string, isExcluded func(string) bool) (string, bool) {
+	primary, _, _ := strings.Cut(want, "-")
+	rules := []func(tag string) bool{
+		func(tag string) bool { return strings.EqualFold(tag, want) },
+		func(tag string) bool { return hasTagPrefix(tag, want) },
+		func(tag string) bool { return hasTagPrefix(want, tag) },
+		func(tag string) bool {
+			p, _, _ := strings.Cut(tag, "-")
+			return strings.EqualFold(p, primary)
+		},
+	}
+	for _, rule := range rules {
+		for _, tag := range supported {
+			if rule(tag) && !isExcluded(tag) {
+				return tag, true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasTagPrefix reports whether prefix is a shorter leading run of the
+// subtags of tag, ignoring case.
+func hasTagPrefix(tag, prefix string) bool {
+	return len(tag) > len(prefix) && tag[len(prefix)] == '-' && strings.EqualFold(tag[:len(prefix)], prefix)
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []LanguageRange
+	}{
+		{"empty", "", nil},
+		{"single", "en", []LanguageRange{{"en", 1}}},
+		{"q ordering", "zh-CN;q=0.8, en;q=0.9", []LanguageRange{{"en", 0.9}, {"zh-CN", 0.8}}},
+		{"equal q keeps order", "fr, de, en;q=1.000", []LanguageRange{{"fr", 1}, {"de", 1}, {"en", 1}}},
+		{"wildcard", "en-US, *;q=0.1", []LanguageRange{{"en-US", 1}, {"*", 0.1}}},
+		{"q zero kept", "en;q=0, fr", []LanguageRange{{"fr", 1}, {"en", 0}}},
+		{"spaces and case", "  zh-Hant-TW ; Q = 0.5 ,EN", []LanguageRange{{"EN", 1}, {"zh-Hant-TW", 0.5}}},
+		{"malformed entries skipped", "en;q=2, 12, fr;q=abc, de;level=1, es-;q=0.5, ja;q=0.3", []LanguageRange{{"ja", 0.3}}},
+		{"too many decimals", "en;q=0.1234", nil},
+		{"subtag too long", "en-abcdefghi", nil},
+		{"garbage", ";;;,,,=", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	supported := []string{"zh-CN", "en", "en-GB", "pt-BR"}
+
+	tests := []struct {
+		name      string
+		header    string
+		want      string
+		wantMatch bool
+	}{
+		{"exact", "en-GB", "en-GB", true},
+		{"case insensitive", "ZH-cn", "zh-CN", true},
+		{"q value decides", "zh-CN;q=0.8, en;q=0.9", "en", true},
+		{"language matches region", "zh", "zh-CN", true},
+		{"region falls back to language", "en-US", "en", true},
+		{"region matches sibling region", "pt-PT", "pt-BR", true},
+		{"ordered fallback", "fr, de;q=0.9, pt;q=0.5", "pt-BR", true},
+		{"wildcard takes first supported", "fr, *;q=0.5", "zh-CN", true},
+		{"wildcard skips excluded", "*, zh-CN;q=0", "en", true},
+		{"excluded not matched by language", "zh-CN;q=0, zh", "", false},
+		{"no match", "fr, de", "", false},
+		{"malformed", "en;q=2", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MatchLocale(ParseAcceptLanguage(tt.header), supported)
+			if got != tt.want || ok != tt.wantMatch {
+				t.Errorf("MatchLocale(%q) = %q, %v; want %q, %v", tt.header, got, ok, tt.want, tt.wantMatch)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// Optional is a request field that distinguishes "not sent" from an explicit
+// JSON null, which a plain pointer cannot: absent leaves the field alone,
+// null clears it, and any other value replaces it. Use it in PATCH request
+// structs:
+//
+//	Bio pkg.Optional[string] `json:"bio" binding:"omitempty,max=2000"`
+//
+// Binding tags validate the value only when one was sent; absent and null
+// fields are treated as empty, so "omitempty" skips them.
+type Optional[T any] struct {
+	present bool
+	null    bool
+	value   T
+}
+
+// Some returns an Optional holding v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{present: true, value: v}
+}
+
+// Null returns an Optional that was sent as JSON null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{present: true, null: true}
+}
+
+// IsPresent reports whether the field was sent, as a value or as null.
+func (o Optional[T]) IsPresent() bool { return o.present }
+
+// IsNull reports whether the field was sent as JSON null.
+func (o Optional[T]) IsNull() bool { return o.null }
+
+// Value returns the value and whether one was sent (present and not null).
+func (o Optional[T]) Value() (T, bool) { return o.value, o.present && !o.null }
+
+// IsZero reports whether the field was not sent, so `json:",omitzero"`
+// omits it when marshalling.
+func (o Optional[T]) IsZero() bool { return !o.present }
+
+// Patch converts o for a domain partial update.
+func (o Optional[T]) Patch() domain.PatchField[T] {
+	return domain.PatchField[T]{Set: o.present, Null: o.null, Value: o.value}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for fields
+// present in the document.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Optional[T]{present: true, null: true, value: zero}
+		return nil
+	}
+	v := zero
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Optional[T]{present: true, value: v}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler: null when absent or null,
+// otherwise the value.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present || o.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// validationValue is what binding tags on an Optional validate: the value,
+// or nil when absent or null.
+func (o Optional[T]) validationValue() any {
+	if v, ok := o.Value(); ok {
+		return v
+	}
+	return nil
+}
+
+type optionalField interface{ validationValue() any }
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		RegisterOptionalValidation(v)
+	}
+}
+
+// RegisterOptionalValidation teaches v to validate the Optional types used
+// in request structs: Optional of string, bool, int, int64, uint, float64 and
+// time.Time. It runs for gin's default validator at init; other Optional
+// instantiations must be added to v with RegisterCustomTypeFunc.
+func RegisterOptionalValidation(v *validator.Validate) {
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		if o, ok := field.Interface().(optionalField); ok {
+			return o.validationValue()
+		}
+		return nil
+	},
+		Optional[string]{}, Optional[bool]{}, Optional[int]{}, Optional[int64]{},
+		Optional[uint]{}, Optional[float64]{}, Optional[time.Time]{},
+	)
+}
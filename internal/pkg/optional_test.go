@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+type optionalInput struct {
+	Name Optional[string] `json:"name" binding:"omitempty,min=2"`
+	Bio  Optional[string] `json:"bio,omitzero" binding:"omitempty,max=5"`
+	Age  Optional[int]    `json:"age" binding:"omitempty,gte=0"`
+}
+
+func TestOptional_UnmarshalJSON(t *testing.T) {
+	var in optionalInput
+	if err := json.Unmarshal([]byte(`{"name":"Ada","bio":null}`), &in); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if v, ok := in.Name.Value(); !in.Name.IsPresent() || in.Name.IsNull() || !ok || v != "Ada" {
+		t.Errorf("Name = %+v, want present value Ada", in.Name)
+	}
+	if _, ok := in.Bio.Value(); !in.Bio.IsPresent() || !in.Bio.IsNull() || ok {
+		t.Errorf("Bio = %+v, want present null", in.Bio)
+	}
+	if in.Age.IsPresent() || in.Age.IsNull() {
+		t.Errorf("Age = %+v, want absent", in.Age)
+	}
+
+	if err := json.Unmarshal([]byte(`{"age":"x"}`), &in); err == nil {
+		t.Error("Unmarshal of a mistyped value: want error")
+	}
+}
+
+func TestOptional_Patch(t *testing.T) {
+	if p := Some("x").Patch(); !p.Set || p.Null || p.Value != "x" {
+		t.Errorf("Some(x).Patch() = %+v", p)
+	}
+	if p := Null[string]().Patch(); !p.Set || !p.Null {
+		t.Errorf("Null().Patch() = %+v", p)
+	}
+	if p := (Optional[string]{}).Patch(); p.Set {
+		t.Errorf("absent Patch() = %+v, want not set", p)
+	}
+}
+
+func TestOptional_MarshalJSON(t *testing.T) {
+	out, err := json.Marshal(optionalInput{Name: Some("Ada"), Age: Null[int]()})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `{"name":"Ada","age":null}`; string(out) != want {
+		t.Errorf("Marshal = %s, want %s", out, want)
+	}
+}
+
+func TestBindAndValidate_Optional(t *testing.T) {
+	for _, tc := range []struct {
+		body  string
+		ok    bool
+		field string
+	}{
+		{`{}`, true, ""},
+		{`{"name":null,"bio":null,"age":null}`, true, ""},
+		{`{"name":"Ada","bio":"hi","age":3}`, true, ""},
+		{`{"name":"A"}`, false, "name"},
+		{`{"bio":"too long"}`, false, "bio"},
+		{`{"age":-1}`, false, "age"},
+	} {
+		c, w := newResponseTestContextWithBody(tc.body)
+		var req optionalInput
+		if got := BindAndValidate(c, &req); got != tc.ok {
+			t.Errorf("BindAndValidate(%s) = %v, want %v (body %s)", tc.body, got, tc.ok, w.Body.String())
+			continue
+		}
+		if tc.ok {
+			continue
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BindAndValidate(%s) status = %d, want 400", tc.body, w.Code)
+		}
+		var resp ValidationErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if _, ok := resp.Errors[tc.field]; !ok {
+			t.Errorf("BindAndValidate(%s) errors = %v, want %q", tc.body, resp.Errors, tc.field)
+		}
+	}
+}
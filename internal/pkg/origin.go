@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestOriginKey holds the validated origin of a request.
+const requestOriginKey = "pkg.request_origin"
+
+type requestOrigin struct {
+	scheme string
+	host   string
+}
+
+// SetRequestOrigin records the scheme ("http" or "https") and host, with an
+// optional port, that absolute URLs for this request are built from. It is
+// set by middleware.AllowedHosts once the Host header has been checked
+// against server.allowed_hosts.
+func SetRequestOrigin(c *gin.Context, scheme, host string) {
+	c.Set(requestOriginKey, requestOrigin{scheme: scheme, host: host})
+}
+
+// AbsoluteURL returns path, which must start with "/", as an absolute URL on
+// the request's validated origin. It reports false when no origin was
+// recorded, e.g. for requests exempt from the Host check; callers should
+// then fall back to the relative path rather than read the Host header.
+func AbsoluteURL(c *gin.Context, path string) (string, bool) {
+	origin, ok := c.Value(requestOriginKey).(requestOrigin)
+	if !ok || !strings.HasPrefix(path, "/") {
+		return path, false
+	}
+	return origin.scheme + "://" + origin.host + path, true
+}
@@ -0,0 +1,20 @@
+package pkg
+
+import "testing"
+
+func TestAbsoluteURL(t *testing.T) {
+	c, _ := newResponseTestContext()
+	// The Host header alone is never used.
+	c.Request.Host = "evil.example.com"
+	if got, ok := AbsoluteURL(c, "/users/1"); ok || got != "/users/1" {
+		t.Errorf("without an origin: AbsoluteURL() = %q, %v; want the path, false", got, ok)
+	}
+
+	SetRequestOrigin(c, "https", "app.example.com:8443")
+	if got, ok := AbsoluteURL(c, "/users/1?tab=notes"); !ok || got != "https://app.example.com:8443/users/1?tab=notes" {
+		t.Errorf("AbsoluteURL() = %q, %v; want the URL on the origin", got, ok)
+	}
+	if got, ok := AbsoluteURL(c, "users/1"); ok || got != "users/1" {
+		t.Errorf("relative path: AbsoluteURL() = %q, %v; want it unchanged, false", got, ok)
+	}
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageWindow is the number of pages shown on each side of the current
+// page by BuildPager.
+const DefaultPageWindow = 2
+
+// PageLink is one entry of a rendered pager: either a page number or an
+// ellipsis marking skipped pages. URL is empty for ellipses and until filled
+// in by BuildPager.
+type PageLink struct {
+	Number     int
+	URL        string
+	IsCurrent  bool
+	IsEllipsis bool
+}
+
+// BuildPageLinks returns the pager entries for current out of total pages:
+// the first and last page, window pages on each side of current, and an
+// ellipsis for every gap of two or more pages (a gap of one is shown as the
+// page itself). current is clamped into [1, total]. It returns nil when
+// total is 0 or 1, so an empty pager renders nothing.
+func BuildPageLinks(current, total, window int) []PageLink {
+	if total <= 1 {
+		return nil
+	}
+	current = min(max(current, 1), total)
+	window = max(window, 0)
+
+	start := max(current-window, 2)
+	end := min(current+window, total-1)
+
+	links := make([]PageLink, 0, end-start+5)
+	links = append(links, PageLink{Number: 1, IsCurrent: current == 1})
+	switch {
+	case start == 3:
+		links = append(links, PageLink{Number: 2})
+	case start > 3:
+		links = append(links, PageLink{IsEllipsis: true})
+	}
+	for p := start; p <= end; p++ {
+		links = append(links, PageLink{Number: p, IsCurrent: p == current})
+	}
+	switch {
+	case end == total-2:
+		links = append(links, PageLink{Number: total - 1})
+	case end < total-2:
+		links = append(links, PageLink{IsEllipsis: true})
+	}
+	links = append(links, PageLink{Number: total, IsCurrent: current == total})
+	return links
+}
+
+// Pager is the template view model for the pagination partial.
+type Pager struct {
+	Links   []PageLink
+	PrevURL string
+	NextURL string
+}
+
+// BuildPager builds the pager for a list page served at baseURL. Every URL
+// keeps the request's query (filters, sort, page_size) and only replaces
+// "page", so navigating never drops the user's current view settings.
+// PrevURL and NextURL are empty on the first and last page respectively.
+func BuildPager(baseURL string, query url.Values, current, total int) Pager {
+	links := BuildPageLinks(current, total, DefaultPageWindow)
+	if links == nil {
+		return Pager{}
+	}
+	current = min(max(current, 1), total)
+
+	pager := Pager{Links: links}
+	for i := range links {
+		if !links[i].IsEllipsis {
+			links[i].URL = PageURL(baseURL, query, links[i].Number)
+		}
+	}
+	if current > 1 {
+		pager.PrevURL = PageURL(baseURL, query, current-1)
+	}
+	if current < total {
+		pager.NextURL = PageURL(baseURL, query, current+1)
+	}
+	return pager
+}
+
+// PageURL returns baseURL with query and page set to the given page number.
+// query is not modified.
+func PageURL(baseURL string, query url.Values, page int) string {
+	q := make(url.Values, len(query)+1)
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("page", strconv.Itoa(page))
+	return baseURL + "?" + q.Encode()
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// renderLinks formats links compactly: "[6]" is the current page and "…" an
+// ellipsis, e.g. "1 … 4 5 [6] 7 8 … 42".
+func renderLinks(links []PageLink) string {
+	parts := make([]string, len(links))
+	for i, l := range links {
+		switch {
+		case l.IsEllipsis:
+			parts[i] = "…"
+		case l.IsCurrent:
+			parts[i] = fmt.Sprintf("[%d]", l.Number)
+		default:
+			parts[i] = fmt.Sprint(l.Number)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildPageLinks(t *testing.T) {
+	tests := []struct {
+		current, total, window int
+		want                   string
+	}{
+		{1, 0, 2, ""},
+		{1, 1, 2, ""},
+		{5, 1, 2, ""},
+		{1, 2, 2, "[1] 2"},
+		{2, 2, 2, "1 [2]"},
+		{1, 5, 2, "[1] 2 3 4 5"},
+		{3, 5, 2, "1 2 [3] 4 5"},
+		{5, 5, 2, "1 2 3 4 [5]"},
+		{6, 42, 2, "1 … 4 5 [6] 7 8 … 42"},
+		{1, 42, 2, "[1] 2 3 … 42"},
+		{2, 42, 2, "1 [2] 3 4 … 42"},
+		{42, 42, 2, "1 … 40 41 [42]"},
+		{41, 42, 2, "1 … 39 40 [41] 42"},
+		// A gap of exactly one page shows the page instead of an ellipsis.
+		{5, 42, 2, "1 2 3 4 [5] 6 7 … 42"},
+		{4, 42, 2, "1 2 3 [4] 5 6 … 42"},
+		{38, 42, 2, "1 … 36 37 [38] 39 40 41 42"},
+		{37, 42, 2, "1 … 35 36 [37] 38 39 … 42"},
+		{3, 7, 2, "1 2 [3] 4 5 6 7"},
+		{4, 9, 2, "1 2 3 [4] 5 6 … 9"},
+		{5, 10, 2, "1 2 3 4 [5] 6 7 … 10"},
+		// Window 0 shows only the current page between the ends.
+		{6, 42, 0, "1 … [6] … 42"},
+		{3, 5, 0, "1 2 [3] 4 5"},
+		{1, 3, 0, "[1] 2 3"},
+		{2, 3, 0, "1 [2] 3"},
+		// Negative windows behave like 0.
+		{6, 42, -3, "1 … [6] … 42"},
+		// Wide windows cover everything.
+		{50, 100, 100, "1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21 22 23 24 25 26 27 28 29 30 31 32 33 34 35 36 37 38 39 40 41 42 43 44 45 46 47 48 49 [50] 51 52 53 54 55 56 57 58 59 60 61 62 63 64 65 66 67 68 69 70 71 72 73 74 75 76 77 78 79 80 81 82 83 84 85 86 87 88 89 90 91 92 93 94 95 96 97 98 99 100"},
+		// Out-of-range current pages are clamped.
+		{0, 42, 2, "[1] 2 3 … 42"},
+		{-7, 42, 2, "[1] 2 3 … 42"},
+		{99, 42, 2, "1 … 40 41 [42]"},
+		{500, 1000, 1, "1 … 499 [500] 501 … 1000"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d_of_%d_w%d", tt.current, tt.total, tt.window), func(t *testing.T) {
+			got := BuildPageLinks(tt.current, tt.total, tt.window)
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("BuildPageLinks() = %q, want nil", renderLinks(got))
+				}
+				return
+			}
+			if s := renderLinks(got); s != tt.want {
+				t.Errorf("BuildPageLinks() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildPageLinks_Invariants checks every small combination: pages are
+// strictly increasing, both ends are always present, exactly one entry is
+// current, and no ellipsis stands in for a single page.
+func TestBuildPageLinks_Invariants(t *testing.T) {
+	for total := 2; total <= 30; total++ {
+		for current := -1; current <= total+2; current++ {
+			for window := 0; window <= 4; window++ {
+				links := BuildPageLinks(current, total, window)
+				name := fmt.Sprintf("current=%d total=%d window=%d: %s", current, total, window, renderLinks(links))
+
+				if links[0].Number != 1 || links[len(links)-1].Number != total {
+					t.Fatalf("%s: first/last must be 1 and %d", name, total)
+				}
+				currents, prev := 0, 0
+				for i, l := range links {
+					if l.IsEllipsis {
+						if l.Number != 0 || l.URL != "" || l.IsCurrent {
+							t.Fatalf("%s: ellipsis must carry no page data", name)
+						}
+						if next := links[i+1].Number; next-prev <= 2 {
+							t.Fatalf("%s: ellipsis between %d and %d hides at most one page", name, prev, next)
+						}
+						continue
+					}
+					if l.Number <= prev {
+						t.Fatalf("%s: pages not strictly increasing", name)
+					}
+					if i > 0 && !links[i-1].IsEllipsis && l.Number != prev+1 {
+						t.Fatalf("%s: gap between %d and %d without ellipsis", name, prev, l.Number)
+					}
+					if l.IsCurrent {
+						currents++
+					}
+					prev = l.Number
+				}
+				if currents != 1 {
+					t.Fatalf("%s: %d current entries, want 1", name, currents)
+				}
+			}
+		}
+	}
+}
+
+func TestBuildPager_PreservesQuery(t *testing.T) {
+	query := url.Values{
+		"name__like": {"ali"},
+		"sort":       {"name:asc"},
+		"page_size":  {"10"},
+		"page":       {"6"},
+	}
+	pager := BuildPager("/users", query, 6, 42)
+
+	if got := renderLinks(pager.Links); got != "1 … 4 5 [6] 7 8 … 42" {
+		t.Fatalf("Links = %q", got)
+	}
+	want := "/users?name__like=ali&page=7&page_size=10&sort=name%3Aasc"
+	if pager.NextURL != want {
+		t.Errorf("NextURL = %q, want %q", pager.NextURL, want)
+	}
+	if want := "/users?name__like=ali&page=5&page_size=10&sort=name%3Aasc"; pager.PrevURL != want {
+		t.Errorf("PrevURL = %q, want %q", pager.PrevURL, want)
+	}
+	for _, l := range pager.Links {
+		if l.IsEllipsis {
+			continue
+		}
+		if want := fmt.Sprintf("/users?name__like=ali&page=%d&page_size=10&sort=name%%3Aasc", l.Number); l.URL != want {
+			t.Errorf("page %d URL = %q, want %q", l.Number, l.URL, want)
+		}
+	}
+	if query.Get("page") != "6" {
+		t.Errorf("BuildPager modified the caller's query: page = %q", query.Get("page"))
+	}
+}
+
+func TestBuildPager_Edges(t *testing.T) {
+	if p := BuildPager("/users", nil, 1, 1); p.Links != nil || p.PrevURL != "" || p.NextURL != "" {
+		t.Errorf("single page: got %+v, want zero Pager", p)
+	}
+	if p := BuildPager("/users", nil, 1, 0); p.Links != nil {
+		t.Errorf("no pages: got %+v, want zero Pager", p)
+	}
+
+	first := BuildPager("/users", nil, 0, 3)
+	if first.PrevURL != "" || first.NextURL != "/users?page=2" {
+		t.Errorf("first page: PrevURL=%q NextURL=%q", first.PrevURL, first.NextURL)
+	}
+	last := BuildPager("/users", nil, 9, 3)
+	if last.PrevURL != "/users?page=2" || last.NextURL != "" {
+		t.Errorf("clamped last page: PrevURL=%q NextURL=%q", last.PrevURL, last.NextURL)
+	}
+}
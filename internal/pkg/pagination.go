@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"context"
+	"maps"
+	"net/url"
 	"regexp"
 	"slices"
 	"strconv"
@@ -21,10 +23,13 @@ const (
 )
 
 // reservedParams lists query parameter names used for pagination/sorting, not for filtering.
+// "cursor" is reserved for cursor-style pagination so it can be rejected when
+// mixed with page/page_size instead of being treated as a filter.
 var reservedParams = map[string]bool{
 	"page":      true,
 	"page_size": true,
 	"sort":      true,
+	"cursor":    true,
 }
 
 // validFieldName matches only alphanumeric characters and underscores.
@@ -35,14 +40,59 @@ var validFieldName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 // so no double-escaping can occur regardless of pair order.
 var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
+// ParamErrors maps query, path or header parameter names to messages
+// describing why they were rejected. ValidationError renders it as a 400 ValidationErrorResponse with
+// one entry per parameter.
+type ParamErrors map[string]string
+
+// Error implements error, listing parameters in name order.
+func (e ParamErrors) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + ": " + e[k]
+	}
+	return "invalid query parameters: " + strings.Join(parts, "; ")
+}
+
 // ParsePageRequest extracts pagination, sorting, and filtering parameters from query params.
-func ParsePageRequest(c *gin.Context) domain.PageRequest {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", strconv.Itoa(defaultPage)))
+// Out-of-range or malformed page/page_size values fall back to defaults, but
+// ambiguous requests are rejected with ParamErrors: a reserved parameter
+// repeated with different values, cursor combined with page/page_size, or a
+// sort field listed more than once.
+func ParsePageRequest(c *gin.Context) (domain.PageRequest, error) {
+	return parsePageQuery(c.Request.URL.Query())
+}
+
+// parsePageQuery implements ParsePageRequest on the raw query values.
+func parsePageQuery(query url.Values) (domain.PageRequest, error) {
+	errs := ParamErrors{}
+	for key := range reservedParams {
+		values := query[key]
+		for _, v := range values {
+			if v != values[0] {
+				errs[key] = "Must not be given multiple different values"
+				break
+			}
+		}
+	}
+	_, hasCursor := query["cursor"]
+	_, hasPage := query["page"]
+	_, hasPageSize := query["page_size"]
+	if hasCursor && (hasPage || hasPageSize) {
+		errs["cursor"] = "Cannot be combined with page or page_size"
+	}
+
+	page, _ := strconv.Atoi(queryValue(query, "page", strconv.Itoa(defaultPage)))
 	if page < 1 {
 		page = defaultPage
 	}
 
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	pageSize, _ := strconv.Atoi(queryValue(query, "page_size", strconv.Itoa(defaultPageSize)))
 	if pageSize < 1 {
 		pageSize = defaultPageSize
 	}
@@ -50,10 +100,19 @@ func ParsePageRequest(c *gin.Context) domain.PageRequest {
 		pageSize = maxPageSize
 	}
 
-	sort := c.DefaultQuery("sort", defaultSort)
+	sort := queryValue(query, "sort", defaultSort)
+	if _, ok := errs["sort"]; !ok {
+		if field := duplicateSortField(sort); field != "" {
+			errs["sort"] = "Must not list field \"" + field + "\" more than once"
+		}
+	}
+
+	if len(errs) > 0 {
+		return domain.PageRequest{}, errs
+	}
 
 	filter := make(map[string]string)
-	for key, values := range c.Request.URL.Query() {
+	for key, values := range query {
 		if reservedParams[key] {
 			continue
 		}
@@ -67,7 +126,35 @@ func ParsePageRequest(c *gin.Context) domain.PageRequest {
 		PageSize: pageSize,
 		Sort:     sort,
 		Filter:   filter,
+	}, nil
+}
+
+// queryValue returns the first value for key, or def when key is absent.
+// Like gin's DefaultQuery, a present but empty key yields "".
+func queryValue(query url.Values, key, def string) string {
+	if values, ok := query[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+// duplicateSortField returns the first field that appears more than once in a
+// comma-separated sort expression ("name:asc,name:desc"), compared
+// case-insensitively, or "" when every field is unique.
+func duplicateSortField(sort string) string {
+	seen := make(map[string]bool)
+	for term := range strings.SplitSeq(sort, ",") {
+		field, _, _ := strings.Cut(term, ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if seen[field] {
+			return field
+		}
+		seen[field] = true
 	}
+	return ""
 }
 
 // Paginate returns a GORM scope that applies LIMIT and OFFSET based on the page request.
@@ -82,56 +169,71 @@ func Paginate(req domain.PageRequest) func(db *gorm.DB) *gorm.DB {
 // Only field names present in the allowed list are accepted; others are silently ignored.
 // Field names are validated against a strict pattern to prevent SQL injection.
 func Sort(req domain.PageRequest, allowed []string) func(db *gorm.DB) *gorm.DB {
+	return ListOptions{SortFields: allowed}.sort(req.Sort)
+}
+
+// sort is Sort with names resolved through opts.
+func (opts ListOptions) sort(sort string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		parts := strings.SplitN(req.Sort, ":", 2)
-		if len(parts) != 2 {
-			return db
+		if order, ok := opts.sortClause(sort); ok {
+			return db.Order(order)
 		}
+		return db
+	}
+}
 
-		field := strings.TrimSpace(parts[0])
-		direction := strings.TrimSpace(strings.ToLower(parts[1]))
-
-		if direction != "asc" && direction != "desc" {
-			return db
-		}
+// sortClause converts a "field:direction" expression into an ORDER BY clause,
+// reporting false when the expression is malformed or the field is not a
+// sort field.
+func (opts ListOptions) sortClause(sort string) (string, bool) {
+	parts := strings.SplitN(sort, ":", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
 
-		if !validFieldName.MatchString(field) {
-			return db
-		}
+	field := strings.TrimSpace(parts[0])
+	direction := strings.TrimSpace(strings.ToLower(parts[1]))
 
-		if !isAllowed(field, allowed) {
-			return db
-		}
+	if direction != "asc" && direction != "desc" {
+		return "", false
+	}
 
-		return db.Order(field + " " + direction)
+	column, ok := opts.column(field, opts.SortFields)
+	if !ok {
+		return "", false
 	}
+	return column + " " + direction, true
 }
 
 // Filter returns a GORM scope that applies WHERE conditions based on the page request filters.
 // Only filter keys present in the allowed list are applied; others are silently ignored.
 // Keys ending with "__like" produce a LIKE '%value%' condition; others use exact match.
+// Conditions are added in key order and values are always bound as arguments, so
+// the same set of filter keys yields the same SQL text, which lets
+// database.prepare_stmt reuse one prepared statement.
 func Filter(req domain.PageRequest, allowed []string) func(db *gorm.DB) *gorm.DB {
+	return ListOptions{FilterFields: allowed}.filter(req.Filter)
+}
+
+// filter is Filter with names resolved through opts.
+func (opts ListOptions) filter(filter map[string]string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		for key, value := range req.Filter {
+		for _, key := range slices.Sorted(maps.Keys(filter)) {
+			value := filter[key]
 			// Check for __like suffix.
-			if before, ok := strings.CutSuffix(key, "__like"); ok {
-				field := before
-				if !validFieldName.MatchString(field) {
-					continue
-				}
-				if !isAllowed(field, allowed) {
+			if field, ok := strings.CutSuffix(key, "__like"); ok {
+				column, ok := opts.column(field, opts.FilterFields)
+				if !ok {
 					continue
 				}
 				escaped := likeEscaper.Replace(value)
-				db = db.Where(field+" LIKE ? ESCAPE '\\'", "%"+escaped+"%")
+				db = db.Where(column+" LIKE ? ESCAPE '\\'", "%"+escaped+"%")
 			} else {
-				if !validFieldName.MatchString(key) {
+				column, ok := opts.column(key, opts.FilterFields)
+				if !ok {
 					continue
 				}
-				if !isAllowed(key, allowed) {
-					continue
-				}
-				db = db.Where(key+" = ?", value)
+				db = db.Where(column+" = ?", value)
 			}
 		}
 		return db
@@ -145,17 +247,66 @@ func isAllowed(field string, allowed []string) bool {
 
 // ListOptions configures which fields are allowed for sorting and filtering
 // in PaginateGORM.
+//
+// DefaultSort ("field:direction", field listed in SortFields) is applied when
+// the requested sort is not usable. Models with UUID primary keys set it to
+// "created_at:desc" and leave "id" out of SortFields, since the request-level
+// default "id:desc" gives no meaningful order for random IDs.
+//
+// Without FieldMap, SortFields and FilterFields name columns, which the
+// sort and filter parameters use as they are. With it, they list the public
+// names the parameters use instead, and FieldMap translates each to the
+// column queried, e.g. both "created_at" and "createdAt" to created_at; a
+// listed name FieldMap lacks is rejected like an unlisted one. FieldMap
+// values must be plain column names unless Expressions lists them.
 type ListOptions struct {
 	SortFields   []string
 	FilterFields []string
+	DefaultSort  string
+	FieldMap     map[string]string
+	// Expressions lists FieldMap values that are SQL expressions rather
+	// than columns, e.g. "LENGTH(name)". They are put in queries as they
+	// are, so they must be constants in code, never taken from a request.
+	Expressions []string
+}
+
+// column resolves the public field name to the column or expression it is
+// queried as, reporting false when the name is not in allowed, is not a
+// valid field name, or (with FieldMap) is unmapped or maps to something
+// that is neither a column name nor one of Expressions.
+func (opts ListOptions) column(field string, allowed []string) (string, bool) {
+	if !validFieldName.MatchString(field) || !isAllowed(field, allowed) {
+		return "", false
+	}
+	if opts.FieldMap == nil {
+		return field, true
+	}
+	column, ok := opts.FieldMap[field]
+	if !ok {
+		return "", false
+	}
+	if !validFieldName.MatchString(column) && !slices.Contains(opts.Expressions, column) {
+		return "", false
+	}
+	return column, true
+}
+
+// sortScope returns the ORDER BY scope for req, falling back to
+// opts.DefaultSort.
+func (opts ListOptions) sortScope(req domain.PageRequest) func(db *gorm.DB) *gorm.DB {
+	if _, ok := opts.sortClause(req.Sort); !ok && opts.DefaultSort != "" {
+		return opts.sort(opts.DefaultSort)
+	}
+	return opts.sort(req.Sort)
 }
 
 // PaginateGORM executes a paginated GORM query using the simp-lee/pagination library.
-// It applies filtering, sorting, and offset/limit via the existing scope helpers,
-// and returns a fully populated Pagination result.
-func PaginateGORM[T any](ctx context.Context, db *gorm.DB, req domain.PageRequest, opts ListOptions) (*pagination.Pagination[T], error) {
-	// Apply filter scope to the base query.
-	filtered := db.Scopes(Filter(req, opts.FilterFields))
+// It applies the tenant of ctx (TenantScope), filtering, sorting, and
+// offset/limit via the existing scope helpers, and returns a fully populated
+// PageResult.
+func PaginateGORM[T any](ctx context.Context, db *gorm.DB, req domain.PageRequest, opts ListOptions) (*domain.PageResult[T], error) {
+	// Apply tenant and filter scopes to the base query.
+	filtered := db.Scopes(TenantScope(ctx), opts.filter(req.Filter))
 
 	paginator := pagination.NewPaginator[T](
 		pagination.WithItemsPerPage[T](req.PageSize),
@@ -167,7 +318,7 @@ func PaginateGORM[T any](ctx context.Context, db *gorm.DB, req domain.PageReques
 		pagination.WithSliceCallback[T](func(ctx context.Context, offset, limit int) ([]T, error) {
 			var items []T
 			err := filtered.Session(&gorm.Session{}).WithContext(ctx).
-				Scopes(Sort(req, opts.SortFields)).
+				Scopes(opts.sortScope(req)).
 				Offset(offset).Limit(limit).
 				Find(&items).Error
 			return items, err
@@ -176,3 +327,26 @@ func PaginateGORM[T any](ctx context.Context, db *gorm.DB, req domain.PageReques
 
 	return paginator.Paginate(ctx, req.Page)
 }
+
+// PageResultFrom builds the PageResult for items, one page already fetched
+// with the Paginate scope, out of total matching rows. It is the shim for
+// repositories that run their own count and slice queries instead of
+// PaginateGORM, so both produce the same JSON shape. As with PaginateGORM,
+// a page past the last one reports the last page number.
+func PageResultFrom[T any](items []T, total int64, req domain.PageRequest) (*domain.PageResult[T], error) {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = defaultPage
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	paginator := pagination.NewPaginator[T](
+		pagination.WithItemsPerPage[T](pageSize),
+		pagination.WithKnownTotal[T](total),
+		pagination.WithSliceCallback[T](func(context.Context, int, int) ([]T, error) {
+			return items, nil
+		}),
+	)
+	return paginator.Paginate(context.Background(), page)
+}
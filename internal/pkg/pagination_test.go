@@ -2,12 +2,15 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -31,7 +34,10 @@ func newTestContext(queryParams url.Values) *gin.Context {
 
 func TestParsePageRequest_Defaults(t *testing.T) {
 	c := newTestContext(url.Values{})
-	pr := ParsePageRequest(c)
+	pr, err := ParsePageRequest(c)
+	if err != nil {
+		t.Fatalf("ParsePageRequest() error = %v", err)
+	}
 
 	if pr.Page != 1 {
 		t.Errorf("expected Page=1, got %d", pr.Page)
@@ -55,7 +61,10 @@ func TestParsePageRequest_CustomValues(t *testing.T) {
 		"status":     {"active"},
 		"name__like": {"john"},
 	})
-	pr := ParsePageRequest(c)
+	pr, err := ParsePageRequest(c)
+	if err != nil {
+		t.Fatalf("ParsePageRequest() error = %v", err)
+	}
 
 	if pr.Page != 3 {
 		t.Errorf("expected Page=3, got %d", pr.Page)
@@ -77,7 +86,10 @@ func TestParsePageRequest_CustomValues(t *testing.T) {
 func TestParsePageRequest_Clamping(t *testing.T) {
 	t.Run("page below minimum", func(t *testing.T) {
 		c := newTestContext(url.Values{"page": {"0"}})
-		pr := ParsePageRequest(c)
+		pr, err := ParsePageRequest(c)
+		if err != nil {
+			t.Fatalf("ParsePageRequest() error = %v", err)
+		}
 		if pr.Page != 1 {
 			t.Errorf("expected Page=1, got %d", pr.Page)
 		}
@@ -85,7 +97,10 @@ func TestParsePageRequest_Clamping(t *testing.T) {
 
 	t.Run("negative page", func(t *testing.T) {
 		c := newTestContext(url.Values{"page": {"-5"}})
-		pr := ParsePageRequest(c)
+		pr, err := ParsePageRequest(c)
+		if err != nil {
+			t.Fatalf("ParsePageRequest() error = %v", err)
+		}
 		if pr.Page != 1 {
 			t.Errorf("expected Page=1, got %d", pr.Page)
 		}
@@ -93,7 +108,10 @@ func TestParsePageRequest_Clamping(t *testing.T) {
 
 	t.Run("page_size below minimum", func(t *testing.T) {
 		c := newTestContext(url.Values{"page_size": {"0"}})
-		pr := ParsePageRequest(c)
+		pr, err := ParsePageRequest(c)
+		if err != nil {
+			t.Fatalf("ParsePageRequest() error = %v", err)
+		}
 		if pr.PageSize != 20 {
 			t.Errorf("expected PageSize=20, got %d", pr.PageSize)
 		}
@@ -101,7 +119,10 @@ func TestParsePageRequest_Clamping(t *testing.T) {
 
 	t.Run("page_size above maximum", func(t *testing.T) {
 		c := newTestContext(url.Values{"page_size": {"200"}})
-		pr := ParsePageRequest(c)
+		pr, err := ParsePageRequest(c)
+		if err != nil {
+			t.Fatalf("ParsePageRequest() error = %v", err)
+		}
 		if pr.PageSize != 100 {
 			t.Errorf("expected PageSize=100, got %d", pr.PageSize)
 		}
@@ -109,7 +130,10 @@ func TestParsePageRequest_Clamping(t *testing.T) {
 
 	t.Run("invalid page_size defaults", func(t *testing.T) {
 		c := newTestContext(url.Values{"page_size": {"abc"}})
-		pr := ParsePageRequest(c)
+		pr, err := ParsePageRequest(c)
+		if err != nil {
+			t.Fatalf("ParsePageRequest() error = %v", err)
+		}
 		if pr.PageSize != 20 {
 			t.Errorf("expected PageSize=20, got %d", pr.PageSize)
 		}
@@ -121,7 +145,10 @@ func TestParsePageRequest_EmptyFilterValuesIgnored(t *testing.T) {
 		"status": {""},
 		"name":   {"john"},
 	})
-	pr := ParsePageRequest(c)
+	pr, err := ParsePageRequest(c)
+	if err != nil {
+		t.Fatalf("ParsePageRequest() error = %v", err)
+	}
 
 	if _, ok := pr.Filter["status"]; ok {
 		t.Error("expected empty filter value to be excluded")
@@ -198,12 +225,117 @@ func newTestDB(t *testing.T) *gorm.DB {
 
 func TestParsePageRequest_NegativePageSize(t *testing.T) {
 	c := newTestContext(url.Values{"page_size": {"-5"}})
-	pr := ParsePageRequest(c)
+	pr, err := ParsePageRequest(c)
+	if err != nil {
+		t.Fatalf("ParsePageRequest() error = %v", err)
+	}
 	if pr.PageSize != 20 {
 		t.Errorf("expected PageSize=20 for negative page_size, got %d", pr.PageSize)
 	}
 }
 
+// --------------- ParsePageRequest: conflicting parameters ---------------
+
+func TestParsePageRequest_Conflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// wantErrs lists the parameters expected in ParamErrors; nil means the
+		// request must be accepted.
+		wantErrs []string
+	}{
+		// Lenient cases that were accepted before must stay accepted.
+		{name: "empty", query: ""},
+		{name: "page only", query: "page=2"},
+		{name: "page and page_size", query: "page=2&page_size=10"},
+		{name: "repeated identical page", query: "page=2&page=2"},
+		{name: "repeated identical page_size", query: "page_size=10&page_size=10"},
+		{name: "repeated identical sort", query: "sort=name:asc&sort=name:asc"},
+		{name: "repeated filter", query: "status=a&status=b"},
+		{name: "empty page value", query: "page="},
+		{name: "invalid page value", query: "page=abc"},
+		{name: "single sort field", query: "sort=name:asc"},
+		{name: "distinct sort fields", query: "sort=name:asc,id:desc"},
+		{name: "empty sort terms", query: "sort=name:asc,,id:desc"},
+		{name: "cursor alone", query: "cursor=abc"},
+		{name: "cursor with sort", query: "cursor=abc&sort=id:asc"},
+		{name: "cursor with filter", query: "cursor=abc&status=active"},
+
+		// Repeated reserved keys with different values.
+		{name: "conflicting page", query: "page=1&page=2", wantErrs: []string{"page"}},
+		{name: "conflicting page_size", query: "page_size=10&page_size=20", wantErrs: []string{"page_size"}},
+		{name: "conflicting sort", query: "sort=name:asc&sort=id:desc", wantErrs: []string{"sort"}},
+		{name: "conflicting cursor", query: "cursor=a&cursor=b", wantErrs: []string{"cursor"}},
+		{name: "conflict after identical values", query: "page=1&page=1&page=3", wantErrs: []string{"page"}},
+		{name: "conflicting page and page_size", query: "page=1&page=2&page_size=5&page_size=6", wantErrs: []string{"page", "page_size"}},
+
+		// Offset and cursor styles mixed.
+		{name: "cursor with page", query: "cursor=abc&page=2", wantErrs: []string{"cursor"}},
+		{name: "cursor with page_size", query: "cursor=abc&page_size=10", wantErrs: []string{"cursor"}},
+		{name: "cursor with page and page_size", query: "cursor=abc&page=2&page_size=10", wantErrs: []string{"cursor"}},
+		{name: "cursor with empty page", query: "cursor=abc&page=", wantErrs: []string{"cursor"}},
+		{name: "cursor with page and conflicting page", query: "cursor=abc&page=1&page=2", wantErrs: []string{"cursor", "page"}},
+
+		// Duplicate sort fields.
+		{name: "duplicate sort field", query: "sort=name:asc,name:desc", wantErrs: []string{"sort"}},
+		{name: "duplicate sort field same direction", query: "sort=name:asc,id:desc,name:asc", wantErrs: []string{"sort"}},
+		{name: "duplicate sort field case-insensitive", query: "sort=Name:asc,name:desc", wantErrs: []string{"sort"}},
+		{name: "duplicate sort field with spaces", query: "sort=name:asc,%20name%20:desc", wantErrs: []string{"sort"}},
+		{name: "duplicate sort field without direction", query: "sort=name,name", wantErrs: []string{"sort"}},
+		{name: "duplicate sort and conflicting page", query: "sort=id:asc,id:desc&page=1&page=2", wantErrs: []string{"page", "sort"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			_, err := ParsePageRequest(c)
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Fatalf("ParsePageRequest() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var pe ParamErrors
+			if !errors.As(err, &pe) {
+				t.Fatalf("ParsePageRequest() error = %v, want ParamErrors", err)
+			}
+			if len(pe) != len(tt.wantErrs) {
+				t.Errorf("ParamErrors = %v, want keys %v", pe, tt.wantErrs)
+			}
+			for _, key := range tt.wantErrs {
+				if pe[key] == "" {
+					t.Errorf("ParamErrors missing %q: %v", key, pe)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePageRequest_CursorIsNotAFilter(t *testing.T) {
+	c := newTestContext(url.Values{"cursor": {"abc"}, "status": {"active"}})
+	pr, err := ParsePageRequest(c)
+	if err != nil {
+		t.Fatalf("ParsePageRequest() error = %v", err)
+	}
+	if _, ok := pr.Filter["cursor"]; ok {
+		t.Errorf("cursor should be reserved, got Filter=%v", pr.Filter)
+	}
+	if pr.Filter["status"] != "active" {
+		t.Errorf("expected Filter[status]=active, got %v", pr.Filter)
+	}
+}
+
+func TestParamErrors_Error(t *testing.T) {
+	err := ParamErrors{"sort": "b", "page": "a"}
+	want := "invalid query parameters: page: a; sort: b"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
 // --------------- Sort scope ---------------
 
 func TestSort(t *testing.T) {
@@ -331,6 +463,41 @@ func TestPaginate(t *testing.T) {
 	}
 }
 
+// --------------- PageResultFrom ---------------
+
+func TestPageResultFrom(t *testing.T) {
+	items := []paginationTestItem{{ID: 11}, {ID: 12}}
+	result, err := PageResultFrom(items, 12, domain.PageRequest{Page: 2, PageSize: 10})
+	if err != nil {
+		t.Fatalf("PageResultFrom: %v", err)
+	}
+	if len(result.Items) != 2 || result.TotalItems != 12 || result.CurrentPage != 2 || result.TotalPages != 2 || result.ItemsPerPage != 10 {
+		t.Errorf("result = %+v, want page 2 of 2 with 12 items", result)
+	}
+	if result.NextPage != nil || result.PreviousPage == nil || *result.PreviousPage != 1 {
+		t.Errorf("previous/next = %v/%v, want 1/nil", result.PreviousPage, result.NextPage)
+	}
+
+	// The JSON shape is the one PaginateGORM results are served in.
+	body, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, key := range []string{`"items":`, `"current_page":2`, `"items_per_page":10`, `"total_items":12`, `"total_pages":2`} {
+		if !strings.Contains(string(body), key) {
+			t.Errorf("JSON %s lacks %s", body, key)
+		}
+	}
+
+	empty, err := PageResultFrom[paginationTestItem](nil, 0, domain.PageRequest{})
+	if err != nil {
+		t.Fatalf("PageResultFrom(empty): %v", err)
+	}
+	if empty.Items == nil || empty.CurrentPage != 1 || empty.ItemsPerPage != defaultPageSize {
+		t.Errorf("empty result = %+v, want page 1 with non-nil items and the default size", empty)
+	}
+}
+
 // --------------- PaginateGORM ---------------
 
 // paginationTestItem is a minimal model for PaginateGORM tests.
@@ -341,7 +508,12 @@ type paginationTestItem struct {
 
 func newSQLiteTestDB(t *testing.T) *gorm.DB {
 	t.Helper()
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	return openSQLiteTestDB(t, &gorm.Config{})
+}
+
+func openSQLiteTestDB(t testing.TB, cfg *gorm.Config) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), cfg)
 	if err != nil {
 		t.Fatalf("open sqlite: %v", err)
 	}
@@ -351,7 +523,7 @@ func newSQLiteTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
-func seedItems(t *testing.T, db *gorm.DB, n int) {
+func seedItems(t testing.TB, db *gorm.DB, n int) {
 	t.Helper()
 	for i := 1; i <= n; i++ {
 		if err := db.Create(&paginationTestItem{Name: "item_" + strconv.Itoa(i)}).Error; err != nil {
@@ -507,6 +679,118 @@ func TestPaginateGORM_SortDesc(t *testing.T) {
 	}
 }
 
+func TestPaginateGORM_DefaultSortFallback(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	for _, name := range []string{"b", "c", "a"} {
+		db.Create(&paginationTestItem{Name: name})
+	}
+	ctx := context.Background()
+	opts := ListOptions{SortFields: []string{"name"}, DefaultSort: "name:asc"}
+
+	tests := []struct {
+		sort      string
+		wantFirst string
+	}{
+		{"id:desc", "a"},   // "id" not sortable: falls back to DefaultSort
+		{"bogus", "a"},     // malformed: falls back
+		{"name:desc", "c"}, // usable sort wins over DefaultSort
+	}
+	for _, tt := range tests {
+		req := domain.PageRequest{Page: 1, PageSize: 10, Sort: tt.sort}
+		result, err := PaginateGORM[paginationTestItem](ctx, db.Model(&paginationTestItem{}), req, opts)
+		if err != nil {
+			t.Fatalf("PaginateGORM(%q): %v", tt.sort, err)
+		}
+		if got := result.Items[0].Name; got != tt.wantFirst {
+			t.Errorf("sort %q: first item = %q, want %q", tt.sort, got, tt.wantFirst)
+		}
+	}
+}
+
+func TestListOptions_Column(t *testing.T) {
+	opts := ListOptions{
+		SortFields: []string{"name", "displayName", "nameLength", "unsafe", "unmapped"},
+		FieldMap: map[string]string{
+			"name":        "name",
+			"displayName": "name",
+			"nameLength":  "LENGTH(name)",
+			"unsafe":      "name; DROP TABLE users",
+		},
+		Expressions: []string{"LENGTH(name)"},
+	}
+	tests := []struct {
+		field  string
+		want   string
+		wantOK bool
+	}{
+		{"name", "name", true},
+		{"displayName", "name", true},        // alias
+		{"nameLength", "LENGTH(name)", true}, // allowlisted expression
+		{"unsafe", "", false},                // neither a column nor allowlisted
+		{"unmapped", "", false},              // sortable but missing from FieldMap
+		{"id", "", false},                    // mapped nowhere and not sortable
+	}
+	for _, tt := range tests {
+		got, ok := opts.column(tt.field, opts.SortFields)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("column(%q) = %q, %v; want %q, %v", tt.field, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// Without FieldMap, allowed names are columns.
+	if got, ok := (ListOptions{}).column("name", []string{"name"}); got != "name" || !ok {
+		t.Errorf("column without FieldMap = %q, %v; want name, true", got, ok)
+	}
+}
+
+func TestPaginateGORM_FieldMap(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	for _, name := range []string{"Bo", "Alexandra", "Cy", "Dee"} {
+		db.Create(&paginationTestItem{Name: name})
+	}
+	ctx := context.Background()
+	opts := ListOptions{
+		SortFields:   []string{"id", "displayName", "nameLength"},
+		FilterFields: []string{"displayName"},
+		FieldMap: map[string]string{
+			"id":          "id",
+			"displayName": "name",
+			"nameLength":  "LENGTH(name)",
+		},
+		Expressions: []string{"LENGTH(name)"},
+	}
+	names := func(req domain.PageRequest) string {
+		t.Helper()
+		req.Page, req.PageSize = 1, 10
+		result, err := PaginateGORM[paginationTestItem](ctx, db.Model(&paginationTestItem{}), req, opts)
+		if err != nil {
+			t.Fatalf("PaginateGORM(%+v): %v", req, err)
+		}
+		var got []string
+		for _, item := range result.Items {
+			got = append(got, item.Name)
+		}
+		return strings.Join(got, ",")
+	}
+
+	if got := names(domain.PageRequest{Sort: "displayName:asc"}); got != "Alexandra,Bo,Cy,Dee" {
+		t.Errorf("sort by alias = %s", got)
+	}
+	if got := names(domain.PageRequest{Sort: "nameLength:desc"}); !strings.HasPrefix(got, "Alexandra,Dee,") {
+		t.Errorf("sort by expression descending = %s, want the longest names first", got)
+	}
+	if got := names(domain.PageRequest{Sort: "nameLength:asc"}); !strings.HasSuffix(got, ",Dee,Alexandra") {
+		t.Errorf("sort by expression ascending = %s, want the longest names last", got)
+	}
+	if got := names(domain.PageRequest{Sort: "name:asc", Filter: map[string]string{"displayName__like": "e"}}); got != "Alexandra,Dee" {
+		t.Errorf("unmapped sort with aliased filter = %s, want insertion order of the matches", got)
+	}
+	// The column name itself is not a public name.
+	if got := names(domain.PageRequest{Sort: "id:asc", Filter: map[string]string{"name": "Bo"}}); got != "Bo,Alexandra,Cy,Dee" {
+		t.Errorf("filter by unmapped column = %s, want it ignored", got)
+	}
+}
+
 func TestPaginateGORM_LikeFilter_SpecialChars(t *testing.T) {
 	db := newSQLiteTestDB(t)
 
@@ -582,6 +866,99 @@ func TestPaginateGORM_LikeFilter_SpecialChars(t *testing.T) {
 	})
 }
 
+// preparedStmts returns the SQL strings db holds prepared statements for.
+func preparedStmts(t testing.TB, db *gorm.DB) []string {
+	t.Helper()
+	pool, ok := db.ConnPool.(*gorm.PreparedStmtDB)
+	if !ok {
+		t.Fatalf("ConnPool = %T, want *gorm.PreparedStmtDB", db.ConnPool)
+	}
+	return pool.Stmts.Keys()
+}
+
+func TestPaginateGORM_PrepareStmt_MatchesUnprepared(t *testing.T) {
+	plain := newSQLiteTestDB(t)
+	prepared := openSQLiteTestDB(t, &gorm.Config{PrepareStmt: true})
+	names := []string{"100% Alice", "Bob_Smith", `Charlie\Backslash`, "1001 Bob", "BobXSmith", "item_1", "item_2", "item_3"}
+	for _, db := range []*gorm.DB{plain, prepared} {
+		for _, name := range names {
+			if err := db.Create(&paginationTestItem{Name: name}).Error; err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	opts := ListOptions{SortFields: []string{"id", "name"}, FilterFields: []string{"id", "name"}}
+	reqs := []domain.PageRequest{
+		{Page: 1, PageSize: 3, Sort: "id:asc"},
+		{Page: 2, PageSize: 3, Sort: "name:desc"},
+		{Page: 1, PageSize: 10, Sort: "id:asc", Filter: map[string]string{"name__like": "100%"}},
+		{Page: 1, PageSize: 10, Sort: "id:asc", Filter: map[string]string{"name__like": "Bob_Smith"}},
+		{Page: 1, PageSize: 10, Sort: "id:asc", Filter: map[string]string{"name__like": `Charlie\`}},
+		{Page: 1, PageSize: 10, Sort: "id:asc", Filter: map[string]string{"name__like": "item", "id": "7"}},
+		{Page: 1, PageSize: 10, Sort: "id:asc", Filter: map[string]string{"name": "BobXSmith"}},
+	}
+	for _, req := range reqs {
+		want, err := PaginateGORM[paginationTestItem](ctx, plain.Model(&paginationTestItem{}), req, opts)
+		if err != nil {
+			t.Fatalf("PaginateGORM(plain, %+v): %v", req, err)
+		}
+		got, err := PaginateGORM[paginationTestItem](ctx, prepared.Model(&paginationTestItem{}), req, opts)
+		if err != nil {
+			t.Fatalf("PaginateGORM(prepared, %+v): %v", req, err)
+		}
+		if got.TotalItems != want.TotalItems || !reflect.DeepEqual(got.Items, want.Items) {
+			t.Errorf("%+v: prepared = %d %v, plain = %d %v", req, got.TotalItems, got.Items, want.TotalItems, want.Items)
+		}
+	}
+}
+
+func TestPaginateGORM_PrepareStmt_ReusesStatements(t *testing.T) {
+	db := openSQLiteTestDB(t, &gorm.Config{PrepareStmt: true})
+	seedItems(t, db, 5)
+	ctx := context.Background()
+	opts := ListOptions{SortFields: []string{"id"}, FilterFields: []string{"id", "name"}}
+
+	list := func(filter map[string]string) {
+		t.Helper()
+		req := domain.PageRequest{Page: 1, PageSize: 10, Sort: "id:asc", Filter: filter}
+		if _, err := PaginateGORM[paginationTestItem](ctx, db.Model(&paginationTestItem{}), req, opts); err != nil {
+			t.Fatalf("PaginateGORM: %v", err)
+		}
+	}
+	list(map[string]string{"name__like": "item", "id": "1"})
+	before := len(preparedStmts(t, db))
+
+	// Different values, and map iteration in any order, must map onto the
+	// same COUNT and SELECT statements.
+	for i := range 20 {
+		list(map[string]string{"id": strconv.Itoa(i), "name__like": "it%em_" + strconv.Itoa(i)})
+	}
+	if after := preparedStmts(t, db); len(after) != before {
+		t.Errorf("prepared statements grew from %d to %d: %v", before, len(after), after)
+	}
+}
+
+// BenchmarkPaginateGORM_PrepareStmt compares a filtered list query with and
+// without cached prepared statements on SQLite.
+func BenchmarkPaginateGORM_PrepareStmt(b *testing.B) {
+	for _, prepare := range []bool{false, true} {
+		b.Run("prepare_stmt="+strconv.FormatBool(prepare), func(b *testing.B) {
+			db := openSQLiteTestDB(b, &gorm.Config{PrepareStmt: prepare})
+			seedItems(b, db, 200)
+			ctx := context.Background()
+			opts := ListOptions{SortFields: []string{"id"}, FilterFields: []string{"name"}}
+			req := domain.PageRequest{Page: 2, PageSize: 20, Sort: "id:desc", Filter: map[string]string{"name__like": "item_1"}}
+			for b.Loop() {
+				if _, err := PaginateGORM[paginationTestItem](ctx, db.Model(&paginationTestItem{}), req, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func TestPaginateGORM_CountError(t *testing.T) {
 	db := newSQLiteTestDB(t)
 	ctx := context.Background()
@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Messages reported through ParamError for path IDs rejected by
+// ParseIDParam and ParseUUIDParam.
+const (
+	InvalidIDMessage   = "Must be a positive integer"
+	InvalidUUIDMessage = "Must be a valid UUID"
+)
+
+// missingHeaderMessage is what RequireHeaders reports for each absent header.
+const missingHeaderMessage = "This header is required"
+
+// ParamError sends a 400 ValidationErrorResponse whose errors name the path,
+// query or header parameter param, as for a rejected body field, so clients
+// can display it the same way:
+//
+//	id, err := pkg.ParseIDParam(c, "id")
+//	if err != nil {
+//		pkg.ParamError(c, "id", pkg.InvalidIDMessage)
+//		return
+//	}
+func ParamError(c *gin.Context, param, message string) {
+	validationErrorWithType(c, ParamErrors{param: message}, nil)
+}
+
+// RequireHeaders reports whether every header in names is present with a
+// non-blank value. Otherwise it sends a 400 ValidationErrorResponse listing
+// each missing header under its name as given and returns false:
+//
+//	if !pkg.RequireHeaders(c, "X-Tenant-ID") { return }
+func RequireHeaders(c *gin.Context, names ...string) bool {
+	var missing ParamErrors
+	for _, name := range names {
+		if strings.TrimSpace(c.GetHeader(name)) != "" {
+			continue
+		}
+		if missing == nil {
+			missing = ParamErrors{}
+		}
+		missing[name] = missingHeaderMessage
+	}
+	if missing == nil {
+		return true
+	}
+	validationErrorWithType(c, missing, nil)
+	return false
+}
+
+// ParseIDParam extracts the URL parameter name as a positive auto-increment
+// ID (domain.BaseModel). Zero, negative, non-numeric, and out-of-range
+// values are rejected.
+func ParseIDParam(c *gin.Context, name string) (uint, error) {
+	raw := c.Param(name)
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 || id > uint64(^uint(0)) {
+		return 0, fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	return uint(id), nil
+}
+
+// ParseUUIDParam extracts the URL parameter name as a UUID primary key
+// (domain.UUIDModel). Only the canonical 36-character hyphenated form is
+// accepted; the result is lowercased so it matches the stored value.
+func ParseUUIDParam(c *gin.Context, name string) (string, error) {
+	raw := c.Param(name)
+	if len(raw) != 36 {
+		return "", fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %s", name, raw)
+	}
+	return id.String(), nil
+}
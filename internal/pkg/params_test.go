@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newParamContext(value string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Params = gin.Params{{Key: "id", Value: value}}
+	return c
+}
+
+func TestParseIDParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   string
+		wantID  uint
+		wantErr bool
+	}{
+		{"valid", "1", 1, false},
+		{"large", "42", 42, false},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, true},
+		{"non-numeric", "abc", 0, true},
+		{"empty", "", 0, true},
+		{"max-uint", strconv.FormatUint(uint64(^uint(0)), 10), ^uint(0), false},
+		{"overflow-uint64", "18446744073709551616", 0, true},
+		// Only representable where uint is 64 bits wide.
+		{"over-uint32-boundary", "4294967296", uint(1 << 32 & uint64(^uint(0))), bits.UintSize == 32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ParseIDParam(newParamContext(tt.param), "id")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIDParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if want := "invalid id: " + tt.param; err.Error() != want {
+					t.Errorf("error = %q, want %q", err.Error(), want)
+				}
+				return
+			}
+			if id != tt.wantID {
+				t.Errorf("ParseIDParam() = %v, want %v", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestParseUUIDParam(t *testing.T) {
+	const canonical = "3f2c8a9e-5b1d-4c7a-9e21-0d6b4f8a1c35"
+	tests := []struct {
+		name    string
+		param   string
+		want    string
+		wantErr bool
+	}{
+		{"canonical", canonical, canonical, false},
+		{"uppercase is normalized", strings.ToUpper(canonical), canonical, false},
+		{"empty", "", "", true},
+		{"integer id", "42", "", true},
+		{"no hyphens", strings.ReplaceAll(canonical, "-", ""), "", true},
+		{"braced", "{" + canonical + "}", "", true},
+		{"urn", "urn:uuid:" + canonical, "", true},
+		{"bad hex", "zf2c8a9e-5b1d-4c7a-9e21-0d6b4f8a1c35", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ParseUUIDParam(newParamContext(tt.param), "id")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUUIDParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.want {
+				t.Errorf("ParseUUIDParam() = %q, want %q", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamError(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/users/abc", nil)
+
+	ParamError(c, "id", InvalidIDMessage)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	want := `{"code":400,"message":"validation error","errors":{"id":"Must be a positive integer"}}`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestRequireHeaders(t *testing.T) {
+	r := gin.New()
+	r.GET("/tenant", func(c *gin.Context) {
+		if !RequireHeaders(c, "X-Tenant-ID", "X-Request-Source") {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		wantCode int
+		wantBody string
+	}{
+		{"all present", map[string]string{"X-Tenant-ID": "acme", "X-Request-Source": "cli"}, http.StatusNoContent, ""},
+		{"one missing", map[string]string{"X-Request-Source": "cli"}, http.StatusBadRequest,
+			`{"code":400,"message":"validation error","errors":{"X-Tenant-ID":"This header is required"}}`},
+		{"blank counts as missing", map[string]string{"X-Tenant-ID": "  ", "X-Request-Source": "cli"}, http.StatusBadRequest,
+			`{"code":400,"message":"validation error","errors":{"X-Tenant-ID":"This header is required"}}`},
+		{"both missing", nil, http.StatusBadRequest,
+			`{"code":400,"message":"validation error","errors":{"X-Request-Source":"This header is required","X-Tenant-ID":"This header is required"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+			}
+		})
+	}
+}
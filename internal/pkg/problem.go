@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the RFC 7807 media type for problem details.
+const ProblemContentType = "application/problem+json"
+
+// problemJSONKey marks a request whose errors must be sent as problem+json
+// regardless of its Accept header (server.api.problem_json).
+const problemJSONKey = "pkg.problem_json"
+
+// Problem is an RFC 7807 problem details object. Type is always
+// "about:blank", so Title is the HTTP status text and Detail carries the
+// error message.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam is one entry of Problem.InvalidParams.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ForceProblemJSON makes every error response for this request use
+// problem+json, as if the client had asked for it.
+func ForceProblemJSON(c *gin.Context) {
+	c.Set(problemJSONKey, true)
+}
+
+// WantsProblemJSON reports whether error responses for c should use
+// problem+json: the request was marked by ForceProblemJSON or its Accept
+// header lists application/problem+json.
+func WantsProblemJSON(c *gin.Context) bool {
+	if c.GetBool(problemJSONKey) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(c.GetHeader("Accept")), ProblemContentType)
+}
+
+// NewProblem builds the problem details for an error response to c.
+// fieldErrors, when non-empty, becomes InvalidParams sorted by name.
+func NewProblem(c *gin.Context, status int, message string, fieldErrors map[string]string) Problem {
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   message,
+		Instance: c.Request.URL.Path,
+	}
+	for name, reason := range fieldErrors {
+		p.InvalidParams = append(p.InvalidParams, InvalidParam{Name: name, Reason: reason})
+	}
+	slices.SortFunc(p.InvalidParams, func(a, b InvalidParam) int { return strings.Compare(a.Name, b.Name) })
+	return p
+}
+
+// ErrorBody returns the JSON body for an error response to c: a Problem when
+// WantsProblemJSON, otherwise the standard Response envelope. For a Problem
+// it also sets the problem+json Content-Type, so it can back a
+// ginx.ErrorFormatter whose result is written with c.JSON.
+func ErrorBody(c *gin.Context, status int, message string) any {
+	if WantsProblemJSON(c) {
+		c.Header("Content-Type", ProblemContentType)
+		return NewProblem(c, status, message, nil)
+	}
+	return Response{Code: status, Message: message}
+}
+
+// JSONError sends an error response with the given status and message in
+// the format negotiated by ErrorBody.
+func JSONError(c *gin.Context, status int, message string) {
+	JSON(c, status, ErrorBody(c, status, message))
+}
+
+// writeValidationProblem sends a 400 problem+json with fieldErrors as
+// invalid-params.
+func writeValidationProblem(c *gin.Context, message string, fieldErrors map[string]string) {
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(http.StatusBadRequest, NewProblem(c, http.StatusBadRequest, message, fieldErrors))
+}
@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+func decodeProblem(t *testing.T, body []byte) Problem {
+	t.Helper()
+	var p Problem
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatalf("failed to unmarshal problem: %v", err)
+	}
+	return p
+}
+
+func TestError_ProblemJSONAccept(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.URL.Path = "/api/v1/users/7"
+	c.Request.Header.Set("Accept", ProblemContentType)
+
+	Error(c, domain.NewAppError(domain.CodeNotFound, "user not found", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	want := Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "user not found", Instance: "/api/v1/users/7"}
+	if got := decodeProblem(t, w.Body.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("problem = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidationError_ProblemJSONInvalidParams(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.Header.Set("Accept", "application/problem+json, application/json;q=0.5")
+
+	ValidationError(c, makeValidationErrors(t))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	p := decodeProblem(t, w.Body.Bytes())
+	if p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Detail != "validation error" {
+		t.Errorf("problem = %+v", p)
+	}
+	want := []InvalidParam{
+		{Name: "email", Reason: "This field is required"},
+		{Name: "name", Reason: "This field is required"},
+	}
+	if !reflect.DeepEqual(p.InvalidParams, want) {
+		t.Errorf("invalid-params = %+v, want %+v", p.InvalidParams, want)
+	}
+}
+
+func TestForceProblemJSON(t *testing.T) {
+	c, w := newResponseTestContext()
+	ForceProblemJSON(c)
+
+	ValidationError(c, ParamErrors{"page": "Must not be given multiple different values"})
+
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	p := decodeProblem(t, w.Body.Bytes())
+	if len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "page" {
+		t.Errorf("invalid-params = %+v, want page", p.InvalidParams)
+	}
+}
+
+func TestErrorBody_DefaultEnvelope(t *testing.T) {
+	c, w := newResponseTestContext()
+	c.Request.Header.Set("Accept", "application/json")
+
+	JSONError(c, http.StatusTooManyRequests, "rate limit exceeded")
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	want := map[string]any{"code": float64(http.StatusTooManyRequests), "message": "rate limit exceeded", "data": nil}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("body = %v, want %v", raw, want)
+	}
+}
@@ -0,0 +1,150 @@
+// Package requestctx holds the per-request values middleware sets and
+// handlers and services read: the authenticated user ID, request ID,
+// locale, tenant, and audit actor. Each has a typed setter and getter
+// backed by an unexported key, instead of ad-hoc string keys.
+//
+// Setters take the *gin.Context and store the value twice: in the gin
+// context, where ginx middleware and helpers look for the user and request
+// IDs, and in c.Request's context.Context, so services that only receive
+// ctx see it too. Getters accept either; given a *gin.Context they check
+// it first and then its request context. With nothing set they return the
+// zero value (and false).
+package requestctx
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+type (
+	userIDKey    struct{}
+	requestIDKey struct{}
+	localeKey    struct{}
+	tenantKey    struct{}
+	actorKey     struct{}
+)
+
+// SetUserID records the authenticated user of the request.
+func SetUserID(c *gin.Context, id string) {
+	ginx.SetUserID(c, id)
+	setRequestValue(c, userIDKey{}, id)
+}
+
+// UserID returns the user SetUserID (or ginx.Auth) recorded; ok is false
+// for anonymous requests.
+func UserID(ctx context.Context) (id string, ok bool) {
+	if c, isGin := ctx.(*gin.Context); isGin {
+		if id, ok := ginx.GetUserID(c); ok && id != "" {
+			return id, true
+		}
+	}
+	return stringValue(ctx, userIDKey{})
+}
+
+// SetRequestID records the ID of the request.
+func SetRequestID(c *gin.Context, id string) {
+	ginx.SetRequestID(c, id)
+	setRequestValue(c, requestIDKey{}, id)
+}
+
+// WithRequestID returns a copy of ctx carrying request ID id, for
+// ginx.ContextInjector, which sees only the context.Context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID SetRequestID or WithRequestID recorded.
+func RequestID(ctx context.Context) (id string, ok bool) {
+	if c, isGin := ctx.(*gin.Context); isGin {
+		if id, ok := ginx.GetRequestID(c); ok && id != "" {
+			return id, true
+		}
+	}
+	return stringValue(ctx, requestIDKey{})
+}
+
+// SetLocale records the locale selected for the request.
+func SetLocale(c *gin.Context, locale string) {
+	c.Set(localeKey{}, locale)
+	setRequestValue(c, localeKey{}, locale)
+}
+
+// Locale returns the locale SetLocale recorded, or "" when none was.
+func Locale(ctx context.Context) string {
+	locale, _ := stringValue(ctx, localeKey{})
+	return locale
+}
+
+// SetTenant records the tenant of the request. It is stored with
+// domain.WithTenant, where domain.TenantScoped and pkg.TenantScope look.
+func SetTenant(c *gin.Context, id string) {
+	c.Set(tenantKey{}, id)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(domain.WithTenant(c.Request.Context(), id))
+	}
+}
+
+// Tenant returns the tenant SetTenant recorded.
+func Tenant(ctx context.Context) (id string, ok bool) {
+	if c, isGin := ctx.(*gin.Context); isGin {
+		if v, ok := c.Get(tenantKey{}); ok {
+			id, ok := v.(string)
+			return id, ok
+		}
+		if c.Request == nil {
+			return "", false
+		}
+		ctx = c.Request.Context()
+	}
+	return domain.TenantFromContext(ctx)
+}
+
+// SetActor records who a change made by the request is attributed to in
+// audit logs, when that is not the authenticated user, e.g. an operator
+// command.
+func SetActor(c *gin.Context, actor string) {
+	c.Set(actorKey{}, actor)
+	setRequestValue(c, actorKey{}, actor)
+}
+
+// Actor returns who changes made by the request are attributed to: the
+// actor SetActor recorded, else the authenticated user, else "".
+func Actor(ctx context.Context) string {
+	if actor, ok := stringValue(ctx, actorKey{}); ok {
+		return actor
+	}
+	id, _ := UserID(ctx)
+	return id
+}
+
+// setRequestValue stores v under key in c.Request's context.
+func setRequestValue(c *gin.Context, key, v any) {
+	if c.Request == nil {
+		return
+	}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), key, v))
+}
+
+// stringValue looks key up in ctx, or for a *gin.Context in its keys and
+// then its request context.
+func stringValue(ctx context.Context, key any) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	if c, isGin := ctx.(*gin.Context); isGin {
+		if v, ok := c.Get(key); ok {
+			s, ok := v.(string)
+			return s, ok && s != ""
+		}
+		if c.Request == nil {
+			return "", false
+		}
+		ctx = c.Request.Context()
+	}
+	s, ok := ctx.Value(key).(string)
+	return s, ok && s != ""
+}
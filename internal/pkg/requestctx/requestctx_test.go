@@ -0,0 +1,123 @@
+package requestctx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/simp-lee/ginx"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// seen is what a service that only receives the request's context.Context
+// can read.
+type seen struct {
+	userID, requestID, locale, tenant, actor string
+}
+
+func service(ctx context.Context) seen {
+	var s seen
+	s.userID, _ = UserID(ctx)
+	s.requestID, _ = RequestID(ctx)
+	s.locale = Locale(ctx)
+	s.tenant, _ = Tenant(ctx)
+	s.actor = Actor(ctx)
+	return s
+}
+
+func TestPropagation_MiddlewareHandlerService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		SetRequestID(c, "req-1")
+		SetUserID(c, "42")
+		SetLocale(c, "en")
+		SetTenant(c, "acme")
+		c.Next()
+	})
+	var fromGin, fromCtx seen
+	var scoped string
+	r.GET("/", func(c *gin.Context) {
+		fromGin = service(c)
+		fromCtx = service(c.Request.Context())
+		scoped, _ = domain.TenantFromContext(c.Request.Context())
+		ginxID, _ := ginx.GetUserID(c)
+		if ginxID != "42" {
+			t.Errorf("ginx.GetUserID() = %q, want 42", ginxID)
+		}
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := seen{userID: "42", requestID: "req-1", locale: "en", tenant: "acme", actor: "42"}
+	if fromGin != want {
+		t.Errorf("from *gin.Context = %+v, want %+v", fromGin, want)
+	}
+	if fromCtx != want {
+		t.Errorf("from context.Context = %+v, want %+v", fromCtx, want)
+	}
+	if scoped != "acme" {
+		t.Errorf("domain.TenantFromContext() = %q, want acme", scoped)
+	}
+}
+
+func TestUserID_SetByGinx(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ginx.SetUserID(c, "7")
+	ginx.SetRequestID(c, "req-7")
+	if id, ok := UserID(c); !ok || id != "7" {
+		t.Errorf("UserID() = %q, %v; want 7 set by ginx", id, ok)
+	}
+	if id, ok := RequestID(c); !ok || id != "req-7" {
+		t.Errorf("RequestID() = %q, %v; want req-7 set by ginx", id, ok)
+	}
+}
+
+func TestActor_OverridesUser(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	SetUserID(c, "42")
+	SetActor(c, "control-socket")
+	if got := Actor(c); got != "control-socket" {
+		t.Errorf("Actor() = %q, want control-socket", got)
+	}
+	if got := Actor(c.Request.Context()); got != "control-socket" {
+		t.Errorf("Actor(request context) = %q, want control-socket", got)
+	}
+}
+
+func TestZeroValues(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, ctx := range map[string]context.Context{
+		"gin":        c,
+		"background": context.Background(),
+		"nil":        nil,
+	} {
+		if got := service(ctx); got != (seen{}) {
+			t.Errorf("%s: values = %+v, want all zero", name, got)
+		}
+		if _, ok := UserID(ctx); ok {
+			t.Errorf("%s: UserID() ok = true, want false", name)
+		}
+		if _, ok := RequestID(ctx); ok {
+			t.Errorf("%s: RequestID() ok = true, want false", name)
+		}
+		if _, ok := Tenant(ctx); ok {
+			t.Errorf("%s: Tenant() ok = true, want false", name)
+		}
+	}
+
+	// A gin context built without a request does not panic either.
+	bare := &gin.Context{}
+	SetUserID(bare, "1")
+	if id, _ := UserID(bare); id != "1" {
+		t.Errorf("UserID(no request) = %q, want 1", id)
+	}
+	if got := Locale(bare); got != "" {
+		t.Errorf("Locale(no request) = %q, want empty", got)
+	}
+}
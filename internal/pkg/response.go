@@ -30,7 +30,7 @@ type ValidationErrorResponse struct {
 
 // Success sends a 200 JSON response with the given data.
 func Success(c *gin.Context, data any) {
-	c.JSON(http.StatusOK, Response{
+	JSON(c, http.StatusOK, Response{
 		Code:    http.StatusOK,
 		Message: "success",
 		Data:    data,
@@ -38,7 +38,8 @@ func Success(c *gin.Context, data any) {
 }
 
 // Error sends a JSON error response. If err is a *domain.AppError, its code is
-// mapped to the appropriate HTTP status; otherwise 500 is returned.
+// mapped to the appropriate HTTP status; otherwise 500 is returned. Clients
+// that want problem+json (see WantsProblemJSON) get a Problem instead.
 func Error(c *gin.Context, err error) {
 	status := domain.HTTPStatusCode(err)
 
@@ -48,17 +49,13 @@ func Error(c *gin.Context, err error) {
 		msg = appErr.Message
 	}
 
-	c.JSON(status, Response{
-		Code:    status,
-		Message: msg,
-		Data:    nil,
-	})
+	JSONError(c, status, msg)
 }
 
 // List sends a 200 JSON response intended for paginated list results.
-// result should typically be a PageResult[T] containing items and pagination metadata.
+// result should typically be a *domain.PageResult[T] containing items and pagination metadata.
 func List(c *gin.Context, result any) {
-	c.JSON(http.StatusOK, Response{
+	JSON(c, http.StatusOK, Response{
 		Code:    http.StatusOK,
 		Message: "success",
 		Data:    result,
@@ -66,7 +63,8 @@ func List(c *gin.Context, result any) {
 }
 
 // ValidationError sends a 400 JSON response with per-field validation error details.
-// It detects validator.ValidationErrors and extracts field-level messages.
+// It detects validator.ValidationErrors and ParamErrors and extracts field-level messages.
+// For problem+json clients the field messages become the Problem's invalid-params.
 func ValidationError(c *gin.Context, err error) {
 	validationErrorWithType(c, err, nil)
 }
@@ -74,10 +72,18 @@ func ValidationError(c *gin.Context, err error) {
 // BindAndValidate binds the request body to obj and validates it.
 // On failure it automatically sends a ValidationError response and returns false.
 // Because obj is available, JSON struct tags are used for field names when possible.
+// JSON bodies may use camelCase or snake_case keys (see normalizeJSONBody); field
+// names in the error follow the negotiated naming (see WantsCamelJSON). A JSON
+// body larger, nested deeper or with more tokens than the request's
+// JSONLimits is rejected with a "body" error before it is decoded.
 // Usage in handlers:
 //
 //	if !pkg.BindAndValidate(c, &req) { return }
 func BindAndValidate(c *gin.Context, obj any) bool {
+	if err := normalizeJSONBody(c, obj); err != nil {
+		validationErrorWithType(c, ParamErrors{"body": err.Error()}, nil)
+		return false
+	}
 	if err := c.ShouldBind(obj); err != nil {
 		validationErrorWithType(c, err, obj)
 		return false
@@ -116,23 +122,36 @@ func friendlyMessage(fe validator.FieldError) string {
 }
 
 // validationErrorWithType sends a 400 validation error response.
-// When obj is non-nil, it reflects on the struct to prefer JSON tag names.
+// When obj is non-nil, it reflects on the struct to prefer JSON tag names, which
+// are converted to camelCase for WantsCamelJSON clients. ParamErrors name query,
+// path and header parameters, whose names are kept as given.
 func validationErrorWithType(c *gin.Context, err error, obj any) {
+	var pe ParamErrors
+	if errors.As(err, &pe) {
+		if WantsProblemJSON(c) {
+			writeValidationProblem(c, "validation error", pe)
+			return
+		}
+		c.JSON(http.StatusBadRequest, ValidationErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "validation error",
+			Errors:  pe,
+		})
+		return
+	}
+
 	var ve validator.ValidationErrors
 	if !errors.As(err, &ve) {
 		// Not a validation error; send a generic bad request.
 		slog.Warn("request validation failed", slog.Any("error", err))
-		c.JSON(http.StatusBadRequest, Response{
-			Code:    http.StatusBadRequest,
-			Message: "bad request",
-			Data:    nil,
-		})
+		JSONError(c, http.StatusBadRequest, "bad request")
 		return
 	}
 
 	// Build a struct-field → json-tag map when the concrete type is available.
 	jsonTags := buildJSONTagMap(obj)
 
+	camel := WantsCamelJSON(c)
 	fieldErrors := make(map[string]string, len(ve))
 	for _, fe := range ve {
 		name := fe.Field()
@@ -141,9 +160,16 @@ func validationErrorWithType(c *gin.Context, err error, obj any) {
 		} else {
 			name = strings.ToLower(name)
 		}
+		if camel {
+			name = CamelCase(name)
+		}
 		fieldErrors[name] = friendlyMessage(fe)
 	}
 
+	if WantsProblemJSON(c) {
+		writeValidationProblem(c, "validation error", fieldErrors)
+		return
+	}
 	c.JSON(http.StatusBadRequest, ValidationErrorResponse{
 		Code:    http.StatusBadRequest,
 		Message: "validation error",
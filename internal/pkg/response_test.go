@@ -297,6 +297,27 @@ func TestValidationError_NonValidationError(t *testing.T) {
 	}
 }
 
+func TestValidationError_ParamErrors(t *testing.T) {
+	c, w := newResponseTestContext()
+
+	ValidationError(c, ParamErrors{"page": "Must not be given multiple different values"})
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Message != "validation error" {
+		t.Errorf("expected message %q, got %q", "validation error", resp.Message)
+	}
+	if resp.Errors["page"] != "Must not be given multiple different values" {
+		t.Errorf("expected per-parameter message for page, got %v", resp.Errors)
+	}
+}
+
 func TestBindAndValidate_InvalidJSON(t *testing.T) {
 	c, w := newResponseTestContextWithBody(`{"invalid json`)
 
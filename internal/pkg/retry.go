@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRetryMaxBackoff caps the RetryTx backoff when WithMaxBackoff is not
+// given.
+const DefaultRetryMaxBackoff = 100 * time.Millisecond
+
+// retryBaseBackoff is the wait before the first retry; it doubles per attempt
+// up to the maximum.
+const retryBaseBackoff = 5 * time.Millisecond
+
+// SQLite result codes and PostgreSQL SQLSTATEs that RetryTx retries.
+const (
+	sqliteBusy   = 5
+	sqliteLocked = 6
+
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// RetryOption configures RetryTx.
+type RetryOption func(*retryOptions)
+
+type retryOptions struct {
+	maxBackoff time.Duration
+}
+
+// WithMaxBackoff caps the wait between attempts. Values <= 0 keep
+// DefaultRetryMaxBackoff.
+func WithMaxBackoff(d time.Duration) RetryOption {
+	return func(o *retryOptions) {
+		if d > 0 {
+			o.maxBackoff = d
+		}
+	}
+}
+
+// RetryTx runs fn in a transaction like WithTx, retrying the whole
+// transaction up to attempts times in total when it fails with a transient
+// lock or serialization error (see IsRetryableTxError). Other errors are
+// returned unchanged after the first attempt. When every attempt fails, the
+// last error is returned wrapped with the attempt count.
+//
+// fn may run more than once, so it must not have side effects outside tx.
+// attempts < 1 is treated as 1. Waiting between attempts stops early when
+// ctx is done.
+func RetryTx(ctx context.Context, db *gorm.DB, attempts int, fn func(tx *gorm.DB) error, opts ...RetryOption) error {
+	o := retryOptions{maxBackoff: DefaultRetryMaxBackoff}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	attempts = max(attempts, 1)
+
+	backoff := retryBaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := WithTx(db.WithContext(ctx), fn)
+		if err == nil || !IsRetryableTxError(err) {
+			return err
+		}
+		if attempt == attempts {
+			return fmt.Errorf("transaction failed after %d attempts: %w", attempt, err)
+		}
+
+		wait := jitter(min(backoff, o.maxBackoff))
+		backoff *= 2
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction failed after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// jitter returns a random duration in [d/2, d].
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// IsRetryableTxError reports whether err is a transient database error worth
+// retrying the transaction for: SQLITE_BUSY or SQLITE_LOCKED (including
+// their extended codes), or a PostgreSQL serialization failure (40001) or
+// deadlock (40P01). Driver errors are recognised by their Code and SQLState
+// methods, so this package does not depend on the drivers.
+func IsRetryableTxError(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		switch pgErr.SQLState() {
+		case pgSerializationFailure, pgDeadlockDetected:
+			return true
+		}
+		return false
+	}
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqliteBusy, sqliteLocked:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sqliteCodeError mimics the SQLite driver's error type.
+type sqliteCodeError struct{ code int }
+
+func (e *sqliteCodeError) Error() string { return fmt.Sprintf("sqlite error %d", e.code) }
+func (e *sqliteCodeError) Code() int     { return e.code }
+
+// pgStateError mimics pgconn.PgError.
+type pgStateError struct{ state string }
+
+func (e *pgStateError) Error() string    { return "pg error " + e.state }
+func (e *pgStateError) SQLState() string { return e.state }
+
+func TestRetryTx_RetriesUntilSuccess(t *testing.T) {
+	db := newTxTestDB(t)
+
+	calls := 0
+	err := RetryTx(context.Background(), db, 3, func(tx *gorm.DB) error {
+		calls++
+		if err := tx.Create(&testItem{Name: fmt.Sprintf("try-%d", calls)}).Error; err != nil {
+			return err
+		}
+		if calls < 3 {
+			return &sqliteCodeError{code: sqliteBusy}
+		}
+		return nil
+	}, WithMaxBackoff(time.Millisecond))
+	if err != nil {
+		t.Fatalf("RetryTx() error = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	// Failed attempts were rolled back; only the last insert is committed.
+	var items []testItem
+	db.Find(&items)
+	if len(items) != 1 || items[0].Name != "try-3" {
+		t.Errorf("items = %+v, want only try-3", items)
+	}
+}
+
+func TestRetryTx_NonRetryableErrorIsNotRetried(t *testing.T) {
+	db := newTxTestDB(t)
+
+	fnErr := errors.New("constraint failed")
+	calls := 0
+	err := RetryTx(context.Background(), db, 5, func(tx *gorm.DB) error {
+		calls++
+		return fnErr
+	})
+	if err != fnErr {
+		t.Fatalf("RetryTx() error = %v, want the original error unchanged", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryTx_GivesUpAfterAttempts(t *testing.T) {
+	db := newTxTestDB(t)
+
+	busy := &pgStateError{state: pgSerializationFailure}
+	calls := 0
+	err := RetryTx(context.Background(), db, 2, func(tx *gorm.DB) error {
+		calls++
+		return busy
+	}, WithMaxBackoff(time.Millisecond))
+	if !errors.Is(err, busy) {
+		t.Fatalf("RetryTx() error = %v, want it to wrap the original error", err)
+	}
+	if want := "transaction failed after 2 attempts: pg error 40001"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRetryTx_StopsWhenContextDone(t *testing.T) {
+	db := newTxTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := RetryTx(ctx, db, 10, func(tx *gorm.DB) error {
+		calls++
+		cancel()
+		return &sqliteCodeError{code: sqliteLocked}
+	}, WithMaxBackoff(time.Hour))
+	if err == nil || calls != 1 {
+		t.Fatalf("RetryTx() = %v after %d calls, want an error after 1 call", err, calls)
+	}
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"sqlite busy", &sqliteCodeError{code: 5}, true},
+		{"sqlite locked", &sqliteCodeError{code: 6}, true},
+		{"sqlite busy snapshot (extended)", &sqliteCodeError{code: 5 | 2<<8}, true},
+		{"sqlite constraint", &sqliteCodeError{code: 19}, false},
+		{"pg serialization failure", &pgStateError{state: "40001"}, true},
+		{"pg deadlock", &pgStateError{state: "40P01"}, true},
+		{"pg unique violation", &pgStateError{state: "23505"}, false},
+		{"wrapped", fmt.Errorf("insert: %w", &sqliteCodeError{code: 5}), true},
+		{"plain", errors.New("database is locked"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableTxError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableTxError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
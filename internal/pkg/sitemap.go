@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+// SitemapMaxURLs is the most URLs one sitemap document may list (sitemaps
+// protocol 0.9). Sources producing entries per record stop there.
+const SitemapMaxURLs = 50000
+
+// SitemapEntry is one page listed in /sitemap.xml.
+type SitemapEntry struct {
+	// Path is the page path, starting with "/"; the sitemap prefixes it
+	// with server.base_url.
+	Path string
+	// LastMod is when the page content last changed; zero omits it.
+	LastMod time.Time
+}
+
+// SitemapSource is what a module contributes to /sitemap.xml: fixed Paths,
+// listed without lastmod, and optionally Entries for pages that exist per
+// record, usually with LastMod taken from UpdatedAt.
+type SitemapSource struct {
+	Paths   []string
+	Entries func(ctx context.Context) ([]SitemapEntry, error)
+	// Permission, as "resource:action", is what a visitor needs to see
+	// the pages (as in middleware.NavItem). Crawlers are anonymous, so the
+	// source is skipped whenever RBAC is enabled.
+	Permission string
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"context"
+	"regexp"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// tenantColumn is the column domain.TenantScoped adds.
+const tenantColumn = "tenant_id"
+
+// TenantIDPattern is the form of a tenant ID: what middleware.Tenant
+// accepts and domain.TenantScoped's column holds.
+var TenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// TenantScope returns a GORM scope limiting the statement to the tenant of
+// ctx (domain.WithTenant). It only applies to models embedding
+// domain.TenantScoped and when ctx carries a tenant, so background jobs,
+// which run without one, see every tenant's rows. PaginateGORM applies it
+// itself; repositories add it to their other queries, updates and deletes:
+//
+//	db.WithContext(ctx).Scopes(pkg.TenantScope(ctx)).First(&user, id)
+//
+// A row of another tenant is then simply not found, so looking one up by ID
+// does not reveal that it exists.
+func TenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		id, ok := domain.TenantFromContext(ctx)
+		if !ok {
+			return db
+		}
+		model := db.Statement.Model
+		if model == nil {
+			model = db.Statement.Dest
+		}
+		if model == nil || db.Statement.Parse(model) != nil || db.Statement.Schema.LookUpField(tenantColumn) == nil {
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: tenantColumn}, Value: id})
+	}
+}
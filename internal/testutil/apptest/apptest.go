@@ -0,0 +1,61 @@
+// Package apptest builds fully wired *app.App instances for tests outside
+// package app (external _test packages, end-to-end suites). Like testutil it
+// imports "testing" and must only be imported from _test.go files.
+package apptest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/simp-lee/gobase/internal/app"
+	"github.com/simp-lee/gobase/internal/testutil"
+)
+
+// DefaultUserID is the token subject used by AuthenticatedRequest.
+const DefaultUserID uint = 1
+
+// NewTestApp returns an App built by app.New from testutil.NewTestConfig with
+// opts applied. Each call gets its own in-memory database (opts may still
+// override the path), the schema is migrated, and App.Close is registered on
+// t.Cleanup.
+func NewTestApp(t testing.TB, opts ...testutil.ConfigOption) *app.App {
+	t.Helper()
+	opts = append([]testutil.ConfigOption{testutil.WithSQLitePath(testutil.MemoryDSN(t))}, opts...)
+
+	a, err := app.New(testutil.NewTestConfig(opts...))
+	if err != nil {
+		t.Fatalf("app.New() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := a.Close(); err != nil {
+			t.Errorf("App.Close() error = %v", err)
+		}
+	})
+	testutil.Migrate(t, a.DB())
+	return a
+}
+
+// AuthenticatedRequest builds a JSON request (see testutil.NewJSONRequest)
+// carrying a bearer token minted by the app's own JWT service for
+// DefaultUserID. The app must have been built with testutil.WithAuth.
+func AuthenticatedRequest(t testing.TB, a *app.App, method, path string, body any) *http.Request {
+	t.Helper()
+	req := testutil.NewJSONRequest(t, method, path, body)
+	req.Header.Set("Authorization", "Bearer "+testutil.MintToken(t, a.JWTService(), DefaultUserID))
+	return req
+}
+
+// RenderPage serves req through a and fails t unless the response has status
+// want and an HTML body. Apps from NewTestApp render with strict templates,
+// so a page whose handler did not pass a key its template reads fails here
+// with the template and key path (see app.WithStrictData).
+func RenderPage(t testing.TB, a *app.App, req *http.Request, want int) *httptest.ResponseRecorder {
+	t.Helper()
+	w := testutil.Serve(a.Handler(), req)
+	if w.Code != want || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("%s %s = %d %s, want %d text/html: %s", req.Method, req.URL, w.Code, w.Header().Get("Content-Type"), want, w.Body.String())
+	}
+	return w
+}
@@ -0,0 +1,124 @@
+// Package testutil provides shared helpers for tests: a minimal valid Config,
+// isolated in-memory SQLite databases, user fixtures, JWT minting, JSON
+// request builders, and a fake HTTP server for App.Run.
+//
+// It imports "testing" and must only be imported from _test.go files. The
+// repository-level acceptance test TestTestutil_NotImportedByProductionCode
+// enforces this. Helpers that need a wired *app.App live in the apptest
+// subpackage so that tests inside package app can still use this package.
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+)
+
+// TestJWTSecret is the JWT secret used by WithAuth. It satisfies the config
+// length rule for non-release modes.
+const TestJWTSecret = "test-secret-key-must-be-at-least-32-chars-long!"
+
+// ConfigOption customizes the Config returned by NewTestConfig. Any
+// func(*config.Config) can be passed for one-off overrides.
+type ConfigOption func(*config.Config)
+
+// NewTestConfig returns a minimal Config that passes Validate: test mode,
+// strict templates, shared in-memory SQLite, and error-level text logging. Options are applied
+// in order.
+func NewTestConfig(opts ...ConfigOption) *config.Config {
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Host: "127.0.0.1",
+			Port: 8080,
+			Mode: gin.TestMode,
+			// Fail renders that read a data key the handler did not pass.
+			Templates: config.TemplatesConfig{Strict: true},
+		},
+		Database: config.DatabaseConfig{
+			Driver: "sqlite",
+			SQLite: config.SQLiteConfig{Path: "file::memory:?cache=shared"},
+		},
+		Log: config.LogConfig{
+			Level:  "error",
+			Format: "text",
+		},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithAuth enables JWT auth with TestJWTSecret, a one-hour token expiry, and
+// the required login/register public paths.
+func WithAuth() ConfigOption {
+	return func(cfg *config.Config) {
+		cfg.Auth.Enabled = true
+		cfg.Auth.JWTSecret = TestJWTSecret
+		cfg.Auth.TokenExpiry = config.Duration(time.Hour)
+		cfg.Auth.PublicPaths = []string{"/api/v1/auth/login", "/api/v1/auth/register"}
+	}
+}
+
+// WithRBAC enables auth (as WithAuth) plus RBAC with small cache settings.
+func WithRBAC() ConfigOption {
+	return func(cfg *config.Config) {
+		WithAuth()(cfg)
+		cfg.Auth.RBAC.Enabled = true
+		cfg.Auth.RBAC.Cache = config.RBACCacheConfig{
+			RoleTTL:              config.Duration(5 * time.Minute),
+			UserRoleTTL:          config.Duration(5 * time.Minute),
+			PermissionTTL:        config.Duration(5 * time.Minute),
+			MaxRoleEntries:       100,
+			MaxUserEntries:       100,
+			MaxPermissionEntries: 100,
+		}
+	}
+}
+
+// WithTenancy enables tenancy with the header resolver, so requests name
+// their tenant in config.DefaultTenantHeader.
+func WithTenancy() ConfigOption {
+	return func(cfg *config.Config) {
+		cfg.Tenancy = config.TenancyConfig{Enabled: true, Resolver: config.TenantResolverHeader}
+	}
+}
+
+// WithMode sets server.mode.
+func WithMode(mode string) ConfigOption {
+	return func(cfg *config.Config) {
+		cfg.Server.Mode = mode
+	}
+}
+
+// WithSQLitePath sets database.sqlite.path, e.g. to MemoryDSN(t) or a file
+// under t.TempDir().
+func WithSQLitePath(path string) ConfigOption {
+	return func(cfg *config.Config) {
+		cfg.Database.SQLite.Path = path
+	}
+}
+
+// WithTablePrefix sets database.table_prefix.
+func WithTablePrefix(prefix string) ConfigOption {
+	return func(cfg *config.Config) {
+		cfg.Database.TablePrefix = prefix
+	}
+}
+
+var memoryDBSeq atomic.Uint64
+
+// MemoryDSN returns a SQLite DSN for a named shared-cache in-memory database
+// unique to this call. Connections opened with the same DSN share data, but
+// no other test sees it, so tests never collide on unique columns.
+func MemoryDSN(t testing.TB) string {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_", "?", "_", "&", "_", "=", "_").Replace(t.Name())
+	return fmt.Sprintf("file:%s_%d?mode=memory&cache=shared", name, memoryDBSeq.Add(1))
+}
@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+// NewTestDB opens an isolated in-memory SQLite database with the application
+// schema migrated and foreign keys enforced, as config.SetupDatabase does.
+// It is closed when the test finishes.
+func NewTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	return OpenTestDB(t, MemoryDSN(t))
+}
+
+// OpenTestDB is NewTestDB for a caller-chosen DSN, typically a MemoryDSN that
+// is also passed to WithSQLitePath so an App built afterwards starts against
+// an already migrated and seeded database. The handle stays open until the
+// test finishes, which keeps a shared in-memory database alive.
+func OpenTestDB(t testing.TB, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(config.SQLiteDSN(dsn)), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	Migrate(t, db)
+	return db
+}
+
+// Migrate creates the application schema on db. app.New only migrates in
+// debug mode, so tests running in test mode call this themselves. Keep the
+// model list in sync with the modules' Models methods.
+func Migrate(t testing.TB, db *gorm.DB) {
+	t.Helper()
+	if err := db.AutoMigrate(&domain.User{}, &domain.Note{}, &domain.Notification{}, &domain.LoginAttempt{}, &domain.Invite{}, &domain.RateLimitOverride{}, &domain.Change{}, &domain.ErasureReceipt{}); err != nil {
+		t.Fatalf("auto migrate: %v", err)
+	}
+}
+
+// SeedUsers inserts users into db and returns them with IDs and timestamps
+// populated. Empty Name and Email fields are filled with unique defaults
+// ("User N", "userN@example.com") so callers only set what the test cares
+// about.
+func SeedUsers(t testing.TB, db *gorm.DB, users ...domain.User) []domain.User {
+	t.Helper()
+	seeded := make([]domain.User, len(users))
+	for i, u := range users {
+		if u.Name == "" {
+			u.Name = fmt.Sprintf("User %d", i+1)
+		}
+		if u.Email == "" {
+			u.Email = fmt.Sprintf("user%d@example.com", i+1)
+		}
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatalf("seed user %q: %v", u.Email, err)
+		}
+		seeded[i] = u
+	}
+	return seeded
+}
+
+// EmailOfLength returns a valid address of n characters, for tests at the
+// length limits. The local part is at most 64 characters and each domain
+// label at most 63, as the address syntax requires; n must be at least 16.
+func EmailOfLength(n int) string {
+	local := min(n-len("@example.com")-3, 64)
+	domain := "example.com"
+	for rest := n - local - 1 - len(domain); rest > 0; rest = n - local - 1 - len(domain) {
+		label := min(rest-1, 63)
+		if label < 1 {
+			local -= 1 - label
+			label = 1
+		}
+		domain = strings.Repeat("d", label) + "." + domain
+	}
+	return strings.Repeat("u", local) + "@" + domain
+}
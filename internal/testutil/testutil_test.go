@@ -0,0 +1,117 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simp-lee/gobase/internal/config"
+	"github.com/simp-lee/gobase/internal/domain"
+)
+
+func TestNewTestConfig_Validates(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ConfigOption
+	}{
+		{name: "minimal"},
+		{name: "auth", opts: []ConfigOption{WithAuth()}},
+		{name: "rbac", opts: []ConfigOption{WithRBAC()}},
+		{name: "debug mode", opts: []ConfigOption{WithMode(gin.DebugMode)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewTestConfig(tt.opts...).Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewTestConfig_AppliesOptionsInOrder(t *testing.T) {
+	cfg := NewTestConfig(WithSQLitePath("a.db"), func(c *config.Config) { c.Database.SQLite.Path = "b.db" })
+	if cfg.Database.SQLite.Path != "b.db" {
+		t.Errorf("SQLite.Path = %q, want %q", cfg.Database.SQLite.Path, "b.db")
+	}
+}
+
+func TestNewTestDB_IsolatedPerCall(t *testing.T) {
+	first := NewTestDB(t)
+	second := NewTestDB(t)
+	SeedUsers(t, first, domain.User{})
+
+	var count int64
+	if err := second.Model(&domain.User{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("second database sees %d users, want 0", count)
+	}
+}
+
+func TestSeedUsers_FillsDefaults(t *testing.T) {
+	db := NewTestDB(t)
+	users := SeedUsers(t, db, domain.User{}, domain.User{Name: "Bob", Email: "bob@example.com"})
+
+	if users[0].ID == 0 || users[0].Name != "User 1" || users[0].Email != "user1@example.com" {
+		t.Errorf("users[0] = %+v, want defaults with an ID", users[0])
+	}
+	if users[1].Name != "Bob" || users[1].Email != "bob@example.com" {
+		t.Errorf("users[1] = %+v, want explicit fields kept", users[1])
+	}
+}
+
+func TestEmailOfLength(t *testing.T) {
+	for _, n := range []int{16, 64, domain.EmailMaxLen, domain.EmailMaxLen + 1, 320} {
+		email := EmailOfLength(n)
+		if len(email) != n {
+			t.Errorf("EmailOfLength(%d) has %d characters", n, len(email))
+		}
+		if _, err := mail.ParseAddress(email); err != nil {
+			t.Errorf("EmailOfLength(%d) = %q: %v", n, email, err)
+		}
+	}
+}
+
+func TestNewJSONRequest_Bodies(t *testing.T) {
+	req := NewJSONRequest(t, http.MethodPost, "/x", map[string]string{"name": "a"})
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	buf := make([]byte, 64)
+	n, _ := req.Body.Read(buf)
+	if string(buf[:n]) != `{"name":"a"}` {
+		t.Errorf("body = %q", buf[:n])
+	}
+
+	if req := NewJSONRequest(t, http.MethodGet, "/x", nil); req.Header.Get("Content-Type") != "" {
+		t.Error("nil body should not set Content-Type")
+	}
+}
+
+func TestFakeHTTPServer(t *testing.T) {
+	listenErr := errors.New("boom")
+	if err := (&FakeHTTPServer{ServeErr: listenErr}).Serve(nil); !errors.Is(err, listenErr) {
+		t.Errorf("Serve() = %v, want %v", err, listenErr)
+	}
+
+	srv := NewFakeHTTPServer()
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(nil) }()
+	<-srv.Started()
+	if srv.ShutdownCalled() {
+		t.Fatal("ShutdownCalled() = true before Shutdown")
+	}
+	_ = srv.Shutdown(context.Background())
+	_ = srv.Shutdown(context.Background())
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Serve() = %v, want http.ErrServerClosed", err)
+	}
+	if !srv.ShutdownCalled() {
+		t.Error("ShutdownCalled() = false after Shutdown")
+	}
+}
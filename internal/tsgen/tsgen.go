@@ -0,0 +1,373 @@
+// Package tsgen renders the Go types of JSON request and response bodies
+// as TypeScript interface declarations, so a frontend can type the API
+// from the same structs the handlers bind and send.
+//
+// Types follow encoding/json: fields are named by their json tag and
+// skipped for "-"; omitempty and omitzero make a field optional; embedded
+// structs without a name are flattened into the outer interface. Numbers
+// are number, byte slices and types that marshal themselves (time.Time,
+// domain.Time) are string, pointers are nullable, maps are Record. A type
+// with IsPresent() bool and Value() (T, bool) methods, like pkg.Optional,
+// is an optional, nullable T.
+package tsgen
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is a Go type to declare as a TypeScript interface.
+type Type struct {
+	// Value is a value of the type, e.g. CreateUserRequest{}.
+	Value any
+	// Name is the interface name; the Go type name when empty.
+	Name string
+	// Generic declares the interface with a type parameter T, which every
+	// `any` in the type's fields stands for, e.g. the data of an envelope.
+	Generic bool
+}
+
+// Generate returns the declarations of types, and of every named struct
+// type they reference, sorted by name. The output depends only on the
+// types, so it can be committed and diffed.
+//
+// A generic Go type that is not listed in types is named after its type
+// and its type arguments, e.g. PaginationUser for
+// pagination.Pagination[domain.User].
+func Generate(types []Type) ([]byte, error) {
+	g := &generator{names: make(map[reflect.Type]string), decls: make(map[string]string), owners: make(map[string]reflect.Type)}
+	for _, t := range types {
+		rt := reflect.TypeOf(t.Value)
+		if rt == nil || rt.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("tsgen: %T is not a struct", t.Value)
+		}
+		name := t.Name
+		if name == "" {
+			name = typeName(rt)
+		}
+		if err := g.claim(name, rt); err != nil {
+			return nil, err
+		}
+		g.generic = t.Generic
+		err := g.declare(rt, name)
+		g.generic = false
+		if err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, 0, len(g.decls))
+	for name := range g.decls {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var buf bytes.Buffer
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(g.decls[name])
+	}
+	return buf.Bytes(), nil
+}
+
+type generator struct {
+	names   map[reflect.Type]string // declared struct types
+	decls   map[string]string       // interface name → declaration
+	owners  map[string]reflect.Type // interface name → its Go type
+	generic bool                    // `any` is T in the type being declared
+}
+
+// claim reserves name for t; two Go types may not share an interface.
+func (g *generator) claim(name string, t reflect.Type) error {
+	if prev, ok := g.owners[name]; ok && prev != t {
+		return fmt.Errorf("tsgen: %s and %s are both declared as %s", prev, t, name)
+	}
+	if prev, ok := g.names[t]; ok && prev != name {
+		return fmt.Errorf("tsgen: %s is declared as both %s and %s", t, prev, name)
+	}
+	g.owners[name] = t
+	g.names[t] = name
+	return nil
+}
+
+// declare adds the interface declaration of struct type t.
+func (g *generator) declare(t reflect.Type, name string) error {
+	if _, ok := g.decls[name]; ok {
+		return nil
+	}
+	g.decls[name] = "" // reserved, for types that reference themselves
+	body, err := g.object(t, "  ", "\n")
+	if err != nil {
+		return err
+	}
+	head := "export interface " + name
+	if g.generic {
+		head += "<T = unknown>"
+	}
+	g.decls[name] = head + " " + body + "\n"
+	return nil
+}
+
+// field is a member of a JSON object.
+type field struct {
+	name     string
+	ts       string
+	optional bool
+	depth    int // of embedding, for encoding/json's dominance rule
+}
+
+// object renders the fields of struct type t as an object type, each
+// field on its own line when sep is "\n".
+func (g *generator) object(t reflect.Type, indent, sep string) (string, error) {
+	var fields []field
+	if err := g.fields(t, 0, &fields); err != nil {
+		return "", err
+	}
+	if len(fields) == 0 {
+		return "{}", nil
+	}
+	var b strings.Builder
+	b.WriteString("{")
+	for _, f := range fields {
+		if sep == "\n" {
+			b.WriteString("\n" + indent)
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(propertyName(f.name))
+		if f.optional {
+			b.WriteString("?")
+		}
+		b.WriteString(": " + f.ts + ";")
+	}
+	if sep == "\n" {
+		b.WriteString("\n")
+	} else {
+		b.WriteString(" ")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// fields appends the JSON fields of struct type t to out, flattening
+// embedded structs. A field hides the fields of the same name embedded
+// deeper than it.
+func (g *generator) fields(t reflect.Type, depth int, out *[]field) error {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := sf.Type
+		if sf.Anonymous && name == "" {
+			et := ft
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct && !marshalsItself(et) {
+				if err := g.fields(et, depth+1, out); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		f := field{name: name, depth: depth}
+		for opt := range strings.SplitSeq(opts, ",") {
+			switch opt {
+			case "omitempty", "omitzero":
+				f.optional = true
+			case "string":
+				if isQuotable(ft) {
+					ft = reflect.TypeFor[string]()
+				}
+			}
+		}
+		if elem, ok := optionalElem(ft); ok {
+			ts, err := g.expr(elem)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+			}
+			f.ts, f.optional = nullable(ts), true
+		} else {
+			ts, err := g.expr(ft)
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+			}
+			f.ts = ts
+		}
+		if i := slices.IndexFunc(*out, func(o field) bool { return o.name == f.name }); i >= 0 {
+			if f.depth >= (*out)[i].depth {
+				continue
+			}
+			*out = slices.Delete(*out, i, i+1)
+		}
+		*out = append(*out, f)
+	}
+	return nil
+}
+
+// expr renders t as a TypeScript type expression, declaring the named
+// struct types it references.
+func (g *generator) expr(t reflect.Type) (string, error) {
+	if elem, ok := optionalElem(t); ok {
+		ts, err := g.expr(elem)
+		return nullable(ts), err
+	}
+	if marshalsItself(t) {
+		return "string", nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "string", nil // base64
+		}
+		ts, err := g.expr(t.Elem())
+		if strings.Contains(ts, " | ") {
+			ts = "(" + ts + ")"
+		}
+		return ts + "[]", err
+	case reflect.Map:
+		ts, err := g.expr(t.Elem())
+		return "Record<string, " + ts + ">", err
+	case reflect.Pointer:
+		ts, err := g.expr(t.Elem())
+		return nullable(ts), err
+	case reflect.Interface:
+		if g.generic && t.NumMethod() == 0 {
+			return "T", nil
+		}
+		return "unknown", nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, "", " ")
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = typeName(t)
+			if err := g.claim(name, t); err != nil {
+				return "", err
+			}
+		}
+		generic := g.generic
+		g.generic = false
+		err := g.declare(t, name)
+		g.generic = generic
+		return name, err
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// nullable adds null to the TypeScript type ts.
+func nullable(ts string) string {
+	if strings.HasSuffix(ts, " | null") {
+		return ts
+	}
+	return ts + " | null"
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// marshalsItself reports whether t has its own JSON encoding, which is
+// taken to be a string.
+func marshalsItself(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	if t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer {
+		return false // a pointer is its element or null
+	}
+	for _, m := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalElem returns T when t has IsPresent() bool and
+// Value() (T, bool) methods.
+func optionalElem(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface {
+		return nil, false
+	}
+	present, ok := t.MethodByName("IsPresent")
+	if !ok || present.Type.NumIn() != 1 || present.Type.NumOut() != 1 || present.Type.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	value, ok := t.MethodByName("Value")
+	if !ok || value.Type.NumIn() != 1 || value.Type.NumOut() != 2 || value.Type.Out(1).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return value.Type.Out(0), true
+}
+
+// isQuotable reports whether the json ",string" option applies to t.
+func isQuotable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// qualifiedIdent matches the package-qualified type names in the type
+// arguments of a generic type's name.
+var qualifiedIdent = regexp.MustCompile(`[\w./-]*\.`)
+
+// typeName is the interface name of named struct type t: its Go name, with
+// the names of its type arguments appended for a generic type.
+func typeName(t reflect.Type) string {
+	base, args, ok := strings.Cut(t.Name(), "[")
+	if !ok {
+		return base
+	}
+	args = qualifiedIdent.ReplaceAllString(args, "")
+	var b strings.Builder
+	b.WriteString(base)
+	for _, word := range strings.FieldsFunc(args, func(r rune) bool {
+		return !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// identifier matches the property names that need no quotes.
+var identifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+func propertyName(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
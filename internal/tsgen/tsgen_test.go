@@ -0,0 +1,122 @@
+package tsgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type stamp struct{ time.Time }
+
+func (s stamp) MarshalJSON() ([]byte, error) { return s.Time.MarshalJSON() }
+
+type maybe[T any] struct {
+	value T
+	ok    bool
+}
+
+func (m maybe[T]) IsPresent() bool  { return m.ok }
+func (m maybe[T]) Value() (T, bool) { return m.value, m.ok }
+
+type base struct {
+	ID        uint   `json:"id"`
+	CreatedAt stamp  `json:"created_at"`
+	Name      string `json:"name"`
+	Kind      string
+}
+
+type child struct {
+	Note string `json:"note"`
+}
+
+type page[T any] struct {
+	Items []T  `json:"items"`
+	Next  *int `json:"next"`
+}
+
+type sample struct {
+	base
+	Name     string            `json:"name"`
+	Hidden   string            `json:"-"`
+	internal string            //nolint:unused
+	Email    string            `json:"email,omitempty"`
+	Count    int64             `json:"count,string"`
+	At       time.Time         `json:"at"`
+	Raw      []byte            `json:"raw"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Child    *child            `json:"child"`
+	Children []*child          `json:"children"`
+	Page     page[child]       `json:"page"`
+	Bio      maybe[string]     `json:"bio"`
+	Extra    any               `json:"extra"`
+	Inline   struct {
+		On bool `json:"on"`
+	} `json:"inline"`
+	Header string `json:"x-header"`
+}
+
+type envelope struct {
+	Code int `json:"code"`
+	Data any `json:"data"`
+}
+
+func TestGenerate(t *testing.T) {
+	out, err := Generate([]Type{
+		{Value: sample{}},
+		{Value: envelope{}, Name: "Envelope", Generic: true},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	got := string(out)
+	for _, want := range []string{
+		"export interface Envelope<T = unknown> {\n  code: number;\n  data: T;\n}\n",
+		"export interface child {\n  note: string;\n}\n",
+		"export interface pageChild {\n  items: child[];\n  next: number | null;\n}\n",
+		"export interface sample {\n  id: number;\n  created_at: string;\n  Kind: string;\n  name: string;\n",
+		"  email?: string;\n",
+		"  count: string;\n",
+		"  at: string;\n",
+		"  raw: string;\n",
+		"  tags: string[];\n",
+		"  labels: Record<string, string>;\n",
+		"  child: child | null;\n",
+		"  children: (child | null)[];\n",
+		"  page: pageChild;\n",
+		"  bio?: string | null;\n",
+		"  extra: unknown;\n",
+		"  inline: { on: boolean; };\n",
+		`  "x-header": string;` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"Hidden", "internal", "Name:"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, got)
+		}
+	}
+	if strings.Index(got, "interface Envelope") > strings.Index(got, "interface child") {
+		t.Errorf("declarations are not sorted by name:\n%s", got)
+	}
+
+	again, err := Generate([]Type{
+		{Value: sample{}},
+		{Value: envelope{}, Name: "Envelope", Generic: true},
+	})
+	if err != nil || string(again) != got {
+		t.Errorf("second Generate() differs (err %v)", err)
+	}
+}
+
+func TestGenerate_NameClash(t *testing.T) {
+	_, err := Generate([]Type{{Value: child{}, Name: "Thing"}, {Value: envelope{}, Name: "Thing"}})
+	if err == nil || !strings.Contains(err.Error(), "both declared as Thing") {
+		t.Errorf("Generate() error = %v, want a name clash", err)
+	}
+	if _, err := Generate([]Type{{Value: "x"}}); err == nil {
+		t.Error("Generate() of a string succeeded, want an error")
+	}
+}
@@ -19,12 +19,22 @@ function toastManager() {
                 id: Date.now(),
                 message: detail.message || 'Operation completed',
                 type: detail.type || 'info',
+                // Optional { label, url }: a button that POSTs to url via htmx.
+                action: detail.action || null,
                 visible: true
             };
             this.toasts.push(toast);
             setTimeout(() => {
                 this.removeToast(toast.id);
-            }, 3000);
+            }, detail.duration || 3000);
+        },
+        runAction(toast) {
+            // #modal-root carries the list page's hx-headers (CSRF token).
+            htmx.ajax('POST', toast.action.url, {
+                source: document.getElementById('modal-root') || document.body,
+                swap: 'none'
+            });
+            this.removeToast(toast.id);
         },
         removeToast(id) {
             const toast = this.toasts.find(t => t.id === id);